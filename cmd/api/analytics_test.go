@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAnalytics checks the figures of a small ledger spread over this month
+// and the last. Dates are relative because trends read history back from
+// today.
+func TestAnalytics(t *testing.T) {
+	api := newTestAPI(t)
+	token := api.register("ada@example.com", "Ada", "Lovelace")
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+
+	checking := api.create("/api/v1/accounts", token, gin.H{"name": "Checking", "type": "checking", "balance": 1000, "currency": "USD"})
+	visa := api.create("/api/v1/accounts", token, gin.H{"name": "Visa", "type": "credit_card", "balance": 0, "currency": "USD", "credit_limit": 2000})
+
+	salary := api.create("/api/v1/categories", token, gin.H{"name": "Salary", "type": "income"})
+	rent := api.create("/api/v1/categories", token, gin.H{"name": "Rent", "type": "expense"})
+	groceries := api.create("/api/v1/categories", token, gin.H{"name": "Groceries", "type": "expense"})
+	dining := api.create("/api/v1/categories", token, gin.H{"name": "Dining", "type": "expense"})
+
+	ledger := []struct {
+		account, category int
+		amount            float64
+		kind              string
+		date              time.Time
+	}{
+		{checking, rent, 1200, "expense", lastMonth},
+		{checking, groceries, 100, "expense", lastMonth},
+		{checking, salary, 3000, "income", thisMonth},
+		{checking, rent, 1200, "expense", thisMonth},
+		{checking, groceries, 300, "expense", thisMonth},
+		{visa, groceries, 200, "expense", thisMonth},
+		{visa, dining, 300, "expense", thisMonth},
+	}
+	for _, entry := range ledger {
+		api.create("/api/v1/transactions", token, gin.H{
+			"account_id": entry.account, "category_id": entry.category,
+			"amount": entry.amount, "type": entry.kind, "date": entry.date,
+		})
+	}
+
+	month := url.Values{
+		"start_date": {thisMonth.Format("2006-01-02")},
+		"end_date":   {thisMonth.AddDate(0, 1, -1).Format("2006-01-02")},
+	}.Encode()
+	assertGolden(t, "analytics_summary", api.request(http.MethodGet, "/api/v1/analytics/summary?"+month, token, nil))
+	assertGolden(t, "analytics_spending", api.request(http.MethodGet, "/api/v1/analytics/spending?"+month, token, nil))
+	assertGolden(t, "analytics_trends", api.request(http.MethodGet, "/api/v1/analytics/trends?period=month", token, nil), "date")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthFlow(t *testing.T) {
+	api := newTestAPI(t)
+	credentials := gin.H{"email": "ada@example.com", "password": "correct horse battery staple"}
+
+	rec := api.request(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"email":      "ada@example.com",
+		"password":   "correct horse battery staple",
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+	})
+	assertGolden(t, "auth_register", rec)
+
+	rec = api.request(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"email":    "ada@example.com",
+		"password": "wrong horse battery staple",
+	})
+	assertGolden(t, "auth_login_wrong_password", rec)
+
+	rec = api.request(http.MethodPost, "/api/v1/auth/login", "", credentials)
+	assertGolden(t, "auth_login", rec)
+	var session struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	api.decode(rec, http.StatusOK, &session)
+
+	assertGolden(t, "auth_profile", api.request(http.MethodGet, "/api/v1/profile", session.Token, nil))
+
+	var refreshed struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	rec = api.request(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refresh_token": session.RefreshToken})
+	api.decode(rec, http.StatusOK, &refreshed)
+	if refreshed.RefreshToken == session.RefreshToken {
+		t.Error("refresh returned the refresh token it was given")
+	}
+	rec = api.request(http.MethodPost, "/api/v1/auth/refresh", "", gin.H{"refresh_token": session.RefreshToken})
+	assertGolden(t, "auth_refresh_reused", rec)
+
+	assertGolden(t, "auth_logout", api.request(http.MethodPost, "/api/v1/auth/logout", refreshed.Token, nil))
+	assertGolden(t, "auth_revoked", api.request(http.MethodGet, "/api/v1/profile", refreshed.Token, nil))
+	assertGolden(t, "auth_invalid_token", api.request(http.MethodGet, "/api/v1/profile", "not-a-token", nil))
+}
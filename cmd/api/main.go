@@ -1,11 +1,29 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"net/http"
 	"os"
 
+	"personal-finance-tracker/internal/assets"
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/bots/telegram"
+	"personal-finance-tracker/internal/config"
 	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/errorreport"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/grpcapi"
 	"personal-finance-tracker/internal/handlers"
+	"personal-finance-tracker/internal/leader"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/secrets"
+	"personal-finance-tracker/internal/service"
+	"personal-finance-tracker/internal/templates"
+	"personal-finance-tracker/internal/tracing"
+	"personal-finance-tracker/migrations"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -16,64 +34,448 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	config.Set(cfg)
+	if cfg.Server.LoadTestMode {
+		log.Println("WARNING: load test mode is on, rate limiting is disabled")
+	}
+	if err := tracing.Setup(cfg.Tracing); err != nil {
+		log.Fatal("Failed to configure tracing: ", err)
+	}
+	if tracing.Enabled() {
+		log.Printf("Exporting traces to %s", cfg.Tracing.Endpoint)
+	}
+
+	reporter, err := errorreport.NewFromConfig(cfg.Sentry)
+	if err != nil {
+		log.Fatal("Failed to configure error reporting: ", err)
+	}
+	errorreport.Use(reporter)
+
 	db, err := database.Initialize()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer db.Close()
 
-	router := gin.Default()
+	if cfg.Database.AutoMigrate {
+		applied, err := database.Migrate(db, assets.Overlay(migrations.Files, cfg.Assets.Path("migrations")))
+		if err != nil {
+			log.Fatal("Failed to migrate database: ", err)
+		}
+		for _, name := range applied {
+			log.Printf("Applied migration %s", name)
+		}
+	}
+	templates.UseOverrides(cfg.Assets.Path("templates"))
 
-	h := handlers.NewHandler(db)
+	cipher, err := secrets.NewFromConfig(cfg.Encryption)
+	if err != nil {
+		log.Fatal("Failed to load encryption keys:", err)
+	}
+	secrets.Use(cipher)
 
-	setupRoutes(router, h)
+	keyManager, err := auth.NewKeyManager(db)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+	auth.UseKeyManager(keyManager)
+	go keyManager.RunRotation(context.Background())
+
+	router := gin.New()
+	router.Use(gin.Logger())
+
+	notifier := notifications.NewDispatcher()
+	svc := service.New(db, notifier, events.NewBroker())
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if replica, err := database.InitializeReplica(); err != nil {
+		log.Printf("Read replica disabled: %v", err)
+	} else if replica != nil {
+		defer replica.Close()
+		svc.UseReadReplica(replica)
+		log.Println("Routing analytics queries to the read replica")
 	}
 
-	log.Printf("Starting server on port %s", port)
-	log.Fatal(router.Run(":" + port))
+	notifier.Register(notifications.NewWebhookChannel(db, svc.Jobs()))
+	if push, err := notifications.NewPushChannel(db); err != nil {
+		log.Printf("Web push disabled: %v", err)
+	} else {
+		notifier.Register(push)
+	}
+
+	if token := cfg.Telegram.BotToken; token != "" {
+		bot := telegram.NewBot(token, db, svc)
+		notifier.Register(bot)
+		go bot.Run(context.Background())
+	}
+
+	if cipher.Enabled() {
+		go func() {
+			updated, err := svc.ReencryptSecrets(context.Background())
+			if err != nil {
+				log.Printf("Error re-encrypting secrets: %v", err)
+			} else if updated > 0 {
+				log.Printf("Re-encrypted %d secrets with the active master key", updated)
+			}
+		}()
+	}
+
+	if publisher, err := events.NewPublisherFromConfig(cfg.Events); err != nil {
+		log.Printf("Event publishing disabled: %v", err)
+	} else if publisher != nil {
+		svc.Outbox().Handle(cfg.Events.Broker, events.Forward(publisher, cfg.Events.TopicPrefix))
+		log.Printf("Publishing domain events to %s", cfg.Events.Broker)
+	}
+
+	go svc.Jobs().Run(context.Background())
+	go svc.Outbox().Run(context.Background())
+	go svc.Fanout().Run(context.Background(), database.DSN())
+
+	// Schedulers run on one instance at a time. The job queue and outbox
+	// above run on all of them, each job or event being claimed by one
+	// instance; the events it streams reach the subscribers of every
+	// instance through the fan-out.
+	schedulers := leader.New(db)
+	schedulers.Go("payment reminders", svc.RunPaymentReminders)
+	schedulers.Go("demo cleanup", svc.RunDemoCleanup)
+	schedulers.Go("scheduled backups", svc.RunScheduledBackups)
+	schedulers.Go("allowances", svc.RunAllowances)
+	schedulers.Go("report schedules", svc.RunReportSchedules)
+	schedulers.Go("emergency fund checks", svc.RunEmergencyFundChecks)
+	schedulers.Go("insights", svc.RunInsights)
+	schedulers.Go("billing grace periods", svc.RunBillingGracePeriods)
+	schedulers.Go("login attempt pruning", svc.RunLoginAttemptPruning)
+	go schedulers.Run(context.Background())
+
+	h := handlers.NewHandler(db, svc)
+
+	tlsConfig, certManager, err := serverTLS(cfg.TLS)
+	if err != nil {
+		log.Fatal("Failed to load TLS certificate: ", err)
+	}
+
+	grpcPort := cfg.Server.GRPCPort
+	go func() {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Printf("gRPC server disabled: %v", err)
+			return
+		}
+		log.Printf("Starting gRPC server on port %s", grpcPort)
+		if err := grpcapi.NewServer(svc, tlsConfig).Serve(listener); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	setupRoutes(router, h)
+
+	log.Fatal(serve(router, cfg, tlsConfig, certManager))
 }
 
 func setupRoutes(router *gin.Engine, h *handlers.Handler) {
+	router.Use(h.Tracing(), h.TraceUser(), h.RequestID(), h.Compress(), h.Recovery(), h.LogBodies(), h.SecurityHeaders(), h.CORSMiddleware(), h.Localize(), h.CSRFMiddleware())
+
 	router.GET("/", h.RootHandler)
 	router.GET("/health", h.HealthCheck)
+	router.GET("/health/live", h.HealthCheck)
+	router.GET("/health/ready", h.ReadinessCheck)
+
+	router.GET("/.well-known/jwks.json", h.JWKS)
+
+	router.GET("/app", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, "/app/") })
+	router.GET("/app/*filepath", h.WebApp())
 
-	api := router.Group("/api/v1")
+	api := router.Group("/api/v1", h.APIVersion(1))
 
 	api.GET("/health", h.HealthCheck)
-	auth := api.Group("/auth")
+	api.GET("/health/live", h.HealthCheck)
+	api.GET("/health/ready", h.ReadinessCheck)
+	auth := api.Group("/auth", h.RequestTimeout(), h.RateLimit("auth", models.RateLimitSettings.AuthRequests))
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.GET("/oauth/providers", h.GetOAuthProviders)
+		auth.GET("/oauth/:provider", h.StartOAuth)
+		auth.GET("/oauth/:provider/callback", h.OAuthCallback)
+		auth.POST("/oauth/:provider/callback", h.OAuthCallback)
+		auth.POST("/oauth/:provider/token", h.ExchangeIDToken)
+		auth.POST("/refresh", h.RefreshToken)
+		auth.GET("/unlock/:token", h.UnlockAccount)
+		auth.GET("/confirm-email/:token", h.ConfirmEmailChange)
+		auth.POST("/password-strength", h.CheckPasswordStrength)
+		auth.POST("/demo", h.CreateDemoUser)
+	}
+
+	api.GET("/meta/icons", h.GetIconCatalog)
+	api.GET("/meta/colors", h.GetColorCatalog)
+
+	api.GET("/widgets/feed/:token", h.RequestTimeout(), h.GetWidgetFeed)
+	api.POST("/ingestion/email/:token", h.SkipBodyLogging(), h.RequestTimeout(), h.IngestEmail)
+	api.POST("/billing/webhook", h.SkipBodyLogging(), h.RequestTimeout(), h.StripeWebhook)
+
+	api.GET("/stream", h.SkipBodyLogging(), h.QueryTokenAuth(), h.AuthMiddleware(), h.StreamEvents)
+
+	admin := api.Group("/admin", h.AuthMiddleware(), h.AdminMiddleware(), h.RateLimit("api", models.RateLimitSettings.APIRequests))
+	{
+		admin.GET("/backups", h.ListBackups)
+		admin.POST("/backups", h.CreateBackup)
+		admin.POST("/backups/:name/restore", h.RestoreBackup)
+		admin.GET("/config", h.GetConfig)
+		admin.GET("/plans", h.GetPlans)
+		admin.PUT("/plans/:id", h.SavePlan)
+		admin.DELETE("/plans/:id", h.DeletePlan)
+		admin.GET("/users/:id/limits", h.GetUserLimits)
+		admin.PUT("/users/:id/plan", h.SetUserPlan)
 	}
 
 	protected := api.Group("/")
-	protected.Use(h.AuthMiddleware())
+	protected.Use(h.RequestTimeout(), h.AuthMiddleware(), h.WorkspaceContext(), h.RateLimit("api", models.RateLimitSettings.APIRequests), h.Idempotency(), h.InvalidateOnWrite())
 	{
 		protected.GET("/profile", h.GetProfile)
 		protected.PUT("/profile", h.UpdateProfile)
+		protected.PUT("/profile/avatar", h.UploadAvatar)
+		protected.DELETE("/profile/avatar", h.DeleteAvatar)
+		protected.GET("/profile/avatar/:size", h.GetAvatar)
+		protected.GET("/settings", h.GetSettings)
+		protected.PUT("/settings", h.UpdateSettings)
 
-		protected.GET("/accounts", h.GetAccounts)
+		protected.GET("/accounts", h.ETag(), h.GetAccounts)
 		protected.POST("/accounts", h.CreateAccount)
 		protected.PUT("/accounts/:id", h.UpdateAccount)
 		protected.DELETE("/accounts/:id", h.DeleteAccount)
+		protected.POST("/accounts/:id/archive", h.ArchiveAccount)
+		protected.POST("/accounts/:id/unarchive", h.UnarchiveAccount)
+		protected.GET("/accounts/:id/statement", h.GetCardStatement)
+		protected.GET("/accounts/:id/balance-history", h.GetBalanceHistory)
+		protected.PUT("/accounts/:id/opening-balance", h.SetOpeningBalance)
+		protected.POST("/accounts/:id/reconcile", h.ReconcileAccount)
 
-		protected.GET("/categories", h.GetCategories)
+		protected.GET("/categories", h.ETag(), h.GetCategories)
 		protected.POST("/categories", h.CreateCategory)
 		protected.PUT("/categories/:id", h.UpdateCategory)
 		protected.DELETE("/categories/:id", h.DeleteCategory)
+		protected.POST("/categories/:id/merge", h.MergeCategory)
+		protected.GET("/category-groups", h.ETag(), h.GetCategoryGroups)
+		protected.POST("/category-groups", h.CreateCategoryGroup)
+		protected.PUT("/category-groups/:id", h.UpdateCategoryGroup)
+		protected.DELETE("/category-groups/:id", h.DeleteCategoryGroup)
 
 		protected.GET("/transactions", h.GetTransactions)
 		protected.POST("/transactions", h.CreateTransaction)
 		protected.PUT("/transactions/:id", h.UpdateTransaction)
 		protected.DELETE("/transactions/:id", h.DeleteTransaction)
+		protected.PUT("/transactions/:id/tax", h.SetTransactionTax)
+		protected.GET("/transactions/:id/vat", h.GetVATBreakdown)
+		protected.PUT("/transactions/:id/vat", h.SetVATBreakdown)
+		protected.DELETE("/transactions/:id/vat", h.DeleteVATBreakdown)
+		protected.GET("/transactions/:id/splits", h.GetTransactionSplit)
+		protected.PUT("/transactions/:id/splits", h.SetTransactionSplit)
 		protected.POST("/transactions/bulk", h.BulkCreateTransactions)
+		protected.POST("/transactions/suggest-category", h.SuggestCategory)
+		protected.POST("/transactions/recategorize", h.RecategorizeTransactions)
+		protected.GET("/transactions/upcoming", h.GetUpcomingTransactions)
+
+		protected.GET("/budgets", h.GetBudgets)
+		protected.POST("/budgets", h.CreateBudget)
+		protected.GET("/budgets/status", h.ETag(), h.GetBudgetStatus)
+		protected.PUT("/budgets/:id", h.UpdateBudget)
+		protected.DELETE("/budgets/:id", h.DeleteBudget)
+
+		protected.GET("/recurring", h.GetRecurringRules)
+		protected.POST("/recurring", h.CreateRecurringRule)
+		protected.PUT("/recurring/:id", h.UpdateRecurringRule)
+		protected.DELETE("/recurring/:id", h.DeleteRecurringRule)
+
+		protected.GET("/audit-log", h.GetAuditLog)
+
+		protected.GET("/payees", h.ETag(), h.GetPayees)
+		protected.POST("/payees", h.CreatePayee)
+		protected.GET("/payees/limits", h.ETag(), h.GetPayeeLimits)
+		protected.PUT("/payees/:id", h.UpdatePayee)
+		protected.DELETE("/payees/:id", h.DeletePayee)
+
+		protected.GET("/household", h.GetHousehold)
+		protected.PUT("/household", h.UpdateHousehold)
+		protected.POST("/household/members", h.AddHouseholdMember)
+		protected.PUT("/household/members/:id", h.UpdateHouseholdMember)
+		protected.DELETE("/household/members/:id", h.RemoveHouseholdMember)
+		protected.POST("/household/profiles", h.CreateManagedProfile)
+		protected.GET("/household/transactions", h.GetHouseholdTransactions)
+		protected.POST("/household/transactions", h.CreateHouseholdTransaction)
+		protected.GET("/household/approvals", h.GetTransactionApprovals)
+		protected.POST("/household/approvals/:id/approve", h.ApproveTransaction)
+		protected.POST("/household/approvals/:id/reject", h.RejectTransaction)
+		protected.GET("/household/allowances", h.GetAllowanceRules)
+		protected.POST("/household/allowances", h.CreateAllowanceRule)
+		protected.DELETE("/household/allowances/:id", h.DeleteAllowanceRule)
+		protected.GET("/advisors", h.GetAdvisorGrants)
+		protected.POST("/advisors", h.GrantAdvisorAccess)
+		protected.DELETE("/advisors/:id", h.RevokeAdvisorAccess)
+		protected.GET("/advisor/clients", h.GetAdvisorClients)
+		protected.POST("/advisor/clients/:id/token", h.IssueDelegatedToken)
+		protected.GET("/workspaces", h.GetWorkspaces)
+		protected.POST("/workspaces", h.CreateWorkspace)
+		protected.GET("/workspaces/:id", h.GetWorkspace)
+		protected.PUT("/workspaces/:id", h.UpdateWorkspace)
+		protected.DELETE("/workspaces/:id", h.DeleteWorkspace)
+		protected.POST("/workspaces/:id/members", h.AddWorkspaceMember)
+		protected.PUT("/workspaces/:id/members/:user_id", h.UpdateWorkspaceMember)
+		protected.DELETE("/workspaces/:id/members/:user_id", h.RemoveWorkspaceMember)
+		protected.GET("/limits", h.GetLimits)
+		protected.GET("/billing", h.GetBilling)
+		protected.POST("/billing/checkout", h.StartCheckout)
+		protected.POST("/billing/portal", h.OpenBillingPortal)
+
+		protected.GET("/dashboard", h.ETag(), h.GetDashboard)
+		protected.PUT("/dashboard", h.UpdateDashboard)
+		protected.GET("/dashboard/data", h.ETag(), h.CacheResponse(), h.GetDashboardData)
+
+		protected.GET("/analytics/summary", h.ETag(), h.CacheResponse(), h.GetAnalyticsSummary)
+		protected.GET("/analytics/spending", h.ETag(), h.CacheResponse(), h.GetSpendingAnalytics)
+		protected.GET("/analytics/spending/groups", h.ETag(), h.CacheResponse(), h.GetGroupSpending)
+		protected.POST("/analytics/batch", h.BatchAnalytics)
+		protected.GET("/reports", h.ETag(), h.CacheResponse(), h.GetReport)
+		protected.GET("/reports/schedules", h.GetReportSchedules)
+		protected.POST("/reports/schedules", h.CreateReportSchedule)
+		protected.GET("/reports/schedules/:id", h.GetReportSchedule)
+		protected.PUT("/reports/schedules/:id", h.UpdateReportSchedule)
+		protected.DELETE("/reports/schedules/:id", h.DeleteReportSchedule)
+		protected.POST("/reports/schedules/:id/send", h.SendReportSchedule)
+		protected.GET("/automations", h.GetAutomationRules)
+		protected.POST("/automations", h.CreateAutomationRule)
+		protected.POST("/automations/simulate", h.SimulateAutomation)
+		protected.GET("/automations/:id", h.GetAutomationRule)
+		protected.PUT("/automations/:id", h.UpdateAutomationRule)
+		protected.DELETE("/automations/:id", h.DeleteAutomationRule)
+		protected.GET("/analytics/trends", h.ETag(), h.CacheResponse(), h.GetSpendingTrends)
+		protected.GET("/analytics/periods", h.ETag(), h.CacheResponse(), h.GetPeriodSummaries)
+		protected.GET("/analytics/year-review", h.ETag(), h.CacheResponse(), h.GetYearReview)
+		protected.GET("/analytics/fees", h.ETag(), h.CacheResponse(), h.GetBankFees)
+		protected.GET("/analytics/locations", h.ETag(), h.CacheResponse(), h.GetLocationSpending)
+		protected.GET("/analytics/inflation", h.ETag(), h.CacheResponse(), h.GetPersonalInflation)
+		protected.GET("/analytics/health", h.ETag(), h.CacheResponse(), h.GetFinancialHealth)
+		protected.GET("/emergency-fund", h.ETag(), h.GetEmergencyFund)
+		protected.PUT("/emergency-fund", h.UpdateEmergencyFund)
+		protected.GET("/subscriptions", h.ETag(), h.CacheResponse(), h.GetSubscriptions)
+		protected.GET("/insights", h.GetInsights)
+		protected.GET("/insights/subscriptions", h.ETag(), h.CacheResponse(), h.GetForgottenSubscriptions)
+		protected.POST("/insights/:id/dismiss", h.DismissInsight)
+		protected.POST("/insights/:id/snooze", h.SnoozeInsight)
+		protected.GET("/analytics/credit-utilization", h.ETag(), h.GetCreditUtilization)
+		protected.GET("/analytics/safe-to-spend", h.ETag(), h.GetSafeToSpend)
+
+		protected.GET("/reports/tax", h.GetTaxReport)
+		protected.GET("/reports/vat", h.GetVATReport)
+		protected.GET("/reports/reimbursements", h.GetOutstandingReimbursements)
+
+		protected.GET("/contacts", h.GetContacts)
+		protected.POST("/contacts", h.CreateContact)
+		protected.GET("/contacts/balances", h.GetContactBalances)
+		protected.PUT("/contacts/:id", h.UpdateContact)
+		protected.DELETE("/contacts/:id", h.DeleteContact)
+		protected.GET("/contacts/:id/settlements", h.GetSplitSettlements)
+		protected.POST("/contacts/:id/settlements", h.RecordSplitSettlement)
+
+		protected.GET("/links", h.GetUserLinks)
+		protected.POST("/links", h.RequestUserLink)
+		protected.POST("/links/:id/accept", h.AcceptUserLink)
+		protected.DELETE("/links/:id", h.DeleteUserLink)
+		protected.GET("/ious", h.GetIOUs)
+		protected.POST("/ious", h.CreateIOU)
+		protected.POST("/ious/:id/confirm", h.ConfirmIOU)
+		protected.POST("/ious/:id/dispute", h.DisputeIOU)
+		protected.POST("/ious/:id/settle", h.SettleIOU)
+
+		protected.GET("/reimbursements", h.GetReimbursements)
+		protected.POST("/reimbursements", h.CreateReimbursement)
+		protected.POST("/reimbursements/:id/submit", h.SubmitReimbursement)
+		protected.POST("/reimbursements/:id/reimburse", h.Reimburse)
+		protected.DELETE("/reimbursements/:id", h.DeleteReimbursement)
+		protected.GET("/wishlist", h.GetWishlist)
+		protected.POST("/wishlist", h.CreatePlannedPurchase)
+		protected.GET("/wishlist/analytics", h.ETag(), h.CacheResponse(), h.GetWishlistAnalytics)
+		protected.GET("/wishlist/:id", h.GetPlannedPurchase)
+		protected.PUT("/wishlist/:id", h.UpdatePlannedPurchase)
+		protected.DELETE("/wishlist/:id", h.DeletePlannedPurchase)
+		protected.POST("/wishlist/:id/purchase", h.MarkPurchased)
+		protected.DELETE("/wishlist/:id/purchase", h.UnmarkPurchased)
+		protected.POST("/analytics/query", h.QueryAnalytics)
+
+		protected.POST("/receipts/scan", h.ScanReceipt)
+
+		protected.GET("/ingestion/address", h.GetIngestionAddress)
+		protected.POST("/ingestion/address/rotate", h.RotateIngestionAddress)
+		protected.GET("/drafts", h.GetDraftTransactions)
+		protected.POST("/drafts/:id/approve", h.ApproveDraftTransaction)
+		protected.POST("/drafts/:id/reject", h.RejectDraftTransaction)
+
+		protected.GET("/integrations/telegram", h.GetTelegramLink)
+		protected.POST("/integrations/telegram/link", h.CreateTelegramLinkCode)
+		protected.DELETE("/integrations/telegram", h.DeleteTelegramLink)
+
+		protected.GET("/notifications/channels", h.GetNotificationChannels)
+		protected.POST("/notifications/channels", h.CreateNotificationChannel)
+		protected.PUT("/notifications/channels/:id", h.UpdateNotificationChannel)
+		protected.DELETE("/notifications/channels/:id", h.DeleteNotificationChannel)
+		protected.POST("/notifications/channels/:id/test", h.TestNotificationChannel)
+
+		protected.GET("/push/vapid-public-key", h.GetVAPIDPublicKey)
+		protected.GET("/push/subscriptions", h.GetPushSubscriptions)
+		protected.POST("/push/subscriptions", h.CreatePushSubscription)
+		protected.DELETE("/push/subscriptions/:id", h.DeletePushSubscription)
+		protected.GET("/push/preferences", h.GetPushPreferences)
+		protected.PUT("/push/preferences", h.UpdatePushPreferences)
+
+		protected.GET("/sync", h.Sync)
+		protected.POST("/sync", h.PushSync)
+
+		protected.GET("/tokens", h.GetAPITokens)
+		protected.POST("/tokens", h.CreateAPIToken)
+		protected.DELETE("/tokens/:id", h.RevokeAPIToken)
+
+		protected.POST("/auth/logout", h.Logout)
+		protected.GET("/sessions", h.GetSessions)
+		protected.DELETE("/sessions", h.RevokeAllSessions)
+		protected.DELETE("/sessions/:id", h.RevokeSession)
+
+		protected.GET("/widgets/tokens", h.GetWidgetTokens)
+		protected.POST("/widgets/tokens", h.CreateWidgetToken)
+		protected.DELETE("/widgets/tokens/:id", h.RevokeWidgetToken)
+
+		protected.GET("/jobs", h.GetJobs)
+		protected.GET("/jobs/:id", h.GetJob)
+		protected.POST("/jobs/:id/retry", h.RetryJob)
+		protected.GET("/jobs/:id/download", h.DownloadJobFile)
+		protected.POST("/imports", h.CreateImport)
+		protected.GET("/imports/profiles", h.GetImportProfiles)
+		protected.POST("/imports/profiles", h.CreateImportProfile)
+		protected.PUT("/imports/profiles/:id", h.UpdateImportProfile)
+		protected.DELETE("/imports/profiles/:id", h.DeleteImportProfile)
+		protected.GET("/imports/:id", h.GetStagedImport)
+		protected.POST("/imports/:id/commit", h.CommitImport)
+		protected.DELETE("/imports/:id", h.DiscardImport)
+		protected.POST("/exports", h.CreateExport)
+	}
+
+	v2 := router.Group("/api/v2", h.APIVersion(2))
+	v2.Use(h.RequestTimeout(), h.AuthMiddleware(), h.WorkspaceContext(), h.RateLimit("api", models.RateLimitSettings.APIRequests), h.Idempotency(), h.InvalidateOnWrite())
+	{
+		v2.GET("/accounts", h.ETag(), h.GetAccountsV2)
+		v2.POST("/accounts", h.CreateAccountV2)
+		v2.PUT("/accounts/:id", h.UpdateAccountV2)
+		v2.DELETE("/accounts/:id", h.DeleteAccountV2)
+		v2.POST("/accounts/:id/archive", h.ArchiveAccountV2)
+		v2.POST("/accounts/:id/unarchive", h.UnarchiveAccountV2)
+
+		v2.GET("/categories", h.ETag(), h.GetCategoriesV2)
+		v2.POST("/categories", h.CreateCategoryV2)
 
-		protected.GET("/analytics/summary", h.GetAnalyticsSummary)
-		protected.GET("/analytics/spending", h.GetSpendingAnalytics)
-		protected.GET("/analytics/trends", h.GetSpendingTrends)
+		v2.GET("/transactions", h.GetTransactionsV2)
+		v2.POST("/transactions", h.CreateTransactionV2)
+		v2.PUT("/transactions/:id", h.UpdateTransactionV2)
+		v2.DELETE("/transactions/:id", h.DeleteTransactionV2)
 	}
 }
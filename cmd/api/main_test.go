@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/handlers"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/service"
+	"personal-finance-tracker/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// dynamicFields differ on every run; goldens hold a placeholder instead.
+var dynamicFields = []string{"token", "refresh_token", "created_at", "updated_at"}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	testdb.Main(m)
+}
+
+// testConfig turns off what would make tests slow or reach the network:
+// rate limits, the breached password lookup and full-strength hashing.
+func testConfig() *config.Config {
+	cfg := config.Defaults()
+	cfg.Server.LoadTestMode = true
+	cfg.Auth.PasswordBreachCheck = false
+	cfg.Auth.Argon2MemoryKiB = 1024
+	cfg.Auth.Argon2Iterations = 1
+	cfg.Auth.Argon2Threads = 1
+	cfg.HTTP.Compression = false
+	return cfg
+}
+
+func newRouter(db *sql.DB) *gin.Engine {
+	config.Set(testConfig())
+	svc := service.New(db, notifications.NewDispatcher(), events.NewBroker())
+	router := gin.New()
+	setupRoutes(router, handlers.NewHandler(db, svc))
+	return router
+}
+
+// testAPI serves the full router over a migrated database of its own.
+type testAPI struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	return &testAPI{t: t, router: newRouter(testdb.New(t))}
+}
+
+func (a *testAPI) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// register signs a new user up and returns their access token.
+func (a *testAPI) register(email, firstName, lastName string) string {
+	a.t.Helper()
+	rec := a.request(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"email":      email,
+		"password":   "correct horse battery staple",
+		"first_name": firstName,
+		"last_name":  lastName,
+	})
+	var resp struct {
+		Token string `json:"token"`
+	}
+	a.decode(rec, http.StatusCreated, &resp)
+	return resp.Token
+}
+
+// create posts body to path and returns the ID of the created record.
+func (a *testAPI) create(path, token string, body interface{}) int {
+	a.t.Helper()
+	var resp struct {
+		ID int `json:"id"`
+	}
+	a.decode(a.request(http.MethodPost, path, token, body), http.StatusCreated, &resp)
+	return resp.ID
+}
+
+func (a *testAPI) decode(rec *httptest.ResponseRecorder, status int, v interface{}) {
+	a.t.Helper()
+	if rec.Code != status {
+		a.t.Fatalf("status %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		a.t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+}
+
+// assertGolden compares the status and JSON body of rec with
+// testdata/<name>.golden. Run the tests with -update to rewrite the file
+// after an intended change.
+func assertGolden(t *testing.T, name string, rec *httptest.ResponseRecorder, dynamic ...string) {
+	t.Helper()
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: response is not JSON: %s", name, rec.Body)
+	}
+	placeholders := make(map[string]bool)
+	for _, field := range append(dynamicFields, dynamic...) {
+		placeholders[field] = true
+	}
+
+	compareGolden(t, name, map[string]interface{}{
+		"status": rec.Code,
+		"body":   normalize(body, placeholders),
+	})
+}
+
+// compareGolden compares v, as indented JSON, with testdata/<name>.golden.
+func compareGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v (run with -update to create it)", name, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: response differs from %s\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+func normalize(v interface{}, placeholders map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if placeholders[key] && value != nil {
+				v[key] = "<" + key + ">"
+			} else {
+				v[key] = normalize(value, placeholders)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i], placeholders)
+		}
+	}
+	return v
+}
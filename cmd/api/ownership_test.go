@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOwnershipEnforced has Bob go after Ada's records by ID. Every attempt
+// must look as if the record did not exist, and Ada's data must come out of
+// it untouched.
+func TestOwnershipEnforced(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada@example.com", "Ada", "Lovelace")
+	bob := api.register("bob@example.com", "Bob", "Babbage")
+
+	adaAccount := api.create("/api/v1/accounts", ada, gin.H{"name": "Checking", "type": "checking", "balance": 1000, "currency": "USD"})
+	adaCategory := api.create("/api/v1/categories", ada, gin.H{"name": "Groceries", "type": "expense"})
+	adaTransaction := api.create("/api/v1/transactions", ada, gin.H{
+		"account_id": adaAccount, "category_id": adaCategory,
+		"amount": 42.5, "type": "expense", "description": "Market", "date": "2026-03-14T12:00:00Z",
+	})
+
+	bobAccount := api.create("/api/v1/accounts", bob, gin.H{"name": "Savings", "type": "savings", "balance": 10, "currency": "USD"})
+	bobCategory := api.create("/api/v1/categories", bob, gin.H{"name": "Books", "type": "expense"})
+	bobTransaction := api.create("/api/v1/transactions", bob, gin.H{
+		"account_id": bobAccount, "category_id": bobCategory,
+		"amount": 5, "type": "expense", "date": "2026-03-14T12:00:00Z",
+	})
+
+	snapshot := func() map[string]string {
+		bodies := make(map[string]string)
+		for _, path := range []string{"/api/v1/accounts?include_archived=true", "/api/v1/categories", "/api/v1/transactions"} {
+			rec := api.request(http.MethodGet, path, ada, nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body)
+			}
+			bodies[path] = rec.Body.String()
+		}
+		return bodies
+	}
+	before := snapshot()
+
+	transaction := func(accountID, categoryID int) gin.H {
+		return gin.H{
+			"account_id": accountID, "category_id": categoryID,
+			"amount": 1, "type": "expense", "date": "2026-03-15T12:00:00Z",
+		}
+	}
+	attempts := []struct {
+		golden string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"ownership_update_account", http.MethodPut, fmt.Sprintf("/api/v1/accounts/%d", adaAccount),
+			gin.H{"name": "Mine now", "type": "checking", "balance": 0, "currency": "USD"}},
+		{"ownership_archive_account", http.MethodPost, fmt.Sprintf("/api/v1/accounts/%d/archive", adaAccount), nil},
+		{"ownership_delete_account", http.MethodDelete, fmt.Sprintf("/api/v1/accounts/%d", adaAccount), nil},
+		{"ownership_update_category", http.MethodPut, fmt.Sprintf("/api/v1/categories/%d", adaCategory),
+			gin.H{"name": "Mine now", "type": "expense"}},
+		{"ownership_delete_category", http.MethodDelete, fmt.Sprintf("/api/v1/categories/%d", adaCategory), nil},
+		{"ownership_update_transaction", http.MethodPut, fmt.Sprintf("/api/v1/transactions/%d", adaTransaction),
+			transaction(bobAccount, bobCategory)},
+		{"ownership_delete_transaction", http.MethodDelete, fmt.Sprintf("/api/v1/transactions/%d", adaTransaction), nil},
+		{"ownership_create_on_foreign_account", http.MethodPost, "/api/v1/transactions",
+			transaction(adaAccount, bobCategory)},
+		{"ownership_create_in_foreign_category", http.MethodPost, "/api/v1/transactions",
+			transaction(bobAccount, adaCategory)},
+		{"ownership_move_to_foreign_account", http.MethodPut, fmt.Sprintf("/api/v1/transactions/%d", bobTransaction),
+			transaction(adaAccount, bobCategory)},
+		{"ownership_move_to_foreign_category", http.MethodPut, fmt.Sprintf("/api/v1/transactions/%d", bobTransaction),
+			transaction(bobAccount, adaCategory)},
+	}
+	for _, attempt := range attempts {
+		assertGolden(t, attempt.golden, api.request(attempt.method, attempt.path, bob, attempt.body))
+	}
+
+	after := snapshot()
+	for path, body := range before {
+		if after[path] != body {
+			t.Errorf("GET %s changed after Bob's requests\nbefore: %s\nafter:  %s", path, body, after[path])
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"testing"
+
+	"personal-finance-tracker/internal/config"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+// publicRoutes are served without a token; every other route must refuse a
+// request that has none.
+var publicRoutes = map[string]bool{
+	"GET /":                                      true,
+	"GET /health":                                true,
+	"GET /health/live":                           true,
+	"GET /health/ready":                          true,
+	"GET /.well-known/jwks.json":                 true,
+	"GET /app":                                   true,
+	"GET /app/*filepath":                         true,
+	"GET /api/v1/health":                         true,
+	"GET /api/v1/health/live":                    true,
+	"GET /api/v1/health/ready":                   true,
+	"POST /api/v1/auth/register":                 true,
+	"POST /api/v1/auth/login":                    true,
+	"GET /api/v1/auth/oauth/providers":           true,
+	"GET /api/v1/auth/oauth/:provider":           true,
+	"GET /api/v1/auth/oauth/:provider/callback":  true,
+	"POST /api/v1/auth/oauth/:provider/callback": true,
+	"POST /api/v1/auth/oauth/:provider/token":    true,
+	"POST /api/v1/auth/refresh":                  true,
+	"GET /api/v1/auth/unlock/:token":             true,
+	"GET /api/v1/auth/confirm-email/:token":      true,
+	"POST /api/v1/auth/password-strength":        true,
+	"POST /api/v1/auth/demo":                     true,
+	"GET /api/v1/meta/icons":                     true,
+	"GET /api/v1/meta/colors":                    true,
+	"GET /api/v1/widgets/feed/:token":            true,
+	"POST /api/v1/ingestion/email/:token":        true,
+	"POST /api/v1/billing/webhook":               true,
+}
+
+var (
+	pathParam = regexp.MustCompile(`:[^/]+`)
+	wildcard  = regexp.MustCompile(`\*[^/]+`)
+)
+
+// TestRoutesRequireAuthentication needs no database: requests without a
+// token are refused before a handler runs, so the connection is never opened.
+// The inventory golden makes every new route an explicit decision about
+// whether it is public.
+func TestRoutesRequireAuthentication(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	router := newRouter(db)
+
+	inventory := map[string][]string{"public": {}, "authenticated": {}}
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		if publicRoutes[key] {
+			inventory["public"] = append(inventory["public"], key)
+			continue
+		}
+		inventory["authenticated"] = append(inventory["authenticated"], key)
+
+		path := wildcard.ReplaceAllString(pathParam.ReplaceAllString(route.Path, "1"), "x")
+		req := httptest.NewRequest(route.Method, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without a token: status %d, want %d", key, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	for _, routes := range inventory {
+		sort.Strings(routes)
+	}
+	compareGolden(t, "routes", inventory)
+}
+
+// TestAdminRoutesRefuseAPITokens has an administrator reach the admin routes
+// with a session but not with an API token of either scope, so automation
+// can never restore a backup over the database.
+func TestAdminRoutesRefuseAPITokens(t *testing.T) {
+	api := newTestAPI(t)
+	cfg := testConfig()
+	cfg.Server.AdminEmails = []string{"ada@example.com"}
+	config.Set(cfg)
+	ada := api.register("ada@example.com", "Ada", "Lovelace")
+
+	if rec := api.request(http.MethodGet, "/api/v1/admin/config", ada, nil); rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/admin/config with a session: status %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, scope := range []string{"read", "read_write"} {
+		var created struct {
+			Token string `json:"token"`
+		}
+		api.decode(api.request(http.MethodPost, "/api/v1/tokens", ada, gin.H{"name": "automation", "scope": scope}),
+			http.StatusCreated, &created)
+
+		for _, route := range []struct{ method, path string }{
+			{http.MethodPost, "/api/v1/admin/backups/latest.dump/restore"},
+			{http.MethodGet, "/api/v1/admin/backups"},
+			{http.MethodGet, "/api/v1/admin/config"},
+		} {
+			if rec := api.request(route.method, route.path, created.Token, nil); rec.Code != http.StatusForbidden {
+				t.Errorf("%s %s with a %s token: status %d, want %d", route.method, route.path, scope, rec.Code, http.StatusForbidden)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serverTLS builds the TLS configuration the API and gRPC listeners share,
+// with the autocert manager when certificates come from Let's Encrypt. Both
+// are nil when TLS is not configured.
+func serverTLS(tlsCfg config.TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	switch {
+	case tlsCfg.CertFile != "":
+		cert, err := tls.LoadX509KeyPair(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, nil, nil
+
+	case len(tlsCfg.AutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(tlsCfg.AutocertDomains...),
+			Cache:      autocert.DirCache(tlsCfg.AutocertCacheDir),
+			Email:      tlsCfg.AutocertEmail,
+		}
+		// The manager's config answers TLS-ALPN challenges on PORT and
+		// offers HTTP/2.
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, manager, nil
+	}
+	return nil, nil, nil
+}
+
+// serve runs the API on PORT: over HTTPS with HTTP/2 when TLS is
+// configured, otherwise over plain HTTP for a reverse proxy to terminate
+// TLS in front of it.
+func serve(handler http.Handler, cfg *config.Config, tlsConfig *tls.Config, manager *autocert.Manager) error {
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		TLSConfig:         tlsConfig,
+	}
+
+	if tlsConfig == nil {
+		log.Printf("Starting server on port %s", cfg.Server.Port)
+		return server.ListenAndServe()
+	}
+
+	startRedirect(cfg.TLS.RedirectPort, cfg.Server.Port, manager)
+	if manager != nil {
+		log.Printf("Starting HTTPS server on port %s with Let's Encrypt certificates for %v", cfg.Server.Port, cfg.TLS.AutocertDomains)
+	} else {
+		log.Printf("Starting HTTPS server on port %s", cfg.Server.Port)
+	}
+	return server.ListenAndServeTLS("", "")
+}
+
+// startRedirect serves plain HTTP on port, if set, redirecting every request
+// to HTTPS. With autocert it first answers ACME HTTP challenges.
+func startRedirect(port, httpsPort string, manager *autocert.Manager) {
+	if port == "" {
+		return
+	}
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+
+	server := &http.Server{Addr: ":" + port, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		log.Printf("Redirecting HTTP on port %s to HTTPS", port)
+		if err := server.ListenAndServe(); err != nil {
+			log.Printf("HTTP redirect server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUpdateTransactionKeepsDate edits a transaction without sending its
+// date, which must leave it on the day it was recorded.
+func TestUpdateTransactionKeepsDate(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada@example.com", "Ada", "Lovelace")
+
+	account := api.create("/api/v1/accounts", ada, gin.H{"name": "Checking", "type": "checking", "balance": 1000, "currency": "USD"})
+	category := api.create("/api/v1/categories", ada, gin.H{"name": "Groceries", "type": "expense"})
+	id := api.create("/api/v1/transactions", ada, gin.H{
+		"account_id": account, "category_id": category,
+		"amount": 42.5, "type": "expense", "description": "Market", "date": "2026-03-14T12:00:00Z",
+	})
+
+	for _, path := range []string{"/api/v1/transactions/%d", "/api/v2/transactions/%d"} {
+		body := gin.H{"account_id": account, "category_id": category, "amount": 40, "amount_cents": 4000, "type": "expense", "description": "Market"}
+		var updated struct {
+			Date time.Time `json:"date"`
+		}
+		api.decode(api.request(http.MethodPut, fmt.Sprintf(path, id), ada, body), http.StatusOK, &updated)
+		if want := time.Date(2026, time.March, 14, 12, 0, 0, 0, time.UTC); !updated.Date.Equal(want) {
+			t.Errorf("PUT %s without a date moved the transaction to %s, want %s", path, updated.Date, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.Int("user", 0, "check accounts of a single user ID (default: all users)")
+	fix := flag.Bool("fix", false, "rebuild drifted balances from the ledger instead of only reporting them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	config.Set(cfg)
+
+	db, err := database.Initialize()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	svc := service.New(db, notifications.NewDispatcher(), events.NewBroker())
+
+	drift, err := svc.BalanceDrift(context.Background(), *userID, *fix)
+	if err != nil {
+		log.Fatal("Failed to check balances:", err)
+	}
+	for _, d := range drift {
+		log.Printf("Account %d (%q, user %d): stored %.2f, ledger %.2f, difference %.2f",
+			d.AccountID, d.Name, d.UserID, d.Stored, d.Ledger, d.Difference)
+	}
+
+	switch {
+	case len(drift) == 0:
+		log.Println("All balances match their ledgers")
+	case *fix:
+		log.Printf("Rebuilt %d balances from their ledgers", len(drift))
+	default:
+		log.Printf("%d balances drifted; run with -fix to rebuild them", len(drift))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var httpClient = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: &http.Transport{MaxIdleConns: 1000, MaxIdleConnsPerHost: 1000, IdleConnTimeout: 90 * time.Second},
+}
+
+// session is a signed-in synthetic user with the IDs its requests refer to.
+type session struct {
+	server     string
+	token      string
+	accounts   []int
+	categories []int
+}
+
+// login signs every user in and loads their accounts and expense
+// categories.
+func login(server string, emails []string, password string) ([]*session, error) {
+	server = strings.TrimRight(server, "/")
+	ctx := context.Background()
+
+	sessions := make([]*session, 0, len(emails))
+	for _, email := range emails {
+		s := &session{server: server}
+
+		var auth models.AuthResponse
+		if err := s.call(ctx, http.MethodPost, "/api/v1/auth/login", models.LoginRequest{Email: email, Password: password}, &auth); err != nil {
+			return nil, fmt.Errorf("signing in %s: %w", email, err)
+		}
+		s.token = auth.Token
+
+		var accounts []models.Account
+		if err := s.call(ctx, http.MethodGet, "/api/v1/accounts", nil, &accounts); err != nil {
+			return nil, fmt.Errorf("loading accounts of %s: %w", email, err)
+		}
+		for _, account := range accounts {
+			s.accounts = append(s.accounts, account.ID)
+		}
+
+		var categories []models.Category
+		if err := s.call(ctx, http.MethodGet, "/api/v1/categories", nil, &categories); err != nil {
+			return nil, fmt.Errorf("loading categories of %s: %w", email, err)
+		}
+		for _, category := range categories {
+			if category.Type == models.TransactionTypes.Expense {
+				s.categories = append(s.categories, category.ID)
+			}
+		}
+
+		if len(s.accounts) == 0 || len(s.categories) == 0 {
+			return nil, fmt.Errorf("%s has no accounts or expense categories, seed it first", email)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// call sends a request and decodes a successful JSON response into out.
+func (s *session) call(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := s.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s failed with status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send makes a timed request and reads the response in full, as a real
+// client would, returning its status.
+func (s *session) send(ctx context.Context, method, path string, body interface{}) (int, error) {
+	resp, err := s.do(ctx, method, path, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, err
+}
+
+func (s *session) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.server+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return httpClient.Do(req)
+}
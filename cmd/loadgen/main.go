@@ -0,0 +1,177 @@
+// Command loadgen seeds synthetic users and drives a realistic mix of API
+// traffic against a running server, then reports latency per route. Run the
+// server with LOAD_TEST_MODE=true so that rate limits do not skew results.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "base URL of the API under test")
+	users := flag.Int("users", 20, "number of synthetic users")
+	months := flag.Int("months", 12, "months of transaction history per seeded user")
+	volume := flag.Float64("volume", 1, "scale of day-to-day purchases of seeded users")
+	password := flag.String("password", "load-password-123", "password of every synthetic user")
+	domain := flag.String("domain", "loadgen.example.com", "email domain; users are load1@<domain>, load2@<domain>, ...")
+	skipSeed := flag.Bool("skip-seed", false, "use existing users instead of seeding them through the database")
+	reset := flag.Bool("reset", false, "replace synthetic users that already exist")
+	workers := flag.Int("concurrency", 20, "number of concurrent clients")
+	duration := flag.Duration("duration", time.Minute, "how long to drive traffic")
+	rate := flag.Float64("rate", 0, "total requests per second (0 sends as fast as the clients can)")
+	readOnly := flag.Bool("read-only", false, "only send reads, leaving the data unchanged")
+	seed := flag.Int64("seed", 0, "random seed for reproducible data and traffic (default: random)")
+	flag.Parse()
+
+	if *users < 1 || *workers < 1 || *months < 1 || *volume <= 0 || *duration <= 0 || *rate < 0 {
+		log.Fatal("-users, -concurrency and -months must be at least 1, -volume and -duration positive and -rate not negative")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	emails := make([]string, *users)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("load%d@%s", i+1, *domain)
+	}
+
+	if !*skipSeed {
+		if err := seedUsers(emails, *password, *months, *volume, *reset, rng); err != nil {
+			log.Fatal("Failed to seed users: ", err)
+		}
+	}
+
+	sessions, err := login(*server, emails, *password)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mix := operations
+	if *readOnly {
+		mix = readOperations()
+	}
+
+	log.Printf("Driving traffic for %s with %d clients against %s (-seed=%d)", *duration, *workers, *server, *seed)
+	stats := newStats()
+	started := time.Now()
+	run(sessions, mix, *workers, *duration, *rate, *seed, stats)
+	stats.report(os.Stdout, time.Since(started))
+}
+
+// seedUsers creates the synthetic users directly in the database configured
+// for this machine, like cmd/seed, which is much faster than the API.
+func seedUsers(emails []string, password string, months int, volume float64, reset bool, rng *rand.Rand) error {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return err
+	}
+	config.Set(cfg)
+
+	db, err := database.Initialize()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	svc := service.New(db, nil, nil)
+
+	created := 0
+	for _, email := range emails {
+		if reset {
+			if _, err := svc.DeleteUserByEmail(ctx, email); err != nil {
+				return fmt.Errorf("deleting %s: %w", email, err)
+			}
+		}
+
+		_, err := svc.SeedUser(ctx, email, password, months, volume, rng)
+		if err == service.ErrEmailTaken {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("seeding %s: %w", email, err)
+		}
+		created++
+	}
+	log.Printf("Seeded %d users, reusing %d existing ones", created, len(emails)-created)
+	return nil
+}
+
+// run sends requests from workers until duration has passed. A positive
+// rate spreads requests evenly across the run instead.
+func run(sessions []*session, mix []operation, workers int, duration time.Duration, rate float64, seed int64, stats *stats) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	var tokens <-chan time.Time
+	if interval := time.Duration(float64(time.Second) / rate); rate > 0 && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	total := 0
+	for _, op := range mix {
+		total += op.weight
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for {
+				if tokens != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-tokens:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				s := sessions[rng.Intn(len(sessions))]
+				op := pick(mix, total, rng)
+				method, path, body := op.request(s, rng)
+
+				began := time.Now()
+				status, err := s.send(ctx, method, path, body)
+				if ctx.Err() != nil {
+					return
+				}
+				stats.record(op.name, status, err, time.Since(began))
+			}
+		}(rand.New(rand.NewSource(seed + int64(i))))
+	}
+	wg.Wait()
+}
+
+func pick(mix []operation, total int, rng *rand.Rand) operation {
+	n := rng.Intn(total)
+	for _, op := range mix {
+		if n < op.weight {
+			return op
+		}
+		n -= op.weight
+	}
+	return mix[len(mix)-1]
+}
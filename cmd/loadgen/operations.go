@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// operation is one kind of request in the traffic mix. Weights follow how
+// the web app and mobile clients use the API: mostly dashboards, transaction
+// pages and analytics, with a steady trickle of new transactions.
+type operation struct {
+	name    string
+	weight  int
+	write   bool
+	request func(s *session, rng *rand.Rand) (method, path string, body interface{})
+}
+
+var operations = []operation{
+	{"dashboard", 10, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, "/api/v1/dashboard/data", nil
+	}},
+	{"accounts", 8, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, "/api/v1/accounts", nil
+	}},
+	{"transactions page", 20, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, fmt.Sprintf("/api/v1/transactions?limit=50&offset=%d", 50*rng.Intn(10)), nil
+	}},
+	{"account transactions", 6, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, fmt.Sprintf("/api/v1/transactions?account_id=%d&limit=50", s.accounts[rng.Intn(len(s.accounts))]), nil
+	}},
+	{"analytics summary", 10, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		start, end := randomRange(rng)
+		return http.MethodGet, "/api/v1/analytics/summary?start_date=" + start + "&end_date=" + end, nil
+	}},
+	{"spending analytics", 8, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		start, end := randomRange(rng)
+		return http.MethodGet, "/api/v1/analytics/spending?start_date=" + start + "&end_date=" + end, nil
+	}},
+	{"spending trends", 6, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		periods := []string{"day", "week", "month"}
+		return http.MethodGet, "/api/v1/analytics/trends?period=" + periods[rng.Intn(len(periods))], nil
+	}},
+	{"balance history", 4, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, fmt.Sprintf("/api/v1/accounts/%d/balance-history?granularity=week", s.accounts[rng.Intn(len(s.accounts))]), nil
+	}},
+	{"budget status", 6, false, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodGet, "/api/v1/budgets/status", nil
+	}},
+	{"create transaction", 12, true, func(s *session, rng *rand.Rand) (string, string, interface{}) {
+		return http.MethodPost, "/api/v1/transactions", models.CreateTransactionRequest{
+			AccountID:   s.accounts[rng.Intn(len(s.accounts))],
+			CategoryID:  s.categories[rng.Intn(len(s.categories))],
+			Amount:      math.Round((2+rng.ExpFloat64()*40)*100) / 100,
+			Type:        models.TransactionTypes.Expense,
+			Description: "Load test purchase",
+		}
+	}},
+}
+
+func readOperations() []operation {
+	var reads []operation
+	for _, op := range operations {
+		if !op.write {
+			reads = append(reads, op)
+		}
+	}
+	return reads
+}
+
+// randomRange picks a range of one to three whole months within the last
+// year, so analytics requests mix rollup reads with partial months.
+func randomRange(rng *rand.Rand) (string, string) {
+	now := time.Now()
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -rng.Intn(12), 0)
+	last := first.AddDate(0, 1+rng.Intn(3), -1)
+	if rng.Intn(4) == 0 {
+		first = first.AddDate(0, 0, rng.Intn(28))
+	}
+	return first.Format("2006-01-02"), last.Format("2006-01-02")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type routeStats struct {
+	latencies []time.Duration
+	failures  int
+	statuses  map[int]int
+}
+
+// stats collects the latency of every request per operation. Requests that
+// fail or answer 4xx/5xx count as failures but still have their latency.
+type stats struct {
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+func newStats() *stats {
+	return &stats{routes: make(map[string]*routeStats)}
+}
+
+func (s *stats) record(name string, status int, err error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route, ok := s.routes[name]
+	if !ok {
+		route = &routeStats{statuses: make(map[int]int)}
+		s.routes[name] = route
+	}
+	route.latencies = append(route.latencies, latency)
+	route.statuses[status]++
+	if err != nil || status >= 400 {
+		route.failures++
+	}
+}
+
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.routes))
+	for name := range s.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tFAILED\tP50\tP95\tP99\tMAX\tSTATUSES\t")
+
+	var all []time.Duration
+	failures := 0
+	for _, name := range names {
+		route := s.routes[name]
+		sort.Slice(route.latencies, func(i, j int) bool { return route.latencies[i] < route.latencies[j] })
+		all = append(all, route.latencies...)
+		failures += route.failures
+
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n", name, len(route.latencies), route.failures,
+			percentile(route.latencies, 50), percentile(route.latencies, 95), percentile(route.latencies, 99),
+			percentile(route.latencies, 100), statusSummary(route.statuses))
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	fmt.Fprintf(tw, "total\t%d\t%d\t%s\t%s\t%s\t%s\t\t\n", len(all), failures,
+		percentile(all, 50), percentile(all, 95), percentile(all, 99), percentile(all, 100))
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d requests in %s (%.1f req/s)\n", len(all), elapsed.Round(time.Millisecond), float64(len(all))/elapsed.Seconds())
+}
+
+// percentile reads the p-th percentile of sorted latencies, rounded for
+// display.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i].Round(100 * time.Microsecond)
+}
+
+// statusSummary lists response counts by status, with 0 for requests that
+// got no response.
+func statusSummary(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	summary := ""
+	for i, code := range codes {
+		if i > 0 {
+			summary += " "
+		}
+		summary += fmt.Sprintf("%d:%d", code, statuses[code])
+	}
+	return summary
+}
@@ -0,0 +1,59 @@
+// Command migrate applies the database migrations embedded in the binary,
+// together with any in <ASSETS_DIR>/migrations, that have not run yet.
+// Databases created by the Postgres container's init scripts have no
+// migration history; record it once with -baseline before migrating.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"personal-finance-tracker/internal/assets"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/migrations"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	baseline := flag.String("baseline", "", "record migrations up to this number (such as 059) as applied without running them")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	config.Set(cfg)
+
+	db, err := database.Initialize()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	files := assets.Overlay(migrations.Files, cfg.Assets.Path("migrations"))
+
+	if *baseline != "" {
+		recorded, err := database.Baseline(db, files, *baseline)
+		if err != nil {
+			log.Fatal("Failed to record migration history:", err)
+		}
+		log.Printf("Recorded %d migrations as applied", len(recorded))
+		return
+	}
+
+	applied, err := database.Migrate(db, files)
+	for _, name := range applied {
+		log.Printf("Applied migration %s", name)
+	}
+	if err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+	log.Printf("Database is up to date (%d migrations applied)", len(applied))
+}
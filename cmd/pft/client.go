@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run \"pft login\" first")
+
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+type client struct {
+	creds credentials
+	http  *http.Client
+}
+
+func newClient(creds credentials) *client {
+	return &client{creds: creds, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// do sends an authenticated request and decodes the JSON response into out.
+// An expired access token is refreshed once and the new tokens are saved.
+func (c *client) do(method, path string, body, out interface{}) error {
+	data, err := c.raw(method, path, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (c *client) raw(method, path string, body interface{}) ([]byte, error) {
+	data, err := c.send(method, path, body, c.creds.Token)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized && c.creds.RefreshToken != "" {
+		if err := c.refresh(); err != nil {
+			return nil, err
+		}
+		data, err = c.send(method, path, body, c.creds.Token)
+	}
+	return data, err
+}
+
+func (c *client) refresh() error {
+	var session struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	data, err := c.send(http.MethodPost, "/api/v1/auth/refresh", map[string]string{"refresh_token": c.creds.RefreshToken}, "")
+	if err != nil {
+		return fmt.Errorf("session expired, run \"pft login\" again: %w", err)
+	}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return err
+	}
+
+	c.creds.Token = session.Token
+	if session.RefreshToken != "" {
+		c.creds.RefreshToken = session.RefreshToken
+	}
+	return saveCredentials(c.creds)
+}
+
+func (c *client) send(method, path string, body interface{}, token string) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(c.creds.Server, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &apiError{Status: resp.StatusCode, Message: errorMessage(data, resp.Status)}
+	}
+	return data, nil
+}
+
+// errorMessage reads both the v1 {"error": ...} body and problem details.
+func errorMessage(data []byte, fallback string) string {
+	var body struct {
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+		Title  string `json:"title"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		for _, message := range []string{body.Error, body.Detail, body.Title} {
+			if message != "" {
+				return message
+			}
+		}
+	}
+	return fallback
+}
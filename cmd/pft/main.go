@@ -0,0 +1,341 @@
+// Command pft is a terminal client for the Personal Finance Tracker API.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"login", "sign in and store the session locally", runLogin},
+		{"logout", "end the session and forget the stored tokens", runLogout},
+		{"add", "add an expense (or income with -income)", runAdd},
+		{"list", "list recent transactions", runList},
+		{"budget", "show this month's budgets and spending", runBudget},
+		{"export", "export transactions to a CSV file", runExport},
+		{"tui", "open the interactive dashboard", runTUI},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "pft:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	if os.Args[1] != "help" && os.Args[1] != "-h" && os.Args[1] != "--help" {
+		fmt.Fprintf(os.Stderr, "pft: unknown command %q\n\n", os.Args[1])
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: pft <command> [flags]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun \"pft <command> -h\" for the flags of a command.")
+}
+
+func authenticated() (*client, error) {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(creds), nil
+}
+
+func runLogin(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	server := flags.String("server", envOr("PFT_SERVER", "http://localhost:8080"), "API base URL")
+	email := flags.String("email", "", "account email")
+	flags.Parse(args)
+
+	reader := bufio.NewReader(os.Stdin)
+	if *email == "" {
+		*email = prompt(reader, "Email: ")
+	}
+	// The password is read from PFT_PASSWORD or stdin rather than a flag so it
+	// does not end up in the shell history.
+	password := os.Getenv("PFT_PASSWORD")
+	if password == "" {
+		password = prompt(reader, "Password: ")
+	}
+
+	c := newClient(credentials{Server: *server})
+	var session models.AuthResponse
+	if err := c.do(http.MethodPost, "/api/v1/auth/login", models.LoginRequest{Email: *email, Password: password}, &session); err != nil {
+		return err
+	}
+
+	err := saveCredentials(credentials{Server: *server, Email: *email, Token: session.Token, RefreshToken: session.RefreshToken})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged in as %s\n", session.User.Email)
+	return nil
+}
+
+func runLogout(args []string) error {
+	flag.NewFlagSet("logout", flag.ExitOnError).Parse(args)
+
+	c, err := authenticated()
+	if err == nil {
+		if err := c.do(http.MethodPost, "/api/v1/auth/logout", nil, nil); err != nil {
+			fmt.Fprintln(os.Stderr, "pft: could not end the session on the server:", err)
+		}
+	} else if !errors.Is(err, errNotLoggedIn) {
+		return err
+	}
+	return deleteCredentials()
+}
+
+func runAdd(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	amount := flags.Float64("amount", 0, "amount (required)")
+	category := flags.String("category", "", "category name or ID (required)")
+	account := flags.String("account", "", "account name or ID (default: the default account from settings)")
+	description := flags.String("desc", "", "description")
+	date := flags.String("date", "", "date as YYYY-MM-DD (default: today)")
+	income := flags.Bool("income", false, "record income instead of an expense")
+	flags.Parse(args)
+
+	if *amount <= 0 || *category == "" {
+		return errors.New("-amount and -category are required")
+	}
+
+	c, err := authenticated()
+	if err != nil {
+		return err
+	}
+
+	req := models.CreateTransactionRequest{Amount: *amount, Type: "expense", Description: *description}
+	if *income {
+		req.Type = "income"
+	}
+	if *date != "" {
+		day, err := time.ParseInLocation("2006-01-02", *date, time.Local)
+		if err != nil {
+			return errors.New("-date must use the YYYY-MM-DD format")
+		}
+		req.Date = &day
+	}
+	if req.CategoryID, err = findCategory(c, *category, req.Type); err != nil {
+		return err
+	}
+	if req.AccountID, err = findAccount(c, *account); err != nil {
+		return err
+	}
+
+	var created models.Transaction
+	if err := c.do(http.MethodPost, "/api/v1/transactions", req, &created); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s #%d: %.2f on %s\n", created.Type, created.ID, created.Amount, created.Date.Format("2006-01-02"))
+	return nil
+}
+
+func findCategory(c *client, value, kind string) (int, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, nil
+	}
+
+	var categories []models.Category
+	if err := c.do(http.MethodGet, "/api/v1/categories", nil, &categories); err != nil {
+		return 0, err
+	}
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, value) && category.Type == kind {
+			return category.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no %s category named %q", kind, value)
+}
+
+func findAccount(c *client, value string) (int, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, nil
+	}
+
+	if value == "" {
+		var settings models.UserSettings
+		if err := c.do(http.MethodGet, "/api/v1/settings", nil, &settings); err != nil {
+			return 0, err
+		}
+		if settings.DefaultAccountID == nil {
+			return 0, errors.New("no default account is set, pass -account")
+		}
+		return *settings.DefaultAccountID, nil
+	}
+
+	var accounts []models.Account
+	if err := c.do(http.MethodGet, "/api/v1/accounts", nil, &accounts); err != nil {
+		return 0, err
+	}
+	for _, account := range accounts {
+		if strings.EqualFold(account.Name, value) {
+			return account.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no account named %q", value)
+}
+
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	limit := flags.Int("n", 20, "number of transactions")
+	flags.Parse(args)
+
+	c, err := authenticated()
+	if err != nil {
+		return err
+	}
+
+	var transactions []models.Transaction
+	query := url.Values{"limit": {strconv.Itoa(*limit)}, "expand": {"category,account"}}
+	if err := c.do(http.MethodGet, "/api/v1/transactions?"+query.Encode(), nil, &transactions); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tAMOUNT\tCATEGORY\tACCOUNT\tDESCRIPTION")
+	for _, t := range transactions {
+		amount := t.Amount
+		if t.Type == "expense" {
+			amount = -amount
+		}
+		category, account, currency := "", "", ""
+		if t.Category != nil {
+			category = t.Category.Name
+		}
+		if t.Account != nil {
+			account, currency = t.Account.Name, t.Account.Currency
+		}
+		fmt.Fprintf(w, "%s\t%.2f %s\t%s\t%s\t%s\n", t.Date.Format("2006-01-02"), amount, currency, category, account, t.Description)
+	}
+	return w.Flush()
+}
+
+func runBudget(args []string) error {
+	flags := flag.NewFlagSet("budget", flag.ExitOnError)
+	date := flags.String("date", "", "show the month containing this YYYY-MM-DD date")
+	flags.Parse(args)
+
+	c, err := authenticated()
+	if err != nil {
+		return err
+	}
+
+	path := "/api/v1/budgets/status"
+	if *date != "" {
+		path += "?" + url.Values{"date": {*date}}.Encode()
+	}
+	var overview models.BudgetOverview
+	if err := c.do(http.MethodGet, path, nil, &overview); err != nil {
+		return err
+	}
+
+	fmt.Printf("Budgets %s to %s (%s)\n\n", overview.PeriodStart, overview.PeriodEnd, overview.Currency)
+	if len(overview.Categories) == 0 {
+		fmt.Println("No monthly budgets set.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "CATEGORY\tBUDGET\tSPENT\tLEFT\tUSED\t")
+	for _, b := range overview.Categories {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%.1f%%\t\n", b.CategoryName, b.Budget, b.Spent, b.Remaining, b.PercentUsed)
+	}
+	fmt.Fprintf(w, "Total\t%.2f\t%.2f\t%.2f\t\t\n", overview.Budget, overview.Spent, overview.Remaining)
+	return w.Flush()
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	from := flags.String("from", "", "first day as YYYY-MM-DD")
+	to := flags.String("to", "", "last day as YYYY-MM-DD")
+	output := flags.String("o", "transactions.csv", "output file, - for stdout")
+	flags.Parse(args)
+
+	c, err := authenticated()
+	if err != nil {
+		return err
+	}
+
+	var job models.Job
+	if err := c.do(http.MethodPost, "/api/v1/exports", models.ExportRequest{StartDate: *from, EndDate: *to}, &job); err != nil {
+		return err
+	}
+
+	jobPath := "/api/v1/jobs/" + strconv.Itoa(job.ID)
+	for job.Status != models.JobStatuses.Completed {
+		if job.Status == models.JobStatuses.Dead {
+			message := "export failed"
+			if job.LastError != nil {
+				message += ": " + *job.LastError
+			}
+			return errors.New(message)
+		}
+		time.Sleep(time.Second)
+		if err := c.do(http.MethodGet, jobPath, nil, &job); err != nil {
+			return err
+		}
+	}
+
+	data, err := c.raw(http.MethodGet, jobPath+"/download", nil)
+	if err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("Exported to %s\n", *output)
+	return nil
+}
+
+func prompt(reader *bufio.Reader, label string) string {
+	fmt.Fprint(os.Stderr, label)
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
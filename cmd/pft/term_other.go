@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package main
+
+import "errors"
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("the dashboard is not supported on this platform")
+}
+
+func terminalSize(fd int) (int, int) {
+	return 80, 24
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+const (
+	ansiClear = "\x1b[2J\x1b[H"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+type dashboard struct {
+	client       *client
+	accounts     []models.Account
+	budget       models.BudgetOverview
+	transactions []models.Transaction
+	status       string
+	keys         *bufio.Reader
+	width        int
+	height       int
+}
+
+func runTUI(args []string) error {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	flags.Parse(args)
+
+	c, err := authenticated()
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	defer fmt.Print(ansiClear)
+
+	d := &dashboard{client: c, keys: bufio.NewReader(os.Stdin)}
+	d.width, d.height = terminalSize(fd)
+	d.reload()
+
+	for {
+		d.render()
+		key, err := d.keys.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case 'q', 3: // Ctrl+C arrives as a byte in raw mode.
+			return nil
+		case 'r':
+			d.width, d.height = terminalSize(fd)
+			d.reload()
+		case 'a':
+			d.quickAdd(false)
+		case 'i':
+			d.quickAdd(true)
+		}
+	}
+}
+
+func (d *dashboard) reload() {
+	d.status = "Updated " + time.Now().Format("15:04:05")
+
+	recent := url.Values{"limit": {strconv.Itoa(max(d.height/3, 5))}, "expand": {"category,account"}}
+	for _, load := range []struct {
+		path string
+		out  interface{}
+	}{
+		{"/api/v1/accounts", &d.accounts},
+		{"/api/v1/budgets/status", &d.budget},
+		{"/api/v1/transactions?" + recent.Encode(), &d.transactions},
+	} {
+		if err := d.client.do(http.MethodGet, load.path, nil, load.out); err != nil {
+			d.status = ansiRed + "Error: " + err.Error() + ansiReset
+			return
+		}
+	}
+}
+
+func (d *dashboard) render() {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	add("%sPersonal Finance Tracker%s  %s%s%s", ansiBold, ansiReset, ansiDim, d.client.creds.Email, ansiReset)
+	add("")
+
+	add("%sAccounts%s", ansiBold, ansiReset)
+	for _, account := range d.accounts {
+		add("  %-28s %s", truncate(account.Name, 28), colorAmount(account.Balance, account.Currency))
+	}
+	add("")
+
+	add("%sBudgets %s to %s%s", ansiBold, d.budget.PeriodStart, d.budget.PeriodEnd, ansiReset)
+	if len(d.budget.Categories) == 0 {
+		add("  %sNo monthly budgets set%s", ansiDim, ansiReset)
+	}
+	barWidth := max(min(d.width-60, 40), 10)
+	for _, b := range d.budget.Categories {
+		add("  %-20s %s %6.1f%%  %10.2f left", truncate(b.CategoryName, 20), bar(b.PercentUsed, barWidth), b.PercentUsed, b.Remaining)
+	}
+	add("")
+
+	add("%sRecent transactions%s", ansiBold, ansiReset)
+	for _, t := range d.transactions {
+		amount, currency, category := t.Amount, "", ""
+		if t.Type == "expense" {
+			amount = -amount
+		}
+		if t.Account != nil {
+			currency = t.Account.Currency
+		}
+		if t.Category != nil {
+			category = t.Category.Name
+		}
+		add("  %s  %s  %-16s %s", t.Date.Format("2006-01-02"), colorAmount(amount, currency),
+			truncate(category, 16), truncate(t.Description, max(d.width-60, 10)))
+	}
+
+	if len(lines) > d.height-2 {
+		lines = lines[:d.height-2]
+	}
+	for len(lines) < d.height-2 {
+		lines = append(lines, "")
+	}
+	add("%s[a]%s add expense  %s[i]%s add income  %s[r]%s refresh  %s[q]%s quit   %s",
+		ansiBold, ansiReset, ansiBold, ansiReset, ansiBold, ansiReset, ansiBold, ansiReset, d.status)
+
+	// Raw mode turns off the translation of \n to \r\n.
+	fmt.Print(ansiClear + strings.Join(lines, "\r\n"))
+}
+
+// quickAdd asks for the transaction on the status line. Escape cancels.
+func (d *dashboard) quickAdd(income bool) {
+	kind := "expense"
+	if income {
+		kind = "income"
+	}
+
+	amountText, ok := d.readLine("Amount: ")
+	if !ok {
+		d.status = "Cancelled"
+		return
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(amountText, ",", "."), 64)
+	if err != nil || amount <= 0 {
+		d.status = ansiRed + "Amount must be a positive number" + ansiReset
+		return
+	}
+	category, ok := d.readLine("Category: ")
+	if !ok {
+		d.status = "Cancelled"
+		return
+	}
+	description, ok := d.readLine("Description: ")
+	if !ok {
+		d.status = "Cancelled"
+		return
+	}
+
+	req := models.CreateTransactionRequest{Amount: amount, Type: kind, Description: description}
+	if req.CategoryID, err = findCategory(d.client, category, kind); err != nil {
+		d.status = ansiRed + err.Error() + ansiReset
+		return
+	}
+	if req.AccountID, err = findAccount(d.client, ""); err != nil {
+		if len(d.accounts) == 0 {
+			d.status = ansiRed + "Create an account first" + ansiReset
+			return
+		}
+		req.AccountID = d.accounts[0].ID
+	}
+
+	var created models.Transaction
+	if err := d.client.do(http.MethodPost, "/api/v1/transactions", req, &created); err != nil {
+		d.status = ansiRed + "Error: " + err.Error() + ansiReset
+		return
+	}
+	d.reload()
+	d.status = ansiGreen + fmt.Sprintf("Added %s of %.2f", kind, amount) + ansiReset
+}
+
+// readLine edits a single line on the bottom row. It reports false when the
+// user pressed Escape.
+func (d *dashboard) readLine(label string) (string, bool) {
+	var input []rune
+	for {
+		fmt.Printf("\x1b[%d;1H\x1b[2K%s%s", d.height, label, string(input))
+
+		r, _, err := d.keys.ReadRune()
+		if err != nil {
+			return "", false
+		}
+		switch r {
+		case '\r', '\n':
+			return strings.TrimSpace(string(input)), true
+		case 27, 3:
+			return "", false
+		case 127, 8:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		default:
+			if r >= ' ' {
+				input = append(input, r)
+			}
+		}
+	}
+}
+
+func bar(percent float64, width int) string {
+	filled := int(math.Round(math.Min(percent, 100) / 100 * float64(width)))
+	color := ansiGreen
+	if percent > 100 {
+		color = ansiRed
+	}
+	return color + strings.Repeat("█", filled) + ansiReset + ansiDim + strings.Repeat("░", width-filled) + ansiReset
+}
+
+func colorAmount(amount float64, currency string) string {
+	color := ansiGreen
+	if amount < 0 {
+		color = ansiRed
+	}
+	return fmt.Sprintf("%s%12.2f%s %-3s", color, amount, ansiReset, currency)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
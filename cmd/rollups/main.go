@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.Int("user", 0, "rebuild rollups for a single user ID (default: all users)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	config.Set(cfg)
+
+	db, err := database.Initialize()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	svc := service.New(db, notifications.NewDispatcher(), events.NewBroker())
+
+	rows, err := svc.BackfillRollups(context.Background(), *userID)
+	if err != nil {
+		log.Fatal("Failed to backfill rollups:", err)
+	}
+	log.Printf("Rebuilt %d monthly rollup rows", rows)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	users := flag.Int("users", 3, "number of users to create")
+	months := flag.Int("months", 12, "months of transaction history per user")
+	volume := flag.Float64("volume", 1, "scale of day-to-day purchases (2 doubles them)")
+	password := flag.String("password", "dev-password-123", "password for every seeded user")
+	domain := flag.String("domain", "example.com", "email domain; users are dev1@<domain>, dev2@<domain>, ...")
+	seed := flag.Int64("seed", 0, "random seed for reproducible data (default: random)")
+	reset := flag.Bool("reset", false, "replace seeded users that already exist")
+	flag.Parse()
+
+	if *users < 1 || *months < 1 || *volume <= 0 {
+		log.Fatal("-users and -months must be at least 1 and -volume must be positive")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	config.Set(cfg)
+
+	db, err := database.Initialize()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	ctx := context.Background()
+	svc := service.New(db, nil, nil)
+
+	for i := 1; i <= *users; i++ {
+		email := fmt.Sprintf("dev%d@%s", i, *domain)
+		if *reset {
+			if _, err := svc.DeleteUserByEmail(ctx, email); err != nil {
+				log.Fatalf("Failed to delete %s: %v", email, err)
+			}
+		}
+
+		user, err := svc.SeedUser(ctx, email, *password, *months, *volume, rng)
+		if err == service.ErrEmailTaken {
+			log.Printf("Skipping %s: user already exists (use -reset to replace it)", email)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Failed to seed %s: %v", email, err)
+		}
+		log.Printf("Created user %d <%s>", user.ID, email)
+	}
+	log.Printf("Seeded with -seed=%d; sign in with password %q", *seed, *password)
+}
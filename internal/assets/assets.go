@@ -0,0 +1,66 @@
+// Package assets lets operators customize files compiled into the binary,
+// such as migrations and templates, without rebuilding it: a file in the
+// override directory replaces the embedded file of the same name, and new
+// files are added alongside the embedded ones.
+package assets
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Overlay serves files from dir where they exist and from base otherwise.
+// An empty dir returns base unchanged.
+func Overlay(base fs.FS, dir string) fs.FS {
+	if dir == "" {
+		return base
+	}
+	return overlay{top: os.DirFS(dir), base: base}
+}
+
+type overlay struct {
+	top  fs.FS
+	base fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	if f, err := o.top.Open(name); err == nil {
+		if info, err := f.Stat(); err == nil && !info.IsDir() {
+			return f, nil
+		}
+		f.Close()
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir lists both directories, with override files taking the place of
+// embedded ones.
+func (o overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	base, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+	top, topErr := fs.ReadDir(o.top, name)
+	if topErr != nil && !errors.Is(topErr, fs.ErrNotExist) {
+		return nil, topErr
+	}
+	if baseErr != nil && topErr != nil {
+		return nil, baseErr
+	}
+
+	merged := make(map[string]fs.DirEntry, len(base)+len(top))
+	for _, entry := range base {
+		merged[entry.Name()] = entry
+	}
+	for _, entry := range top {
+		merged[entry.Name()] = entry
+	}
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
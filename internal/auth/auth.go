@@ -1,58 +1,84 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"os"
+	"strings"
 	"time"
 
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key"
-	}
-	return []byte(secret)
+	return []byte(config.Get().Auth.JWTSecret)
 }
 
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	SessionID int    `json:"sid,omitempty"`
+	// GrantID and AdvisorID are set on delegated tokens, which let the
+	// advisor of an advisor grant read UserID's data.
+	GrantID   int `json:"grant,omitempty"`
+	AdvisorID int `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return hashArgon2id(password, argon2ParamsFromConfig(config.Get().Auth))
 }
 
 func CheckPasswordHash(password, hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		return checkArgon2id(password, hash)
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
 
-func GenerateJWT(userID int, email string) (string, error) {
+func GenerateJWT(userID int, email string, sessionID int) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(models.SessionSettings.AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTSecret())
+	return keys().sign(claims)
+}
+
+// GenerateDelegatedJWT issues a token for the advisor of grantID to read
+// ownerID's data until expiresAt. It is bound to the grant, not a session.
+func GenerateDelegatedJWT(ownerID int, email string, advisorID, grantID int, expiresAt time.Time) (string, error) {
+	claims := &Claims{
+		UserID:    ownerID,
+		Email:     email,
+		GrantID:   grantID,
+		AdvisorID: advisorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return keys().sign(claims)
 }
 
 func ValidateJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return getJWTSecret(), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys().verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -64,3 +90,22 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+func GenerateRandomToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func SignPayload(payload []byte) string {
+	mac := hmac.New(sha256.New, getJWTSecret())
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
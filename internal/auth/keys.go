@@ -0,0 +1,393 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/secrets"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyReloadInterval = 5 * time.Minute
+
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	private   interface{}
+	public    interface{}
+	CreatedAt time.Time
+}
+
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type KeyManager struct {
+	mu        sync.RWMutex
+	db        *sql.DB
+	algorithm string
+	rotation  time.Duration
+	keys      []*SigningKey
+	fallback  []*SigningKey
+}
+
+var keyManager *KeyManager
+
+func UseKeyManager(m *KeyManager) {
+	keyManager = m
+}
+
+func keys() *KeyManager {
+	if keyManager == nil {
+		return &KeyManager{algorithm: "HS256", fallback: staticHMACKeys()}
+	}
+	return keyManager
+}
+
+func JWKS() JWKSet {
+	return keys().JWKS()
+}
+
+func NewKeyManager(db *sql.DB) (*KeyManager, error) {
+	cfg := config.Get().Auth
+	algorithm := strings.ToUpper(cfg.JWTSigningAlg)
+	if algorithm != "HS256" && algorithm != "RS256" && algorithm != "EDDSA" {
+		return nil, fmt.Errorf("unsupported JWT_SIGNING_ALG %q", algorithm)
+	}
+	if algorithm == "EDDSA" {
+		algorithm = "EdDSA"
+	}
+
+	m := &KeyManager{db: db, algorithm: algorithm, rotation: cfg.JWTKeyRotationInterval, fallback: staticHMACKeys()}
+	if !m.managed() {
+		return m, nil
+	}
+
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	if len(m.keys) == 0 || m.keys[0].Algorithm != algorithm {
+		if err := m.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *KeyManager) managed() bool {
+	return m.algorithm != "HS256" || m.rotation > 0
+}
+
+func staticHMACKeys() []*SigningKey {
+	secrets := []string{string(getJWTSecret())}
+	for _, secret := range config.Get().Auth.JWTPreviousSecrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	keys := make([]*SigningKey, 0, len(secrets))
+	for _, secret := range secrets {
+		sum := sha256.Sum256([]byte(secret))
+		keys = append(keys, &SigningKey{ID: "hs-" + hex.EncodeToString(sum[:4]), Algorithm: "HS256", private: []byte(secret), public: []byte(secret)})
+	}
+	return keys
+}
+
+func (m *KeyManager) RunRotation(ctx context.Context) {
+	if !m.managed() {
+		return
+	}
+
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.reload(); err != nil {
+				log.Printf("Error reloading JWT signing keys: %v", err)
+				continue
+			}
+			if m.rotation > 0 && time.Since(m.current().CreatedAt) >= m.rotation {
+				if err := m.Rotate(); err != nil {
+					log.Printf("Error rotating JWT signing key: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func (m *KeyManager) Rotate() error {
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return err
+	}
+	kid := hex.EncodeToString(kidBytes)
+
+	encoded, err := generateKey(m.algorithm)
+	if err != nil {
+		return err
+	}
+	encoded, err = secrets.Encrypt(encoded)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only one instance rotates at a time; the others pick the key up on reload.
+	var locked bool
+	if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock(hashtext('jwt_signing_keys'))`).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return m.reload()
+	}
+
+	_, err = tx.Exec(`INSERT INTO jwt_signing_keys (kid, algorithm, private_key, created_at) VALUES ($1, $2, $3, NOW())`,
+		kid, m.algorithm, encoded)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Rotated JWT signing key, new kid %s (%s)", kid, m.algorithm)
+	return m.reload()
+}
+
+func (m *KeyManager) reload() error {
+	rows, err := m.db.Query(`SELECT kid, algorithm, private_key, created_at FROM jwt_signing_keys
+			  WHERE retired_at IS NULL ORDER BY created_at DESC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var keys []*SigningKey
+	var retired []string
+	for rows.Next() {
+		var kid, algorithm, encoded string
+		var createdAt time.Time
+		if err := rows.Scan(&kid, &algorithm, &encoded, &createdAt); err != nil {
+			return err
+		}
+
+		// A key stays valid for verification until every token it signed has expired.
+		if len(keys) > 0 && time.Since(keys[len(keys)-1].CreatedAt) > models.SessionSettings.AccessTokenTTL+time.Hour {
+			retired = append(retired, kid)
+			continue
+		}
+
+		encoded, err := secrets.Decrypt(encoded)
+		if err != nil {
+			log.Printf("Skipping unreadable JWT signing key %s: %v", kid, err)
+			continue
+		}
+		key, err := decodeKey(kid, algorithm, encoded, createdAt)
+		if err != nil {
+			log.Printf("Skipping unreadable JWT signing key %s: %v", kid, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, kid := range retired {
+		if _, err := m.db.Exec(`UPDATE jwt_signing_keys SET retired_at = NOW() WHERE kid = $1`, kid); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *KeyManager) current() *SigningKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.managed() && len(m.keys) > 0 {
+		return m.keys[0]
+	}
+	return m.fallback[0]
+}
+
+func (m *KeyManager) lookup(kid string) (*SigningKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, set := range [][]*SigningKey{m.keys, m.fallback} {
+		for _, key := range set {
+			if key.ID == kid {
+				return key, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (m *KeyManager) sign(claims jwt.Claims) (string, error) {
+	key := m.current()
+
+	var method jwt.SigningMethod
+	switch key.Algorithm {
+	case "RS256":
+		method = jwt.SigningMethodRS256
+	case "EdDSA":
+		method = jwt.SigningMethodEdDSA
+	default:
+		method = jwt.SigningMethodHS256
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+func (m *KeyManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Tokens issued before key IDs were introduced are signed with JWT_SECRET.
+		if token.Method.Alg() != "HS256" {
+			return nil, errors.New("token has no key ID")
+		}
+		return m.fallback[0].public, nil
+	}
+
+	key, ok := m.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+func (m *KeyManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kid: key.ID, Kty: "RSA", Alg: key.Algorithm, Use: "sig",
+				N: base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kid: key.ID, Kty: "OKP", Alg: key.Algorithm, Use: "sig", Crv: "Ed25519",
+				X: base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
+
+func generateKey(algorithm string) (string, error) {
+	var der []byte
+	var err error
+
+	switch algorithm {
+	case "RS256":
+		var key *rsa.PrivateKey
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err == nil {
+			der, err = x509.MarshalPKCS8PrivateKey(key)
+		}
+	case "EdDSA":
+		var key ed25519.PrivateKey
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+		if err == nil {
+			der, err = x509.MarshalPKCS8PrivateKey(key)
+		}
+	default:
+		secret := make([]byte, 64)
+		if _, err := rand.Read(secret); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(secret), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+func decodeKey(kid, algorithm, encoded string, createdAt time.Time) (*SigningKey, error) {
+	key := &SigningKey{ID: kid, Algorithm: algorithm, CreatedAt: createdAt}
+
+	if algorithm == "HS256" {
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		key.private, key.public = secret, secret
+		return key, nil
+	}
+
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.private, key.public = private, &private.PublicKey
+	case ed25519.PrivateKey:
+		key.private, key.public = private, private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
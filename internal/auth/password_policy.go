@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"personal-finance-tracker/internal/config"
+)
+
+type PasswordPolicy struct {
+	MinLength     int
+	MinScore      int
+	CheckBreached bool
+}
+
+type PasswordStrength struct {
+	Score    int      `json:"score"`
+	Guesses  float64  `json:"guesses"`
+	Feedback []string `json:"feedback,omitempty"`
+}
+
+type PasswordPolicyError struct {
+	Reasons []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Reasons, "; ")
+}
+
+var commonPasswords = []string{
+	"password", "123456", "12345678", "qwerty", "abc123", "111111", "123123", "admin", "letmein",
+	"welcome", "monkey", "dragon", "football", "baseball", "iloveyou", "master", "sunshine", "princess",
+	"shadow", "superman", "trustno1", "passw0rd", "qwertyuiop", "zaq12wsx", "starwars", "whatever",
+	"login", "hello", "freedom", "secret", "haslo", "zaq1@wsx", "polska", "kochamcie", "qazwsx",
+	"1q2w3e4r", "asdfgh", "zxcvbn", "michael", "jordan", "hunter", "ranger", "summer", "winter",
+	"finance", "money", "budget",
+}
+
+var keyboardSequences = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"01234567890",
+	"qwertyuiopasdfghjklzxcvbnm",
+	"1qaz2wsx3edc4rfv5tgb6yhn",
+}
+
+func PasswordPolicyFromConfig(cfg config.AuthConfig) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		MinScore:      cfg.PasswordMinScore,
+		CheckBreached: cfg.PasswordBreachCheck,
+	}
+}
+
+func (p PasswordPolicy) Validate(ctx context.Context, password string, userInputs ...string) (PasswordStrength, error) {
+	strength := EstimateStrength(password, userInputs...)
+
+	var reasons []string
+	if len([]rune(password)) < p.MinLength {
+		reasons = append(reasons, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if strength.Score < p.MinScore {
+		reasons = append(reasons, "is too easy to guess")
+	}
+
+	if p.CheckBreached && len(reasons) == 0 {
+		count, err := BreachCount(ctx, password)
+		if err != nil {
+			log.Printf("Breached password check unavailable: %v", err)
+		}
+		if count > 0 {
+			reasons = append(reasons, fmt.Sprintf("has appeared in %d known data breaches", count))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return strength, &PasswordPolicyError{Reasons: reasons}
+	}
+	return strength, nil
+}
+
+func EstimateStrength(password string, userInputs ...string) PasswordStrength {
+	var strength PasswordStrength
+	lower := strings.ToLower(password)
+
+	pool := 0
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if hasLower {
+		pool += 26
+	}
+	if hasUpper {
+		pool += 26
+	}
+	if hasDigit {
+		pool += 10
+	}
+	if hasSymbol {
+		pool += 33
+	}
+
+	// Characters that belong to a known word, a keyboard run or a repeat add
+	// almost nothing to the guess count, so they are discounted.
+	effective := float64(len([]rune(password)))
+	for _, word := range append(commonPasswords, userInputs...) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len(word) >= 3 && strings.Contains(lower, word) {
+			effective -= float64(len(word)) - 1
+			strength.Feedback = append(strength.Feedback, "avoid common words, names and personal details")
+			break
+		}
+	}
+	if run := longestSequence(lower); run >= 3 {
+		effective -= float64(run) - 1
+		strength.Feedback = append(strength.Feedback, "avoid keyboard patterns and sequences like abc or 123")
+	}
+	if run := longestRepeat(lower); run >= 3 {
+		effective -= float64(run) - 1
+		strength.Feedback = append(strength.Feedback, "avoid repeated characters")
+	}
+	if effective < 1 {
+		effective = 1
+	}
+	if pool == 0 {
+		pool = 1
+	}
+
+	strength.Guesses = math.Pow(float64(pool), effective)
+	switch {
+	case strength.Guesses < 1e3:
+		strength.Score = 0
+	case strength.Guesses < 1e6:
+		strength.Score = 1
+	case strength.Guesses < 1e8:
+		strength.Score = 2
+	case strength.Guesses < 1e10:
+		strength.Score = 3
+	default:
+		strength.Score = 4
+	}
+
+	if strength.Score < 3 && len(strength.Feedback) == 0 {
+		strength.Feedback = append(strength.Feedback, "use a longer password or a passphrase of several unrelated words")
+	}
+	return strength
+}
+
+func longestSequence(s string) int {
+	longest := 0
+	for _, seq := range keyboardSequences {
+		for i := 0; i < len(s); i++ {
+			for j := len(s); j > i+longest; j-- {
+				part := s[i:j]
+				if strings.Contains(seq, part) || strings.Contains(reverse(seq), part) {
+					longest = j - i
+					break
+				}
+			}
+		}
+	}
+	return longest
+}
+
+func longestRepeat(s string) int {
+	runes := []rune(s)
+	longest, current := 0, 0
+	for i := range runes {
+		if i > 0 && runes[i] == runes[i-1] {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+var breachClient = &http.Client{Timeout: 5 * time.Second}
+
+func BreachCount(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.pwnedpasswords.com/range/"+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "personal-finance-tracker")
+
+	resp, err := breachClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("breach check returned %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		hashSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && hashSuffix == suffix {
+			return strconv.Atoi(count)
+		}
+	}
+	return 0, scanner.Err()
+}
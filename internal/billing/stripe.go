@@ -0,0 +1,225 @@
+// Package billing talks to the Stripe API: customers, Checkout and billing
+// portal sessions, subscriptions and signed webhook events.
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+var ErrInvalidSignature = errors.New("invalid Stripe signature")
+
+type Client struct {
+	secretKey     string
+	webhookSecret string
+	baseURL       string
+	http          *http.Client
+}
+
+// NewClientFromConfig returns nil when no Stripe key is configured, which
+// leaves billing disabled.
+func NewClientFromConfig(cfg config.BillingConfig) *Client {
+	if cfg.StripeSecretKey == "" {
+		return nil
+	}
+	return &Client{
+		secretKey:     cfg.StripeSecretKey,
+		webhookSecret: cfg.StripeWebhookSecret,
+		baseURL:       "https://api.stripe.com/v1",
+		http:          &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type Event struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// Subscription is the part of a Stripe subscription billing acts on. Newer
+// API versions report the billing period on the items instead of the
+// subscription.
+type Subscription struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	Items             struct {
+		Data []struct {
+			CurrentPeriodEnd int64 `json:"current_period_end"`
+			Price            struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// PriceID is the price of the subscription's first item; subscriptions
+// started through Checkout have exactly one.
+func (s *Subscription) PriceID() string {
+	if len(s.Items.Data) == 0 {
+		return ""
+	}
+	return s.Items.Data[0].Price.ID
+}
+
+func (s *Subscription) PeriodEnd() *time.Time {
+	end := s.CurrentPeriodEnd
+	if end == 0 && len(s.Items.Data) > 0 {
+		end = s.Items.Data[0].CurrentPeriodEnd
+	}
+	if end == 0 {
+		return nil
+	}
+	t := time.Unix(end, 0).UTC()
+	return &t
+}
+
+func (c *Client) CreateCustomer(ctx context.Context, email string, userID int) (string, error) {
+	var customer struct {
+		ID string `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/customers", url.Values{
+		"email":             {email},
+		"metadata[user_id]": {strconv.Itoa(userID)},
+	}, &customer)
+	return customer.ID, err
+}
+
+// CreateCheckoutSession starts a Checkout page subscribing the customer to
+// the price and returns its URL.
+func (c *Client) CreateCheckoutSession(ctx context.Context, customerID, priceID, successURL, cancelURL string) (string, error) {
+	var session struct {
+		URL string `json:"url"`
+	}
+	err := c.do(ctx, http.MethodPost, "/checkout/sessions", url.Values{
+		"mode":                    {"subscription"},
+		"customer":                {customerID},
+		"line_items[0][price]":    {priceID},
+		"line_items[0][quantity]": {"1"},
+		"success_url":             {successURL},
+		"cancel_url":              {cancelURL},
+	}, &session)
+	return session.URL, err
+}
+
+// CreatePortalSession returns the URL of a billing portal page where the
+// customer manages their subscription, payment methods and invoices.
+func (c *Client) CreatePortalSession(ctx context.Context, customerID, returnURL string) (string, error) {
+	var session struct {
+		URL string `json:"url"`
+	}
+	err := c.do(ctx, http.MethodPost, "/billing_portal/sessions", url.Values{
+		"customer":   {customerID},
+		"return_url": {returnURL},
+	}, &session)
+	return session.URL, err
+}
+
+func (c *Client) Subscription(ctx context.Context, id string) (*Subscription, error) {
+	var subscription Subscription
+	if err := c.do(ctx, http.MethodGet, "/subscriptions/"+url.PathEscape(id), nil, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// CancelSubscription ends a subscription right away, without a refund.
+func (c *Client) CancelSubscription(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/subscriptions/"+url.PathEscape(id), nil, nil)
+}
+
+// ParseEvent checks the Stripe-Signature header of a webhook request and
+// decodes its event. Events signed more than tolerance ago are rejected.
+func (c *Client) ParseEvent(payload []byte, header string, tolerance time.Duration) (*Event, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > tolerance || age < -tolerance {
+		return nil, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decoding Stripe event: %w", err)
+	}
+	return &event, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, form url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.secretKey, "")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var envelope struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return fmt.Errorf("stripe %s %s failed with status %d: %s", method, path, resp.StatusCode, envelope.Error.Message)
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
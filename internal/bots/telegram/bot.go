@@ -0,0 +1,225 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/service"
+)
+
+type Bot struct {
+	client *Client
+	db     *sql.DB
+	svc    *service.Service
+}
+
+func NewBot(token string, db *sql.DB, svc *service.Service) *Bot {
+	return &Bot{
+		client: NewClient(token),
+		db:     db,
+		svc:    svc,
+	}
+}
+
+func (b *Bot) Name() string {
+	return "telegram"
+}
+
+func (b *Bot) Send(ctx context.Context, n notifications.Notification) error {
+	var chatID int64
+	err := b.db.QueryRowContext(ctx, `SELECT chat_id FROM telegram_links WHERE user_id = $1`, n.UserID).Scan(&chatID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.client.SendMessage(ctx, chatID, n.Title+"\n"+n.Message)
+}
+
+func (b *Bot) Run(ctx context.Context) {
+	log.Println("Telegram bot started")
+
+	offset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		updates, err := b.client.GetUpdates(ctx, offset, 30)
+		if err != nil {
+			log.Printf("Telegram getUpdates failed: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil && update.Message.Text != "" {
+				b.handleMessage(ctx, update.Message)
+			}
+		}
+	}
+}
+
+func (b *Bot) handleMessage(ctx context.Context, msg *Message) {
+	reply := b.respond(ctx, msg)
+	if reply == "" {
+		return
+	}
+
+	if err := b.client.SendMessage(ctx, msg.Chat.ID, reply); err != nil {
+		log.Printf("Telegram sendMessage failed: %v", err)
+	}
+}
+
+func (b *Bot) respond(ctx context.Context, msg *Message) string {
+	text := strings.TrimSpace(msg.Text)
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	command := fields[0]
+
+	if command == "/start" || command == "/link" {
+		return b.link(ctx, msg, strings.TrimSpace(strings.TrimPrefix(text, command)))
+	}
+
+	userID, err := b.userForChat(ctx, msg.Chat.ID)
+	if err == sql.ErrNoRows {
+		return "This chat is not linked yet. Generate a code in the app and send /start <code>."
+	}
+	if err != nil {
+		log.Printf("Telegram user lookup failed: %v", err)
+		return "Something went wrong, please try again later."
+	}
+
+	switch command {
+	case "/help":
+		return helpText
+	case "/balance":
+		return b.balances(ctx, userID)
+	case "/unlink":
+		if _, err := b.db.ExecContext(ctx, `DELETE FROM telegram_links WHERE user_id = $1`, userID); err != nil {
+			return "Failed to unlink this chat."
+		}
+		return "Chat unlinked."
+	}
+
+	entry, ok := ParseQuickEntry(text)
+	if !ok {
+		return "I did not understand that. " + helpText
+	}
+
+	return b.addTransaction(ctx, userID, entry)
+}
+
+func (b *Bot) link(ctx context.Context, msg *Message, code string) string {
+	if code == "" {
+		return "Send /start <code> with the link code from the app."
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "Something went wrong, please try again later."
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `DELETE FROM telegram_link_codes WHERE code = $1 AND expires_at > NOW() RETURNING user_id`, code).Scan(&userID)
+	if err != nil {
+		return "This link code is invalid or has expired."
+	}
+
+	username := ""
+	if msg.From != nil {
+		username = msg.From.Username
+	}
+
+	query := `INSERT INTO telegram_links (user_id, chat_id, username, linked_at) VALUES ($1, $2, $3, NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET chat_id = EXCLUDED.chat_id, username = EXCLUDED.username, linked_at = NOW()`
+	if _, err := tx.ExecContext(ctx, query, userID, msg.Chat.ID, username); err != nil {
+		log.Printf("Telegram link failed: %v", err)
+		return "This chat is already linked to another account."
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "Something went wrong, please try again later."
+	}
+
+	return "Chat linked! " + helpText
+}
+
+func (b *Bot) userForChat(ctx context.Context, chatID int64) (int, error) {
+	var userID int
+	err := b.db.QueryRowContext(ctx, `SELECT user_id FROM telegram_links WHERE chat_id = $1`, chatID).Scan(&userID)
+	return userID, err
+}
+
+func (b *Bot) balances(ctx context.Context, userID int) string {
+	accounts, err := b.svc.GetAccounts(ctx, userID)
+	if err != nil {
+		return "Failed to load balances."
+	}
+	if len(accounts) == 0 {
+		return "You have no accounts yet."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Balances:\n")
+	for _, account := range accounts {
+		sb.WriteString(fmt.Sprintf("%s: %.2f %s\n", account.Name, account.Balance, account.Currency))
+	}
+	return sb.String()
+}
+
+func (b *Bot) addTransaction(ctx context.Context, userID int, entry QuickEntry) string {
+	accountID, err := b.svc.DefaultAccountID(ctx, userID)
+	if err != nil {
+		return "Create an account in the app first."
+	}
+
+	categoryID, err := b.svc.SuggestCategory(ctx, userID, entry.Description, entry.Amount, entry.Type)
+	if err != nil {
+		return "Create a category in the app first."
+	}
+
+	transaction := models.Transaction{
+		UserID:      userID,
+		AccountID:   accountID,
+		CategoryID:  categoryID,
+		Amount:      entry.Amount,
+		Type:        entry.Type,
+		Description: entry.Description,
+		Date:        time.Now(),
+	}
+
+	if err := b.svc.CreateTransaction(ctx, &transaction, false); err != nil {
+		var capErr *service.BudgetCapError
+		if errors.As(err, &capErr) {
+			return fmt.Sprintf("Not saved: only %.2f left in the %s budget this month.", capErr.Status.Remaining, capErr.Status.CategoryName)
+		}
+		var quotaErr *service.QuotaError
+		if errors.As(err, &quotaErr) {
+			return fmt.Sprintf("Not saved: your plan allows %d transactions a month.", quotaErr.Limit)
+		}
+		log.Printf("Telegram transaction failed: %v", err)
+		return "Failed to save the transaction."
+	}
+
+	return fmt.Sprintf("Saved %s %.2f (%s).", entry.Type, entry.Amount, entry.Description)
+}
+
+const helpText = `Send "coffee 4.50" to add an expense or "+2500 salary" for income.
+/balance - account balances
+/unlink - disconnect this chat`
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type QuickEntry struct {
+	Description string
+	Amount      float64
+	Type        string
+}
+
+var (
+	amountLastPattern  = regexp.MustCompile(`^(.+?)\s+([+-]?\d+(?:[.,]\d{1,2})?)$`)
+	amountFirstPattern = regexp.MustCompile(`^([+-]?\d+(?:[.,]\d{1,2})?)\s+(.+)$`)
+)
+
+func ParseQuickEntry(text string) (QuickEntry, bool) {
+	text = strings.TrimSpace(text)
+
+	var description, amountText string
+	if match := amountLastPattern.FindStringSubmatch(text); match != nil {
+		description, amountText = match[1], match[2]
+	} else if match := amountFirstPattern.FindStringSubmatch(text); match != nil {
+		amountText, description = match[1], match[2]
+	} else {
+		return QuickEntry{}, false
+	}
+
+	entry := QuickEntry{Description: strings.TrimSpace(description), Type: "expense"}
+	if strings.HasPrefix(amountText, "+") {
+		entry.Type = "income"
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimLeft(strings.Replace(amountText, ",", ".", 1), "+-"), 64)
+	if err != nil || amount <= 0 {
+		return QuickEntry{}, false
+	}
+	entry.Amount = amount
+
+	return entry, true
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+// Pinger is implemented by stores backed by an external server, so health
+// checks can tell whether it is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) (string, error)
+}
+
+// NewFromConfig connects to the configured Redis URL when it is set so state
+// is shared between instances, and falls back to a process-local store
+// otherwise.
+func NewFromConfig(cfg config.RedisConfig, maxEntries int) Store {
+	if cfg.URL == "" {
+		return NewMemory(maxEntries)
+	}
+
+	store, err := NewRedis(cfg.URL, cfg.KeyPrefix)
+	if err != nil {
+		log.Printf("Redis unavailable, using in-memory cache: %v", err)
+		return NewMemory(maxEntries)
+	}
+	log.Println("Using Redis for cache and shared state")
+	return store
+}
+
+// ResponsePrefix marks keys holding cached responses. They are the only keys
+// the in-memory store evicts when it is full.
+const ResponsePrefix = "response:"
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// Memory is a process-local store. Cached responses (keys under
+// ResponsePrefix) are capped at maxSize and evicted least recently used
+// first. Every other key is state whose loss changes behaviour, such as cache
+// versions, session revocations, rate limit counters and idempotency keys;
+// it is never evicted and is only dropped once it expires.
+type Memory struct {
+	mu        sync.Mutex
+	responses map[string]*list.Element
+	recent    *list.List
+	state     map[string]*entry
+	maxSize   int
+	sweepAt   int
+}
+
+func NewMemory(maxSize int) *Memory {
+	return &Memory{
+		responses: make(map[string]*list.Element),
+		recent:    list.New(),
+		state:     make(map[string]*entry),
+		maxSize:   maxSize,
+		sweepAt:   maxSize,
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(key)
+	if e == nil {
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(key, value, expiry(ttl))
+	return nil
+}
+
+func (m *Memory) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lookup(key) != nil {
+		return false, nil
+	}
+	m.put(key, value, expiry(ttl))
+	return true, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	m.remove(key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	expiresAt := expiry(ttl)
+	if e := m.lookup(key); e != nil {
+		n, _ = strconv.ParseInt(string(e.value), 10, 64)
+		if ttl > 0 {
+			expiresAt = e.expiresAt
+		}
+	}
+	n++
+	m.put(key, []byte(strconv.FormatInt(n, 10)), expiresAt)
+	return n, nil
+}
+
+// lookup returns the live entry for key, dropping it if it has expired and
+// marking a response as recently used. Called with the lock held.
+func (m *Memory) lookup(key string) *entry {
+	var e *entry
+	element, isResponse := m.responses[key]
+	if isResponse {
+		e = element.Value.(*entry)
+	} else if e = m.state[key]; e == nil {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		m.remove(key)
+		return nil
+	}
+	if isResponse {
+		m.recent.MoveToFront(element)
+	}
+	return e
+}
+
+// put stores an entry, evicting the least recently used responses beyond
+// maxSize. Called with the lock held.
+func (m *Memory) put(key string, value []byte, expiresAt time.Time) {
+	e := &entry{key: key, value: value, expiresAt: expiresAt}
+	if !strings.HasPrefix(key, ResponsePrefix) {
+		m.state[key] = e
+		if m.maxSize > 0 && len(m.state) >= m.sweepAt {
+			m.sweep()
+		}
+		return
+	}
+
+	if element, ok := m.responses[key]; ok {
+		element.Value = e
+		m.recent.MoveToFront(element)
+		return
+	}
+	m.responses[key] = m.recent.PushFront(e)
+	for m.maxSize > 0 && len(m.responses) > m.maxSize {
+		oldest := m.recent.Back()
+		m.recent.Remove(oldest)
+		delete(m.responses, oldest.Value.(*entry).key)
+	}
+}
+
+func (m *Memory) remove(key string) {
+	if element, ok := m.responses[key]; ok {
+		m.recent.Remove(element)
+		delete(m.responses, key)
+		return
+	}
+	delete(m.state, key)
+}
+
+// sweep drops expired state and sets the size at which to sweep again, so
+// state that keeps expiring does not pile up and a large live set is not
+// swept on every write. Called with the lock held.
+func (m *Memory) sweep() {
+	now := time.Now()
+	for key, e := range m.state {
+		if e.expired(now) {
+			delete(m.state, key)
+		}
+	}
+	m.sweepAt = 2 * len(m.state)
+	if m.sweepAt < m.maxSize {
+		m.sweepAt = m.maxSize
+	}
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
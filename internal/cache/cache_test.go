@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryEvictsLeastRecentlyUsedResponses(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	m.Set(ctx, ResponsePrefix+"a", []byte("a"), time.Minute)
+	m.Set(ctx, ResponsePrefix+"b", []byte("b"), time.Minute)
+	m.Get(ctx, ResponsePrefix+"a")
+	m.Set(ctx, ResponsePrefix+"c", []byte("c"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := m.Get(ctx, ResponsePrefix+key); ok != want {
+			t.Errorf("response %s cached = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestMemoryKeepsStateWhenFull(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	m.Set(ctx, "session:1:revoked", []byte("1"), time.Hour)
+	m.Incr(ctx, "user:1:version", 0)
+	m.Incr(ctx, "ratelimit:api:ip:1", time.Minute)
+	for i := 0; i < 10; i++ {
+		m.Set(ctx, ResponsePrefix+strconv.Itoa(i), []byte("{}"), time.Minute)
+		m.SetNX(ctx, "idempotency:1:"+strconv.Itoa(i), []byte("{}"), time.Minute)
+	}
+
+	for _, key := range []string{"session:1:revoked", "user:1:version", "ratelimit:api:ip:1", "idempotency:1:0"} {
+		if _, ok, _ := m.Get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if n, _ := m.Incr(ctx, "ratelimit:api:ip:1", time.Minute); n != 2 {
+		t.Errorf("rate limit counter = %d, want 2", n)
+	}
+}
+
+func TestMemoryDropsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	m.Set(ctx, "session:1:revoked", []byte("1"), time.Nanosecond)
+	m.Set(ctx, ResponsePrefix+"a", []byte("a"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	for _, key := range []string{"session:1:revoked", ResponsePrefix + "a"} {
+		if _, ok, _ := m.Get(ctx, key); ok {
+			t.Errorf("%s outlived its TTL", key)
+		}
+	}
+	if ok, _ := m.SetNX(ctx, "session:1:revoked", []byte("1"), time.Minute); !ok {
+		t.Error("SetNX refused a key whose entry had expired")
+	}
+}
@@ -0,0 +1,146 @@
+package classifier
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Example struct {
+	CategoryID  int
+	Description string
+	Amount      float64
+}
+
+type Prediction struct {
+	CategoryID int
+	Confidence float64
+}
+
+type Model struct {
+	docs        int
+	classDocs   map[int]int
+	tokenCounts map[int]map[string]int
+	totalTokens map[int]int
+	vocab       map[string]struct{}
+}
+
+func Train(examples []Example) *Model {
+	m := &Model{
+		classDocs:   make(map[int]int),
+		tokenCounts: make(map[int]map[string]int),
+		totalTokens: make(map[int]int),
+		vocab:       make(map[string]struct{}),
+	}
+
+	for _, ex := range examples {
+		tokens := Features(ex.Description, ex.Amount)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		m.docs++
+		m.classDocs[ex.CategoryID]++
+		if m.tokenCounts[ex.CategoryID] == nil {
+			m.tokenCounts[ex.CategoryID] = make(map[string]int)
+		}
+		for _, token := range tokens {
+			m.tokenCounts[ex.CategoryID][token]++
+			m.totalTokens[ex.CategoryID]++
+			m.vocab[token] = struct{}{}
+		}
+	}
+
+	return m
+}
+
+func (m *Model) Empty() bool {
+	return m.docs == 0
+}
+
+func (m *Model) Predict(description string, amount float64) []Prediction {
+	if m.Empty() {
+		return nil
+	}
+
+	tokens := Features(description, amount)
+	vocabSize := float64(len(m.vocab))
+
+	scores := make(map[int]float64, len(m.classDocs))
+	for categoryID, docs := range m.classDocs {
+		score := math.Log(float64(docs) / float64(m.docs))
+		denominator := float64(m.totalTokens[categoryID]) + vocabSize
+		for _, token := range tokens {
+			count := float64(m.tokenCounts[categoryID][token])
+			score += math.Log((count + 1) / denominator)
+		}
+		scores[categoryID] = score
+	}
+
+	maxScore := math.Inf(-1)
+	for _, score := range scores {
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	var sum float64
+	predictions := make([]Prediction, 0, len(scores))
+	for categoryID, score := range scores {
+		p := math.Exp(score - maxScore)
+		sum += p
+		predictions = append(predictions, Prediction{CategoryID: categoryID, Confidence: p})
+	}
+
+	for i := range predictions {
+		predictions[i].Confidence /= sum
+	}
+
+	sort.Slice(predictions, func(i, j int) bool {
+		if predictions[i].Confidence == predictions[j].Confidence {
+			return predictions[i].CategoryID < predictions[j].CategoryID
+		}
+		return predictions[i].Confidence > predictions[j].Confidence
+	})
+
+	return predictions
+}
+
+func Features(description string, amount float64) []string {
+	tokens := Tokenize(description)
+	if amount != 0 {
+		tokens = append(tokens, AmountBucket(amount))
+	}
+	return tokens
+}
+
+func Tokenize(description string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || isNumeric(field) {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+func AmountBucket(amount float64) string {
+	bucket := int(math.Log2(math.Abs(amount) + 1))
+	return "__amount_" + strconv.Itoa(bucket)
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,40 +3,88 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"strings"
 
-	_ "github.com/lib/pq"
+	"personal-finance-tracker/internal/config"
+
+	"github.com/lib/pq"
 )
 
 func Initialize() (*sql.DB, error) {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "postgres")
-	dbname := getEnv("DB_NAME", "finance_tracker")
-	sslmode := getEnv("DB_SSLMODE", "disable")
+	return open(DSN())
+}
+
+// DSN returns the connection string of the primary database, for
+// connections that cannot come from the pool, such as LISTEN.
+func DSN() string {
+	cfg := config.Get().Database
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+}
+
+// ClientEnv returns the connection settings as libpq environment variables
+// for command-line tools such as pg_dump.
+func ClientEnv() []string {
+	cfg := config.Get().Database
+	return []string{
+		"PGHOST=" + cfg.Host,
+		"PGPORT=" + cfg.Port,
+		"PGUSER=" + cfg.User,
+		"PGPASSWORD=" + cfg.Password,
+		"PGDATABASE=" + cfg.Name,
+		"PGSSLMODE=" + cfg.SSLMode,
+	}
+}
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
+func Name() string {
+	return config.Get().Database.Name
+}
 
-	db, err := sql.Open("postgres", dsn)
+// InitializeReplica connects to the read-only replica in DB_REPLICA_DSN. It
+// returns nil when no replica is configured.
+func InitializeReplica() (*sql.DB, error) {
+	dsn := config.Get().Database.ReplicaDSN
+	if dsn == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := pq.ParseURL(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_REPLICA_DSN: %w", err)
+		}
+		dsn = parsed
+	}
+	return open(dsn)
+}
+
+func open(dsn string) (*sql.DB, error) {
+	cfg := config.Get().Database
+
+	// Sessions run in UTC so timestamps never depend on the server's zone.
+	dsn += " timezone=UTC"
+	if timeout := cfg.StatementTimeout; timeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	db := openDB(&slowQueryConnector{
+		Connector: connector,
+		threshold: cfg.SlowQueryThreshold,
+	})
+
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	return db, nil
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
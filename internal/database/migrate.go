@@ -0,0 +1,147 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+var ErrUntrackedSchema = errors.New("database has tables but no migration history; record the migrations it already has with cmd/migrate -baseline")
+
+// Migrate applies the .sql files in files that are not yet recorded in
+// schema_migrations, in name order, each in its own transaction. Instances
+// starting together serialize on an advisory lock, so every migration runs
+// once. It returns the names of the migrations it applied.
+func Migrate(db *sql.DB, files fs.FS) ([]string, error) {
+	if err := createMigrationTable(db); err != nil {
+		return nil, err
+	}
+	names, err := migrationNames(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		return nil, err
+	}
+	if recorded == 0 {
+		var existing bool
+		if err := db.QueryRow(`SELECT to_regclass('users') IS NOT NULL`).Scan(&existing); err != nil {
+			return nil, err
+		}
+		if existing {
+			return nil, ErrUntrackedSchema
+		}
+	}
+
+	var applied []string
+	for _, name := range names {
+		ran, err := applyMigration(db, files, name)
+		if err != nil {
+			return applied, fmt.Errorf("migration %s: %w", name, err)
+		}
+		if ran {
+			applied = append(applied, name)
+		}
+	}
+	return applied, nil
+}
+
+// Baseline records the migrations numbered up to and including through
+// (such as 059) as applied without running them, for databases whose schema
+// was created before migrations were tracked, e.g. by the Postgres
+// container's init scripts.
+func Baseline(db *sql.DB, files fs.FS, through string) ([]string, error) {
+	if err := createMigrationTable(db); err != nil {
+		return nil, err
+	}
+	names, err := migrationNames(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded []string
+	for _, name := range names {
+		if number(name) > number(through) {
+			break
+		}
+		result, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, NOW()) ON CONFLICT DO NOTHING`, version(name))
+		if err != nil {
+			return recorded, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			recorded = append(recorded, name)
+		}
+	}
+	return recorded, nil
+}
+
+func createMigrationTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`)
+	return err
+}
+
+func migrationNames(files fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && path.Ext(entry.Name()) == ".sql" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func version(name string) string {
+	return strings.TrimSuffix(name, ".sql")
+}
+
+// number is the NNN prefix that orders migrations.
+func number(name string) string {
+	n, _, _ := strings.Cut(version(name), "_")
+	return n
+}
+
+func applyMigration(db *sql.DB, files fs.FS, name string) (bool, error) {
+	script, err := fs.ReadFile(files, name)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))`); err != nil {
+		return false, err
+	}
+	var done bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version(name)).Scan(&done); err != nil {
+		return false, err
+	}
+	if done {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, NOW())`, version(name)); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
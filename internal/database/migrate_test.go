@@ -0,0 +1,164 @@
+package database_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/testdb"
+	"personal-finance-tracker/migrations"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	db := testdb.Empty(t)
+
+	applied, err := database.Migrate(db, migrations.Files)
+	if err != nil {
+		t.Fatalf("Migrate() on an empty database: %v", err)
+	}
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(names) {
+		t.Errorf("Migrate() applied %d migrations, want %d", len(applied), len(names))
+	}
+
+	for _, table := range []string{"users", "accounts", "categories", "transactions", "budget_rules"} {
+		var exists bool
+		if err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if !exists {
+			t.Errorf("table %s was not created", table)
+		}
+	}
+
+	applied, err = database.Migrate(db, migrations.Files)
+	if err != nil {
+		t.Fatalf("Migrate() on a migrated database: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Migrate() on a migrated database applied %v, want none", applied)
+	}
+}
+
+func TestMigrateUntrackedSchema(t *testing.T) {
+	db := testdb.Empty(t)
+	if _, err := db.Exec(`CREATE TABLE users (id SERIAL PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := database.Migrate(db, migrations.Files); err != database.ErrUntrackedSchema {
+		t.Fatalf("Migrate() = %v, want ErrUntrackedSchema", err)
+	}
+}
+
+// TestMigrateRepairsCrossTenantRows writes rows that cross users, as the API
+// could before the tenant foreign keys existed, and checks that 058 repairs
+// them and validates the keys.
+func TestMigrateRepairsCrossTenantRows(t *testing.T) {
+	db := testdb.Empty(t)
+
+	before := fstest.MapFS{}
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if name >= "058" {
+			continue
+		}
+		data, err := fs.ReadFile(migrations.Files, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before[name] = &fstest.MapFile{Data: data}
+	}
+	if _, err := database.Migrate(db, before); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replica mode skips the foreign key triggers, as the legacy rows did.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := []string{
+		`SET LOCAL session_replication_role = replica`,
+		`INSERT INTO users (id, email, password_hash) VALUES (1, 'ada@example.com', ''), (2, 'bob@example.com', '')`,
+		`INSERT INTO accounts (id, user_id, name, type) VALUES (1, 1, 'Checking', 'checking')`,
+		`INSERT INTO categories (id, user_id, name, type) VALUES (1, 1, 'Groceries', 'expense'), (2, 2, 'Books', 'expense')`,
+		`INSERT INTO categories (id, user_id, name, type, parent_id) VALUES (3, 2, 'Comics', 'expense', 1)`,
+		`INSERT INTO transactions (id, user_id, account_id, category_id, amount, type, date, client_id) VALUES
+			(1, 2, 1, 2, 10, 'expense', '2026-03-14', 'bob-phone-1'),
+			(2, 1, 1, 2, 20, 'expense', '2026-03-14', 'ada-phone-1')`,
+		`SELECT setval('users_id_seq', 2), setval('accounts_id_seq', 1), setval('categories_id_seq', 3), setval('transactions_id_seq', 2)`,
+	}
+	for _, query := range legacy {
+		if _, err := tx.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := database.Migrate(db, migrations.Files)
+	if err != nil {
+		t.Fatalf("Migrate() over cross-tenant rows: %v", err)
+	}
+	if len(applied) != len(names)-len(before) {
+		t.Errorf("Migrate() applied %v", applied)
+	}
+
+	var books int
+	if err := db.QueryRow(`SELECT id FROM categories WHERE user_id = 1 AND name = 'Books' AND type = 'expense'`).Scan(&books); err != nil {
+		t.Fatalf("Ada's copy of Books: %v", err)
+	}
+	want := map[int]struct {
+		userID   int
+		clientID *string
+	}{
+		1: {1, nil},
+		2: {1, strPtr("ada-phone-1")},
+	}
+	for id, w := range want {
+		var userID, categoryID int
+		var clientID *string
+		if err := db.QueryRow(`SELECT user_id, category_id, client_id FROM transactions WHERE id = $1`, id).Scan(&userID, &categoryID, &clientID); err != nil {
+			t.Fatal(err)
+		}
+		if userID != w.userID || categoryID != books || !equalStr(clientID, w.clientID) {
+			t.Errorf("transaction %d: user %d, category %d, client_id %v; want user %d, category %d, client_id %v",
+				id, userID, categoryID, clientID, w.userID, books, w.clientID)
+		}
+	}
+
+	var parentID *int
+	if err := db.QueryRow(`SELECT parent_id FROM categories WHERE id = 3`).Scan(&parentID); err != nil {
+		t.Fatal(err)
+	}
+	if parentID != nil {
+		t.Errorf("Comics is still nested under category %d of another user", *parentID)
+	}
+
+	var unvalidated int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pg_constraint WHERE conname LIKE '%\_tenant' AND NOT convalidated`).Scan(&unvalidated); err != nil {
+		t.Fatal(err)
+	}
+	if unvalidated != 0 {
+		t.Errorf("%d tenant constraints are not validated", unvalidated)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func equalStr(a, b *string) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
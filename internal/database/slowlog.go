@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"time"
+)
+
+type pgConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// slowQueryConnector wraps the pq connector so every statement, including
+// those run inside transactions, is timed and logged when it is slow.
+type slowQueryConnector struct {
+	driver.Connector
+	threshold time.Duration
+}
+
+func (c *slowQueryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pg, ok := conn.(pgConn)
+	if !ok || c.threshold <= 0 {
+		return conn, nil
+	}
+	return &slowQueryConn{pgConn: pg, threshold: c.threshold}, nil
+}
+
+type slowQueryConn struct {
+	pgConn
+	threshold time.Duration
+}
+
+func (c *slowQueryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.pgConn.QueryContext(ctx, query, args)
+	c.observe(start, query, len(args), err)
+	return rows, err
+}
+
+func (c *slowQueryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.pgConn.ExecContext(ctx, query, args)
+	c.observe(start, query, len(args), err)
+	return result, err
+}
+
+func (c *slowQueryConn) observe(start time.Time, query string, args int, err error) {
+	elapsed := time.Since(start)
+	if elapsed < c.threshold {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	log.Printf("Slow query (%s, %d args, %s): %s", elapsed.Round(time.Millisecond), args, status, compactQuery(query))
+}
+
+func compactQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > 500 {
+		query = query[:500] + "..."
+	}
+	return query
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"personal-finance-tracker/internal/tracing"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// openDB opens a pool over connector. When tracing is on, statements run
+// for a traced request or job are recorded as client spans; those run
+// outside one, such as pool health checks, are not.
+func openDB(connector driver.Connector) *sql.DB {
+	if !tracing.Enabled() {
+		return sql.OpenDB(connector)
+	}
+	return otelsql.OpenDB(connector,
+		otelsql.WithAttributes(attribute.String("db.system", "postgresql")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			OmitConnectorConnect: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}))
+}
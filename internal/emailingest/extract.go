@@ -0,0 +1,168 @@
+package emailingest
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Draft struct {
+	Merchant    string
+	Amount      float64
+	Type        string
+	Description string
+	Date        time.Time
+}
+
+type Extractor struct {
+	Merchant string
+	Match    func(Email) bool
+	Totals   []*regexp.Regexp
+}
+
+var (
+	genericTotals = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(?:grand total|order total|total charged|amount due|total due|total)\s*:?\s*(?:[A-Z]{3}|[$€£]|zł)?\s*(\d[\d ,.]*\d)`),
+		regexp.MustCompile(`(?i)(?:do zapłaty|razem|suma)\s*:?\s*(\d[\d ,.]*\d)`),
+	}
+
+	Extractors = []Extractor{
+		{
+			Merchant: "Amazon",
+			Match:    fromOrSubjectContains("amazon"),
+			Totals: []*regexp.Regexp{
+				regexp.MustCompile(`(?i)order total\s*:?\s*(?:[A-Z]{3}|[$€£])?\s*(\d[\d ,.]*\d)`),
+				regexp.MustCompile(`(?i)grand total\s*:?\s*(?:[A-Z]{3}|[$€£])?\s*(\d[\d ,.]*\d)`),
+			},
+		},
+		{
+			Merchant: "Uber",
+			Match:    fromOrSubjectContains("uber"),
+			Totals: []*regexp.Regexp{
+				regexp.MustCompile(`(?i)total\s*:?\s*(?:[A-Z]{3}|[$€£]|zł|PLN)?\s*(\d[\d ,.]*\d)`),
+			},
+		},
+		{
+			Merchant: "",
+			Match:    subjectContainsAny("invoice", "bill", "statement", "faktura", "rachunek"),
+			Totals: []*regexp.Regexp{
+				regexp.MustCompile(`(?i)(?:amount due|total due|balance due|do zapłaty)\s*:?\s*(?:[A-Z]{3}|[$€£]|zł)?\s*(\d[\d ,.]*\d)`),
+			},
+		},
+	}
+)
+
+func Extract(email Email) (Draft, bool) {
+	draft := Draft{
+		Type:        "expense",
+		Date:        email.Date,
+		Description: email.Subject,
+		Merchant:    senderName(email.From),
+	}
+
+	for _, extractor := range Extractors {
+		if !extractor.Match(email) {
+			continue
+		}
+		if extractor.Merchant != "" {
+			draft.Merchant = extractor.Merchant
+		}
+		if amount, ok := findAmount(email.Body, extractor.Totals); ok {
+			draft.Amount = amount
+			return draft, true
+		}
+	}
+
+	if amount, ok := findAmount(email.Body, genericTotals); ok {
+		draft.Amount = amount
+		return draft, true
+	}
+
+	return draft, false
+}
+
+func findAmount(body string, patterns []*regexp.Regexp) (float64, bool) {
+	for _, pattern := range patterns {
+		if match := pattern.FindStringSubmatch(body); match != nil {
+			if amount, ok := parseAmount(match[1]); ok && amount > 0 {
+				return amount, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func parseAmount(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+	decimalAt := lastDot
+	if lastComma > lastDot {
+		decimalAt = lastComma
+	}
+
+	if decimalAt >= 0 && len(value)-decimalAt-1 == 2 {
+		whole := strings.NewReplacer(".", "", ",", "").Replace(value[:decimalAt])
+		value = whole + "." + value[decimalAt+1:]
+	} else {
+		value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	return amount, err == nil
+}
+
+func senderName(from string) string {
+	if idx := strings.Index(from, "<"); idx > 0 {
+		return strings.Trim(strings.TrimSpace(from[:idx]), `"`)
+	}
+	if idx := strings.Index(from, "@"); idx >= 0 {
+		domain := from[idx+1:]
+		if dot := strings.Index(domain, "."); dot > 0 {
+			domain = domain[:dot]
+		}
+		domain = strings.Trim(domain, "> ")
+		if domain != "" {
+			domain = strings.ToUpper(domain[:1]) + domain[1:]
+		}
+		return domain
+	}
+	return strings.TrimSpace(from)
+}
+
+func fromOrSubjectContains(keyword string) func(Email) bool {
+	return func(email Email) bool {
+		return strings.Contains(strings.ToLower(email.From), keyword) ||
+			strings.Contains(strings.ToLower(email.Subject), keyword)
+	}
+}
+
+func subjectContainsAny(keywords ...string) func(Email) bool {
+	return func(email Email) bool {
+		subject := strings.ToLower(email.Subject)
+		for _, keyword := range keywords {
+			if strings.Contains(subject, keyword) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func decodeBase64(data []byte) []byte {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' {
+			return -1
+		}
+		return r
+	}, string(data))
+
+	decoded, err := base64.StdEncoding.DecodeString(cleaned)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
@@ -0,0 +1,342 @@
+// Package errorreport sends errors and panics to a Sentry-compatible
+// service (Sentry, GlitchTip, Bugsink, ...) through its store endpoint.
+// Reports carry the request they happened in with credentials scrubbed;
+// bodies are never sent. Without a reporter in use Capture does nothing.
+package errorreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/redact"
+)
+
+const filtered = "[Filtered]"
+
+// Report describes one error. Request, when set, is scrubbed before it is
+// sent.
+type Report struct {
+	Level     string
+	Message   string
+	ErrorType string
+	Stack     []uintptr
+	Request   *http.Request
+	Route     string
+	UserID    int
+	RequestID string
+	TraceID   string
+}
+
+type Reporter struct {
+	storeURL    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	http        *http.Client
+	queue       chan event
+}
+
+// NewFromConfig returns nil when no DSN is configured, which leaves error
+// reporting off. The DSN has the form https://<key>@<host>/<project>.
+func NewFromConfig(cfg config.SentryConfig) (*Reporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil || dsn.User == nil || dsn.User.Username() == "" || dsn.Host == "" {
+		return nil, fmt.Errorf("SENTRY_DSN must look like https://<key>@<host>/<project>")
+	}
+	path := strings.Trim(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project, prefix := path[slash+1:], ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("SENTRY_DSN has no project ID")
+	}
+
+	hostname, _ := os.Hostname()
+	r := &Reporter{
+		storeURL:    fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, prefix, project),
+		auth:        "Sentry sentry_version=7, sentry_client=personal-finance-tracker/1.0, sentry_key=" + dsn.User.Username(),
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  hostname,
+		http:        &http.Client{Timeout: models.ErrorReportSettings.Timeout},
+		queue:       make(chan event, models.ErrorReportSettings.QueueSize),
+	}
+	if secret, ok := dsn.User.Password(); ok {
+		r.auth += ", sentry_secret=" + secret
+	}
+	go r.run()
+	return r, nil
+}
+
+var (
+	mu     sync.RWMutex
+	active *Reporter
+)
+
+func Use(r *Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = r
+}
+
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active != nil
+}
+
+// Capture queues a report for sending. Reports that arrive while the queue
+// is full are dropped so that a burst of failures cannot pile up memory.
+func Capture(report Report) {
+	mu.RLock()
+	r := active
+	mu.RUnlock()
+	if r == nil {
+		return
+	}
+
+	select {
+	case r.queue <- r.event(report):
+	default:
+		log.Printf("Error report dropped, queue full: %s", report.Message)
+	}
+}
+
+// CaptureError reports err with the stack of the caller.
+func CaptureError(err error, report Report) {
+	if err == nil {
+		return
+	}
+	report.Message = err.Error()
+	report.ErrorType = fmt.Sprintf("%T", err)
+	report.Stack = Callers(1)
+	Capture(report)
+}
+
+// Callers returns the stack of the caller of Callers, skipping skip more
+// frames.
+func Callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	return pcs[:runtime.Callers(skip+2, pcs)]
+}
+
+func (r *Reporter) run() {
+	for e := range r.queue {
+		if err := r.send(e); err != nil {
+			log.Printf("Failed to send error report %s: %v", e.EventID, err)
+		}
+	}
+}
+
+func (r *Reporter) send(e event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error reporting service answered %s", resp.Status)
+	}
+	return nil
+}
+
+// event is the Sentry event payload.
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+	Request     *requestInfo      `json:"request,omitempty"`
+	User        *userInfo         `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type requestInfo struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+type userInfo struct {
+	ID string `json:"id"`
+}
+
+func (r *Reporter) event(report Report) event {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	level := report.Level
+	if level == "" {
+		level = "error"
+	}
+	e := event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "personal-finance-tracker",
+		ServerName:  r.serverName,
+		Environment: r.environment,
+		Release:     r.release,
+		Transaction: report.Route,
+		Tags:        map[string]string{},
+	}
+
+	if report.ErrorType != "" || len(report.Stack) > 0 {
+		errorType := report.ErrorType
+		if errorType == "" {
+			errorType = "error"
+		}
+		e.Exception = &exceptions{Values: []exception{{Type: errorType, Value: report.Message, Stacktrace: newStacktrace(report.Stack)}}}
+	} else {
+		e.Message = report.Message
+	}
+
+	if report.Request != nil {
+		e.Request = newRequestInfo(report.Request)
+		if e.Transaction == "" {
+			e.Transaction = report.Request.Method + " " + report.Request.URL.Path
+		}
+	}
+	if report.UserID != 0 {
+		e.User = &userInfo{ID: fmt.Sprint(report.UserID)}
+	}
+	if report.RequestID != "" {
+		e.Tags["request_id"] = report.RequestID
+	}
+	if report.TraceID != "" {
+		e.Tags["trace_id"] = report.TraceID
+	}
+	return e
+}
+
+// newStacktrace lists frames oldest first, as Sentry expects.
+func newStacktrace(pcs []uintptr) *stacktrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	var frames []frame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		f, more := iter.Next()
+		module, function := splitFunction(f.Function)
+		frames = append(frames, frame{
+			Function: function,
+			Module:   module,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(module, "personal-finance-tracker/"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &stacktrace{Frames: frames}
+}
+
+// splitFunction splits personal-finance-tracker/internal/jobs.(*Queue).run
+// into the package path and the function name.
+func splitFunction(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+2+dot:]
+}
+
+func newRequestInfo(req *http.Request) *requestInfo {
+	info := &requestInfo{
+		Method:      req.Method,
+		URL:         (&url.URL{Scheme: scheme(req), Host: req.Host, Path: req.URL.Path}).String(),
+		QueryString: scrubQuery(req.URL.Query()).Encode(),
+		Headers:     make(map[string]string),
+	}
+	for name, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if redact.SensitiveKey(name) {
+			value = filtered
+		}
+		info.Headers[name] = value
+	}
+	return info
+}
+
+func scheme(req *http.Request) string {
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
+
+func scrubQuery(query url.Values) url.Values {
+	for name := range query {
+		if redact.SensitiveKey(name) {
+			query[name] = []string{filtered}
+		}
+	}
+	return query
+}
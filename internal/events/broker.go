@@ -0,0 +1,91 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+type EventTypes struct {
+	TransactionCreated     string
+	BalanceChanged         string
+	BudgetThresholdCrossed string
+	JobFinished            string
+}
+
+var Types = EventTypes{
+	TransactionCreated:     "transaction.created",
+	BalanceChanged:         "account.balance_changed",
+	BudgetThresholdCrossed: "budget.threshold_crossed",
+	JobFinished:            "job.finished",
+}
+
+type Event struct {
+	ID        int64       `json:"id,omitempty"`
+	Type      string      `json:"type"`
+	UserID    int         `json:"-"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[chan Event]struct{}
+	bufferSize  int
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[int]map[chan Event]struct{}),
+		bufferSize:  32,
+	}
+}
+
+func (b *Broker) Subscribe(userID int) (<-chan Event, func()) {
+	ch := make(chan Event, b.bufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// HasSubscribers reports whether the user has a stream open on this
+// instance.
+func (b *Broker) HasSubscribers(userID int) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[userID]) > 0
+}
+
+func (b *Broker) Publish(e Event) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[e.UserID] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
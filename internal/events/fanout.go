@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// notification is an event as sent over the fan-out channel. Data is left
+// out when it would not fit; outbox events are then read back by ID.
+type notification struct {
+	ID        int64           `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	UserID    int             `json:"user_id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Fanout delivers stream events to the subscribers of every API instance,
+// not only the one that dispatched them: Publish sends the event with
+// Postgres NOTIFY and Run passes what each instance hears to its Broker.
+type Fanout struct {
+	db     *sql.DB
+	broker *Broker
+}
+
+func NewFanout(db *sql.DB, broker *Broker) *Fanout {
+	return &Fanout{db: db, broker: broker}
+}
+
+// Publish notifies every instance of e, including this one.
+func (f *Fanout) Publish(ctx context.Context, e Event) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	n := notification{ID: e.ID, Type: e.Type, UserID: e.UserID, Data: data, CreatedAt: e.CreatedAt}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if len(payload) > models.FanoutSettings.MaxPayloadBytes {
+		n.Data = nil
+		if payload, err = json.Marshal(n); err != nil {
+			return err
+		}
+	}
+
+	_, err = f.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, models.FanoutSettings.Channel, string(payload))
+	return err
+}
+
+// Run listens on a connection of its own, opened with dsn, until ctx is
+// cancelled. Events sent while the connection is down are not replayed;
+// streams are live views and clients reload their data on reconnect.
+func (f *Fanout) Run(ctx context.Context, dsn string) {
+	settings := models.FanoutSettings
+	listener := pq.NewListener(dsn, settings.MinReconnect, settings.MaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Event stream listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(settings.Channel); err != nil {
+		log.Printf("Error listening for stream events: %v", err)
+		return
+	}
+
+	ping := time.NewTicker(settings.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			go listener.Ping()
+		case n := <-listener.Notify:
+			// A nil notification follows a reconnect.
+			if n == nil {
+				continue
+			}
+			if err := f.receive(ctx, n.Extra); err != nil {
+				log.Printf("Error receiving stream event: %v", err)
+			}
+		}
+	}
+}
+
+func (f *Fanout) receive(ctx context.Context, payload string) error {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return err
+	}
+	if !f.broker.HasSubscribers(n.UserID) {
+		return nil
+	}
+
+	if n.Data == nil && n.ID != 0 {
+		var data []byte
+		err := f.db.QueryRowContext(ctx, `SELECT payload FROM outbox_events WHERE id = $1`, n.ID).Scan(&data)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		n.Data = data
+	}
+
+	f.broker.Publish(Event{ID: n.ID, Type: n.Type, UserID: n.UserID, Data: n.Data, CreatedAt: n.CreatedAt})
+	return nil
+}
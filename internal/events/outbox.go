@@ -0,0 +1,232 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/jobs"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Record writes e to the outbox with q. Passing the database transaction of
+// the change the event describes means the event exists exactly when the
+// change was committed.
+func Record(ctx context.Context, q execer, e Event) error {
+	payload, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `INSERT INTO outbox_events (user_id, type, payload, next_attempt_at, created_at)
+			  VALUES ($1, $2, $3, NOW(), NOW())`, e.UserID, e.Type, payload)
+	return err
+}
+
+// Handler consumes a dispatched event. Data holds the recorded payload as
+// json.RawMessage.
+type Handler func(ctx context.Context, e Event) error
+
+type namedHandler struct {
+	name   string
+	handle Handler
+}
+
+// Outbox dispatches recorded events to its handlers. Events are claimed with
+// SKIP LOCKED, so several API instances share the work and each event is
+// handled by one of them, and an event a handler fails on is retried with
+// backoff for that handler only; delivery is at least once.
+type Outbox struct {
+	db       *sql.DB
+	mu       sync.RWMutex
+	handlers []namedHandler
+	policy   jobs.RetryPolicy
+	wake     chan struct{}
+}
+
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{
+		db: db,
+		policy: jobs.RetryPolicy{
+			MaxAttempts: models.OutboxSettings.MaxAttempts,
+			BaseDelay:   models.OutboxSettings.BaseRetryDelay,
+			MaxDelay:    models.OutboxSettings.MaxRetryDelay,
+		},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Handle registers a handler under a name that identifies it across
+// restarts; renaming it redelivers events still being retried.
+func (o *Outbox) Handle(name string, handler Handler) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handlers = append(o.handlers, namedHandler{name: name, handle: handler})
+}
+
+// Wake starts a dispatch round without waiting for the next poll. Call it
+// after committing recorded events.
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches events until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(models.OutboxSettings.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		for {
+			n, err := o.dispatch(ctx)
+			if err != nil {
+				log.Printf("Error dispatching events: %v", err)
+			}
+			if err != nil || n < models.OutboxSettings.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		case <-cleanup.C:
+			if err := o.prune(); err != nil {
+				log.Printf("Error pruning dispatched events: %v", err)
+			}
+		}
+	}
+}
+
+type outboxEvent struct {
+	Event
+	attempts  int
+	delivered []string
+}
+
+// dispatch hands a batch of due events to every handler. Claiming an event
+// pushes its next attempt ClaimTTL ahead in a short transaction of its own,
+// so no row stays locked while handlers reach out over the network; the
+// claim keeps other instances off the event, and lapses if this one dies.
+func (o *Outbox) dispatch(ctx context.Context) (int, error) {
+	rows, err := o.db.QueryContext(ctx, `UPDATE outbox_events SET next_attempt_at = NOW() + make_interval(secs => $2)
+			  WHERE id IN (SELECT id FROM outbox_events
+			  WHERE dispatched_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+			  ORDER BY id
+			  LIMIT $1
+			  FOR UPDATE SKIP LOCKED)
+			  RETURNING id, user_id, type, payload, attempts, delivered, created_at`,
+		models.OutboxSettings.BatchSize, models.OutboxSettings.ClaimTTL.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	var batch []outboxEvent
+	for rows.Next() {
+		var e outboxEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Type, &payload, &e.attempts, pq.Array(&e.delivered), &e.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		e.Data = json.RawMessage(payload)
+		batch = append(batch, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID })
+
+	o.mu.RLock()
+	handlers := make([]namedHandler, len(o.handlers))
+	copy(handlers, o.handlers)
+	o.mu.RUnlock()
+
+	for i := range batch {
+		e := &batch[i]
+		if err := deliver(ctx, handlers, e); err != nil {
+			if err := o.fail(ctx, *e, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if _, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET dispatched_at = NOW(), delivered = $2 WHERE id = $1`,
+			e.ID, pq.Array(e.delivered)); err != nil {
+			return 0, err
+		}
+	}
+	return len(batch), nil
+}
+
+// deliver passes e to every handler it has not reached yet, adding each one
+// that succeeds to e.delivered, and stops at the first failure.
+func deliver(ctx context.Context, handlers []namedHandler, e *outboxEvent) error {
+	for _, handler := range handlers {
+		if contains(e.delivered, handler.name) {
+			continue
+		}
+		if err := handle(ctx, handler, e.Event); err != nil {
+			return fmt.Errorf("%s: %w", handler.name, err)
+		}
+		e.delivered = append(e.delivered, handler.name)
+	}
+	return nil
+}
+
+func handle(ctx context.Context, handler namedHandler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panicked: %v", r)
+		}
+	}()
+	return handler.handle(ctx, e)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fail schedules the next attempt, or gives up on the event once the retry
+// policy is exhausted.
+func (o *Outbox) fail(ctx context.Context, e outboxEvent, cause error) error {
+	attempts := e.attempts + 1
+	if attempts >= o.policy.MaxAttempts {
+		log.Printf("Event %d (%s) dropped after %d attempts: %v", e.ID, e.Type, attempts, cause)
+		_, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET attempts = $2, last_error = $3, delivered = $4, failed_at = NOW()
+				  WHERE id = $1`, e.ID, attempts, cause.Error(), pq.Array(e.delivered))
+		return err
+	}
+
+	log.Printf("Event %d (%s) failed on attempt %d: %v", e.ID, e.Type, attempts, cause)
+	_, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET attempts = $2, last_error = $3, delivered = $4,
+			  next_attempt_at = NOW() + make_interval(secs => $5)
+			  WHERE id = $1`, e.ID, attempts, cause.Error(), pq.Array(e.delivered), o.policy.Backoff(attempts).Seconds())
+	return err
+}
+
+func (o *Outbox) prune() error {
+	_, err := o.db.Exec(`DELETE FROM outbox_events WHERE dispatched_at < NOW() - make_interval(secs => $1)`,
+		models.OutboxSettings.Retention.Seconds())
+	return err
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+// SchemaVersions is the version of each event's data published to external
+// brokers. Bump it whenever a field is removed or changes meaning; adding
+// fields keeps the version.
+var SchemaVersions = map[string]int{
+	Types.TransactionCreated:     1,
+	Types.BalanceChanged:         1,
+	Types.BudgetThresholdCrossed: 1,
+}
+
+// Envelope is the JSON message published for an event. ID is the outbox ID,
+// which consumers can use to drop the duplicates at-least-once delivery
+// allows.
+type Envelope struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	UserID        int             `json:"user_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Publisher sends a message to an external broker, returning once the broker
+// has accepted it. Key groups messages that must stay in order.
+type Publisher interface {
+	Publish(ctx context.Context, topic, key string, payload []byte) error
+}
+
+// NewPublisherFromConfig returns nil when event publishing is disabled.
+func NewPublisherFromConfig(cfg config.EventsConfig) (Publisher, error) {
+	switch cfg.Broker {
+	case "nats":
+		return NewNATS(cfg.URL)
+	case "kafka":
+		return NewKafkaREST(cfg.URL), nil
+	}
+	return nil, nil
+}
+
+// Forward returns an outbox handler publishing every versioned event to the
+// topic prefix.type, keyed by user so each user's events stay in order.
+func Forward(p Publisher, prefix string) Handler {
+	return func(ctx context.Context, e Event) error {
+		version, ok := SchemaVersions[e.Type]
+		if !ok {
+			return nil
+		}
+		data, _ := e.Data.(json.RawMessage)
+		payload, err := json.Marshal(Envelope{
+			ID:            e.ID,
+			Type:          e.Type,
+			SchemaVersion: version,
+			UserID:        e.UserID,
+			OccurredAt:    e.CreatedAt.UTC(),
+			Data:          data,
+		})
+		if err != nil {
+			return err
+		}
+		if err := p.Publish(ctx, prefix+"."+e.Type, strconv.Itoa(e.UserID), payload); err != nil {
+			return fmt.Errorf("publish %s: %w", e.Type, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package grpcapi
+
+import (
+	"personal-finance-tracker/internal/grpcapi/financev1"
+	"personal-finance-tracker/internal/models"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toUser(u models.User) *financev1.User {
+	return &financev1.User{
+		Id:        int64(u.ID),
+		Email:     u.Email,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}
+}
+
+func toAccount(a models.Account) *financev1.Account {
+	return &financev1.Account{
+		Id:          int64(a.ID),
+		UserId:      int64(a.UserID),
+		Name:        a.Name,
+		Type:        a.Type,
+		Balance:     a.Balance,
+		Currency:    a.Currency,
+		Description: a.Description,
+		CreatedAt:   timestamppb.New(a.CreatedAt),
+		UpdatedAt:   timestamppb.New(a.UpdatedAt),
+	}
+}
+
+func toCategory(c models.Category) *financev1.Category {
+	category := &financev1.Category{
+		Id:        int64(c.ID),
+		UserId:    int64(c.UserID),
+		Name:      c.Name,
+		Type:      c.Type,
+		Color:     c.Color,
+		Icon:      c.Icon,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+	if c.ParentID != nil {
+		parentID := int64(*c.ParentID)
+		category.ParentId = &parentID
+	}
+	return category
+}
+
+func toTransaction(t models.Transaction) *financev1.Transaction {
+	return &financev1.Transaction{
+		Id:          int64(t.ID),
+		UserId:      int64(t.UserID),
+		AccountId:   int64(t.AccountID),
+		CategoryId:  int64(t.CategoryID),
+		Amount:      t.Amount,
+		Type:        t.Type,
+		Description: t.Description,
+		Date:        timestamppb.New(t.Date),
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+		UpdatedAt:   timestamppb.New(t.UpdatedAt),
+	}
+}
@@ -0,0 +1,322 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/grpcapi/financev1"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// pendingApprovalHeader carries the ID of a household approval when a
+// transaction is held for the owner instead of being recorded.
+const pendingApprovalHeader = "pending-approval-id"
+
+// readMethods are the only calls API tokens with the read scope may make;
+// a method added later stays closed to them until it is listed here.
+var readMethods = map[string]bool{
+	financev1.FinanceService_GetProfile_FullMethodName:       true,
+	financev1.FinanceService_ListAccounts_FullMethodName:     true,
+	financev1.FinanceService_ListCategories_FullMethodName:   true,
+	financev1.FinanceService_ListTransactions_FullMethodName: true,
+}
+
+type userIDKey struct{}
+
+type Server struct {
+	financev1.UnimplementedFinanceServiceServer
+	svc *service.Service
+}
+
+// NewServer returns the gRPC server, serving TLS when tlsConfig is set.
+func NewServer(svc *service.Service, tlsConfig *tls.Config) *grpc.Server {
+	s := &Server{svc: svc}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.UnaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(s.StreamAuthInterceptor),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	financev1.RegisterFinanceServiceServer(server, s)
+	return server
+}
+
+func (s *Server) UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate accepts the same JWTs and API tokens as the REST API and
+// counts the call against the caller's API rate limit.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+
+	tokenString := strings.TrimPrefix(md.Get("authorization")[0], "Bearer ")
+	if strings.HasPrefix(tokenString, models.APITokenSettings.Prefix) {
+		token, _, err := s.svc.AuthenticateAPIToken(ctx, tokenString)
+		if err == service.ErrInvalidAPIToken {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		if err != nil {
+			return nil, statusError("Failed to validate token", err)
+		}
+		if token.Scope == models.APITokenScopes.Read && !readMethods[method] {
+			return nil, status.Error(codes.PermissionDenied, "API token has read-only scope")
+		}
+		return s.rateLimit(ctx, token.UserID)
+	}
+
+	claims, err := auth.ValidateJWT(tokenString)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	// Delegated tokens are only good for the read-only REST routes their
+	// advisor grant covers.
+	if claims.GrantID != 0 {
+		return nil, status.Error(codes.PermissionDenied, "delegated tokens are not accepted here")
+	}
+
+	if claims.SessionID != 0 {
+		active, err := s.svc.SessionActive(ctx, claims.SessionID, claims.UserID)
+		if err != nil {
+			return nil, statusError("Failed to validate session", err)
+		}
+		if !active {
+			return nil, status.Error(codes.Unauthenticated, "session has been revoked")
+		}
+	}
+
+	return s.rateLimit(ctx, claims.UserID)
+}
+
+// rateLimit counts a call, or a streamed message, in the same window of the
+// shared store as the user's REST requests.
+func (s *Server) rateLimit(ctx context.Context, userID int) (context.Context, error) {
+	ctx = context.WithValue(ctx, userIDKey{}, userID)
+	if config.Get().Server.LoadTestMode {
+		return ctx, nil
+	}
+
+	window := models.RateLimitSettings.Window
+	windowStart := time.Now().Truncate(window)
+	key := fmt.Sprintf("ratelimit:api:user:%d:%d", userID, windowStart.Unix())
+
+	count, err := s.svc.Cache().Incr(ctx, key, window)
+	if err != nil {
+		log.Printf("Rate limiter unavailable: %v", err)
+		return ctx, nil
+	}
+	if count > int64(models.RateLimitSettings.APIRequests) {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded, try again later")
+	}
+	return ctx, nil
+}
+
+func userID(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey{}).(int)
+	return id
+}
+
+func (s *Server) GetProfile(ctx context.Context, req *financev1.GetProfileRequest) (*financev1.User, error) {
+	user, err := s.svc.GetUser(ctx, userID(ctx))
+	if err != nil {
+		return nil, statusError("Failed to fetch profile", err)
+	}
+	return toUser(user), nil
+}
+
+func (s *Server) ListAccounts(ctx context.Context, req *financev1.ListAccountsRequest) (*financev1.ListAccountsResponse, error) {
+	accounts, err := s.svc.GetAccounts(ctx, userID(ctx))
+	if err != nil {
+		return nil, statusError("Failed to fetch accounts", err)
+	}
+
+	resp := &financev1.ListAccountsResponse{}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, toAccount(account))
+	}
+	return resp, nil
+}
+
+func (s *Server) ListCategories(ctx context.Context, req *financev1.ListCategoriesRequest) (*financev1.ListCategoriesResponse, error) {
+	categories, err := s.svc.GetCategories(ctx, userID(ctx), req.GetType())
+	if err != nil {
+		return nil, statusError("Failed to fetch categories", err)
+	}
+
+	resp := &financev1.ListCategoriesResponse{}
+	for _, category := range categories {
+		resp.Categories = append(resp.Categories, toCategory(category))
+	}
+	return resp, nil
+}
+
+func (s *Server) ListTransactions(ctx context.Context, req *financev1.ListTransactionsRequest) (*financev1.ListTransactionsResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = models.Pagination.DefaultLimit
+	}
+
+	transactions, err := s.svc.GetTransactions(ctx, userID(ctx), limit, int(req.GetOffset()))
+	if err != nil {
+		return nil, statusError("Failed to fetch transactions", err)
+	}
+
+	resp := &financev1.ListTransactionsResponse{}
+	for _, t := range transactions {
+		resp.Transactions = append(resp.Transactions, toTransaction(t))
+	}
+	return resp, nil
+}
+
+// CreateTransaction records a transaction. One held for the household
+// owner's approval comes back without an ID, the approval's ID being in the
+// pending-approval-id header.
+func (s *Server) CreateTransaction(ctx context.Context, req *financev1.CreateTransactionRequest) (*financev1.Transaction, error) {
+	transaction, approval, err := s.createTransaction(ctx, userID(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(pendingApprovalHeader, strconv.Itoa(approval.ID))); err != nil {
+			return nil, err
+		}
+	}
+	return toTransaction(transaction), nil
+}
+
+func (s *Server) StreamCreateTransactions(stream financev1.FinanceService_StreamCreateTransactionsServer) error {
+	uid := userID(stream.Context())
+	resp := &financev1.StreamCreateTransactionsResponse{}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.rateLimit(stream.Context(), uid); err != nil {
+			return err
+		}
+		transaction, approval, err := s.createTransaction(stream.Context(), uid, req)
+		if err != nil {
+			return err
+		}
+		if approval != nil {
+			stream.SetTrailer(metadata.Pairs(pendingApprovalHeader, strconv.Itoa(approval.ID)))
+			continue
+		}
+		resp.Created++
+		resp.TransactionIds = append(resp.TransactionIds, int64(transaction.ID))
+	}
+}
+
+// createTransaction records the transaction in the caller's books or, when
+// the account is not theirs, in their household's, where the owner may have
+// to approve it first. A transaction without a category gets the suggested
+// one, as over REST.
+func (s *Server) createTransaction(ctx context.Context, userID int, req *financev1.CreateTransactionRequest) (models.Transaction, *models.TransactionApproval, error) {
+	if req.GetAmount() <= 0 {
+		return models.Transaction{}, nil, status.Error(codes.InvalidArgument, "amount must be greater than zero")
+	}
+	if req.GetType() != "income" && req.GetType() != "expense" {
+		return models.Transaction{}, nil, status.Error(codes.InvalidArgument, "type must be income or expense")
+	}
+
+	transaction := models.Transaction{
+		UserID:      userID,
+		AccountID:   int(req.GetAccountId()),
+		CategoryID:  int(req.GetCategoryId()),
+		Amount:      req.GetAmount(),
+		Type:        req.GetType(),
+		Description: req.GetDescription(),
+		Date:        time.Now(),
+	}
+	if req.GetDate() != nil {
+		transaction.Date = req.GetDate().AsTime()
+	}
+	if transaction.CategoryID == 0 {
+		categoryID, err := s.svc.SuggestCategory(ctx, userID, transaction.Description, transaction.Amount, transaction.Type)
+		if err == service.ErrCategoryNotFound {
+			return transaction, nil, status.Error(codes.InvalidArgument, "category_id is required")
+		}
+		if err != nil {
+			return transaction, nil, statusError("Failed to create transaction", err)
+		}
+		transaction.CategoryID = categoryID
+	}
+
+	var approval *models.TransactionApproval
+	err := s.svc.CreateTransaction(ctx, &transaction, false)
+	if errors.Is(err, service.ErrAccountNotFound) || errors.Is(err, service.ErrCategoryNotFound) {
+		var householdErr error
+		approval, householdErr = s.svc.CreateHouseholdTransaction(ctx, userID, &transaction)
+		if householdErr != service.ErrNotInHousehold {
+			err = householdErr
+		}
+	}
+	if err != nil {
+		return transaction, nil, statusError("Failed to create transaction", err)
+	}
+	return transaction, approval, nil
+}
+
+func statusError(message string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAccountNotFound), errors.Is(err, service.ErrCategoryNotFound),
+		errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrAccountArchived), errors.Is(err, service.ErrBudgetCapExceeded):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrReadOnlyMember):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrQuotaReached):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	log.Printf("%s: %v", message, err)
+	return status.Error(codes.Internal, message)
+}
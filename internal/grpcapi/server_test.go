@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"testing"
+
+	"personal-finance-tracker/internal/grpcapi/financev1"
+)
+
+// TestReadMethodsExist catches a read method that was renamed or removed, which
+// would otherwise quietly close it to read-only tokens.
+func TestReadMethodsExist(t *testing.T) {
+	desc := financev1.FinanceService_ServiceDesc
+	methods := make(map[string]bool)
+	for _, m := range desc.Methods {
+		methods["/"+desc.ServiceName+"/"+m.MethodName] = true
+	}
+	for _, s := range desc.Streams {
+		methods["/"+desc.ServiceName+"/"+s.StreamName] = true
+	}
+
+	for method := range readMethods {
+		if !methods[method] {
+			t.Errorf("read method %s is not served", method)
+		}
+	}
+	if readMethods[financev1.FinanceService_CreateTransaction_FullMethodName] ||
+		readMethods[financev1.FinanceService_StreamCreateTransactions_FullMethodName] {
+		t.Error("a write method is listed as a read method")
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware admits administrators signed in with a session. API tokens
+// are refused even for administrators: a long-lived automation token must
+// never be able to restore a backup over the whole database.
+func (h *Handler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("token_scope") != "" {
+			abortWithError(c, http.StatusForbidden, "API tokens cannot be used for administration")
+			return
+		}
+		admin, err := h.svc.IsAdmin(c.Request.Context(), c.GetInt("user_id"))
+		if err != nil && err != service.ErrUserNotFound {
+			log.Printf("Error checking admin access: %v", err)
+			abortWithError(c, http.StatusInternalServerError, "Failed to check permissions")
+			return
+		}
+		if !admin {
+			abortWithError(c, http.StatusForbidden, "Administrator access required")
+			return
+		}
+		c.Next()
+	}
+}
+
+func (h *Handler) CreateBackup(c *gin.Context) {
+	backup, err := h.svc.CreateBackup(c.Request.Context(), models.BackupTriggers.Manual)
+	if err == service.ErrBackupsUnavailable {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating backup: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backup"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, backup)
+}
+
+func (h *Handler) ListBackups(c *gin.Context) {
+	backups, err := h.svc.ListBackups(c.Request.Context())
+	if err == service.ErrBackupsUnavailable {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error listing backups: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list backups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, backups)
+}
+
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	var req models.RestoreBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := c.Param("name")
+	safety, err := h.svc.RestoreBackup(c.Request.Context(), name, req.Confirm)
+	switch err {
+	case nil:
+	case service.ErrBackupNotConfirmed:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case service.ErrBackupNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrBackupsUnavailable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error restoring backup %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore backup"})
+		return
+	}
+
+	log.Printf("Database restored from backup %s by user %d", name, c.GetInt("user_id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Database restored", "restored": name, "previous_state": safety})
+}
+
+// GetConfig shows the configuration the server started with, with secrets
+// masked, to debug deployments.
+func (h *Handler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.Get().Redacted())
+}
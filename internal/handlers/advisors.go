@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAdvisorGrants lists the advisors the user gave access to.
+func (h *Handler) GetAdvisorGrants(c *gin.Context) {
+	grants, err := h.svc.AdvisorGrants(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching advisor grants: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch advisor grants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+func (h *Handler) GrantAdvisorAccess(c *gin.Context) {
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage advisor access"})
+		return
+	}
+
+	var req models.AdvisorGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresInDays > models.AdvisorSettings.MaxExpiryDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_days must not exceed %d", models.AdvisorSettings.MaxExpiryDays)})
+		return
+	}
+
+	grant, err := h.svc.GrantAdvisorAccess(c.Request.Context(), c.GetInt("user_id"), req)
+	switch err {
+	case nil:
+		c.JSON(http.StatusCreated, grant)
+	case service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidAdvisor, service.ErrAccountNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrAdvisorGrantExists, service.ErrTooManyAdvisorGrants:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to grant advisor access: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant advisor access"})
+	}
+}
+
+func (h *Handler) RevokeAdvisorAccess(c *gin.Context) {
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage advisor access"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid advisor grant ID"})
+		return
+	}
+
+	err = h.svc.RevokeAdvisorAccess(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrAdvisorGrantNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to revoke advisor access: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke advisor access"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Advisor access revoked"})
+}
+
+// GetAdvisorClients lists the users whose data the caller may read as
+// their advisor.
+func (h *Handler) GetAdvisorClients(c *gin.Context) {
+	grants, err := h.svc.AdvisorClients(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching advisor clients: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch advisor clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// IssueDelegatedToken gives the advisor of a grant a short-lived token to
+// read the client's data with.
+func (h *Handler) IssueDelegatedToken(c *gin.Context) {
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to issue delegated tokens"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid advisor grant ID"})
+		return
+	}
+
+	grant, err := h.svc.ActiveAdvisorGrant(c.Request.Context(), id)
+	if err == nil && grant.AdvisorID != c.GetInt("user_id") {
+		err = service.ErrAdvisorGrantNotFound
+	}
+	if err == service.ErrAdvisorGrantNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching advisor grant: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue delegated token"})
+		return
+	}
+
+	expiresAt := time.Now().Add(models.AdvisorSettings.TokenTTL)
+	if grant.ExpiresAt != nil && grant.ExpiresAt.Before(expiresAt) {
+		expiresAt = *grant.ExpiresAt
+	}
+
+	token, err := auth.GenerateDelegatedJWT(grant.OwnerID, grant.OwnerEmail, grant.AdvisorID, grant.ID, expiresAt)
+	if err != nil {
+		log.Printf("Failed to generate delegated token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.DelegatedToken{Token: token, ExpiresAt: expiresAt, Grant: grant})
+}
+
+// authenticateDelegated serves a request made with a delegated token as the
+// grant's owner, if the grant is still active and covers it, and records it
+// in the owner's audit log.
+func (h *Handler) authenticateDelegated(c *gin.Context, claims *auth.Claims) {
+	grant, err := h.svc.ActiveAdvisorGrant(c.Request.Context(), claims.GrantID)
+	if err == service.ErrAdvisorGrantNotFound || (err == nil && (grant.OwnerID != claims.UserID || grant.AdvisorID != claims.AdvisorID)) {
+		abortWithError(c, http.StatusUnauthorized, "Advisor access has been revoked")
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking advisor grant: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to validate advisor access")
+		return
+	}
+
+	if !isReadOnlyMethod(c.Request.Method) {
+		abortWithError(c, http.StatusForbidden, "Advisor access is read-only")
+		return
+	}
+	if !advisorMayRead(c, grant) {
+		abortWithError(c, http.StatusForbidden, "Advisor access does not cover this resource")
+		return
+	}
+
+	c.Set("user_id", grant.OwnerID)
+	c.Set("email", grant.OwnerEmail)
+	c.Set("advisor_grant", grant)
+	c.Next()
+
+	// The request context may have timed out by now, and the access should
+	// be on record regardless.
+	if err := h.svc.RecordAdvisorAccess(context.Background(), grant, c.Request.Method, c.Request.URL.RequestURI(), c.Writer.Status()); err != nil {
+		log.Printf("Error recording advisor access: %v", err)
+	}
+}
+
+// advisorMayRead reports whether a grant covers the v1 route being read:
+// the account list, the statement, balance history and transactions of a
+// granted account, and the analytics when the grant includes them.
+// Analytics cover all of the owner's accounts.
+func advisorMayRead(c *gin.Context, grant models.AdvisorGrant) bool {
+	switch c.FullPath() {
+	case "/api/v1/accounts":
+		return true
+	case "/api/v1/accounts/:id/statement", "/api/v1/accounts/:id/balance-history":
+		id, err := strconv.Atoi(c.Param("id"))
+		return err == nil && grantsAccount(grant, id)
+	case "/api/v1/transactions":
+		id, err := strconv.Atoi(c.Query("account_id"))
+		return err == nil && grantsAccount(grant, id)
+	}
+	return grant.Analytics && strings.HasPrefix(c.FullPath(), "/api/v1/analytics/")
+}
+
+func grantsAccount(grant models.AdvisorGrant, accountID int) bool {
+	for _, id := range grant.AccountIDs {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// advisorAccounts keeps the accounts an advisor's grant covers when the
+// request was made with a delegated token.
+func advisorAccounts(c *gin.Context, accounts []models.Account) []models.Account {
+	value, ok := c.Get("advisor_grant")
+	if !ok {
+		return accounts
+	}
+	grant := value.(models.AdvisorGrant)
+
+	granted := []models.Account{}
+	for _, account := range accounts {
+		if grantsAccount(grant, account.ID) {
+			granted = append(granted, account)
+		}
+	}
+	return granted
+}
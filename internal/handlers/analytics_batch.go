@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchAnalytics runs a list of named analytics concurrently over a shared
+// date range and scope and answers with all results at once. A failing
+// analytic is reported under its name without failing the others.
+func (h *Handler) BatchAnalytics(c *gin.Context) {
+	var req models.AnalyticsBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Requests) > models.AnalyticsBatchSettings.MaxRequests {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a batch holds at most " + strconv.Itoa(models.AnalyticsBatchSettings.MaxRequests) + " requests"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	loc := h.svc.Location(c.Request.Context(), userID)
+
+	var start, end time.Time
+	var err error
+	if req.StartDate != "" {
+		if start, err = time.ParseInLocation("2006-01-02", req.StartDate, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+	if req.EndDate != "" {
+		if end, err = time.ParseInLocation("2006-01-02", req.EndDate, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must use the YYYY-MM-DD format"})
+			return
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	names := make(map[string]bool)
+	for i, item := range req.Requests {
+		if names[item.Name] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "request names must be unique"})
+			return
+		}
+		names[item.Name] = true
+
+		switch item.Type {
+		case "trends":
+			switch item.Period {
+			case "day", "week", "month", "pay_period":
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "trends need a period of day, week, month or pay_period"})
+				return
+			}
+		case "periods":
+			if item.Count == 0 {
+				req.Requests[i].Count = models.SettingsDefaults.PeriodHistory
+			} else if item.Count < 1 || item.Count > models.SettingsDefaults.MaxPeriodHistory {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and " + strconv.Itoa(models.SettingsDefaults.MaxPeriodHistory)})
+				return
+			}
+		}
+	}
+
+	ctx := c.Request.Context()
+	results := make([]models.AnalyticsBatchResult, len(req.Requests))
+	var wg sync.WaitGroup
+	for i, item := range req.Requests {
+		wg.Add(1)
+		go func(i int, item models.AnalyticsBatchItem) {
+			defer wg.Done()
+			data, err := h.batchAnalytic(ctx, userID, start, end, req.Scope, item)
+			if err != nil {
+				log.Printf("Error running %s analytics for user %d: %v", item.Type, userID, err)
+				results[i].Error = "Failed to compute " + item.Type
+				return
+			}
+			results[i].Data = data
+		}(i, item)
+	}
+	wg.Wait()
+
+	response := make(map[string]models.AnalyticsBatchResult, len(results))
+	for i, item := range req.Requests {
+		response[item.Name] = results[i]
+	}
+	c.JSON(http.StatusOK, gin.H{"results": response})
+}
+
+// batchAnalytic computes one analytic of a batch. Trends and periods are
+// anchored on the last day of the range, or today when it is open-ended.
+func (h *Handler) batchAnalytic(ctx context.Context, userID int, start, end time.Time, scope string, item models.AnalyticsBatchItem) (interface{}, error) {
+	at := time.Now().In(h.svc.Location(ctx, userID))
+	if !end.IsZero() {
+		at = end.AddDate(0, 0, -1)
+	}
+
+	switch item.Type {
+	case "summary":
+		summary := models.AnalyticsSummary{Scope: scope}
+		err := h.fillAnalyticsSummary(ctx, userID, start, end, &summary)
+		return summary, err
+	case "spending":
+		analytics, err := h.spendingAnalytics(ctx, userID, start, end, scope)
+		if analytics == nil {
+			analytics = []models.SpendingByCategory{}
+		}
+		return analytics, err
+	case "spending_groups":
+		return h.svc.SpendingByGroup(ctx, userID, start, end, scope)
+	case "trends":
+		date := at.Format("2006-01-02")
+		trends, err := h.calculateSpendingTrends(ctx, userID, item.Period, date, scope)
+		return models.SpendingTrendsResponse{Period: item.Period, Date: date, Trends: trends}, err
+	case "periods":
+		return h.svc.PeriodSummaries(ctx, userID, at, item.Count, scope)
+	case "net_worth":
+		return h.svc.NetWorth(ctx, userID, scope)
+	}
+	return nil, fmt.Errorf("unknown analytics type %q", item.Type)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetAPITokens(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	query := `SELECT id, user_id, name, token_prefix, scope, expires_at, last_used_at, revoked_at, created_at, updated_at
+			  FROM api_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := h.db.QueryContext(c.Request.Context(), query, userID)
+	if err != nil {
+		log.Printf("Error fetching API tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API tokens"})
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		var token models.APIToken
+		err := rows.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenPrefix, &token.Scope,
+			&token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt, &token.CreatedAt, &token.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) CreateAPIToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage API tokens"})
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Scope == "" {
+		req.Scope = models.APITokenScopes.Read
+	}
+	if req.ExpiresInDays > models.APITokenSettings.MaxExpiryDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_days must not exceed %d", models.APITokenSettings.MaxExpiryDays)})
+		return
+	}
+
+	secret, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		log.Printf("Failed to generate API token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	token := models.APITokenSettings.Prefix + secret
+
+	apiToken := models.APIToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenPrefix: token[:models.APITokenSettings.DisplayPrefixLen],
+		Scope:       req.Scope,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiToken.ExpiresAt = &expiresAt
+	}
+
+	query := `INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scope, expires_at, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, userID, apiToken.Name, apiToken.TokenPrefix, auth.HashToken(token), apiToken.Scope, apiToken.ExpiresAt).
+		Scan(&apiToken.ID, &apiToken.CreatedAt, &apiToken.UpdatedAt)
+	if err != nil {
+		log.Printf("Failed to create API token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPITokenResponse{Token: token, APIToken: apiToken})
+}
+
+func (h *Handler) RevokeAPIToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage API tokens"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API token ID"})
+		return
+	}
+
+	result, err := h.db.ExecContext(c.Request.Context(), `UPDATE api_tokens SET revoked_at = NOW(), updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		log.Printf("Failed to revoke API token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked"})
+}
+
+func (h *Handler) authenticateAPIToken(c *gin.Context, token string) {
+	apiToken, email, err := h.svc.AuthenticateAPIToken(c.Request.Context(), token)
+	if err != nil {
+		if err != service.ErrInvalidAPIToken {
+			log.Printf("Error validating API token: %v", err)
+		}
+		abortWithError(c, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	if apiToken.Scope == models.APITokenScopes.Read && !isReadOnlyMethod(c.Request.Method) {
+		abortWithError(c, http.StatusForbidden, "API token has read-only scope")
+		return
+	}
+
+	c.Set("user_id", apiToken.UserID)
+	c.Set("email", email)
+	c.Set("token_scope", apiToken.Scope)
+	c.Next()
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
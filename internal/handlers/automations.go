@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetAutomationRules(c *gin.Context) {
+	rules, err := h.svc.AutomationRules(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching automation rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch automation rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *Handler) GetAutomationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid automation rule ID"})
+		return
+	}
+
+	rule, err := h.svc.AutomationRule(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrAutomationRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error fetching automation rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch automation rule"})
+	default:
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func (h *Handler) CreateAutomationRule(c *gin.Context) {
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.WebhookURL != "" && !h.enforceQuota(c, models.QuotaResources.Webhooks, 1) {
+		return
+	}
+
+	rule, err := h.svc.CreateAutomationRule(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case isAutomationValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrTooManyAutomationRules:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to create automation rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create automation rule"})
+	default:
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+func (h *Handler) UpdateAutomationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid automation rule ID"})
+		return
+	}
+
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.svc.UpdateAutomationRule(c.Request.Context(), c.GetInt("user_id"), id, req)
+	switch {
+	case isAutomationValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrAutomationRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update automation rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update automation rule"})
+	default:
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func (h *Handler) DeleteAutomationRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid automation rule ID"})
+		return
+	}
+
+	err = h.svc.DeleteAutomationRule(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrAutomationRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to delete automation rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete automation rule"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Automation rule deleted"})
+	}
+}
+
+// SimulateAutomation lists the recent transactions a rule would have fired
+// on, so it can be tried out before it is saved.
+func (h *Handler) SimulateAutomation(c *gin.Context) {
+	var req models.AutomationSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	simulation, err := h.svc.SimulateAutomation(c.Request.Context(), c.GetInt("user_id"), req, time.Now())
+	switch {
+	case isAutomationValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error simulating automation rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate automation rule"})
+	default:
+		c.JSON(http.StatusOK, simulation)
+	}
+}
+
+func isAutomationValidationError(err error) bool {
+	return err == service.ErrAutomationThreshold || err == service.ErrAutomationAction || err == service.ErrAccountNotFound ||
+		err == service.ErrCategoryNotFound
+}
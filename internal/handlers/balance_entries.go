@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SetOpeningBalance(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var req models.OpeningBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	date, err := time.ParseInLocation("2006-01-02", req.Date, h.svc.Location(c.Request.Context(), userID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+		return
+	}
+
+	entry, err := h.svc.SetOpeningBalance(c.Request.Context(), userID, id, *req.Amount, date)
+	switch {
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrAccountArchived:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to set opening balance: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set opening balance"})
+	default:
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+func (h *Handler) ReconcileAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var req models.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	var day *time.Time
+	if req.Date != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.Date, h.svc.Location(c.Request.Context(), userID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+		day = &date
+	}
+
+	result, err := h.svc.Reconcile(c.Request.Context(), userID, id, *req.Balance, day, req.Description)
+	switch {
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrAccountArchived:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to reconcile account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile account"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetBalanceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+	loc := h.svc.Location(c.Request.Context(), userID)
+
+	var start time.Time
+	end := time.Now()
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	granularity := c.DefaultQuery("granularity", models.Granularities.Day)
+	history, err := h.svc.BalanceHistory(c.Request.Context(), userID, id, granularity, start, end)
+	switch {
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrInvalidGranularity || err == service.ErrHistoryRange:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to compute balance history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balance history"})
+	default:
+		c.JSON(http.StatusOK, history)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBankFees breaks down the bank charges of a calendar year, the current
+// one unless ?year= is given.
+func (h *Handler) GetBankFees(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	year := time.Now().In(h.svc.Location(c.Request.Context(), userID)).Year()
+	if value := c.Query("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil || year < 1900 || year > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a four-digit year"})
+			return
+		}
+	}
+
+	fees, err := h.svc.BankFees(c.Request.Context(), userID, year)
+	if err != nil {
+		log.Printf("Error computing bank fees: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute bank fees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fees)
+}
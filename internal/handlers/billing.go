@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"personal-finance-tracker/internal/billing"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBilling shows the caller's subscription, limits and the plans they can
+// buy.
+func (h *Handler) GetBilling(c *gin.Context) {
+	status, err := h.svc.BillingStatus(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching billing status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch billing status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *Handler) StartCheckout(c *gin.Context) {
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage billing"})
+		return
+	}
+
+	var req models.BillingCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	url, err := h.svc.StartCheckout(c.Request.Context(), c.GetInt("user_id"), req.PlanID)
+	h.respondBillingSession(c, url, err)
+}
+
+// OpenBillingPortal returns a link to the Stripe billing portal.
+func (h *Handler) OpenBillingPortal(c *gin.Context) {
+	if c.GetString("token_scope") != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot be used to manage billing"})
+		return
+	}
+
+	url, err := h.svc.BillingPortal(c.Request.Context(), c.GetInt("user_id"))
+	h.respondBillingSession(c, url, err)
+}
+
+func (h *Handler) respondBillingSession(c *gin.Context, url string, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, models.BillingSession{URL: url})
+	case service.ErrBillingDisabled, service.ErrPlanNotFound, service.ErrNoBillingAccount:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrPlanNotForSale:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrAlreadySubscribed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to create billing session: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to reach the payment provider"})
+	}
+}
+
+// StripeWebhook receives Stripe's subscription events. Errors other than a
+// bad signature answer 500 so that Stripe retries the event.
+func (h *Handler) StripeWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, models.BillingSettings.MaxWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	err = h.svc.HandleBillingEvent(c.Request.Context(), payload, c.GetHeader("Stripe-Signature"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	case err == service.ErrBillingDisabled:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, billing.ErrInvalidSignature):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Error handling Stripe event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle event"})
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetBudgetStatus(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	at := time.Now()
+	if value := c.Query("date"); value != "" {
+		var err error
+		if at, err = time.ParseInLocation("2006-01-02", value, h.svc.Location(c.Request.Context(), userID)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	overview, err := h.svc.BudgetOverview(c.Request.Context(), userID, at)
+	if err != nil {
+		log.Printf("Error computing budget status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get budget status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
+func (h *Handler) GetBudgets(c *gin.Context) {
+	rules, err := h.svc.GetBudgetRules(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching budgets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// budgetFromRequest reads a budget request and answers 400 when its dates
+// are malformed.
+func (h *Handler) budgetFromRequest(c *gin.Context, id int) (models.BudgetRule, bool) {
+	var req models.BudgetRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.BudgetRule{}, false
+	}
+
+	userID := c.GetInt("user_id")
+	rule := models.BudgetRule{ID: id, UserID: userID, CategoryID: req.CategoryID, Amount: req.Amount, HardCap: req.HardCap}
+
+	now := time.Now().In(h.svc.Location(c.Request.Context(), userID))
+	rule.StartDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var err error
+	if req.StartDate != "" {
+		if rule.StartDate, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must use the YYYY-MM-DD format"})
+			return rule, false
+		}
+	}
+	if req.EndDate != nil {
+		end, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must use the YYYY-MM-DD format"})
+			return rule, false
+		}
+		if end.Before(rule.StartDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+			return rule, false
+		}
+		rule.EndDate = &end
+	}
+	return rule, true
+}
+
+func (h *Handler) CreateBudget(c *gin.Context) {
+	rule, ok := h.budgetFromRequest(c, 0)
+	if !ok {
+		return
+	}
+
+	err := h.svc.CreateBudgetRule(c.Request.Context(), &rule)
+	if err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create budget: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create budget"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *Handler) UpdateBudget(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID"})
+		return
+	}
+	rule, ok := h.budgetFromRequest(c, id)
+	if !ok {
+		return
+	}
+
+	err = h.svc.UpdateBudgetRule(c.Request.Context(), &rule)
+	if err == service.ErrBudgetNotFound || err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update budget: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) DeleteBudget(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID"})
+		return
+	}
+
+	err = h.svc.DeleteBudgetRule(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrBudgetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete budget: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted"})
+}
+
+// abortWithBudgetCap answers 409 with the state of the capped budget, so the
+// client can show what is left and offer to retry with ?force=true.
+func abortWithBudgetCap(c *gin.Context, capErr *service.BudgetCapError) {
+	if c.GetInt("api_version") < 2 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": capErr.Error(), "budget": capErr.Status})
+		return
+	}
+
+	status := capErr.Status
+	body, err := json.Marshal(struct {
+		models.Problem
+		Budget gin.H `json:"budget"`
+	}{
+		Problem: models.Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(http.StatusConflict),
+			Status:   http.StatusConflict,
+			Detail:   capErr.Error(),
+			Instance: c.Request.URL.Path,
+		},
+		Budget: gin.H{
+			"category_id":     status.CategoryID,
+			"category_name":   status.CategoryName,
+			"budget_cents":    toCents(status.Budget),
+			"spent_cents":     toCents(status.Spent),
+			"remaining_cents": toCents(status.Remaining),
+		},
+	})
+	if err != nil {
+		c.AbortWithStatus(http.StatusConflict)
+		return
+	}
+	c.Data(http.StatusConflict, problemContentType, body)
+	c.Abort()
+}
+
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.Pagination.DefaultLimit)))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", strconv.Itoa(models.Pagination.DefaultOffset)))
+	if limit <= 0 || limit > models.Pagination.MaxLimit {
+		limit = models.Pagination.DefaultLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := h.svc.AuditLog(c.Request.Context(), c.GetInt("user_id"), limit, offset)
+	if err != nil {
+		log.Printf("Error fetching audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/cache"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// ETag buffers successful GET responses, tags them with a hash of the body and
+// answers If-None-Match / If-Modified-Since with 304 Not Modified.
+func (h *Handler) ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if writer.status != http.StatusOK {
+			c.Writer.WriteHeader(writer.status)
+			c.Writer.Write(writer.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(writer.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		header := c.Writer.Header()
+		header.Set("ETag", etag)
+		header.Set("Cache-Control", "private, no-cache")
+
+		lastModified, known := h.svc.LastModified(c.GetInt("user_id"))
+		if known {
+			header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+
+		if notModified(c.Request, etag, lastModified, known) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+
+		c.Writer.WriteHeader(http.StatusOK)
+		c.Writer.Write(writer.body.Bytes())
+	}
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time, known bool) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" && known {
+		t, err := http.ParseTime(since)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
+
+type cachedResponse struct {
+	ContentType string          `json:"content_type"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// CacheResponse stores successful GET responses per user and URL. Entries are
+// keyed by the user's cache version, so any write makes them unreachable.
+func (h *Handler) CacheResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		userID := c.GetInt("user_id")
+		key := fmt.Sprintf(cache.ResponsePrefix+"%d:%d:%s", userID, h.svc.UserCacheVersion(userID), c.Request.URL.RequestURI())
+		store := h.svc.Cache()
+
+		if value, ok, err := store.Get(context.Background(), key); err == nil && ok {
+			var cached cachedResponse
+			if err := json.Unmarshal(value, &cached); err == nil {
+				c.Header("X-Cache", "HIT")
+				c.Data(http.StatusOK, cached.ContentType, cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if writer.status == http.StatusOK && json.Valid(writer.body.Bytes()) {
+			value, err := json.Marshal(cachedResponse{ContentType: original.Header().Get("Content-Type"), Body: writer.body.Bytes()})
+			if err == nil {
+				if err := store.Set(context.Background(), key, value, models.CacheSettings.AnalyticsTTL); err != nil {
+					log.Printf("Error caching response: %v", err)
+				}
+			}
+		}
+
+		c.Header("X-Cache", "MISS")
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(writer.body.Bytes())
+	}
+}
+
+// InvalidateOnWrite bumps the user's cache version after every successful
+// mutating request.
+func (h *Handler) InvalidateOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if userID := c.GetInt("user_id"); userID != 0 && c.Writer.Status() < http.StatusBadRequest {
+			h.svc.InvalidateUserCache(userID)
+		}
+	}
+}
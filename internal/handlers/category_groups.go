@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetCategoryGroups(c *gin.Context) {
+	groups, err := h.svc.GetCategoryGroups(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching category groups: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch category groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+func (h *Handler) CreateCategoryGroup(c *gin.Context) {
+	var req models.CategoryGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := models.CategoryGroup{
+		UserID:        c.GetInt("user_id"),
+		Name:          req.Name,
+		MonthlyBudget: req.MonthlyBudget,
+	}
+
+	err := h.svc.CreateCategoryGroup(c.Request.Context(), &group)
+	if err == service.ErrCategoryGroupExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create category group: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category group"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func (h *Handler) UpdateCategoryGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category group ID"})
+		return
+	}
+
+	var req models.CategoryGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := models.CategoryGroup{
+		ID:            id,
+		UserID:        c.GetInt("user_id"),
+		Name:          req.Name,
+		MonthlyBudget: req.MonthlyBudget,
+	}
+
+	err = h.svc.UpdateCategoryGroup(c.Request.Context(), &group)
+	switch {
+	case err == service.ErrCategoryGroupNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrCategoryGroupExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update category group: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category group"})
+	default:
+		c.JSON(http.StatusOK, group)
+	}
+}
+
+func (h *Handler) DeleteCategoryGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category group ID"})
+		return
+	}
+
+	err = h.svc.DeleteCategoryGroup(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrCategoryGroupNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete category group: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category group deleted"})
+}
+
+func (h *Handler) GetGroupSpending(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	startDate, endDate, ok := parseDateRange(c, h.svc.Location(c.Request.Context(), userID))
+	if !ok {
+		return
+	}
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	groups, err := h.svc.SpendingByGroup(c.Request.Context(), userID, startDate, endDate, scope)
+	if err != nil {
+		log.Printf("Error getting group spending: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"personal-finance-tracker/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// encoder is a Content-Encoding the server can compress responses with.
+// Encoders are listed in order of preference for clients that accept
+// several equally.
+type encoder struct {
+	name string
+	pool *sync.Pool
+}
+
+var encoders = []encoder{
+	{name: "br", pool: &sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}}},
+	{name: "gzip", pool: &sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}},
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress compresses responses the client accepts compressed, which mainly
+// pays off for transaction listings, analytics and CSV exports. Only text
+// formats are compressed, so already-compressed downloads such as ZIP, PDF
+// or image files are passed through, as are event streams and bodies below
+// HTTP_COMPRESSION_MIN_SIZE.
+func (h *Handler) Compress() gin.HandlerFunc {
+	cfg := config.Get().HTTP
+	if !cfg.Compression {
+		return func(c *gin.Context) { c.Next() }
+	}
+	minSize := cfg.CompressionMinSize
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		enc, ok := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoder: enc, minSize: minSize}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest quality
+// in an Accept-Encoding header. A coding named with q=0 is refused even when
+// * would accept it.
+func negotiateEncoding(header string) (encoder, bool) {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(params)), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[name] = quality
+	}
+
+	var best encoder
+	bestQuality := 0.0
+	for _, enc := range encoders {
+		quality, ok := qualities[enc.name]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = enc, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+// compressWriter holds back the start of the body until it reaches the
+// minimum size, then decides whether to compress it from the response
+// headers.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoder encoder
+	minSize int
+
+	buffer   []byte
+	decided  bool
+	writer   compressor
+	finished bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if !w.compressible() {
+			w.decide(false)
+			return w.ResponseWriter.Write(data)
+		}
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.minSize {
+			return len(data), nil
+		}
+		w.decide(true)
+		return len(data), nil
+	}
+	if w.writer != nil {
+		return w.writer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buffer) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.writer != nil {
+		w.writer.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// compressible reports whether the response so far allows compression. It
+// sets Vary on text responses even when they end up too small, so that
+// caches keep encodings apart.
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.ResponseWriter.Written() {
+		return false
+	}
+	if status := w.Status(); status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	if !compressibleType(header.Get("Content-Type")) {
+		return false
+	}
+	header.Add("Vary", "Accept-Encoding")
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < w.minSize {
+		return false
+	}
+	return true
+}
+
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "application/x-ndjson", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// decide starts the response, compressed or not, and writes out what was
+// held back.
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if compress {
+		header := w.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoder.name)
+		w.writer = w.encoder.pool.Get().(compressor)
+		w.writer.Reset(w.ResponseWriter)
+	}
+	if len(w.buffer) == 0 {
+		return
+	}
+	buffer := w.buffer
+	w.buffer = nil
+	if w.writer != nil {
+		w.writer.Write(buffer)
+	} else {
+		w.ResponseWriter.Write(buffer)
+	}
+}
+
+// finish sends a body that stayed below the minimum size as it is and
+// completes a compressed one.
+func (w *compressWriter) finish() {
+	if w.finished {
+		return
+	}
+	w.finished = true
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.writer != nil {
+		w.writer.Close()
+		w.writer.Reset(io.Discard)
+		w.encoder.pool.Put(w.writer)
+		w.writer = nil
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"personal-finance-tracker/internal/config"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"gzip, br", "br"},
+		{"gzip, deflate, br", "br"},
+		{"GZIP", "gzip"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0.2, gzip;q=0.8", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5", "br"},
+		{"gzip;q=0", ""},
+		{"gzip;q=0, *;q=0.5", "br"},
+		{"br;q=0, gzip;q=0, *;q=0.5", ""},
+		{"br;q=0, *", "gzip"},
+		{"*;q=0", ""},
+		{"*;q=0, gzip", "gzip"},
+		{"gzip;q=bogus, br", "br"},
+		{"gzip; q=0.7, br; Q=0.3", "gzip"},
+		{"deflate", ""},
+	}
+
+	for _, tt := range tests {
+		enc, ok := negotiateEncoding(tt.header)
+		got := ""
+		if ok {
+			got = enc.name
+		}
+		if got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.Set(&config.Config{HTTP: config.HTTPConfig{Compression: true, CompressionMinSize: 64}})
+	body := strings.Repeat(`{"description":"coffee","amount":4.5},`, 50)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		encoding    string
+	}{
+		{"brotli", "gzip, br", "application/json", body, "br"},
+		{"gzip", "gzip", "application/json", body, "gzip"},
+		{"refused", "gzip;q=0, br;q=0", "application/json", body, ""},
+		{"small", "br", "application/json", `{"ok":true}`, ""},
+		{"binary", "br", "application/pdf", body, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			h := &Handler{}
+			router.Use(h.Compress())
+			router.GET("/", func(c *gin.Context) {
+				c.Data(http.StatusOK, tt.contentType, []byte(tt.body))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.accept)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+
+			var reader io.Reader = rec.Body
+			switch tt.encoding {
+			case "br":
+				reader = brotli.NewReader(rec.Body)
+			case "gzip":
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				reader = gz
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded) != tt.body {
+				t.Errorf("decoded body differs from the original")
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetCardStatement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	at := time.Now()
+	if value := c.Query("date"); value != "" {
+		if at, err = time.ParseInLocation("2006-01-02", value, h.svc.Location(c.Request.Context(), c.GetInt("user_id"))); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	statement, err := h.svc.CardStatement(c.Request.Context(), c.GetInt("user_id"), id, at)
+	switch {
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrNoStatementCycle:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to compute card statement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statement"})
+	default:
+		c.JSON(http.StatusOK, statement)
+	}
+}
+
+func (h *Handler) GetCreditUtilization(c *gin.Context) {
+	usage, err := h.svc.CreditUtilization(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Failed to compute credit utilization: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute credit utilization"})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetDashboard(c *gin.Context) {
+	widgets, err := h.svc.Dashboard(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching dashboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dashboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, widgets)
+}
+
+func (h *Handler) UpdateDashboard(c *gin.Context) {
+	var req models.DashboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	widgets := make([]models.DashboardWidget, len(req.Widgets))
+	for i, widget := range req.Widgets {
+		widgets[i] = models.DashboardWidget{Type: widget.Type, Params: widget.Params}
+	}
+
+	saved, err := h.svc.SaveDashboard(c.Request.Context(), c.GetInt("user_id"), widgets)
+	switch {
+	case err == service.ErrWidgetType || err == service.ErrWidgetParams || err == service.ErrTooManyWidgets:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to save dashboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save dashboard"})
+	default:
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+func (h *Handler) GetDashboardData(c *gin.Context) {
+	data, err := h.svc.DashboardData(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error loading dashboard data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dashboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
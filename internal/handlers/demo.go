@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"personal-finance-tracker/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDemoUser signs in to a new sandbox account with generated data, so
+// the app can be tried or demoed without entering real finances.
+func (h *Handler) CreateDemoUser(c *gin.Context) {
+	if !config.Get().Server.DemoEnabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Demo accounts are disabled"})
+		return
+	}
+
+	user, err := h.svc.CreateDemoUser(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to create demo user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create demo account"})
+		return
+	}
+
+	response, err := h.issueSession(c, user)
+	if err != nil {
+		log.Printf("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetEmergencyFund(c *gin.Context) {
+	fund, err := h.svc.EmergencyFund(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Error computing emergency fund: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute emergency fund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fund)
+}
+
+func (h *Handler) UpdateEmergencyFund(c *gin.Context) {
+	var req models.EmergencyFundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fund, err := h.svc.SetEmergencyFund(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update emergency fund: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update emergency fund"})
+	default:
+		c.JSON(http.StatusOK, fund)
+	}
+}
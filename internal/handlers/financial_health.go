@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetFinancialHealth(c *gin.Context) {
+	health, err := h.svc.FinancialHealth(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Error computing financial health: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute financial health"})
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
@@ -1,38 +1,79 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/nlquery"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/oauth"
+	"personal-finance-tracker/internal/receipts"
+	"personal-finance-tracker/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	db *sql.DB
+	db             *sql.DB
+	svc            *service.Service
+	queryParser    nlquery.Parser
+	ocrEngine      receipts.OCREngine
+	webhooks       *notifications.WebhookChannel
+	oauthProviders *oauth.Registry
 }
 
-func NewHandler(db *sql.DB) *Handler {
-	return &Handler{db: db}
+func NewHandler(db *sql.DB, svc *service.Service) *Handler {
+	return &Handler{
+		db:             db,
+		svc:            svc,
+		queryParser:    nlquery.NewParser(nlquery.NewProviderFromConfig(config.Get().LLM)),
+		ocrEngine:      receipts.NewEngineFromConfig(config.Get().OCR),
+		webhooks:       notifications.NewWebhookChannel(db, nil),
+		oauthProviders: oauth.NewRegistryFromConfig(config.Get().OAuth),
+	}
 }
 
+// HealthCheck is the liveness probe: it only reports that the process is
+// serving requests, so a slow database never gets the instance restarted.
 func (h *Handler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	c.JSON(http.StatusOK, gin.H{"status": "healthy", "version": models.AppVersion})
+}
+
+// ReadinessCheck answers 503 while a dependency is down, so orchestrators and
+// load balancers stop routing traffic to the instance until it recovers.
+func (h *Handler) ReadinessCheck(c *gin.Context) {
+	report := h.svc.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != models.HealthStatuses.Up {
+		status = http.StatusServiceUnavailable
+		for _, component := range report.Components {
+			if component.Status == models.HealthStatuses.Down {
+				log.Printf("Readiness check failed for %s: %s", component.Name, component.Error)
+			}
+		}
+	}
+	c.JSON(status, report)
 }
 
 func (h *Handler) RootHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Personal Finance Tracker API",
-		"version": "1.0.0",
+		"version": models.AppVersion,
 		"endpoints": gin.H{
 			"health":       "/health or /api/v1/health",
+			"readiness":    "/health/ready or /api/v1/health/ready",
+			"dashboard":    "/app/",
 			"auth":         "/api/v1/auth/{register,login}",
 			"accounts":     "/api/v1/accounts",
 			"categories":   "/api/v1/categories",
@@ -47,33 +88,65 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "Authorization header required")
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if strings.HasPrefix(tokenString, models.APITokenSettings.Prefix) {
+			h.authenticateAPIToken(c, tokenString)
+			return
+		}
+
 		claims, err := auth.ValidateJWT(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			abortWithError(c, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
+		if claims.GrantID != 0 {
+			h.authenticateDelegated(c, claims)
+			return
+		}
+
+		if claims.SessionID != 0 {
+			active, err := h.svc.SessionActive(c.Request.Context(), claims.SessionID, claims.UserID)
+			if err != nil {
+				log.Printf("Error checking session: %v", err)
+				abortWithError(c, http.StatusInternalServerError, "Failed to validate session")
+				return
+			}
+			if !active {
+				abortWithError(c, http.StatusUnauthorized, "Session has been revoked")
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
 
 func (h *Handler) Register(c *gin.Context) {
+	if !passwordAuthEnabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password authentication is disabled"})
+		return
+	}
+
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("Register request: %+v", req)
+	localPart, _, _ := strings.Cut(req.Email, "@")
+	strength, err := auth.PasswordPolicyFromConfig(config.Get().Auth).Validate(c.Request.Context(), req.Password, localPart, req.FirstName, req.LastName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "strength": strength})
+		return
+	}
 
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -86,20 +159,13 @@ func (h *Handler) Register(c *gin.Context) {
 	query := `INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at) 
 			  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`
 
-	err = h.db.QueryRow(query, req.Email, hashedPassword, req.FirstName, req.LastName).Scan(&userID)
+	err = h.db.QueryRowContext(c.Request.Context(), query, req.Email, hashedPassword, req.FirstName, req.LastName).Scan(&userID)
 	if err != nil {
 		log.Printf("Failed to create user in database: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
-	token, err := auth.GenerateJWT(userID, req.Email)
-	if err != nil {
-		log.Printf("Failed to generate JWT: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
-	}
-
 	user := models.User{
 		ID:        userID,
 		Email:     req.Email,
@@ -107,90 +173,164 @@ func (h *Handler) Register(c *gin.Context) {
 		LastName:  req.LastName,
 	}
 
-	c.JSON(http.StatusCreated, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	response, err := h.issueSession(c, user)
+	if err != nil {
+		log.Printf("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *Handler) Login(c *gin.Context) {
+	if !passwordAuthEnabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password authentication is disabled"})
+		return
+	}
+
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	retryAfter, err := h.svc.LoginRetryAfter(c.Request.Context(), req.Email, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+		return
+	}
+
 	var user models.User
 	query := `SELECT id, email, password_hash, first_name, last_name FROM users WHERE email = $1`
 
-	err := h.db.QueryRow(query, req.Email).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName)
-	if err != nil {
+	err = h.db.QueryRowContext(c.Request.Context(), query, req.Email).Scan(&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName)
+	if err != nil || !auth.CheckPasswordHash(req.Password, user.Password) {
+		if err := h.svc.RecordLoginFailure(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	if !auth.CheckPasswordHash(req.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
+	if err := h.svc.RecordLoginSuccess(c.Request.Context(), user.ID, req.Email, c.ClientIP()); err != nil {
+		log.Printf("Error recording login: %v", err)
+	}
+
+	if auth.NeedsRehash(user.Password) {
+		if err := h.svc.UpdatePasswordHash(c.Request.Context(), user.ID, req.Password); err != nil {
+			log.Printf("Error upgrading password hash: %v", err)
+		}
 	}
 
-	token, err := auth.GenerateJWT(user.ID, user.Email)
+	response, err := h.issueSession(c, user)
 	if err != nil {
+		log.Printf("Failed to create session: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) GetProfile(c *gin.Context) {
+	user, err := h.svc.GetProfile(c.Request.Context(), c.GetInt("user_id"))
+	if err == service.ErrUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to fetch profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	var user models.User
-	query := `SELECT id, email, first_name, last_name, created_at, updated_at FROM users WHERE id = $1`
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	err := h.db.QueryRow(query, userID).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
+	if req.NewPassword != "" {
+		user, err := h.svc.GetUser(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		localPart, _, _ := strings.Cut(user.Email, "@")
+		strength, err := auth.PasswordPolicyFromConfig(config.Get().Auth).Validate(c.Request.Context(), req.NewPassword, localPart, user.FirstName, user.LastName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "strength": strength})
+			return
+		}
+	}
+
+	update, err := h.svc.UpdateProfile(c.Request.Context(), userID, c.GetInt("session_id"), req)
+	switch {
+	case err == service.ErrWrongPassword:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err == service.ErrEmailTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err == service.ErrUserNotFound:
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
+	case errors.Is(err, service.ErrInvalidProfile), errors.Is(err, service.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to update profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, update)
 }
 
-func (h *Handler) UpdateProfile(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated"})
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	_, err := h.svc.ConfirmEmailChange(c.Request.Context(), c.Param("token"))
+	if err == service.ErrEmailChangeTokenInvalid {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrEmailTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to confirm email change: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm email change"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address changed"})
 }
 
 func (h *Handler) GetAccounts(c *gin.Context) {
-	userID := c.GetInt("user_id")
-
-	query := `SELECT id, user_id, name, type, balance, currency, description, created_at, updated_at 
-			  FROM accounts WHERE user_id = $1 ORDER BY created_at DESC`
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
 
-	rows, err := h.db.Query(query, userID)
+	accounts, err := h.svc.ListAccounts(c.Request.Context(), c.GetInt("user_id"), c.Query("include_archived") == "true", scope)
 	if err != nil {
+		log.Printf("Failed to fetch accounts: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
 		return
 	}
-	defer rows.Close()
 
-	var accounts []models.Account
-	for rows.Next() {
-		var account models.Account
-		err := rows.Scan(&account.ID, &account.UserID, &account.Name, &account.Type,
-			&account.Balance, &account.Currency, &account.Description,
-			&account.CreatedAt, &account.UpdatedAt)
-		if err != nil {
-			continue
-		}
-		accounts = append(accounts, account)
-	}
-
-	c.JSON(http.StatusOK, accounts)
+	c.JSON(http.StatusOK, advisorAccounts(c, accounts))
 }
 
 func (h *Handler) CreateAccount(c *gin.Context) {
@@ -203,14 +343,17 @@ func (h *Handler) CreateAccount(c *gin.Context) {
 	}
 
 	account.UserID = userID
+	if !h.enforceQuota(c, models.QuotaResources.Accounts, 1) {
+		return
+	}
 
-	query := `INSERT INTO accounts (user_id, name, type, balance, currency, description, created_at, updated_at) 
-			  VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) RETURNING id, created_at, updated_at`
-
-	err := h.db.QueryRow(query, account.UserID, account.Name, account.Type,
-		account.Balance, account.Currency, account.Description).
-		Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
+	err := h.svc.CreateAccount(c.Request.Context(), &account)
+	if isAccountValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
+		log.Printf("Failed to create account: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
 		return
 	}
@@ -219,74 +362,460 @@ func (h *Handler) CreateAccount(c *gin.Context) {
 }
 
 func (h *Handler) UpdateAccount(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Account updated"})
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var account models.Account
+	if err := c.ShouldBindJSON(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account.ID = id
+	account.UserID = c.GetInt("user_id")
+
+	err = h.svc.UpdateAccount(c.Request.Context(), &account)
+	if err == service.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if isAccountValidationError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
 }
 
 func (h *Handler) DeleteAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	err = h.svc.DeleteAccount(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrResourceInUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account still has transactions"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
 }
 
+func isAccountValidationError(err error) bool {
+	return err == service.ErrInvalidAccountType || err == service.ErrInvalidCreditLimit || err == service.ErrInvalidInterestRate ||
+		err == service.ErrInvalidCardCycle || err == service.ErrInvalidScope
+}
+
+func (h *Handler) ArchiveAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var req models.ArchiveAccountRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	account, err := h.svc.ArchiveAccount(c.Request.Context(), c.GetInt("user_id"), id, req.Close)
+	if err == service.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to archive account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) UnarchiveAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	account, err := h.svc.UnarchiveAccount(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to unarchive account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unarchive account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
 func (h *Handler) GetCategories(c *gin.Context) {
-	c.JSON(http.StatusOK, []models.Category{})
+	categories, err := h.svc.GetCategories(c.Request.Context(), c.GetInt("user_id"), c.Query("type"))
+	if err != nil {
+		log.Printf("Failed to fetch categories: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+	if categories == nil {
+		categories = []models.Category{}
+	}
+
+	c.JSON(http.StatusOK, categories)
 }
 
 func (h *Handler) CreateCategory(c *gin.Context) {
-	c.JSON(http.StatusCreated, gin.H{"message": "Category created"})
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := models.Category{
+		UserID:   c.GetInt("user_id"),
+		Name:     req.Name,
+		Type:     req.Type,
+		Color:    req.Color,
+		Icon:     req.Icon,
+		ParentID: req.ParentID,
+
+		TaxDeductible: req.TaxDeductible,
+		TaxCode:       req.TaxCode,
+
+		GroupID: req.GroupID,
+	}
+
+	err := h.svc.CreateCategory(c.Request.Context(), &category)
+	if err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Parent category not found"})
+		return
+	}
+	if err == service.ErrCategoryGroupNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrUnknownIcon || err == service.ErrInvalidColor || err == service.ErrColorContrast {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
 }
 
 func (h *Handler) UpdateCategory(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Category updated"})
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := models.Category{
+		ID:       id,
+		UserID:   c.GetInt("user_id"),
+		Name:     req.Name,
+		Type:     req.Type,
+		Color:    req.Color,
+		Icon:     req.Icon,
+		ParentID: req.ParentID,
+
+		TaxDeductible: req.TaxDeductible,
+		TaxCode:       req.TaxCode,
+
+		GroupID: req.GroupID,
+	}
+
+	err = h.svc.UpdateCategory(c.Request.Context(), &category)
+	if err == service.ErrCategoryNotFound || err == service.ErrCategoryGroupNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrUnknownIcon || err == service.ErrInvalidColor || err == service.ErrColorContrast {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
 }
 
 func (h *Handler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var reassignTo *int
+	if value := c.Query("reassign_to"); value != "" {
+		target, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		reassignTo = &target
+	}
+
+	err = h.svc.DeleteCategory(c.Request.Context(), c.GetInt("user_id"), id, reassignTo)
+	if err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrCategoryInUse {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrResourceInUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category is still used by transactions"})
+		return
+	}
+	if err == service.ErrMergeSameCategory || err == service.ErrCategoryTypeMismatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
 }
 
+func (h *Handler) MergeCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req models.CategoryMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merge, err := h.svc.MergeCategory(c.Request.Context(), c.GetInt("user_id"), id, req.TargetID)
+	switch {
+	case err == service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrMergeSameCategory || err == service.ErrCategoryTypeMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to merge category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge category"})
+	default:
+		c.JSON(http.StatusOK, merge)
+	}
+}
+
 func (h *Handler) GetTransactions(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.Pagination.DefaultLimit)))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", strconv.Itoa(models.Pagination.DefaultOffset)))
 
-	query := `SELECT t.id, t.user_id, t.account_id, t.category_id, t.amount, t.type, 
-			  t.description, t.date, t.created_at, t.updated_at
-			  FROM transactions t 
-			  WHERE t.user_id = $1 
-			  ORDER BY t.date DESC, t.created_at DESC 
-			  LIMIT $2 OFFSET $3`
+	expand, ok := parseTransactionExpand(c)
+	if !ok {
+		return
+	}
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+	accountID, ok := parseAccountFilter(c)
+	if !ok {
+		return
+	}
+	geo, ok := parseGeoFilter(c)
+	if !ok {
+		return
+	}
 
-	rows, err := h.db.Query(query, userID, limit, offset)
+	transactions, err := h.svc.GetTransactionsExpanded(c.Request.Context(), userID, limit, offset, expand, scope, accountID, geo)
 	if err != nil {
+		log.Printf("Failed to fetch transactions: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
 		return
 	}
-	defer rows.Close()
-
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.AccountID,
-			&transaction.CategoryID, &transaction.Amount, &transaction.Type,
-			&transaction.Description, &transaction.Date,
-			&transaction.CreatedAt, &transaction.UpdatedAt)
-		if err != nil {
-			continue
-		}
-		transactions = append(transactions, transaction)
-	}
 
 	c.JSON(http.StatusOK, transactions)
 }
 
 func (h *Handler) CreateTransaction(c *gin.Context) {
-	c.JSON(http.StatusCreated, gin.H{"message": "Transaction created"})
+	userID := c.GetInt("user_id")
+
+	var req models.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	transaction := models.Transaction{
+		UserID:      userID,
+		AccountID:   req.AccountID,
+		CategoryID:  req.CategoryID,
+		Amount:      req.Amount,
+		Type:        req.Type,
+		Description: req.Description,
+		Date:        time.Now(),
+		Scope:       req.Scope,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Place:       req.Place,
+		Quantity:    req.Quantity,
+		Unit:        req.Unit,
+	}
+	if req.Date != nil {
+		transaction.Date = *req.Date
+	}
+
+	if err := h.suggestCategory(c.Request.Context(), &transaction); err != nil {
+		if err == service.ErrCategoryNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+			return
+		}
+		log.Printf("Failed to suggest a category: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	if err := h.svc.CreateTransaction(c.Request.Context(), &transaction, force); err != nil {
+		if abortWithQuota(c, err) {
+			return
+		}
+		var capErr *service.BudgetCapError
+		if errors.As(err, &capErr) {
+			abortWithBudgetCap(c, capErr)
+			return
+		}
+		if err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrAccountArchived {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to create transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
 }
 
 func (h *Handler) UpdateTransaction(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Transaction updated"})
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req models.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+		return
+	}
+
+	transaction := models.Transaction{
+		ID:          id,
+		UserID:      c.GetInt("user_id"),
+		AccountID:   req.AccountID,
+		CategoryID:  req.CategoryID,
+		Amount:      req.Amount,
+		Type:        req.Type,
+		Description: req.Description,
+		Scope:       req.Scope,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Place:       req.Place,
+		Quantity:    req.Quantity,
+		Unit:        req.Unit,
+	}
+	if req.Date != nil {
+		transaction.Date = *req.Date
+	}
+
+	err = h.svc.UpdateTransaction(c.Request.Context(), &transaction)
+	if err == service.ErrTransactionNotFound || err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrAccountArchived {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
 }
 
 func (h *Handler) DeleteTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	err = h.svc.DeleteTransaction(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted"})
 }
 
@@ -297,110 +826,157 @@ func (h *Handler) BulkCreateTransactions(c *gin.Context) {
 func (h *Handler) GetAnalyticsSummary(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	startDate := c.DefaultQuery("start_date", "")
-	endDate := c.DefaultQuery("end_date", "")
-
-	var summary models.AnalyticsSummary
-
-	query := `
-		SELECT 
-			COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE 0 END), 0) as total_income,
-			COALESCE(SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), 0) as total_expenses,
-			COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE -amount END), 0) as net_income
-		FROM transactions 
-		WHERE user_id = $1`
-
-	params := []interface{}{userID}
-	paramCount := 1
+	settings, err := h.svc.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics summary"})
+		return
+	}
+	now := time.Now().In(service.SettingsLocation(settings))
 
-	if startDate != "" {
-		paramCount++
-		query += fmt.Sprintf(" AND date >= $%d", paramCount)
-		params = append(params, startDate)
+	startDate, endDate, ok := parseDateRange(c, now.Location())
+	if !ok {
+		return
+	}
+	scope, ok := parseScope(c)
+	if !ok {
+		return
 	}
 
-	if endDate != "" {
-		paramCount++
-		query += fmt.Sprintf(" AND date <= $%d", paramCount)
-		params = append(params, endDate)
+	summary := models.AnalyticsSummary{Scope: scope}
+
+	if period := c.Query("period"); period != "" {
+		switch period {
+		case "current":
+			startDate, endDate = service.PayPeriod(settings, now)
+		case "previous":
+			current, _ := service.PayPeriod(settings, now)
+			startDate, endDate = service.PayPeriod(settings, current.AddDate(0, 0, -1))
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be current or previous"})
+			return
+		}
+		summary.StartDate = startDate.Format("2006-01-02")
+		summary.EndDate = endDate.AddDate(0, 0, -1).Format("2006-01-02")
 	}
 
-	err := h.db.QueryRow(query, params...).Scan(&summary.TotalIncome, &summary.TotalExpenses, &summary.NetIncome)
-	if err != nil {
+	if err := h.fillAnalyticsSummary(c.Request.Context(), userID, startDate, endDate, &summary); err != nil {
 		log.Printf("Error getting analytics summary: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics summary"})
 		return
 	}
 
-	balanceQuery := `SELECT COALESCE(SUM(balance), 0) FROM accounts WHERE user_id = $1`
-	err = h.db.QueryRow(balanceQuery, userID).Scan(&summary.AccountBalance)
+	if c.Query("period") != "" {
+		summary.Period = "pay_period"
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// fillAnalyticsSummary adds the totals of [start, end), net worth and credit
+// utilization in summary.Scope to summary. Net worth and utilization are
+// best effort and left out when they fail.
+func (h *Handler) fillAnalyticsSummary(ctx context.Context, userID int, start, end time.Time, summary *models.AnalyticsSummary) error {
+	totals, err := h.svc.CategoryTotals(ctx, userID, start, end, summary.Scope)
 	if err != nil {
-		log.Printf("Error getting account balance: %v", err)
-		summary.AccountBalance = 0
+		return err
+	}
+
+	for _, total := range totals {
+		if total.Type == "income" {
+			summary.TotalIncome += total.Amount
+		} else {
+			summary.TotalExpenses += total.Amount
+		}
+	}
+	summary.NetIncome = summary.TotalIncome - summary.TotalExpenses
+
+	netWorth, err := h.svc.NetWorth(ctx, userID, summary.Scope)
+	if err != nil {
+		log.Printf("Error getting net worth: %v", err)
+	} else {
+		summary.AccountBalance = netWorth.NetWorth
+		summary.Assets = netWorth.Assets
+		summary.Liabilities = netWorth.Liabilities
+		summary.NetWorth = netWorth.NetWorth
+	}
+
+	if usage, err := h.svc.CreditUtilization(ctx, userID); err != nil {
+		log.Printf("Error getting credit utilization: %v", err)
+	} else if usage.CreditLimit > 0 {
+		summary.CreditUtilization = &usage.Utilization
 	}
 
 	summary.Period = "custom"
-	if startDate == "" && endDate == "" {
+	if start.IsZero() && end.IsZero() {
 		summary.Period = "all_time"
 	}
+	return nil
+}
 
-	c.JSON(http.StatusOK, summary)
+// parseDateRange reads the inclusive start_date/end_date query parameters as
+// days in loc and returns them as a half-open range; missing bounds are left
+// zero.
+func parseDateRange(c *gin.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	var start, end time.Time
+	var err error
+
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must use the YYYY-MM-DD format"})
+			return start, end, false
+		}
+	}
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must use the YYYY-MM-DD format"})
+			return start, end, false
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true
 }
 
 func (h *Handler) GetSpendingAnalytics(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	startDate := c.DefaultQuery("start_date", "")
-	endDate := c.DefaultQuery("end_date", "")
-
-	query := `
-		SELECT 
-			c.id,
-			c.name,
-			COALESCE(SUM(t.amount), 0) as total_amount
-		FROM categories c
-		LEFT JOIN transactions t ON c.id = t.category_id AND t.type = 'expense'
-		WHERE c.user_id = $1 AND c.type = 'expense'`
-
-	params := []interface{}{userID}
-	paramCount := 1
-
-	if startDate != "" {
-		paramCount++
-		query += fmt.Sprintf(" AND t.date >= $%d", paramCount)
-		params = append(params, startDate)
+	startDate, endDate, ok := parseDateRange(c, h.svc.Location(c.Request.Context(), userID))
+	if !ok {
+		return
 	}
-
-	if endDate != "" {
-		paramCount++
-		query += fmt.Sprintf(" AND t.date <= $%d", paramCount)
-		params = append(params, endDate)
+	scope, ok := parseScope(c)
+	if !ok {
+		return
 	}
 
-	query += `
-		GROUP BY c.id, c.name
-		ORDER BY total_amount DESC`
-
-	rows, err := h.db.Query(query, params...)
+	analytics, err := h.spendingAnalytics(c.Request.Context(), userID, startDate, endDate, scope)
 	if err != nil {
 		log.Printf("Error getting spending analytics: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending analytics"})
 		return
 	}
-	defer rows.Close()
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// spendingAnalytics returns every expense category with its spending in the
+// range and its share of the total, largest first.
+func (h *Handler) spendingAnalytics(ctx context.Context, userID int, start, end time.Time, scope string) ([]models.SpendingByCategory, error) {
+	spendingByCategory, err := h.expenseTotals(ctx, userID, start, end, scope)
+	if err != nil {
+		return nil, err
+	}
 
 	var analytics []models.SpendingByCategory
 	var totalSpending float64
 
-	for rows.Next() {
-		var spending models.SpendingByCategory
-		err := rows.Scan(&spending.CategoryID, &spending.CategoryName, &spending.Amount)
-		if err != nil {
-			log.Printf("Error scanning spending row: %v", err)
-			continue
-		}
-		analytics = append(analytics, spending)
-		totalSpending += spending.Amount
+	for _, category := range spendingByCategory {
+		analytics = append(analytics, models.SpendingByCategory{
+			CategoryID:   category.CategoryID,
+			CategoryName: category.CategoryName,
+			Amount:       category.CurrentSpend,
+		})
+		totalSpending += category.CurrentSpend
 	}
 
 	for i := range analytics {
@@ -410,8 +986,47 @@ func (h *Handler) GetSpendingAnalytics(c *gin.Context) {
 			analytics[i].Percentage = 0
 		}
 	}
+	return analytics, nil
+}
 
-	c.JSON(http.StatusOK, analytics)
+// expenseTotals returns every expense category of the user with its spending
+// in the range, largest first.
+func (h *Handler) expenseTotals(ctx context.Context, userID int, start, end time.Time, scope string) ([]models.SpendingTrend, error) {
+	totals, err := h.svc.CategoryTotals(ctx, userID, start, end, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make(map[int]float64)
+	for _, total := range totals {
+		if total.Type == "expense" {
+			spent[total.CategoryID] += total.Amount
+		}
+	}
+
+	rows, err := h.svc.ReadDB().QueryContext(ctx, `SELECT id, name FROM categories WHERE user_id = $1 AND type = 'expense'`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.SpendingTrend
+	for rows.Next() {
+		var category models.SpendingTrend
+		if err := rows.Scan(&category.CategoryID, &category.CategoryName); err != nil {
+			return nil, err
+		}
+		category.CurrentSpend = spent[category.CategoryID]
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].CurrentSpend > categories[j].CurrentSpend
+	})
+	return categories, nil
 }
 
 func (h *Handler) GetSpendingTrends(c *gin.Context) {
@@ -424,10 +1039,10 @@ func (h *Handler) GetSpendingTrends(c *gin.Context) {
 	}
 
 	if req.Date == "" {
-		req.Date = time.Now().Format("2006-01-02")
+		req.Date = time.Now().In(h.svc.Location(c.Request.Context(), userID)).Format("2006-01-02")
 	}
 
-	trends, err := h.calculateSpendingTrends(userID, req.Period, req.Date)
+	trends, err := h.calculateSpendingTrends(c.Request.Context(), userID, req.Period, req.Date, req.Scope)
 	if err != nil {
 		log.Printf("Error calculating spending trends: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate spending trends"})
@@ -443,8 +1058,13 @@ func (h *Handler) GetSpendingTrends(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *Handler) calculateSpendingTrends(userID int, period, dateStr string) ([]models.SpendingTrend, error) {
-	date, err := time.Parse("2006-01-02", dateStr)
+func (h *Handler) calculateSpendingTrends(ctx context.Context, userID int, period, dateStr, scope string) ([]models.SpendingTrend, error) {
+	settings, err := h.svc.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", dateStr, service.SettingsLocation(settings))
 	if err != nil {
 		return nil, err
 	}
@@ -459,106 +1079,51 @@ func (h *Handler) calculateSpendingTrends(userID int, period, dateStr string) ([
 		prevStartDate = startDate.AddDate(0, 0, -1)
 		prevEndDate = startDate
 	case "week":
-		weekday := int(date.Weekday())
-		if weekday == 0 {
-			weekday = 7
-		}
-		startDate = date.AddDate(0, 0, -(weekday - 1))
-		startDate = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+		startDate = service.WeekStart(date, settings.FirstDayOfWeek)
 		endDate = startDate.AddDate(0, 0, 7)
 		prevStartDate = startDate.AddDate(0, 0, -7)
 		prevEndDate = startDate
 	case "month":
-		startDate = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		startDate = service.FiscalMonthStart(date, settings.FiscalMonthStartDay)
 		endDate = startDate.AddDate(0, 1, 0)
 		prevStartDate = startDate.AddDate(0, -1, 0)
 		prevEndDate = startDate
+	case "pay_period":
+		startDate, endDate = service.PayPeriod(settings, date)
+		prevStartDate, prevEndDate = service.PayPeriod(settings, startDate.AddDate(0, 0, -1))
 	default:
 		return nil, fmt.Errorf("invalid period: %s", period)
 	}
 
-	currentQuery := `
-		SELECT c.id, c.name, COALESCE(SUM(t.amount), 0) as amount
-		FROM categories c
-		LEFT JOIN transactions t ON c.id = t.category_id 
-			AND t.user_id = $1 
-			AND t.type = 'expense'
-			AND t.date >= $2 
-			AND t.date < $3
-		WHERE c.user_id = $1 AND c.type = 'expense'
-		GROUP BY c.id, c.name
-		ORDER BY amount DESC
-	`
-
-	currentRows, err := h.db.Query(currentQuery, userID, startDate, endDate)
+	current, err := h.expenseTotals(ctx, userID, startDate, endDate, scope)
 	if err != nil {
 		return nil, err
 	}
-	defer currentRows.Close()
 
-	prevQuery := `
-		SELECT c.id, COALESCE(SUM(t.amount), 0) as amount
-		FROM categories c
-		LEFT JOIN transactions t ON c.id = t.category_id 
-			AND t.user_id = $1 
-			AND t.type = 'expense'
-			AND t.date >= $2 
-			AND t.date < $3
-		WHERE c.user_id = $1 AND c.type = 'expense'
-		GROUP BY c.id
-	`
-
-	prevRows, err := h.db.Query(prevQuery, userID, prevStartDate, prevEndDate)
+	previous, err := h.svc.CategoryTotals(ctx, userID, prevStartDate, prevEndDate, scope)
 	if err != nil {
 		return nil, err
 	}
-	defer prevRows.Close()
-
 	prevSpending := make(map[int]float64)
-	for prevRows.Next() {
-		var categoryID int
-		var amount float64
-		if err := prevRows.Scan(&categoryID, &amount); err != nil {
-			continue
+	for _, total := range previous {
+		if total.Type == "expense" {
+			prevSpending[total.CategoryID] += total.Amount
 		}
-		prevSpending[categoryID] = amount
 	}
 
-	var trends []models.SpendingTrend
-	for currentRows.Next() {
-		var trend models.SpendingTrend
-		if err := currentRows.Scan(&trend.CategoryID, &trend.CategoryName, &trend.CurrentSpend); err != nil {
-			continue
-		}
-
-		historicalAvg, err := h.getHistoricalAverage(userID, trend.CategoryID, period)
-		if err != nil {
-			historicalAvg = trend.CurrentSpend
-		}
+	historical, err := h.historicalAverages(ctx, userID, period, scope)
+	if err != nil {
+		return nil, err
+	}
 
+	var trends []models.SpendingTrend
+	for _, trend := range current {
+		historicalAvg := historical[trend.CategoryID]
 		prevAmount := prevSpending[trend.CategoryID]
 		prediction := h.calculatePrediction(trend.CurrentSpend, prevAmount, historicalAvg, period)
 
 		trend.PredictedSpend = prediction
-
-		if prevAmount > 0 {
-			change := ((trend.CurrentSpend - prevAmount) / prevAmount) * 100
-			trend.ChangePercent = change
-
-			if change > models.TrendLimits.UpThreshold {
-				trend.TrendDirection = models.TrendDirections.Up
-			} else if change < models.TrendLimits.DownThreshold {
-				trend.TrendDirection = models.TrendDirections.Down
-			} else {
-				trend.TrendDirection = models.TrendDirections.Stable
-			}
-		} else if prevAmount == 0 && trend.CurrentSpend > 0 {
-			trend.TrendDirection = models.TrendDirections.Up
-			trend.ChangePercent = 999.9
-		} else {
-			trend.TrendDirection = models.TrendDirections.New
-			trend.ChangePercent = 0
-		}
+		trend.TrendDirection, trend.ChangePercent = trendDirection(trend.CurrentSpend, prevAmount)
 
 		trends = append(trends, trend)
 	}
@@ -566,29 +1131,53 @@ func (h *Handler) calculateSpendingTrends(userID int, period, dateStr string) ([
 	return trends, nil
 }
 
-func (h *Handler) getHistoricalAverage(userID, categoryID int, period string) (float64, error) {
+// trendDirection compares spending with the previous period's and returns
+// the direction and the change in percent. Spending where there was none is
+// reported as a 999.9% rise.
+func trendDirection(current, previous float64) (string, float64) {
+	if previous > 0 {
+		change := ((current - previous) / previous) * 100
+
+		if change > models.TrendLimits.UpThreshold {
+			return models.TrendDirections.Up, change
+		} else if change < models.TrendLimits.DownThreshold {
+			return models.TrendDirections.Down, change
+		}
+		return models.TrendDirections.Stable, change
+	} else if current > 0 {
+		return models.TrendDirections.Up, 999.9
+	}
+	return models.TrendDirections.New, 0
+}
+
+// historicalAverages returns the average expense transaction amount per
+// category over the lookback window for the period.
+func (h *Handler) historicalAverages(ctx context.Context, userID int, period, scope string) (map[int]float64, error) {
 	var days int
 	switch period {
 	case "day":
 		days = models.HistoricalDays.DayLookback
 	case "week":
 		days = models.HistoricalDays.WeekLookback
-	case "month":
+	case "month", "pay_period":
 		days = models.HistoricalDays.MonthLookback
 	}
 
-	query := `
-		SELECT COALESCE(AVG(amount), 0)
-		FROM transactions 
-		WHERE user_id = $1 
-			AND category_id = $2 
-			AND type = 'expense'
-			AND date >= NOW() - ($3 * INTERVAL '1 day')
-	`
+	now := time.Now().In(h.svc.Location(ctx, userID))
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -days)
 
-	var avg float64
-	err := h.db.QueryRow(query, userID, categoryID, days).Scan(&avg)
-	return avg, err
+	totals, err := h.svc.CategoryTotals(ctx, userID, since, time.Time{}, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	averages := make(map[int]float64)
+	for _, total := range totals {
+		if total.Type == "expense" && total.Count > 0 {
+			averages[total.CategoryID] = total.Amount / float64(total.Count)
+		}
+	}
+	return averages, nil
 }
 
 func (h *Handler) calculatePrediction(current, previous, historical float64, period string) float64 {
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetHousehold(c *gin.Context) {
+	household, err := h.svc.Household(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching household: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+func (h *Handler) UpdateHousehold(c *gin.Context) {
+	var req models.UpdateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	household, err := h.svc.UpdateHousehold(c.Request.Context(), c.GetInt("user_id"), req.ApprovalThreshold)
+	if err == service.ErrNestedHousehold {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update household: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update household"})
+		return
+	}
+
+	c.JSON(http.StatusOK, household)
+}
+
+func (h *Handler) AddHouseholdMember(c *gin.Context) {
+	var req models.AddHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.AddHouseholdMember(c.Request.Context(), c.GetInt("user_id"), req.Email, req.Role, req.AccountIDs)
+	switch {
+	case err == service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "No user with this email address"})
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrInvalidMember:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrMemberExists, err == service.ErrNestedHousehold:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to add household member: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add household member"})
+	default:
+		c.JSON(http.StatusCreated, member)
+	}
+}
+
+func (h *Handler) CreateManagedProfile(c *gin.Context) {
+	var req models.ManagedProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	localPart, _, _ := strings.Cut(req.Email, "@")
+	strength, err := auth.PasswordPolicyFromConfig(config.Get().Auth).Validate(c.Request.Context(), req.Password, localPart, req.FirstName, req.LastName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "strength": strength})
+		return
+	}
+
+	member, err := h.svc.CreateManagedProfile(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case err == service.ErrEmailTaken, err == service.ErrNestedHousehold:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to create managed profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
+	default:
+		c.JSON(http.StatusCreated, member)
+	}
+}
+
+func (h *Handler) UpdateHouseholdMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.UpdateHouseholdMember(c.Request.Context(), c.GetInt("user_id"), id, req.Role, req.AccountIDs)
+	switch {
+	case err == service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household member not found"})
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update household member: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update household member"})
+	default:
+		c.JSON(http.StatusOK, member)
+	}
+}
+
+func (h *Handler) RemoveHouseholdMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.svc.RemoveHouseholdMember(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household member not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to remove household member: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove household member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Household member removed"})
+}
+
+// CreateHouseholdTransaction answers 201 with the transaction, or 202 with
+// the approval when the owner has to confirm it first.
+func (h *Handler) CreateHouseholdTransaction(c *gin.Context) {
+	var req models.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_id is required"})
+		return
+	}
+
+	transaction := models.Transaction{
+		AccountID:   req.AccountID,
+		CategoryID:  req.CategoryID,
+		Amount:      req.Amount,
+		Type:        req.Type,
+		Description: req.Description,
+		Date:        time.Now(),
+	}
+	if req.Date != nil {
+		transaction.Date = *req.Date
+	}
+
+	approval, err := h.svc.CreateHouseholdTransaction(c.Request.Context(), c.GetInt("user_id"), &transaction)
+	if abortWithQuota(c, err) {
+		return
+	}
+	var capErr *service.BudgetCapError
+	switch {
+	case errors.As(err, &capErr):
+		abortWithBudgetCap(c, capErr)
+	case err == service.ErrNotInHousehold, err == service.ErrReadOnlyMember:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err == service.ErrAccountNotFound, err == service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrAccountArchived:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to create household transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+	case approval != nil:
+		c.JSON(http.StatusAccepted, approval)
+	default:
+		c.JSON(http.StatusCreated, transaction)
+	}
+}
+
+func (h *Handler) GetHouseholdTransactions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.Pagination.DefaultLimit)))
+	if limit <= 0 || limit > models.Pagination.MaxLimit {
+		limit = models.Pagination.DefaultLimit
+	}
+	accountID, _ := strconv.Atoi(c.Query("account_id"))
+
+	transactions, err := h.svc.HouseholdTransactions(c.Request.Context(), c.GetInt("user_id"), accountID, limit)
+	switch {
+	case err == service.ErrNotInHousehold:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err == service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error fetching household transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+	default:
+		c.JSON(http.StatusOK, transactions)
+	}
+}
+
+func (h *Handler) GetTransactionApprovals(c *gin.Context) {
+	status := c.DefaultQuery("status", models.DraftStatuses.Pending)
+
+	approvals, err := h.svc.TransactionApprovals(c.Request.Context(), c.GetInt("user_id"), status)
+	if err != nil {
+		log.Printf("Error fetching approvals: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approvals"})
+		return
+	}
+
+	c.JSON(http.StatusOK, approvals)
+}
+
+func (h *Handler) ApproveTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid approval ID"})
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	transaction, err := h.svc.ApproveTransaction(c.Request.Context(), c.GetInt("user_id"), id, force)
+	if abortWithQuota(c, err) {
+		return
+	}
+	var capErr *service.BudgetCapError
+	if errors.As(err, &capErr) {
+		abortWithBudgetCap(c, capErr)
+		return
+	}
+	switch {
+	case err == service.ErrApprovalNotFound, err == service.ErrAccountNotFound, err == service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrAccountArchived:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to approve transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve transaction"})
+	default:
+		c.JSON(http.StatusCreated, transaction)
+	}
+}
+
+func (h *Handler) RejectTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid approval ID"})
+		return
+	}
+
+	err = h.svc.RejectTransaction(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrApprovalNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to reject transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction rejected"})
+}
+
+func (h *Handler) GetAllowanceRules(c *gin.Context) {
+	rules, err := h.svc.AllowanceRules(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching allowance rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch allowance rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *Handler) CreateAllowanceRule(c *gin.Context) {
+	var req models.AllowanceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must use the YYYY-MM-DD format"})
+		return
+	}
+
+	rule, err := h.svc.CreateAllowanceRule(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case err == service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household member not found"})
+	case err == service.ErrAccountNotFound, err == service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to create allowance rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create allowance rule"})
+	default:
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+func (h *Handler) DeleteAllowanceRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid allowance rule ID"})
+		return
+	}
+
+	err = h.svc.DeleteAllowanceRule(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrAllowanceNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete allowance rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete allowance rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Allowance rule deleted"})
+}
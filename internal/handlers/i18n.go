@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"personal-finance-tracker/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localizedFields are the error response fields that carry fixed messages:
+// "error" in v1 responses and "title"/"detail" in v2 problem documents.
+var localizedFields = []string{"error", "title", "detail"}
+
+// Localize picks the response language from Accept-Language and translates
+// error messages found in the message catalog; anything else, such as
+// validation errors with request-specific text, is passed through.
+func (h *Handler) Localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		localizer := i18n.New(i18n.Match(c.GetHeader("Accept-Language")))
+		c.Set("localizer", localizer)
+		c.Header("Content-Language", localizer.Tag().String())
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		c.Writer = &localizedWriter{ResponseWriter: c.Writer, localizer: localizer}
+		c.Next()
+	}
+}
+
+type localizedWriter struct {
+	gin.ResponseWriter
+	localizer *i18n.Localizer
+}
+
+func (w *localizedWriter) Write(data []byte) (int, error) {
+	if w.Status() < 400 || !strings.Contains(w.Header().Get("Content-Type"), "json") {
+		return w.ResponseWriter.Write(data)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+
+	translated := false
+	for _, field := range localizedFields {
+		if text, ok := body[field].(string); ok {
+			if message, ok := w.localizer.Translated(text); ok {
+				body[field] = message
+				translated = true
+			}
+		}
+	}
+	if !translated {
+		return w.ResponseWriter.Write(data)
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(encoded); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *localizedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
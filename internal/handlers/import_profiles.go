@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetImportProfiles(c *gin.Context) {
+	profiles, err := h.svc.ImportProfiles(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching import profiles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import profiles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+func (h *Handler) CreateImportProfile(c *gin.Context) {
+	var req models.ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := importProfileFromRequest(req)
+	profile.UserID = c.GetInt("user_id")
+	err := h.svc.CreateImportProfile(c.Request.Context(), &profile)
+	h.respondImportProfile(c, http.StatusCreated, profile, err, "Failed to create import profile")
+}
+
+func (h *Handler) UpdateImportProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import profile ID"})
+		return
+	}
+
+	var req models.ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile := importProfileFromRequest(req)
+	profile.ID = id
+	profile.UserID = c.GetInt("user_id")
+	err = h.svc.UpdateImportProfile(c.Request.Context(), &profile)
+	h.respondImportProfile(c, http.StatusOK, profile, err, "Failed to update import profile")
+}
+
+func (h *Handler) DeleteImportProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import profile ID"})
+		return
+	}
+
+	err = h.svc.DeleteImportProfile(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrImportProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete import profile: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete import profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Import profile deleted"})
+}
+
+func importProfileFromRequest(req models.ImportProfileRequest) models.ImportProfile {
+	return models.ImportProfile{
+		Name:             req.Name,
+		Columns:          req.Columns,
+		Delimiter:        req.Delimiter,
+		DateFormat:       req.DateFormat,
+		DecimalSeparator: req.DecimalSeparator,
+		SignConvention:   req.SignConvention,
+		AccountID:        req.AccountID,
+	}
+}
+
+func (h *Handler) respondImportProfile(c *gin.Context, status int, profile models.ImportProfile, err error, message string) {
+	switch err {
+	case nil:
+		c.JSON(status, profile)
+	case service.ErrImportProfileNotFound, service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrImportProfileColumns, service.ErrImportDateFormat, service.ErrImportSeparators:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrImportProfileExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("%s: %v", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
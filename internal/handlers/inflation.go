@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPersonalInflation shows how the price per unit of purchases recorded
+// with a quantity moved between start_date and end_date, in series by
+// ?by=tag (the default), merchant or category.
+func (h *Handler) GetPersonalInflation(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	startDate, endDate, ok := parseDateRange(c, h.svc.Location(c.Request.Context(), userID))
+	if !ok {
+		return
+	}
+
+	by := c.DefaultQuery("by", "tag")
+	switch by {
+	case "tag", "merchant", "category":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be tag, merchant or category"})
+		return
+	}
+
+	inflation, err := h.svc.PersonalInflation(c.Request.Context(), userID, startDate, endDate, by)
+	if err != nil {
+		log.Printf("Error getting personal inflation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get personal inflation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, inflation)
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/emailingest"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetIngestionAddress(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var token string
+	err := h.db.QueryRowContext(c.Request.Context(), `SELECT token FROM ingestion_addresses WHERE user_id = $1`, userID).Scan(&token)
+	if err == sql.ErrNoRows {
+		token, err = h.rotateIngestionToken(c.Request.Context(), userID)
+	}
+	if err != nil {
+		log.Printf("Error loading ingestion address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load ingestion address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ingestionAddress(token))
+}
+
+func (h *Handler) RotateIngestionAddress(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	token, err := h.rotateIngestionToken(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error rotating ingestion address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate ingestion address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ingestionAddress(token))
+}
+
+func (h *Handler) rotateIngestionToken(ctx context.Context, userID int) (string, error) {
+	token, err := auth.GenerateRandomToken(12)
+	if err != nil {
+		return "", err
+	}
+
+	query := `INSERT INTO ingestion_addresses (user_id, token, created_at, updated_at)
+			  VALUES ($1, $2, NOW(), NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, updated_at = NOW()`
+
+	_, err = h.db.ExecContext(ctx, query, userID, token)
+	return token, err
+}
+
+func ingestionAddress(token string) models.IngestionAddress {
+	return models.IngestionAddress{
+		Address:    "receipts+" + token + "@" + config.Get().Ingestion.EmailDomain,
+		WebhookURL: "/api/v1/ingestion/email/" + token,
+	}
+}
+
+func (h *Handler) IngestEmail(c *gin.Context) {
+	var userID int
+	err := h.db.QueryRowContext(c.Request.Context(), `SELECT user_id FROM ingestion_addresses WHERE token = $1`, c.Param("token")).Scan(&userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown ingestion address"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving ingestion address: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest email"})
+		return
+	}
+
+	email, err := readInboundEmail(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse email: " + err.Error()})
+		return
+	}
+
+	parsed, ok := emailingest.Extract(email)
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No transaction amount found in email"})
+		return
+	}
+
+	draft := models.DraftTransaction{
+		UserID:      userID,
+		Source:      "email",
+		Status:      models.DraftStatuses.Pending,
+		Merchant:    parsed.Merchant,
+		Amount:      parsed.Amount,
+		Type:        parsed.Type,
+		Description: parsed.Description,
+		Date:        parsed.Date,
+		RawSubject:  email.Subject,
+	}
+
+	query := `INSERT INTO draft_transactions (user_id, source, status, merchant, amount, type, description, date, raw_subject, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, draft.UserID, draft.Source, draft.Status, draft.Merchant, draft.Amount,
+		draft.Type, draft.Description, draft.Date, draft.RawSubject).
+		Scan(&draft.ID, &draft.CreatedAt, &draft.UpdatedAt)
+	if err != nil {
+		log.Printf("Error saving draft transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest email"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, draft)
+}
+
+func readInboundEmail(c *gin.Context) (emailingest.Email, error) {
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		var req models.InboundEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return emailingest.Email{}, err
+		}
+
+		email := emailingest.Email{From: req.From, Subject: req.Subject, Body: req.Text, Date: time.Now()}
+		if email.Body == "" {
+			email.Body = req.HTML
+		}
+		if date, err := time.Parse(time.RFC3339, req.Date); err == nil {
+			email.Date = date
+		}
+		return email, nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, models.IngestionSettings.MaxEmailBytes))
+	if err != nil {
+		return emailingest.Email{}, err
+	}
+	return emailingest.ParseMessage(raw)
+}
+
+func (h *Handler) GetDraftTransactions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	status := c.DefaultQuery("status", models.DraftStatuses.Pending)
+
+	query := `SELECT id, user_id, source, status, COALESCE(merchant, ''), amount, type, COALESCE(description, ''),
+			  date, COALESCE(raw_subject, ''), transaction_id, created_at, updated_at
+			  FROM draft_transactions WHERE user_id = $1 AND status = $2 ORDER BY date DESC`
+
+	rows, err := h.db.QueryContext(c.Request.Context(), query, userID, status)
+	if err != nil {
+		log.Printf("Error fetching drafts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch drafts"})
+		return
+	}
+	defer rows.Close()
+
+	drafts := []models.DraftTransaction{}
+	for rows.Next() {
+		var d models.DraftTransaction
+		err := rows.Scan(&d.ID, &d.UserID, &d.Source, &d.Status, &d.Merchant, &d.Amount, &d.Type,
+			&d.Description, &d.Date, &d.RawSubject, &d.TransactionID, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		drafts = append(drafts, d)
+	}
+
+	c.JSON(http.StatusOK, drafts)
+}
+
+func (h *Handler) ApproveDraftTransaction(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return
+	}
+
+	var req models.ApproveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+	defer tx.Rollback()
+
+	transaction := models.Transaction{UserID: userID, AccountID: req.AccountID, CategoryID: req.CategoryID}
+	query := `SELECT amount, type, COALESCE(description, ''), date FROM draft_transactions
+			  WHERE id = $1 AND user_id = $2 AND status = $3 FOR UPDATE`
+
+	err = tx.QueryRowContext(ctx, query, id, userID, models.DraftStatuses.Pending).
+		Scan(&transaction.Amount, &transaction.Type, &transaction.Description, &transaction.Date)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending draft not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading draft: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+
+	if req.Amount != nil {
+		transaction.Amount = *req.Amount
+	}
+	if req.Description != nil {
+		transaction.Description = *req.Description
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	balance, err := h.svc.InsertWithinCap(ctx, tx, &transaction, force)
+	if err != nil {
+		if abortWithQuota(c, err) {
+			return
+		}
+		var capErr *service.BudgetCapError
+		if errors.As(err, &capErr) {
+			abortWithBudgetCap(c, capErr)
+			return
+		}
+		if err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrAccountArchived {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error creating transaction from draft: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE draft_transactions SET status = $1, transaction_id = $2, updated_at = NOW() WHERE id = $3 AND user_id = $4`,
+		models.DraftStatuses.Approved, transaction.ID, id, userID)
+	if err != nil {
+		log.Printf("Error updating draft status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+
+	if err := service.RecordTransactionCreated(ctx, tx, transaction, balance); err != nil {
+		log.Printf("Error recording transaction event: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve draft"})
+		return
+	}
+
+	h.svc.TransactionCreated(transaction)
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+func (h *Handler) RejectDraftTransaction(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid draft ID"})
+		return
+	}
+
+	result, err := h.db.ExecContext(c.Request.Context(), `UPDATE draft_transactions SET status = $1, updated_at = NOW()
+			  WHERE id = $2 AND user_id = $3 AND status = $4`,
+		models.DraftStatuses.Rejected, id, userID, models.DraftStatuses.Pending)
+	if err != nil {
+		log.Printf("Error rejecting draft: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject draft"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending draft not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Draft rejected"})
+}
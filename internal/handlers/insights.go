@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/insights"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetInsights(c *gin.Context) {
+	list, err := h.svc.Insights().List(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Error fetching insights: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch insights"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *Handler) DismissInsight(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid insight ID"})
+		return
+	}
+
+	err = h.svc.Insights().Dismiss(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == insights.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to dismiss insight: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss insight"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Insight dismissed"})
+	}
+}
+
+func (h *Handler) SnoozeInsight(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid insight ID"})
+		return
+	}
+
+	var req models.InsightSnoozeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	until := time.Now().AddDate(0, 0, req.Days)
+	insight, err := h.svc.Insights().Snooze(c.Request.Context(), c.GetInt("user_id"), id, until)
+	switch {
+	case err == insights.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to snooze insight: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to snooze insight"})
+	default:
+		c.JSON(http.StatusOK, insight)
+	}
+}
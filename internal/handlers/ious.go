@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetUserLinks(c *gin.Context) {
+	links, err := h.svc.UserLinks(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching user links: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (h *Handler) RequestUserLink(c *gin.Context) {
+	var req models.UserLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.svc.RequestUserLink(c.Request.Context(), c.GetInt("user_id"), req.Email)
+	switch err {
+	case nil:
+	case service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrInvalidLink:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case service.ErrLinkExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to request user link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+func (h *Handler) AcceptUserLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	err = h.svc.AcceptUserLink(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrLinkNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to accept user link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link accepted"})
+}
+
+func (h *Handler) DeleteUserLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	err = h.svc.DeleteUserLink(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrLinkNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete user link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link removed"})
+}
+
+func (h *Handler) GetIOUs(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.IOUStatuses.Pending, models.IOUStatuses.Confirmed, models.IOUStatuses.Disputed, models.IOUStatuses.Settled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, confirmed, disputed or settled"})
+		return
+	}
+
+	ious, err := h.svc.IOUs(c.Request.Context(), c.GetInt("user_id"), status)
+	if err != nil {
+		log.Printf("Error fetching IOUs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch IOUs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ious)
+}
+
+func (h *Handler) CreateIOU(c *gin.Context) {
+	var req models.IOURequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	iou, err := h.svc.CreateIOU(c.Request.Context(), c.GetInt("user_id"), req)
+	if err == service.ErrLinkNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create IOU: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create IOU"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, iou)
+}
+
+func (h *Handler) ConfirmIOU(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IOU ID"})
+		return
+	}
+
+	iou, err := h.svc.ConfirmIOU(c.Request.Context(), c.GetInt("user_id"), id)
+	h.respondIOU(c, iou, err)
+}
+
+func (h *Handler) DisputeIOU(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IOU ID"})
+		return
+	}
+
+	var req models.IOUDisputeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	iou, err := h.svc.DisputeIOU(c.Request.Context(), c.GetInt("user_id"), id, req.Reason)
+	h.respondIOU(c, iou, err)
+}
+
+func (h *Handler) SettleIOU(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IOU ID"})
+		return
+	}
+
+	var req models.IOUSettleRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	iou, err := h.svc.SettleIOU(c.Request.Context(), c.GetInt("user_id"), id, req.AccountID)
+	h.respondIOU(c, iou, err)
+}
+
+func (h *Handler) respondIOU(c *gin.Context, iou models.IOU, err error) {
+	if abortWithQuota(c, err) {
+		return
+	}
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, iou)
+	case service.ErrIOUNotFound, service.ErrAccountNotFound, service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrAccountArchived, service.ErrCounterpartyAccount:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrIOUCounterparty:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrIOUStatus:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to update IOU: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update IOU"})
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/jobs"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetJobs(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.JobStatuses.Pending, models.JobStatuses.Running, models.JobStatuses.Completed, models.JobStatuses.Dead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job status"})
+		return
+	}
+
+	list, err := h.svc.Jobs().List(c.Request.Context(), c.GetInt("user_id"), status, models.Pagination.DefaultLimit)
+	if err != nil {
+		log.Printf("Error fetching jobs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *Handler) GetJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.svc.Jobs().Get(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == jobs.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching job: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *Handler) RetryJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := h.svc.Jobs().Retry(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == jobs.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if err == jobs.ErrNotDead {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error retrying job: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) DownloadJobFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	file, err := h.svc.JobFile(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == jobs.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No file available for this job"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching job file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch file"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+file.Name+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func (h *Handler) CreateImport(c *gin.Context) {
+	accountID := 0
+	if value := c.PostForm("account_id"); value != "" {
+		var err error
+		if accountID, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import file is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > models.ImportSettings.MaxFileBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, models.ImportSettings.MaxFileBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+		return
+	}
+
+	job, err := h.svc.StartImport(c.Request.Context(), c.GetInt("user_id"), accountID, header.Filename, data, c.PostForm("profile"))
+	switch err {
+	case nil:
+	case service.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	case service.ErrImportProfileNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrInvalidImportFile, service.ErrImportAccountRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Error starting import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+strconv.Itoa(job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) CreateExport(c *gin.Context) {
+	var req models.ExportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	job, err := h.svc.StartExport(c.Request.Context(), c.GetInt("user_id"), req)
+	if err != nil {
+		log.Printf("Error starting export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+		return
+	}
+
+	c.Header("Location", "/api/v1/jobs/"+strconv.Itoa(job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLimits shows the caller's plan, its limits and how much of them is
+// used.
+func (h *Handler) GetLimits(c *gin.Context) {
+	status, err := h.svc.LimitsStatus(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching limits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *Handler) GetPlans(c *gin.Context) {
+	plans, err := h.svc.Plans(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching plans: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// SavePlan creates or replaces the plan named in the path.
+func (h *Handler) SavePlan(c *gin.Context) {
+	var req models.PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.svc.SavePlan(c.Request.Context(), c.Param("id"), req)
+	if err == service.ErrPlanID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrPlanPrice {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to save plan: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *Handler) DeletePlan(c *gin.Context) {
+	err := h.svc.DeletePlan(c.Request.Context(), c.Param("id"))
+	if err == service.ErrPlanNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete plan: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plan deleted"})
+}
+
+func (h *Handler) GetUserLimits(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	status, err := h.svc.LimitsStatus(c.Request.Context(), userID)
+	if err == service.ErrUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching limits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetUserPlan assigns a user a plan and overrides of its limits.
+func (h *Handler) SetUserPlan(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UserPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.svc.SetUserPlan(c.Request.Context(), userID, req)
+	if err == service.ErrPlanNotFound || err == service.ErrUserNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to set user plan: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set user plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// enforceQuota answers 402 Payment Required, and returns false, when adding
+// more of resource would take the caller over their plan's limit.
+func (h *Handler) enforceQuota(c *gin.Context, resource string, adding int64) bool {
+	err := h.svc.CheckQuota(c.Request.Context(), c.GetInt("user_id"), resource, adding)
+	if err == nil {
+		return true
+	}
+	if !abortWithQuota(c, err) {
+		log.Printf("Error checking %s quota: %v", resource, err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to check plan limits")
+	}
+	return false
+}
+
+// abortWithQuota answers a QuotaError with 402 and the limit that was hit,
+// reporting whether err was one.
+func abortWithQuota(c *gin.Context, err error) bool {
+	var quotaErr *service.QuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	quota := gin.H{"resource": quotaErr.Resource, "limit": quotaErr.Limit, "used": quotaErr.Used}
+	if c.GetInt("api_version") < 2 {
+		c.AbortWithStatusJSON(http.StatusPaymentRequired, gin.H{"error": quotaErr.Error(), "quota": quota})
+		return true
+	}
+
+	body, marshalErr := json.Marshal(struct {
+		models.Problem
+		Quota gin.H `json:"quota"`
+	}{
+		Problem: models.Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(http.StatusPaymentRequired),
+			Status:   http.StatusPaymentRequired,
+			Detail:   quotaErr.Error(),
+			Instance: c.Request.URL.Path,
+		},
+		Quota: quota,
+	})
+	if marshalErr != nil {
+		c.AbortWithStatus(http.StatusPaymentRequired)
+		return true
+	}
+	c.Data(http.StatusPaymentRequired, problemContentType, body)
+	c.Abort()
+	return true
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLocationSpending clusters expenses by where they were made for map
+// views. ?precision= sets how many decimal places of the coordinates a
+// cluster shares; the date, scope and geo filters of the transaction
+// listing apply too.
+func (h *Handler) GetLocationSpending(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	startDate, endDate, ok := parseDateRange(c, h.svc.Location(c.Request.Context(), userID))
+	if !ok {
+		return
+	}
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+	geo, ok := parseGeoFilter(c)
+	if !ok {
+		return
+	}
+
+	precision := models.GeoSettings.DefaultPrecision
+	if value := c.Query("precision"); value != "" {
+		var err error
+		if precision, err = strconv.Atoi(value); err != nil || precision < 0 || precision > models.GeoSettings.MaxPrecision {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("precision must be between 0 and %d", models.GeoSettings.MaxPrecision)})
+			return
+		}
+	}
+
+	spending, err := h.svc.SpendingByLocation(c.Request.Context(), userID, startDate, endDate, scope, precision, geo)
+	if err != nil {
+		log.Printf("Error getting location spending: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get spending analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, spending)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/redact"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogBodies logs the request and response bodies of every request when
+// HTTP_LOG_BODIES is on, for debugging clients. Passwords, tokens and other
+// credentials are redacted and account and card numbers masked; binary
+// bodies are only summarized. Routes opt out with SkipBodyLogging.
+func (h *Handler) LogBodies() gin.HandlerFunc {
+	if !config.Get().HTTP.LogBodies {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limit := models.HTTPLogSettings.MaxBodyBytes
+
+	return func(c *gin.Context) {
+		var request []byte
+		if c.Request.Body != nil {
+			request, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+			c.Request.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(request), c.Request.Body), Closer: c.Request.Body}
+		}
+		writer := &bodyLogWriter{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = writer
+
+		c.Next()
+
+		if c.GetBool("skip_body_log") {
+			return
+		}
+		path := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			path += "?" + redact.Query(c.Request.URL.Query()).Encode()
+		}
+		requestBody, requestCut := truncate(request, limit)
+		responseBody, responseCut := truncate(writer.body.Bytes(), limit)
+		log.Printf("DEBUG %s %s -> %d (request %s)\n  request: %s\n  response: %s",
+			c.Request.Method, path, c.Writer.Status(), c.GetString("request_id"),
+			redact.Body(c.ContentType(), requestBody, requestCut),
+			redact.Body(c.Writer.Header().Get("Content-Type"), responseBody, responseCut))
+	}
+}
+
+// SkipBodyLogging keeps a route's bodies out of LogBodies, for routes whose
+// payloads are too sensitive or too long-lived to log, such as inbound
+// emails and event streams.
+func (h *Handler) SkipBodyLogging() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("skip_body_log", true)
+		c.Next()
+	}
+}
+
+func truncate(body []byte, limit int) ([]byte, bool) {
+	if len(body) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
+
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyLogWriter keeps the first bytes of the response for LogBodies.
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body  bytes.Buffer
+	limit int
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) keep(data []byte) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIconCatalog lists the icons categories may use. The catalog only
+// changes with a release, so it is public and cacheable.
+func (h *Handler) GetIconCatalog(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.JSON(http.StatusOK, service.IconCatalog())
+}
+
+// GetColorCatalog lists the color palette with the foreground to draw on
+// each color.
+func (h *Handler) GetColorCatalog(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.JSON(http.StatusOK, service.ColorCatalog())
+}
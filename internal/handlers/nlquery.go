@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/nlquery"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) QueryAnalytics(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetInt("user_id")
+
+	var req models.AnalyticsQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := h.getCategoryNames(ctx, userID)
+	if err != nil {
+		log.Printf("Error loading categories for analytics query: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer query"})
+		return
+	}
+
+	interpretation, err := h.queryParser.Parse(ctx, req.Question, time.Now().In(h.svc.Location(ctx, userID)), categories)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Could not interpret question"})
+		return
+	}
+
+	result, err := h.runAnalyticsQuery(ctx, userID, interpretation)
+	if err != nil {
+		log.Printf("Error running analytics query: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer query"})
+		return
+	}
+
+	// The query treats EndDate as exclusive; the response reports the last
+	// day included, like the end_date of the other analytics endpoints.
+	c.JSON(http.StatusOK, models.AnalyticsQueryResponse{
+		Question: req.Question,
+		Interpretation: models.AnalyticsQueryInterpretation{
+			Metric:    interpretation.Metric,
+			Category:  interpretation.Category,
+			StartDate: interpretation.StartDate.Format("2006-01-02"),
+			EndDate:   interpretation.EndDate.AddDate(0, 0, -1).Format("2006-01-02"),
+			Period:    interpretation.Period,
+			Source:    interpretation.Source,
+		},
+		Result: result,
+	})
+}
+
+func (h *Handler) getCategoryNames(ctx context.Context, userID int) ([]string, error) {
+	rows, err := h.svc.ReadDB().QueryContext(ctx, `SELECT DISTINCT name FROM categories WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (h *Handler) runAnalyticsQuery(ctx context.Context, userID int, interpretation *nlquery.Interpretation) (models.AnalyticsQueryResult, error) {
+	valueExpr := "COALESCE(SUM(t.amount), 0)"
+	typeFilter := ""
+	switch interpretation.Metric {
+	case nlquery.Metrics.Spend, nlquery.Metrics.Count:
+		typeFilter = " AND t.type = 'expense'"
+	case nlquery.Metrics.Income:
+		typeFilter = " AND t.type = 'income'"
+	case nlquery.Metrics.Net:
+		typeFilter = " AND t.type IN ('income', 'expense')"
+		valueExpr = "COALESCE(SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END), 0)"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, COUNT(t.id)
+		FROM transactions t
+		LEFT JOIN categories c ON c.id = t.category_id
+		WHERE t.user_id = $1 AND t.date >= $2 AND t.date < $3%s`, valueExpr, typeFilter)
+
+	params := []interface{}{userID, interpretation.StartDate, interpretation.EndDate}
+
+	if interpretation.Category != "" {
+		query += " AND LOWER(c.name) = LOWER($4)"
+		params = append(params, interpretation.Category)
+	}
+
+	var result models.AnalyticsQueryResult
+	err := h.svc.ReadDB().QueryRowContext(ctx, query, params...).Scan(&result.Value, &result.TransactionCount)
+	if interpretation.Metric == nlquery.Metrics.Count {
+		result.Value = float64(result.TransactionCount)
+	}
+
+	return result, err
+}
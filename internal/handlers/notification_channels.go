@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/secrets"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+func (h *Handler) GetNotificationChannels(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	query := `SELECT id, user_id, type, name, webhook_url, events, enabled, created_at, updated_at
+			  FROM notification_channels WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := h.db.QueryContext(c.Request.Context(), query, userID)
+	if err != nil {
+		log.Printf("Error fetching notification channels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification channels"})
+		return
+	}
+	defer rows.Close()
+
+	channels := []models.NotificationChannel{}
+	for rows.Next() {
+		var channel models.NotificationChannel
+		err := rows.Scan(&channel.ID, &channel.UserID, &channel.Type, &channel.Name, &channel.WebhookURL,
+			pq.Array(&channel.Events), &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		if channel.WebhookURL, err = secrets.Decrypt(channel.WebhookURL); err != nil {
+			log.Printf("Error decrypting webhook URL for channel %d: %v", channel.ID, err)
+			continue
+		}
+		channels = append(channels, channel)
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+func (h *Handler) CreateNotificationChannel(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.enforceQuota(c, models.QuotaResources.Webhooks, 1) {
+		return
+	}
+
+	channel := models.NotificationChannel{
+		UserID:     userID,
+		Type:       req.Type,
+		Name:       req.Name,
+		WebhookURL: req.WebhookURL,
+		Events:     req.Events,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if channel.Events == nil {
+		channel.Events = []string{}
+	}
+
+	webhookURL, err := secrets.Encrypt(channel.WebhookURL)
+	if err != nil {
+		log.Printf("Error encrypting webhook URL: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification channel"})
+		return
+	}
+
+	query := `INSERT INTO notification_channels (user_id, type, name, webhook_url, events, enabled, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, channel.UserID, channel.Type, channel.Name, webhookURL,
+		pq.Array(channel.Events), channel.Enabled).
+		Scan(&channel.ID, &channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		log.Printf("Error creating notification channel: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification channel"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, channel)
+}
+
+func (h *Handler) UpdateNotificationChannel(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req models.NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel := models.NotificationChannel{
+		ID:         id,
+		UserID:     userID,
+		Type:       req.Type,
+		Name:       req.Name,
+		WebhookURL: req.WebhookURL,
+		Events:     req.Events,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if channel.Events == nil {
+		channel.Events = []string{}
+	}
+
+	webhookURL, err := secrets.Encrypt(channel.WebhookURL)
+	if err != nil {
+		log.Printf("Error encrypting webhook URL: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification channel"})
+		return
+	}
+
+	query := `UPDATE notification_channels 
+			  SET type = $1, name = $2, webhook_url = $3, events = $4, enabled = $5, updated_at = NOW()
+			  WHERE id = $6 AND user_id = $7 RETURNING created_at, updated_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, channel.Type, channel.Name, webhookURL, pq.Array(channel.Events),
+		channel.Enabled, id, userID).Scan(&channel.CreatedAt, &channel.UpdatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating notification channel: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification channel"})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *Handler) DeleteNotificationChannel(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	result, err := h.db.ExecContext(c.Request.Context(), `DELETE FROM notification_channels WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting notification channel: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification channel"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel deleted"})
+}
+
+func (h *Handler) TestNotificationChannel(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var kind, url string
+	err = h.db.QueryRowContext(c.Request.Context(), `SELECT type, webhook_url FROM notification_channels WHERE id = $1 AND user_id = $2`, id, userID).
+		Scan(&kind, &url)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load notification channel"})
+		return
+	}
+	if url, err = secrets.Decrypt(url); err != nil {
+		log.Printf("Error decrypting webhook URL for channel %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load notification channel"})
+		return
+	}
+
+	notification := notifications.Notification{
+		UserID:    userID,
+		Type:      notifications.Types.Test,
+		Title:     "Test notification",
+		Message:   "Your notification channel is configured correctly.",
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.webhooks.Deliver(c.Request.Context(), kind, url, notification); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Delivery failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/oauth"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errOAuthState = errors.New("invalid or expired OAuth state")
+)
+
+type oauthState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Expires  int64  `json:"expires"`
+}
+
+func (h *Handler) GetOAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oauthProviders.Names()})
+}
+
+func (h *Handler) StartOAuth(c *gin.Context) {
+	provider, err := h.oauthProviders.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, nonce, err := encodeOAuthState(provider.Name())
+	if err != nil {
+		log.Printf("Failed to create OAuth state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start OAuth login"})
+		return
+	}
+
+	// The nonce cookie ties the state to this browser, so a state captured
+	// elsewhere cannot complete a login.
+	setOAuthNonceCookie(c, provider, nonce, int(models.OAuthSettings.StateTTL.Seconds()))
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state))
+}
+
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	provider, err := h.oauthProviders.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid callback parameters"})
+		return
+	}
+	params := c.Request.Form
+
+	if providerErr := params.Get("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth login was not completed: " + providerErr})
+		return
+	}
+	nonce, _ := c.Cookie(models.OAuthSettings.NonceCookie)
+	setOAuthNonceCookie(c, provider, "", -1)
+	if err := decodeOAuthState(params.Get("state"), provider.Name(), nonce); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Get("code") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code is required"})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), params.Get("code"), params)
+	if err != nil {
+		log.Printf("OAuth exchange with %s failed: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "OAuth login failed"})
+		return
+	}
+
+	h.completeOAuthLogin(c, identity, true)
+}
+
+func (h *Handler) ExchangeIDToken(c *gin.Context) {
+	provider, err := h.oauthProviders.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	verifier, ok := provider.(oauth.TokenVerifier)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provider does not support ID token exchange"})
+		return
+	}
+
+	var req models.IDTokenExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identity, err := verifier.VerifyIDToken(c.Request.Context(), req.IDToken)
+	if err != nil {
+		log.Printf("ID token from %s rejected: %v", provider.Name(), err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		return
+	}
+
+	h.completeOAuthLogin(c, identity, false)
+}
+
+func (h *Handler) completeOAuthLogin(c *gin.Context, identity *oauth.Identity, allowRedirect bool) {
+	user, err := h.svc.ResolveOAuthIdentity(c.Request.Context(), identity)
+	if err != nil {
+		if err == service.ErrOAuthEmailRequired {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrOAuthEmailTaken {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to resolve OAuth user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth login failed"})
+		return
+	}
+
+	response, err := h.issueSession(c, user)
+	if err != nil {
+		log.Printf("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if redirect := config.Get().OAuth.SuccessRedirectURL; redirect != "" && allowRedirect {
+		fragment := url.Values{"token": {response.Token}, "refresh_token": {response.RefreshToken}}
+		c.Redirect(http.StatusFound, redirect+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func passwordAuthEnabled() bool {
+	return config.Get().Auth.PasswordAuthEnabled
+}
+
+// setOAuthNonceCookie sets, or with a negative maxAge clears, the cookie
+// holding the nonce of a pending login. Browsers send Lax cookies only with
+// top-level GET navigations, so providers that post the callback get a
+// SameSite=None cookie instead, which has to be Secure.
+func setOAuthNonceCookie(c *gin.Context, provider oauth.Provider, nonce string, maxAge int) {
+	secure := config.Get().HTTP.CookieSecure
+	sameSite := http.SameSiteLaxMode
+	if poster, ok := provider.(oauth.FormPoster); ok && poster.FormPost() {
+		sameSite, secure = http.SameSiteNoneMode, true
+	}
+	c.SetSameSite(sameSite)
+	c.SetCookie(models.OAuthSettings.NonceCookie, nonce, maxAge, "/", "", secure, true)
+}
+
+func encodeOAuthState(provider string) (string, string, error) {
+	nonce, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	payload, err := json.Marshal(oauthState{
+		Provider: provider,
+		Nonce:    nonce,
+		Expires:  time.Now().Add(models.OAuthSettings.StateTTL).Unix(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + auth.SignPayload(payload), nonce, nil
+}
+
+// decodeOAuthState checks the signature, provider and expiry of a state and
+// that it carries the nonce from the browser's cookie.
+func decodeOAuthState(state, provider, nonce string) error {
+	encoded, signature, ok := strings.Cut(state, ".")
+	if !ok {
+		return errOAuthState
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(auth.SignPayload(payload))) {
+		return errOAuthState
+	}
+
+	var decoded oauthState
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return errOAuthState
+	}
+	if decoded.Provider != provider || time.Now().Unix() > decoded.Expires {
+		return errOAuthState
+	}
+	if nonce == "" || !hmac.Equal([]byte(decoded.Nonce), []byte(nonce)) {
+		return errOAuthState
+	}
+	return nil
+}
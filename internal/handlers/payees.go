@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetPayees(c *gin.Context) {
+	payees, err := h.svc.GetPayees(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching payees: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payees)
+}
+
+func (h *Handler) CreatePayee(c *gin.Context) {
+	var req models.PayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee := models.Payee{
+		UserID:       c.GetInt("user_id"),
+		Name:         req.Name,
+		MatchText:    req.MatchText,
+		MonthlyLimit: req.MonthlyLimit,
+	}
+
+	err := h.svc.CreatePayee(c.Request.Context(), &payee)
+	if err == service.ErrPayeeExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create payee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payee"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payee)
+}
+
+func (h *Handler) UpdatePayee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payee ID"})
+		return
+	}
+
+	var req models.PayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee := models.Payee{
+		ID:           id,
+		UserID:       c.GetInt("user_id"),
+		Name:         req.Name,
+		MatchText:    req.MatchText,
+		MonthlyLimit: req.MonthlyLimit,
+	}
+
+	err = h.svc.UpdatePayee(c.Request.Context(), &payee)
+	switch {
+	case err == service.ErrPayeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrPayeeExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update payee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payee"})
+	default:
+		c.JSON(http.StatusOK, payee)
+	}
+}
+
+func (h *Handler) DeletePayee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payee ID"})
+		return
+	}
+
+	err = h.svc.DeletePayee(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrPayeeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete payee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payee"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payee deleted"})
+}
+
+func (h *Handler) GetPayeeLimits(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	at := time.Now()
+	if value := c.Query("date"); value != "" {
+		var err error
+		if at, err = time.ParseInLocation("2006-01-02", value, h.svc.Location(c.Request.Context(), userID)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	overview, err := h.svc.PayeeLimits(c.Request.Context(), userID, at)
+	if err != nil {
+		log.Printf("Error computing payee limits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get payee limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetPeriodSummaries(c *gin.Context) {
+	count := models.SettingsDefaults.PeriodHistory
+	if value := c.Query("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > models.SettingsDefaults.MaxPeriodHistory {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and " + strconv.Itoa(models.SettingsDefaults.MaxPeriodHistory)})
+			return
+		}
+		count = n
+	}
+
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	summaries, err := h.svc.PeriodSummaries(c.Request.Context(), c.GetInt("user_id"), time.Now(), count, scope)
+	if err != nil {
+		log.Printf("Error getting period summaries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get period summaries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
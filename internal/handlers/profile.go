@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	file, header, err := c.Request.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar image is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > models.ProfileSettings.AvatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar image is too large"})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, models.ProfileSettings.AvatarMaxBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read avatar image"})
+		return
+	}
+
+	user, err := h.svc.SetAvatar(c.Request.Context(), c.GetInt("user_id"), data)
+	if errors.Is(err, service.ErrInvalidImage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if abortWithQuota(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error saving avatar: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	err := h.svc.DeleteAvatar(c.Request.Context(), c.GetInt("user_id"))
+	if err == service.ErrAvatarNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting avatar: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete avatar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Avatar deleted"})
+}
+
+func (h *Handler) GetAvatar(c *gin.Context) {
+	size, err := strconv.Atoi(c.Param("size"))
+	if err != nil || !avatarSize(size) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unsupported avatar size"})
+		return
+	}
+
+	data, contentType, err := h.svc.Avatar(c.Request.Context(), c.GetInt("user_id"), size)
+	if err == service.ErrAvatarNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading avatar: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+
+	// Avatar URLs carry the upload time, so a new upload changes the URL.
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func avatarSize(size int) bool {
+	for _, s := range models.ProfileSettings.AvatarSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/secrets"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetVAPIDPublicKey(c *gin.Context) {
+	keys, err := notifications.LoadOrCreateVAPIDKeys(h.db)
+	if err != nil {
+		log.Printf("Error loading VAPID keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Web push is not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"public_key": keys.PublicKey})
+}
+
+func (h *Handler) GetPushSubscriptions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	rows, err := h.db.QueryContext(c.Request.Context(), `SELECT id, endpoint, COALESCE(user_agent, ''), last_used_at, created_at
+			  FROM push_subscriptions WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		log.Printf("Error fetching push subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch push subscriptions"})
+		return
+	}
+	defer rows.Close()
+
+	subscriptions := []models.PushSubscription{}
+	for rows.Next() {
+		var s models.PushSubscription
+		if err := rows.Scan(&s.ID, &s.Endpoint, &s.UserAgent, &s.LastUsedAt, &s.CreatedAt); err != nil {
+			continue
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *Handler) CreatePushSubscription(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.PushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription := models.PushSubscription{Endpoint: req.Endpoint, UserAgent: c.Request.UserAgent()}
+
+	p256dh, err := secrets.Encrypt(req.Keys.P256dh)
+	if err != nil {
+		log.Printf("Error encrypting push subscription keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+	authSecret, err := secrets.Encrypt(req.Keys.Auth)
+	if err != nil {
+		log.Printf("Error encrypting push subscription keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+
+	query := `INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent, created_at)
+			  VALUES ($1, $2, $3, $4, $5, NOW())
+			  ON CONFLICT (endpoint) DO UPDATE SET user_id = EXCLUDED.user_id, p256dh = EXCLUDED.p256dh,
+			  auth = EXCLUDED.auth, user_agent = EXCLUDED.user_agent
+			  RETURNING id, created_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, userID, req.Endpoint, p256dh, authSecret, subscription.UserAgent).
+		Scan(&subscription.ID, &subscription.CreatedAt)
+	if err != nil {
+		log.Printf("Error saving push subscription: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+func (h *Handler) DeletePushSubscription(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+
+	result, err := h.db.ExecContext(c.Request.Context(), `DELETE FROM push_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		log.Printf("Error deleting push subscription: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete push subscription"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Push subscription not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription deleted"})
+}
+
+func (h *Handler) GetPushPreferences(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	preferences := make(map[string]bool, len(notifications.PushTypes))
+	for _, notificationType := range notifications.PushTypes {
+		preferences[notificationType] = false
+	}
+
+	rows, err := h.db.QueryContext(c.Request.Context(), `SELECT notification_type, enabled FROM push_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		log.Printf("Error fetching push preferences: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch push preferences"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+		if err := rows.Scan(&notificationType, &enabled); err != nil {
+			continue
+		}
+		if _, ok := preferences[notificationType]; ok {
+			preferences[notificationType] = enabled
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+func (h *Handler) UpdatePushPreferences(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.PushPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for notificationType := range req.Preferences {
+		if !isPushType(notificationType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown notification type: " + notificationType})
+			return
+		}
+	}
+
+	tx, err := h.db.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update push preferences"})
+		return
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO push_preferences (user_id, notification_type, enabled, updated_at)
+			  VALUES ($1, $2, $3, NOW())
+			  ON CONFLICT (user_id, notification_type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()`
+
+	for notificationType, enabled := range req.Preferences {
+		if _, err := tx.ExecContext(c.Request.Context(), query, userID, notificationType, enabled); err != nil {
+			log.Printf("Error updating push preference: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update push preferences"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update push preferences"})
+		return
+	}
+
+	h.GetPushPreferences(c)
+}
+
+func isPushType(notificationType string) bool {
+	for _, t := range notifications.PushTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit counts requests per user (or per client IP before authentication)
+// in fixed windows kept in the shared store, so limits hold across instances.
+func (h *Handler) RateLimit(scope string, limit int) gin.HandlerFunc {
+	if config.Get().Server.LoadTestMode {
+		return func(c *gin.Context) { c.Next() }
+	}
+	window := models.RateLimitSettings.Window
+
+	return func(c *gin.Context) {
+		subject := "ip:" + c.ClientIP()
+		if userID := c.GetInt("user_id"); userID != 0 {
+			subject = "user:" + strconv.Itoa(userID)
+		}
+
+		windowStart := time.Now().Truncate(window)
+		key := fmt.Sprintf("ratelimit:%s:%s:%d", scope, subject, windowStart.Unix())
+
+		count, err := h.svc.Cache().Incr(c.Request.Context(), key, window)
+		if err != nil {
+			log.Printf("Rate limiter unavailable: %v", err)
+			c.Next()
+			return
+		}
+
+		remaining := int64(limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+		if count > int64(limit) {
+			retryAfter := time.Until(windowStart.Add(window))
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			abortWithError(c, http.StatusTooManyRequests, "Rate limit exceeded, try again later")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type idempotentResponse struct {
+	Fingerprint string          `json:"fingerprint"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// Idempotency replays the stored response when a POST is retried with the same
+// Idempotency-Key, so clients can safely retry after timeouts.
+func (h *Handler) Idempotency() gin.HandlerFunc {
+	settings := models.IdempotencySettings
+
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(settings.Header)
+		if c.Request.Method != http.MethodPost || idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > 255 {
+			abortWithError(c, http.StatusBadRequest, settings.Header+" must be at most 255 characters")
+			return
+		}
+
+		// Uploads are left unbuffered for their handlers to limit; they are
+		// told apart by length only. Other bodies are hashed up to a bound.
+		var body []byte
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			body = []byte(c.ContentType() + " " + strconv.FormatInt(c.Request.ContentLength, 10))
+		} else {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, settings.MaxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					abortWithError(c, http.StatusRequestEntityTooLarge, "Request body is too large")
+					return
+				}
+				abortWithError(c, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		keySum := sha256.Sum256([]byte(idempotencyKey))
+		key := fmt.Sprintf("idempotency:%d:%s", c.GetInt("user_id"), hex.EncodeToString(keySum[:]))
+		store := h.svc.Cache()
+		ctx := context.Background()
+
+		if value, ok, err := store.Get(ctx, key); err == nil && ok {
+			var stored idempotentResponse
+			if err := json.Unmarshal(value, &stored); err == nil {
+				if stored.Fingerprint != fingerprint {
+					abortWithError(c, http.StatusUnprocessableEntity, settings.Header+" was already used for a different request")
+					return
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(stored.Status, stored.ContentType, stored.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		locked, err := store.SetNX(ctx, key+":lock", []byte("1"), settings.LockTTL)
+		if err != nil {
+			log.Printf("Idempotency store unavailable: %v", err)
+			c.Next()
+			return
+		}
+		if !locked {
+			abortWithError(c, http.StatusConflict, "A request with this "+settings.Header+" is still being processed")
+			return
+		}
+		defer store.Delete(ctx, key+":lock")
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if writer.status < http.StatusInternalServerError && json.Valid(writer.body.Bytes()) {
+			value, err := json.Marshal(idempotentResponse{
+				Fingerprint: fingerprint,
+				Status:      writer.status,
+				ContentType: original.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+			})
+			if err == nil {
+				if err := store.Set(ctx, key, value, settings.TTL); err != nil {
+					log.Printf("Error storing idempotent response: %v", err)
+				}
+			}
+		}
+
+		c.Writer.WriteHeader(writer.status)
+		c.Writer.Write(writer.body.Bytes())
+	}
+}
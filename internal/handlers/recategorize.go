@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) RecategorizeTransactions(c *gin.Context) {
+	var req models.RecategorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	result, err := h.svc.Recategorize(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case err == service.ErrRecategorizeFilter:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrCategoryNotFound || err == service.ErrAccountNotFound || err == service.ErrPayeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == service.ErrRecategorizeChanged:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "matched": result.Updated})
+	case err != nil:
+		log.Printf("Failed to recategorize transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recategorize transactions"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
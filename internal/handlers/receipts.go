@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/receipts"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ScanReceipt(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	// Bound the body before the multipart form is parsed, leaving room for
+	// the form's other fields and headers.
+	maxBody := models.ReceiptSettings.MaxImageBytes + models.ReceiptSettings.MaxFormOverheadBytes
+	if c.Request.ContentLength > maxBody {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Receipt image is too large"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBody)
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Receipt image is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Receipt image is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > models.ReceiptSettings.MaxImageBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Receipt image is too large"})
+		return
+	}
+
+	image, err := io.ReadAll(io.LimitReader(file, models.ReceiptSettings.MaxImageBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read receipt image"})
+		return
+	}
+
+	text, err := h.ocrEngine.Recognize(c.Request.Context(), image)
+	if err != nil {
+		log.Printf("OCR failed using %s: %v", h.ocrEngine.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read text from receipt"})
+		return
+	}
+
+	settings, err := h.svc.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan receipt"})
+		return
+	}
+	receipt := receipts.Parse(text, monthFirstDates(settings))
+
+	draft := models.TransactionDraft{
+		Amount:      receipt.Total,
+		Type:        "expense",
+		Description: receipt.Merchant,
+		Date:        time.Now(),
+	}
+	if receipt.Date != nil {
+		draft.Date = *receipt.Date
+	}
+
+	if accountID, err := strconv.Atoi(c.PostForm("account_id")); err == nil {
+		draft.AccountID = &accountID
+	}
+
+	if categoryID, err := h.svc.SuggestCategory(c.Request.Context(), userID, draft.Description, draft.Amount, draft.Type); err == nil {
+		draft.CategoryID = &categoryID
+	}
+
+	c.JSON(http.StatusOK, models.ReceiptScanResponse{
+		Engine:  h.ocrEngine.Name(),
+		Receipt: receipt,
+		Draft:   draft,
+	})
+}
+
+// monthFirstDates tells whether the user writes dates month first: with the
+// MM/DD/YYYY format or, unless they chose DD/MM/YYYY, a US locale.
+func monthFirstDates(settings models.UserSettings) bool {
+	switch settings.DateFormat {
+	case "MM/DD/YYYY":
+		return true
+	case "DD/MM/YYYY":
+		return false
+	}
+	return strings.HasSuffix(settings.Locale, "-US")
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"personal-finance-tracker/internal/errorreport"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID gives every request a correlation ID, returned in the
+// X-Request-ID header and in 500 responses and attached to error reports.
+// An ID sent by a proxy is kept; otherwise the trace ID is used when the
+// request is traced, so logs, reports and traces share one ID.
+func (h *Handler) RequestID() gin.HandlerFunc {
+	header := models.ErrorReportSettings.RequestIDHeader
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(header)
+		if !validRequestID(id) {
+			id = newRequestID(c)
+		}
+		c.Set("request_id", id)
+		c.Header(header, id)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID(c *gin.Context) string {
+	if id := tracing.TraceID(c.Request.Context()); id != "" {
+		return id
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Recovery turns a panic into a 500 response carrying the request ID and
+// reports it with the request it happened in. Responses that end in 500
+// without a panic are reported too, so that failures handlers only log
+// still show up.
+func (h *Handler) Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+			if err, ok := value.(error); ok && brokenConnection(err) {
+				c.Abort()
+				return
+			}
+
+			log.Printf("Panic serving %s %s (request %s): %v\n%s", c.Request.Method, c.Request.URL.Path, c.GetString("request_id"), value, debug.Stack())
+			report := requestReport(c)
+			report.Level = "fatal"
+			report.ErrorType = "panic"
+			report.Message = fmt.Sprint(value)
+			report.Stack = errorreport.Callers(1)
+			errorreport.Capture(report)
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			abortWithInternalError(c)
+		}()
+
+		c.Next()
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError && status != http.StatusServiceUnavailable {
+			report := requestReport(c)
+			report.Message = fmt.Sprintf("%s %s answered %d", c.Request.Method, report.Route, status)
+			if len(c.Errors) > 0 {
+				report.Message += ": " + c.Errors.Last().Error()
+			}
+			errorreport.Capture(report)
+		}
+	}
+}
+
+// abortWithInternalError writes the 500 envelope of the request's API
+// version, with the request ID to quote when reporting the problem.
+func abortWithInternalError(c *gin.Context) {
+	if c.GetInt("api_version") >= 2 {
+		writeProblem(c, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "request_id": c.GetString("request_id")})
+}
+
+func requestReport(c *gin.Context) errorreport.Report {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return errorreport.Report{
+		Request:   c.Request,
+		Route:     c.Request.Method + " " + route,
+		UserID:    c.GetInt("user_id"),
+		RequestID: c.GetString("request_id"),
+		TraceID:   tracing.TraceID(c.Request.Context()),
+	}
+}
+
+// brokenConnection reports whether a panic came from writing to a client
+// that went away, which is not worth a report.
+func brokenConnection(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetRecurringRules(c *gin.Context) {
+	rules, err := h.svc.GetRecurringRules(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching recurring rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recurring rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// recurringFromRequest reads a recurring rule request and answers 400 when
+// its dates are malformed.
+func (h *Handler) recurringFromRequest(c *gin.Context, id int) (models.RecurringRule, bool) {
+	var req models.RecurringRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.RecurringRule{}, false
+	}
+
+	rule := models.RecurringRule{
+		ID:          id,
+		UserID:      c.GetInt("user_id"),
+		AccountID:   req.AccountID,
+		CategoryID:  req.CategoryID,
+		Amount:      req.Amount,
+		Type:        req.Type,
+		Description: req.Description,
+		Frequency:   req.Frequency,
+	}
+
+	var err error
+	if rule.StartDate, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must use the YYYY-MM-DD format"})
+		return rule, false
+	}
+	if req.EndDate != nil {
+		end, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must use the YYYY-MM-DD format"})
+			return rule, false
+		}
+		if end.Before(rule.StartDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+			return rule, false
+		}
+		rule.EndDate = &end
+	}
+	return rule, true
+}
+
+func (h *Handler) CreateRecurringRule(c *gin.Context) {
+	rule, ok := h.recurringFromRequest(c, 0)
+	if !ok {
+		return
+	}
+
+	err := h.svc.CreateRecurringRule(c.Request.Context(), &rule)
+	if err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create recurring rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *Handler) UpdateRecurringRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring rule ID"})
+		return
+	}
+	rule, ok := h.recurringFromRequest(c, id)
+	if !ok {
+		return
+	}
+
+	err = h.svc.UpdateRecurringRule(c.Request.Context(), &rule)
+	switch err {
+	case nil:
+	case service.ErrRecurringNotFound, service.ErrAccountNotFound, service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to update recurring rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) DeleteRecurringRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring rule ID"})
+		return
+	}
+
+	err = h.svc.DeleteRecurringRule(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrRecurringNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete recurring rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring rule deleted"})
+}
+
+func (h *Handler) GetUpcomingTransactions(c *gin.Context) {
+	days := models.UpcomingSettings.DefaultDays
+	if value := c.Query("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > models.UpcomingSettings.MaxDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+			return
+		}
+	}
+
+	feed, err := h.svc.Upcoming(c.Request.Context(), c.GetInt("user_id"), time.Now(), days)
+	if err != nil {
+		log.Printf("Error projecting upcoming transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get upcoming transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+func (h *Handler) GetSafeToSpend(c *gin.Context) {
+	result, err := h.svc.SafeToSpend(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Failed to compute safe-to-spend: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute safe-to-spend"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
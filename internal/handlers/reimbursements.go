@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetReimbursements(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.ReimbursementStatuses.Pending, models.ReimbursementStatuses.Submitted, models.ReimbursementStatuses.Reimbursed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, submitted or reimbursed"})
+		return
+	}
+
+	reimbursements, err := h.svc.Reimbursements(c.Request.Context(), c.GetInt("user_id"), status)
+	if err != nil {
+		log.Printf("Error fetching reimbursements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reimbursements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reimbursements)
+}
+
+func (h *Handler) GetOutstandingReimbursements(c *gin.Context) {
+	report, err := h.svc.OutstandingReimbursements(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error building outstanding reimbursements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reimbursements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) CreateReimbursement(c *gin.Context) {
+	var req models.ReimbursementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TripDate != nil {
+		if _, err := time.Parse("2006-01-02", *req.TripDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "trip_date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	reimbursement := models.Reimbursement{
+		UserID:        c.GetInt("user_id"),
+		TransactionID: req.TransactionID,
+		DistanceKm:    req.DistanceKm,
+		RatePerKm:     req.RatePerKm,
+		TripDate:      req.TripDate,
+		Description:   req.Description,
+		Payer:         req.Payer,
+	}
+
+	err := h.svc.CreateReimbursement(c.Request.Context(), &reimbursement)
+	switch err {
+	case nil:
+	case service.ErrTransactionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrInvalidReimbursement:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case service.ErrReimbursementExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to create reimbursement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reimbursement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reimbursement)
+}
+
+func (h *Handler) SubmitReimbursement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reimbursement ID"})
+		return
+	}
+
+	reimbursement, err := h.svc.SubmitReimbursement(c.Request.Context(), c.GetInt("user_id"), id)
+	h.respondReimbursement(c, reimbursement, err)
+}
+
+func (h *Handler) Reimburse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reimbursement ID"})
+		return
+	}
+
+	var req models.ReimburseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reimbursement, err := h.svc.Reimburse(c.Request.Context(), c.GetInt("user_id"), id, req.TransactionID)
+	h.respondReimbursement(c, reimbursement, err)
+}
+
+func (h *Handler) respondReimbursement(c *gin.Context, reimbursement models.Reimbursement, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, reimbursement)
+	case service.ErrReimbursementNotFound, service.ErrTransactionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrNotIncome:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrReimbursementStatus:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to update reimbursement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reimbursement"})
+	}
+}
+
+func (h *Handler) DeleteReimbursement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reimbursement ID"})
+		return
+	}
+
+	err = h.svc.DeleteReimbursement(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrReimbursementNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete reimbursement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reimbursement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reimbursement deleted"})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetReportSchedules(c *gin.Context) {
+	schedules, err := h.svc.ReportSchedules(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching report schedules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch report schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *Handler) GetReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report schedule ID"})
+		return
+	}
+
+	schedule, err := h.svc.ReportSchedule(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrReportScheduleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error fetching report schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch report schedule"})
+	default:
+		c.JSON(http.StatusOK, schedule)
+	}
+}
+
+func (h *Handler) CreateReportSchedule(c *gin.Context) {
+	var req models.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Delivery == models.ReportDeliveries.Webhook && !h.enforceQuota(c, models.QuotaResources.Webhooks, 1) {
+		return
+	}
+
+	schedule, err := h.svc.CreateReportSchedule(c.Request.Context(), c.GetInt("user_id"), req)
+	switch {
+	case isReportScheduleValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrTooManyReportSchedules:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to create report schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report schedule"})
+	default:
+		c.JSON(http.StatusCreated, schedule)
+	}
+}
+
+func (h *Handler) UpdateReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report schedule ID"})
+		return
+	}
+
+	var req models.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.svc.UpdateReportSchedule(c.Request.Context(), c.GetInt("user_id"), id, req)
+	switch {
+	case isReportScheduleValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == service.ErrReportScheduleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to update report schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report schedule"})
+	default:
+		c.JSON(http.StatusOK, schedule)
+	}
+}
+
+func (h *Handler) DeleteReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report schedule ID"})
+		return
+	}
+
+	err = h.svc.DeleteReportSchedule(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrReportScheduleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Failed to delete report schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete report schedule"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Report schedule deleted"})
+	}
+}
+
+// SendReportSchedule delivers a scheduled report now instead of waiting for
+// its next run.
+func (h *Handler) SendReportSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report schedule ID"})
+		return
+	}
+
+	job, err := h.svc.SendReportSchedule(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrReportScheduleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error queueing report schedule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send report"})
+	default:
+		c.Header("Location", "/api/v1/jobs/"+strconv.Itoa(job.ID))
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+func isReportScheduleValidationError(err error) bool {
+	return err == service.ErrReportDimension || err == service.ErrReportMetric || err == service.ErrInvalidCron ||
+		err == service.ErrCronTooFrequent || err == service.ErrReportWebhookURL || err == service.ErrAccountNotFound ||
+		err == service.ErrCategoryNotFound
+}
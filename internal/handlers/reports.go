@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetReport(c *gin.Context) {
+	var filter models.TransactionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The filter's dates are bound as calendar days; anchor them in the
+	// user's timezone and make the end exclusive.
+	userID := c.GetInt("user_id")
+	loc := h.svc.Location(c.Request.Context(), userID)
+	if filter.StartDate != nil {
+		start := time.Date(filter.StartDate.Year(), filter.StartDate.Month(), filter.StartDate.Day(), 0, 0, 0, 0, loc)
+		filter.StartDate = &start
+	}
+	if filter.EndDate != nil {
+		end := time.Date(filter.EndDate.Year(), filter.EndDate.Month(), filter.EndDate.Day()+1, 0, 0, 0, 0, loc)
+		filter.EndDate = &end
+	}
+
+	req := models.ReportRequest{GroupBy: c.Query("group_by"), Pivot: c.Query("pivot"), Filter: filter}
+	if value := c.Query("metrics"); value != "" {
+		req.Metrics = strings.Split(value, ",")
+	}
+
+	report, err := h.svc.Report(c.Request.Context(), userID, req)
+	switch {
+	case err == service.ErrReportDimension || err == service.ErrReportMetric:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error building report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'; base-uri 'none'"
+
+func (h *Handler) CORSMiddleware() gin.HandlerFunc {
+	allowed := map[string]bool{}
+	allowAll := false
+	cfg := config.Get().HTTP
+	for _, origin := range cfg.CORSAllowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			allowAll = true
+		} else if origin != "" {
+			allowed[origin] = true
+		}
+	}
+	allowCredentials := cfg.CORSAllowCredentials
+	if allowAll && allowCredentials {
+		log.Println("CORS_ALLOW_CREDENTIALS ignored because CORS_ALLOWED_ORIGINS is *")
+		allowCredentials = false
+	}
+
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+	allowHeaders := strings.Join([]string{"Authorization", "Content-Type", "If-None-Match", "If-Match", models.CSRFSettings.HeaderName, models.WorkspaceSettings.Header,
+		models.IdempotencySettings.Header, models.ErrorReportSettings.RequestIDHeader}, ", ")
+	exposeHeaders := strings.Join([]string{"ETag", "Retry-After", "Idempotent-Replayed", models.ErrorReportSettings.RequestIDHeader}, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		if !allowAll && !allowed[origin] {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if allowAll {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if allowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Expose-Headers", exposeHeaders)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (h *Handler) SecurityHeaders() gin.HandlerFunc {
+	cfg := config.Get().HTTP
+	hstsMaxAge := cfg.HSTSMaxAge
+	csp := cfg.ContentSecurityPolicy
+	if csp == "" {
+		csp = defaultContentSecurityPolicy
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if hstsMaxAge > 0 {
+			header.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(hstsMaxAge)+"; includeSubDomains")
+		}
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		header.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+		header.Set("Content-Security-Policy", csp)
+		c.Next()
+	}
+}
+
+// CSRFMiddleware implements the double-submit cookie pattern. It only applies
+// to requests authenticated by a session cookie; bearer tokens are never sent
+// by the browser on its own, so they are not exposed to CSRF.
+func (h *Handler) CSRFMiddleware() gin.HandlerFunc {
+	settings := models.CSRFSettings
+	secure := config.Get().HTTP.CookieSecure
+
+	return func(c *gin.Context) {
+		if _, err := c.Cookie(settings.SessionCookieName); err != nil || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		cookieToken, err := c.Cookie(settings.CookieName)
+		if err != nil || cookieToken == "" {
+			cookieToken, err = auth.GenerateRandomToken(32)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue CSRF token"})
+				c.Abort()
+				return
+			}
+			c.SetSameSite(http.SameSiteStrictMode)
+			c.SetCookie(settings.CookieName, cookieToken, int(settings.CookieTTL.Seconds()), "/", "", secure, false)
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		headerToken := c.GetHeader(settings.HeaderName)
+		if headerToken == "" || subtle.ConstantTimeCompare([]byte(headerToken), []byte(cookieToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing or invalid CSRF token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) issueSession(c *gin.Context, user models.User) (models.AuthResponse, error) {
+	sessionID, refreshToken, err := h.svc.CreateSession(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	token, err := auth.GenerateJWT(user.ID, user.Email, sessionID)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, sessionID, refreshToken, err := h.svc.RefreshSession(c.Request.Context(), req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err == service.ErrSessionNotFound {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to refresh session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	token, err := auth.GenerateJWT(user.ID, user.Email, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
+	})
+}
+
+func (h *Handler) Logout(c *gin.Context) {
+	sessionID := c.GetInt("session_id")
+	if sessionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is not bound to a session"})
+		return
+	}
+
+	if err := h.svc.RevokeSession(c.Request.Context(), c.GetInt("user_id"), sessionID); err != nil && err != service.ErrSessionNotFound {
+		log.Printf("Failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func (h *Handler) GetSessions(c *gin.Context) {
+	sessions, err := h.svc.GetSessions(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	current := c.GetInt("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) RevokeSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	err = h.svc.RevokeSession(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrSessionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	revoked, err := h.svc.RevokeAllSessions(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Failed to revoke sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out everywhere", "revoked": revoked})
+}
+
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.JWKS())
+}
+
+func (h *Handler) UnlockAccount(c *gin.Context) {
+	err := h.svc.UnlockAccount(c.Request.Context(), c.Param("token"))
+	if err == service.ErrUnlockTokenInvalid {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to unlock account: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked, you can sign in again"})
+}
+
+func (h *Handler) CheckPasswordStrength(c *gin.Context) {
+	var req models.PasswordStrengthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	localPart, _, _ := strings.Cut(req.Email, "@")
+	strength, err := auth.PasswordPolicyFromConfig(config.Get().Auth).Validate(c.Request.Context(), req.Password, localPart, req.FirstName, req.LastName)
+	response := gin.H{"strength": strength, "valid": err == nil}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetContacts(c *gin.Context) {
+	contacts, err := h.svc.Contacts(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching contacts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contacts)
+}
+
+func (h *Handler) CreateContact(c *gin.Context) {
+	var req models.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact := models.Contact{UserID: c.GetInt("user_id"), Name: req.Name, Email: req.Email}
+	err := h.svc.CreateContact(c.Request.Context(), &contact)
+	if err == service.ErrContactExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create contact: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+func (h *Handler) UpdateContact(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req models.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact := models.Contact{ID: id, UserID: c.GetInt("user_id"), Name: req.Name, Email: req.Email}
+	err = h.svc.UpdateContact(c.Request.Context(), &contact)
+	switch err {
+	case nil:
+	case service.ErrContactNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrContactExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to update contact: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+func (h *Handler) DeleteContact(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	err = h.svc.DeleteContact(c.Request.Context(), c.GetInt("user_id"), id)
+	switch err {
+	case nil:
+	case service.ErrContactNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrResourceInUse:
+		c.JSON(http.StatusConflict, gin.H{"error": "Contact still has shared expenses or settlements"})
+		return
+	default:
+		log.Printf("Failed to delete contact: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted"})
+}
+
+func (h *Handler) GetContactBalances(c *gin.Context) {
+	balances, err := h.svc.ContactBalances(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error computing contact balances: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute contact balances"})
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+func (h *Handler) GetTransactionSplit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	split, err := h.svc.TransactionSplit(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching transaction split: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction split"})
+		return
+	}
+
+	c.JSON(http.StatusOK, split)
+}
+
+func (h *Handler) SetTransactionSplit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req models.SplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Equal {
+		for _, share := range req.Shares {
+			if share.Amount <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Each share needs an amount unless the split is equal"})
+				return
+			}
+		}
+	}
+
+	split, err := h.svc.SetTransactionSplit(c.Request.Context(), c.GetInt("user_id"), id, req)
+	switch err {
+	case nil:
+	case service.ErrTransactionNotFound, service.ErrContactNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrSplitNotExpense, service.ErrSplitsExceedAmount, service.ErrDuplicateShare:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to split transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split transaction"})
+		return
+	}
+
+	c.JSON(http.StatusOK, split)
+}
+
+func (h *Handler) GetSplitSettlements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	settlements, err := h.svc.SplitSettlements(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrContactNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching settlements: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settlements)
+}
+
+func (h *Handler) RecordSplitSettlement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return
+	}
+
+	var req models.SplitSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	settlement := models.SplitSettlement{ContactID: id, Direction: req.Direction, Amount: req.Amount, Date: req.Date, Note: req.Note}
+	err = h.svc.RecordSettlement(c.Request.Context(), c.GetInt("user_id"), &settlement)
+	if err == service.ErrContactNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to record settlement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record settlement"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
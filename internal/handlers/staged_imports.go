@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetStagedImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	staged, err := h.svc.StagedImport(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrImportNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, staged)
+}
+
+func (h *Handler) CommitImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	var req models.ImportCommitRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	for _, edit := range req.Rows {
+		if edit.Date == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *edit.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must use the YYYY-MM-DD format"})
+			return
+		}
+	}
+
+	progress, err := h.svc.CommitImport(c.Request.Context(), c.GetInt("user_id"), id, req.Rows)
+	if abortWithQuota(c, err) {
+		return
+	}
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, progress)
+	case service.ErrImportNotFound, service.ErrImportRowNotFound, service.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrImportCommitted:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to commit import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit import"})
+	}
+}
+
+func (h *Handler) DiscardImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	err = h.svc.DiscardImport(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrImportNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to discard import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discard import"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Import discarded"})
+}
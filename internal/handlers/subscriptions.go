@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetSubscriptions(c *gin.Context) {
+	subscriptions, err := h.svc.Subscriptions(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Error detecting subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *Handler) GetForgottenSubscriptions(c *gin.Context) {
+	forgotten, err := h.svc.ForgottenSubscriptions(c.Request.Context(), c.GetInt("user_id"), time.Now())
+	if err != nil {
+		log.Printf("Error detecting forgotten subscriptions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, forgotten)
+}
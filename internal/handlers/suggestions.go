@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"personal-finance-tracker/internal/classifier"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SuggestCategory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.CategorySuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	examples, categoryNames, err := h.svc.ClassifierExamples(c.Request.Context(), userID, req.Type)
+	if err != nil {
+		log.Printf("Error loading classifier training data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest category"})
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = models.ClassifierSettings.MaxSuggestions
+	}
+
+	model := classifier.Train(examples)
+	predictions := model.Predict(req.Description, req.Amount)
+	if len(predictions) > limit {
+		predictions = predictions[:limit]
+	}
+
+	suggestions := make([]models.CategorySuggestion, 0, len(predictions))
+	for _, p := range predictions {
+		suggestions = append(suggestions, models.CategorySuggestion{
+			CategoryID:   p.CategoryID,
+			CategoryName: categoryNames[p.CategoryID],
+			Confidence:   p.Confidence,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.CategorySuggestionResponse{
+		Description:     req.Description,
+		TrainingSamples: len(examples),
+		Suggestions:     suggestions,
+	})
+}
+
+// suggestCategory gives a transaction created without a category the one
+// the classifier predicts from the user's history.
+func (h *Handler) suggestCategory(ctx context.Context, transaction *models.Transaction) error {
+	if transaction.CategoryID != 0 {
+		return nil
+	}
+	categoryID, err := h.svc.SuggestCategory(ctx, transaction.UserID, transaction.Description, transaction.Amount, transaction.Type)
+	if err != nil {
+		return err
+	}
+	transaction.CategoryID = categoryID
+	return nil
+}
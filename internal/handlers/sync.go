@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const syncTokenPrefix = "v1."
+
+var errSyncConflict = errors.New("record was modified on the server")
+
+func (h *Handler) Sync(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := c.GetInt("user_id")
+
+	since, err := decodeSyncToken(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sync token"})
+		return
+	}
+
+	var now time.Time
+	if err := h.db.QueryRowContext(ctx, `SELECT NOW()`).Scan(&now); err != nil {
+		log.Printf("Error reading server time: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync"})
+		return
+	}
+
+	changes := models.SyncChanges{Token: encodeSyncToken(now)}
+	filter := `user_id = $1 AND updated_at > $2 AND updated_at <= $3`
+
+	changes.Accounts, err = h.syncAccounts(ctx, filter, userID, since, now)
+	if err == nil {
+		changes.Categories, err = h.syncCategories(ctx, filter, userID, since, now)
+	}
+	if err == nil {
+		changes.Transactions, err = h.syncTransactions(ctx, filter, userID, since, now)
+	}
+	if err == nil {
+		changes.Tombstones, err = h.syncTombstones(ctx, userID, since, now)
+	}
+	if err != nil {
+		log.Printf("Error loading sync changes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+func (h *Handler) PushSync(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userID := c.GetInt("user_id")
+
+	var req models.SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := []models.SyncResult{}
+	accountIDs := make(map[string]int)
+	categoryIDs := make(map[string]int)
+
+	for _, account := range req.Accounts {
+		result := h.pushSyncAccount(ctx, userID, account)
+		if result.ID != 0 {
+			accountIDs[account.ClientID] = result.ID
+		}
+		results = append(results, result)
+	}
+
+	for _, category := range req.Categories {
+		result := h.pushSyncCategory(ctx, userID, category)
+		if result.ID != 0 {
+			categoryIDs[category.ClientID] = result.ID
+		}
+		results = append(results, result)
+	}
+
+	for _, transaction := range req.Transactions {
+		results = append(results, h.pushSyncTransaction(ctx, userID, transaction, accountIDs, categoryIDs))
+	}
+
+	c.JSON(http.StatusOK, models.SyncPushResponse{Results: results})
+}
+
+func (h *Handler) pushSyncAccount(ctx context.Context, userID int, a models.SyncAccount) models.SyncResult {
+	result := models.SyncResult{Entity: models.SyncEntities.Account, ClientID: a.ClientID}
+
+	if err := service.ValidateAccount(&models.Account{Type: a.Type}); err != nil {
+		return syncFailure(result, err)
+	}
+
+	id, err := h.resolveSyncID(ctx, "accounts", userID, a.ID, a.ClientID)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+
+	if id == 0 {
+		if err := h.svc.CheckQuota(ctx, userID, models.QuotaResources.Accounts, 1); err != nil {
+			return syncFailure(result, err)
+		}
+		query := `INSERT INTO accounts (user_id, client_id, name, type, balance, opening_balance, currency, description, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $5, $6, $7, NOW(), NOW()) RETURNING id`
+
+		err := h.db.QueryRowContext(ctx, query, userID, a.ClientID, a.Name, a.Type, a.Balance, a.Currency, a.Description).Scan(&result.ID)
+		if err != nil {
+			return syncFailure(result, err)
+		}
+		result.Status = models.SyncStatuses.Created
+		return result
+	}
+
+	query := `UPDATE accounts SET name = $1, type = $2, currency = $3, description = $4, updated_at = NOW()
+			  WHERE id = $5 AND user_id = $6 AND updated_at <= $7`
+
+	res, err := h.db.ExecContext(ctx, query, a.Name, a.Type, a.Currency, a.Description, id, userID, a.UpdatedAt)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	result.ID = id
+	if n, _ := res.RowsAffected(); n == 0 {
+		server, err := h.syncAccounts(ctx, `id = $1 AND user_id = $2`, id, userID)
+		if err != nil || len(server) == 0 {
+			return syncFailure(result, service.ErrAccountNotFound)
+		}
+		return syncConflict(result, server[0])
+	}
+
+	result.Status = models.SyncStatuses.Updated
+	return result
+}
+
+func (h *Handler) pushSyncCategory(ctx context.Context, userID int, cat models.SyncCategory) models.SyncResult {
+	result := models.SyncResult{Entity: models.SyncEntities.Category, ClientID: cat.ClientID}
+
+	id, err := h.resolveSyncID(ctx, "categories", userID, cat.ID, cat.ClientID)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	if cat.ParentID != nil {
+		if err := h.svc.EnsureOwned(ctx, "categories", *cat.ParentID, userID); err != nil {
+			return syncFailure(result, err)
+		}
+	}
+
+	if id == 0 {
+		query := `INSERT INTO categories (user_id, client_id, name, type, color, icon, parent_id, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id`
+
+		err := h.db.QueryRowContext(ctx, query, userID, cat.ClientID, cat.Name, cat.Type, cat.Color, cat.Icon, cat.ParentID).Scan(&result.ID)
+		if err != nil {
+			return syncFailure(result, err)
+		}
+		result.Status = models.SyncStatuses.Created
+		return result
+	}
+
+	query := `UPDATE categories SET name = $1, type = $2, color = $3, icon = $4, parent_id = $5, updated_at = NOW()
+			  WHERE id = $6 AND user_id = $7 AND updated_at <= $8`
+
+	res, err := h.db.ExecContext(ctx, query, cat.Name, cat.Type, cat.Color, cat.Icon, cat.ParentID, id, userID, cat.UpdatedAt)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	result.ID = id
+	if n, _ := res.RowsAffected(); n == 0 {
+		server, err := h.syncCategories(ctx, `id = $1 AND user_id = $2`, id, userID)
+		if err != nil || len(server) == 0 {
+			return syncFailure(result, service.ErrCategoryNotFound)
+		}
+		return syncConflict(result, server[0])
+	}
+
+	result.Status = models.SyncStatuses.Updated
+	return result
+}
+
+func (h *Handler) pushSyncTransaction(ctx context.Context, userID int, st models.SyncTransaction, accountIDs, categoryIDs map[string]int) models.SyncResult {
+	result := models.SyncResult{Entity: models.SyncEntities.Transaction, ClientID: st.ClientID}
+
+	transaction := models.Transaction{
+		UserID:      userID,
+		AccountID:   st.AccountID,
+		CategoryID:  st.CategoryID,
+		Amount:      st.Amount,
+		Type:        st.Type,
+		Description: st.Description,
+		Date:        st.Date,
+		Latitude:    st.Latitude,
+		Longitude:   st.Longitude,
+		Place:       st.Place,
+		Quantity:    st.Quantity,
+		Unit:        st.Unit,
+	}
+
+	var err error
+	if st.AccountClientID != "" {
+		if transaction.AccountID, err = h.resolveClientReference(ctx, "accounts", userID, st.AccountClientID, accountIDs); err != nil {
+			return syncFailure(result, service.ErrAccountNotFound)
+		}
+	}
+	if st.CategoryClientID != "" {
+		if transaction.CategoryID, err = h.resolveClientReference(ctx, "categories", userID, st.CategoryClientID, categoryIDs); err != nil {
+			return syncFailure(result, service.ErrCategoryNotFound)
+		}
+	}
+
+	id, err := h.resolveSyncID(ctx, "transactions", userID, st.ID, st.ClientID)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	defer tx.Rollback()
+
+	if id == 0 {
+		balance, err := h.svc.InsertWithinCap(ctx, tx, &transaction, false)
+		var capErr *service.BudgetCapError
+		if errors.As(err, &capErr) {
+			return syncBudgetCap(result, capErr)
+		}
+		if err != nil {
+			return syncFailure(result, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE transactions SET client_id = $1 WHERE id = $2 AND user_id = $3`, st.ClientID, transaction.ID, userID); err != nil {
+			return syncFailure(result, err)
+		}
+		if err := service.RecordTransactionCreated(ctx, tx, transaction, balance); err != nil {
+			return syncFailure(result, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return syncFailure(result, err)
+		}
+
+		h.svc.TransactionCreated(transaction)
+		result.ID = transaction.ID
+		result.Status = models.SyncStatuses.Created
+		return result
+	}
+
+	result.ID = id
+	transaction.ID = id
+
+	var serverUpdatedAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT updated_at FROM transactions WHERE id = $1 AND user_id = $2 FOR UPDATE`, id, userID).Scan(&serverUpdatedAt)
+	if err == sql.ErrNoRows {
+		return syncFailure(result, service.ErrTransactionNotFound)
+	}
+	if err != nil {
+		return syncFailure(result, err)
+	}
+	if serverUpdatedAt.After(st.UpdatedAt) {
+		tx.Rollback()
+		server, err := h.syncTransactions(ctx, `id = $1 AND user_id = $2`, id, userID)
+		if err != nil || len(server) == 0 {
+			return syncFailure(result, errSyncConflict)
+		}
+		return syncConflict(result, server[0])
+	}
+
+	if err := service.UpdateTransaction(ctx, tx, &transaction); err != nil {
+		return syncFailure(result, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return syncFailure(result, err)
+	}
+	h.svc.TransactionUpdated(userID)
+
+	result.Status = models.SyncStatuses.Updated
+	return result
+}
+
+func (h *Handler) resolveSyncID(ctx context.Context, table string, userID int, id *int, clientID string) (int, error) {
+	if id != nil {
+		return *id, h.svc.EnsureOwned(ctx, table, *id, userID)
+	}
+
+	var existing int
+	err := h.db.QueryRowContext(ctx, `SELECT id FROM `+table+` WHERE user_id = $1 AND client_id = $2`, userID, clientID).Scan(&existing)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return existing, err
+}
+
+func (h *Handler) resolveClientReference(ctx context.Context, table string, userID int, clientID string, resolved map[string]int) (int, error) {
+	if id, ok := resolved[clientID]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := h.db.QueryRowContext(ctx, `SELECT id FROM `+table+` WHERE user_id = $1 AND client_id = $2`, userID, clientID).Scan(&id)
+	return id, err
+}
+
+func (h *Handler) syncAccounts(ctx context.Context, filter string, args ...interface{}) ([]models.Account, error) {
+	query := `SELECT id, user_id, name, type, balance, currency, COALESCE(description, ''), scope, created_at, updated_at
+			  FROM accounts WHERE ` + filter + ` ORDER BY updated_at`
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.Account{}
+	for rows.Next() {
+		var account models.Account
+		if err := rows.Scan(&account.ID, &account.UserID, &account.Name, &account.Type, &account.Balance,
+			&account.Currency, &account.Description, &account.Scope, &account.CreatedAt, &account.UpdatedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+func (h *Handler) syncCategories(ctx context.Context, filter string, args ...interface{}) ([]models.Category, error) {
+	query := `SELECT id, user_id, name, type, COALESCE(color, ''), COALESCE(icon, ''), parent_id, created_at, updated_at,
+			  tax_deductible, tax_code
+			  FROM categories WHERE ` + filter + ` ORDER BY updated_at`
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.UserID, &category.Name, &category.Type, &category.Color,
+			&category.Icon, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.TaxDeductible,
+			&category.TaxCode); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (h *Handler) syncTransactions(ctx context.Context, filter string, args ...interface{}) ([]models.Transaction, error) {
+	query := `SELECT id, user_id, account_id, COALESCE(category_id, 0), amount, type, COALESCE(description, ''), date, scope,
+			  latitude, longitude, COALESCE(place, ''), quantity, COALESCE(unit, ''), created_at, updated_at
+			  FROM transactions WHERE ` + filter + ` ORDER BY updated_at`
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type,
+			&t.Description, &t.Date, &t.Scope, &t.Latitude, &t.Longitude, &t.Place, &t.Quantity, &t.Unit, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+func (h *Handler) syncTombstones(ctx context.Context, userID int, since, until time.Time) ([]models.SyncTombstone, error) {
+	query := `SELECT entity_type, entity_id, deleted_at FROM sync_tombstones
+			  WHERE user_id = $1 AND deleted_at > $2 AND deleted_at <= $3 ORDER BY deleted_at`
+
+	rows, err := h.db.QueryContext(ctx, query, userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := []models.SyncTombstone{}
+	for rows.Next() {
+		var tombstone models.SyncTombstone
+		if err := rows.Scan(&tombstone.EntityType, &tombstone.EntityID, &tombstone.DeletedAt); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+	return tombstones, rows.Err()
+}
+
+func syncConflict(result models.SyncResult, server interface{}) models.SyncResult {
+	result.Status = models.SyncStatuses.Conflict
+	result.Error = errSyncConflict.Error()
+	result.Server = server
+	return result
+}
+
+// syncBudgetCap reports a transaction over a hard-capped budget as a
+// conflict carrying the budget, like the 409 of the transactions endpoint.
+func syncBudgetCap(result models.SyncResult, capErr *service.BudgetCapError) models.SyncResult {
+	result.Status = models.SyncStatuses.Conflict
+	result.Error = capErr.Error()
+	result.Server = gin.H{"budget": capErr.Status}
+	return result
+}
+
+func syncFailure(result models.SyncResult, err error) models.SyncResult {
+	result.Status = models.SyncStatuses.Error
+	switch {
+	case errors.Is(err, service.ErrAccountNotFound), errors.Is(err, service.ErrCategoryNotFound),
+		errors.Is(err, service.ErrTransactionNotFound), errors.Is(err, service.ErrAccountArchived),
+		errors.Is(err, service.ErrInvalidAccountType), errors.Is(err, service.ErrQuotaReached), errors.Is(err, errSyncConflict):
+		result.Error = err.Error()
+	default:
+		log.Printf("Error syncing %s %s: %v", result.Entity, result.ClientID, err)
+		result.Error = "Failed to sync record"
+	}
+	return result
+}
+
+func encodeSyncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(t.UnixMicro(), 10)))
+}
+
+func decodeSyncToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	value, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return time.Time{}, errors.New("unsupported sync token version")
+	}
+
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMicro(micros).UTC(), nil
+}
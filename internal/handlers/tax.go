@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SetTransactionTax(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req models.TransactionTaxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tax := models.TransactionTax{TransactionID: id, TaxDeductible: req.TaxDeductible, TaxCode: req.TaxCode}
+	err = h.svc.SetTransactionTax(c.Request.Context(), c.GetInt("user_id"), &tax)
+	if err == service.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update transaction tax: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction tax"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tax)
+}
+
+func (h *Handler) GetTaxReport(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	year := time.Now().In(h.svc.Location(c.Request.Context(), userID)).Year()
+	if value := c.Query("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil || year < 1900 || year > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a four-digit year"})
+			return
+		}
+	}
+
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.svc.TaxReport(c.Request.Context(), userID, year, scope)
+	if err != nil {
+		log.Printf("Error building tax report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build tax report"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	data, err := service.TaxReportCSV(report)
+	if err != nil {
+		log.Printf("Error writing tax report CSV: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build tax report"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tax-report-%d.csv"`, year))
+	c.Data(http.StatusOK, "text/csv", data)
+}
+
+func (h *Handler) GetVATBreakdown(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	breakdown, err := h.svc.VATBreakdown(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching VAT breakdown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch VAT breakdown"})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+func (h *Handler) SetVATBreakdown(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req models.VATBreakdownRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	breakdown, err := h.svc.SetVATBreakdown(c.Request.Context(), c.GetInt("user_id"), id, req.Lines)
+	switch err {
+	case nil:
+	case service.ErrTransactionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case service.ErrVATLineMismatch, service.ErrVATTotalMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		log.Printf("Failed to save VAT breakdown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save VAT breakdown"})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+func (h *Handler) DeleteVATBreakdown(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	err = h.svc.DeleteVATBreakdown(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrTransactionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete VAT breakdown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete VAT breakdown"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "VAT breakdown deleted"})
+}
+
+func (h *Handler) GetVATReport(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	now := time.Now().In(h.svc.Location(c.Request.Context(), userID))
+	year, quarter := now.Year(), (int(now.Month())+2)/3
+	if value := c.Query("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil || year < 1900 || year > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a four-digit year"})
+			return
+		}
+	}
+	if value := c.Query("quarter"); value != "" {
+		var err error
+		if quarter, err = strconv.Atoi(value); err != nil || quarter < 1 || quarter > 4 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quarter must be between 1 and 4"})
+			return
+		}
+	}
+
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.svc.VATReport(c.Request.Context(), userID, year, quarter, scope)
+	if err != nil {
+		log.Printf("Error building VAT report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build VAT report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetTelegramLink(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var link models.TelegramLink
+	var username sql.NullString
+	var linkedAt time.Time
+	err := h.db.QueryRowContext(c.Request.Context(), `SELECT username, linked_at FROM telegram_links WHERE user_id = $1`, userID).Scan(&username, &linkedAt)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error loading telegram link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load telegram link"})
+		return
+	}
+
+	if err == nil {
+		link.Linked = true
+		link.Username = username.String
+		link.LinkedAt = &linkedAt
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+func (h *Handler) CreateTelegramLinkCode(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	code, err := auth.GenerateRandomToken(6)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate link code"})
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(models.TelegramSettings.LinkCodeTTLMinutes) * time.Minute)
+
+	_, err = h.db.ExecContext(c.Request.Context(), `INSERT INTO telegram_link_codes (code, user_id, expires_at, created_at) VALUES ($1, $2, $3, NOW())`,
+		code, userID, expiresAt)
+	if err != nil {
+		log.Printf("Error creating telegram link code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate link code"})
+		return
+	}
+
+	response := models.TelegramLinkCode{
+		Code:      code,
+		Command:   "/start " + code,
+		ExpiresAt: expiresAt,
+	}
+	if botName := config.Get().Telegram.BotUsername; botName != "" {
+		response.BotURL = "https://t.me/" + botName + "?start=" + code
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) DeleteTelegramLink(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	if _, err := h.db.ExecContext(c.Request.Context(), `DELETE FROM telegram_links WHERE user_id = $1`, userID); err != nil {
+		log.Printf("Error deleting telegram link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink telegram"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Telegram unlinked"})
+}
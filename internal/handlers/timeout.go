@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"context"
+
+	"personal-finance-tracker/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout bounds the request context, which every database query made
+// while handling the request inherits.
+func (h *Handler) RequestTimeout() gin.HandlerFunc {
+	timeout := config.Get().HTTP.RequestTimeout
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing records every request as a server span, continuing the caller's
+// trace when the request carries a traceparent header; tracing.Sampler
+// decides whether it is recorded. Handlers pass the span on through the
+// request context to database queries, jobs and webhooks.
+func (h *Handler) Tracing() gin.HandlerFunc {
+	if !tracing.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return otelgin.Middleware(config.Get().Tracing.ServiceName)
+}
+
+// TraceUser adds the signed-in user to the request's span once the request
+// has been handled. It runs after Tracing, within its span.
+func (h *Handler) TraceUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if userID := c.GetInt("user_id"); userID != 0 {
+			trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.Int("enduser.id", userID))
+		}
+	}
+}
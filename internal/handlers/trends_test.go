@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+	"personal-finance-tracker/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+func TestCalculatePrediction(t *testing.T) {
+	tests := []struct {
+		name                          string
+		current, previous, historical float64
+		want                          float64
+	}{
+		{"no spending", 0, 0, 0, 0},
+		{"rising", 100, 80, 50, 58},
+		{"falling", 80, 100, 50, 34},
+		{"no previous period", 100, 0, 50, 50},
+		{"history only", 0, 0, 30, 6},
+		// 0.4*10 + 0.4*(10-200) is negative, so 80% of the current spend.
+		{"steep drop", 10, 200, 0, 8},
+	}
+
+	h := &Handler{}
+	for _, tt := range tests {
+		got := h.calculatePrediction(tt.current, tt.previous, tt.historical, "month")
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: calculatePrediction(%v, %v, %v) = %v, want %v", tt.name, tt.current, tt.previous, tt.historical, got, tt.want)
+		}
+	}
+}
+
+func TestTrendDirection(t *testing.T) {
+	tests := []struct {
+		current, previous float64
+		direction         string
+		change            float64
+	}{
+		{150, 100, models.TrendDirections.Up, 50},
+		{110.5, 100, models.TrendDirections.Up, 10.5},
+		{110, 100, models.TrendDirections.Stable, 10},
+		{100, 100, models.TrendDirections.Stable, 0},
+		{90, 100, models.TrendDirections.Stable, -10},
+		{50, 100, models.TrendDirections.Down, -50},
+		{0, 100, models.TrendDirections.Down, -100},
+		{25, 0, models.TrendDirections.Up, 999.9},
+		{0, 0, models.TrendDirections.New, 0},
+	}
+
+	for _, tt := range tests {
+		direction, change := trendDirection(tt.current, tt.previous)
+		if direction != tt.direction || math.Abs(change-tt.change) > 1e-9 {
+			t.Errorf("trendDirection(%v, %v) = %q, %v, want %q, %v", tt.current, tt.previous, direction, change, tt.direction, tt.change)
+		}
+	}
+}
+
+// benchmarkHandler serves a user with two years of history at roughly
+// fourteen transactions a day.
+func benchmarkHandler(b *testing.B) (*Handler, int) {
+	db := testdb.New(b)
+	userID := testdb.Ledger(b, db, 730, 10000)
+	return NewHandler(db, service.New(db, nil, events.NewBroker())), userID
+}
+
+func BenchmarkAnalyticsSummary(b *testing.B) {
+	h, userID := benchmarkHandler(b)
+	ctx := context.Background()
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, -3, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var summary models.AnalyticsSummary
+		if err := h.fillAnalyticsSummary(ctx, userID, start, end, &summary); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSpendingAnalytics(b *testing.B) {
+	h, userID := benchmarkHandler(b)
+	ctx := context.Background()
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, -3, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.spendingAnalytics(ctx, userID, start, end, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSpendingTrends(b *testing.B) {
+	h, userID := benchmarkHandler(b)
+	ctx := context.Background()
+	today := time.Now().UTC().Format("2006-01-02")
+
+	for _, period := range []string{"week", "month", "pay_period"} {
+		b.Run(period, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := h.calculateSpendingTrends(ctx, userID, period, today, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func accountV2(a models.Account) models.AccountV2 {
+	v := models.AccountV2{
+		ID:           a.ID,
+		Name:         a.Name,
+		Type:         a.Type,
+		BalanceCents: toCents(a.Balance),
+		Currency:     a.Currency,
+		Description:  a.Description,
+		Scope:        a.Scope,
+		CreatedAt:    a.CreatedAt,
+		UpdatedAt:    a.UpdatedAt,
+		ArchivedAt:   a.ArchivedAt,
+		ClosedAt:     a.ClosedAt,
+	}
+	if a.ClosingBalance != nil {
+		cents := toCents(*a.ClosingBalance)
+		v.ClosingBalanceCents = &cents
+	}
+	if a.CreditLimit != nil {
+		limit, available := toCents(*a.CreditLimit), toCents(*a.AvailableCredit)
+		v.CreditLimitCents, v.AvailableCreditCents = &limit, &available
+	}
+	v.InterestRate = a.InterestRate
+	v.StatementClosingDay = a.StatementClosingDay
+	v.PaymentDueDay = a.PaymentDueDay
+	return v
+}
+
+func categoryV2(c models.Category) models.CategoryV2 {
+	return models.CategoryV2{
+		ID:        c.ID,
+		Name:      c.Name,
+		Type:      c.Type,
+		Color:     c.Color,
+		Icon:      c.Icon,
+		ParentID:  c.ParentID,
+		GroupID:   c.GroupID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func transactionV2(t models.Transaction) models.TransactionV2 {
+	v := models.TransactionV2{
+		ID:          t.ID,
+		AccountID:   t.AccountID,
+		CategoryID:  t.CategoryID,
+		AmountCents: toCents(t.Amount),
+		Type:        t.Type,
+		Description: t.Description,
+		Date:        t.Date,
+		Scope:       t.Scope,
+		Latitude:    t.Latitude,
+		Longitude:   t.Longitude,
+		Place:       t.Place,
+		Quantity:    t.Quantity,
+		Unit:        t.Unit,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+		Category:    t.Category,
+		Account:     t.Account,
+	}
+	if t.RunningBalance != nil {
+		cents := toCents(*t.RunningBalance)
+		v.RunningBalanceCents = &cents
+	}
+	return v
+}
+
+func applyAccountTypeFields(req models.AccountV2Request, a *models.Account) {
+	if req.CreditLimitCents != nil {
+		limit := fromCents(*req.CreditLimitCents)
+		a.CreditLimit = &limit
+	}
+	a.InterestRate = req.InterestRate
+	a.StatementClosingDay = req.StatementClosingDay
+	a.PaymentDueDay = req.PaymentDueDay
+}
+
+func pathID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		abortWithError(c, http.StatusBadRequest, "id must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) GetAccountsV2(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.svc.ListAccounts(c.Request.Context(), c.GetInt("user_id"), c.Query("include_archived") == "true", scope)
+	if err != nil {
+		log.Printf("Failed to fetch accounts: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to fetch accounts")
+		return
+	}
+
+	data := []models.AccountV2{}
+	for i := offset; i < len(accounts) && i < offset+limit; i++ {
+		data = append(data, accountV2(accounts[i]))
+	}
+
+	c.JSON(http.StatusOK, newPage(data, len(data), limit, offset, len(accounts)))
+}
+
+func (h *Handler) CreateAccountV2(c *gin.Context) {
+	var req models.AccountV2Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	account := models.Account{
+		UserID:      c.GetInt("user_id"),
+		Name:        req.Name,
+		Type:        req.Type,
+		Balance:     fromCents(req.BalanceCents),
+		Currency:    req.Currency,
+		Description: req.Description,
+		Scope:       req.Scope,
+	}
+	applyAccountTypeFields(req, &account)
+	if !h.enforceQuota(c, models.QuotaResources.Accounts, 1) {
+		return
+	}
+	err := h.svc.CreateAccount(c.Request.Context(), &account)
+	if isAccountValidationError(err) {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create account: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to create account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, accountV2(account))
+}
+
+func (h *Handler) UpdateAccountV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AccountV2Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	account := models.Account{
+		ID:          id,
+		UserID:      c.GetInt("user_id"),
+		Name:        req.Name,
+		Type:        req.Type,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Scope:       req.Scope,
+	}
+	applyAccountTypeFields(req, &account)
+	err := h.svc.UpdateAccount(c.Request.Context(), &account)
+	if err == service.ErrAccountNotFound {
+		abortWithError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if isAccountValidationError(err) {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update account: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to update account")
+		return
+	}
+
+	c.JSON(http.StatusOK, accountV2(account))
+}
+
+func (h *Handler) DeleteAccountV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	err := h.svc.DeleteAccount(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrAccountNotFound:
+		abortWithError(c, http.StatusNotFound, err.Error())
+	case err == service.ErrResourceInUse:
+		abortWithError(c, http.StatusConflict, "Account still has transactions")
+	case err != nil:
+		log.Printf("Failed to delete account: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to delete account")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) ArchiveAccountV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ArchiveAccountRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	account, err := h.svc.ArchiveAccount(c.Request.Context(), c.GetInt("user_id"), id, req.Close)
+	switch {
+	case err == service.ErrAccountNotFound:
+		abortWithError(c, http.StatusNotFound, err.Error())
+	case err != nil:
+		log.Printf("Failed to archive account: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to archive account")
+	default:
+		c.JSON(http.StatusOK, accountV2(account))
+	}
+}
+
+func (h *Handler) UnarchiveAccountV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	account, err := h.svc.UnarchiveAccount(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrAccountNotFound:
+		abortWithError(c, http.StatusNotFound, err.Error())
+	case err != nil:
+		log.Printf("Failed to unarchive account: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to unarchive account")
+	default:
+		c.JSON(http.StatusOK, accountV2(account))
+	}
+}
+
+func (h *Handler) GetCategoriesV2(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	categories, err := h.svc.GetCategories(c.Request.Context(), c.GetInt("user_id"), c.Query("type"))
+	if err != nil {
+		log.Printf("Failed to fetch categories: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to fetch categories")
+		return
+	}
+
+	data := []models.CategoryV2{}
+	for i := offset; i < len(categories) && i < offset+limit; i++ {
+		data = append(data, categoryV2(categories[i]))
+	}
+
+	c.JSON(http.StatusOK, newPage(data, len(data), limit, offset, len(categories)))
+}
+
+func (h *Handler) CreateCategoryV2(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	category := models.Category{
+		UserID:   c.GetInt("user_id"),
+		Name:     req.Name,
+		Type:     req.Type,
+		Color:    req.Color,
+		Icon:     req.Icon,
+		ParentID: req.ParentID,
+
+		TaxDeductible: req.TaxDeductible,
+		TaxCode:       req.TaxCode,
+
+		GroupID: req.GroupID,
+	}
+	err := h.svc.CreateCategory(c.Request.Context(), &category)
+	if err == service.ErrCategoryNotFound {
+		abortWithError(c, http.StatusUnprocessableEntity, "Parent category not found")
+		return
+	}
+	if err == service.ErrCategoryGroupNotFound || err == service.ErrUnknownIcon || err == service.ErrInvalidColor ||
+		err == service.ErrColorContrast {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create category: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, categoryV2(category))
+}
+
+func (h *Handler) GetTransactionsV2(c *gin.Context) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	expand, ok := parseTransactionExpand(c)
+	if !ok {
+		return
+	}
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+	accountID, ok := parseAccountFilter(c)
+	if !ok {
+		return
+	}
+	geo, ok := parseGeoFilter(c)
+	if !ok {
+		return
+	}
+	userID := c.GetInt("user_id")
+
+	transactions, err := h.svc.GetTransactionsExpanded(c.Request.Context(), userID, limit, offset, expand, scope, accountID, geo)
+	if err != nil {
+		log.Printf("Failed to fetch transactions: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to fetch transactions")
+		return
+	}
+	total, err := h.svc.CountTransactions(c.Request.Context(), userID, scope, accountID, geo)
+	if err != nil {
+		log.Printf("Failed to count transactions: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to fetch transactions")
+		return
+	}
+
+	data := make([]models.TransactionV2, 0, len(transactions))
+	for _, t := range transactions {
+		data = append(data, transactionV2(t))
+	}
+
+	c.JSON(http.StatusOK, newPage(data, len(data), limit, offset, total))
+}
+
+func (h *Handler) transactionFromV2(c *gin.Context, id int) (models.Transaction, bool) {
+	var req models.TransactionV2Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return models.Transaction{}, false
+	}
+
+	transaction := models.Transaction{
+		ID:          id,
+		UserID:      c.GetInt("user_id"),
+		AccountID:   req.AccountID,
+		CategoryID:  req.CategoryID,
+		Amount:      fromCents(req.AmountCents),
+		Type:        req.Type,
+		Description: req.Description,
+		Scope:       req.Scope,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Place:       req.Place,
+		Quantity:    req.Quantity,
+		Unit:        req.Unit,
+	}
+	// A new transaction defaults to now; an update without a date keeps the
+	// stored one.
+	if req.Date != nil {
+		transaction.Date = *req.Date
+	} else if id == 0 {
+		transaction.Date = time.Now()
+	}
+	return transaction, true
+}
+
+func (h *Handler) CreateTransactionV2(c *gin.Context) {
+	transaction, ok := h.transactionFromV2(c, 0)
+	if !ok {
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	err := h.svc.CreateTransaction(c.Request.Context(), &transaction, force)
+	if abortWithQuota(c, err) {
+		return
+	}
+	var capErr *service.BudgetCapError
+	if errors.As(err, &capErr) {
+		abortWithBudgetCap(c, capErr)
+		return
+	}
+	if err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound || err == service.ErrAccountArchived {
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create transaction: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to create transaction")
+		return
+	}
+
+	c.JSON(http.StatusCreated, transactionV2(transaction))
+}
+
+func (h *Handler) UpdateTransactionV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	transaction, ok := h.transactionFromV2(c, id)
+	if !ok {
+		return
+	}
+
+	err := h.svc.UpdateTransaction(c.Request.Context(), &transaction)
+	switch {
+	case err == service.ErrTransactionNotFound:
+		abortWithError(c, http.StatusNotFound, err.Error())
+	case err == service.ErrAccountNotFound || err == service.ErrCategoryNotFound || err == service.ErrAccountArchived:
+		abortWithError(c, http.StatusUnprocessableEntity, err.Error())
+	case err != nil:
+		log.Printf("Failed to update transaction: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to update transaction")
+	default:
+		c.JSON(http.StatusOK, transactionV2(transaction))
+	}
+}
+
+func (h *Handler) DeleteTransactionV2(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	err := h.svc.DeleteTransaction(c.Request.Context(), c.GetInt("user_id"), id)
+	switch {
+	case err == service.ErrTransactionNotFound:
+		abortWithError(c, http.StatusNotFound, err.Error())
+	case err != nil:
+		log.Printf("Failed to delete transaction: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "Failed to delete transaction")
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
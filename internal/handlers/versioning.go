@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+func (h *Handler) APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		c.Header("API-Version", strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+// abortWithError writes an error in the shape expected by the API version of
+// the request: {"error": "..."} for v1 and RFC 7807 problem details from v2.
+func abortWithError(c *gin.Context, status int, message string) {
+	if c.GetInt("api_version") >= 2 {
+		writeProblem(c, status, message)
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+func writeProblem(c *gin.Context, status int, detail string) {
+	title := http.StatusText(status)
+	problem := models.Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString("request_id"),
+	}
+	if detail != title {
+		problem.Detail = detail
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		c.Status(status)
+		return
+	}
+	c.Data(status, problemContentType, body)
+}
+
+func parsePagination(c *gin.Context) (int, int, bool) {
+	settings := models.Pagination
+
+	limit := settings.DefaultLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			abortWithError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	if limit > settings.MaxLimit {
+		limit = settings.MaxLimit
+	}
+
+	offset := 0
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			abortWithError(c, http.StatusBadRequest, "offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+
+	return limit, offset, true
+}
+
+func parseTransactionExpand(c *gin.Context) (models.TransactionExpand, bool) {
+	var expand models.TransactionExpand
+	for _, field := range strings.Split(c.Query("expand"), ",") {
+		switch strings.TrimSpace(field) {
+		case "":
+		case "category":
+			expand.Category = true
+		case "account":
+			expand.Account = true
+		default:
+			abortWithError(c, http.StatusBadRequest, "Unsupported expand value "+strconv.Quote(field)+" (supported: category, account)")
+			return expand, false
+		}
+	}
+	return expand, true
+}
+
+// parseScope reads the optional scope query parameter that limits results to
+// personal or business records.
+func parseScope(c *gin.Context) (string, bool) {
+	scope := c.Query("scope")
+	if !service.ValidScope(scope) {
+		abortWithError(c, http.StatusBadRequest, service.ErrInvalidScope.Error())
+		return "", false
+	}
+	return scope, true
+}
+
+// parseAccountFilter reads the optional account_id query parameter that lists
+// one account's transactions; 0 means every account.
+func parseAccountFilter(c *gin.Context) (int, bool) {
+	value := c.Query("account_id")
+	if value == "" {
+		return 0, true
+	}
+	accountID, err := strconv.Atoi(value)
+	if err != nil || accountID < 1 {
+		abortWithError(c, http.StatusBadRequest, "account_id must be a positive integer")
+		return 0, false
+	}
+	return accountID, true
+}
+
+// parseGeoFilter reads the optional bbox=south,west,north,east and
+// near=latitude,longitude query parameters; radius_km sets how near, 1 km by
+// default.
+func parseGeoFilter(c *gin.Context) (models.GeoFilter, bool) {
+	var geo models.GeoFilter
+	if value := c.Query("bbox"); value != "" {
+		coords, ok := parseCoordinates(value, 4)
+		if !ok || !validLatitude(coords[0]) || !validLongitude(coords[1]) || !validLatitude(coords[2]) ||
+			!validLongitude(coords[3]) || coords[0] > coords[2] {
+			abortWithError(c, http.StatusBadRequest, "bbox must be south,west,north,east in degrees")
+			return geo, false
+		}
+		geo.Bounds = &models.GeoBounds{South: coords[0], West: coords[1], North: coords[2], East: coords[3]}
+	}
+	if value := c.Query("near"); value != "" {
+		coords, ok := parseCoordinates(value, 2)
+		if !ok || !validLatitude(coords[0]) || !validLongitude(coords[1]) {
+			abortWithError(c, http.StatusBadRequest, "near must be latitude,longitude in degrees")
+			return geo, false
+		}
+		geo.Near = &models.GeoPoint{Latitude: coords[0], Longitude: coords[1]}
+		geo.RadiusKm = models.GeoSettings.DefaultRadiusKm
+	}
+	if value := c.Query("radius_km"); value != "" {
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil || geo.Near == nil || radius <= 0 || radius > models.GeoSettings.MaxRadiusKm {
+			abortWithError(c, http.StatusBadRequest, "radius_km must be a positive number of kilometres and needs near")
+			return geo, false
+		}
+		geo.RadiusKm = radius
+	}
+	return geo, true
+}
+
+func parseCoordinates(value string, n int) ([]float64, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, false
+	}
+	coords := make([]float64, n)
+	for i, part := range parts {
+		coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, false
+		}
+		coords[i] = coord
+	}
+	return coords, true
+}
+
+func validLatitude(v float64) bool {
+	return v >= -90 && v <= 90
+}
+
+func validLongitude(v float64) bool {
+	return v >= -180 && v <= 180
+}
+
+func newPage(data interface{}, count, limit, offset, total int) models.Page {
+	page := models.Page{
+		Data:       data,
+		Pagination: models.PageInfo{Limit: limit, Offset: offset, Total: total},
+	}
+	if next := offset + count; count > 0 && next < total {
+		page.Pagination.NextOffset = &next
+	}
+	return page
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
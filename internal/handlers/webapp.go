@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"personal-finance-tracker/internal/webapp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webAppContentSecurityPolicy relaxes the API policy just enough for the
+// dashboard's own scripts, styles and API calls.
+const webAppContentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; img-src 'self' data:; " +
+	"connect-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// WebApp serves the embedded dashboard under /app.
+func (h *Handler) WebApp() gin.HandlerFunc {
+	files := http.StripPrefix("/app", http.FileServer(http.FS(webapp.Files())))
+
+	return func(c *gin.Context) {
+		c.Header("Content-Security-Policy", webAppContentSecurityPolicy)
+		c.Header("Cache-Control", "no-cache")
+		files.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetWidgetTokens(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	query := `SELECT id, user_id, name, refresh_interval, last_used_at, revoked_at, created_at, updated_at
+			  FROM widget_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := h.db.QueryContext(c.Request.Context(), query, userID)
+	if err != nil {
+		log.Printf("Error fetching widget tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch widget tokens"})
+		return
+	}
+	defer rows.Close()
+
+	widgets := []models.WidgetToken{}
+	for rows.Next() {
+		var widget models.WidgetToken
+		err := rows.Scan(&widget.ID, &widget.UserID, &widget.Name, &widget.RefreshInterval,
+			&widget.LastUsedAt, &widget.RevokedAt, &widget.CreatedAt, &widget.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		widgets = append(widgets, widget)
+	}
+
+	c.JSON(http.StatusOK, widgets)
+}
+
+func (h *Handler) CreateWidgetToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.CreateWidgetTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RefreshInterval == 0 {
+		req.RefreshInterval = models.WidgetSettings.DefaultRefreshInterval
+	}
+	if req.RefreshInterval < models.WidgetSettings.MinRefreshInterval || req.RefreshInterval > models.WidgetSettings.MaxRefreshInterval {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("refresh_interval must be between %d and %d seconds",
+			models.WidgetSettings.MinRefreshInterval, models.WidgetSettings.MaxRefreshInterval)})
+		return
+	}
+
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		log.Printf("Failed to generate widget token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	widget := models.WidgetToken{
+		UserID:          userID,
+		Name:            req.Name,
+		RefreshInterval: req.RefreshInterval,
+	}
+
+	query := `INSERT INTO widget_tokens (user_id, name, token_hash, refresh_interval, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = h.db.QueryRowContext(c.Request.Context(), query, userID, req.Name, auth.HashToken(token), req.RefreshInterval).
+		Scan(&widget.ID, &widget.CreatedAt, &widget.UpdatedAt)
+	if err != nil {
+		log.Printf("Failed to create widget token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create widget token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateWidgetTokenResponse{
+		Token:   token,
+		FeedURL: "/api/v1/widgets/feed/" + token,
+		Widget:  widget,
+	})
+}
+
+func (h *Handler) RevokeWidgetToken(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid widget token ID"})
+		return
+	}
+
+	result, err := h.db.ExecContext(c.Request.Context(), `UPDATE widget_tokens SET revoked_at = NOW(), updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID)
+	if err != nil {
+		log.Printf("Failed to revoke widget token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke widget token"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Widget token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Widget token revoked"})
+}
+
+func (h *Handler) GetWidgetFeed(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var widgetID, userID, refreshInterval int
+	query := `SELECT id, user_id, refresh_interval FROM widget_tokens WHERE token_hash = $1 AND revoked_at IS NULL`
+
+	err := h.db.QueryRowContext(ctx, query, auth.HashToken(c.Param("token"))).Scan(&widgetID, &userID, &refreshInterval)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Widget not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading widget token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load widget"})
+		return
+	}
+
+	feed, err := h.buildWidgetFeed(ctx, userID, refreshInterval)
+	if err != nil {
+		log.Printf("Error building widget feed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build widget feed"})
+		return
+	}
+
+	if _, err := h.db.ExecContext(ctx, `UPDATE widget_tokens SET last_used_at = NOW() WHERE id = $1`, widgetID); err != nil {
+		log.Printf("Error updating widget last_used_at: %v", err)
+	}
+
+	payload, err := json.Marshal(feed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode widget feed"})
+		return
+	}
+
+	signature := auth.SignPayload(payload)
+	etag := `"` + signature[:32] + `"`
+
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", refreshInterval))
+	c.Header("ETag", etag)
+	c.Header("X-Widget-Signature", signature)
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", payload)
+}
+
+func (h *Handler) buildWidgetFeed(ctx context.Context, userID, refreshInterval int) (models.WidgetFeed, error) {
+	now := time.Now().In(h.svc.Location(ctx, userID))
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	feed := models.WidgetFeed{
+		Month:          monthStart.Format("2006-01"),
+		RefreshSeconds: refreshInterval,
+		GeneratedAt:    now.Truncate(time.Duration(refreshInterval) * time.Second).UTC().Format(time.RFC3339),
+	}
+
+	err := h.svc.ReadDB().QueryRowContext(ctx, `SELECT COALESCE(SUM(balance), 0) FROM accounts WHERE user_id = $1 AND archived_at IS NULL`, userID).Scan(&feed.Balance)
+	if err != nil {
+		return feed, err
+	}
+
+	spendQuery := `
+		SELECT 
+			COALESCE(SUM(CASE WHEN type = 'expense' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN type = 'expense' THEN -amount ELSE amount END), 0)
+		FROM transactions
+		WHERE user_id = $1 AND date >= $2 AND date < $3`
+
+	err = h.svc.ReadDB().QueryRowContext(ctx, spendQuery, userID, monthStart, monthEnd).Scan(&feed.MonthSpend, &feed.NetWorthDelta)
+	if err != nil {
+		return feed, err
+	}
+
+	budgetQuery := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM budget_rules
+		WHERE user_id = $1 
+			AND period = 'monthly'
+			AND start_date < $3
+			AND (end_date IS NULL OR end_date >= $2)`
+
+	err = h.svc.ReadDB().QueryRowContext(ctx, budgetQuery, userID, monthStart, monthEnd).Scan(&feed.MonthBudget)
+	if err != nil {
+		return feed, err
+	}
+
+	if feed.MonthBudget > 0 {
+		feed.BudgetUsed = (feed.MonthSpend / feed.MonthBudget) * 100
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWishlist lists planned purchases with whether safe-to-spend covers
+// them, optionally only those with ?status=planned or purchased.
+func (h *Handler) GetWishlist(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.WishlistStatuses.Planned, models.WishlistStatuses.Purchased:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be planned or purchased"})
+		return
+	}
+
+	wishlist, err := h.svc.Wishlist(c.Request.Context(), c.GetInt("user_id"), status, time.Now())
+	if err != nil {
+		log.Printf("Error fetching wishlist: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+func (h *Handler) GetPlannedPurchase(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planned purchase ID"})
+		return
+	}
+
+	purchase, err := h.svc.PlannedPurchase(c.Request.Context(), c.GetInt("user_id"), id)
+	h.respondPlannedPurchase(c, http.StatusOK, purchase, err)
+}
+
+func (h *Handler) CreatePlannedPurchase(c *gin.Context) {
+	req, ok := bindPlannedPurchase(c)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.svc.CreatePlannedPurchase(c.Request.Context(), c.GetInt("user_id"), req)
+	h.respondPlannedPurchase(c, http.StatusCreated, purchase, err)
+}
+
+func (h *Handler) UpdatePlannedPurchase(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planned purchase ID"})
+		return
+	}
+	req, ok := bindPlannedPurchase(c)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.svc.UpdatePlannedPurchase(c.Request.Context(), c.GetInt("user_id"), id, req)
+	h.respondPlannedPurchase(c, http.StatusOK, purchase, err)
+}
+
+func (h *Handler) DeletePlannedPurchase(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planned purchase ID"})
+		return
+	}
+
+	err = h.svc.DeletePlannedPurchase(c.Request.Context(), c.GetInt("user_id"), id)
+	if err == service.ErrPlannedPurchaseNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to delete planned purchase: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete planned purchase"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Planned purchase deleted"})
+}
+
+// MarkPurchased attaches the expense that paid for a wishlist item.
+func (h *Handler) MarkPurchased(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planned purchase ID"})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchase, err := h.svc.MarkPurchased(c.Request.Context(), c.GetInt("user_id"), id, req.TransactionID)
+	h.respondPlannedPurchase(c, http.StatusOK, purchase, err)
+}
+
+func (h *Handler) UnmarkPurchased(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planned purchase ID"})
+		return
+	}
+
+	purchase, err := h.svc.UnmarkPurchased(c.Request.Context(), c.GetInt("user_id"), id)
+	h.respondPlannedPurchase(c, http.StatusOK, purchase, err)
+}
+
+// GetWishlistAnalytics compares planned with actual cost for the wishlist
+// items purchased between start_date and end_date.
+func (h *Handler) GetWishlistAnalytics(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	startDate, endDate, ok := parseDateRange(c, h.svc.Location(c.Request.Context(), userID))
+	if !ok {
+		return
+	}
+
+	analytics, err := h.svc.WishlistAnalytics(c.Request.Context(), userID, startDate, endDate)
+	if err != nil {
+		log.Printf("Error getting wishlist analytics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wishlist analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func bindPlannedPurchase(c *gin.Context) (models.PlannedPurchaseRequest, bool) {
+	var req models.PlannedPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	if req.TargetDate != nil {
+		if _, err := time.Parse("2006-01-02", *req.TargetDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_date must use the YYYY-MM-DD format"})
+			return req, false
+		}
+	}
+	return req, true
+}
+
+func (h *Handler) respondPlannedPurchase(c *gin.Context, status int, purchase models.PlannedPurchase, err error) {
+	switch err {
+	case nil:
+		c.JSON(status, purchase)
+	case service.ErrPlannedPurchaseNotFound, service.ErrTransactionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrCategoryNotFound, service.ErrPurchaseNotExpense:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrTooManyPlannedPurchases, service.ErrPurchaseTransactionTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to save planned purchase: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save planned purchase"})
+	}
+}
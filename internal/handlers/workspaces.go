@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// workspaceRoutes are the first path segments, after the API version, of
+// the routes that serve a workspace's books. Everything else, such as the
+// profile, sessions or notifications, stays personal.
+var workspaceRoutes = map[string]bool{
+	"accounts": true, "categories": true, "category-groups": true, "transactions": true, "budgets": true,
+	"recurring": true, "payees": true, "imports": true, "receipts": true, "reimbursements": true,
+	"analytics": true, "reports": true, "dashboard": true, "exports": true, "audit-log": true, "settings": true,
+}
+
+// WorkspaceContext serves requests that carry the X-Workspace-ID header from
+// that workspace's books instead of the caller's own. Viewers may only
+// read, and only admins may change the workspace's settings.
+func (h *Handler) WorkspaceContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(models.WorkspaceSettings.Header)
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		workspaceID, err := strconv.Atoi(value)
+		if err != nil || workspaceID < 1 {
+			abortWithError(c, http.StatusBadRequest, "Invalid workspace ID")
+			return
+		}
+		if _, delegated := c.Get("advisor_grant"); delegated {
+			abortWithError(c, http.StatusForbidden, "Delegated tokens cannot be used in a workspace")
+			return
+		}
+
+		route := strings.TrimPrefix(c.FullPath(), "/api/")
+		_, route, _ = strings.Cut(route, "/")
+		section, _, _ := strings.Cut(route, "/")
+		if !workspaceRoutes[section] {
+			abortWithError(c, http.StatusBadRequest, "This endpoint is not available in a workspace")
+			return
+		}
+
+		membership, err := h.svc.WorkspaceMembership(c.Request.Context(), c.GetInt("user_id"), workspaceID)
+		if err == service.ErrWorkspaceNotFound {
+			abortWithError(c, http.StatusNotFound, "Workspace not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking workspace membership: %v", err)
+			abortWithError(c, http.StatusInternalServerError, "Failed to validate workspace access")
+			return
+		}
+
+		required := models.WorkspaceRoles.Viewer
+		if !isReadOnlyMethod(c.Request.Method) {
+			required = models.WorkspaceRoles.Member
+			if section == "settings" {
+				required = models.WorkspaceRoles.Admin
+			}
+		}
+		if !service.WorkspaceRoleAllows(membership.Role, required) {
+			abortWithError(c, http.StatusForbidden, service.ErrWorkspaceRole.Error())
+			return
+		}
+
+		c.Set("workspace_member_id", c.GetInt("user_id"))
+		c.Set("workspace_id", membership.WorkspaceID)
+		c.Set("user_id", membership.LedgerUserID)
+		c.Next()
+	}
+}
+
+func (h *Handler) GetWorkspaces(c *gin.Context) {
+	workspaces, err := h.svc.Workspaces(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error fetching workspaces: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workspaces"})
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+func (h *Handler) GetWorkspace(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	workspace, err := h.svc.Workspace(c.Request.Context(), c.GetInt("user_id"), id)
+	h.respondWorkspace(c, http.StatusOK, workspace, err)
+}
+
+func (h *Handler) CreateWorkspace(c *gin.Context) {
+	var req models.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.svc.CreateWorkspace(c.Request.Context(), c.GetInt("user_id"), req.Name)
+	h.respondWorkspace(c, http.StatusCreated, workspace, err)
+}
+
+func (h *Handler) UpdateWorkspace(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req models.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.svc.RenameWorkspace(c.Request.Context(), c.GetInt("user_id"), id, req.Name)
+	h.respondWorkspace(c, http.StatusOK, workspace, err)
+}
+
+// DeleteWorkspace deletes a workspace together with its accounts,
+// categories and transactions.
+func (h *Handler) DeleteWorkspace(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	err = h.svc.DeleteWorkspace(c.Request.Context(), c.GetInt("user_id"), id)
+	if err != nil {
+		h.respondWorkspace(c, http.StatusOK, models.Workspace{}, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted"})
+}
+
+func (h *Handler) AddWorkspaceMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var req models.WorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.AddWorkspaceMember(c.Request.Context(), c.GetInt("user_id"), id, req)
+	h.respondWorkspace(c, http.StatusCreated, member, err)
+}
+
+func (h *Handler) UpdateWorkspaceMember(c *gin.Context) {
+	id, memberID, ok := workspaceMemberParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.WorkspaceRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.UpdateWorkspaceMember(c.Request.Context(), c.GetInt("user_id"), id, memberID, req.Role)
+	h.respondWorkspace(c, http.StatusOK, member, err)
+}
+
+// RemoveWorkspaceMember removes a member; members may also remove
+// themselves to leave the workspace.
+func (h *Handler) RemoveWorkspaceMember(c *gin.Context) {
+	id, memberID, ok := workspaceMemberParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.svc.RemoveWorkspaceMember(c.Request.Context(), c.GetInt("user_id"), id, memberID)
+	if err != nil {
+		h.respondWorkspace(c, http.StatusOK, nil, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace member removed"})
+}
+
+func workspaceMemberParams(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return 0, 0, false
+	}
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return 0, 0, false
+	}
+	return id, memberID, true
+}
+
+func (h *Handler) respondWorkspace(c *gin.Context, status int, body interface{}, err error) {
+	switch err {
+	case nil:
+		c.JSON(status, body)
+	case service.ErrWorkspaceNotFound, service.ErrWorkspaceMemberNotFound, service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrWorkspaceRole, service.ErrWorkspaceOwner:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrTooManyWorkspaces, service.ErrTooManyWorkspaceMembers, service.ErrWorkspaceMemberExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to save workspace: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save workspace"})
+	}
+}
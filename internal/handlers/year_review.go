@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetYearReview sums up a calendar year, the current one unless ?year= is
+// given.
+func (h *Handler) GetYearReview(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	year := time.Now().In(h.svc.Location(c.Request.Context(), userID)).Year()
+	if value := c.Query("year"); value != "" {
+		var err error
+		if year, err = strconv.Atoi(value); err != nil || year < 1900 || year > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a four-digit year"})
+			return
+		}
+	}
+
+	scope, ok := parseScope(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.svc.YearReview(c.Request.Context(), userID, year, scope)
+	if err != nil {
+		log.Printf("Error building year review: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build year review"})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,114 @@
+// Package i18n translates user-facing messages and formats amounts for a
+// language. Messages are keyed by their English text, so a message missing
+// from a catalog is shown in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	supported = []language.Tag{language.English}
+	catalogs  = map[language.Tag]map[string]string{}
+	matcher   language.Matcher
+)
+
+func init() {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		log.Fatalf("Failed to read message catalogs: %v", err)
+	}
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			log.Fatalf("Failed to read message catalog %s: %v", file.Name(), err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Fatalf("Invalid message catalog %s: %v", file.Name(), err)
+		}
+
+		tag := language.MustParse(strings.TrimSuffix(file.Name(), ".json"))
+		catalogs[tag] = messages
+		supported = append(supported, tag)
+	}
+	matcher = language.NewMatcher(supported)
+}
+
+// Match picks the supported language that best fits the preferred ones, given
+// as Accept-Language headers or locale names such as pl-PL.
+func Match(preferred ...string) language.Tag {
+	var tags []language.Tag
+	for _, value := range preferred {
+		parsed, _, err := language.ParseAcceptLanguage(value)
+		if err == nil {
+			tags = append(tags, parsed...)
+		}
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return language.English
+	}
+	return supported[index]
+}
+
+type Localizer struct {
+	tag      language.Tag
+	messages map[string]string
+	printer  *message.Printer
+}
+
+func New(tag language.Tag) *Localizer {
+	return &Localizer{tag: tag, messages: catalogs[tag], printer: message.NewPrinter(tag)}
+}
+
+func (l *Localizer) Tag() language.Tag {
+	return l.tag
+}
+
+// T translates a message and formats it with args like fmt.Sprintf.
+func (l *Localizer) T(key string, args ...interface{}) string {
+	format, ok := l.messages[key]
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Translated reports whether the catalog has the message, so text that is not
+// a known message is passed through untouched.
+func (l *Localizer) Translated(key string) (string, bool) {
+	format, ok := l.messages[key]
+	return format, ok
+}
+
+// Amount formats a money amount with the language's separators followed by
+// the ISO currency code, e.g. "1,234.50 USD" or "1 234,50 PLN".
+func (l *Localizer) Amount(amount float64, currency string) string {
+	formatted := l.printer.Sprint(number.Decimal(amount, number.Scale(2)))
+	if currency == "" {
+		return formatted
+	}
+	return formatted + " " + currency
+}
+
+// Number formats a number with the language's separators and the given
+// number of decimals.
+func (l *Localizer) Number(value float64, decimals int) string {
+	return l.printer.Sprint(number.Decimal(value, number.Scale(decimals)))
+}
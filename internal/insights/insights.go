@@ -0,0 +1,181 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var ErrNotFound = errors.New("insight not found")
+
+// Finding is one insight an analyzer reports. Key must stay the same on
+// every run while the finding holds.
+type Finding struct {
+	Key      string
+	Severity string
+	Title    string
+	Message  string
+	Data     map[string]interface{}
+}
+
+// Analyzer reports every finding of its type that currently holds for the
+// user.
+type Analyzer func(ctx context.Context, userID int, now time.Time) ([]Finding, error)
+
+type registration struct {
+	insightType string
+	analyzer    Analyzer
+}
+
+// Engine runs the registered analyzers and keeps their findings in the
+// insights table, where users dismiss or snooze them.
+type Engine struct {
+	db        *sql.DB
+	mu        sync.RWMutex
+	analyzers []registration
+}
+
+func NewEngine(db *sql.DB) *Engine {
+	return &Engine{db: db}
+}
+
+// Register adds an analyzer. Analyzers run in the order they are registered.
+func (e *Engine) Register(insightType string, analyzer Analyzer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.analyzers = append(e.analyzers, registration{insightType: insightType, analyzer: analyzer})
+}
+
+func (e *Engine) registrations() []registration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]registration(nil), e.analyzers...)
+}
+
+// Analyze runs every analyzer for the user. A failing analyzer keeps the
+// insights of its last run and does not stop the others.
+func (e *Engine) Analyze(ctx context.Context, userID int, now time.Time) error {
+	var failed error
+	for _, r := range e.registrations() {
+		findings, err := run(ctx, r.analyzer, userID, now)
+		if err == nil {
+			err = e.store(ctx, userID, r.insightType, findings)
+		}
+		if err != nil {
+			log.Printf("Error running %s insights for user %d: %v", r.insightType, userID, err)
+			failed = fmt.Errorf("%s: %w", r.insightType, err)
+		}
+	}
+	return failed
+}
+
+func run(ctx context.Context, analyzer Analyzer, userID int, now time.Time) (findings []Finding, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analyzer panicked: %v", r)
+		}
+	}()
+	return analyzer(ctx, userID, now)
+}
+
+// store saves the findings of one analyzer and removes its insights that no
+// longer hold. Dismissing and snoozing survive the update.
+func (e *Engine) store(ctx context.Context, userID int, insightType string, findings []Finding) error {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	keys := make([]string, 0, len(findings))
+	for _, f := range findings {
+		data := []byte("{}")
+		if f.Data != nil {
+			if data, err = json.Marshal(f.Data); err != nil {
+				return err
+			}
+		}
+
+		query := `INSERT INTO insights (user_id, type, key, severity, title, message, data, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+				  ON CONFLICT (user_id, type, key) DO UPDATE SET severity = EXCLUDED.severity, title = EXCLUDED.title,
+				  message = EXCLUDED.message, data = EXCLUDED.data, updated_at = NOW()`
+
+		if _, err := tx.ExecContext(ctx, query, userID, insightType, f.Key, f.Severity, f.Title, f.Message, data); err != nil {
+			return err
+		}
+		keys = append(keys, f.Key)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM insights WHERE user_id = $1 AND type = $2 AND NOT (key = ANY($3))`,
+		userID, insightType, pq.Array(keys)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const insightColumns = `id, user_id, type, key, severity, title, message, data, snoozed_until, created_at, updated_at`
+
+func scanInsight(row interface{ Scan(...interface{}) error }) (models.Insight, error) {
+	var i models.Insight
+	err := row.Scan(&i.ID, &i.UserID, &i.Type, &i.Key, &i.Severity, &i.Title, &i.Message, &i.Data,
+		&i.SnoozedUntil, &i.CreatedAt, &i.UpdatedAt)
+	return i, err
+}
+
+// List returns the user's insights that are neither dismissed nor snoozed
+// at now, warnings first.
+func (e *Engine) List(ctx context.Context, userID int, now time.Time) ([]models.Insight, error) {
+	query := `SELECT ` + insightColumns + ` FROM insights
+			  WHERE user_id = $1 AND dismissed_at IS NULL AND (snoozed_until IS NULL OR snoozed_until <= $2)
+			  ORDER BY severity = $3 DESC, created_at DESC, id DESC`
+
+	rows, err := e.db.QueryContext(ctx, query, userID, now, models.InsightSeverities.Warning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	insights := []models.Insight{}
+	for rows.Next() {
+		insight, err := scanInsight(rows)
+		if err != nil {
+			return nil, err
+		}
+		insights = append(insights, insight)
+	}
+	return insights, rows.Err()
+}
+
+// Dismiss hides an insight for as long as its analyzer keeps reporting it.
+func (e *Engine) Dismiss(ctx context.Context, userID, id int) error {
+	result, err := e.db.ExecContext(ctx, `UPDATE insights SET dismissed_at = NOW() WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Snooze hides an insight until the given time.
+func (e *Engine) Snooze(ctx context.Context, userID, id int, until time.Time) (models.Insight, error) {
+	query := `UPDATE insights SET snoozed_until = $3 WHERE id = $1 AND user_id = $2 AND dismissed_at IS NULL
+			  RETURNING ` + insightColumns
+
+	insight, err := scanInsight(e.db.QueryRowContext(ctx, query, id, userID, until))
+	if err == sql.ErrNoRows {
+		return models.Insight{}, ErrNotFound
+	}
+	return insight, err
+}
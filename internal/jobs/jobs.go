@@ -0,0 +1,504 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/errorreport"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrNotFound    = errors.New("job not found")
+	ErrUnknownType = errors.New("unknown job type")
+	ErrNotDead     = errors.New("only dead jobs can be retried")
+
+	errWorkerStopped = errors.New("worker stopped before the job finished")
+)
+
+// Handler runs a single job. The returned value is stored as the job result
+// and shown to the user when polling.
+type Handler func(ctx context.Context, job models.Job) (interface{}, error)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: models.JobSettings.DefaultMaxAttempts,
+		BaseDelay:   models.JobSettings.BaseRetryDelay,
+		MaxDelay:    models.JobSettings.MaxRetryDelay,
+	}
+}
+
+// Backoff doubles the delay after every failed attempt up to MaxDelay.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Queue is a PostgreSQL-backed job queue. Jobs survive restarts, are claimed
+// with SKIP LOCKED so several API instances can share the work, and are moved
+// to the dead status once their retry policy is exhausted.
+type Queue struct {
+	db           *sql.DB
+	mu           sync.RWMutex
+	handlers     map[string]registration
+	workers      int
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	wake         chan struct{}
+	onFinish     []func(models.Job)
+}
+
+func NewQueue(db *sql.DB) *Queue {
+	cfg := config.Get().Jobs
+	return &Queue{
+		db:           db,
+		handlers:     make(map[string]registration),
+		workers:      cfg.Workers,
+		pollInterval: cfg.PollInterval,
+		lockTimeout:  models.JobSettings.LockTimeout,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Ping checks that the queue table can be read.
+func (q *Queue) Ping(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, `SELECT 1 FROM jobs LIMIT 1`)
+	return err
+}
+
+func (q *Queue) Register(jobType string, handler Handler, policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = registration{handler: handler, policy: policy}
+}
+
+// OnFinish registers a callback invoked once a job completes or is moved to
+// the dead status.
+func (q *Queue) OnFinish(fn func(models.Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onFinish = append(q.onFinish, fn)
+}
+
+// ReportProgress stores intermediate state so clients polling the job see how
+// far it got.
+func (q *Queue) ReportProgress(ctx context.Context, jobID int, progress interface{}) error {
+	body, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	_, err = q.db.ExecContext(ctx, `UPDATE jobs SET progress = $2, updated_at = NOW() WHERE id = $1`, jobID, body)
+	return err
+}
+
+func (q *Queue) registration(jobType string) (registration, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	r, ok := q.handlers[jobType]
+	return r, ok
+}
+
+func (q *Queue) Enqueue(ctx context.Context, userID int, jobType string, payload interface{}) (models.Job, error) {
+	r, ok := q.registration(jobType)
+	if !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	var owner interface{}
+	if userID != 0 {
+		owner = userID
+	}
+
+	query := `INSERT INTO jobs (user_id, type, payload, status, max_attempts, trace_parent, run_at, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW(), NOW(), NOW())
+			  RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.QueryRowContext(ctx, query, owner, jobType, body, models.JobStatuses.Pending, r.policy.MaxAttempts,
+		tracing.TraceParent(ctx)))
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (q *Queue) Get(ctx context.Context, userID, id int) (models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanJob(q.db.QueryRowContext(ctx, query, id, userID))
+	if err == sql.ErrNoRows {
+		return models.Job{}, ErrNotFound
+	}
+	return job, err
+}
+
+func (q *Queue) List(ctx context.Context, userID int, status string, limit int) ([]models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs
+			  WHERE user_id = $1 AND ($2 = '' OR status = $2)
+			  ORDER BY created_at DESC LIMIT $3`
+
+	rows, err := q.db.QueryContext(ctx, query, userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Retry moves a dead job back to the queue with a fresh set of attempts.
+func (q *Queue) Retry(ctx context.Context, userID, id int) (models.Job, error) {
+	query := `UPDATE jobs SET status = $3, attempts = 0, run_at = NOW(), locked_until = NULL, updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2 AND status = $4
+			  RETURNING ` + jobColumns
+
+	job, err := scanJob(q.db.QueryRowContext(ctx, query, id, userID, models.JobStatuses.Pending, models.JobStatuses.Dead))
+	if err == sql.ErrNoRows {
+		if _, err := q.Get(ctx, userID, id); err != nil {
+			return models.Job{}, err
+		}
+		return models.Job{}, ErrNotDead
+	}
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Run starts the worker pool and blocks until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	if q.workers == 0 {
+		log.Println("Job workers disabled")
+		return
+	}
+	log.Printf("Starting %d job workers", q.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	stale := time.NewTicker(q.pollInterval)
+	defer stale.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-stale.C:
+			if err := q.expire(ctx); err != nil {
+				log.Printf("Error expiring stale jobs: %v", err)
+			}
+		case <-cleanup.C:
+			if err := q.prune(); err != nil {
+				log.Printf("Error pruning finished jobs: %v", err)
+			}
+		}
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, err := q.claim(ctx)
+			if err == sql.ErrNoRows {
+				break
+			}
+			if err != nil {
+				log.Printf("Error claiming job: %v", err)
+				break
+			}
+			q.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks the next runnable job. Running jobs whose lock expired belong to
+// a worker that died and are picked up again while they have attempts left;
+// expire moves the others to the dead status.
+func (q *Queue) claim(ctx context.Context) (models.Job, error) {
+	query := `UPDATE jobs SET status = $1, attempts = attempts + 1, started_at = NOW(), updated_at = NOW(),
+			  locked_until = NOW() + make_interval(secs => $2)
+			  WHERE id = (
+				  SELECT id FROM jobs
+				  WHERE (status = $3 AND run_at <= NOW()) OR (status = $1 AND locked_until < NOW() AND attempts < max_attempts)
+				  ORDER BY run_at
+				  LIMIT 1
+				  FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING ` + jobColumns
+
+	return scanJob(q.db.QueryRowContext(ctx, query, models.JobStatuses.Running, q.lockTimeout.Seconds(), models.JobStatuses.Pending))
+}
+
+// process runs a claimed job in a span that continues the trace of whoever
+// enqueued it.
+func (q *Queue) process(ctx context.Context, job models.Job) {
+	ctx, span := tracing.StartRemote(ctx, "job "+job.Type, trace.SpanKindConsumer, job.TraceParent)
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("job.id", job.ID),
+		attribute.String("job.type", job.Type),
+		attribute.Int("job.attempt", job.Attempts))
+
+	r, ok := q.registration(job.Type)
+	if !ok {
+		err := fmt.Errorf("%w: %s", ErrUnknownType, job.Type)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		q.fail(job, DefaultRetryPolicy(), err)
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, q.lockTimeout)
+	result, err := run(runCtx, r.handler, job)
+	cancel()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		q.fail(job, r.policy, err)
+		return
+	}
+
+	var body []byte
+	if result != nil {
+		if body, err = json.Marshal(result); err != nil {
+			q.fail(job, r.policy, err)
+			return
+		}
+	}
+
+	query := `UPDATE jobs SET status = $2, result = $3, last_error = NULL, locked_until = NULL,
+			  completed_at = NOW(), updated_at = NOW()
+			  WHERE id = $1`
+
+	if _, err := q.db.Exec(query, job.ID, models.JobStatuses.Completed, body); err != nil {
+		log.Printf("Error completing job %d: %v", job.ID, err)
+		return
+	}
+	q.finished(job.ID)
+}
+
+func run(ctx context.Context, handler Handler, job models.Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+			errorreport.Capture(errorreport.Report{
+				Level:     "fatal",
+				ErrorType: "panic",
+				Message:   err.Error(),
+				Stack:     errorreport.Callers(1),
+				Route:     "job " + job.Type,
+				UserID:    job.UserID,
+				TraceID:   tracing.TraceID(ctx),
+			})
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (q *Queue) fail(job models.Job, policy RetryPolicy, cause error) {
+	status := models.JobStatuses.Pending
+	if job.Attempts >= job.MaxAttempts {
+		status = models.JobStatuses.Dead
+		log.Printf("Job %d (%s) moved to dead letter after %d attempts: %v", job.ID, job.Type, job.Attempts, cause)
+	} else {
+		log.Printf("Job %d (%s) failed on attempt %d: %v", job.ID, job.Type, job.Attempts, cause)
+	}
+
+	query := `UPDATE jobs SET status = $2, last_error = $3, locked_until = NULL, updated_at = NOW(),
+			  run_at = NOW() + make_interval(secs => $4)
+			  WHERE id = $1`
+
+	if _, err := q.db.Exec(query, job.ID, status, cause.Error(), policy.Backoff(job.Attempts).Seconds()); err != nil {
+		log.Printf("Error recording job %d failure: %v", job.ID, err)
+		return
+	}
+	if status == models.JobStatuses.Dead {
+		q.finished(job.ID)
+	}
+}
+
+func (q *Queue) finished(jobID int) {
+	q.mu.RLock()
+	callbacks := make([]func(models.Job), len(q.onFinish))
+	copy(callbacks, q.onFinish)
+	q.mu.RUnlock()
+	if len(callbacks) == 0 {
+		return
+	}
+
+	job, err := scanJob(q.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, jobID))
+	if err != nil {
+		log.Printf("Error loading finished job %d: %v", jobID, err)
+		return
+	}
+	for _, fn := range callbacks {
+		fn(job)
+	}
+}
+
+// expire moves running jobs whose lock expired on their last attempt to the
+// dead status, so a job that keeps taking its worker down is not retried
+// forever.
+func (q *Queue) expire(ctx context.Context) error {
+	rows, err := q.db.QueryContext(ctx, `UPDATE jobs SET status = $2, last_error = $3, locked_until = NULL, updated_at = NOW()
+			  WHERE status = $1 AND locked_until < NOW() AND attempts >= max_attempts
+			  RETURNING id, type, attempts`,
+		models.JobStatuses.Running, models.JobStatuses.Dead, errWorkerStopped.Error())
+	if err != nil {
+		return err
+	}
+
+	var expired []int
+	for rows.Next() {
+		var id, attempts int
+		var jobType string
+		if err := rows.Scan(&id, &jobType, &attempts); err != nil {
+			rows.Close()
+			return err
+		}
+		log.Printf("Job %d (%s) moved to dead letter after %d attempts: %v", id, jobType, attempts, errWorkerStopped)
+		expired = append(expired, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range expired {
+		q.finished(id)
+	}
+	return nil
+}
+
+// prune deletes completed jobs and job files past the retention period.
+// Files still named in the payload of a pending or running job, such as an
+// import waiting for a worker, are kept until that job finishes.
+func (q *Queue) prune() error {
+	_, err := q.db.Exec(`DELETE FROM jobs WHERE status = $1 AND completed_at < NOW() - make_interval(secs => $2)`,
+		models.JobStatuses.Completed, models.JobSettings.Retention.Seconds())
+	if err != nil {
+		return err
+	}
+	_, err = q.db.Exec(`DELETE FROM job_files f WHERE f.created_at < NOW() - make_interval(secs => $1)
+			  AND NOT EXISTS (
+				  SELECT 1 FROM jobs j
+				  WHERE j.status IN ($2, $3) AND j.payload->>'file_id' = f.id::text
+			  )`, models.JobSettings.Retention.Seconds(), models.JobStatuses.Pending, models.JobStatuses.Running)
+	return err
+}
+
+// SaveFile stores an uploaded input or a generated output so it can be passed
+// between the HTTP request and the worker by ID.
+func (q *Queue) SaveFile(ctx context.Context, userID int, name, contentType string, data []byte) (int, error) {
+	var id int
+	query := `INSERT INTO job_files (user_id, name, content_type, data, created_at)
+			  VALUES ($1, $2, $3, $4, NOW()) RETURNING id`
+
+	err := q.db.QueryRowContext(ctx, query, userID, name, contentType, data).Scan(&id)
+	return id, err
+}
+
+func (q *Queue) File(ctx context.Context, userID, id int) (models.JobFile, error) {
+	var file models.JobFile
+	query := `SELECT id, user_id, name, content_type, data, created_at FROM job_files WHERE id = $1 AND user_id = $2`
+
+	err := q.db.QueryRowContext(ctx, query, id, userID).Scan(&file.ID, &file.UserID, &file.Name, &file.ContentType, &file.Data, &file.CreatedAt)
+	if err == sql.ErrNoRows {
+		return models.JobFile{}, ErrNotFound
+	}
+	return file, err
+}
+
+const jobColumns = `id, COALESCE(user_id, 0), type, payload, progress, result, status, attempts, max_attempts, last_error,
+			  run_at, started_at, completed_at, created_at, updated_at, COALESCE(trace_parent, '')`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload, progress, result []byte
+	err := row.Scan(&job.ID, &job.UserID, &job.Type, &payload, &progress, &result, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.RunAt, &job.StartedAt, &job.CompletedAt, &job.CreatedAt, &job.UpdatedAt, &job.TraceParent)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job.Payload = payload
+	if progress != nil {
+		job.Progress = progress
+	}
+	if result != nil {
+		job.Result = result
+	}
+	return job, nil
+}
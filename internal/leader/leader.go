@@ -0,0 +1,129 @@
+// Package leader elects one API instance among those sharing the database
+// to run the periodic schedulers, so that reminders, allowances, reports and
+// the like are not produced twice when several replicas are running.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// lockName keys the session-level advisory lock held by the leader.
+const lockName = "scheduler_leader"
+
+type task struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// Elector campaigns for leadership by taking a Postgres advisory lock on a
+// connection of its own. The lock is released when the leader stops or its
+// connection dies, and another instance takes over within
+// models.LeaderSettings.RetryInterval.
+type Elector struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	tasks []task
+}
+
+func New(db *sql.DB) *Elector {
+	return &Elector{db: db}
+}
+
+// Go registers a scheduler to run while this instance leads. run must return
+// once its context is cancelled; it is started again on every term.
+func (e *Elector) Go(name string, run func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tasks = append(e.tasks, task{name, run})
+}
+
+// Run campaigns until ctx is cancelled, running the registered schedulers
+// whenever this instance holds the lock.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(models.LeaderSettings.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.campaign(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error electing scheduler leader: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign tries to take the lock once and, when it succeeds, leads until
+// ctx is cancelled or the lock connection fails.
+func (e *Elector) campaign(ctx context.Context) error {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, lockName).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, lockName)
+
+	return e.lead(ctx, conn)
+}
+
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn) error {
+	termCtx, cancel := context.WithCancel(ctx)
+
+	e.mu.Lock()
+	tasks := e.tasks
+	e.mu.Unlock()
+
+	names := make([]string, len(tasks))
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		names[i] = t.name
+		wg.Add(1)
+		go func(t task) {
+			defer wg.Done()
+			t.run(termCtx)
+		}(t)
+	}
+	log.Printf("Elected scheduler leader, running %s", strings.Join(names, ", "))
+
+	// Stop the schedulers before giving the lock up, so that the next
+	// leader never runs them alongside this one for longer than a heartbeat.
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	heartbeat := time.NewTicker(models.LeaderSettings.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stepping down as scheduler leader")
+			return nil
+		case <-heartbeat.C:
+			if _, err := conn.ExecContext(ctx, `SELECT 1`); err != nil {
+				return fmt.Errorf("lost the leader lock, stopping schedulers: %w", err)
+			}
+		}
+	}
+}
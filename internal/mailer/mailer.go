@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+	SendAttachment(to, subject, body string, attachment Attachment) error
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+func NewFromConfig(cfg config.SMTPConfig) Mailer {
+	if cfg.Host == "" {
+		return LogMailer{}
+	}
+
+	return &SMTPMailer{
+		addr:     cfg.Host + ":" + cfg.Port,
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+	}
+}
+
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	return m.send(to, strings.Join(append(m.headers(to, subject),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	), "\r\n"))
+}
+
+// SendAttachment sends body as text with one file attached.
+func (m *SMTPMailer) SendAttachment(to, subject, body string, attachment Attachment) error {
+	if strings.ContainsAny(to+subject+attachment.Name+attachment.ContentType, "\r\n\"") {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	boundary := fmt.Sprintf("pft-%d", time.Now().UnixNano())
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	lines = append(lines, encoded)
+
+	return m.send(to, strings.Join(append(m.headers(to, subject),
+		"Content-Type: multipart/mixed; boundary=\""+boundary+"\"",
+		"",
+		"--"+boundary,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+		"--"+boundary,
+		"Content-Type: "+attachment.ContentType,
+		"Content-Transfer-Encoding: base64",
+		"Content-Disposition: attachment; filename=\""+attachment.Name+"\"",
+		"",
+		strings.Join(lines, "\r\n"),
+		"--"+boundary+"--",
+	), "\r\n"))
+}
+
+func (m *SMTPMailer) headers(to, subject string) []string {
+	return []string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+	}
+}
+
+func (m *SMTPMailer) send(to, message string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(message))
+}
+
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("SMTP not configured, email to %s not sent: %s\n%s", to, subject, body)
+	return nil
+}
+
+func (LogMailer) SendAttachment(to, subject, body string, attachment Attachment) error {
+	log.Printf("SMTP not configured, email to %s not sent: %s (%s, %d bytes attached)\n%s", to, subject, attachment.Name, len(attachment.Data), body)
+	return nil
+}
@@ -0,0 +1,95 @@
+package models
+
+// CatalogIcon is an icon clients are expected to ship. Group only orders the
+// icon picker.
+type CatalogIcon struct {
+	Name  string `json:"name"`
+	Group string `json:"group"`
+}
+
+// CatalogColor is a palette color. Foreground is the icon and text color to
+// draw on it.
+type CatalogColor struct {
+	Name       string `json:"name"`
+	Hex        string `json:"hex"`
+	Foreground string `json:"foreground"`
+}
+
+var IconCatalog = []CatalogIcon{
+	{"wallet", "finance"},
+	{"bank", "finance"},
+	{"credit-card", "finance"},
+	{"piggy-bank", "finance"},
+	{"cash", "finance"},
+	{"chart", "finance"},
+	{"receipt", "finance"},
+	{"briefcase", "work"},
+	{"laptop", "work"},
+	{"gift", "work"},
+	{"home", "home"},
+	{"bolt", "home"},
+	{"droplet", "home"},
+	{"flame", "home"},
+	{"wifi", "home"},
+	{"phone", "home"},
+	{"wrench", "home"},
+	{"sofa", "home"},
+	{"cart", "food"},
+	{"utensils", "food"},
+	{"coffee", "food"},
+	{"pizza", "food"},
+	{"car", "transport"},
+	{"fuel", "transport"},
+	{"bus", "transport"},
+	{"train", "transport"},
+	{"bike", "transport"},
+	{"plane", "transport"},
+	{"parking", "transport"},
+	{"shopping-bag", "shopping"},
+	{"shirt", "shopping"},
+	{"tag", "shopping"},
+	{"heart", "health"},
+	{"pill", "health"},
+	{"stethoscope", "health"},
+	{"dumbbell", "health"},
+	{"film", "leisure"},
+	{"music", "leisure"},
+	{"gamepad", "leisure"},
+	{"book", "leisure"},
+	{"ticket", "leisure"},
+	{"umbrella", "leisure"},
+	{"graduation-cap", "family"},
+	{"baby", "family"},
+	{"paw", "family"},
+	{"users", "family"},
+	{"shield", "other"},
+	{"globe", "other"},
+	{"star", "other"},
+	{"folder", "other"},
+}
+
+// ColorCatalog is the palette offered to users. Every color leaves enough
+// contrast for its foreground; Foreground is filled in when served.
+var ColorCatalog = []CatalogColor{
+	{Name: "red", Hex: "#d63031"},
+	{Name: "coral", Hex: "#ff6b6b"},
+	{Name: "orange", Hex: "#e17055"},
+	{Name: "amber", Hex: "#fdcb6e"},
+	{Name: "yellow", Hex: "#f9ca24"},
+	{Name: "lime", Hex: "#badc58"},
+	{Name: "green", Hex: "#00b894"},
+	{Name: "emerald", Hex: "#1e8449"},
+	{Name: "teal", Hex: "#4ecdc4"},
+	{Name: "cyan", Hex: "#00cec9"},
+	{Name: "sky", Hex: "#45b7d1"},
+	{Name: "blue", Hex: "#0767b5"},
+	{Name: "navy", Hex: "#2c3e50"},
+	{Name: "indigo", Hex: "#6c5ce7"},
+	{Name: "violet", Hex: "#a29bfe"},
+	{Name: "purple", Hex: "#8e44ad"},
+	{Name: "pink", Hex: "#fd79a8"},
+	{Name: "rose", Hex: "#c2185b"},
+	{Name: "brown", Hex: "#8d6e63"},
+	{Name: "slate", Hex: "#636e72"},
+	{Name: "gray", Hex: "#b2bec3"},
+}
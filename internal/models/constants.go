@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type TrendDirectionTypes struct {
 	Up     string
 	Down   string
@@ -51,11 +53,13 @@ var HistoricalDays = HistoricalPeriods{
 type PaginationDefaults struct {
 	DefaultLimit  int
 	DefaultOffset int
+	MaxLimit      int
 }
 
 var Pagination = PaginationDefaults{
 	DefaultLimit:  20,
 	DefaultOffset: 0,
+	MaxLimit:      200,
 }
 
 type PredictionFactors struct {
@@ -65,3 +69,1191 @@ type PredictionFactors struct {
 var PredictionSettings = PredictionFactors{
 	ConservativeEstimate: 0.8,
 }
+
+type ClassifierDefaults struct {
+	TrainingLimit  int
+	MaxSuggestions int
+}
+
+var ClassifierSettings = ClassifierDefaults{
+	TrainingLimit:  5000,
+	MaxSuggestions: 3,
+}
+
+type WidgetLimits struct {
+	DefaultRefreshInterval int
+	MinRefreshInterval     int
+	MaxRefreshInterval     int
+}
+
+var WidgetSettings = WidgetLimits{
+	DefaultRefreshInterval: 300,
+	MinRefreshInterval:     60,
+	MaxRefreshInterval:     86400,
+}
+
+// ReceiptLimits bound receipt scans. MaxFormOverheadBytes is what the
+// multipart form may add to the image, such as headers and account_id.
+type ReceiptLimits struct {
+	MaxImageBytes        int64
+	MaxFormOverheadBytes int64
+}
+
+var ReceiptSettings = ReceiptLimits{
+	MaxImageBytes:        10 << 20,
+	MaxFormOverheadBytes: 64 << 10,
+}
+
+type DraftStatusTypes struct {
+	Pending  string
+	Approved string
+	Rejected string
+}
+
+var DraftStatuses = DraftStatusTypes{
+	Pending:  "pending",
+	Approved: "approved",
+	Rejected: "rejected",
+}
+
+type HouseholdRoleTypes struct {
+	Owner   string
+	Editor  string
+	Viewer  string
+	Limited string
+}
+
+var HouseholdRoles = HouseholdRoleTypes{
+	Owner:   "owner",
+	Editor:  "editor",
+	Viewer:  "viewer",
+	Limited: "limited",
+}
+
+type ReimbursementStatusTypes struct {
+	Pending    string
+	Submitted  string
+	Reimbursed string
+}
+
+var ReimbursementStatuses = ReimbursementStatusTypes{
+	Pending:    "pending",
+	Submitted:  "submitted",
+	Reimbursed: "reimbursed",
+}
+
+type SettlementDirectionTypes struct {
+	Received string
+	Paid     string
+}
+
+var SettlementDirections = SettlementDirectionTypes{
+	Received: "received",
+	Paid:     "paid",
+}
+
+type UserLinkStatusTypes struct {
+	Pending  string
+	Accepted string
+}
+
+var UserLinkStatuses = UserLinkStatusTypes{
+	Pending:  "pending",
+	Accepted: "accepted",
+}
+
+type IOUStatusTypes struct {
+	Pending   string
+	Confirmed string
+	Disputed  string
+	Settled   string
+}
+
+var IOUStatuses = IOUStatusTypes{
+	Pending:   "pending",
+	Confirmed: "confirmed",
+	Disputed:  "disputed",
+	Settled:   "settled",
+}
+
+type IOUDirectionTypes struct {
+	Lent     string
+	Borrowed string
+}
+
+var IOUDirections = IOUDirectionTypes{
+	Lent:     "lent",
+	Borrowed: "borrowed",
+}
+
+type ImportStatusTypes struct {
+	Staged    string
+	Committed string
+}
+
+var ImportStatuses = ImportStatusTypes{
+	Staged:    "staged",
+	Committed: "committed",
+}
+
+type ImportSignConventionTypes struct {
+	Signed      string
+	Inverted    string
+	DebitCredit string
+}
+
+// ImportSignConventions say how a bank export marks money leaving the
+// account: a negative amount, a positive one, or a separate debit column.
+var ImportSignConventions = ImportSignConventionTypes{
+	Signed:      "signed",
+	Inverted:    "inverted",
+	DebitCredit: "debit_credit",
+}
+
+type AllowanceFrequencyTypes struct {
+	Weekly  string
+	Monthly string
+}
+
+var AllowanceFrequencies = AllowanceFrequencyTypes{
+	Weekly:  "weekly",
+	Monthly: "monthly",
+}
+
+type RecurrenceFrequencyTypes struct {
+	Weekly   string
+	Biweekly string
+	Monthly  string
+	Yearly   string
+}
+
+var RecurrenceFrequencies = RecurrenceFrequencyTypes{
+	Weekly:   "weekly",
+	Biweekly: "biweekly",
+	Monthly:  "monthly",
+	Yearly:   "yearly",
+}
+
+type UpcomingSourceTypes struct {
+	Recurring string
+	Bill      string
+	Transfer  string
+}
+
+var UpcomingSources = UpcomingSourceTypes{
+	Recurring: "recurring",
+	Bill:      "bill",
+	Transfer:  "transfer",
+}
+
+type UpcomingLimits struct {
+	DefaultDays int
+	MaxDays     int
+}
+
+var UpcomingSettings = UpcomingLimits{
+	DefaultDays: 30,
+	MaxDays:     366,
+}
+
+type AllowanceLimits struct {
+	CheckInterval time.Duration
+}
+
+var AllowanceSettings = AllowanceLimits{
+	CheckInterval: time.Hour,
+}
+
+type VATLimits struct {
+	// Tolerance absorbs cent rounding when lines are checked against totals.
+	Tolerance float64
+}
+
+var VATSettings = VATLimits{
+	Tolerance: 0.005,
+}
+
+type IngestionLimits struct {
+	MaxEmailBytes int64
+}
+
+var IngestionSettings = IngestionLimits{
+	MaxEmailBytes: 5 << 20,
+}
+
+type AuditActionTypes struct {
+	BudgetCapOverride string
+	Recategorize      string
+	CategoryMerge     string
+	AdvisorGrant      string
+	AdvisorRevoke     string
+	AdvisorAccess     string
+	BillingPlan       string
+}
+
+var AuditActions = AuditActionTypes{
+	BudgetCapOverride: "budget.cap_override",
+	Recategorize:      "transactions.recategorize",
+	CategoryMerge:     "category.merge",
+	AdvisorGrant:      "advisor.grant",
+	AdvisorRevoke:     "advisor.revoke",
+	AdvisorAccess:     "advisor.access",
+	BillingPlan:       "billing.plan",
+}
+
+type BudgetAlertThresholds struct {
+	WarningRatio float64
+}
+
+var BudgetAlertSettings = BudgetAlertThresholds{
+	WarningRatio: 0.8,
+}
+
+type TelegramLimits struct {
+	LinkCodeTTLMinutes int
+}
+
+var TelegramSettings = TelegramLimits{
+	LinkCodeTTLMinutes: 15,
+}
+
+type TransactionAlertThresholds struct {
+	LargeAmount float64
+}
+
+var TransactionAlertSettings = TransactionAlertThresholds{
+	LargeAmount: 1000,
+}
+
+type SyncStatusTypes struct {
+	Created  string
+	Updated  string
+	Conflict string
+	Error    string
+}
+
+var SyncStatuses = SyncStatusTypes{
+	Created:  "created",
+	Updated:  "updated",
+	Conflict: "conflict",
+	Error:    "error",
+}
+
+type SyncEntityTypes struct {
+	Account     string
+	Category    string
+	Transaction string
+}
+
+var SyncEntities = SyncEntityTypes{
+	Account:     "account",
+	Category:    "category",
+	Transaction: "transaction",
+}
+
+type APITokenScopeTypes struct {
+	Read      string
+	ReadWrite string
+}
+
+var APITokenScopes = APITokenScopeTypes{
+	Read:      "read",
+	ReadWrite: "read_write",
+}
+
+type APITokenLimits struct {
+	Prefix           string
+	DisplayPrefixLen int
+	MaxExpiryDays    int
+}
+
+var APITokenSettings = APITokenLimits{
+	Prefix:           "pft_",
+	DisplayPrefixLen: 12,
+	MaxExpiryDays:    365,
+}
+
+// AdvisorLimits bound advisor grants. Delegated tokens live for TokenTTL,
+// or until the grant expires if that is sooner.
+type AdvisorLimits struct {
+	MaxGrants     int
+	MaxExpiryDays int
+	TokenTTL      time.Duration
+}
+
+var AdvisorSettings = AdvisorLimits{
+	MaxGrants:     20,
+	MaxExpiryDays: 365,
+	TokenTTL:      time.Hour,
+}
+
+type OAuthLimits struct {
+	StateTTL    time.Duration
+	NonceCookie string
+}
+
+var OAuthSettings = OAuthLimits{
+	StateTTL:    10 * time.Minute,
+	NonceCookie: "oauth_nonce",
+}
+
+type SessionLimits struct {
+	RefreshTokenPrefix string
+	RefreshTokenTTL    time.Duration
+	AccessTokenTTL     time.Duration
+	LastSeenInterval   time.Duration
+}
+
+var SessionSettings = SessionLimits{
+	RefreshTokenPrefix: "pfr_",
+	RefreshTokenTTL:    30 * 24 * time.Hour,
+	AccessTokenTTL:     24 * time.Hour,
+	LastSeenInterval:   time.Minute,
+}
+
+type ProfileLimits struct {
+	EmailChangeTTL    time.Duration
+	AvatarMaxBytes    int64
+	AvatarMaxPixels   int
+	AvatarSizes       []int
+	AvatarJPEGQuality int
+}
+
+var ProfileSettings = ProfileLimits{
+	EmailChangeTTL:    24 * time.Hour,
+	AvatarMaxBytes:    5 << 20,
+	AvatarMaxPixels:   4096 * 4096,
+	AvatarSizes:       []int{64, 128, 256},
+	AvatarJPEGQuality: 85,
+}
+
+type DemoLimits struct {
+	TTL             time.Duration
+	Months          int
+	CleanupInterval time.Duration
+	EmailDomain     string
+}
+
+var DemoSettings = DemoLimits{
+	TTL:             24 * time.Hour,
+	Months:          12,
+	CleanupInterval: time.Hour,
+	EmailDomain:     "demo.invalid",
+}
+
+// WorkspaceRoleTypes are what members may do in a workspace: viewers read
+// the books, members also change them, admins also manage members and
+// settings, and the owner can also delete the workspace.
+type WorkspaceRoleTypes struct {
+	Owner  string
+	Admin  string
+	Member string
+	Viewer string
+}
+
+var WorkspaceRoles = WorkspaceRoleTypes{
+	Owner:  "owner",
+	Admin:  "admin",
+	Member: "member",
+	Viewer: "viewer",
+}
+
+type WorkspaceLimits struct {
+	Header        string
+	MaxWorkspaces int
+	MaxMembers    int
+	EmailDomain   string
+}
+
+var WorkspaceSettings = WorkspaceLimits{
+	Header:        "X-Workspace-ID",
+	MaxWorkspaces: 10,
+	MaxMembers:    50,
+	EmailDomain:   "workspace.invalid",
+}
+
+// QuotaResourceTypes name the limits a plan can set.
+type QuotaResourceTypes struct {
+	Accounts             string
+	TransactionsPerMonth string
+	StorageBytes         string
+	Webhooks             string
+}
+
+var QuotaResources = QuotaResourceTypes{
+	Accounts:             "accounts",
+	TransactionsPerMonth: "transactions_per_month",
+	StorageBytes:         "storage_bytes",
+	Webhooks:             "webhooks",
+}
+
+// BillingStatusTypes are the Stripe subscription statuses the billing
+// webhook acts on. Active and trialing subscriptions grant their plan,
+// past_due and unpaid ones keep it for the grace period, and canceled ones
+// end it.
+type BillingStatusTypes struct {
+	Active            string
+	Trialing          string
+	PastDue           string
+	Unpaid            string
+	Canceled          string
+	Incomplete        string
+	IncompleteExpired string
+}
+
+var BillingStatuses = BillingStatusTypes{
+	Active:            "active",
+	Trialing:          "trialing",
+	PastDue:           "past_due",
+	Unpaid:            "unpaid",
+	Canceled:          "canceled",
+	Incomplete:        "incomplete",
+	IncompleteExpired: "incomplete_expired",
+}
+
+// BillingLimits are the defaults of Stripe billing. Webhook events signed
+// more than WebhookTolerance ago are rejected as replays.
+type BillingLimits struct {
+	GracePeriod      time.Duration
+	CheckInterval    time.Duration
+	WebhookTolerance time.Duration
+	MaxWebhookBytes  int64
+}
+
+var BillingSettings = BillingLimits{
+	GracePeriod:      7 * 24 * time.Hour,
+	CheckInterval:    time.Hour,
+	WebhookTolerance: 5 * time.Minute,
+	MaxWebhookBytes:  1 << 20,
+}
+
+// ErrorReportLimits bound how error reports are sent. Reports beyond
+// QueueSize waiting to be sent are dropped.
+type ErrorReportLimits struct {
+	RequestIDHeader string
+	QueueSize       int
+	Timeout         time.Duration
+}
+
+var ErrorReportSettings = ErrorReportLimits{
+	RequestIDHeader: "X-Request-ID",
+	QueueSize:       100,
+	Timeout:         10 * time.Second,
+}
+
+// HTTPLogLimits bound request and response bodies logged with
+// HTTP_LOG_BODIES; longer bodies are cut.
+type HTTPLogLimits struct {
+	MaxBodyBytes int
+}
+
+var HTTPLogSettings = HTTPLogLimits{
+	MaxBodyBytes: 4 << 10,
+}
+
+type BackupKindTypes struct {
+	Manual     string
+	Scheduled  string
+	PreRestore string
+}
+
+var BackupTriggers = BackupKindTypes{
+	Manual:     "manual",
+	Scheduled:  "scheduled",
+	PreRestore: "pre-restore",
+}
+
+type BackupLimits struct {
+	Dir           string
+	Interval      time.Duration
+	Retention     int
+	CheckInterval time.Duration
+}
+
+var BackupSettings = BackupLimits{
+	Dir:           "backups",
+	Interval:      24 * time.Hour,
+	Retention:     7,
+	CheckInterval: 10 * time.Minute,
+}
+
+type LoginSecurityLimits struct {
+	MaxFailedAttempts int
+	FailureWindow     time.Duration
+	BaseLockout       time.Duration
+	MaxLockout        time.Duration
+	LockoutDecay      time.Duration
+	IPWindow          time.Duration
+	IPMaxFailures     int
+	PruneInterval     time.Duration
+}
+
+var LoginSecuritySettings = LoginSecurityLimits{
+	MaxFailedAttempts: 5,
+	FailureWindow:     15 * time.Minute,
+	BaseLockout:       time.Minute,
+	MaxLockout:        24 * time.Hour,
+	LockoutDecay:      24 * time.Hour,
+	IPWindow:          15 * time.Minute,
+	IPMaxFailures:     20,
+	PruneInterval:     time.Hour,
+}
+
+type CSRFLimits struct {
+	CookieName        string
+	HeaderName        string
+	SessionCookieName string
+	CookieTTL         time.Duration
+}
+
+var CSRFSettings = CSRFLimits{
+	CookieName:        "pft_csrf",
+	HeaderName:        "X-CSRF-Token",
+	SessionCookieName: "pft_session",
+	CookieTTL:         12 * time.Hour,
+}
+
+type CacheLimits struct {
+	AnalyticsTTL     time.Duration
+	MemoryMaxEntries int
+}
+
+var CacheSettings = CacheLimits{
+	AnalyticsTTL:     5 * time.Minute,
+	MemoryMaxEntries: 10000,
+}
+
+type RateLimits struct {
+	Window       time.Duration
+	AuthRequests int
+	APIRequests  int
+}
+
+var RateLimitSettings = RateLimits{
+	Window:       time.Minute,
+	AuthRequests: 20,
+	APIRequests:  300,
+}
+
+type IdempotencyLimits struct {
+	Header       string
+	TTL          time.Duration
+	LockTTL      time.Duration
+	MaxBodyBytes int64
+}
+
+var IdempotencySettings = IdempotencyLimits{
+	Header:       "Idempotency-Key",
+	TTL:          24 * time.Hour,
+	LockTTL:      30 * time.Second,
+	MaxBodyBytes: 1 << 20,
+}
+
+type JobStatusTypes struct {
+	Pending   string
+	Running   string
+	Completed string
+	Dead      string
+}
+
+var JobStatuses = JobStatusTypes{
+	Pending:   "pending",
+	Running:   "running",
+	Completed: "completed",
+	Dead:      "dead",
+}
+
+type JobKindTypes struct {
+	WebhookDelivery   string
+	Import            string
+	Export            string
+	Report            string
+	AutomationWebhook string
+}
+
+var JobTypes = JobKindTypes{
+	WebhookDelivery:   "webhook_delivery",
+	Import:            "import",
+	Export:            "export",
+	Report:            "report",
+	AutomationWebhook: "automation_webhook",
+}
+
+type JobLimits struct {
+	Workers            int
+	PollInterval       time.Duration
+	LockTimeout        time.Duration
+	DefaultMaxAttempts int
+	BaseRetryDelay     time.Duration
+	MaxRetryDelay      time.Duration
+	Retention          time.Duration
+}
+
+var JobSettings = JobLimits{
+	Workers:            4,
+	PollInterval:       2 * time.Second,
+	LockTimeout:        5 * time.Minute,
+	DefaultMaxAttempts: 5,
+	BaseRetryDelay:     30 * time.Second,
+	MaxRetryDelay:      time.Hour,
+	Retention:          7 * 24 * time.Hour,
+}
+
+// OutboxLimits time event dispatch. A claimed event is left to its instance
+// for ClaimTTL, which has to outlast the handlers of a whole batch.
+type OutboxLimits struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	ClaimTTL       time.Duration
+	MaxAttempts    int
+	BaseRetryDelay time.Duration
+	MaxRetryDelay  time.Duration
+	Retention      time.Duration
+}
+
+var OutboxSettings = OutboxLimits{
+	PollInterval:   time.Second,
+	BatchSize:      100,
+	ClaimTTL:       5 * time.Minute,
+	MaxAttempts:    10,
+	BaseRetryDelay: 5 * time.Second,
+	MaxRetryDelay:  10 * time.Minute,
+	Retention:      7 * 24 * time.Hour,
+}
+
+// FanoutLimits shape the Postgres notifications that carry stream events to
+// every API instance. Notification payloads are capped below Postgres's
+// 8000 bytes; larger events are sent without their data and read back from
+// the outbox.
+type FanoutLimits struct {
+	Channel         string
+	MaxPayloadBytes int
+	MinReconnect    time.Duration
+	MaxReconnect    time.Duration
+	PingInterval    time.Duration
+}
+
+var FanoutSettings = FanoutLimits{
+	Channel:         "stream_events",
+	MaxPayloadBytes: 7900,
+	MinReconnect:    10 * time.Second,
+	MaxReconnect:    time.Minute,
+	PingInterval:    90 * time.Second,
+}
+
+// LeaderLimits time scheduler leader election: the leader checks the
+// connection holding its lock every Heartbeat, and the other instances try
+// to take over every RetryInterval.
+type LeaderLimits struct {
+	Heartbeat     time.Duration
+	RetryInterval time.Duration
+}
+
+var LeaderSettings = LeaderLimits{
+	Heartbeat:     10 * time.Second,
+	RetryInterval: 15 * time.Second,
+}
+
+type AccountKindTypes struct {
+	Checking   string
+	Savings    string
+	CreditCard string
+	Cash       string
+	Investment string
+	Loan       string
+}
+
+var AccountTypes = AccountKindTypes{
+	Checking:   "checking",
+	Savings:    "savings",
+	CreditCard: "credit_card",
+	Cash:       "cash",
+	Investment: "investment",
+	Loan:       "loan",
+}
+
+type ScopeTypes struct {
+	Personal string
+	Business string
+}
+
+var Scopes = ScopeTypes{
+	Personal: "personal",
+	Business: "business",
+}
+
+type CreditCardLimits struct {
+	MinimumPaymentRatio   float64
+	MinimumPaymentFloor   float64
+	ReminderDaysBefore    int
+	ReminderCheckInterval time.Duration
+	UtilizationAlertRatio float64
+}
+
+var CreditCardSettings = CreditCardLimits{
+	MinimumPaymentRatio:   0.03,
+	MinimumPaymentFloor:   30,
+	ReminderDaysBefore:    3,
+	ReminderCheckInterval: time.Hour,
+	UtilizationAlertRatio: 0.8,
+}
+
+type UserSettingsDefaults struct {
+	BaseCurrency        string
+	Locale              string
+	FirstDayOfWeek      int
+	FiscalMonthStartDay int
+	DateFormat          string
+	PayPeriod           string
+	Timezone            string
+	PeriodHistory       int
+	MaxPeriodHistory    int
+}
+
+var SettingsDefaults = UserSettingsDefaults{
+	BaseCurrency:        "USD",
+	Locale:              "en-US",
+	FirstDayOfWeek:      1,
+	FiscalMonthStartDay: 1,
+	DateFormat:          "YYYY-MM-DD",
+	PayPeriod:           "monthly",
+	Timezone:            "UTC",
+	PeriodHistory:       6,
+	MaxPeriodHistory:    36,
+}
+
+type PayPeriodKinds struct {
+	Monthly  string
+	Weekly   string
+	Biweekly string
+}
+
+var PayPeriods = PayPeriodKinds{
+	Monthly:  "monthly",
+	Weekly:   "weekly",
+	Biweekly: "biweekly",
+}
+
+// DateFormats maps the date formats users can choose to Go layouts.
+var DateFormats = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"DD.MM.YYYY": "02.01.2006",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+}
+
+type ImportLimits struct {
+	MaxFileBytes      int64
+	MaxReportedErrors int
+	ProgressEvery     int
+	DefaultCategory   string
+}
+
+var ImportSettings = ImportLimits{
+	MaxFileBytes:      20 << 20,
+	MaxReportedErrors: 100,
+	ProgressEvery:     100,
+	DefaultCategory:   "Other",
+}
+
+type TimeoutLimits struct {
+	Request time.Duration
+}
+
+var TimeoutSettings = TimeoutLimits{
+	Request: 15 * time.Second,
+}
+
+// AppVersion is reported by the health endpoints. Release builds set it with
+// -ldflags "-X personal-finance-tracker/internal/models.AppVersion=...".
+var AppVersion = "1.0.0"
+
+type HealthStatusTypes struct {
+	Up       string
+	Down     string
+	Disabled string
+}
+
+var HealthStatuses = HealthStatusTypes{
+	Up:       "up",
+	Down:     "down",
+	Disabled: "disabled",
+}
+
+type HealthLimits struct {
+	ProbeTimeout time.Duration
+}
+
+var HealthSettings = HealthLimits{
+	ProbeTimeout: 2 * time.Second,
+}
+
+type GranularityTypes struct {
+	Day   string
+	Week  string
+	Month string
+}
+
+var Granularities = GranularityTypes{
+	Day:   "day",
+	Week:  "week",
+	Month: "month",
+}
+
+// BalanceHistoryLimits bounds balance history charts. DefaultSpans is the
+// number of points returned per granularity when no start date is given.
+type BalanceHistoryLimits struct {
+	DefaultSpans map[string]int
+	MaxPoints    int
+}
+
+var BalanceHistorySettings = BalanceHistoryLimits{
+	DefaultSpans: map[string]int{
+		Granularities.Day:   90,
+		Granularities.Week:  26,
+		Granularities.Month: 12,
+	},
+	MaxPoints: 1000,
+}
+
+// TransactionTypeNames lists transaction types. Income and expense amounts
+// are positive; opening balance and adjustment entries are signed, have no
+// category and are left out of income and spending figures.
+type TransactionTypeNames struct {
+	Income     string
+	Expense    string
+	Opening    string
+	Adjustment string
+}
+
+var TransactionTypes = TransactionTypeNames{
+	Income:     "income",
+	Expense:    "expense",
+	Opening:    "opening",
+	Adjustment: "adjustment",
+}
+
+type RecategorizeLimits struct {
+	SampleSize int
+}
+
+var RecategorizeSettings = RecategorizeLimits{
+	SampleSize: 20,
+}
+
+// AppearanceLimits govern custom category colors. Icons are drawn in
+// LightForeground or DarkForeground, whichever contrasts more with the
+// color, and that contrast must reach MinContrast (WCAG AA for text).
+type AppearanceLimits struct {
+	MinContrast     float64
+	LightForeground string
+	DarkForeground  string
+}
+
+var AppearanceSettings = AppearanceLimits{
+	MinContrast:     4.5,
+	LightForeground: "#ffffff",
+	DarkForeground:  "#1f2937",
+}
+
+type DashboardWidgetTypeNames struct {
+	Summary       string
+	CategoryDonut string
+	TrendLine     string
+	BudgetBars    string
+	NetWorth      string
+}
+
+var DashboardWidgetTypes = DashboardWidgetTypeNames{
+	Summary:       "summary",
+	CategoryDonut: "category_donut",
+	TrendLine:     "trend_line",
+	BudgetBars:    "budget_bars",
+	NetWorth:      "net_worth",
+}
+
+type DashboardLimits struct {
+	MaxWidgets    int
+	DefaultSlices int
+	MaxSlices     int
+}
+
+var DashboardSettings = DashboardLimits{
+	MaxWidgets:    20,
+	DefaultSlices: 6,
+	MaxSlices:     12,
+}
+
+type AnalyticsBatchLimits struct {
+	MaxRequests int
+}
+
+var AnalyticsBatchSettings = AnalyticsBatchLimits{
+	MaxRequests: 10,
+}
+
+type ReportFormatTypes struct {
+	CSV string
+	PDF string
+}
+
+var ReportFormats = ReportFormatTypes{
+	CSV: "csv",
+	PDF: "pdf",
+}
+
+type ReportDeliveryTypes struct {
+	Email   string
+	Webhook string
+}
+
+var ReportDeliveries = ReportDeliveryTypes{
+	Email:   "email",
+	Webhook: "webhook",
+}
+
+type ReportScheduleLimits struct {
+	CheckInterval  time.Duration
+	MaxSchedules   int
+	MaxRunsPerHour int
+	WebhookTimeout time.Duration
+}
+
+var ReportScheduleSettings = ReportScheduleLimits{
+	CheckInterval:  time.Minute,
+	MaxSchedules:   20,
+	MaxRunsPerHour: 1,
+	WebhookTimeout: 30 * time.Second,
+}
+
+type YearReviewLimits struct {
+	TopCategories int
+	TopMerchants  int
+}
+
+var YearReviewSettings = YearReviewLimits{
+	TopCategories: 5,
+	TopMerchants:  5,
+}
+
+// FinancialHealthLimits sets the window the health score looks at and the
+// values that earn a metric full marks.
+type FinancialHealthLimits struct {
+	Months                int
+	TargetSavingsRate     float64
+	TargetExpenseRatio    float64
+	TargetEmergencyMonths float64
+	MaxDebtToIncome       float64
+	TrendThreshold        float64
+}
+
+var FinancialHealthSettings = FinancialHealthLimits{
+	Months:                3,
+	TargetSavingsRate:     20,
+	TargetExpenseRatio:    50,
+	TargetEmergencyMonths: 6,
+	MaxDebtToIncome:       50,
+	TrendThreshold:        5,
+}
+
+type EmergencyFundLimits struct {
+	Months                 int
+	DefaultThresholdMonths float64
+	CheckInterval          time.Duration
+}
+
+var EmergencyFundSettings = EmergencyFundLimits{
+	Months:                 6,
+	DefaultThresholdMonths: 3,
+	CheckInterval:          time.Hour,
+}
+
+type HealthRatingTypes struct {
+	Excellent string
+	Good      string
+	Fair      string
+	Poor      string
+}
+
+var HealthRatings = HealthRatingTypes{
+	Excellent: "excellent",
+	Good:      "good",
+	Fair:      "fair",
+	Poor:      "poor",
+}
+
+// A subscription needs MinCharges charges in the last LookbackMonths whose
+// intervals stay within IntervalTolerance of its frequency. A charge moving
+// by more than PriceChangePercent from the one before is a price change.
+// Monthly subscriptions of at most ForgottenMaxAmount running for at least
+// ForgottenMinMonths may be forgotten.
+type SubscriptionLimits struct {
+	LookbackMonths     int
+	MinCharges         int
+	IntervalTolerance  float64
+	PriceChangePercent float64
+	ForgottenMaxAmount float64
+	ForgottenMinMonths int
+}
+
+var SubscriptionSettings = SubscriptionLimits{
+	LookbackMonths:     25,
+	MinCharges:         3,
+	IntervalTolerance:  0.2,
+	PriceChangePercent: 5,
+	ForgottenMaxAmount: 30,
+	ForgottenMinMonths: 6,
+}
+
+type InsightTypeNames struct {
+	SpendingSpike      string
+	DuplicateCharge    string
+	BankFee            string
+	UnusualBill        string
+	SavingsOpportunity string
+}
+
+var InsightTypes = InsightTypeNames{
+	SpendingSpike:      "spending_spike",
+	DuplicateCharge:    "duplicate_charge",
+	BankFee:            "bank_fee",
+	UnusualBill:        "unusual_bill",
+	SavingsOpportunity: "savings_opportunity",
+}
+
+type InsightSeverityLevels struct {
+	Info    string
+	Warning string
+}
+
+var InsightSeverities = InsightSeverityLevels{
+	Info:    "info",
+	Warning: "warning",
+}
+
+type BankChargeKindNames struct {
+	Fee       string
+	Overdraft string
+	Interest  string
+}
+
+var BankChargeKinds = BankChargeKindNames{
+	Fee:       "fee",
+	Overdraft: "overdraft",
+	Interest:  "interest",
+}
+
+// A category spikes when its spending this financial month reaches
+// SpikeRatio times its average over the SpikeMonths before, and by at least
+// SpikeMinAmount. Expenses of the same amount and merchant on one account
+// within DuplicateDays are duplicates. A bill is unusual at UnusualBillRatio
+// times its median charge.
+type InsightLimits struct {
+	RunInterval      time.Duration
+	SpikeMonths      int
+	SpikeRatio       float64
+	SpikeMinAmount   float64
+	DuplicateDays    int
+	LookbackDays     int
+	UnusualBillRatio float64
+}
+
+var InsightSettings = InsightLimits{
+	RunInterval:      6 * time.Hour,
+	SpikeMonths:      3,
+	SpikeRatio:       1.5,
+	SpikeMinAmount:   100,
+	DuplicateDays:    2,
+	LookbackDays:     30,
+	UnusualBillRatio: 1.5,
+}
+
+type AutomationTriggerTypes struct {
+	TransactionCreated string
+	BudgetExceeded     string
+	BalanceBelow       string
+}
+
+var AutomationTriggers = AutomationTriggerTypes{
+	TransactionCreated: "transaction_created",
+	BudgetExceeded:     "budget_exceeded",
+	BalanceBelow:       "balance_below",
+}
+
+type AutomationActionTypes struct {
+	SetCategory   string
+	AddTag        string
+	MoveToAccount string
+	Notify        string
+	Webhook       string
+	Contribute    string
+}
+
+var AutomationActions = AutomationActionTypes{
+	SetCategory:   "set_category",
+	AddTag:        "add_tag",
+	MoveToAccount: "move_to_account",
+	Notify:        "notify",
+	Webhook:       "webhook",
+	Contribute:    "contribute",
+}
+
+type AutomationLimits struct {
+	MaxRules       int
+	SimulationDays int
+	MaxMatches     int
+	WebhookTimeout time.Duration
+}
+
+var AutomationSettings = AutomationLimits{
+	MaxRules:       50,
+	SimulationDays: 30,
+	MaxMatches:     100,
+	WebhookTimeout: 30 * time.Second,
+}
+
+type GeoLimits struct {
+	DefaultRadiusKm  float64
+	MaxRadiusKm      float64
+	DefaultPrecision int
+	MaxPrecision     int
+	MaxLocations     int
+}
+
+var GeoSettings = GeoLimits{
+	DefaultRadiusKm:  1,
+	MaxRadiusKm:      20000,
+	DefaultPrecision: 3,
+	MaxPrecision:     5,
+	MaxLocations:     1000,
+}
+
+type WishlistPriorityTypes struct {
+	Low    string
+	Medium string
+	High   string
+}
+
+var WishlistPriorities = WishlistPriorityTypes{
+	Low:    "low",
+	Medium: "medium",
+	High:   "high",
+}
+
+type WishlistStatusTypes struct {
+	Planned   string
+	Purchased string
+}
+
+var WishlistStatuses = WishlistStatusTypes{
+	Planned:   "planned",
+	Purchased: "purchased",
+}
+
+type WishlistLimits struct {
+	MaxItems int
+}
+
+var WishlistSettings = WishlistLimits{
+	MaxItems: 200,
+}
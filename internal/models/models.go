@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -12,6 +13,46 @@ type User struct {
 	LastName  string    `json:"last_name" db:"last_name"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	PhoneNumber       *string           `json:"phone_number,omitempty" db:"phone_number"`
+	PreferredCurrency string            `json:"preferred_currency,omitempty"`
+	AvatarURLs        map[string]string `json:"avatar_urls,omitempty"`
+}
+
+type UserSettings struct {
+	BaseCurrency            string          `json:"base_currency"`
+	Locale                  string          `json:"locale"`
+	FirstDayOfWeek          int             `json:"first_day_of_week"`
+	FiscalMonthStartDay     int             `json:"fiscal_month_start_day"`
+	DateFormat              string          `json:"date_format"`
+	NotificationPreferences map[string]bool `json:"notification_preferences"`
+	DefaultAccountID        *int            `json:"default_account_id"`
+	PayPeriod               string          `json:"pay_period"`
+	PayPeriodAnchor         *string         `json:"pay_period_anchor"`
+	Timezone                string          `json:"timezone"`
+	UpdatedAt               *time.Time      `json:"updated_at,omitempty"`
+}
+
+type UpdateSettingsRequest struct {
+	BaseCurrency            *string         `json:"base_currency"`
+	Locale                  *string         `json:"locale"`
+	FirstDayOfWeek          *int            `json:"first_day_of_week"`
+	FiscalMonthStartDay     *int            `json:"fiscal_month_start_day"`
+	DateFormat              *string         `json:"date_format"`
+	NotificationPreferences map[string]bool `json:"notification_preferences"`
+	DefaultAccountID        *int            `json:"default_account_id"`
+	ClearDefaultAccount     bool            `json:"clear_default_account"`
+	PayPeriod               *string         `json:"pay_period"`
+	PayPeriodAnchor         *string         `json:"pay_period_anchor"`
+	Timezone                *string         `json:"timezone"`
+}
+
+type PeriodSummary struct {
+	Start         string  `json:"start_date"`
+	End           string  `json:"end_date"`
+	TotalIncome   float64 `json:"total_income"`
+	TotalExpenses float64 `json:"total_expenses"`
+	NetIncome     float64 `json:"net_income"`
 }
 
 type Account struct {
@@ -22,8 +63,117 @@ type Account struct {
 	Balance     float64   `json:"balance" db:"balance"`
 	Currency    string    `json:"currency" db:"currency"`
 	Description string    `json:"description" db:"description"`
+	Scope       string    `json:"scope" db:"scope"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	ArchivedAt     *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	ClosingBalance *float64   `json:"closing_balance,omitempty" db:"closing_balance"`
+
+	CreditLimit     *float64 `json:"credit_limit,omitempty" db:"credit_limit"`
+	InterestRate    *float64 `json:"interest_rate,omitempty" db:"interest_rate"`
+	AvailableCredit *float64 `json:"available_credit,omitempty" db:"-"`
+
+	StatementClosingDay *int `json:"statement_closing_day,omitempty" db:"statement_closing_day"`
+	PaymentDueDay       *int `json:"payment_due_day,omitempty" db:"payment_due_day"`
+}
+
+type CreditCardStatement struct {
+	AccountID        int       `json:"account_id"`
+	PeriodStart      time.Time `json:"period_start"`
+	ClosingDate      time.Time `json:"closing_date"`
+	DueDate          time.Time `json:"due_date"`
+	StatementBalance float64   `json:"statement_balance"`
+	Purchases        float64   `json:"purchases"`
+	Payments         float64   `json:"payments"`
+	PaidSinceClosing float64   `json:"paid_since_closing"`
+	RemainingDue     float64   `json:"remaining_due"`
+	MinimumPayment   float64   `json:"minimum_payment"`
+	CurrentBalance   float64   `json:"current_balance"`
+	CreditLimit      *float64  `json:"credit_limit,omitempty"`
+	Utilization      *float64  `json:"utilization,omitempty"`
+}
+
+type BalancePoint struct {
+	Date         string  `json:"date"`
+	Balance      float64 `json:"balance"`
+	Income       float64 `json:"income"`
+	Expenses     float64 `json:"expenses"`
+	Adjustments  float64 `json:"adjustments"`
+	Transactions int     `json:"transactions"`
+}
+
+// BalanceHistory holds an account's closing balance per day, week or month.
+// Each point's date is the start of its bucket.
+type BalanceHistory struct {
+	AccountID   int            `json:"account_id"`
+	Granularity string         `json:"granularity"`
+	StartDate   string         `json:"start_date"`
+	EndDate     string         `json:"end_date"`
+	Currency    string         `json:"currency"`
+	Points      []BalancePoint `json:"points"`
+}
+
+// BalanceDrift is an account whose stored balance no longer matches its
+// opening balance plus its transactions.
+type BalanceDrift struct {
+	AccountID  int     `json:"account_id"`
+	UserID     int     `json:"user_id"`
+	Name       string  `json:"name"`
+	Stored     float64 `json:"stored"`
+	Ledger     float64 `json:"ledger"`
+	Difference float64 `json:"difference"`
+	Fixed      bool    `json:"fixed"`
+}
+
+type CardUtilization struct {
+	AccountID   int     `json:"account_id"`
+	Name        string  `json:"name"`
+	CreditLimit float64 `json:"credit_limit"`
+	Owed        float64 `json:"owed"`
+	Utilization float64 `json:"utilization"`
+}
+
+type CreditUtilization struct {
+	CreditLimit float64           `json:"credit_limit"`
+	Owed        float64           `json:"owed"`
+	Utilization float64           `json:"utilization"`
+	Cards       []CardUtilization `json:"cards"`
+}
+
+type NetWorth struct {
+	Assets      float64 `json:"assets"`
+	Liabilities float64 `json:"liabilities"`
+	NetWorth    float64 `json:"net_worth"`
+}
+
+type ArchiveAccountRequest struct {
+	Close bool `json:"close"`
+}
+
+// OpeningBalanceRequest sets the balance an account had on Date, for
+// accounts tracked from part way through their history.
+type OpeningBalanceRequest struct {
+	Amount *float64 `json:"amount" binding:"required"`
+	Date   string   `json:"date" binding:"required"`
+}
+
+// ReconcileRequest gives the balance a bank statement shows at the end of
+// Date, or now when Date is empty.
+type ReconcileRequest struct {
+	Balance     *float64 `json:"balance" binding:"required"`
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+}
+
+type Reconciliation struct {
+	AccountID        int          `json:"account_id"`
+	StatementBalance float64      `json:"statement_balance"`
+	LedgerBalance    float64      `json:"ledger_balance"`
+	Difference       float64      `json:"difference"`
+	Adjustment       *Transaction `json:"adjustment"`
+	Balance          float64      `json:"balance"`
 }
 
 type Category struct {
@@ -36,6 +186,11 @@ type Category struct {
 	ParentID  *int      `json:"parent_id" db:"parent_id"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	TaxDeductible bool    `json:"tax_deductible" db:"tax_deductible"`
+	TaxCode       *string `json:"tax_code" db:"tax_code"`
+
+	GroupID *int `json:"group_id" db:"group_id"`
 }
 
 type Transaction struct {
@@ -48,8 +203,40 @@ type Transaction struct {
 	Description string    `json:"description" db:"description"`
 	Date        time.Time `json:"date" db:"date"`
 	Tags        []string  `json:"tags" db:"tags"`
+	Scope       string    `json:"scope" db:"scope"`
+	Latitude    *float64  `json:"latitude,omitempty" db:"latitude"`
+	Longitude   *float64  `json:"longitude,omitempty" db:"longitude"`
+	Place       string    `json:"place,omitempty" db:"place"`
+	Quantity    *float64  `json:"quantity,omitempty" db:"quantity"`
+	Unit        string    `json:"unit,omitempty" db:"unit"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	Category *TransactionCategoryRef `json:"category,omitempty" db:"-"`
+	Account  *TransactionAccountRef  `json:"account,omitempty" db:"-"`
+
+	// RunningBalance is the account balance after this transaction; it is
+	// only set in listings filtered to one account.
+	RunningBalance *float64 `json:"running_balance,omitempty" db:"-"`
+}
+
+type TransactionCategoryRef struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+}
+
+type TransactionAccountRef struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Currency string `json:"currency"`
+}
+
+type TransactionExpand struct {
+	Category bool
+	Account  bool
 }
 
 type BudgetRule struct {
@@ -60,13 +247,71 @@ type BudgetRule struct {
 	Period     string     `json:"period" db:"period"`
 	StartDate  time.Time  `json:"start_date" db:"start_date"`
 	EndDate    *time.Time `json:"end_date" db:"end_date"`
+	HardCap    bool       `json:"hard_cap" db:"hard_cap"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+type CategoryBudgetStatus struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Budget       float64 `json:"budget"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"`
+	PercentUsed  float64 `json:"percent_used"`
+}
+
+// BudgetRuleRequest sets a monthly budget for a category. StartDate defaults
+// to the first day of the current month; HardCap refuses expenses over it.
+type BudgetRuleRequest struct {
+	CategoryID int     `json:"category_id" binding:"required"`
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	StartDate  string  `json:"start_date"`
+	EndDate    *string `json:"end_date"`
+	HardCap    bool    `json:"hard_cap"`
+}
+
+type BudgetOverview struct {
+	PeriodStart string                 `json:"period_start"`
+	PeriodEnd   string                 `json:"period_end"`
+	Currency    string                 `json:"currency"`
+	Budget      float64                `json:"budget"`
+	Spent       float64                `json:"spent"`
+	Remaining   float64                `json:"remaining"`
+	Categories  []CategoryBudgetStatus `json:"categories"`
+	Groups      []GroupBudgetStatus    `json:"groups"`
+}
+
+// CategoryGroup rolls categories up for reporting. Its monthly budget covers
+// the expenses of every category in the group, on top of their own budgets.
+type CategoryGroup struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	Name          string    `json:"name"`
+	MonthlyBudget *float64  `json:"monthly_budget"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CategoryGroupRequest creates or replaces a category group; leaving
+// MonthlyBudget out removes the group budget.
+type CategoryGroupRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	MonthlyBudget *float64 `json:"monthly_budget" binding:"omitempty,gt=0"`
+}
+
+type GroupBudgetStatus struct {
+	GroupID     int     `json:"group_id"`
+	GroupName   string  `json:"group_name"`
+	Budget      float64 `json:"budget"`
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	PercentUsed float64 `json:"percent_used"`
+}
+
 type RegisterRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 }
@@ -76,27 +321,294 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest changes only the fields that are set. Changing the
+// password or email requires the current password.
+type UpdateProfileRequest struct {
+	FirstName       *string `json:"first_name"`
+	LastName        *string `json:"last_name"`
+	Email           *string `json:"email" binding:"omitempty,email"`
+	CurrentPassword string  `json:"current_password"`
+	NewPassword     string  `json:"new_password"`
+
+	PhoneNumber       *string `json:"phone_number"`
+	PreferredCurrency *string `json:"preferred_currency"`
+}
+
+type ProfileUpdate struct {
+	User            User   `json:"user"`
+	PendingEmail    string `json:"pending_email,omitempty"`
+	SessionsRevoked int64  `json:"sessions_revoked,omitempty"`
+}
+
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	User         User   `json:"user"`
 }
 
+// TransactionFilter selects transactions by account, category, type, scope
+// and inclusive date range. Dates are days in the user's timezone.
 type TransactionFilter struct {
 	AccountID  *int       `form:"account_id"`
 	CategoryID *int       `form:"category_id"`
-	Type       *string    `form:"type"`
-	StartDate  *time.Time `form:"start_date"`
-	EndDate    *time.Time `form:"end_date"`
+	Type       *string    `form:"type" binding:"omitempty,oneof=income expense"`
+	StartDate  *time.Time `form:"start_date" time_format:"2006-01-02"`
+	EndDate    *time.Time `form:"end_date" time_format:"2006-01-02"`
+	Scope      string     `form:"scope" binding:"omitempty,oneof=personal business"`
 	Limit      int        `form:"limit"`
 	Offset     int        `form:"offset"`
 }
 
+// GeoFilter keeps the transactions made inside Bounds or within RadiusKm of
+// Near. Either may be nil to leave it out.
+type GeoFilter struct {
+	Bounds   *GeoBounds
+	Near     *GeoPoint
+	RadiusKm float64
+}
+
+// GeoBounds is a bounding box in degrees. West may exceed East for a box
+// crossing the antimeridian.
+type GeoBounds struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// ReportRequest groups the transactions matching Filter by GroupBy and, when
+// Pivot is set, spreads each group over the values of Pivot.
+type ReportRequest struct {
+	GroupBy string
+	Pivot   string
+	Metrics []string
+	Filter  TransactionFilter
+}
+
+// ReportValues holds the requested metrics (sum, count, avg) of one cell.
+type ReportValues map[string]float64
+
+type ReportColumn struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+type ReportRow struct {
+	Key   string                  `json:"key"`
+	Label string                  `json:"label"`
+	Cells map[string]ReportValues `json:"cells,omitempty"`
+	Total ReportValues            `json:"total"`
+}
+
+// Report is a pivot table: Rows by GroupBy, Columns by Pivot, with totals
+// per column and overall.
+type Report struct {
+	GroupBy    string                  `json:"group_by"`
+	Pivot      string                  `json:"pivot,omitempty"`
+	Metrics    []string                `json:"metrics"`
+	Columns    []ReportColumn          `json:"columns,omitempty"`
+	Rows       []ReportRow             `json:"rows"`
+	Totals     map[string]ReportValues `json:"totals,omitempty"`
+	GrandTotal ReportValues            `json:"grand_total"`
+}
+
+// ReportScheduleFilter narrows a scheduled report. Dates are not part of it:
+// each delivery covers the time since the previous one.
+type ReportScheduleFilter struct {
+	AccountID  *int    `json:"account_id,omitempty"`
+	CategoryID *int    `json:"category_id,omitempty"`
+	Type       *string `json:"type,omitempty" binding:"omitempty,oneof=income expense"`
+	Scope      string  `json:"scope,omitempty" binding:"omitempty,oneof=personal business"`
+}
+
+// ReportSchedule delivers a report as a file by email or webhook whenever
+// its cron expression fires in the user's timezone.
+type ReportSchedule struct {
+	ID         int                  `json:"id"`
+	UserID     int                  `json:"user_id"`
+	Name       string               `json:"name"`
+	GroupBy    string               `json:"group_by"`
+	Pivot      string               `json:"pivot,omitempty"`
+	Metrics    []string             `json:"metrics"`
+	Filter     ReportScheduleFilter `json:"filter"`
+	Format     string               `json:"format"`
+	Delivery   string               `json:"delivery"`
+	WebhookURL string               `json:"webhook_url,omitempty"`
+	Cron       string               `json:"cron"`
+	Enabled    bool                 `json:"enabled"`
+	NextRunAt  time.Time            `json:"next_run_at"`
+	LastRunAt  *time.Time           `json:"last_run_at,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+type ReportScheduleRequest struct {
+	Name       string               `json:"name" binding:"required,max=100"`
+	GroupBy    string               `json:"group_by" binding:"required"`
+	Pivot      string               `json:"pivot"`
+	Metrics    []string             `json:"metrics"`
+	Filter     ReportScheduleFilter `json:"filter"`
+	Format     string               `json:"format" binding:"required,oneof=csv pdf"`
+	Delivery   string               `json:"delivery" binding:"required,oneof=email webhook"`
+	WebhookURL string               `json:"webhook_url" binding:"omitempty,url"`
+	Cron       string               `json:"cron" binding:"required"`
+	Enabled    *bool                `json:"enabled"`
+}
+
+type YearReviewMonth struct {
+	Month         string  `json:"month"`
+	TotalIncome   float64 `json:"total_income"`
+	TotalExpenses float64 `json:"total_expenses"`
+	NetIncome     float64 `json:"net_income"`
+}
+
+// YearReviewMerchant is a payee, or the description of transactions that
+// match no payee.
+type YearReviewMerchant struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+	Count  int     `json:"count"`
+}
+
+type YearReviewExpense struct {
+	TransactionID int     `json:"transaction_id"`
+	Date          string  `json:"date"`
+	Description   string  `json:"description"`
+	CategoryName  string  `json:"category_name"`
+	Amount        float64 `json:"amount"`
+}
+
+// YearReviewCategoryChange compares a category's spending with the year
+// before; a negative Change is a saving.
+type YearReviewCategoryChange struct {
+	CategoryID     int     `json:"category_id"`
+	CategoryName   string  `json:"category_name"`
+	Amount         float64 `json:"amount"`
+	PreviousAmount float64 `json:"previous_amount"`
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+}
+
+// YearReview sums up a calendar year in the user's timezone.
+type YearReview struct {
+	Year             int                       `json:"year"`
+	Scope            string                    `json:"scope,omitempty"`
+	Currency         string                    `json:"currency"`
+	TotalIncome      float64                   `json:"total_income"`
+	TotalExpenses    float64                   `json:"total_expenses"`
+	NetIncome        float64                   `json:"net_income"`
+	SavingsRate      float64                   `json:"savings_rate"`
+	TransactionCount int                       `json:"transaction_count"`
+	TopCategories    []SpendingByCategory      `json:"top_categories"`
+	TopMerchants     []YearReviewMerchant      `json:"top_merchants"`
+	BiggestExpense   *YearReviewExpense        `json:"biggest_expense"`
+	MostImproved     *YearReviewCategoryChange `json:"most_improved_category"`
+	Months           []YearReviewMonth         `json:"months"`
+}
+
+// HealthMetric is one part of the financial health score. Score runs from 0
+// to 100; Previous and Trend compare with the window before, when the
+// metric can be worked out for it.
+type HealthMetric struct {
+	Key         string   `json:"key"`
+	Value       float64  `json:"value"`
+	Previous    *float64 `json:"previous,omitempty"`
+	Score       float64  `json:"score"`
+	Weight      float64  `json:"weight"`
+	Trend       string   `json:"trend,omitempty"`
+	Explanation string   `json:"explanation"`
+}
+
+// FinancialHealth weighs the metrics into one score from 0 to 100. Metrics
+// that do not apply, such as budget adherence without budgets, are left
+// out and the weights of the others scaled up.
+type FinancialHealth struct {
+	PeriodStart string         `json:"period_start"`
+	PeriodEnd   string         `json:"period_end"`
+	Currency    string         `json:"currency"`
+	Score       float64        `json:"score"`
+	Rating      string         `json:"rating"`
+	Metrics     []HealthMetric `json:"metrics"`
+}
+
+// EmergencyFund reports how many months of average essential spending the
+// emergency fund accounts cover. Without designated accounts the savings
+// accounts count; without essential categories every expense does.
+type EmergencyFund struct {
+	AccountIDs               []int    `json:"account_ids"`
+	EssentialCategoryIDs     []int    `json:"essential_category_ids"`
+	ThresholdMonths          float64  `json:"threshold_months"`
+	Currency                 string   `json:"currency"`
+	Balance                  float64  `json:"balance"`
+	MonthlyEssentialSpending float64  `json:"monthly_essential_spending"`
+	CoverageMonths           *float64 `json:"coverage_months"`
+	BelowThreshold           bool     `json:"below_threshold"`
+	PeriodStart              string   `json:"period_start"`
+	PeriodEnd                string   `json:"period_end"`
+}
+
+type EmergencyFundRequest struct {
+	AccountIDs           []int   `json:"account_ids"`
+	EssentialCategoryIDs []int   `json:"essential_category_ids"`
+	ThresholdMonths      float64 `json:"threshold_months" binding:"omitempty,gt=0,lte=120"`
+}
+
+// Subscription is a charge detected as repeating at a regular interval at
+// the same merchant, with the price changes seen between its charges.
+type Subscription struct {
+	Merchant     string                    `json:"merchant"`
+	Frequency    string                    `json:"frequency"`
+	Amount       float64                   `json:"amount"`
+	Currency     string                    `json:"currency"`
+	ChargeCount  int                       `json:"charge_count"`
+	FirstCharge  string                    `json:"first_charge"`
+	LastCharge   string                    `json:"last_charge"`
+	NextCharge   string                    `json:"next_charge"`
+	PriceChanges []SubscriptionPriceChange `json:"price_changes"`
+}
+
+type SubscriptionPriceChange struct {
+	TransactionID  int     `json:"transaction_id"`
+	Date           string  `json:"date"`
+	PreviousAmount float64 `json:"previous_amount"`
+	Amount         float64 `json:"amount"`
+	Change         float64 `json:"change"`
+	ChangePercent  float64 `json:"change_percent"`
+}
+
+// ForgottenSubscriptions lists the subscriptions that may be paid for
+// without being used, with what they cost a year.
+type ForgottenSubscriptions struct {
+	Currency      string                  `json:"currency"`
+	AnnualCost    float64                 `json:"annual_cost"`
+	Subscriptions []ForgottenSubscription `json:"subscriptions"`
+}
+
+type ForgottenSubscription struct {
+	Subscription
+	MonthsRunning int     `json:"months_running"`
+	AnnualCost    float64 `json:"annual_cost"`
+}
+
 type AnalyticsSummary struct {
 	TotalIncome    float64 `json:"total_income"`
 	TotalExpenses  float64 `json:"total_expenses"`
 	NetIncome      float64 `json:"net_income"`
 	AccountBalance float64 `json:"account_balance"`
+	Assets         float64 `json:"assets"`
+	Liabilities    float64 `json:"liabilities"`
+	NetWorth       float64 `json:"net_worth"`
 	Period         string  `json:"period"`
+	StartDate      string  `json:"start_date,omitempty"`
+	EndDate        string  `json:"end_date,omitempty"`
+	Scope          string  `json:"scope,omitempty"`
+
+	CreditUtilization *float64 `json:"credit_utilization,omitempty"`
 }
 
 type SpendingByCategory struct {
@@ -106,6 +618,63 @@ type SpendingByCategory struct {
 	Percentage   float64 `json:"percentage"`
 }
 
+// SpendingByGroup breaks spending down by category group. Categories outside
+// any group are reported together with a null GroupID.
+type SpendingByGroup struct {
+	GroupID    *int                 `json:"group_id"`
+	GroupName  string               `json:"group_name"`
+	Amount     float64              `json:"amount"`
+	Percentage float64              `json:"percentage"`
+	Categories []SpendingByCategory `json:"categories"`
+}
+
+// DashboardWidget is one tile of the user's dashboard, in Position order.
+type DashboardWidget struct {
+	ID       int                   `json:"id"`
+	Type     string                `json:"type"`
+	Position int                   `json:"position"`
+	Params   DashboardWidgetParams `json:"params"`
+}
+
+// DashboardWidgetParams configure a widget; each type reads only some of
+// them. Period is the current or previous pay period of summary and
+// category_donut, Limit the number of donut slices before the rest is merged
+// into "Other", Count the pay periods of a trend_line. Scope limits any
+// widget but budget_bars to personal or business data.
+type DashboardWidgetParams struct {
+	Scope  string `json:"scope,omitempty"`
+	Period string `json:"period,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+// DashboardRequest replaces the whole dashboard; an empty list restores the
+// default layout.
+type DashboardRequest struct {
+	Widgets []DashboardWidgetRequest `json:"widgets" binding:"required,dive"`
+}
+
+type DashboardWidgetRequest struct {
+	Type   string                `json:"type" binding:"required"`
+	Params DashboardWidgetParams `json:"params"`
+}
+
+// DashboardWidgetData is the resolved data of one widget. A widget that
+// failed to load carries Error instead of failing the whole dashboard.
+type DashboardWidgetData struct {
+	ID    int         `json:"id"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	Error string      `json:"error,omitempty"`
+}
+
+type CategoryTotal struct {
+	CategoryID int
+	Type       string
+	Amount     float64
+	Count      int
+}
+
 type SpendingTrend struct {
 	CategoryID     int     `json:"category_id"`
 	CategoryName   string  `json:"category_name"`
@@ -118,8 +687,33 @@ type SpendingTrend struct {
 type SpendingTrendsRequest struct {
 	Period string `form:"period" binding:"required"`
 	Date   string `form:"date"`
+	Scope  string `form:"scope" binding:"omitempty,oneof=personal business"`
 }
 
+// AnalyticsBatchRequest runs several analytics over one shared date range
+// and scope. Dates are inclusive days; Name keys each result.
+type AnalyticsBatchRequest struct {
+	StartDate string               `json:"start_date"`
+	EndDate   string               `json:"end_date"`
+	Scope     string               `json:"scope" binding:"omitempty,oneof=personal business"`
+	Requests  []AnalyticsBatchItem `json:"requests" binding:"required,min=1,dive"`
+}
+
+// AnalyticsBatchItem names one analytic. Trends read Period (day, week,
+// month or pay_period) for the period ending with the range; periods read
+// Count.
+type AnalyticsBatchItem struct {
+	Name   string `json:"name" binding:"required,max=50"`
+	Type   string `json:"type" binding:"required,oneof=summary spending spending_groups trends periods net_worth"`
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+type AnalyticsBatchResult struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
 type SpendingTrendsResponse struct {
 	Period string          `json:"period"`
 	Date   string          `json:"date"`
@@ -132,3 +726,1554 @@ type PredictionData struct {
 	RecentTrend   float64 `json:"recent_trend"`
 	Seasonality   float64 `json:"seasonality"`
 }
+
+type CategorySuggestionRequest struct {
+	Description string  `json:"description" binding:"required"`
+	Amount      float64 `json:"amount"`
+	Type        string  `json:"type"`
+	Limit       int     `json:"limit"`
+}
+
+type CategorySuggestion struct {
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Confidence   float64 `json:"confidence"`
+}
+
+type CategorySuggestionResponse struct {
+	Description     string               `json:"description"`
+	TrainingSamples int                  `json:"training_samples"`
+	Suggestions     []CategorySuggestion `json:"suggestions"`
+}
+
+type WidgetToken struct {
+	ID              int        `json:"id" db:"id"`
+	UserID          int        `json:"user_id" db:"user_id"`
+	Name            string     `json:"name" db:"name"`
+	RefreshInterval int        `json:"refresh_interval" db:"refresh_interval"`
+	LastUsedAt      *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt       *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateWidgetTokenRequest struct {
+	Name            string `json:"name" binding:"required"`
+	RefreshInterval int    `json:"refresh_interval"`
+}
+
+type CreateWidgetTokenResponse struct {
+	Token   string      `json:"token"`
+	FeedURL string      `json:"feed_url"`
+	Widget  WidgetToken `json:"widget"`
+}
+
+type WidgetFeed struct {
+	Balance        float64 `json:"balance"`
+	MonthSpend     float64 `json:"month_spend"`
+	MonthBudget    float64 `json:"month_budget"`
+	BudgetUsed     float64 `json:"budget_used_percent"`
+	NetWorthDelta  float64 `json:"net_worth_delta"`
+	Month          string  `json:"month"`
+	RefreshSeconds int     `json:"refresh_seconds"`
+	GeneratedAt    string  `json:"generated_at"`
+}
+
+type AnalyticsQueryRequest struct {
+	Question string `json:"question" binding:"required"`
+}
+
+type AnalyticsQueryResult struct {
+	Value            float64 `json:"value"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+type AnalyticsQueryInterpretation struct {
+	Metric    string `json:"metric"`
+	Category  string `json:"category,omitempty"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Period    string `json:"period"`
+	Source    string `json:"source"`
+}
+
+type AnalyticsQueryResponse struct {
+	Question       string                       `json:"question"`
+	Interpretation AnalyticsQueryInterpretation `json:"interpretation"`
+	Result         AnalyticsQueryResult         `json:"result"`
+}
+
+type TransactionDraft struct {
+	AccountID   *int      `json:"account_id"`
+	CategoryID  *int      `json:"category_id"`
+	Amount      float64   `json:"amount"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+}
+
+type ReceiptScanResponse struct {
+	Engine  string           `json:"engine"`
+	Receipt interface{}      `json:"receipt"`
+	Draft   TransactionDraft `json:"draft"`
+}
+
+type IngestionAddress struct {
+	Address    string `json:"address"`
+	WebhookURL string `json:"webhook_url"`
+}
+
+type InboundEmailRequest struct {
+	From    string `json:"from"`
+	Subject string `json:"subject" binding:"required"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+	Date    string `json:"date"`
+}
+
+type DraftTransaction struct {
+	ID            int       `json:"id" db:"id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	Source        string    `json:"source" db:"source"`
+	Status        string    `json:"status" db:"status"`
+	Merchant      string    `json:"merchant" db:"merchant"`
+	Amount        float64   `json:"amount" db:"amount"`
+	Type          string    `json:"type" db:"type"`
+	Description   string    `json:"description" db:"description"`
+	Date          time.Time `json:"date" db:"date"`
+	RawSubject    string    `json:"raw_subject" db:"raw_subject"`
+	TransactionID *int      `json:"transaction_id" db:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type ApproveDraftRequest struct {
+	AccountID   int      `json:"account_id" binding:"required"`
+	CategoryID  int      `json:"category_id" binding:"required"`
+	Amount      *float64 `json:"amount"`
+	Description *string  `json:"description"`
+}
+
+type CategoryMergeRequest struct {
+	TargetID int `json:"target_id" binding:"required"`
+}
+
+// CategoryMerge counts the records moved from a merged category.
+type CategoryMerge struct {
+	TargetID       int `json:"target_id"`
+	Transactions   int `json:"transactions"`
+	Budgets        int `json:"budgets"`
+	RecurringRules int `json:"recurring_rules"`
+	AllowanceRules int `json:"allowance_rules"`
+	Approvals      int `json:"approvals"`
+	Subcategories  int `json:"subcategories"`
+}
+
+type CreateTransactionRequest struct {
+	AccountID int `json:"account_id" binding:"required"`
+	// CategoryID may be left out when creating a transaction, which then
+	// gets the category the classifier suggests.
+	CategoryID  int     `json:"category_id"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Type        string  `json:"type" binding:"required,oneof=income expense"`
+	Description string  `json:"description"`
+	// Date defaults to now on creation; an update without it keeps the
+	// stored date.
+	Date *time.Time `json:"date"`
+	// Scope defaults to the account's scope.
+	Scope string `json:"scope" binding:"omitempty,oneof=personal business"`
+	// Latitude and Longitude are sent together. An update without them
+	// keeps the stored location.
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Place     string   `json:"place" binding:"max=255"`
+	// Quantity and Unit, such as 40 and "l", are sent together and kept
+	// like the location.
+	Quantity *float64 `json:"quantity" binding:"required_with=Unit,omitempty,gt=0"`
+	Unit     string   `json:"unit" binding:"required_with=Quantity,max=20"`
+}
+
+type CategoryRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"required,oneof=income expense"`
+	Color    string `json:"color"`
+	Icon     string `json:"icon"`
+	ParentID *int   `json:"parent_id"`
+
+	TaxDeductible bool    `json:"tax_deductible"`
+	TaxCode       *string `json:"tax_code" binding:"omitempty,max=50"`
+
+	GroupID *int `json:"group_id"`
+}
+
+// TransactionTaxRequest overrides the tax treatment a transaction inherits
+// from its category; null fields fall back to the category again.
+type TransactionTaxRequest struct {
+	TaxDeductible *bool   `json:"tax_deductible"`
+	TaxCode       *string `json:"tax_code" binding:"omitempty,max=50"`
+}
+
+type TransactionTax struct {
+	TransactionID int     `json:"transaction_id"`
+	TaxDeductible *bool   `json:"tax_deductible"`
+	TaxCode       *string `json:"tax_code"`
+}
+
+type TaxReportItem struct {
+	TransactionID int     `json:"transaction_id"`
+	Date          string  `json:"date"`
+	Description   string  `json:"description"`
+	Category      string  `json:"category"`
+	Account       string  `json:"account"`
+	Amount        float64 `json:"amount"`
+}
+
+type TaxCodeSummary struct {
+	TaxCode      string          `json:"tax_code"`
+	Total        float64         `json:"total"`
+	Count        int             `json:"count"`
+	Transactions []TaxReportItem `json:"transactions"`
+}
+
+// VATLineRequest describes one line of a transaction's VAT breakdown. The
+// VAT amount defaults to the rate applied to the net amount and the gross
+// amount to their sum.
+type VATLineRequest struct {
+	Description string   `json:"description" binding:"max=255"`
+	NetAmount   float64  `json:"net_amount" binding:"gte=0"`
+	VATRate     float64  `json:"vat_rate" binding:"gte=0,lte=100"`
+	VATAmount   *float64 `json:"vat_amount" binding:"omitempty,gte=0"`
+	GrossAmount *float64 `json:"gross_amount"`
+}
+
+type VATBreakdownRequest struct {
+	Lines []VATLineRequest `json:"lines" binding:"required,min=1,max=100,dive"`
+}
+
+type VATLine struct {
+	ID          int     `json:"id"`
+	Description string  `json:"description"`
+	NetAmount   float64 `json:"net_amount"`
+	VATRate     float64 `json:"vat_rate"`
+	VATAmount   float64 `json:"vat_amount"`
+	GrossAmount float64 `json:"gross_amount"`
+}
+
+type VATBreakdown struct {
+	TransactionID int       `json:"transaction_id"`
+	NetAmount     float64   `json:"net_amount"`
+	VATAmount     float64   `json:"vat_amount"`
+	GrossAmount   float64   `json:"gross_amount"`
+	Lines         []VATLine `json:"lines"`
+}
+
+type VATRateSummary struct {
+	VATRate     float64 `json:"vat_rate"`
+	NetAmount   float64 `json:"net_amount"`
+	VATAmount   float64 `json:"vat_amount"`
+	GrossAmount float64 `json:"gross_amount"`
+}
+
+// VATReport sums the VAT breakdowns of a quarter per rate: output VAT on
+// income, input VAT on expenses, and the difference due.
+type VATReport struct {
+	Year       int              `json:"year"`
+	Quarter    int              `json:"quarter"`
+	Scope      string           `json:"scope,omitempty"`
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Currency   string           `json:"currency"`
+	Output     []VATRateSummary `json:"output"`
+	Input      []VATRateSummary `json:"input"`
+	OutputVAT  float64          `json:"output_vat"`
+	InputVAT   float64          `json:"input_vat"`
+	VATPayable float64          `json:"vat_payable"`
+}
+
+// Reimbursement is money owed back to the user, for an expense transaction
+// or for a mileage claim priced at distance times rate.
+type Reimbursement struct {
+	ID                         int        `json:"id"`
+	UserID                     int        `json:"user_id"`
+	TransactionID              *int       `json:"transaction_id"`
+	DistanceKm                 *float64   `json:"distance_km,omitempty"`
+	RatePerKm                  *float64   `json:"rate_per_km,omitempty"`
+	TripDate                   *string    `json:"trip_date,omitempty"`
+	Amount                     float64    `json:"amount"`
+	Description                string     `json:"description"`
+	Payer                      string     `json:"payer"`
+	Status                     string     `json:"status"`
+	SubmittedAt                *time.Time `json:"submitted_at"`
+	ReimbursedAt               *time.Time `json:"reimbursed_at"`
+	ReimbursementTransactionID *int       `json:"reimbursement_transaction_id"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+// ReimbursementRequest flags an expense as reimbursable or, without a
+// transaction, records a mileage claim.
+type ReimbursementRequest struct {
+	TransactionID *int     `json:"transaction_id"`
+	DistanceKm    *float64 `json:"distance_km" binding:"omitempty,gt=0"`
+	RatePerKm     *float64 `json:"rate_per_km" binding:"omitempty,gt=0"`
+	TripDate      *string  `json:"trip_date"`
+	Description   string   `json:"description" binding:"max=255"`
+	Payer         string   `json:"payer" binding:"max=100"`
+}
+
+type ReimburseRequest struct {
+	TransactionID int `json:"transaction_id" binding:"required"`
+}
+
+type PayerReimbursements struct {
+	Payer  string  `json:"payer"`
+	Amount float64 `json:"amount"`
+	Count  int     `json:"count"`
+}
+
+// OutstandingReimbursements lists what is still owed, pending or submitted.
+type OutstandingReimbursements struct {
+	Currency       string                `json:"currency"`
+	Total          float64               `json:"total"`
+	Pending        float64               `json:"pending"`
+	Submitted      float64               `json:"submitted"`
+	Payers         []PayerReimbursements `json:"payers"`
+	Reimbursements []Reimbursement       `json:"reimbursements"`
+}
+
+type Contact struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ContactRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Email string `json:"email" binding:"omitempty,email,max=255"`
+}
+
+type SplitShare struct {
+	ContactID   int     `json:"contact_id"`
+	ContactName string  `json:"contact_name"`
+	Amount      float64 `json:"amount"`
+}
+
+type SplitShareRequest struct {
+	ContactID int     `json:"contact_id" binding:"required"`
+	Amount    float64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// SplitRequest replaces the shares of a transaction. With Equal set the
+// amount is divided evenly between the user and the listed contacts and the
+// share amounts are ignored.
+type SplitRequest struct {
+	Shares []SplitShareRequest `json:"shares" binding:"dive"`
+	Equal  bool                `json:"equal"`
+}
+
+// TransactionSplit shows who owes what of a transaction the user paid;
+// OwnShare is what is left for the user.
+type TransactionSplit struct {
+	TransactionID int          `json:"transaction_id"`
+	Amount        float64      `json:"amount"`
+	OwnShare      float64      `json:"own_share"`
+	Shares        []SplitShare `json:"shares"`
+}
+
+type SplitSettlement struct {
+	ID        int       `json:"id"`
+	ContactID int       `json:"contact_id"`
+	Direction string    `json:"direction"`
+	Amount    float64   `json:"amount"`
+	Date      string    `json:"date"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SplitSettlementRequest struct {
+	Direction string  `json:"direction" binding:"required,oneof=received paid"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Date      string  `json:"date"`
+	Note      string  `json:"note" binding:"max=255"`
+}
+
+// ContactBalance is positive when the contact owes the user and negative
+// when the user owes the contact.
+type ContactBalance struct {
+	ContactID int     `json:"contact_id"`
+	Name      string  `json:"name"`
+	Owed      float64 `json:"owed"`
+	Received  float64 `json:"received"`
+	Paid      float64 `json:"paid"`
+	Balance   float64 `json:"balance"`
+}
+
+// UserLink connects two registered users for IOUs. UserID and Email describe
+// the other user; Incoming is set when they sent the request.
+type UserLink struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Email      string     `json:"email"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	Status     string     `json:"status"`
+	Incoming   bool       `json:"incoming"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+type UserLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// IOU is money one linked user owes another, seen from the requesting user:
+// Direction is lent when the counterparty owes them and borrowed otherwise.
+// TransactionID is the requesting user's side of the settlement.
+type IOU struct {
+	ID                int        `json:"id"`
+	CreditorID        int        `json:"creditor_id"`
+	DebtorID          int        `json:"debtor_id"`
+	CreatedBy         int        `json:"created_by"`
+	Direction         string     `json:"direction"`
+	CounterpartyID    int        `json:"counterparty_id"`
+	CounterpartyEmail string     `json:"counterparty_email"`
+	Amount            float64    `json:"amount"`
+	Description       string     `json:"description"`
+	Date              string     `json:"date"`
+	Status            string     `json:"status"`
+	DisputeReason     string     `json:"dispute_reason"`
+	TransactionID     *int       `json:"transaction_id"`
+	SettledAt         *time.Time `json:"settled_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type IOURequest struct {
+	UserID      int     `json:"user_id" binding:"required"`
+	Direction   string  `json:"direction" binding:"required,oneof=lent borrowed"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Description string  `json:"description" binding:"max=255"`
+	Date        string  `json:"date"`
+}
+
+type IOUDisputeRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// IOUSettleRequest picks the account the settling user pays from or into;
+// the other side is booked to their default account.
+type IOUSettleRequest struct {
+	AccountID *int `json:"account_id"`
+}
+
+// TaxReport groups a year's deductible expenses by tax code. Expenses
+// without a code are listed under an empty one.
+type TaxReport struct {
+	Year     int              `json:"year"`
+	Scope    string           `json:"scope,omitempty"`
+	Currency string           `json:"currency"`
+	Total    float64          `json:"total"`
+	Codes    []TaxCodeSummary `json:"codes"`
+}
+
+type Payee struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	Name         string    `json:"name"`
+	MatchText    string    `json:"match_text"`
+	MonthlyLimit *float64  `json:"monthly_limit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PayeeRequest creates or replaces a payee. MatchText defaults to the name;
+// leaving MonthlyLimit out removes the limit.
+type PayeeRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	MatchText    string   `json:"match_text" binding:"max=100"`
+	MonthlyLimit *float64 `json:"monthly_limit" binding:"omitempty,gt=0"`
+}
+
+type PayeeLimitStatus struct {
+	PayeeID     int     `json:"payee_id"`
+	PayeeName   string  `json:"payee_name"`
+	Limit       float64 `json:"limit"`
+	Spent       float64 `json:"spent"`
+	Remaining   float64 `json:"remaining"`
+	PercentUsed float64 `json:"percent_used"`
+}
+
+type PayeeLimitOverview struct {
+	PeriodStart string             `json:"period_start"`
+	PeriodEnd   string             `json:"period_end"`
+	Currency    string             `json:"currency"`
+	Payees      []PayeeLimitStatus `json:"payees"`
+}
+
+// Household is what a user sees of their household: owners get the members,
+// members get the accounts they may use and the owner's categories.
+type Household struct {
+	OwnerID           int               `json:"owner_id"`
+	OwnerEmail        string            `json:"owner_email"`
+	Role              string            `json:"role"`
+	ApprovalThreshold *float64          `json:"approval_threshold"`
+	Members           []HouseholdMember `json:"members,omitempty"`
+	Accounts          []Account         `json:"accounts,omitempty"`
+	Categories        []Category        `json:"categories,omitempty"`
+}
+
+type HouseholdMember struct {
+	UserID     int       `json:"user_id"`
+	Email      string    `json:"email"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Role       string    `json:"role"`
+	Managed    bool      `json:"managed"`
+	AccountIDs []int     `json:"account_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UpdateHouseholdRequest sets the amount above which editors' transactions
+// need the owner's approval. Leaving it out disables approvals.
+type UpdateHouseholdRequest struct {
+	ApprovalThreshold *float64 `json:"approval_threshold" binding:"omitempty,gt=0"`
+}
+
+// AddHouseholdMemberRequest adds an existing user. Viewers and limited
+// members only get the accounts listed in AccountIDs.
+type AddHouseholdMemberRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Role       string `json:"role" binding:"omitempty,oneof=editor viewer limited"`
+	AccountIDs []int  `json:"account_ids"`
+}
+
+type UpdateHouseholdMemberRequest struct {
+	Role       string `json:"role" binding:"required,oneof=editor viewer limited"`
+	AccountIDs []int  `json:"account_ids"`
+}
+
+// ManagedProfileRequest creates a login for someone without their own
+// account, such as a child, managed by the household owner.
+type ManagedProfileRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	FirstName  string `json:"first_name" binding:"required"`
+	LastName   string `json:"last_name"`
+	Role       string `json:"role" binding:"omitempty,oneof=viewer limited"`
+	AccountIDs []int  `json:"account_ids"`
+}
+
+// AllowanceRule moves Amount from the owner's FromAccountID to the member's
+// ToAccountID every week or month, starting on NextDate.
+type AllowanceRule struct {
+	ID            int       `json:"id"`
+	OwnerID       int       `json:"owner_id"`
+	MemberID      int       `json:"member_id"`
+	FromAccountID int       `json:"from_account_id"`
+	ToAccountID   int       `json:"to_account_id"`
+	CategoryID    int       `json:"category_id"`
+	Amount        float64   `json:"amount"`
+	Frequency     string    `json:"frequency"`
+	NextDate      string    `json:"next_date"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type AllowanceRuleRequest struct {
+	MemberID      int     `json:"member_id" binding:"required"`
+	FromAccountID int     `json:"from_account_id" binding:"required"`
+	ToAccountID   int     `json:"to_account_id" binding:"required,nefield=FromAccountID"`
+	CategoryID    int     `json:"category_id" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Frequency     string  `json:"frequency" binding:"required,oneof=weekly monthly"`
+	StartDate     string  `json:"start_date" binding:"required"`
+}
+
+type TransactionApproval struct {
+	ID            int        `json:"id"`
+	OwnerID       int        `json:"owner_id"`
+	RequestedBy   *int       `json:"requested_by"`
+	AccountID     int        `json:"account_id"`
+	CategoryID    int        `json:"category_id"`
+	Amount        float64    `json:"amount"`
+	Type          string     `json:"type"`
+	Description   string     `json:"description"`
+	Date          time.Time  `json:"date"`
+	Status        string     `json:"status"`
+	TransactionID *int       `json:"transaction_id"`
+	DecidedAt     *time.Time `json:"decided_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type RecurringRule struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	AccountID   int        `json:"account_id"`
+	CategoryID  int        `json:"category_id"`
+	Amount      float64    `json:"amount"`
+	Type        string     `json:"type"`
+	Description string     `json:"description"`
+	Frequency   string     `json:"frequency"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type RecurringRuleRequest struct {
+	AccountID   int     `json:"account_id" binding:"required"`
+	CategoryID  int     `json:"category_id" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Type        string  `json:"type" binding:"required,oneof=income expense"`
+	Description string  `json:"description"`
+	Frequency   string  `json:"frequency" binding:"required,oneof=weekly biweekly monthly yearly"`
+	StartDate   string  `json:"start_date" binding:"required"`
+	EndDate     *string `json:"end_date"`
+}
+
+// UpcomingTransaction is a projected transaction. Transfers leave AccountID
+// and arrive in ToAccountID; bills are card payments due on Date.
+type UpcomingTransaction struct {
+	Date        string  `json:"date"`
+	Source      string  `json:"source"`
+	SourceID    int     `json:"source_id"`
+	AccountID   int     `json:"account_id"`
+	ToAccountID *int    `json:"to_account_id,omitempty"`
+	CategoryID  *int    `json:"category_id,omitempty"`
+	Type        string  `json:"type"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+type UpcomingFeed struct {
+	From         string                `json:"from"`
+	To           string                `json:"to"`
+	Currency     string                `json:"currency"`
+	Income       float64               `json:"income"`
+	Expenses     float64               `json:"expenses"`
+	Transactions []UpcomingTransaction `json:"transactions"`
+}
+
+// SafeToSpend is what is left of the liquid balances until Until, the next
+// expected income, once scheduled outflows and unspent budgets are set aside.
+type SafeToSpend struct {
+	Currency           string               `json:"currency"`
+	Until              string               `json:"until"`
+	NextIncome         *UpcomingTransaction `json:"next_income"`
+	LiquidBalance      float64              `json:"liquid_balance"`
+	UpcomingBills      float64              `json:"upcoming_bills"`
+	ScheduledExpenses  float64              `json:"scheduled_expenses"`
+	ScheduledTransfers float64              `json:"scheduled_transfers"`
+	UnspentBudgets     float64              `json:"unspent_budgets"`
+	SafeToSpend        float64              `json:"safe_to_spend"`
+}
+
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	UserID     int                    `json:"user_id"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   *int                   `json:"entity_id"`
+	Details    map[string]interface{} `json:"details"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type TelegramLinkCode struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	BotURL    string    `json:"bot_url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type TelegramLink struct {
+	Linked   bool       `json:"linked"`
+	Username string     `json:"username,omitempty"`
+	LinkedAt *time.Time `json:"linked_at,omitempty"`
+}
+
+type NotificationChannel struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Type       string    `json:"type" db:"type"`
+	Name       string    `json:"name" db:"name"`
+	WebhookURL string    `json:"webhook_url" db:"webhook_url"`
+	Events     []string  `json:"events" db:"events"`
+	Enabled    bool      `json:"enabled" db:"enabled"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type NotificationChannelRequest struct {
+	Type       string   `json:"type" binding:"required,oneof=slack discord"`
+	Name       string   `json:"name" binding:"required"`
+	WebhookURL string   `json:"webhook_url" binding:"required,url"`
+	Events     []string `json:"events"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh" binding:"required"`
+	Auth   string `json:"auth" binding:"required"`
+}
+
+type PushSubscriptionRequest struct {
+	Endpoint string               `json:"endpoint" binding:"required,url"`
+	Keys     PushSubscriptionKeys `json:"keys" binding:"required"`
+}
+
+type PushSubscription struct {
+	ID         int        `json:"id" db:"id"`
+	Endpoint   string     `json:"endpoint" db:"endpoint"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type PushPreferencesRequest struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
+
+type SyncTombstone struct {
+	EntityType string    `json:"entity_type"`
+	EntityID   int       `json:"entity_id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+type SyncChanges struct {
+	Accounts     []Account       `json:"accounts"`
+	Categories   []Category      `json:"categories"`
+	Transactions []Transaction   `json:"transactions"`
+	Tombstones   []SyncTombstone `json:"tombstones"`
+	Token        string          `json:"token"`
+}
+
+type SyncAccount struct {
+	ClientID    string    `json:"client_id" binding:"required"`
+	ID          *int      `json:"id"`
+	Name        string    `json:"name" binding:"required"`
+	Type        string    `json:"type" binding:"required"`
+	Balance     float64   `json:"balance"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at" binding:"required"`
+}
+
+type SyncCategory struct {
+	ClientID  string    `json:"client_id" binding:"required"`
+	ID        *int      `json:"id"`
+	Name      string    `json:"name" binding:"required"`
+	Type      string    `json:"type" binding:"required"`
+	Color     string    `json:"color"`
+	Icon      string    `json:"icon"`
+	ParentID  *int      `json:"parent_id"`
+	UpdatedAt time.Time `json:"updated_at" binding:"required"`
+}
+
+type SyncTransaction struct {
+	ClientID         string    `json:"client_id" binding:"required"`
+	ID               *int      `json:"id"`
+	AccountID        int       `json:"account_id"`
+	AccountClientID  string    `json:"account_client_id"`
+	CategoryID       int       `json:"category_id"`
+	CategoryClientID string    `json:"category_client_id"`
+	Amount           float64   `json:"amount" binding:"required,gt=0"`
+	Type             string    `json:"type" binding:"required,oneof=income expense"`
+	Description      string    `json:"description"`
+	Date             time.Time `json:"date" binding:"required"`
+	Latitude         *float64  `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude        *float64  `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Place            string    `json:"place" binding:"max=255"`
+	Quantity         *float64  `json:"quantity" binding:"required_with=Unit,omitempty,gt=0"`
+	Unit             string    `json:"unit" binding:"required_with=Quantity,max=20"`
+	UpdatedAt        time.Time `json:"updated_at" binding:"required"`
+}
+
+type SyncPushRequest struct {
+	Accounts     []SyncAccount     `json:"accounts" binding:"dive"`
+	Categories   []SyncCategory    `json:"categories" binding:"dive"`
+	Transactions []SyncTransaction `json:"transactions" binding:"dive"`
+}
+
+type SyncResult struct {
+	Entity   string      `json:"entity"`
+	ClientID string      `json:"client_id"`
+	ID       int         `json:"id,omitempty"`
+	Status   string      `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Server   interface{} `json:"server,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncResult `json:"results"`
+}
+
+type APIToken struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	TokenPrefix string     `json:"token_prefix" db:"token_prefix"`
+	Scope       string     `json:"scope" db:"scope"`
+	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PlanLimits caps what a user may create; a nil limit is unlimited.
+// TransactionsPerMonth counts transactions created in the calendar month
+// (UTC) and StorageBytes the user's stored files such as avatars.
+type PlanLimits struct {
+	Accounts             *int64 `json:"accounts" binding:"omitempty,min=0"`
+	TransactionsPerMonth *int64 `json:"transactions_per_month" binding:"omitempty,min=0"`
+	StorageBytes         *int64 `json:"storage_bytes" binding:"omitempty,min=0"`
+	Webhooks             *int64 `json:"webhooks" binding:"omitempty,min=0"`
+}
+
+// Plan is a set of limits. Plans with a StripePriceID can be bought through
+// billing.
+type Plan struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Limits        PlanLimits `json:"limits"`
+	StripePriceID string     `json:"stripe_price_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type PlanRequest struct {
+	Name          string     `json:"name" binding:"required,max=100"`
+	Limits        PlanLimits `json:"limits"`
+	StripePriceID string     `json:"stripe_price_id" binding:"max=100"`
+}
+
+// UserPlanRequest assigns a plan to a user, or the default plan when PlanID
+// is empty, and overrides single limits of it.
+type UserPlanRequest struct {
+	PlanID string     `json:"plan_id" binding:"max=50"`
+	Limits PlanLimits `json:"limits"`
+}
+
+type PlanUsage struct {
+	Accounts             int64 `json:"accounts"`
+	TransactionsPerMonth int64 `json:"transactions_per_month"`
+	StorageBytes         int64 `json:"storage_bytes"`
+	Webhooks             int64 `json:"webhooks"`
+}
+
+// LimitsStatus is the plan in effect for a user, its limits after the
+// user's overrides and how much of them is used.
+type LimitsStatus struct {
+	Plan   string     `json:"plan,omitempty"`
+	Limits PlanLimits `json:"limits"`
+	Usage  PlanUsage  `json:"usage"`
+}
+
+// BillingStatus is a user's subscription as last reported by Stripe, the
+// limits in effect and the plans they can subscribe to. GraceUntil is set
+// while a failed payment is being retried; the plan ends after it.
+type BillingStatus struct {
+	Enabled           bool         `json:"enabled"`
+	Status            string       `json:"status,omitempty"`
+	Plan              string       `json:"plan,omitempty"`
+	CurrentPeriodEnd  *time.Time   `json:"current_period_end,omitempty"`
+	CancelAtPeriodEnd bool         `json:"cancel_at_period_end"`
+	GraceUntil        *time.Time   `json:"grace_until,omitempty"`
+	Limits            LimitsStatus `json:"limits"`
+	Plans             []Plan       `json:"plans"`
+}
+
+type BillingCheckoutRequest struct {
+	PlanID string `json:"plan_id" binding:"required,max=50"`
+}
+
+// BillingSession is a Stripe Checkout or billing portal page to send the
+// user to.
+type BillingSession struct {
+	URL string `json:"url"`
+}
+
+// Workspace is a set of shared books. Role is the caller's role in it;
+// Members are only listed for a single workspace.
+type Workspace struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Role        string            `json:"role"`
+	MemberCount int               `json:"member_count"`
+	Members     []WorkspaceMember `json:"members,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkspaceMembership is what the workspace middleware needs to serve a
+// member's request from the workspace's books.
+type WorkspaceMembership struct {
+	WorkspaceID  int
+	LedgerUserID int
+	Role         string
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type WorkspaceMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"omitempty,oneof=admin member viewer"`
+}
+
+type WorkspaceRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member viewer"`
+}
+
+// AdvisorGrant gives an advisor read-only access to some of the owner's
+// accounts and, with Analytics, to the owner's analytics. The advisor reads
+// them with delegated tokens issued for the grant.
+type AdvisorGrant struct {
+	ID           int        `json:"id"`
+	OwnerID      int        `json:"owner_id"`
+	OwnerEmail   string     `json:"owner_email"`
+	AdvisorID    int        `json:"advisor_id"`
+	AdvisorEmail string     `json:"advisor_email"`
+	AccountIDs   []int      `json:"account_ids"`
+	Analytics    bool       `json:"analytics"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type AdvisorGrantRequest struct {
+	Email         string `json:"email" binding:"required,email"`
+	AccountIDs    []int  `json:"account_ids" binding:"required,min=1,dive,gt=0"`
+	Analytics     bool   `json:"analytics"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1"`
+}
+
+// DelegatedToken is a bearer token the advisor of Grant uses to read the
+// owner's data until ExpiresAt.
+type DelegatedToken struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Grant     AdvisorGrant `json:"grant"`
+}
+
+type CreateAPITokenRequest struct {
+	Name          string `json:"name" binding:"required,max=100"`
+	Scope         string `json:"scope" binding:"omitempty,oneof=read read_write"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1"`
+}
+
+type CreateAPITokenResponse struct {
+	Token    string   `json:"token"`
+	APIToken APIToken `json:"api_token"`
+}
+
+type IDTokenExchangeRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+}
+
+type Session struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Current    bool       `json:"current"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type PasswordStrengthRequest struct {
+	Password  string `json:"password" binding:"required"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type PageInfo struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"next_offset"`
+}
+
+type Page struct {
+	Data       interface{} `json:"data"`
+	Pagination PageInfo    `json:"pagination"`
+}
+
+type AccountV2 struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	BalanceCents int64     `json:"balance_cents"`
+	Currency     string    `json:"currency"`
+	Description  string    `json:"description"`
+	Scope        string    `json:"scope"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	ArchivedAt          *time.Time `json:"archived_at,omitempty"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+	ClosingBalanceCents *int64     `json:"closing_balance_cents,omitempty"`
+
+	CreditLimitCents     *int64   `json:"credit_limit_cents,omitempty"`
+	AvailableCreditCents *int64   `json:"available_credit_cents,omitempty"`
+	InterestRate         *float64 `json:"interest_rate,omitempty"`
+	StatementClosingDay  *int     `json:"statement_closing_day,omitempty"`
+	PaymentDueDay        *int     `json:"payment_due_day,omitempty"`
+}
+
+type CategoryV2 struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Color     string    `json:"color"`
+	Icon      string    `json:"icon"`
+	ParentID  *int      `json:"parent_id"`
+	GroupID   *int      `json:"group_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TransactionV2 struct {
+	ID          int       `json:"id"`
+	AccountID   int       `json:"account_id"`
+	CategoryID  int       `json:"category_id"`
+	AmountCents int64     `json:"amount_cents"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+	Scope       string    `json:"scope"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
+	Place       string    `json:"place,omitempty"`
+	Quantity    *float64  `json:"quantity,omitempty"`
+	Unit        string    `json:"unit,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Category *TransactionCategoryRef `json:"category,omitempty"`
+	Account  *TransactionAccountRef  `json:"account,omitempty"`
+
+	RunningBalanceCents *int64 `json:"running_balance_cents,omitempty"`
+}
+
+type AccountV2Request struct {
+	Name             string   `json:"name" binding:"required"`
+	Type             string   `json:"type" binding:"required,oneof=checking savings credit_card cash investment loan"`
+	BalanceCents     int64    `json:"balance_cents"`
+	Currency         string   `json:"currency" binding:"required,len=3"`
+	Description      string   `json:"description"`
+	CreditLimitCents *int64   `json:"credit_limit_cents"`
+	InterestRate     *float64 `json:"interest_rate"`
+	Scope            string   `json:"scope" binding:"omitempty,oneof=personal business"`
+
+	StatementClosingDay *int `json:"statement_closing_day"`
+	PaymentDueDay       *int `json:"payment_due_day"`
+}
+
+type TransactionV2Request struct {
+	AccountID   int    `json:"account_id" binding:"required"`
+	CategoryID  int    `json:"category_id" binding:"required"`
+	AmountCents int64  `json:"amount_cents" binding:"required,gt=0"`
+	Type        string `json:"type" binding:"required,oneof=income expense"`
+	Description string `json:"description"`
+	// Date defaults to now on creation; an update without it keeps the
+	// stored date.
+	Date *time.Time `json:"date"`
+	// Scope defaults to the account's scope.
+	Scope string `json:"scope" binding:"omitempty,oneof=personal business"`
+	// Latitude and Longitude are sent together. An update without them
+	// keeps the stored location.
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180"`
+	Place     string   `json:"place" binding:"max=255"`
+	// Quantity and Unit, such as 40 and "l", are sent together and kept
+	// like the location.
+	Quantity *float64 `json:"quantity" binding:"required_with=Unit,omitempty,gt=0"`
+	Unit     string   `json:"unit" binding:"required_with=Quantity,max=20"`
+}
+
+type Job struct {
+	ID          int             `json:"id" db:"id"`
+	UserID      int             `json:"-" db:"user_id"`
+	Type        string          `json:"type" db:"type"`
+	Status      string          `json:"status" db:"status"`
+	Payload     json.RawMessage `json:"-" db:"payload"`
+	Progress    json.RawMessage `json:"progress,omitempty" db:"progress"`
+	Result      json.RawMessage `json:"result,omitempty" db:"result"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty" db:"last_error"`
+	RunAt       time.Time       `json:"run_at" db:"run_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	TraceParent string          `json:"-" db:"trace_parent"`
+}
+
+// AutomationRule runs its actions when its trigger fires on a transaction
+// matching its conditions: when the transaction is created, when it takes
+// its category over budget or when it takes its account below
+// Conditions.BalanceBelow.
+type AutomationRule struct {
+	ID              int                  `json:"id"`
+	UserID          int                  `json:"user_id"`
+	Name            string               `json:"name"`
+	Trigger         string               `json:"trigger"`
+	Conditions      AutomationConditions `json:"conditions"`
+	Actions         []AutomationAction   `json:"actions"`
+	WebhookURL      string               `json:"webhook_url,omitempty"`
+	Enabled         bool                 `json:"enabled"`
+	LastTriggeredAt *time.Time           `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// AutomationConditions narrow down the transactions a rule fires on. Unset
+// conditions match every transaction; the description matches by
+// case-insensitive substring.
+type AutomationConditions struct {
+	DescriptionContains string   `json:"description_contains,omitempty" binding:"max=255"`
+	AccountID           *int     `json:"account_id,omitempty"`
+	CategoryID          *int     `json:"category_id,omitempty"`
+	Type                string   `json:"type,omitempty" binding:"omitempty,oneof=income expense"`
+	MinAmount           *float64 `json:"min_amount,omitempty"`
+	MaxAmount           *float64 `json:"max_amount,omitempty"`
+	BalanceBelow        *float64 `json:"balance_below,omitempty"`
+}
+
+// AutomationAction is one step of a rule. CategoryID is used by
+// set_category, AccountID by move_to_account, Tag by add_tag and Title and
+// Message by notify; webhook posts to the rule's webhook_url. contribute
+// saves Amount, or Percent of the transaction, into the savings account
+// AccountID, recorded on CategoryID.
+type AutomationAction struct {
+	Type       string   `json:"type" binding:"required,oneof=set_category add_tag move_to_account notify webhook contribute"`
+	CategoryID *int     `json:"category_id,omitempty"`
+	AccountID  *int     `json:"account_id,omitempty"`
+	Tag        string   `json:"tag,omitempty" binding:"max=50"`
+	Title      string   `json:"title,omitempty" binding:"max=100"`
+	Message    string   `json:"message,omitempty" binding:"max=500"`
+	Amount     *float64 `json:"amount,omitempty" binding:"omitempty,gt=0"`
+	Percent    *float64 `json:"percent,omitempty" binding:"omitempty,gt=0,max=100"`
+}
+
+type AutomationRuleRequest struct {
+	Name       string               `json:"name" binding:"required,max=100"`
+	Trigger    string               `json:"trigger" binding:"required,oneof=transaction_created budget_exceeded balance_below"`
+	Conditions AutomationConditions `json:"conditions"`
+	Actions    []AutomationAction   `json:"actions" binding:"required,min=1,max=10,dive"`
+	WebhookURL string               `json:"webhook_url" binding:"omitempty,url"`
+	Enabled    *bool                `json:"enabled"`
+}
+
+// AutomationSimulationRequest replays a rule over the transactions of the
+// last Days days, 30 by default, without running its actions.
+type AutomationSimulationRequest struct {
+	Rule AutomationRuleRequest `json:"rule" binding:"required"`
+	Days int                   `json:"days" binding:"omitempty,min=1,max=365"`
+}
+
+type AutomationSimulation struct {
+	From      string            `json:"from"`
+	To        string            `json:"to"`
+	Count     int               `json:"count"`
+	Truncated bool              `json:"truncated"`
+	Matches   []AutomationMatch `json:"matches"`
+}
+
+// AutomationMatch is a transaction a simulated rule fired on. Balance is the
+// account balance after it for balance_below rules, Spent the category's
+// spending in the month after it for budget_exceeded rules.
+type AutomationMatch struct {
+	TransactionID int      `json:"transaction_id"`
+	Date          string   `json:"date"`
+	Description   string   `json:"description"`
+	Type          string   `json:"type"`
+	Amount        float64  `json:"amount"`
+	AccountID     int      `json:"account_id"`
+	CategoryID    int      `json:"category_id"`
+	Balance       *float64 `json:"balance,omitempty"`
+	Spent         *float64 `json:"spent,omitempty"`
+}
+
+// PersonalInflation follows what the user pays per unit for expenses
+// recorded with a quantity, in series by tag, merchant or category and unit.
+// InflationPercent averages the price change of the series, weighted by
+// what was spent on each.
+type PersonalInflation struct {
+	PeriodStart      string        `json:"period_start,omitempty"`
+	PeriodEnd        string        `json:"period_end,omitempty"`
+	Currency         string        `json:"currency"`
+	By               string        `json:"by"`
+	InflationPercent *float64      `json:"inflation_percent"`
+	Series           []PriceSeries `json:"series"`
+}
+
+// PriceSeries is the monthly price per unit of one kind of purchase.
+// ChangePercent compares the last month with the first and
+// AnnualizedPercent spreads it over a year; both need two months.
+type PriceSeries struct {
+	Label             string           `json:"label"`
+	Unit              string           `json:"unit"`
+	Quantity          float64          `json:"quantity"`
+	Amount            float64          `json:"amount"`
+	FirstUnitPrice    float64          `json:"first_unit_price"`
+	LastUnitPrice     float64          `json:"last_unit_price"`
+	ChangePercent     *float64         `json:"change_percent"`
+	AnnualizedPercent *float64         `json:"annualized_percent"`
+	Months            []UnitPricePoint `json:"months"`
+}
+
+type UnitPricePoint struct {
+	Month     string  `json:"month"`
+	Quantity  float64 `json:"quantity"`
+	Amount    float64 `json:"amount"`
+	UnitPrice float64 `json:"unit_price"`
+	Count     int     `json:"count"`
+}
+
+// PlannedPurchase is an item on the wishlist. It counts as purchased once
+// linked to the expense that paid for it; ActualPrice and PurchasedOn come
+// from that transaction. Affordable is only set on planned items of a
+// Wishlist.
+type PlannedPurchase struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	Name          string    `json:"name"`
+	Notes         string    `json:"notes"`
+	URL           string    `json:"url"`
+	TargetPrice   float64   `json:"target_price"`
+	Priority      string    `json:"priority"`
+	CategoryID    *int      `json:"category_id"`
+	TargetDate    *string   `json:"target_date"`
+	Status        string    `json:"status"`
+	TransactionID *int      `json:"transaction_id"`
+	ActualPrice   *float64  `json:"actual_price,omitempty"`
+	PurchasedOn   *string   `json:"purchased_on,omitempty"`
+	Affordable    *bool     `json:"affordable,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type PlannedPurchaseRequest struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Notes       string  `json:"notes" binding:"max=500"`
+	URL         string  `json:"url" binding:"omitempty,url,max=500"`
+	TargetPrice float64 `json:"target_price" binding:"required,gt=0"`
+	Priority    string  `json:"priority" binding:"omitempty,oneof=low medium high"`
+	CategoryID  *int    `json:"category_id"`
+	TargetDate  *string `json:"target_date"`
+}
+
+type PurchaseRequest struct {
+	TransactionID int `json:"transaction_id" binding:"required"`
+}
+
+// Wishlist lists planned purchases by priority, then target date. Walking
+// the planned items in that order, an item is affordable when SafeToSpend
+// covers it together with every planned item before it.
+type Wishlist struct {
+	Currency    string            `json:"currency"`
+	SafeToSpend float64           `json:"safe_to_spend"`
+	Planned     float64           `json:"planned"`
+	Items       []PlannedPurchase `json:"items"`
+}
+
+// WishlistAnalytics compares what purchases from the wishlist were expected
+// to cost with what they did, for the purchases made between PeriodStart
+// and PeriodEnd. Difference is actual less planned.
+type WishlistAnalytics struct {
+	PeriodStart       string            `json:"period_start,omitempty"`
+	PeriodEnd         string            `json:"period_end,omitempty"`
+	Currency          string            `json:"currency"`
+	Count             int               `json:"count"`
+	Planned           float64           `json:"planned"`
+	Actual            float64           `json:"actual"`
+	Difference        float64           `json:"difference"`
+	DifferencePercent float64           `json:"difference_percent"`
+	OverTarget        int               `json:"over_target"`
+	UnderTarget       int               `json:"under_target"`
+	Purchases         []PlannedVsActual `json:"purchases"`
+}
+
+type PlannedVsActual struct {
+	PlannedPurchaseID int     `json:"planned_purchase_id"`
+	Name              string  `json:"name"`
+	TransactionID     int     `json:"transaction_id"`
+	PurchasedOn       string  `json:"purchased_on"`
+	Planned           float64 `json:"planned"`
+	Actual            float64 `json:"actual"`
+	Difference        float64 `json:"difference"`
+	DifferencePercent float64 `json:"difference_percent"`
+}
+
+// LocationSpending clusters expenses by where they were made for map views.
+// Expenses without a location are only counted in Unlocated. Total and
+// Count cover the listed locations, the largest ones when Truncated.
+type LocationSpending struct {
+	PeriodStart string            `json:"period_start,omitempty"`
+	PeriodEnd   string            `json:"period_end,omitempty"`
+	Currency    string            `json:"currency"`
+	Precision   int               `json:"precision"`
+	Total       float64           `json:"total"`
+	Count       int               `json:"count"`
+	Unlocated   float64           `json:"unlocated"`
+	Truncated   bool              `json:"truncated"`
+	Locations   []LocationCluster `json:"locations"`
+}
+
+// LocationCluster sums the expenses whose coordinates round to the same
+// Precision decimal places. Latitude and Longitude are their centre and
+// Place the place name given most often.
+type LocationCluster struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Place     string  `json:"place,omitempty"`
+	Amount    float64 `json:"amount"`
+	Count     int     `json:"count"`
+}
+
+// BankFees breaks down what a calendar year cost in bank fees, overdraft
+// charges and interest, told apart by description or category name.
+type BankFees struct {
+	Year         int                  `json:"year"`
+	Currency     string               `json:"currency"`
+	Total        float64              `json:"total"`
+	Count        int                  `json:"count"`
+	Kinds        []BankFeeKind        `json:"kinds"`
+	Months       []BankFeeMonth       `json:"months"`
+	Transactions []BankFeeTransaction `json:"transactions"`
+}
+
+type BankFeeKind struct {
+	Kind   string  `json:"kind"`
+	Amount float64 `json:"amount"`
+	Count  int     `json:"count"`
+}
+
+type BankFeeMonth struct {
+	Month  string  `json:"month"`
+	Amount float64 `json:"amount"`
+}
+
+type BankFeeTransaction struct {
+	TransactionID int     `json:"transaction_id"`
+	Date          string  `json:"date"`
+	Description   string  `json:"description"`
+	CategoryName  string  `json:"category_name"`
+	Kind          string  `json:"kind"`
+	Amount        float64 `json:"amount"`
+}
+
+// Insight is a finding of an insight analyzer. Key tells findings of the
+// same type apart, so a dismissed or snoozed insight stays that way while
+// the analyzer keeps reporting it.
+type Insight struct {
+	ID           int             `json:"id" db:"id"`
+	UserID       int             `json:"-" db:"user_id"`
+	Type         string          `json:"type" db:"type"`
+	Key          string          `json:"key" db:"key"`
+	Severity     string          `json:"severity" db:"severity"`
+	Title        string          `json:"title" db:"title"`
+	Message      string          `json:"message" db:"message"`
+	Data         json.RawMessage `json:"data,omitempty" db:"data"`
+	SnoozedUntil *time.Time      `json:"snoozed_until,omitempty" db:"snoozed_until"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+type InsightSnoozeRequest struct {
+	Days int `json:"days" binding:"required,min=1,max=365"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportProgress struct {
+	RowsTotal     int              `json:"rows_total"`
+	RowsProcessed int              `json:"rows_processed"`
+	Imported      int              `json:"imported"`
+	Failed        int              `json:"failed"`
+	Errors        []ImportRowError `json:"errors,omitempty"`
+}
+
+// StagedImport is a parsed import waiting for review. Rows are only written
+// to transactions when the import is committed; duplicates of existing
+// transactions and rows that failed to parse start out excluded.
+type StagedImport struct {
+	ID          int               `json:"id"`
+	UserID      int               `json:"-"`
+	AccountID   int               `json:"account_id"`
+	JobID       *int              `json:"job_id,omitempty"`
+	FileName    string            `json:"file_name"`
+	Status      string            `json:"status"`
+	RowsTotal   int               `json:"rows_total"`
+	Duplicates  int               `json:"duplicates"`
+	Failed      int               `json:"failed"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CommittedAt *time.Time        `json:"committed_at,omitempty"`
+	Rows        []StagedImportRow `json:"rows,omitempty"`
+}
+
+type StagedImportRow struct {
+	ID                  int        `json:"id"`
+	Row                 int        `json:"row"`
+	Date                *time.Time `json:"date,omitempty"`
+	Description         string     `json:"description"`
+	Amount              float64    `json:"amount"`
+	Type                string     `json:"type"`
+	CategoryName        string     `json:"category_name"`
+	SuggestedCategoryID *int       `json:"suggested_category_id"`
+	DuplicateOf         *int       `json:"duplicate_of"`
+	Error               string     `json:"error,omitempty"`
+	Include             bool       `json:"include"`
+	TransactionID       *int       `json:"transaction_id,omitempty"`
+}
+
+// ImportCommitRequest edits staged rows before they are written; fields
+// left out keep their staged values.
+type ImportCommitRequest struct {
+	Rows []ImportRowEdit `json:"rows" binding:"dive"`
+}
+
+type ImportRowEdit struct {
+	ID          int      `json:"id" binding:"required"`
+	Include     *bool    `json:"include"`
+	CategoryID  *int     `json:"category_id"`
+	Description *string  `json:"description" binding:"omitempty,min=1,max=255"`
+	Date        *string  `json:"date"`
+	Amount      *float64 `json:"amount" binding:"omitempty,gt=0"`
+	Type        *string  `json:"type" binding:"omitempty,oneof=income expense"`
+}
+
+// ImportProfile maps a bank's CSV export onto transactions. Columns maps the
+// fields an import reads to the bank's headers; DateFormat is written with
+// YYYY, YY, MM and DD. Built-in profiles are identified by Key and have no ID.
+type ImportProfile struct {
+	ID               int               `json:"id,omitempty"`
+	UserID           int               `json:"-"`
+	Key              string            `json:"key,omitempty"`
+	Name             string            `json:"name"`
+	Builtin          bool              `json:"builtin"`
+	Columns          map[string]string `json:"columns"`
+	Delimiter        string            `json:"delimiter"`
+	DateFormat       string            `json:"date_format"`
+	DecimalSeparator string            `json:"decimal_separator"`
+	SignConvention   string            `json:"sign_convention"`
+	AccountID        *int              `json:"account_id"`
+	CreatedAt        *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt        *time.Time        `json:"updated_at,omitempty"`
+}
+
+type ImportProfileRequest struct {
+	Name             string            `json:"name" binding:"required,max=100"`
+	Columns          map[string]string `json:"columns" binding:"required"`
+	Delimiter        string            `json:"delimiter"`
+	DateFormat       string            `json:"date_format" binding:"max=20"`
+	DecimalSeparator string            `json:"decimal_separator"`
+	SignConvention   string            `json:"sign_convention" binding:"omitempty,oneof=signed inverted debit_credit"`
+	AccountID        *int              `json:"account_id"`
+}
+
+type ExportRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Scramble  bool   `json:"scramble"`
+	Scope     string `json:"scope" binding:"omitempty,oneof=personal business"`
+}
+
+// RecategorizeRequest moves the transactions matching every given filter to
+// CategoryID. Only transactions of the target category's type match. Without
+// Apply the matches are only previewed; ExpectedCount makes applying fail if
+// the matches changed since the preview.
+type RecategorizeRequest struct {
+	Description    string `json:"description"`
+	PayeeID        *int   `json:"payee_id"`
+	FromCategoryID *int   `json:"from_category_id"`
+	AccountID      *int   `json:"account_id"`
+	StartDate      string `json:"start_date"`
+	EndDate        string `json:"end_date"`
+	CategoryID     int    `json:"category_id" binding:"required"`
+	Apply          bool   `json:"apply"`
+	ExpectedCount  *int   `json:"expected_count"`
+}
+
+type Recategorization struct {
+	Matched int           `json:"matched"`
+	Updated int           `json:"updated"`
+	Applied bool          `json:"applied"`
+	Sample  []Transaction `json:"sample"`
+}
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Trigger   string    `json:"trigger"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RestoreBackupRequest repeats the backup name to confirm that the current
+// database may be overwritten.
+type RestoreBackupRequest struct {
+	Confirm string `json:"confirm" binding:"required"`
+}
+
+type JobFile struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"-" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Data        []byte    `json:"-" db:"data"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type ComponentHealth struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Version   string `json:"version,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status     string            `json:"status"`
+	Version    string            `json:"version"`
+	GoVersion  string            `json:"go_version"`
+	Components []ComponentHealth `json:"components"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
@@ -0,0 +1,163 @@
+package nlquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+type LLMParser struct {
+	Provider Provider
+	Fallback Parser
+}
+
+type llmAnswer struct {
+	Metric    string `json:"metric"`
+	Category  string `json:"category"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Period    string `json:"period"`
+}
+
+func (p *LLMParser) Parse(ctx context.Context, question string, now time.Time, categories []string) (*Interpretation, error) {
+	interpretation, err := p.parseWithProvider(ctx, question, now, categories)
+	if err != nil {
+		log.Printf("LLM provider %s failed, using rule-based fallback: %v", p.Provider.Name(), err)
+		return p.Fallback.Parse(ctx, question, now, categories)
+	}
+	return interpretation, nil
+}
+
+func (p *LLMParser) parseWithProvider(ctx context.Context, question string, now time.Time, categories []string) (*Interpretation, error) {
+	prompt := fmt.Sprintf(`Translate the personal finance question into JSON with keys:
+"metric" (one of "spend", "income", "net", "count"), "category" (one of %q or empty),
+"start_date" and "end_date" (YYYY-MM-DD, end date exclusive) and "period" (short label).
+Today is %s. Respond with JSON only.
+
+Question: %s`, categories, now.Format("2006-01-02"), question)
+
+	completion, err := p.Provider.Complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	completion = strings.TrimSpace(completion)
+	completion = strings.TrimPrefix(completion, "```json")
+	completion = strings.Trim(completion, "` \n")
+
+	var answer llmAnswer
+	if err := json.Unmarshal([]byte(completion), &answer); err != nil {
+		return nil, fmt.Errorf("invalid provider response: %w", err)
+	}
+
+	switch answer.Metric {
+	case Metrics.Spend, Metrics.Income, Metrics.Net, Metrics.Count:
+	default:
+		return nil, fmt.Errorf("unsupported metric: %q", answer.Metric)
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", answer.StartDate, now.Location())
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.ParseInLocation("2006-01-02", answer.EndDate, now.Location())
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("invalid date range %s - %s", answer.StartDate, answer.EndDate)
+	}
+
+	category := ""
+	for _, name := range categories {
+		if strings.EqualFold(name, answer.Category) {
+			category = name
+		}
+	}
+
+	return &Interpretation{
+		Metric:    answer.Metric,
+		Category:  category,
+		StartDate: start,
+		EndDate:   end,
+		Period:    answer.Period,
+		Source:    p.Provider.Name(),
+	}, nil
+}
+
+type OpenAIProvider struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	Client  *http.Client
+}
+
+func NewProviderFromConfig(cfg config.LLMConfig) Provider {
+	if cfg.APIKey == "" {
+		return nil
+	}
+
+	return &OpenAIProvider{
+		BaseURL: strings.TrimSuffix(cfg.APIURL, "/"),
+		APIKey:  cfg.APIKey,
+		Model:   cfg.Model,
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return "llm:" + p.Model
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":       p.Model,
+		"temperature": 0,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("provider returned no choices")
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,50 @@
+package nlquery
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type MetricTypes struct {
+	Spend  string
+	Income string
+	Net    string
+	Count  string
+}
+
+var Metrics = MetricTypes{
+	Spend:  "spend",
+	Income: "income",
+	Net:    "net",
+	Count:  "count",
+}
+
+var ErrNotUnderstood = errors.New("question not understood")
+
+// Interpretation is what a question asks for over [StartDate, EndDate).
+type Interpretation struct {
+	Metric    string    `json:"metric"`
+	Category  string    `json:"category,omitempty"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Period    string    `json:"period"`
+	Source    string    `json:"source"`
+}
+
+type Parser interface {
+	Parse(ctx context.Context, question string, now time.Time, categories []string) (*Interpretation, error)
+}
+
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+func NewParser(provider Provider) Parser {
+	rules := &RuleParser{}
+	if provider == nil {
+		return rules
+	}
+	return &LLMParser{Provider: provider, Fallback: rules}
+}
@@ -0,0 +1,141 @@
+package nlquery
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RuleParser struct{}
+
+var lastNDaysPattern = regexp.MustCompile(`(?:last|past)\s+(\d+)\s+days?`)
+
+func (p *RuleParser) Parse(ctx context.Context, question string, now time.Time, categories []string) (*Interpretation, error) {
+	q := strings.ToLower(strings.TrimSpace(question))
+	if q == "" {
+		return nil, ErrNotUnderstood
+	}
+
+	metric, asksMetric := detectMetric(q)
+	category := detectCategory(q, categories)
+	start, end, period, namesPeriod := detectPeriod(q, now)
+	// A question naming neither what to sum nor when is not one the rules
+	// understand, rather than a request for this month's spending.
+	if !asksMetric && category == "" && !namesPeriod {
+		return nil, ErrNotUnderstood
+	}
+
+	return &Interpretation{
+		Metric:    metric,
+		Category:  category,
+		StartDate: start,
+		EndDate:   end,
+		Period:    period,
+		Source:    "rules",
+	}, nil
+}
+
+// detectMetric returns what the question asks to sum and whether it said so;
+// without a hint the metric is spending.
+func detectMetric(q string) (string, bool) {
+	switch {
+	case strings.Contains(q, "how many"), strings.Contains(q, "number of"):
+		return Metrics.Count, true
+	case containsAny(q, "net", "save", "saved", "savings", "left over"):
+		return Metrics.Net, true
+	case containsAny(q, "earn", "earned", "income", "made", "receive", "received", "salary"):
+		return Metrics.Income, true
+	case containsAny(q, "spend", "spent", "spending", "expense", "expenses", "cost", "paid", "pay", "buy", "bought"):
+		return Metrics.Spend, true
+	default:
+		return Metrics.Spend, false
+	}
+}
+
+func detectCategory(q string, categories []string) string {
+	best := ""
+	for _, category := range categories {
+		name := strings.ToLower(strings.TrimSpace(category))
+		if name == "" {
+			continue
+		}
+		if strings.Contains(q, name) || (len(name) > 3 && strings.Contains(q, strings.TrimSuffix(name, "s"))) {
+			if len(category) > len(best) {
+				best = category
+			}
+		}
+	}
+	return best
+}
+
+// detectPeriod returns the range the question asks about and whether it named
+// one; without a hint the range is this month.
+func detectPeriod(q string, now time.Time) (time.Time, time.Time, string, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	yearStart := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+
+	weekday := int(today.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	weekStart := today.AddDate(0, 0, -(weekday - 1))
+
+	if match := lastNDaysPattern.FindStringSubmatch(q); match != nil {
+		days, _ := strconv.Atoi(match[1])
+		return today.AddDate(0, 0, -days+1), today.AddDate(0, 0, 1), "last_" + match[1] + "_days", true
+	}
+
+	switch {
+	case strings.Contains(q, "yesterday"):
+		return today.AddDate(0, 0, -1), today, "yesterday", true
+	case strings.Contains(q, "today"):
+		return today, today.AddDate(0, 0, 1), "today", true
+	case strings.Contains(q, "last week"):
+		return weekStart.AddDate(0, 0, -7), weekStart, "last_week", true
+	case strings.Contains(q, "this week"):
+		return weekStart, weekStart.AddDate(0, 0, 7), "this_week", true
+	case strings.Contains(q, "last month"):
+		return monthStart.AddDate(0, -1, 0), monthStart, "last_month", true
+	case strings.Contains(q, "this month"):
+		return monthStart, monthStart.AddDate(0, 1, 0), "this_month", true
+	case strings.Contains(q, "last year"):
+		return yearStart.AddDate(-1, 0, 0), yearStart, "last_year", true
+	case strings.Contains(q, "this year"):
+		return yearStart, yearStart.AddDate(1, 0, 0), "this_year", true
+	}
+
+	for month := time.January; month <= time.December; month++ {
+		if containsWord(q, strings.ToLower(month.String())) {
+			start := time.Date(now.Year(), month, 1, 0, 0, 0, 0, now.Location())
+			if start.After(now) {
+				start = start.AddDate(-1, 0, 0)
+			}
+			return start, start.AddDate(0, 1, 0), start.Format("2006-01"), true
+		}
+	}
+
+	return monthStart, monthStart.AddDate(0, 1, 0), "this_month", false
+}
+
+func containsAny(q string, words ...string) bool {
+	for _, word := range words {
+		if containsWord(q, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWord(q, word string) bool {
+	for _, field := range strings.FieldsFunc(q, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if field == word {
+			return true
+		}
+	}
+	return strings.Contains(word, " ") && strings.Contains(q, word)
+}
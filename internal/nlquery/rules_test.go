@@ -0,0 +1,53 @@
+package nlquery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRuleParser(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	categories := []string{"Groceries", "Dining Out", "Salary"}
+
+	tests := []struct {
+		question string
+		metric   string
+		category string
+		period   string
+		start    string
+		end      string
+	}{
+		{"How much did I spend on groceries last month?", Metrics.Spend, "Groceries", "last_month", "2026-09-01", "2026-10-01"},
+		{"what did I earn this year", Metrics.Income, "", "this_year", "2026-01-01", "2027-01-01"},
+		{"How many dining out transactions in the past 7 days", Metrics.Count, "Dining Out", "last_7_days", "2026-10-10", "2026-10-17"},
+		{"how much did I save in march", Metrics.Net, "", "2026-03", "2026-03-01", "2026-04-01"},
+		{"spending in december", Metrics.Spend, "", "2025-12", "2025-12-01", "2026-01-01"},
+		{"groceries", Metrics.Spend, "Groceries", "this_month", "2026-10-01", "2026-11-01"},
+		{"yesterday", Metrics.Spend, "", "yesterday", "2026-10-15", "2026-10-16"},
+	}
+
+	parser := &RuleParser{}
+	for _, tt := range tests {
+		got, err := parser.Parse(context.Background(), tt.question, now, categories)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.question, err)
+			continue
+		}
+		if got.Metric != tt.metric || got.Category != tt.category || got.Period != tt.period ||
+			got.StartDate.Format("2006-01-02") != tt.start || got.EndDate.Format("2006-01-02") != tt.end {
+			t.Errorf("Parse(%q) = %s %q %s [%s, %s), want %s %q %s [%s, %s)", tt.question,
+				got.Metric, got.Category, got.Period, got.StartDate.Format("2006-01-02"), got.EndDate.Format("2006-01-02"),
+				tt.metric, tt.category, tt.period, tt.start, tt.end)
+		}
+	}
+}
+
+func TestRuleParserNotUnderstood(t *testing.T) {
+	parser := &RuleParser{}
+	for _, question := range []string{"", "   ", "asdf qwerty", "what is the meaning of life", "hello there"} {
+		if _, err := parser.Parse(context.Background(), question, time.Now(), []string{"Groceries"}); err != ErrNotUnderstood {
+			t.Errorf("Parse(%q) = %v, want ErrNotUnderstood", question, err)
+		}
+	}
+}
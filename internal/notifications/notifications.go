@@ -0,0 +1,136 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type NotificationTypes struct {
+	BudgetWarning    string
+	BudgetExceeded   string
+	LargeTransaction string
+	BillReminder     string
+	AnomalyAlert     string
+	SecurityAlert    string
+	JobFinished      string
+	CreditUsage      string
+	PayeeWarning     string
+	PayeeExceeded    string
+	ApprovalRequest  string
+	ApprovalDecision string
+	AllowancePaid    string
+	IOUActivity      string
+	EmergencyFundLow string
+	PriceIncrease    string
+	Automation       string
+	Test             string
+}
+
+var Types = NotificationTypes{
+	BudgetWarning:    "budget_warning",
+	BudgetExceeded:   "budget_exceeded",
+	LargeTransaction: "large_transaction",
+	BillReminder:     "bill_reminder",
+	AnomalyAlert:     "anomaly_alert",
+	SecurityAlert:    "security_alert",
+	JobFinished:      "job_finished",
+	CreditUsage:      "credit_utilization",
+	PayeeWarning:     "payee_limit_warning",
+	PayeeExceeded:    "payee_limit_exceeded",
+	ApprovalRequest:  "approval_requested",
+	ApprovalDecision: "approval_decided",
+	AllowancePaid:    "allowance_paid",
+	IOUActivity:      "iou_activity",
+	EmergencyFundLow: "emergency_fund_low",
+	PriceIncrease:    "subscription_price_increase",
+	Automation:       "automation",
+	Test:             "test",
+}
+
+var PushTypes = []string{
+	Types.BudgetWarning,
+	Types.BudgetExceeded,
+	Types.LargeTransaction,
+	Types.BillReminder,
+	Types.AnomalyAlert,
+	Types.SecurityAlert,
+	Types.JobFinished,
+	Types.CreditUsage,
+	Types.PayeeWarning,
+	Types.PayeeExceeded,
+	Types.ApprovalRequest,
+	Types.ApprovalDecision,
+	Types.AllowancePaid,
+	Types.IOUActivity,
+	Types.EmergencyFundLow,
+	Types.PriceIncrease,
+	Types.Automation,
+}
+
+type Notification struct {
+	UserID    int                    `json:"user_id"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+type Dispatcher struct {
+	mu       sync.RWMutex
+	channels []Channel
+	allow    func(Notification) bool
+	timeout  time.Duration
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{timeout: 10 * time.Second}
+}
+
+func (d *Dispatcher) Register(channel Channel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels = append(d.channels, channel)
+}
+
+// SetFilter installs a check run before every dispatch; notifications it
+// rejects are dropped.
+func (d *Dispatcher) SetFilter(allow func(Notification) bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.allow = allow
+}
+
+func (d *Dispatcher) Dispatch(n Notification) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+
+	d.mu.RLock()
+	channels := make([]Channel, len(d.channels))
+	copy(channels, d.channels)
+	allow := d.allow
+	d.mu.RUnlock()
+
+	if allow != nil && !allow(n) {
+		return
+	}
+
+	for _, channel := range channels {
+		go func(channel Channel) {
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			defer cancel()
+
+			if err := channel.Send(ctx, n); err != nil {
+				log.Printf("Failed to deliver %s notification via %s: %v", n.Type, channel.Name(), err)
+			}
+		}(channel)
+	}
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/secrets"
+)
+
+type PushChannel struct {
+	db      *sql.DB
+	keys    *VAPIDKeys
+	subject string
+	client  *http.Client
+}
+
+func NewPushChannel(db *sql.DB) (*PushChannel, error) {
+	keys, err := LoadOrCreateVAPIDKeys(db)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := config.Get().WebPush.VAPIDSubject
+
+	return &PushChannel{
+		db:      db,
+		keys:    keys,
+		subject: subject,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *PushChannel) Name() string {
+	return "webpush"
+}
+
+func (p *PushChannel) Send(ctx context.Context, n Notification) error {
+	var enabled bool
+	err := p.db.QueryRowContext(ctx, `SELECT enabled FROM push_preferences WHERE user_id = $1 AND notification_type = $2`,
+		n.UserID, n.Type).Scan(&enabled)
+	if err == sql.ErrNoRows || (err == nil && !enabled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := p.db.QueryContext(ctx, `SELECT id, endpoint, p256dh, auth FROM push_subscriptions WHERE user_id = $1`, n.UserID)
+	if err != nil {
+		return err
+	}
+
+	type subscription struct {
+		id int
+		PushSubscription
+	}
+	var subscriptions []subscription
+	for rows.Next() {
+		var s subscription
+		if err := rows.Scan(&s.id, &s.Endpoint, &s.P256dh, &s.Auth); err != nil {
+			continue
+		}
+		if s.P256dh, err = secrets.Decrypt(s.P256dh); err != nil {
+			continue
+		}
+		if s.Auth, err = secrets.Decrypt(s.Auth); err != nil {
+			continue
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	rows.Close()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":  n.Type,
+		"title": n.Title,
+		"body":  n.Message,
+		"data":  n.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, s := range subscriptions {
+		err := SendPush(ctx, p.client, p.keys, p.subject, s.PushSubscription, payload, 86400)
+		switch {
+		case err == ErrSubscriptionGone:
+			if _, err := p.db.Exec(`DELETE FROM push_subscriptions WHERE id = $1`, s.id); err != nil {
+				log.Printf("Failed to remove expired push subscription: %v", err)
+			}
+		case err != nil:
+			lastErr = err
+		default:
+			p.db.Exec(`UPDATE push_subscriptions SET last_used_at = NOW() WHERE id = $1`, s.id)
+		}
+	}
+
+	return lastErr
+}
@@ -0,0 +1,266 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"personal-finance-tracker/internal/jobs"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/secrets"
+	"personal-finance-tracker/internal/tracing"
+)
+
+type WebhookTypes struct {
+	Slack   string
+	Discord string
+}
+
+var Webhooks = WebhookTypes{
+	Slack:   "slack",
+	Discord: "discord",
+}
+
+type WebhookChannel struct {
+	db     *sql.DB
+	client *http.Client
+	queue  *jobs.Queue
+}
+
+type webhookJob struct {
+	ChannelID    int          `json:"channel_id"`
+	Notification Notification `json:"notification"`
+}
+
+// NewWebhookChannel delivers through the job queue when one is given, so failed
+// deliveries are retried with backoff instead of being dropped.
+func NewWebhookChannel(db *sql.DB, queue *jobs.Queue) *WebhookChannel {
+	w := &WebhookChannel{
+		db:     db,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  queue,
+	}
+	if queue != nil {
+		queue.Register(models.JobTypes.WebhookDelivery, w.deliverJob, jobs.DefaultRetryPolicy())
+	}
+	return w
+}
+
+func (w *WebhookChannel) Name() string {
+	return "webhooks"
+}
+
+func (w *WebhookChannel) Send(ctx context.Context, n Notification) error {
+	query := `SELECT id, type, webhook_url FROM notification_channels
+			  WHERE user_id = $1 AND enabled = TRUE AND (cardinality(events) = 0 OR $2 = ANY(events))`
+
+	rows, err := w.db.QueryContext(ctx, query, n.UserID, n.Type)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type target struct {
+		id        int
+		kind, url string
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.kind, &t.url); err != nil {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, t := range targets {
+		if w.queue != nil {
+			if _, err := w.queue.Enqueue(ctx, n.UserID, models.JobTypes.WebhookDelivery, webhookJob{ChannelID: t.id, Notification: n}); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+
+		url, err := secrets.Decrypt(t.url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := w.Deliver(ctx, t.kind, url, n); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (w *WebhookChannel) deliverJob(ctx context.Context, job models.Job) (interface{}, error) {
+	var payload webhookJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	var kind, url string
+	query := `SELECT type, webhook_url FROM notification_channels WHERE id = $1 AND user_id = $2 AND enabled = TRUE`
+
+	err := w.db.QueryRowContext(ctx, query, payload.ChannelID, job.UserID).Scan(&kind, &url)
+	if err == sql.ErrNoRows {
+		return map[string]interface{}{"skipped": "channel removed or disabled"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if url, err = secrets.Decrypt(url); err != nil {
+		return nil, err
+	}
+
+	if err := w.Deliver(ctx, kind, url, payload.Notification); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"channel_id": payload.ChannelID, "delivered": true}, nil
+}
+
+func (w *WebhookChannel) Deliver(ctx context.Context, kind, url string, n Notification) error {
+	var payload interface{}
+	switch kind {
+	case Webhooks.Slack:
+		payload = SlackPayload(n)
+	case Webhooks.Discord:
+		payload = DiscordPayload(n)
+	default:
+		return fmt.Errorf("unsupported webhook type: %s", kind)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tracing.Do(w.client, req, kind+" webhook")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook returned status %d", kind, resp.StatusCode)
+	}
+	return nil
+}
+
+func SlackPayload(n Notification) map[string]interface{} {
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": emoji(n.Type) + " " + n.Title},
+		},
+		{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": n.Message},
+		},
+	}
+
+	if fields := dataFields(n); len(fields) > 0 {
+		var slackFields []map[string]interface{}
+		for _, f := range fields {
+			slackFields = append(slackFields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", f.name, f.value),
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": slackFields})
+	}
+
+	blocks = append(blocks, map[string]interface{}{
+		"type": "context",
+		"elements": []map[string]interface{}{
+			{"type": "mrkdwn", "text": "Personal Finance Tracker • " + n.CreatedAt.Format("2006-01-02 15:04")},
+		},
+	})
+
+	return map[string]interface{}{
+		"text":   n.Title,
+		"blocks": blocks,
+	}
+}
+
+func DiscordPayload(n Notification) map[string]interface{} {
+	var fields []map[string]interface{}
+	for _, f := range dataFields(n) {
+		fields = append(fields, map[string]interface{}{"name": f.name, "value": f.value, "inline": true})
+	}
+
+	return map[string]interface{}{
+		"username": "Personal Finance Tracker",
+		"embeds": []map[string]interface{}{
+			{
+				"title":       emoji(n.Type) + " " + n.Title,
+				"description": n.Message,
+				"color":       color(n.Type),
+				"fields":      fields,
+				"timestamp":   n.CreatedAt.UTC().Format(time.RFC3339),
+			},
+		},
+	}
+}
+
+type field struct {
+	name  string
+	value string
+}
+
+func dataFields(n Notification) []field {
+	var fields []field
+	for _, key := range []string{"amount", "spent", "budget", "description"} {
+		value, ok := n.Data[key]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case float64:
+			fields = append(fields, field{name: key, value: fmt.Sprintf("%.2f", v)})
+		default:
+			fields = append(fields, field{name: key, value: fmt.Sprint(v)})
+		}
+	}
+	return fields
+}
+
+func emoji(notificationType string) string {
+	switch notificationType {
+	case Types.BudgetExceeded, Types.PayeeExceeded:
+		return "🚨"
+	case Types.BudgetWarning, Types.PayeeWarning:
+		return "⚠️"
+	case Types.LargeTransaction:
+		return "💸"
+	default:
+		return "🔔"
+	}
+}
+
+func color(notificationType string) int {
+	switch notificationType {
+	case Types.BudgetExceeded, Types.PayeeExceeded:
+		return 0xE74C3C
+	case Types.BudgetWarning, Types.PayeeWarning:
+		return 0xF39C12
+	case Types.LargeTransaction:
+		return 0x3498DB
+	default:
+		return 0x95A5A6
+	}
+}
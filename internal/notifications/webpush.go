@@ -0,0 +1,246 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/secrets"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrSubscriptionGone = errors.New("push subscription is no longer valid")
+
+type VAPIDKeys struct {
+	PublicKey  string
+	PrivateKey *ecdsa.PrivateKey
+}
+
+type PushSubscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+func LoadOrCreateVAPIDKeys(db *sql.DB) (*VAPIDKeys, error) {
+	if cfg := config.Get().WebPush; cfg.VAPIDPrivateKey != "" {
+		return parseVAPIDKeys(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey)
+	}
+
+	var public, private string
+	err := db.QueryRow(`SELECT public_key, private_key FROM vapid_keys ORDER BY id DESC LIMIT 1`).Scan(&public, &private)
+	if err == nil {
+		if private, err = secrets.Decrypt(private); err != nil {
+			return nil, err
+		}
+		return parseVAPIDKeys(public, private)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &VAPIDKeys{PublicKey: encodePublicKey(key), PrivateKey: key}
+	private, err = secrets.Encrypt(base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32))))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`INSERT INTO vapid_keys (public_key, private_key, created_at) VALUES ($1, $2, NOW())`, keys.PublicKey, private)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func parseVAPIDKeys(public, private string) (*VAPIDKeys, error) {
+	d, err := base64.RawURLEncoding.DecodeString(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	key := new(ecdsa.PrivateKey)
+	key.Curve = elliptic.P256()
+	key.D = new(big.Int).SetBytes(d)
+	key.PublicKey.X, key.PublicKey.Y = key.Curve.ScalarBaseMult(d)
+
+	derived := encodePublicKey(key)
+	if public != "" && public != derived {
+		return nil, errors.New("VAPID public key does not match private key")
+	}
+
+	return &VAPIDKeys{PublicKey: derived, PrivateKey: key}, nil
+}
+
+func encodePublicKey(key *ecdsa.PrivateKey) string {
+	public, err := key.PublicKey.ECDH()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(public.Bytes())
+}
+
+func (k *VAPIDKeys) authorization(endpoint, subject string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+
+	signed, err := token.SignedString(k.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, k.PublicKey), nil
+}
+
+func EncryptPayload(sub PushSubscription, payload []byte) ([]byte, error) {
+	clientPublic, err := decodeBase64URL(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := decodeBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	curve := ecdh.P256()
+	clientKey, err := curve.NewPublicKey(clientPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	serverKey, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	sharedSecret, err := serverKey.ECDH(clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), clientPublic...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm, err := expand(hkdf.Extract(sha256.New, sharedSecret, authSecret), keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	contentKey, err := expand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := expand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	var body bytes.Buffer
+	body.Write(salt)
+	binary.Write(&body, binary.BigEndian, uint32(4096))
+	body.WriteByte(byte(len(serverPublic)))
+	body.Write(serverPublic)
+	body.Write(ciphertext)
+
+	return body.Bytes(), nil
+}
+
+func SendPush(ctx context.Context, client *http.Client, keys *VAPIDKeys, subject string, sub PushSubscription, payload []byte, ttl int) error {
+	body, err := EncryptPayload(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := keys.authorization(sub.Endpoint, subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(ttl))
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func expand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.URLEncoding.DecodeString(value)
+}
@@ -0,0 +1,208 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	appconfig "personal-finance-tracker/internal/config"
+)
+
+var ErrUnknownProvider = errors.New("unknown OAuth provider")
+
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(state string) string
+	Exchange(ctx context.Context, code string, params url.Values) (*Identity, error)
+}
+
+type TokenVerifier interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*Identity, error)
+}
+
+// FormPoster is implemented by providers that send the user back with a
+// cross-site form POST instead of a redirect.
+type FormPoster interface {
+	FormPost() bool
+}
+
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistryFromConfig(cfg appconfig.OAuthConfig) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	base := strings.TrimRight(cfg.RedirectBaseURL, "/")
+
+	if cfg.GoogleClientID != "" {
+		r.Register(NewGoogleProvider(cfg.GoogleClientID, cfg.GoogleClientSecret, callbackURL(base, "google")))
+	}
+	if cfg.GitHubClientID != "" {
+		r.Register(NewGitHubProvider(cfg.GitHubClientID, cfg.GitHubClientSecret, callbackURL(base, "github")))
+	}
+	if cfg.AppleClientID != "" {
+		apple, err := NewAppleProvider(cfg.AppleClientID, cfg.AppleTeamID, cfg.AppleKeyID,
+			cfg.ApplePrivateKey, callbackURL(base, "apple"))
+		if err != nil {
+			log.Printf("Apple sign-in disabled: %v", err)
+		} else {
+			r.Register(apple)
+		}
+	}
+	if cfg.OIDCIssuerURL != "" {
+		name := cfg.OIDCProviderName
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		oidc, err := NewOIDCProvider(ctx, name, cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCClientSecret,
+			callbackURL(base, name), cfg.OIDCScopes,
+			ClaimMapping{
+				Email:     cfg.OIDCEmailClaim,
+				FirstName: cfg.OIDCFirstNameClaim,
+				LastName:  cfg.OIDCLastNameClaim,
+			},
+			cfg.OIDCTrustEmail)
+		cancel()
+		if err != nil {
+			log.Printf("OIDC login disabled: %v", err)
+		} else {
+			r.Register(oidc)
+		}
+	}
+
+	return r
+}
+
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func callbackURL(base, provider string) string {
+	return base + "/api/v1/auth/oauth/" + provider + "/callback"
+}
+
+type config struct {
+	clientID     string
+	clientSecret string
+	authURL      string
+	tokenURL     string
+	redirectURL  string
+	scopes       []string
+}
+
+func (c config) authCodeURL(state string, extra url.Values) string {
+	params := url.Values{
+		"client_id":     {c.clientID},
+		"redirect_uri":  {c.redirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(c.scopes, " ")},
+		"state":         {state},
+	}
+	for key, values := range extra {
+		params[key] = values
+	}
+	return c.authURL + "?" + params.Encode()
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (c config) exchange(ctx context.Context, code, clientSecret string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.redirectURL},
+		"client_id":     {c.clientID},
+		"client_secret": {clientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := doJSON(req, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s %s", token.Error, token.Description)
+	}
+	if token.AccessToken == "" && token.IDToken == "" {
+		return nil, errors.New("token exchange returned no token")
+	}
+	return &token, nil
+}
+
+func getJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return doJSON(req, out)
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+func splitName(name string) (string, string) {
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return first, strings.TrimSpace(last)
+}
@@ -0,0 +1,258 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type ClaimMapping struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+type OIDCProvider struct {
+	config
+	name       string
+	issuer     string
+	jwksURL    string
+	claims     ClaimMapping
+	trustEmail bool
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func NewOIDCProvider(ctx context.Context, name, issuer, clientID, clientSecret, redirectURL string, scopes []string, claims ClaimMapping, trustEmail bool) (*OIDCProvider, error) {
+	issuer = strings.TrimRight(issuer, "/")
+
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := doJSON(req, &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC issuer mismatch: configured %s, discovered %s", issuer, discovery.Issuer)
+	}
+
+	return &OIDCProvider{
+		config: config{
+			clientID:     clientID,
+			clientSecret: clientSecret,
+			authURL:      discovery.AuthorizationEndpoint,
+			tokenURL:     discovery.TokenEndpoint,
+			redirectURL:  redirectURL,
+			scopes:       scopes,
+		},
+		name:       name,
+		issuer:     discovery.Issuer,
+		jwksURL:    discovery.JWKSURI,
+		claims:     claims,
+		trustEmail: trustEmail,
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string) string {
+	return p.authCodeURL(state, url.Values{"nonce": {stateNonce(state)}})
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, params url.Values) (*Identity, error) {
+	token, err := p.exchange(ctx, code, p.clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("OIDC provider returned no ID token")
+	}
+
+	return p.verify(ctx, token.IDToken, stateNonce(params.Get("state")))
+}
+
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, idToken string) (*Identity, error) {
+	return p.verify(ctx, idToken, "")
+}
+
+func (p *OIDCProvider) verify(ctx context.Context, idToken, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256"}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("invalid ID token: missing expiry")
+	}
+	if nonce != "" {
+		if claimed, _ := claims["nonce"].(string); claimed != nonce {
+			return nil, errors.New("invalid ID token: nonce mismatch")
+		}
+	}
+
+	identity := &Identity{Provider: p.name}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims[p.claims.Email].(string)
+	identity.FirstName, _ = claims[p.claims.FirstName].(string)
+	identity.LastName, _ = claims[p.claims.LastName].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+	if p.trustEmail {
+		identity.EmailVerified = true
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+	return identity, nil
+}
+
+func (p *OIDCProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.fetchedAt) < time.Minute {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := fetchJWKS(ctx, p.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.fetchedAt = time.Now()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func fetchJWKS(ctx context.Context, jwksURL string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := doJSON(req, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func stateNonce(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,240 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type GoogleProvider struct {
+	config
+}
+
+func NewGoogleProvider(clientID, clientSecret, redirectURL string) *GoogleProvider {
+	return &GoogleProvider{config{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		tokenURL:     "https://oauth2.googleapis.com/token",
+		redirectURL:  redirectURL,
+		scopes:       []string{"openid", "email", "profile"},
+	}}
+}
+
+func (p *GoogleProvider) Name() string {
+	return "google"
+}
+
+func (p *GoogleProvider) AuthCodeURL(state string) string {
+	return p.authCodeURL(state, url.Values{"prompt": {"select_account"}})
+}
+
+func (p *GoogleProvider) Exchange(ctx context.Context, code string, params url.Values) (*Identity, error) {
+	token, err := p.exchange(ctx, code, p.clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := getJSON(ctx, "https://openidconnect.googleapis.com/v1/userinfo", token.AccessToken, &info); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Provider:      p.Name(),
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		FirstName:     info.GivenName,
+		LastName:      info.FamilyName,
+	}, nil
+}
+
+type GitHubProvider struct {
+	config
+}
+
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
+	return &GitHubProvider{config{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		redirectURL:  redirectURL,
+		scopes:       []string{"read:user", "user:email"},
+	}}
+}
+
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *GitHubProvider) AuthCodeURL(state string) string {
+	return p.authCodeURL(state, nil)
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code string, params url.Values) (*Identity, error) {
+	token, err := p.exchange(ctx, code, p.clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, "https://api.github.com/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, "https://api.github.com/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Provider: p.Name(), Subject: strconv.FormatInt(user.ID, 10)}
+	identity.FirstName, identity.LastName = splitName(user.Name)
+	if identity.FirstName == "" {
+		identity.FirstName = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
+
+type AppleProvider struct {
+	config
+	teamID string
+	keyID  string
+	key    *ecdsa.PrivateKey
+}
+
+func NewAppleProvider(clientID, teamID, keyID, privateKey, redirectURL string) (*AppleProvider, error) {
+	if teamID == "" || keyID == "" || privateKey == "" {
+		return nil, errors.New("APPLE_TEAM_ID, APPLE_KEY_ID and APPLE_PRIVATE_KEY are required")
+	}
+
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(privateKey, `\n`, "\n")))
+	if block == nil {
+		return nil, errors.New("APPLE_PRIVATE_KEY is not a PEM encoded key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("APPLE_PRIVATE_KEY must be an EC key")
+	}
+
+	return &AppleProvider{
+		config: config{
+			clientID:    clientID,
+			authURL:     "https://appleid.apple.com/auth/authorize",
+			tokenURL:    "https://appleid.apple.com/auth/token",
+			redirectURL: redirectURL,
+			scopes:      []string{"name", "email"},
+		},
+		teamID: teamID,
+		keyID:  keyID,
+		key:    key,
+	}, nil
+}
+
+func (p *AppleProvider) Name() string {
+	return "apple"
+}
+
+func (p *AppleProvider) AuthCodeURL(state string) string {
+	return p.authCodeURL(state, url.Values{"response_mode": {"form_post"}})
+}
+
+func (p *AppleProvider) FormPost() bool {
+	return true
+}
+
+func (p *AppleProvider) Exchange(ctx context.Context, code string, params url.Values) (*Identity, error) {
+	secret, err := p.signedClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.exchange(ctx, code, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ID token comes straight from Apple's token endpoint over TLS, so its
+	// claims can be trusted without verifying the signature.
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token.IDToken, claims); err != nil {
+		return nil, fmt.Errorf("invalid Apple ID token: %w", err)
+	}
+
+	identity := &Identity{Provider: p.Name()}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	// Apple only sends the user's name on the first authorization, as a JSON
+	// form field next to the code.
+	var user struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if raw := params.Get("user"); raw != "" && json.Unmarshal([]byte(raw), &user) == nil {
+		identity.FirstName = user.Name.FirstName
+		identity.LastName = user.Name.LastName
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("Apple ID token has no subject")
+	}
+	return identity, nil
+}
+
+func (p *AppleProvider) signedClientSecret() (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    p.teamID,
+		Subject:   p.clientID,
+		Audience:  jwt.ClaimStrings{"https://appleid.apple.com"},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+	})
+	token.Header["kid"] = p.keyID
+	return token.SignedString(p.key)
+}
@@ -0,0 +1,115 @@
+// Package pdf writes plain-text PDF documents: a title and lines of
+// monospaced text on A4 pages, enough for tabular reports without a
+// layout engine.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth   = 595
+	pageHeight  = 842
+	margin      = 40
+	fontSize    = 9
+	titleSize   = 14
+	lineHeight  = 11
+	linesOnPage = (pageHeight - 2*margin - 2*lineHeight) / lineHeight
+)
+
+// MaxLineWidth is how many characters fit on a line; longer lines are cut.
+const MaxLineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+
+// folded spells letters missing from the PDF's Latin-1 based encoding
+// without their diacritics.
+var folded = map[rune]byte{
+	'ą': 'a', 'ć': 'c', 'ę': 'e', 'ł': 'l', 'ń': 'n', 'ś': 's', 'ź': 'z', 'ż': 'z',
+	'Ą': 'A', 'Ć': 'C', 'Ę': 'E', 'Ł': 'L', 'Ń': 'N', 'Ś': 'S', 'Ź': 'Z', 'Ż': 'Z',
+	'–': '-', '—': '-', '’': '\'', '„': '"', '”': '"',
+}
+
+// Text renders title on top of the first page followed by lines in Courier,
+// starting new pages as needed.
+func Text(title string, lines []string) []byte {
+	var pages [][]string
+	for len(lines) > linesOnPage {
+		pages = append(pages, lines[:linesOnPage])
+		lines = lines[linesOnPage:]
+	}
+	pages = append(pages, lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	// Objects 1 to 4 are the catalog, page tree and fonts; each page then
+	// takes a page object followed by its content stream.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		y := pageHeight - margin
+		if i == 0 {
+			fmt.Fprintf(&content, "BT /F2 %d Tf %d %d Td (%s) Tj ET\n", titleSize, margin, y-titleSize, escape(title))
+			y -= 2 * lineHeight
+		}
+		content.WriteString(fmt.Sprintf("BT /F1 %d Tf %d TL %d %d Td\n", fontSize, lineHeight, margin, y-lineHeight))
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escape(line))
+		}
+		content.WriteString("ET\n")
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// escape encodes s for a PDF string literal, cutting it to MaxLineWidth.
+func escape(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == MaxLineWidth {
+			break
+		}
+		n++
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		case folded[r] != 0:
+			b.WriteByte(folded[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+type OCREngine interface {
+	Name() string
+	Recognize(ctx context.Context, image []byte) (string, error)
+}
+
+func NewEngineFromConfig(cfg config.OCRConfig) OCREngine {
+	switch cfg.Provider {
+	case "google_vision":
+		return &CloudVisionEngine{
+			APIKey:   cfg.GoogleVisionAPIKey,
+			Endpoint: "https://vision.googleapis.com/v1/images:annotate",
+			Client:   &http.Client{Timeout: 30 * time.Second},
+		}
+	default:
+		return &TesseractEngine{Binary: cfg.TesseractPath, Lang: cfg.Lang}
+	}
+}
+
+type TesseractEngine struct {
+	Binary string
+	Lang   string
+}
+
+func (e *TesseractEngine) Name() string {
+	return "tesseract"
+}
+
+func (e *TesseractEngine) Recognize(ctx context.Context, image []byte) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, e.Binary, "stdin", "stdout", "-l", e.Lang)
+	cmd.Stdin = bytes.NewReader(image)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w: %s", err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+type CloudVisionEngine struct {
+	APIKey   string
+	Endpoint string
+	Client   *http.Client
+}
+
+func (e *CloudVisionEngine) Name() string {
+	return "google_vision"
+}
+
+func (e *CloudVisionEngine) Recognize(ctx context.Context, image []byte) (string, error) {
+	if e.APIKey == "" {
+		return "", fmt.Errorf("GOOGLE_VISION_API_KEY is not configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"requests": []map[string]interface{}{
+			{
+				"image":    map[string]string{"content": base64.StdEncoding.EncodeToString(image)},
+				"features": []map[string]string{{"type": "TEXT_DETECTION"}},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint+"?key="+e.APIKey, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vision API returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Responses []struct {
+			FullTextAnnotation struct {
+				Text string `json:"text"`
+			} `json:"fullTextAnnotation"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"responses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.Responses) == 0 {
+		return "", fmt.Errorf("vision API returned no results")
+	}
+	if result.Responses[0].Error != nil {
+		return "", fmt.Errorf("vision API error: %s", result.Responses[0].Error.Message)
+	}
+
+	return result.Responses[0].FullTextAnnotation.Text, nil
+}
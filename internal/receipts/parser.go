@@ -0,0 +1,143 @@
+package receipts
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LineItem struct {
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+type Receipt struct {
+	Merchant  string     `json:"merchant"`
+	Date      *time.Time `json:"date"`
+	Total     float64    `json:"total"`
+	LineItems []LineItem `json:"line_items"`
+	RawText   string     `json:"raw_text"`
+}
+
+var (
+	amountPattern = regexp.MustCompile(`(-?\d{1,3}(?:[ ,.]\d{3})*[.,]\d{2})\s*(?:[A-Za-z]{1,3}|zł|€|\$)?\s*$`)
+	// datePatterns lists the layouts each pattern may be in, day first before
+	// month first.
+	datePatterns = []struct {
+		pattern *regexp.Regexp
+		layouts []string
+	}{
+		{regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`), []string{"2006-01-02"}},
+		{regexp.MustCompile(`\b(\d{2}\.\d{2}\.\d{4})\b`), []string{"02.01.2006"}},
+		{regexp.MustCompile(`\b(\d{2}/\d{2}/\d{4})\b`), []string{"02/01/2006", "01/02/2006"}},
+		{regexp.MustCompile(`\b(\d{2}-\d{2}-\d{4})\b`), []string{"02-01-2006"}},
+	}
+	totalKeywords = []string{"total", "suma", "razem", "amount due", "do zapłaty", "grand total"}
+	skipKeywords  = []string{"subtotal", "tax", "vat", "ptu", "change", "reszta", "cash", "gotówka", "card", "karta", "tip"}
+)
+
+// Parse reads the merchant, date, total and line items from OCR text. A
+// slash date such as 03/04/2026 is read month first when monthFirst is set
+// and day first otherwise, falling back to the other order when the
+// preferred one is not a valid date.
+func Parse(text string, monthFirst bool) Receipt {
+	receipt := Receipt{RawText: text, LineItems: []LineItem{}}
+
+	var largest float64
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		lower := strings.ToLower(line)
+
+		if receipt.Merchant == "" && strings.IndexFunc(line, isLetter) >= 0 && !amountPattern.MatchString(line) {
+			receipt.Merchant = line
+		}
+
+		if receipt.Date == nil {
+			receipt.Date = parseDate(line, monthFirst)
+		}
+
+		match := amountPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		amount, ok := parseAmount(match[1])
+		if !ok {
+			continue
+		}
+		if amount > largest {
+			largest = amount
+		}
+
+		switch {
+		case containsAny(lower, totalKeywords) && !strings.Contains(lower, "subtotal"):
+			receipt.Total = amount
+		case containsAny(lower, skipKeywords):
+		default:
+			description := strings.TrimSpace(strings.TrimSuffix(line, match[0]))
+			if description != "" {
+				receipt.LineItems = append(receipt.LineItems, LineItem{Description: description, Amount: amount})
+			}
+		}
+	}
+
+	if receipt.Total == 0 {
+		receipt.Total = largest
+	}
+
+	return receipt
+}
+
+func parseDate(line string, monthFirst bool) *time.Time {
+	for _, candidate := range datePatterns {
+		match := candidate.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		layouts := candidate.layouts
+		if monthFirst && len(layouts) == 2 {
+			layouts = []string{layouts[1], layouts[0]}
+		}
+		for _, layout := range layouts {
+			if date, err := time.Parse(layout, match[1]); err == nil {
+				return &date
+			}
+		}
+	}
+	return nil
+}
+
+func parseAmount(value string) (float64, bool) {
+	value = strings.ReplaceAll(value, " ", "")
+	if len(value) < 3 {
+		return 0, false
+	}
+
+	decimalSep := value[len(value)-3]
+	whole := strings.NewReplacer(".", "", ",", "").Replace(value[:len(value)-3])
+	if decimalSep != '.' && decimalSep != ',' {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseFloat(whole+"."+value[len(value)-2:], 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127
+}
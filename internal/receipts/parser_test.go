@@ -0,0 +1,103 @@
+package receipts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		monthFirst bool
+		merchant   string
+		date       string
+		total      float64
+		items      []LineItem
+	}{
+		{
+			name:     "polish receipt",
+			text:     "Biedronka\nul. Długa 5\n2026-03-14 12:30\nMleko 3,49\nChleb 4,99\nPTU A 0,62\nSUMA PLN 8,48\nKarta 8,48",
+			merchant: "Biedronka",
+			date:     "2026-03-14",
+			total:    8.48,
+			items:    []LineItem{{"Mleko", 3.49}, {"Chleb", 4.99}},
+		},
+		{
+			name:     "dotted date and thousands",
+			text:     "Media Expert\n14.03.2026\nTelewizor 1 299,00\nRazem 1 299,00 zł",
+			merchant: "Media Expert",
+			date:     "2026-03-14",
+			total:    1299,
+			items:    []LineItem{{"Telewizor", 1299}},
+		},
+		{
+			name:     "slash date day first",
+			text:     "Corner Shop\n03/04/2026\nCoffee 2.50\nTotal 2.50",
+			merchant: "Corner Shop",
+			date:     "2026-04-03",
+			total:    2.5,
+			items:    []LineItem{{"Coffee", 2.5}},
+		},
+		{
+			name:       "slash date month first",
+			text:       "Corner Shop\n03/04/2026\nCoffee 2.50\nTotal 2.50",
+			monthFirst: true,
+			merchant:   "Corner Shop",
+			date:       "2026-03-04",
+			total:      2.5,
+			items:      []LineItem{{"Coffee", 2.5}},
+		},
+		{
+			name:     "US date read day first falls back to month first",
+			text:     "Walgreens\n12/25/2026\nGrand Total $14.20",
+			merchant: "Walgreens",
+			date:     "2026-12-25",
+			total:    14.2,
+			items:    []LineItem{},
+		},
+		{
+			name:       "day first date read month first falls back",
+			text:       "Tesco\n25/12/2026\nTotal 9.99",
+			monthFirst: true,
+			merchant:   "Tesco",
+			date:       "2026-12-25",
+			total:      9.99,
+			items:      []LineItem{},
+		},
+		{
+			name:     "no total keyword takes the largest amount",
+			text:     "Kiosk\nGazeta 6,50\nBaton 3,20",
+			merchant: "Kiosk",
+			total:    6.5,
+			items:    []LineItem{{"Gazeta", 6.5}, {"Baton", 3.2}},
+		},
+		{
+			name:  "empty",
+			text:  "",
+			items: []LineItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text, tt.monthFirst)
+			if got.Merchant != tt.merchant {
+				t.Errorf("merchant = %q, want %q", got.Merchant, tt.merchant)
+			}
+			date := ""
+			if got.Date != nil {
+				date = got.Date.Format("2006-01-02")
+			}
+			if date != tt.date {
+				t.Errorf("date = %q, want %q", date, tt.date)
+			}
+			if got.Total != tt.total {
+				t.Errorf("total = %v, want %v", got.Total, tt.total)
+			}
+			if !reflect.DeepEqual(got.LineItems, tt.items) {
+				t.Errorf("line items = %v, want %v", got.LineItems, tt.items)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package redact removes credentials and account numbers from data before it
+// is logged or reported. Values of sensitive fields are replaced whole;
+// anything that looks like a card number or IBAN elsewhere is masked down to
+// its last four characters.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const Mask = "[REDACTED]"
+
+var sensitiveWords = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "session", "csrf",
+	"api_key", "api-key", "apikey", "signature", "iban", "account_number", "card_number", "cvv",
+	"webhook_url", "dsn",
+}
+
+// SensitiveKey reports whether a field, header or parameter name may hold
+// credentials or account numbers.
+func SensitiveKey(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// 12 to 19 digits, optionally grouped by spaces or dashes: card and
+	// domestic account numbers.
+	cardNumber = regexp.MustCompile(`\b\d(?:[ -]?\d){11,18}\b`)
+	iban       = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`)
+)
+
+// String masks account and card numbers in free text.
+func String(s string) string {
+	s = iban.ReplaceAllStringFunc(s, maskNumber)
+	return cardNumber.ReplaceAllStringFunc(s, maskNumber)
+}
+
+func maskNumber(number string) string {
+	compact := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	return "****" + compact[len(compact)-4:]
+}
+
+// Query redacts sensitive parameters and masks numbers in the others.
+func Query(values url.Values) url.Values {
+	out := make(url.Values, len(values))
+	for name, list := range values {
+		for _, value := range list {
+			if SensitiveKey(name) {
+				value = Mask
+			} else {
+				value = String(value)
+			}
+			out.Add(name, value)
+		}
+	}
+	return out
+}
+
+// JSON redacts a JSON document, reporting false when data is not valid
+// JSON.
+func JSON(data []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(value(doc))
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if SensitiveKey(key) && field != nil {
+				v[key] = Mask
+			} else {
+				v[key] = value(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = value(item)
+		}
+		return v
+	case string:
+		return String(v)
+	case json.Number:
+		if masked := String(v.String()); masked != v.String() {
+			return masked
+		}
+		return v
+	}
+	return v
+}
+
+// Body describes an HTTP body for a log line: JSON, forms and text are
+// redacted, while other content is only summarized by size and type.
+// Bodies cut short at the logging limit are marked as truncated.
+func Body(contentType string, data []byte, truncated bool) string {
+	if len(data) == 0 {
+		return "(empty)"
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var out string
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if truncated {
+			// A cut document cannot be parsed, so keys cannot be trusted
+			// to be redacted.
+			return fmt.Sprintf("(%d+ bytes of %s, too large to log)", len(data), mediaType)
+		}
+		redacted, ok := JSON(data)
+		if !ok {
+			return fmt.Sprintf("(%d bytes of invalid JSON)", len(data))
+		}
+		out = string(redacted)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return fmt.Sprintf("(%d bytes of invalid form)", len(data))
+		}
+		out = Query(values).Encode()
+	case strings.HasPrefix(mediaType, "text/") && mediaType != "text/event-stream":
+		out = String(string(data))
+	default:
+		if mediaType == "" {
+			mediaType = "unknown type"
+		}
+		return fmt.Sprintf("(%d bytes of %s)", len(data), mediaType)
+	}
+	if truncated {
+		out += "...(truncated)"
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+type EnvKeyProvider struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewEnvKeyProvider parses "id:base64key,id:base64key". The first entry is
+// used for new values, the rest are kept so older values can still be read.
+func NewEnvKeyProvider(spec string) (*EnvKeyProvider, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	p := &EnvKeyProvider{keys: make(map[string][]byte)}
+	for _, entry := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid master key entry %q, expected id:base64key", entry)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %s: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %s must be 32 bytes, got %d", id, len(key))
+		}
+		if _, exists := p.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate master key id %s", id)
+		}
+
+		p.keys[id] = key
+		if p.activeID == "" {
+			p.activeID = id
+		}
+	}
+	return p, nil
+}
+
+func (p *EnvKeyProvider) ActiveKeyID() string {
+	return p.activeID
+}
+
+func (p *EnvKeyProvider) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return seal(key, dataKey)
+}
+
+func (p *EnvKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(key, wrapped)
+}
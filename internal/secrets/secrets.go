@@ -0,0 +1,214 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personal-finance-tracker/internal/config"
+)
+
+const prefix = "enc:v1:"
+
+var ErrUnknownKey = errors.New("value was encrypted with an unknown master key")
+
+// KeyProvider wraps and unwraps data keys with a master key. The env provider
+// keeps master keys in memory; a KMS-backed provider can implement the same
+// interface without the master key ever leaving the KMS.
+type KeyProvider interface {
+	ActiveKeyID() string
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+type Cipher struct {
+	provider KeyProvider
+}
+
+var defaultCipher = &Cipher{}
+
+func Use(c *Cipher) {
+	defaultCipher = c
+}
+
+func Encrypt(plaintext string) (string, error) {
+	return defaultCipher.Encrypt(context.Background(), plaintext)
+}
+
+func Decrypt(value string) (string, error) {
+	return defaultCipher.Decrypt(context.Background(), value)
+}
+
+func NeedsRewrap(value string) bool {
+	return defaultCipher.NeedsRewrap(value)
+}
+
+func Rewrap(value string) (string, error) {
+	return defaultCipher.Rewrap(context.Background(), value)
+}
+
+func New(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider}
+}
+
+func NewFromConfig(cfg config.EncryptionConfig) (*Cipher, error) {
+	provider, err := NewEnvKeyProvider(cfg.MasterKeys)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return &Cipher{}, nil
+	}
+	return New(provider), nil
+}
+
+func (c *Cipher) Enabled() bool {
+	return c.provider != nil
+}
+
+func (c *Cipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if c.provider == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	keyID := c.provider.ActiveKeyID()
+	wrapped, err := c.provider.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) Decrypt(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	if c.provider == nil {
+		return "", errors.New("encrypted value found but ENCRYPTION_MASTER_KEYS is not set")
+	}
+
+	keyID, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := c.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether a stored value is still plaintext or wrapped
+// with a master key other than the active one.
+func (c *Cipher) NeedsRewrap(value string) bool {
+	if c.provider == nil || value == "" {
+		return false
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return true
+	}
+	keyID, _, _, err := parse(value)
+	return err == nil && keyID != c.provider.ActiveKeyID()
+}
+
+// Rewrap re-encrypts only the data key under the active master key, leaving
+// the payload untouched. Plaintext values are encrypted.
+func (c *Cipher) Rewrap(ctx context.Context, value string) (string, error) {
+	if c.provider == nil {
+		return value, nil
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return c.Encrypt(ctx, value)
+	}
+
+	keyID, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := c.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	activeID := c.provider.ActiveKeyID()
+	rewrapped, err := c.provider.WrapKey(ctx, activeID, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + activeID + ":" + base64.RawStdEncoding.EncodeToString(rewrapped) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, errors.New("malformed encrypted value")
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed wrapped key: %w", err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	return parts[0], wrapped, sealed, nil
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
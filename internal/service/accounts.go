@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrInvalidAccountType  = errors.New("account type must be one of checking, savings, credit_card, cash, investment, loan")
+	ErrInvalidCreditLimit  = errors.New("credit limit must be non-negative and is only allowed on credit_card accounts")
+	ErrInvalidInterestRate = errors.New("interest rate must be between 0 and 100 and is not allowed on cash accounts")
+	ErrInvalidCardCycle    = errors.New("statement closing day and payment due day must both be set between 1 and 28, on credit_card accounts only")
+	ErrInvalidScope        = errors.New("scope must be personal or business")
+)
+
+const accountColumns = `id, user_id, name, type, balance, currency, COALESCE(description, ''), scope, created_at, updated_at,
+			  archived_at, closed_at, closing_balance, credit_limit, interest_rate, statement_closing_day, payment_due_day`
+
+func scanAccount(row interface{ Scan(...interface{}) error }, a *models.Account) error {
+	err := row.Scan(&a.ID, &a.UserID, &a.Name, &a.Type, &a.Balance, &a.Currency, &a.Description, &a.Scope,
+		&a.CreatedAt, &a.UpdatedAt, &a.ArchivedAt, &a.ClosedAt, &a.ClosingBalance, &a.CreditLimit, &a.InterestRate,
+		&a.StatementClosingDay, &a.PaymentDueDay)
+	if err == nil && a.CreditLimit != nil {
+		available := *a.CreditLimit + a.Balance
+		a.AvailableCredit = &available
+	}
+	return err
+}
+
+// IsLiability reports whether balances of the account type are money owed.
+// Their balances go negative as debt grows, like any account spent from.
+func IsLiability(accountType string) bool {
+	return accountType == models.AccountTypes.CreditCard || accountType == models.AccountTypes.Loan
+}
+
+// ValidScope reports whether scope names a scope; empty means unscoped.
+func ValidScope(scope string) bool {
+	return scope == "" || scope == models.Scopes.Personal || scope == models.Scopes.Business
+}
+
+func ValidateAccount(a *models.Account) error {
+	switch a.Type {
+	case models.AccountTypes.Checking, models.AccountTypes.Savings, models.AccountTypes.CreditCard,
+		models.AccountTypes.Cash, models.AccountTypes.Investment, models.AccountTypes.Loan:
+	default:
+		return ErrInvalidAccountType
+	}
+	if !ValidScope(a.Scope) {
+		return ErrInvalidScope
+	}
+	if a.CreditLimit != nil && (a.Type != models.AccountTypes.CreditCard || *a.CreditLimit < 0) {
+		return ErrInvalidCreditLimit
+	}
+	if a.InterestRate != nil && (a.Type == models.AccountTypes.Cash || *a.InterestRate < 0 || *a.InterestRate > 100) {
+		return ErrInvalidInterestRate
+	}
+	if a.StatementClosingDay != nil || a.PaymentDueDay != nil {
+		if a.Type != models.AccountTypes.CreditCard || a.StatementClosingDay == nil || a.PaymentDueDay == nil ||
+			*a.StatementClosingDay < 1 || *a.StatementClosingDay > 28 || *a.PaymentDueDay < 1 || *a.PaymentDueDay > 28 {
+			return ErrInvalidCardCycle
+		}
+	}
+	return nil
+}
+
+// NetWorth sums the balances of the user's accounts in scope, or of all of
+// them when scope is empty.
+func (s *Service) NetWorth(ctx context.Context, userID int, scope string) (models.NetWorth, error) {
+	query := `SELECT COALESCE(SUM(balance) FILTER (WHERE type NOT IN ($2, $3)), 0),
+			  COALESCE(-SUM(balance) FILTER (WHERE type IN ($2, $3)), 0)
+			  FROM accounts WHERE user_id = $1 AND ($4 = '' OR scope = $4)`
+
+	var nw models.NetWorth
+	err := s.ReadDB().QueryRowContext(ctx, query, userID, models.AccountTypes.CreditCard, models.AccountTypes.Loan, scope).
+		Scan(&nw.Assets, &nw.Liabilities)
+	nw.NetWorth = nw.Assets - nw.Liabilities
+	return nw, err
+}
+
+func (s *Service) GetAccounts(ctx context.Context, userID int) ([]models.Account, error) {
+	return s.ListAccounts(ctx, userID, false, "")
+}
+
+// ListAccounts returns the user's accounts; archived ones only when asked,
+// since default listings should show accounts that are still in use. An
+// empty scope lists both personal and business accounts.
+func (s *Service) ListAccounts(ctx context.Context, userID int, includeArchived bool, scope string) ([]models.Account, error) {
+	query := `SELECT ` + accountColumns + `
+			  FROM accounts WHERE user_id = $1 AND ($2 OR archived_at IS NULL) AND ($3 = '' OR scope = $3)
+			  ORDER BY created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, includeArchived, scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		var account models.Account
+		if err := scanAccount(rows, &account); err != nil {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (s *Service) GetAccount(ctx context.Context, userID, accountID int) (models.Account, error) {
+	var account models.Account
+	err := scanAccount(s.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1 AND user_id = $2`, accountID, userID), &account)
+	if err == sql.ErrNoRows {
+		return account, ErrAccountNotFound
+	}
+	return account, err
+}
+
+// ArchiveAccount hides the account from listings and blocks new transactions
+// on it. When closing, the balance at that moment is kept as the closing
+// balance so the final state stays visible after the account is reopened.
+func (s *Service) ArchiveAccount(ctx context.Context, userID, accountID int, closing bool) (models.Account, error) {
+	query := `UPDATE accounts SET archived_at = COALESCE(archived_at, NOW()),
+			  closed_at = CASE WHEN $3 THEN COALESCE(closed_at, NOW()) ELSE closed_at END,
+			  closing_balance = CASE WHEN $3 THEN COALESCE(closing_balance, balance) ELSE closing_balance END,
+			  updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2
+			  RETURNING ` + accountColumns
+
+	var account models.Account
+	err := scanAccount(s.db.QueryRowContext(ctx, query, accountID, userID, closing), &account)
+	if err == sql.ErrNoRows {
+		return account, ErrAccountNotFound
+	}
+	return account, err
+}
+
+func (s *Service) UnarchiveAccount(ctx context.Context, userID, accountID int) (models.Account, error) {
+	query := `UPDATE accounts SET archived_at = NULL, closed_at = NULL, updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2
+			  RETURNING ` + accountColumns
+
+	var account models.Account
+	err := scanAccount(s.db.QueryRowContext(ctx, query, accountID, userID), &account)
+	if err == sql.ErrNoRows {
+		return account, ErrAccountNotFound
+	}
+	return account, err
+}
+
+func (s *Service) DefaultAccountID(ctx context.Context, userID int) (int, error) {
+	var accountID int
+	query := `SELECT id FROM accounts WHERE user_id = $1 AND archived_at IS NULL
+			  ORDER BY id = (SELECT default_account_id FROM user_settings WHERE user_id = $1) DESC NULLS LAST, created_at ASC
+			  LIMIT 1`
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&accountID)
+	if err == sql.ErrNoRows {
+		return 0, ErrAccountNotFound
+	}
+	return accountID, err
+}
+
+func (s *Service) UpdateAccount(ctx context.Context, a *models.Account) error {
+	if err := ValidateAccount(a); err != nil {
+		return err
+	}
+
+	// Leaving the scope out keeps it, so older clients do not reset it.
+	query := `UPDATE accounts SET name = $1, type = $2, currency = $3, description = $4,
+			  credit_limit = $5, interest_rate = $6, statement_closing_day = $7, payment_due_day = $8,
+			  scope = COALESCE(NULLIF($11, ''), scope), updated_at = NOW()
+			  WHERE id = $9 AND user_id = $10 RETURNING ` + accountColumns
+
+	err := scanAccount(s.db.QueryRowContext(ctx, query, a.Name, a.Type, a.Currency, a.Description,
+		a.CreditLimit, a.InterestRate, a.StatementClosingDay, a.PaymentDueDay, a.ID, a.UserID, a.Scope), a)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound
+	}
+	return err
+}
+
+func (s *Service) DeleteAccount(ctx context.Context, userID, accountID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1 AND user_id = $2`, accountID, userID)
+	if isForeignKeyViolation(err) {
+		return ErrResourceInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func (s *Service) CreateAccount(ctx context.Context, a *models.Account) error {
+	if err := ValidateAccount(a); err != nil {
+		return err
+	}
+
+	if a.Scope == "" {
+		a.Scope = models.Scopes.Personal
+	}
+
+	// The starting balance is the opening balance; transactions move it.
+	query := `INSERT INTO accounts (user_id, name, type, balance, opening_balance, currency, description, credit_limit, interest_rate,
+			  statement_closing_day, payment_due_day, scope, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW()) RETURNING ` + accountColumns
+
+	return scanAccount(s.db.QueryRowContext(ctx, query, a.UserID, a.Name, a.Type, a.Balance, a.Currency, a.Description,
+		a.CreditLimit, a.InterestRate, a.StatementClosingDay, a.PaymentDueDay, a.Scope), a)
+}
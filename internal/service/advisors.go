@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAdvisorGrantNotFound = errors.New("advisor grant not found")
+	ErrAdvisorGrantExists   = errors.New("this advisor already has access; revoke it before granting new access")
+	ErrTooManyAdvisorGrants = errors.New("too many advisor grants")
+	ErrInvalidAdvisor       = errors.New("you cannot grant advisor access to yourself")
+)
+
+// advisorGrantColumns select a grant g with the owner o and advisor v.
+const advisorGrantColumns = `g.id, g.owner_id, o.email, g.advisor_id, v.email,
+			  ARRAY(SELECT account_id FROM advisor_grant_accounts a WHERE a.grant_id = g.id ORDER BY account_id),
+			  g.analytics, g.expires_at, g.last_used_at, g.revoked_at, g.created_at, g.updated_at`
+
+const advisorGrantJoins = `JOIN users o ON o.id = g.owner_id JOIN users v ON v.id = g.advisor_id`
+
+// activeAdvisorGrant is the condition for grants whose tokens are accepted.
+const activeAdvisorGrant = `g.revoked_at IS NULL AND (g.expires_at IS NULL OR g.expires_at > NOW())`
+
+func scanAdvisorGrant(row interface{ Scan(...interface{}) error }, g *models.AdvisorGrant) error {
+	var accountIDs pq.Int64Array
+	err := row.Scan(&g.ID, &g.OwnerID, &g.OwnerEmail, &g.AdvisorID, &g.AdvisorEmail, &accountIDs,
+		&g.Analytics, &g.ExpiresAt, &g.LastUsedAt, &g.RevokedAt, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	g.AccountIDs = make([]int, len(accountIDs))
+	for i, id := range accountIDs {
+		g.AccountIDs[i] = int(id)
+	}
+	return nil
+}
+
+func (s *Service) queryAdvisorGrants(ctx context.Context, query string, args ...interface{}) ([]models.AdvisorGrant, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.AdvisorGrant{}
+	for rows.Next() {
+		var g models.AdvisorGrant
+		if err := scanAdvisorGrant(rows, &g); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// AdvisorGrants lists the access the owner gave to advisors, revoked grants
+// included, newest first.
+func (s *Service) AdvisorGrants(ctx context.Context, ownerID int) ([]models.AdvisorGrant, error) {
+	return s.queryAdvisorGrants(ctx, `SELECT `+advisorGrantColumns+` FROM advisor_grants g `+advisorGrantJoins+`
+			  WHERE g.owner_id = $1 ORDER BY g.created_at DESC, g.id DESC`, ownerID)
+}
+
+// AdvisorClients lists the active grants other users gave the advisor.
+func (s *Service) AdvisorClients(ctx context.Context, advisorID int) ([]models.AdvisorGrant, error) {
+	return s.queryAdvisorGrants(ctx, `SELECT `+advisorGrantColumns+` FROM advisor_grants g `+advisorGrantJoins+`
+			  WHERE g.advisor_id = $1 AND `+activeAdvisorGrant+` ORDER BY o.email, g.id`, advisorID)
+}
+
+// ActiveAdvisorGrant returns a grant that is neither revoked nor expired,
+// or ErrAdvisorGrantNotFound.
+func (s *Service) ActiveAdvisorGrant(ctx context.Context, id int) (models.AdvisorGrant, error) {
+	var g models.AdvisorGrant
+	err := scanAdvisorGrant(s.db.QueryRowContext(ctx, `SELECT `+advisorGrantColumns+` FROM advisor_grants g `+advisorGrantJoins+`
+			  WHERE g.id = $1 AND `+activeAdvisorGrant, id), &g)
+	if err == sql.ErrNoRows {
+		return g, ErrAdvisorGrantNotFound
+	}
+	return g, err
+}
+
+// GrantAdvisorAccess gives the user with req.Email read-only access to the
+// listed accounts of the owner and, with req.Analytics, to their analytics.
+func (s *Service) GrantAdvisorAccess(ctx context.Context, ownerID int, req models.AdvisorGrantRequest) (models.AdvisorGrant, error) {
+	var grant models.AdvisorGrant
+
+	var advisorID int
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE LOWER(email) = LOWER($1)`, req.Email).Scan(&advisorID)
+	if err == sql.ErrNoRows {
+		return grant, ErrUserNotFound
+	}
+	if err != nil {
+		return grant, err
+	}
+	if advisorID == ownerID {
+		return grant, ErrInvalidAdvisor
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return grant, err
+	}
+	defer tx.Rollback()
+
+	// An expired grant no longer counts, so it is closed to make room for
+	// the new one.
+	if _, err := tx.ExecContext(ctx, `UPDATE advisor_grants SET revoked_at = NOW(), updated_at = NOW()
+			  WHERE owner_id = $1 AND advisor_id = $2 AND revoked_at IS NULL AND expires_at <= NOW()`, ownerID, advisorID); err != nil {
+		return grant, err
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM advisor_grants WHERE owner_id = $1 AND revoked_at IS NULL`, ownerID).
+		Scan(&count); err != nil {
+		return grant, err
+	}
+	if count >= models.AdvisorSettings.MaxGrants {
+		return grant, ErrTooManyAdvisorGrants
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		at := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &at
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `INSERT INTO advisor_grants (owner_id, advisor_id, analytics, expires_at, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`, ownerID, advisorID, req.Analytics, expiresAt).Scan(&id)
+	if isUniqueViolation(err) {
+		return grant, ErrAdvisorGrantExists
+	}
+	if err != nil {
+		return grant, err
+	}
+
+	for _, accountID := range req.AccountIDs {
+		if err := ensureOwned(ctx, tx, "accounts", accountID, ownerID); err != nil {
+			return grant, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO advisor_grant_accounts (grant_id, account_id) VALUES ($1, $2)
+				  ON CONFLICT DO NOTHING`, id, accountID); err != nil {
+			return grant, err
+		}
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntry{
+		UserID:     ownerID,
+		Action:     models.AuditActions.AdvisorGrant,
+		EntityType: "advisor_grant",
+		EntityID:   &id,
+		Details:    map[string]interface{}{"advisor_id": advisorID, "account_ids": req.AccountIDs, "analytics": req.Analytics},
+	})
+	if err != nil {
+		return grant, err
+	}
+
+	err = scanAdvisorGrant(tx.QueryRowContext(ctx, `SELECT `+advisorGrantColumns+` FROM advisor_grants g `+advisorGrantJoins+`
+			  WHERE g.id = $1`, id), &grant)
+	if err != nil {
+		return grant, err
+	}
+	return grant, tx.Commit()
+}
+
+// RevokeAdvisorAccess ends a grant; the delegated tokens issued for it stop
+// working on their next request.
+func (s *Service) RevokeAdvisorAccess(ctx context.Context, ownerID, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var advisorID int
+	err = tx.QueryRowContext(ctx, `UPDATE advisor_grants SET revoked_at = NOW(), updated_at = NOW()
+			  WHERE id = $1 AND owner_id = $2 AND revoked_at IS NULL RETURNING advisor_id`, id, ownerID).Scan(&advisorID)
+	if err == sql.ErrNoRows {
+		return ErrAdvisorGrantNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntry{
+		UserID:     ownerID,
+		Action:     models.AuditActions.AdvisorRevoke,
+		EntityType: "advisor_grant",
+		EntityID:   &id,
+		Details:    map[string]interface{}{"advisor_id": advisorID},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordAdvisorAccess adds a request made with a delegated token to the
+// owner's audit log.
+func (s *Service) RecordAdvisorAccess(ctx context.Context, grant models.AdvisorGrant, method, path string, status int) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE advisor_grants SET last_used_at = NOW() WHERE id = $1`, grant.ID); err != nil {
+		return err
+	}
+	return recordAudit(ctx, s.db, models.AuditEntry{
+		UserID:     grant.OwnerID,
+		Action:     models.AuditActions.AdvisorAccess,
+		EntityType: "advisor_grant",
+		EntityID:   &grant.ID,
+		Details:    map[string]interface{}{"advisor_id": grant.AdvisorID, "method": method, "path": path, "status": status},
+	})
+}
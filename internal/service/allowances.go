@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+const allowanceColumns = `id, owner_id, member_id, from_account_id, to_account_id, category_id, amount, frequency,
+			  to_char(next_date, 'YYYY-MM-DD'), created_at, updated_at`
+
+func scanAllowanceRule(row interface{ Scan(...interface{}) error }, r *models.AllowanceRule) error {
+	return row.Scan(&r.ID, &r.OwnerID, &r.MemberID, &r.FromAccountID, &r.ToAccountID, &r.CategoryID, &r.Amount,
+		&r.Frequency, &r.NextDate, &r.CreatedAt, &r.UpdatedAt)
+}
+
+func (s *Service) AllowanceRules(ctx context.Context, ownerID int) ([]models.AllowanceRule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+allowanceColumns+` FROM allowance_rules WHERE owner_id = $1 ORDER BY next_date, id`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AllowanceRule{}
+	for rows.Next() {
+		var rule models.AllowanceRule
+		if err := scanAllowanceRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateAllowanceRule schedules a recurring transfer to a member. The
+// receiving account has to be one the member can use.
+func (s *Service) CreateAllowanceRule(ctx context.Context, ownerID int, req models.AllowanceRuleRequest) (models.AllowanceRule, error) {
+	rule := models.AllowanceRule{
+		OwnerID:       ownerID,
+		MemberID:      req.MemberID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		CategoryID:    req.CategoryID,
+		Amount:        req.Amount,
+		Frequency:     req.Frequency,
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return rule, err
+	}
+	rule.NextDate = start.Format("2006-01-02")
+
+	var role string
+	err = s.db.QueryRowContext(ctx, `SELECT role FROM household_members WHERE owner_id = $1 AND member_id = $2`, ownerID, req.MemberID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return rule, ErrUserNotFound
+	}
+	if err != nil {
+		return rule, err
+	}
+	if err := ensureOwned(ctx, s.db, "categories", req.CategoryID, ownerID); err != nil {
+		return rule, err
+	}
+	if err := ensureOwned(ctx, s.db, "accounts", req.FromAccountID, ownerID); err != nil {
+		return rule, err
+	}
+	household := models.Household{OwnerID: ownerID, Role: role}
+	if err := s.memberCanUse(ctx, household, req.MemberID, req.ToAccountID); err != nil {
+		return rule, err
+	}
+
+	query := `INSERT INTO allowance_rules (owner_id, member_id, from_account_id, to_account_id, category_id, amount, frequency, next_date, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = s.db.QueryRowContext(ctx, query, rule.OwnerID, rule.MemberID, rule.FromAccountID, rule.ToAccountID, rule.CategoryID,
+		rule.Amount, rule.Frequency, rule.NextDate).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	return rule, err
+}
+
+func (s *Service) DeleteAllowanceRule(ctx context.Context, ownerID, ruleID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM allowance_rules WHERE id = $1 AND owner_id = $2`, ruleID, ownerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAllowanceNotFound
+	}
+	return nil
+}
+
+func (s *Service) RunAllowances(ctx context.Context) {
+	ticker := time.NewTicker(models.AllowanceSettings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.payAllowances(ctx, time.Now()); err != nil {
+			log.Printf("Error paying allowances: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// payAllowances pays every rule due on or before today in the owner's
+// timezone, catching up on periods missed while the server was down.
+func (s *Service) payAllowances(ctx context.Context, now time.Time) error {
+	// Timezones are at most a day ahead of UTC, so this finds every rule that
+	// can be due somewhere; the exact day is checked per owner.
+	rows, err := s.db.QueryContext(ctx, `SELECT id, owner_id FROM allowance_rules WHERE next_date <= $1::date`,
+		now.UTC().AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+
+	type due struct{ id, ownerID int }
+	var rules []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.id, &d.ownerID); err != nil {
+			rows.Close()
+			return err
+		}
+		rules = append(rules, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range rules {
+		local := now.In(s.Location(ctx, d.ownerID))
+		today := local.Format("2006-01-02")
+		for {
+			paid, err := s.payAllowance(ctx, d.id, today, local)
+			if err != nil {
+				log.Printf("Error paying allowance rule %d: %v", d.id, err)
+			}
+			if !paid || err != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// payAllowance records one transfer of a rule if it is due and moves the rule
+// to its next date. The row lock keeps other instances from paying it twice.
+func (s *Service) payAllowance(ctx context.Context, ruleID int, today string, at time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var rule models.AllowanceRule
+	err = scanAllowanceRule(tx.QueryRowContext(ctx, `SELECT `+allowanceColumns+` FROM allowance_rules
+			  WHERE id = $1 AND next_date <= $2::date FOR UPDATE SKIP LOCKED`, ruleID, today), &rule)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	ownerL, currency := s.localizer(rule.OwnerID)
+	var member string
+	if err := tx.QueryRowContext(ctx, `SELECT first_name FROM users WHERE id = $1`, rule.MemberID).Scan(&member); err != nil {
+		return false, err
+	}
+
+	out := models.Transaction{UserID: rule.OwnerID, AccountID: rule.FromAccountID, CategoryID: rule.CategoryID,
+		Amount: rule.Amount, Type: "expense", Description: ownerL.T("Allowance for %s", member), Date: at}
+	in := models.Transaction{UserID: rule.OwnerID, AccountID: rule.ToAccountID, CategoryID: rule.CategoryID,
+		Amount: rule.Amount, Type: "income", Description: ownerL.T("Allowance for %s", member), Date: at}
+	outBalance, err := InsertTransaction(ctx, tx, &out)
+	if err != nil {
+		return false, err
+	}
+	inBalance, err := InsertTransaction(ctx, tx, &in)
+	if err != nil {
+		return false, err
+	}
+
+	step := "1 month"
+	if rule.Frequency == models.AllowanceFrequencies.Weekly {
+		step = "7 days"
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE allowance_rules SET next_date = (next_date + $1::interval)::date, updated_at = NOW()
+			  WHERE id = $2`, step, rule.ID); err != nil {
+		return false, err
+	}
+	if err := RecordTransactionCreated(ctx, tx, out, outBalance); err != nil {
+		return false, err
+	}
+	if err := RecordTransactionCreated(ctx, tx, in, inBalance); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	s.TransactionCreated(out)
+	s.TransactionCreated(in)
+
+	l, _ := s.localizer(rule.MemberID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  rule.MemberID,
+		Type:    notifications.Types.AllowancePaid,
+		Title:   l.T("Allowance received"),
+		Message: l.T("%s was added to your account.", l.Amount(rule.Amount, currency)),
+		Data: map[string]interface{}{
+			"rule_id":        rule.ID,
+			"account_id":     rule.ToAccountID,
+			"amount":         rule.Amount,
+			"transaction_id": in.ID,
+		},
+	})
+	return true, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+)
+
+var ErrInvalidAPIToken = errors.New("invalid token")
+
+// AuthenticateAPIToken returns the active API token matching token and the
+// email of its owner, and records that it was used.
+func (s *Service) AuthenticateAPIToken(ctx context.Context, token string) (models.APIToken, string, error) {
+	var t models.APIToken
+	var email string
+	query := `SELECT t.id, t.user_id, t.scope, u.email
+			  FROM api_tokens t JOIN users u ON u.id = t.user_id
+			  WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND (t.expires_at IS NULL OR t.expires_at > NOW())`
+
+	err := s.db.QueryRowContext(ctx, query, auth.HashToken(token)).Scan(&t.ID, &t.UserID, &t.Scope, &email)
+	if err == sql.ErrNoRows {
+		return t, "", ErrInvalidAPIToken
+	}
+	if err != nil {
+		return t, "", err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = NOW() WHERE id = $1`, t.ID); err != nil {
+		log.Printf("Error updating API token usage: %v", err)
+	}
+	return t, email, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var hexColor = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// IconCatalog lists the icons a category may use.
+func IconCatalog() []models.CatalogIcon {
+	return models.IconCatalog
+}
+
+// ColorCatalog lists the palette with the foreground to draw on each color.
+func ColorCatalog() []models.CatalogColor {
+	colors := make([]models.CatalogColor, len(models.ColorCatalog))
+	for i, color := range models.ColorCatalog {
+		color.Foreground = ColorForeground(color.Hex)
+		colors[i] = color
+	}
+	return colors
+}
+
+// ColorForeground picks the light or dark foreground that contrasts more with
+// a #rrggbb color.
+func ColorForeground(hex string) string {
+	light, dark := models.AppearanceSettings.LightForeground, models.AppearanceSettings.DarkForeground
+	if contrastRatio(hex, light) >= contrastRatio(hex, dark) {
+		return light
+	}
+	return dark
+}
+
+// normalizeAppearance checks a category's icon and color against the
+// catalogs. The color may be a palette name or any #rrggbb value whose
+// foreground stays readable; it is stored as lowercase hex. Empty values
+// leave the client to pick a default.
+func normalizeAppearance(c *models.Category) error {
+	if c.Icon != "" && !iconInCatalog(c.Icon) {
+		return ErrUnknownIcon
+	}
+	if c.Color == "" {
+		return nil
+	}
+
+	color := strings.ToLower(strings.TrimSpace(c.Color))
+	for _, entry := range models.ColorCatalog {
+		if color == entry.Name || color == entry.Hex {
+			c.Color = entry.Hex
+			return nil
+		}
+	}
+	if !hexColor.MatchString(color) {
+		return ErrInvalidColor
+	}
+	if contrastRatio(color, ColorForeground(color)) < models.AppearanceSettings.MinContrast {
+		return ErrColorContrast
+	}
+	c.Color = color
+	return nil
+}
+
+func iconInCatalog(name string) bool {
+	for _, icon := range models.IconCatalog {
+		if icon.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// contrastRatio is the WCAG 2 contrast ratio between two #rrggbb colors,
+// from 1 to 21.
+func contrastRatio(a, b string) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func relativeLuminance(hex string) float64 {
+	value, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	channel := func(shift uint) float64 {
+		c := float64((value>>shift)&0xff) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(16) + 0.7152*channel(8) + 0.0722*channel(0)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"personal-finance-tracker/internal/models"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordAudit writes an audit entry with q, so it can share the database
+// transaction of the change it describes.
+func recordAudit(ctx context.Context, q execer, entry models.AuditEntry) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+	if entry.Details == nil {
+		details = []byte("{}")
+	}
+
+	_, err = q.ExecContext(ctx, `INSERT INTO audit_log (user_id, action, entity_type, entity_id, details, created_at)
+			  VALUES ($1, $2, $3, $4, $5, NOW())`, entry.UserID, entry.Action, entry.EntityType, entry.EntityID, details)
+	return err
+}
+
+func (s *Service) AuditLog(ctx context.Context, userID, limit, offset int) ([]models.AuditEntry, error) {
+	query := `SELECT id, user_id, action, entity_type, entity_id, details, created_at
+			  FROM audit_log WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Action, &entry.EntityType, &entry.EntityID, &details, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
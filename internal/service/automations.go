@@ -0,0 +1,702 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/secrets"
+	"personal-finance-tracker/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAutomationRuleNotFound = errors.New("automation rule not found")
+	ErrTooManyAutomationRules = errors.New("too many automation rules")
+	ErrAutomationThreshold    = errors.New("balance_below rules need a balance_below condition")
+	ErrAutomationAction       = errors.New("set_category needs a category_id, move_to_account an account_id, add_tag a tag, webhook a webhook_url and contribute an account_id, a category_id and either an amount or a percent")
+)
+
+var automationWebhookClient = &http.Client{Timeout: models.AutomationSettings.WebhookTimeout}
+
+type automationWebhookJob struct {
+	RuleID        int    `json:"rule_id"`
+	TransactionID int    `json:"transaction_id"`
+	Trigger       string `json:"trigger"`
+}
+
+const automationRuleColumns = `id, user_id, name, trigger, conditions, actions, webhook_url, enabled, last_triggered_at,
+			  created_at, updated_at`
+
+func scanAutomationRule(row interface{ Scan(...interface{}) error }, r *models.AutomationRule) error {
+	var conditions, actions []byte
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.Trigger, &conditions, &actions, &r.WebhookURL, &r.Enabled,
+		&r.LastTriggeredAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(conditions, &r.Conditions); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(actions, &r.Actions); err != nil {
+		return err
+	}
+	r.WebhookURL, err = secrets.Decrypt(r.WebhookURL)
+	return err
+}
+
+func (s *Service) AutomationRules(ctx context.Context, userID int) ([]models.AutomationRule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+automationRuleColumns+` FROM automation_rules WHERE user_id = $1 ORDER BY name, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AutomationRule{}
+	for rows.Next() {
+		var rule models.AutomationRule
+		if err := scanAutomationRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Service) AutomationRule(ctx context.Context, userID, id int) (models.AutomationRule, error) {
+	var rule models.AutomationRule
+	err := scanAutomationRule(s.db.QueryRowContext(ctx, `SELECT `+automationRuleColumns+` FROM automation_rules
+			  WHERE id = $1 AND user_id = $2`, id, userID), &rule)
+	if err == sql.ErrNoRows {
+		return rule, ErrAutomationRuleNotFound
+	}
+	return rule, err
+}
+
+// prepareAutomationRule validates a rule request. The accounts and
+// categories it names must belong to the user.
+func (s *Service) prepareAutomationRule(ctx context.Context, userID int, req models.AutomationRuleRequest) (models.AutomationRule, error) {
+	rule := models.AutomationRule{
+		UserID:     userID,
+		Name:       req.Name,
+		Trigger:    req.Trigger,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+		WebhookURL: req.WebhookURL,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if rule.Trigger == models.AutomationTriggers.BalanceBelow && rule.Conditions.BalanceBelow == nil {
+		return rule, ErrAutomationThreshold
+	}
+	if rule.Conditions.AccountID != nil {
+		if err := ensureOwned(ctx, s.db, "accounts", *rule.Conditions.AccountID, userID); err != nil {
+			return rule, err
+		}
+	}
+	if rule.Conditions.CategoryID != nil {
+		if err := ensureOwned(ctx, s.db, "categories", *rule.Conditions.CategoryID, userID); err != nil {
+			return rule, err
+		}
+	}
+
+	webhook := false
+	for _, action := range rule.Actions {
+		switch action.Type {
+		case models.AutomationActions.SetCategory:
+			if action.CategoryID == nil {
+				return rule, ErrAutomationAction
+			}
+			if err := ensureOwned(ctx, s.db, "categories", *action.CategoryID, userID); err != nil {
+				return rule, err
+			}
+		case models.AutomationActions.MoveToAccount:
+			if action.AccountID == nil {
+				return rule, ErrAutomationAction
+			}
+			if err := ensureOwned(ctx, s.db, "accounts", *action.AccountID, userID); err != nil {
+				return rule, err
+			}
+		case models.AutomationActions.AddTag:
+			if strings.TrimSpace(action.Tag) == "" {
+				return rule, ErrAutomationAction
+			}
+		case models.AutomationActions.Webhook:
+			if rule.WebhookURL == "" {
+				return rule, ErrAutomationAction
+			}
+			webhook = true
+		case models.AutomationActions.Contribute:
+			if action.AccountID == nil || action.CategoryID == nil || (action.Amount == nil) == (action.Percent == nil) {
+				return rule, ErrAutomationAction
+			}
+			if err := ensureOwned(ctx, s.db, "accounts", *action.AccountID, userID); err != nil {
+				return rule, err
+			}
+			if err := ensureOwned(ctx, s.db, "categories", *action.CategoryID, userID); err != nil {
+				return rule, err
+			}
+		}
+	}
+	if !webhook {
+		rule.WebhookURL = ""
+	}
+	return rule, nil
+}
+
+func (s *Service) CreateAutomationRule(ctx context.Context, userID int, req models.AutomationRuleRequest) (models.AutomationRule, error) {
+	rule, err := s.prepareAutomationRule(ctx, userID, req)
+	if err != nil {
+		return rule, err
+	}
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM automation_rules WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return rule, err
+	}
+	if count >= models.AutomationSettings.MaxRules {
+		return rule, ErrTooManyAutomationRules
+	}
+
+	conditions, actions, webhookURL, err := encodeAutomationRule(rule)
+	if err != nil {
+		return rule, err
+	}
+
+	query := `INSERT INTO automation_rules (user_id, name, trigger, conditions, actions, webhook_url, enabled, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = s.db.QueryRowContext(ctx, query, userID, rule.Name, rule.Trigger, conditions, actions, webhookURL, rule.Enabled).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	return rule, err
+}
+
+func (s *Service) UpdateAutomationRule(ctx context.Context, userID, id int, req models.AutomationRuleRequest) (models.AutomationRule, error) {
+	rule, err := s.prepareAutomationRule(ctx, userID, req)
+	if err != nil {
+		return rule, err
+	}
+
+	conditions, actions, webhookURL, err := encodeAutomationRule(rule)
+	if err != nil {
+		return rule, err
+	}
+
+	query := `UPDATE automation_rules SET name = $1, trigger = $2, conditions = $3, actions = $4, webhook_url = $5, enabled = $6,
+			  updated_at = NOW()
+			  WHERE id = $7 AND user_id = $8 RETURNING ` + automationRuleColumns
+
+	err = scanAutomationRule(s.db.QueryRowContext(ctx, query, rule.Name, rule.Trigger, conditions, actions, webhookURL,
+		rule.Enabled, id, userID), &rule)
+	if err == sql.ErrNoRows {
+		return rule, ErrAutomationRuleNotFound
+	}
+	return rule, err
+}
+
+func (s *Service) DeleteAutomationRule(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM automation_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAutomationRuleNotFound
+	}
+	return nil
+}
+
+func encodeAutomationRule(rule models.AutomationRule) (conditions, actions []byte, webhookURL string, err error) {
+	if conditions, err = json.Marshal(rule.Conditions); err != nil {
+		return
+	}
+	if actions, err = json.Marshal(rule.Actions); err != nil {
+		return
+	}
+	webhookURL, err = secrets.Encrypt(rule.WebhookURL)
+	return
+}
+
+// automationMatches reports whether a transaction meets every condition set
+// on a rule. The balance condition is checked by the trigger instead.
+func automationMatches(c models.AutomationConditions, t models.Transaction) bool {
+	if c.DescriptionContains != "" && !strings.Contains(strings.ToLower(t.Description), strings.ToLower(c.DescriptionContains)) {
+		return false
+	}
+	if c.AccountID != nil && *c.AccountID != t.AccountID {
+		return false
+	}
+	if c.CategoryID != nil && *c.CategoryID != t.CategoryID {
+		return false
+	}
+	if c.Type != "" && c.Type != t.Type {
+		return false
+	}
+	if c.MinAmount != nil && t.Amount < *c.MinAmount {
+		return false
+	}
+	if c.MaxAmount != nil && t.Amount > *c.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// automationBalanceCrossed reports whether a transaction that left its
+// account at balance took it below threshold, for balance_below rules.
+func automationBalanceCrossed(t models.Transaction, balance, threshold *float64) bool {
+	if balance == nil || threshold == nil {
+		return false
+	}
+	return fellBelow(*balance-SignedAmount(t.Type, t.Amount), *balance, *threshold)
+}
+
+// fellBelow reports whether a balance went from at or above threshold to
+// below it, so a rule fires once per drop rather than on every transaction
+// while the balance stays low.
+func fellBelow(before, after, threshold float64) bool {
+	return before >= threshold && after < threshold
+}
+
+// automationContribution is the amount a contribute action saves out of a
+// transaction: its fixed amount or a percent of the transaction.
+func automationContribution(action models.AutomationAction, amount float64) float64 {
+	if action.Amount != nil {
+		return *action.Amount
+	}
+	if action.Percent != nil {
+		return math.Round(amount**action.Percent) / 100
+	}
+	return 0
+}
+
+func (s *Service) enabledAutomationRules(ctx context.Context, userID int, trigger string) ([]models.AutomationRule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+automationRuleColumns+` FROM automation_rules
+			  WHERE user_id = $1 AND trigger = $2 AND enabled ORDER BY id`, userID, trigger)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AutomationRule
+	for rows.Next() {
+		var rule models.AutomationRule
+		if err := scanAutomationRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// automationTransaction reads a transaction as it is now, since an earlier
+// rule may have changed it.
+func (s *Service) automationTransaction(ctx context.Context, userID, id int) (models.Transaction, error) {
+	var t models.Transaction
+	err := s.db.QueryRowContext(ctx, `SELECT id, user_id, account_id, category_id, amount, type, COALESCE(description, ''), date,
+			  tags, scope, latitude, longitude, COALESCE(place, ''), quantity, COALESCE(unit, ''), created_at, updated_at
+			  FROM transactions WHERE id = $1 AND user_id = $2`, id, userID).
+		Scan(&t.ID, &t.UserID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type, &t.Description, &t.Date,
+			pq.Array(&t.Tags), &t.Scope, &t.Latitude, &t.Longitude, &t.Place, &t.Quantity, &t.Unit, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return t, ErrTransactionNotFound
+	}
+	return t, err
+}
+
+// handleAutomationEvent runs the rules a domain event triggers. Actions are
+// not undone, so a failing rule is logged rather than failing the event,
+// which would run the other rules again.
+func (s *Service) handleAutomationEvent(ctx context.Context, e events.Event) error {
+	payload, _ := e.Data.(json.RawMessage)
+
+	type firing struct {
+		trigger       string
+		transactionID int
+		balance       *float64
+	}
+	var firings []firing
+
+	switch e.Type {
+	case events.Types.TransactionCreated:
+		var created transactionCreated
+		if err := json.Unmarshal(payload, &created); err != nil {
+			return err
+		}
+		balance := created.Balance
+		// A contribution does not fire transaction_created rules, which
+		// could otherwise contribute from it again without end.
+		if created.AutomationRuleID == 0 {
+			firings = append(firings, firing{models.AutomationTriggers.TransactionCreated, created.ID, nil})
+		}
+		firings = append(firings, firing{models.AutomationTriggers.BalanceBelow, created.ID, &balance})
+	case events.Types.BudgetThresholdCrossed:
+		var crossed struct {
+			Level         string `json:"level"`
+			TransactionID int    `json:"transaction_id"`
+		}
+		if err := json.Unmarshal(payload, &crossed); err != nil {
+			return err
+		}
+		if crossed.Level != notifications.Types.BudgetExceeded || crossed.TransactionID == 0 {
+			return nil
+		}
+		firings = append(firings, firing{models.AutomationTriggers.BudgetExceeded, crossed.TransactionID, nil})
+	default:
+		return nil
+	}
+
+	for _, f := range firings {
+		rules, err := s.enabledAutomationRules(ctx, e.UserID, f.trigger)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := s.runAutomation(ctx, rule, f.transactionID, f.balance); err != nil {
+				log.Printf("Error running automation rule %d: %v", rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runAutomation runs a rule's actions on a transaction if it still matches.
+// balance is the account balance the transaction left for balance_below
+// rules, which fire when it takes the balance below their threshold.
+func (s *Service) runAutomation(ctx context.Context, rule models.AutomationRule, transactionID int, balance *float64) error {
+	t, err := s.automationTransaction(ctx, rule.UserID, transactionID)
+	if err == ErrTransactionNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !automationMatches(rule.Conditions, t) {
+		return nil
+	}
+	if rule.Trigger == models.AutomationTriggers.BalanceBelow && !automationBalanceCrossed(t, balance, rule.Conditions.BalanceBelow) {
+		return nil
+	}
+
+	for _, action := range rule.Actions {
+		switch action.Type {
+		case models.AutomationActions.SetCategory:
+			t.CategoryID = *action.CategoryID
+			err = s.UpdateTransaction(ctx, &t)
+		case models.AutomationActions.MoveToAccount:
+			t.AccountID = *action.AccountID
+			err = s.UpdateTransaction(ctx, &t)
+		case models.AutomationActions.AddTag:
+			err = s.addTransactionTag(ctx, t, strings.TrimSpace(action.Tag))
+		case models.AutomationActions.Notify:
+			s.notifyAutomation(rule, action, t, balance)
+		case models.AutomationActions.Webhook:
+			_, err = s.jobs.Enqueue(ctx, rule.UserID, models.JobTypes.AutomationWebhook,
+				automationWebhookJob{RuleID: rule.ID, TransactionID: t.ID, Trigger: rule.Trigger})
+		case models.AutomationActions.Contribute:
+			err = s.contributeAutomation(ctx, rule, action, t)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", action.Type, err)
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE automation_rules SET last_triggered_at = NOW() WHERE id = $1`, rule.ID)
+	return err
+}
+
+func (s *Service) addTransactionTag(ctx context.Context, t models.Transaction, tag string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE transactions SET tags = array_append(COALESCE(tags, '{}'), $1), updated_at = NOW()
+			  WHERE id = $2 AND user_id = $3 AND NOT ($1 = ANY(COALESCE(tags, '{}')))`, tag, t.ID, t.UserID)
+	if err != nil {
+		return err
+	}
+	s.TransactionUpdated(t.UserID)
+	return nil
+}
+
+// contributeAutomation moves part of a transaction from its account into
+// the savings account of a contribute action, such as one set aside for the
+// emergency fund, as an expense and an income on the action's category.
+func (s *Service) contributeAutomation(ctx context.Context, rule models.AutomationRule, action models.AutomationAction, t models.Transaction) error {
+	amount := automationContribution(action, t.Amount)
+	if amount <= 0 || *action.AccountID == t.AccountID {
+		return nil
+	}
+
+	l, _ := s.localizer(t.UserID)
+	description := l.T("Contribution: %s", rule.Name)
+	now := time.Now()
+	out := models.Transaction{UserID: t.UserID, AccountID: t.AccountID, CategoryID: *action.CategoryID,
+		Amount: amount, Type: models.TransactionTypes.Expense, Description: description, Date: now}
+	in := models.Transaction{UserID: t.UserID, AccountID: *action.AccountID, CategoryID: *action.CategoryID,
+		Amount: amount, Type: models.TransactionTypes.Income, Description: description, Date: now}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range []*models.Transaction{&out, &in} {
+		balance, err := InsertTransaction(ctx, tx, c)
+		if err != nil {
+			return err
+		}
+		if err := recordTransactionCreated(ctx, tx, transactionCreated{Transaction: *c, Balance: balance, AutomationRuleID: rule.ID}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.TransactionCreated(out)
+	s.TransactionCreated(in)
+	return nil
+}
+
+func (s *Service) notifyAutomation(rule models.AutomationRule, action models.AutomationAction, t models.Transaction, balance *float64) {
+	l, currency := s.localizer(t.UserID)
+	n := notifications.Notification{
+		UserID:  t.UserID,
+		Type:    notifications.Types.Automation,
+		Title:   action.Title,
+		Message: action.Message,
+		Data: map[string]interface{}{
+			"rule_id":        rule.ID,
+			"trigger":        rule.Trigger,
+			"transaction_id": t.ID,
+			"amount":         t.Amount,
+			"description":    t.Description,
+		},
+	}
+	if n.Title == "" {
+		n.Title = rule.Name
+	}
+	if n.Message == "" {
+		n.Message = l.T("A %s of %s was recorded: %s", l.T(t.Type), l.Amount(t.Amount, currency), t.Description)
+		if balance != nil {
+			n.Message = l.T("Your account balance fell to %s after %s: %s", l.Amount(*balance, currency),
+				l.Amount(t.Amount, currency), t.Description)
+		}
+	}
+	s.notifier.Dispatch(n)
+}
+
+func (s *Service) runAutomationWebhook(ctx context.Context, job models.Job) (interface{}, error) {
+	var payload automationWebhookJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.AutomationRule(ctx, job.UserID, payload.RuleID)
+	if err == ErrAutomationRuleNotFound || (err == nil && rule.WebhookURL == "") {
+		return map[string]interface{}{"skipped": "rule removed or has no webhook"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.automationTransaction(ctx, job.UserID, payload.TransactionID)
+	if err == ErrTransactionNotFound {
+		return map[string]interface{}{"skipped": "transaction removed"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"rule_id":     rule.ID,
+		"rule_name":   rule.Name,
+		"trigger":     payload.Trigger,
+		"transaction": t,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Automation-Rule", strconv.Itoa(rule.ID))
+
+	resp, err := tracing.Do(automationWebhookClient, req, "automation webhook")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("automation webhook returned status %d", resp.StatusCode)
+	}
+	return map[string]interface{}{"rule_id": rule.ID, "transaction_id": t.ID, "delivered": true}, nil
+}
+
+// SimulateAutomation replays a rule over the transactions of the last days
+// in date order and lists those it would have fired on, without running
+// its actions. Balances and budget spending are rebuilt as of each one.
+func (s *Service) SimulateAutomation(ctx context.Context, userID int, req models.AutomationSimulationRequest, now time.Time) (models.AutomationSimulation, error) {
+	simulation := models.AutomationSimulation{Matches: []models.AutomationMatch{}}
+
+	rule, err := s.prepareAutomationRule(ctx, userID, req.Rule)
+	if err != nil {
+		return simulation, err
+	}
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return simulation, err
+	}
+	days := req.Days
+	if days == 0 {
+		days = models.AutomationSettings.SimulationDays
+	}
+	loc := SettingsLocation(settings)
+	since := now.In(loc).AddDate(0, 0, -days)
+	from := FiscalMonthStart(since, settings.FiscalMonthStartDay)
+	simulation.From = since.Format("2006-01-02")
+	simulation.To = now.In(loc).Format("2006-01-02")
+
+	// Budget spending counts from the start of the financial month, balances
+	// from every earlier transaction.
+	balances := make(map[int]float64)
+	if rule.Trigger == models.AutomationTriggers.BalanceBelow {
+		rows, err := s.ReadDB().QueryContext(ctx, `SELECT a.id, a.opening_balance + COALESCE((
+					  SELECT SUM(CASE WHEN t.type = 'expense' THEN -t.amount ELSE t.amount END)
+					  FROM transactions t WHERE t.account_id = a.id AND t.date < $2), 0)
+				  FROM accounts a WHERE a.user_id = $1`, userID, from)
+		if err != nil {
+			return simulation, err
+		}
+		for rows.Next() {
+			var id int
+			var balance float64
+			if err := rows.Scan(&id, &balance); err != nil {
+				rows.Close()
+				return simulation, err
+			}
+			balances[id] = balance
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return simulation, err
+		}
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT id, account_id, category_id, amount, type, COALESCE(description, ''), date
+			  FROM transactions WHERE user_id = $1 AND date >= $2 AND date <= $3
+			  ORDER BY date, id`, userID, from, now)
+	if err != nil {
+		return simulation, err
+	}
+	var transactions []models.Transaction
+	for rows.Next() {
+		t := models.Transaction{UserID: userID}
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type, &t.Description, &t.Date); err != nil {
+			rows.Close()
+			return simulation, err
+		}
+		transactions = append(transactions, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return simulation, err
+	}
+
+	err = replayAutomation(&simulation, rule, transactions, balances, since, loc, settings.FiscalMonthStartDay,
+		func(categoryID int, date time.Time) (float64, error) {
+			status, err := s.MonthlyBudgetStatus(ctx, userID, categoryID, date)
+			if err != nil && err != sql.ErrNoRows {
+				return 0, err
+			}
+			if status == nil {
+				return 0, nil
+			}
+			return status.Budget, nil
+		})
+	return simulation, err
+}
+
+// replayAutomation adds the transactions a rule fires on to simulation.
+// Transactions are in date order and those before since only build up the
+// balances, which start as the account balances before the first of them,
+// and the spending of each category's financial month. budget returns a
+// category's budget for the month of date, zero when it has none.
+func replayAutomation(simulation *models.AutomationSimulation, rule models.AutomationRule, transactions []models.Transaction,
+	balances map[int]float64, since time.Time, loc *time.Location, fiscalMonthStartDay int,
+	budget func(categoryID int, date time.Time) (float64, error)) error {
+	type budgetMonth struct {
+		categoryID int
+		month      string
+	}
+	budgets := make(map[budgetMonth]float64)
+	spent := make(map[budgetMonth]float64)
+
+	for _, t := range transactions {
+		var match models.AutomationMatch
+		fired := false
+
+		switch rule.Trigger {
+		case models.AutomationTriggers.TransactionCreated:
+			fired = true
+		case models.AutomationTriggers.BalanceBelow:
+			before := balances[t.AccountID]
+			balances[t.AccountID] += SignedAmount(t.Type, t.Amount)
+			after := balances[t.AccountID]
+			if fellBelow(before, after, *rule.Conditions.BalanceBelow) {
+				fired = true
+				rounded := math.Round(after*100) / 100
+				match.Balance = &rounded
+			}
+		case models.AutomationTriggers.BudgetExceeded:
+			if t.Type != models.TransactionTypes.Expense || t.CategoryID == 0 {
+				continue
+			}
+			key := budgetMonth{t.CategoryID, FiscalMonthStart(t.Date.In(loc), fiscalMonthStartDay).Format("2006-01-02")}
+			limit, ok := budgets[key]
+			if !ok {
+				var err error
+				if limit, err = budget(t.CategoryID, t.Date); err != nil {
+					return err
+				}
+				budgets[key] = limit
+			}
+			before := spent[key]
+			spent[key] += t.Amount
+			if limit > 0 && before <= limit && spent[key] > limit {
+				fired = true
+				rounded := math.Round(spent[key]*100) / 100
+				match.Spent = &rounded
+			}
+		}
+
+		if !fired || t.Date.Before(since) || !automationMatches(rule.Conditions, t) {
+			continue
+		}
+		simulation.Count++
+		if len(simulation.Matches) >= models.AutomationSettings.MaxMatches {
+			simulation.Truncated = true
+			continue
+		}
+		match.TransactionID = t.ID
+		match.Date = t.Date.In(loc).Format("2006-01-02")
+		match.Description = t.Description
+		match.Type = t.Type
+		match.Amount = t.Amount
+		match.AccountID = t.AccountID
+		match.CategoryID = t.CategoryID
+		simulation.Matches = append(simulation.Matches, match)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestAutomationMatches(t *testing.T) {
+	transaction := models.Transaction{AccountID: 1, CategoryID: 2, Amount: 50, Type: "expense", Description: "Lidl Warszawa"}
+
+	tests := []struct {
+		name       string
+		conditions models.AutomationConditions
+		want       bool
+	}{
+		{"no conditions", models.AutomationConditions{}, true},
+		{"description ignores case", models.AutomationConditions{DescriptionContains: "LIDL"}, true},
+		{"description differs", models.AutomationConditions{DescriptionContains: "Biedronka"}, false},
+		{"account", models.AutomationConditions{AccountID: intPtr(1)}, true},
+		{"other account", models.AutomationConditions{AccountID: intPtr(3)}, false},
+		{"category", models.AutomationConditions{CategoryID: intPtr(2)}, true},
+		{"other category", models.AutomationConditions{CategoryID: intPtr(3)}, false},
+		{"type", models.AutomationConditions{Type: "expense"}, true},
+		{"other type", models.AutomationConditions{Type: "income"}, false},
+		{"amount at min", models.AutomationConditions{MinAmount: floatPtr(50)}, true},
+		{"amount under min", models.AutomationConditions{MinAmount: floatPtr(50.01)}, false},
+		{"amount at max", models.AutomationConditions{MaxAmount: floatPtr(50)}, true},
+		{"amount over max", models.AutomationConditions{MaxAmount: floatPtr(49.99)}, false},
+		{"balance left to the trigger", models.AutomationConditions{BalanceBelow: floatPtr(0)}, true},
+		{"every condition", models.AutomationConditions{DescriptionContains: "lidl", AccountID: intPtr(1), CategoryID: intPtr(2),
+			Type: "expense", MinAmount: floatPtr(10), MaxAmount: floatPtr(100)}, true},
+		{"one condition fails", models.AutomationConditions{DescriptionContains: "lidl", AccountID: intPtr(1), Type: "income"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := automationMatches(tt.conditions, transaction); got != tt.want {
+			t.Errorf("%s: automationMatches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAutomationBalanceCrossed(t *testing.T) {
+	tests := []struct {
+		name      string
+		typ       string
+		amount    float64
+		balance   *float64
+		threshold *float64
+		want      bool
+	}{
+		{"expense takes balance below", "expense", 30, floatPtr(80), floatPtr(100), true},
+		{"expense from exactly the threshold", "expense", 20, floatPtr(80), floatPtr(100), true},
+		{"expense lands on the threshold", "expense", 20, floatPtr(100), floatPtr(100), false},
+		{"balance was already below", "expense", 10, floatPtr(80), floatPtr(100), false},
+		{"balance stays above", "expense", 10, floatPtr(150), floatPtr(100), false},
+		{"income cannot lower the balance", "income", 10, floatPtr(80), floatPtr(100), false},
+		{"negative threshold", "expense", 50, floatPtr(-20), floatPtr(0), true},
+		{"no balance", "expense", 30, nil, floatPtr(100), false},
+		{"no threshold", "expense", 30, floatPtr(80), nil, false},
+	}
+
+	for _, tt := range tests {
+		transaction := models.Transaction{Type: tt.typ, Amount: tt.amount}
+		if got := automationBalanceCrossed(transaction, tt.balance, tt.threshold); got != tt.want {
+			t.Errorf("%s: automationBalanceCrossed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAutomationContribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		action models.AutomationAction
+		amount float64
+		want   float64
+	}{
+		{"fixed amount", models.AutomationAction{Amount: floatPtr(25)}, 1000, 25},
+		{"percent", models.AutomationAction{Percent: floatPtr(10)}, 1234.56, 123.46},
+		{"percent rounds to a cent", models.AutomationAction{Percent: floatPtr(0.1)}, 3, 0},
+		{"neither", models.AutomationAction{}, 100, 0},
+	}
+
+	for _, tt := range tests {
+		if got := automationContribution(tt.action, tt.amount); got != tt.want {
+			t.Errorf("%s: automationContribution = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplayAutomation(t *testing.T) {
+	at := func(date string, id, accountID, categoryID int, typ string, amount float64) models.Transaction {
+		return models.Transaction{ID: id, AccountID: accountID, CategoryID: categoryID, Type: typ, Amount: amount, Date: day(date)}
+	}
+	type fired struct {
+		id    int
+		value float64
+	}
+	tests := []struct {
+		name         string
+		rule         models.AutomationRule
+		transactions []models.Transaction
+		balances     map[int]float64
+		budgets      map[string]float64 // by category and month
+		since        string
+		want         []fired
+	}{
+		{
+			name: "balance fires once per drop",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BalanceBelow,
+				Conditions: models.AutomationConditions{BalanceBelow: floatPtr(100)}},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 30),
+				at("2026-10-02", 2, 1, 2, "expense", 30),
+				at("2026-10-03", 3, 1, 2, "expense", 10),
+				at("2026-10-04", 4, 1, 2, "income", 50),
+				at("2026-10-05", 5, 1, 2, "expense", 40),
+			},
+			balances: map[int]float64{1: 150},
+			since:    "2026-10-01",
+			want:     []fired{{2, 90}, {5, 90}},
+		},
+		{
+			name: "balances are kept per account",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BalanceBelow,
+				Conditions: models.AutomationConditions{BalanceBelow: floatPtr(0)}},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 60),
+				at("2026-10-02", 2, 2, 2, "expense", 60),
+				at("2026-10-03", 3, 1, 2, "expense", 60),
+			},
+			balances: map[int]float64{1: 100, 2: 100},
+			since:    "2026-10-01",
+			want:     []fired{{3, -20}},
+		},
+		{
+			name: "drops before the window only move the balance",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BalanceBelow,
+				Conditions: models.AutomationConditions{BalanceBelow: floatPtr(100)}},
+			transactions: []models.Transaction{
+				at("2026-09-20", 1, 1, 2, "expense", 80),
+				at("2026-10-02", 2, 1, 2, "expense", 10),
+				at("2026-10-03", 3, 1, 2, "income", 100),
+				at("2026-10-04", 4, 1, 2, "expense", 100),
+			},
+			balances: map[int]float64{1: 150},
+			since:    "2026-10-01",
+			want:     []fired{{4, 60}},
+		},
+		{
+			name: "budget fires when spending first goes over",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BudgetExceeded},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 60),
+				at("2026-10-02", 2, 1, 2, "income", 500),
+				at("2026-10-03", 3, 1, 2, "expense", 40),
+				at("2026-10-04", 4, 1, 2, "expense", 0.01),
+				at("2026-10-05", 5, 1, 2, "expense", 20),
+				at("2026-11-01", 6, 1, 2, "expense", 120),
+			},
+			budgets: map[string]float64{"2 2026-10-01": 100, "2 2026-11-01": 100},
+			since:   "2026-10-01",
+			want:    []fired{{4, 100.01}, {6, 120}},
+		},
+		{
+			name: "spending is kept per category and a category without a budget never fires",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BudgetExceeded},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 60),
+				at("2026-10-02", 2, 1, 3, "expense", 60),
+				at("2026-10-03", 3, 1, 0, "expense", 500),
+				at("2026-10-04", 4, 1, 2, "expense", 60),
+				at("2026-10-05", 5, 1, 4, "expense", 1000),
+			},
+			budgets: map[string]float64{"2 2026-10-01": 100, "3 2026-10-01": 50},
+			since:   "2026-10-01",
+			want:    []fired{{2, 60}, {4, 120}},
+		},
+		{
+			name: "budget crossed before the window",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BudgetExceeded},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 150),
+				at("2026-10-10", 2, 1, 2, "expense", 10),
+			},
+			budgets: map[string]float64{"2 2026-10-01": 100},
+			since:   "2026-10-05",
+			want:    []fired{},
+		},
+		{
+			name: "conditions filter what fired",
+			rule: models.AutomationRule{Trigger: models.AutomationTriggers.BudgetExceeded,
+				Conditions: models.AutomationConditions{MinAmount: floatPtr(50)}},
+			transactions: []models.Transaction{
+				at("2026-10-01", 1, 1, 2, "expense", 90),
+				at("2026-10-02", 2, 1, 2, "expense", 20),
+				at("2026-11-01", 3, 1, 2, "expense", 150),
+			},
+			budgets: map[string]float64{"2 2026-10-01": 100, "2 2026-11-01": 100},
+			since:   "2026-10-01",
+			want:    []fired{{3, 150}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := func(categoryID int, date time.Time) (float64, error) {
+				if categoryID == 0 {
+					t.Fatal("budget looked up for an uncategorized transaction")
+				}
+				return tt.budgets[fmt.Sprintf("%d %s", categoryID, FiscalMonthStart(date, 1).Format("2006-01-02"))], nil
+			}
+
+			simulation := models.AutomationSimulation{Matches: []models.AutomationMatch{}}
+			err := replayAutomation(&simulation, tt.rule, tt.transactions, tt.balances, day(tt.since), time.UTC, 1, budget)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []fired{}
+			for _, match := range simulation.Matches {
+				value := match.Balance
+				if value == nil {
+					value = match.Spent
+				}
+				if value == nil {
+					t.Fatalf("match %d has neither a balance nor spending", match.TransactionID)
+				}
+				got = append(got, fired{match.TransactionID, *value})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fired on %v, want %v", got, tt.want)
+			}
+			if simulation.Count != len(tt.want) {
+				t.Errorf("count = %d, want %d", simulation.Count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestReplayAutomationTruncates(t *testing.T) {
+	rule := models.AutomationRule{Trigger: models.AutomationTriggers.TransactionCreated}
+	var transactions []models.Transaction
+	for i := 0; i < models.AutomationSettings.MaxMatches+5; i++ {
+		transactions = append(transactions, models.Transaction{ID: i + 1, Type: "expense", Amount: 1, Date: day("2026-10-01")})
+	}
+
+	simulation := models.AutomationSimulation{Matches: []models.AutomationMatch{}}
+	if err := replayAutomation(&simulation, rule, transactions, nil, day("2026-10-01"), time.UTC, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if simulation.Count != len(transactions) || len(simulation.Matches) != models.AutomationSettings.MaxMatches || !simulation.Truncated {
+		t.Errorf("count = %d, matches = %d, truncated = %v", simulation.Count, len(simulation.Matches), simulation.Truncated)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/storage"
+)
+
+var (
+	ErrInvalidImage   = errors.New("avatar must be a JPEG, PNG or GIF image")
+	ErrAvatarNotFound = errors.New("avatar not found")
+)
+
+// SetAvatar crops the image to a centered square and stores it resized to
+// every standard avatar size.
+func (s *Service) SetAvatar(ctx context.Context, userID int, data []byte) (models.User, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return models.User{}, ErrInvalidImage
+	}
+	if config.Width*config.Height > models.ProfileSettings.AvatarMaxPixels {
+		return models.User{}, fmt.Errorf("%w: image is larger than %d pixels", ErrInvalidImage, models.ProfileSettings.AvatarMaxPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.User{}, ErrInvalidImage
+	}
+	square := cropSquare(src)
+
+	images := make([][]byte, len(models.ProfileSettings.AvatarSizes))
+	var total int64
+	for i, size := range models.ProfileSettings.AvatarSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: models.ProfileSettings.AvatarJPEGQuality}); err != nil {
+			return models.User{}, err
+		}
+		images[i] = buf.Bytes()
+		total += int64(buf.Len())
+	}
+
+	// The new images replace the current ones, so only the difference
+	// counts against the storage limit.
+	current, err := s.storedBytes(ctx, avatarPrefix(userID))
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := s.CheckQuota(ctx, userID, models.QuotaResources.StorageBytes, total-current); err != nil {
+		return models.User{}, err
+	}
+
+	for i, size := range models.ProfileSettings.AvatarSizes {
+		if err := s.files.Put(ctx, avatarKey(userID, size), "image/jpeg", images[i]); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET avatar_updated_at = NOW(), updated_at = NOW() WHERE id = $1`, userID); err != nil {
+		return models.User{}, err
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+func (s *Service) DeleteAvatar(ctx context.Context, userID int) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE users SET avatar_updated_at = NULL, updated_at = NOW()
+			  WHERE id = $1 AND avatar_updated_at IS NOT NULL`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAvatarNotFound
+	}
+
+	for _, size := range models.ProfileSettings.AvatarSizes {
+		if err := s.files.Delete(ctx, avatarKey(userID, size)); err != nil {
+			log.Printf("Error deleting avatar of user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) Avatar(ctx context.Context, userID, size int) ([]byte, string, error) {
+	data, contentType, err := s.files.Get(ctx, avatarKey(userID, size))
+	if err == storage.ErrNotFound {
+		return nil, "", ErrAvatarNotFound
+	}
+	return data, contentType, err
+}
+
+func avatarPrefix(userID int) string {
+	return fmt.Sprintf("avatars/%d/", userID)
+}
+
+func avatarKey(userID, size int) string {
+	return fmt.Sprintf("%s%d.jpg", avatarPrefix(userID), size)
+}
+
+func avatarURLs(updatedAt sql.NullTime) map[string]string {
+	if !updatedAt.Valid {
+		return nil
+	}
+	urls := make(map[string]string, len(models.ProfileSettings.AvatarSizes))
+	version := strconv.FormatInt(updatedAt.Time.Unix(), 10)
+	for _, size := range models.ProfileSettings.AvatarSizes {
+		urls[strconv.Itoa(size)] = fmt.Sprintf("/api/v1/profile/avatar/%d?v=%s", size, version)
+	}
+	return urls
+}
+
+// cropSquare copies the centered square of the image onto white, so
+// transparent areas do not turn black once encoded as JPEG.
+func cropSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, offset, draw.Over)
+	return dst
+}
+
+// resize scales a square image by averaging the source pixels that fall into
+// each target pixel, which keeps downscaled avatars smooth.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for i := 0; i < 4; i++ {
+						sum[i] += int(row[sx*4+i])
+					}
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for i := 0; i < 4; i++ {
+				dst.Pix[offset+i] = uint8(sum[i] / count)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/storage"
+)
+
+var (
+	ErrBackupsUnavailable = errors.New("backup storage is not available")
+	ErrBackupNotFound     = errors.New("backup not found")
+	ErrBackupNotConfirmed = errors.New("confirm must repeat the backup name")
+)
+
+const backupPrefix = "backups/"
+
+// backupStore keeps backups on disk in the backup directory rather than in the
+// attachment store, which may live in the database being backed up. The
+// directory is only created once backups are used.
+func (s *Service) backupStore() (*storage.Disk, error) {
+	s.backupsOnce.Do(func() {
+		store, err := storage.NewDisk(config.Get().Backups.Dir)
+		if err != nil {
+			log.Printf("Backup directory unavailable, backups are disabled: %v", err)
+			return
+		}
+		s.backups = store
+	})
+	if s.backups == nil {
+		return nil, ErrBackupsUnavailable
+	}
+	return s.backups, nil
+}
+
+// CreateBackup writes a pg_dump archive of the whole database.
+func (s *Service) CreateBackup(ctx context.Context, trigger string) (models.Backup, error) {
+	store, err := s.backupStore()
+	if err != nil {
+		return models.Backup{}, err
+	}
+
+	now := time.Now().UTC()
+	backup := models.Backup{
+		Name:      fmt.Sprintf("pft-%s-%s.dump", now.Format("20060102-150405"), trigger),
+		Trigger:   trigger,
+		CreatedAt: now,
+	}
+
+	// The dump is piped straight into the store, so a large database is
+	// never held in memory. A failed pg_dump fails the write, which then
+	// leaves nothing behind.
+	dump, output := io.Pipe()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "pg_dump", "--format=custom", "--no-owner", "--no-privileges")
+	cmd.Env = append(os.Environ(), database.ClientEnv()...)
+	cmd.Stdout, cmd.Stderr = output, &stderr
+	if err := cmd.Start(); err != nil {
+		return models.Backup{}, fmt.Errorf("pg_dump failed: %v", err)
+	}
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			err = fmt.Errorf("pg_dump failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		output.CloseWithError(err)
+	}()
+
+	backup.SizeBytes, err = store.Write(ctx, backupPrefix+backup.Name, dump)
+	// Closing the reader stops pg_dump if the store gave up early.
+	dump.Close()
+	if err != nil {
+		return models.Backup{}, err
+	}
+	return backup, nil
+}
+
+// ListBackups returns the stored backups, newest first.
+func (s *Service) ListBackups(ctx context.Context) ([]models.Backup, error) {
+	store, err := s.backupStore()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := store.List(ctx, backupPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []models.Backup{}
+	for _, object := range objects {
+		backup, ok := parseBackupName(path.Base(object.Key))
+		if !ok {
+			continue
+		}
+		backup.SizeBytes = object.Size
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the database contents with the backup. The current
+// state is backed up first so a wrong restore can be undone.
+func (s *Service) RestoreBackup(ctx context.Context, name, confirm string) (models.Backup, error) {
+	store, err := s.backupStore()
+	if err != nil {
+		return models.Backup{}, err
+	}
+	if confirm != name {
+		return models.Backup{}, ErrBackupNotConfirmed
+	}
+	if _, ok := parseBackupName(name); !ok {
+		return models.Backup{}, ErrBackupNotFound
+	}
+
+	dump, err := store.Open(ctx, backupPrefix+name)
+	if err == storage.ErrNotFound {
+		return models.Backup{}, ErrBackupNotFound
+	}
+	if err != nil {
+		return models.Backup{}, err
+	}
+	defer dump.Close()
+
+	safety, err := s.CreateBackup(ctx, models.BackupTriggers.PreRestore)
+	if err != nil {
+		return models.Backup{}, fmt.Errorf("backing up current database: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "pg_restore", "--clean", "--if-exists", "--no-owner", "--no-privileges",
+		"--single-transaction", "--dbname="+database.Name())
+	cmd.Env = append(os.Environ(), database.ClientEnv()...)
+	cmd.Stdin, cmd.Stderr = dump, &stderr
+	if err := cmd.Run(); err != nil {
+		return safety, fmt.Errorf("pg_restore failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return safety, nil
+}
+
+// RunScheduledBackups takes a backup whenever the newest scheduled one is
+// older than BACKUP_INTERVAL and prunes old ones beyond BACKUP_RETENTION. An
+// advisory lock keeps several instances from backing up at the same time.
+func (s *Service) RunScheduledBackups(ctx context.Context) {
+	cfg := config.Get().Backups
+	interval, retention := cfg.Interval, cfg.Retention
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(models.BackupSettings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.scheduledBackup(ctx, interval, retention); err != nil {
+			log.Printf("Error running scheduled backup: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) scheduledBackup(ctx context.Context, interval time.Duration, retention int) error {
+	store, err := s.backupStore()
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext('scheduled_backups'))`).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext('scheduled_backups'))`)
+
+	backups, err := s.ListBackups(ctx)
+	if err != nil {
+		return err
+	}
+
+	var scheduled []models.Backup
+	for _, backup := range backups {
+		if backup.Trigger == models.BackupTriggers.Scheduled {
+			scheduled = append(scheduled, backup)
+		}
+	}
+	if len(scheduled) == 0 || time.Since(scheduled[0].CreatedAt) >= interval {
+		backup, err := s.CreateBackup(ctx, models.BackupTriggers.Scheduled)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created scheduled backup %s (%d bytes)", backup.Name, backup.SizeBytes)
+		scheduled = append([]models.Backup{backup}, scheduled...)
+	}
+
+	for _, backup := range scheduled[min(retention, len(scheduled)):] {
+		if err := store.Delete(ctx, backupPrefix+backup.Name); err != nil {
+			return err
+		}
+		log.Printf("Deleted backup %s beyond retention", backup.Name)
+	}
+	return nil
+}
+
+// parseBackupName reads the creation time and trigger from names such as
+// pft-20240131-020000-scheduled.dump.
+func parseBackupName(name string) (models.Backup, bool) {
+	rest, ok := strings.CutPrefix(name, "pft-")
+	if !ok || !strings.HasSuffix(rest, ".dump") || len(rest) < len("20060102-150405-") {
+		return models.Backup{}, false
+	}
+	createdAt, err := time.Parse("20060102-150405", rest[:15])
+	if err != nil || rest[15] != '-' {
+		return models.Backup{}, false
+	}
+	trigger := strings.TrimSuffix(rest[16:], ".dump")
+	return models.Backup{Name: name, Trigger: trigger, CreatedAt: createdAt}, trigger != ""
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// lockAccountForEntry locks an open account for a balance entry and returns
+// its scope.
+func lockAccountForEntry(ctx context.Context, tx *sql.Tx, userID, accountID int) (string, error) {
+	var scope string
+	var archived bool
+	err := tx.QueryRowContext(ctx, `SELECT scope, archived_at IS NOT NULL FROM accounts WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		accountID, userID).Scan(&scope, &archived)
+	if err == sql.ErrNoRows {
+		return "", ErrAccountNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if archived {
+		return "", ErrAccountArchived
+	}
+	return scope, nil
+}
+
+func insertBalanceEntry(ctx context.Context, tx *sql.Tx, t *models.Transaction) error {
+	query := `INSERT INTO transactions (user_id, account_id, category_id, amount, type, description, date, scope, created_at, updated_at)
+			  VALUES ($1, $2, NULL, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return tx.QueryRowContext(ctx, query, t.UserID, t.AccountID, t.Amount, t.Type, t.Description, t.Date, t.Scope).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// SetOpeningBalance records the balance an account had at date, replacing
+// any earlier opening entry and the starting balance the account was created
+// with, and rebuilds the account balance from the ledger.
+func (s *Service) SetOpeningBalance(ctx context.Context, userID, accountID int, amount float64, date time.Time) (models.Transaction, error) {
+	entry := models.Transaction{
+		UserID:      userID,
+		AccountID:   accountID,
+		Amount:      amount,
+		Type:        models.TransactionTypes.Opening,
+		Description: "Opening balance",
+		Date:        date,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entry, err
+	}
+	defer tx.Rollback()
+
+	if entry.Scope, err = lockAccountForEntry(ctx, tx, userID, accountID); err != nil {
+		return entry, err
+	}
+
+	err = tx.QueryRowContext(ctx, `UPDATE transactions SET amount = $1, date = $2, updated_at = NOW()
+			  WHERE account_id = $3 AND user_id = $4 AND type = $5
+			  RETURNING id, description, scope, created_at, updated_at`,
+		amount, date, accountID, userID, entry.Type).Scan(&entry.ID, &entry.Description, &entry.Scope, &entry.CreatedAt, &entry.UpdatedAt)
+	if err == sql.ErrNoRows {
+		err = insertBalanceEntry(ctx, tx, &entry)
+	}
+	if err != nil {
+		return entry, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts SET opening_balance = 0 WHERE id = $1`, accountID); err != nil {
+		return entry, err
+	}
+	balance, err := RecomputeBalance(ctx, tx, userID, accountID)
+	if err != nil {
+		return entry, err
+	}
+	if err := recordBalanceChanged(ctx, tx, userID, accountID, balance); err != nil {
+		return entry, err
+	}
+	if err := tx.Commit(); err != nil {
+		return entry, err
+	}
+
+	s.TransactionUpdated(userID)
+	return entry, nil
+}
+
+// Reconcile compares a statement balance with the ledger balance at the same
+// moment: the end of day when given, otherwise now. A difference is booked
+// as an adjustment entry on that day, so the history before it is left as
+// it was.
+func (s *Service) Reconcile(ctx context.Context, userID, accountID int, statementBalance float64, day *time.Time, description string) (models.Reconciliation, error) {
+	result := models.Reconciliation{AccountID: accountID, StatementBalance: statementBalance}
+
+	now := time.Now()
+	at, until := now, now
+	if day != nil {
+		at, until = *day, day.AddDate(0, 0, 1)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	scope, err := lockAccountForEntry(ctx, tx, userID, accountID)
+	if err != nil {
+		return result, err
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT a.opening_balance + COALESCE((
+			  SELECT SUM(CASE WHEN t.type = 'expense' THEN -t.amount ELSE t.amount END)
+			  FROM transactions t WHERE t.account_id = a.id AND t.date < $2), 0)
+			  FROM accounts a WHERE a.id = $1`, accountID, until).Scan(&result.LedgerBalance)
+	if err != nil {
+		return result, err
+	}
+
+	result.Difference = math.Round((statementBalance-result.LedgerBalance)*100) / 100
+	if result.Difference != 0 {
+		if description == "" {
+			description = "Balance adjustment"
+		}
+		result.Adjustment = &models.Transaction{
+			UserID:      userID,
+			AccountID:   accountID,
+			Amount:      result.Difference,
+			Type:        models.TransactionTypes.Adjustment,
+			Description: description,
+			Date:        at,
+			Scope:       scope,
+		}
+		if err := insertBalanceEntry(ctx, tx, result.Adjustment); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Balance, err = RecomputeBalance(ctx, tx, userID, accountID); err != nil {
+		return result, err
+	}
+	if result.Adjustment != nil {
+		if err := recordBalanceChanged(ctx, tx, userID, accountID, result.Balance); err != nil {
+			return result, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+
+	if result.Adjustment != nil {
+		s.TransactionUpdated(userID)
+	}
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrInvalidGranularity = errors.New("granularity must be day, week or month")
+	ErrHistoryRange       = errors.New("start_date must not be after end_date, and the range must cover at most 1000 points")
+)
+
+// historyBucket returns the start of the bucket containing day.
+func historyBucket(day time.Time, granularity string, firstDayOfWeek int) time.Time {
+	switch granularity {
+	case models.Granularities.Week:
+		return WeekStart(day, firstDayOfWeek)
+	case models.Granularities.Month:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+}
+
+func nextBucket(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.Granularities.Week:
+		return start.AddDate(0, 0, 7)
+	case models.Granularities.Month:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// BalanceHistory returns the account's closing balance for each bucket from
+// start to end, both local days. Balances are worked back from the current
+// balance through the ledger rather than read from snapshots, so backdated,
+// edited and deleted transactions move every point after their date. A zero
+// start picks the default span for the granularity.
+func (s *Service) BalanceHistory(ctx context.Context, userID, accountID int, granularity string, start, end time.Time) (models.BalanceHistory, error) {
+	history := models.BalanceHistory{AccountID: accountID, Granularity: granularity}
+
+	switch granularity {
+	case models.Granularities.Day, models.Granularities.Week, models.Granularities.Month:
+	default:
+		return history, ErrInvalidGranularity
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return history, err
+	}
+	loc := SettingsLocation(settings)
+
+	end = historyBucket(end.In(loc), models.Granularities.Day, 0)
+	if start.IsZero() {
+		span := models.BalanceHistorySettings.DefaultSpans[granularity]
+		start = historyBucket(end, granularity, settings.FirstDayOfWeek)
+		for i := 1; i < span; i++ {
+			start = historyBucket(start.AddDate(0, 0, -1), granularity, settings.FirstDayOfWeek)
+		}
+	}
+	start = historyBucket(start.In(loc), granularity, settings.FirstDayOfWeek)
+	if start.After(end) {
+		return history, ErrHistoryRange
+	}
+
+	var buckets []time.Time
+	for b := start; !b.After(end); b = nextBucket(b, granularity) {
+		if len(buckets) == models.BalanceHistorySettings.MaxPoints {
+			return history, ErrHistoryRange
+		}
+		buckets = append(buckets, b)
+	}
+
+	// One snapshot for the balance and the ledger, so a transaction saved
+	// between the two reads cannot skew every point.
+	tx, err := s.ReadDB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return history, err
+	}
+	defer tx.Rollback()
+
+	var balance float64
+	err = tx.QueryRowContext(ctx, `SELECT balance, currency FROM accounts WHERE id = $1 AND user_id = $2`,
+		accountID, userID).Scan(&balance, &history.Currency)
+	if err == sql.ErrNoRows {
+		return history, ErrAccountNotFound
+	}
+	if err != nil {
+		return history, err
+	}
+
+	query := `SELECT (date AT TIME ZONE $3)::date,
+			  COALESCE(SUM(amount) FILTER (WHERE type = 'income'), 0),
+			  COALESCE(SUM(amount) FILTER (WHERE type = 'expense'), 0),
+			  COALESCE(SUM(amount) FILTER (WHERE type NOT IN ('income', 'expense')), 0),
+			  COUNT(*)
+			  FROM transactions
+			  WHERE account_id = $1 AND user_id = $2 AND date >= $4
+			  GROUP BY 1
+			  ORDER BY 1`
+
+	rows, err := tx.QueryContext(ctx, query, accountID, userID, loc.String(), start)
+	if err != nil {
+		return history, err
+	}
+	defer rows.Close()
+
+	history.Points = make([]models.BalancePoint, len(buckets))
+	for i, b := range buckets {
+		history.Points[i].Date = b.Format("2006-01-02")
+	}
+
+	// Everything from start onwards is backed out of the current balance to
+	// get the opening balance; days past end only count towards that.
+	i := 0
+	for rows.Next() {
+		var day time.Time
+		var income, expenses, adjustments float64
+		var count int
+		if err := rows.Scan(&day, &income, &expenses, &adjustments, &count); err != nil {
+			return history, err
+		}
+		balance -= income - expenses + adjustments
+
+		local := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		if local.After(end) {
+			continue
+		}
+		for i+1 < len(buckets) && !local.Before(buckets[i+1]) {
+			i++
+		}
+		history.Points[i].Income += income
+		history.Points[i].Expenses += expenses
+		history.Points[i].Adjustments += adjustments
+		history.Points[i].Transactions += count
+	}
+	if err := rows.Err(); err != nil {
+		return history, err
+	}
+
+	for i := range history.Points {
+		balance += history.Points[i].Income - history.Points[i].Expenses + history.Points[i].Adjustments
+		history.Points[i].Balance = math.Round(balance*100) / 100
+	}
+
+	history.StartDate = start.Format("2006-01-02")
+	history.EndDate = end.Format("2006-01-02")
+	return history, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// bankChargePatterns tell bank charges apart by description or category
+// name. Overdraft and interest come first, as their descriptions often
+// mention a fee too.
+var bankChargePatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{models.BankChargeKinds.Overdraft, regexp.MustCompile(`(?i)\b(?:overdraft|nsf\b|insufficient funds|returned item|przekroczeni)`)},
+	{models.BankChargeKinds.Interest, regexp.MustCompile(`(?i)\b(?:interest\b|odset|finance charge)`)},
+	{models.BankChargeKinds.Fee, regexp.MustCompile(`(?i)\b(?:fees?\b|prowizj|commission|service charge|maintenance charge|opłata za (?:prowadzenie|kart))`)},
+}
+
+// bankCategory marks a category of bank charges. Its expenses are fees
+// unless their description says otherwise.
+var bankCategory = regexp.MustCompile(`(?i)\bbank`)
+
+// bankChargeHints narrow expenses down in SQL before bankChargePatterns
+// decide.
+var bankChargeHints = []string{"%fee%", "%prowizj%", "%commission%", "%charge%", "%opłata za%", "%interest%",
+	"%odset%", "%overdraft%", "%nsf%", "%insufficient funds%", "%returned item%", "%przekroczeni%", "%bank%"}
+
+func classifyBankCharge(description, category string) (string, bool) {
+	for _, p := range bankChargePatterns {
+		if p.pattern.MatchString(description) {
+			return p.kind, true
+		}
+	}
+	for _, p := range bankChargePatterns {
+		if p.pattern.MatchString(category) {
+			return p.kind, true
+		}
+	}
+	if bankCategory.MatchString(category) {
+		return models.BankChargeKinds.Fee, true
+	}
+	return "", false
+}
+
+// bankCharges lists the expenses in [start, end) that are bank fees,
+// overdraft charges or interest, oldest first.
+func (s *Service) bankCharges(ctx context.Context, userID int, start, end time.Time) ([]models.BankFeeTransaction, error) {
+	loc := s.Location(ctx, userID)
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT t.id, t.date, COALESCE(t.description, ''), COALESCE(c.name, ''), t.amount
+			  FROM transactions t
+			  LEFT JOIN categories c ON c.id = t.category_id
+			  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date < $3
+				AND (t.description ILIKE ANY($4) OR c.name ILIKE ANY($4))
+			  ORDER BY t.date, t.id`, userID, start, end, pq.Array(bankChargeHints))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	charges := []models.BankFeeTransaction{}
+	for rows.Next() {
+		var charge models.BankFeeTransaction
+		var date time.Time
+		if err := rows.Scan(&charge.TransactionID, &date, &charge.Description, &charge.CategoryName, &charge.Amount); err != nil {
+			return nil, err
+		}
+		kind, ok := classifyBankCharge(charge.Description, charge.CategoryName)
+		if !ok {
+			continue
+		}
+		charge.Kind = kind
+		charge.Date = date.In(loc).Format("2006-01-02")
+		charges = append(charges, charge)
+	}
+	return charges, rows.Err()
+}
+
+// BankFees breaks down a calendar year of bank charges in the user's
+// timezone by kind and month.
+func (s *Service) BankFees(ctx context.Context, userID, year int) (models.BankFees, error) {
+	fees := models.BankFees{Year: year}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return fees, err
+	}
+	fees.Currency = settings.BaseCurrency
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, SettingsLocation(settings))
+
+	if fees.Transactions, err = s.bankCharges(ctx, userID, start, start.AddDate(1, 0, 0)); err != nil {
+		return fees, err
+	}
+
+	fees.Kinds = []models.BankFeeKind{
+		{Kind: models.BankChargeKinds.Fee},
+		{Kind: models.BankChargeKinds.Overdraft},
+		{Kind: models.BankChargeKinds.Interest},
+	}
+	fees.Months = make([]models.BankFeeMonth, 12)
+	for i := range fees.Months {
+		fees.Months[i].Month = start.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	for _, charge := range fees.Transactions {
+		fees.Total += charge.Amount
+		fees.Count++
+		for i := range fees.Kinds {
+			if fees.Kinds[i].Kind == charge.Kind {
+				fees.Kinds[i].Amount += charge.Amount
+				fees.Kinds[i].Count++
+			}
+		}
+		month, _ := time.Parse("2006-01-02", charge.Date)
+		fees.Months[month.Month()-1].Amount += charge.Amount
+	}
+
+	fees.Total = math.Round(fees.Total*100) / 100
+	for i := range fees.Kinds {
+		fees.Kinds[i].Amount = math.Round(fees.Kinds[i].Amount*100) / 100
+	}
+	for i := range fees.Months {
+		fees.Months[i].Amount = math.Round(fees.Months[i].Amount*100) / 100
+	}
+	return fees, nil
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/billing"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrBillingDisabled   = errors.New("billing is not enabled")
+	ErrPlanNotForSale    = errors.New("plan cannot be bought")
+	ErrAlreadySubscribed = errors.New("already subscribed, change the plan in the billing portal")
+	ErrNoBillingAccount  = errors.New("no billing account yet, subscribe to a plan first")
+)
+
+// BillingStatus reports the user's subscription, the limits in effect and
+// the plans on sale.
+func (s *Service) BillingStatus(ctx context.Context, userID int) (models.BillingStatus, error) {
+	status := models.BillingStatus{Enabled: s.billing != nil, Plans: []models.Plan{}}
+
+	var plan sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT status, plan_id, current_period_end, cancel_at_period_end, grace_until
+			  FROM billing_customers WHERE user_id = $1`, userID).
+		Scan(&status.Status, &plan, &status.CurrentPeriodEnd, &status.CancelAtPeriodEnd, &status.GraceUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return status, err
+	}
+	status.Plan = plan.String
+
+	if status.Limits, err = s.LimitsStatus(ctx, userID); err != nil {
+		return status, err
+	}
+
+	plans, err := s.Plans(ctx)
+	if err != nil {
+		return status, err
+	}
+	for _, p := range plans {
+		if p.StripePriceID != "" {
+			status.Plans = append(status.Plans, p)
+		}
+	}
+	return status, nil
+}
+
+// StartCheckout returns the URL of a Stripe Checkout page subscribing the
+// user to a plan. Subscribers change plans in the billing portal instead.
+func (s *Service) StartCheckout(ctx context.Context, userID int, planID string) (string, error) {
+	if s.billing == nil {
+		return "", ErrBillingDisabled
+	}
+
+	var priceID string
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(stripe_price_id, '') FROM plans WHERE id = $1`, planID).Scan(&priceID)
+	if err == sql.ErrNoRows {
+		return "", ErrPlanNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if priceID == "" {
+		return "", ErrPlanNotForSale
+	}
+
+	var status string
+	err = s.db.QueryRowContext(ctx, `SELECT status FROM billing_customers WHERE user_id = $1`, userID).Scan(&status)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	switch status {
+	case models.BillingStatuses.Active, models.BillingStatuses.Trialing, models.BillingStatuses.PastDue, models.BillingStatuses.Unpaid:
+		return "", ErrAlreadySubscribed
+	}
+
+	customerID, err := s.billingCustomer(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return s.billing.CreateCheckoutSession(ctx, customerID, priceID, billingReturnURL("success"), billingReturnURL("canceled"))
+}
+
+// BillingPortal returns the URL of the Stripe billing portal, where the user
+// changes or cancels their plan and manages payment methods and invoices.
+func (s *Service) BillingPortal(ctx context.Context, userID int) (string, error) {
+	if s.billing == nil {
+		return "", ErrBillingDisabled
+	}
+
+	var customerID string
+	err := s.db.QueryRowContext(ctx, `SELECT stripe_customer_id FROM billing_customers WHERE user_id = $1`, userID).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return "", ErrNoBillingAccount
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.billing.CreatePortalSession(ctx, customerID, billingReturnURL(""))
+}
+
+// billingCustomer returns the user's Stripe customer, creating it on their
+// first checkout.
+func (s *Service) billingCustomer(ctx context.Context, userID int) (string, error) {
+	var customerID string
+	err := s.db.QueryRowContext(ctx, `SELECT stripe_customer_id FROM billing_customers WHERE user_id = $1`, userID).Scan(&customerID)
+	if err != sql.ErrNoRows {
+		return customerID, err
+	}
+
+	var email string
+	if err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	if customerID, err = s.billing.CreateCustomer(ctx, email, userID); err != nil {
+		return "", err
+	}
+
+	// A concurrent checkout may have created another customer meanwhile; the
+	// first one stored wins and the other stays unused in Stripe.
+	_, err = s.db.ExecContext(ctx, `INSERT INTO billing_customers (user_id, stripe_customer_id, created_at, updated_at)
+			  VALUES ($1, $2, NOW(), NOW()) ON CONFLICT (user_id) DO NOTHING`, userID, customerID)
+	if err != nil {
+		return "", err
+	}
+	err = s.db.QueryRowContext(ctx, `SELECT stripe_customer_id FROM billing_customers WHERE user_id = $1`, userID).Scan(&customerID)
+	return customerID, err
+}
+
+func billingReturnURL(result string) string {
+	cfg := config.Get()
+	base := cfg.Billing.ReturnURL
+	if base == "" {
+		base = strings.TrimSuffix(cfg.Server.BaseURL, "/") + "/app/"
+	}
+	if result == "" {
+		return base
+	}
+	if strings.Contains(base, "?") {
+		return base + "&billing=" + result
+	}
+	return base + "?billing=" + result
+}
+
+// HandleBillingEvent applies a signed Stripe webhook event to the
+// subscription of the customer it is about. Events are applied once, and
+// never over a newer one.
+func (s *Service) HandleBillingEvent(ctx context.Context, payload []byte, signature string) error {
+	if s.billing == nil {
+		return ErrBillingDisabled
+	}
+
+	event, err := s.billing.ParseEvent(payload, signature, models.BillingSettings.WebhookTolerance)
+	if err != nil {
+		return err
+	}
+
+	var subscription *billing.Subscription
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		subscription = &billing.Subscription{}
+		if err := json.Unmarshal(event.Data.Object, subscription); err != nil {
+			return err
+		}
+	case "checkout.session.completed":
+		var session struct {
+			Subscription string `json:"subscription"`
+		}
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			return err
+		}
+		if session.Subscription == "" {
+			return nil
+		}
+		if subscription, err = s.billing.Subscription(ctx, session.Subscription); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO billing_events (id, type, received_at) VALUES ($1, $2, NOW())
+			  ON CONFLICT (id) DO NOTHING`, event.ID, event.Type)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	if err := s.applySubscription(ctx, tx, subscription, time.Unix(event.Created, 0).UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applySubscription records a subscription's state and gives its customer
+// the plan they pay for. Past due subscriptions keep the plan until the
+// grace period ends; canceled ones fall back to the default plan.
+func (s *Service) applySubscription(ctx context.Context, tx *sql.Tx, sub *billing.Subscription, eventAt time.Time) error {
+	var userID int
+	var currentPlan sql.NullString
+	var lastEventAt, graceUntil *time.Time
+	err := tx.QueryRowContext(ctx, `SELECT b.user_id, u.plan_id, b.event_at, b.grace_until
+			  FROM billing_customers b JOIN users u ON u.id = b.user_id
+			  WHERE b.stripe_customer_id = $1 FOR UPDATE OF b`, sub.Customer).
+		Scan(&userID, &currentPlan, &lastEventAt, &graceUntil)
+	if err == sql.ErrNoRows {
+		log.Printf("Ignoring Stripe subscription %s of unknown customer %s", sub.ID, sub.Customer)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lastEventAt != nil && eventAt.Before(*lastEventAt) {
+		return nil
+	}
+
+	var planID string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM plans WHERE stripe_price_id = $1`, sub.PriceID()).Scan(&planID)
+	if err == sql.ErrNoRows {
+		log.Printf("Stripe price %s of subscription %s is not mapped to a plan", sub.PriceID(), sub.ID)
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	userPlan := currentPlan.String
+	switch sub.Status {
+	case models.BillingStatuses.Active, models.BillingStatuses.Trialing:
+		userPlan, graceUntil = planID, nil
+	case models.BillingStatuses.PastDue, models.BillingStatuses.Unpaid:
+		if graceUntil == nil {
+			until := now.Add(config.Get().Billing.GracePeriod)
+			graceUntil = &until
+		}
+		userPlan = planID
+		if !graceUntil.After(now) {
+			userPlan = ""
+		}
+	case models.BillingStatuses.Canceled, models.BillingStatuses.IncompleteExpired:
+		userPlan, graceUntil = "", nil
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE billing_customers SET subscription_id = $2, status = $3, plan_id = NULLIF($4, ''),
+			  current_period_end = $5, cancel_at_period_end = $6, grace_until = $7, event_at = $8, updated_at = NOW()
+			  WHERE user_id = $1`,
+		userID, sub.ID, sub.Status, planID, sub.PeriodEnd(), sub.CancelAtPeriodEnd, graceUntil, eventAt)
+	if err != nil {
+		return err
+	}
+
+	if userPlan == currentPlan.String {
+		return nil
+	}
+	return setBillingPlan(ctx, tx, userID, currentPlan.String, userPlan, sub.Status)
+}
+
+func setBillingPlan(ctx context.Context, tx *sql.Tx, userID int, from, to, status string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE users SET plan_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1`, userID, to)
+	if err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, models.AuditEntry{
+		UserID:     userID,
+		Action:     models.AuditActions.BillingPlan,
+		EntityType: "plan",
+		Details:    map[string]interface{}{"plan": to, "previous_plan": from, "status": status},
+	})
+}
+
+// RunBillingGracePeriods ends the plans of subscribers whose failed payment
+// was not settled within the grace period.
+func (s *Service) RunBillingGracePeriods(ctx context.Context) {
+	if s.billing == nil {
+		return
+	}
+
+	ticker := time.NewTicker(models.BillingSettings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.endGracePeriods(ctx); err != nil {
+			log.Printf("Error ending billing grace periods: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) endGracePeriods(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT b.user_id, b.plan_id, b.status
+			  FROM billing_customers b JOIN users u ON u.id = b.user_id
+			  WHERE b.grace_until <= NOW() AND u.plan_id = b.plan_id`)
+	if err != nil {
+		return err
+	}
+
+	type expired struct {
+		userID int
+		plan   string
+		status string
+	}
+	var users []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.userID, &e.plan, &e.status); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range users {
+		if err := s.endGracePeriod(ctx, e.userID, e.plan, e.status); err != nil {
+			log.Printf("Error ending billing grace period of user %d: %v", e.userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) endGracePeriod(ctx context.Context, userID int, plan, status string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// A payment settled since the query clears grace_until; skip the user then.
+	var stillExpired bool
+	err = tx.QueryRowContext(ctx, `SELECT grace_until <= NOW() FROM billing_customers WHERE user_id = $1 AND grace_until IS NOT NULL
+			  FOR UPDATE`, userID).Scan(&stillExpired)
+	if err == sql.ErrNoRows || (err == nil && !stillExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setBillingPlan(ctx, tx, userID, plan, "", status); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// cancelSubscription ends the user's Stripe subscription, so that deleted
+// users are not charged again.
+func (s *Service) cancelSubscription(ctx context.Context, userID int) error {
+	if s.billing == nil {
+		return nil
+	}
+
+	var subscriptionID sql.NullString
+	var status string
+	err := s.db.QueryRowContext(ctx, `SELECT subscription_id, status FROM billing_customers WHERE user_id = $1`, userID).
+		Scan(&subscriptionID, &status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !subscriptionID.Valid || status == models.BillingStatuses.Canceled || status == models.BillingStatuses.IncompleteExpired {
+		return nil
+	}
+	return s.billing.CancelSubscription(ctx, subscriptionID.String)
+}
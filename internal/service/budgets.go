@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+type BudgetStatus struct {
+	CategoryID   int
+	CategoryName string
+	Budget       float64
+	Spent        float64
+}
+
+// MonthlyBudgetStatus compares monthly budgets with spending in the user's
+// financial month containing at, which may start mid-month.
+func (s *Service) MonthlyBudgetStatus(ctx context.Context, userID, categoryID int, at time.Time) (*BudgetStatus, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	monthStart := FiscalMonthStart(at.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	status := &BudgetStatus{CategoryID: categoryID}
+
+	query := `
+		SELECT c.name, COALESCE(SUM(br.amount), 0)
+		FROM categories c
+		LEFT JOIN budget_rules br ON br.category_id = c.id
+			AND br.user_id = c.user_id
+			AND br.period = 'monthly'
+			AND br.start_date < $4
+			AND (br.end_date IS NULL OR br.end_date >= $3)
+		WHERE c.id = $1 AND c.user_id = $2
+		GROUP BY c.name`
+
+	err = s.db.QueryRowContext(ctx, query, categoryID, userID, monthStart, monthEnd).Scan(&status.CategoryName, &status.Budget)
+	if err != nil {
+		return nil, err
+	}
+
+	spentQuery := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE user_id = $1 AND category_id = $2 AND type = 'expense' AND date >= $3 AND date < $4`
+
+	err = s.db.QueryRowContext(ctx, spentQuery, userID, categoryID, monthStart, monthEnd).Scan(&status.Spent)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// BudgetOverview lists every category and category group with a monthly
+// budget in the user's financial month containing at, with what has been
+// spent against it. The totals cover category budgets only, since group
+// budgets overlap them.
+func (s *Service) BudgetOverview(ctx context.Context, userID int, at time.Time) (models.BudgetOverview, error) {
+	overview := models.BudgetOverview{Categories: []models.CategoryBudgetStatus{}, Groups: []models.GroupBudgetStatus{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return overview, err
+	}
+	monthStart := FiscalMonthStart(at.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	overview.PeriodStart = monthStart.Format("2006-01-02")
+	overview.PeriodEnd = monthEnd.AddDate(0, 0, -1).Format("2006-01-02")
+	overview.Currency = settings.BaseCurrency
+
+	query := `
+		SELECT c.id, c.name, b.budget, COALESCE(SUM(t.amount), 0)
+		FROM (
+			SELECT category_id, SUM(amount) AS budget
+			FROM budget_rules
+			WHERE user_id = $1 AND period = 'monthly' AND start_date < $3
+				AND (end_date IS NULL OR end_date >= $2)
+			GROUP BY category_id
+		) b
+		JOIN categories c ON c.id = b.category_id AND c.user_id = $1
+		LEFT JOIN transactions t ON t.category_id = c.id AND t.user_id = $1
+			AND t.type = 'expense' AND t.date >= $2 AND t.date < $3
+		GROUP BY c.id, c.name, b.budget
+		ORDER BY c.name`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, monthStart, monthEnd)
+	if err != nil {
+		return overview, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.CategoryBudgetStatus
+		if err := rows.Scan(&status.CategoryID, &status.CategoryName, &status.Budget, &status.Spent); err != nil {
+			return overview, err
+		}
+		status.Remaining = status.Budget - status.Spent
+		if status.Budget > 0 {
+			status.PercentUsed = math.Round(status.Spent/status.Budget*1000) / 10
+		}
+
+		overview.Budget += status.Budget
+		overview.Spent += status.Spent
+		overview.Categories = append(overview.Categories, status)
+	}
+	overview.Remaining = overview.Budget - overview.Spent
+	if err := rows.Err(); err != nil {
+		return overview, err
+	}
+
+	overview.Groups, err = s.groupBudgets(ctx, userID, monthStart, monthEnd)
+	return overview, err
+}
+
+func (s *Service) checkBudgetAlerts(ctx context.Context, t models.Transaction) error {
+	status, err := s.MonthlyBudgetStatus(ctx, t.UserID, t.CategoryID, t.Date)
+	if err != nil {
+		return err
+	}
+	if status.Budget <= 0 {
+		return nil
+	}
+
+	before := status.Spent - t.Amount
+	warningLevel := status.Budget * models.BudgetAlertSettings.WarningRatio
+
+	l, currency := s.localizer(t.UserID)
+	spent, budget := l.Amount(status.Spent, currency), l.Amount(status.Budget, currency)
+
+	var notification notifications.Notification
+	switch {
+	case before <= status.Budget && status.Spent > status.Budget:
+		notification = notifications.Notification{
+			Type:    notifications.Types.BudgetExceeded,
+			Title:   l.T("Budget exceeded: %s", status.CategoryName),
+			Message: l.T("You have spent %s of your %s monthly budget.", spent, budget),
+		}
+	case before < warningLevel && status.Spent >= warningLevel:
+		notification = notifications.Notification{
+			Type:    notifications.Types.BudgetWarning,
+			Title:   l.T("Budget almost used: %s", status.CategoryName),
+			Message: l.T("You have spent %s of your %s monthly budget (%s%%).", spent, budget, l.Number(status.Spent/status.Budget*100, 0)),
+		}
+	default:
+		return nil
+	}
+
+	notification.UserID = t.UserID
+	notification.Data = map[string]interface{}{
+		"category_id": status.CategoryID,
+		"budget":      status.Budget,
+		"spent":       status.Spent,
+	}
+
+	s.notifier.Dispatch(notification)
+	err = events.Record(ctx, s.db, events.Event{
+		Type:   events.Types.BudgetThresholdCrossed,
+		UserID: t.UserID,
+		Data: map[string]interface{}{
+			"level":          notification.Type,
+			"category_id":    status.CategoryID,
+			"transaction_id": t.ID,
+			"budget":         status.Budget,
+			"spent":          status.Spent,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.outbox.Wake()
+	return nil
+}
+
+// BudgetCapError is returned when an expense would take a hard-capped
+// category over its monthly budget. Status describes the budget before it.
+type BudgetCapError struct {
+	Status models.CategoryBudgetStatus
+}
+
+func (e *BudgetCapError) Error() string {
+	return ErrBudgetCapExceeded.Error()
+}
+
+func (e *BudgetCapError) Is(target error) bool {
+	return target == ErrBudgetCapExceeded
+}
+
+// budgetCapExceeded checks an expense against the hard-capped monthly budget
+// of its category, if there is one. The budget rules stay locked until the
+// transaction ends, so concurrent expenses cannot both slip under the cap.
+func (s *Service) budgetCapExceeded(ctx context.Context, tx *sql.Tx, t *models.Transaction) (*BudgetCapError, error) {
+	if t.Type != "expense" {
+		return nil, nil
+	}
+
+	settings, err := s.GetSettings(ctx, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	monthStart := FiscalMonthStart(t.Date.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	rows, err := tx.QueryContext(ctx, `SELECT amount, hard_cap FROM budget_rules
+			  WHERE user_id = $1 AND category_id = $2 AND period = 'monthly' AND start_date < $4
+				AND (end_date IS NULL OR end_date >= $3)
+			  FOR UPDATE`, t.UserID, t.CategoryID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+	var budget float64
+	capped := false
+	for rows.Next() {
+		var amount float64
+		var hardCap bool
+		if err := rows.Scan(&amount, &hardCap); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		budget += amount
+		capped = capped || hardCap
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || !capped {
+		return nil, err
+	}
+
+	status := models.CategoryBudgetStatus{CategoryID: t.CategoryID, Budget: budget}
+	err = tx.QueryRowContext(ctx, `SELECT c.name, COALESCE((SELECT SUM(amount) FROM transactions
+			  WHERE user_id = $2 AND category_id = $1 AND type = 'expense' AND date >= $3 AND date < $4), 0)
+			  FROM categories c WHERE c.id = $1 AND c.user_id = $2`, t.CategoryID, t.UserID, monthStart, monthEnd).
+		Scan(&status.CategoryName, &status.Spent)
+	if err != nil {
+		return nil, err
+	}
+	if status.Spent+t.Amount <= status.Budget {
+		return nil, nil
+	}
+
+	status.Remaining = status.Budget - status.Spent
+	if status.Budget > 0 {
+		status.PercentUsed = math.Round(status.Spent/status.Budget*1000) / 10
+	}
+	return &BudgetCapError{Status: status}, nil
+}
+
+const budgetRuleColumns = `id, user_id, category_id, amount, period, start_date, end_date, hard_cap, created_at, updated_at`
+
+func scanBudgetRule(row interface{ Scan(...interface{}) error }, b *models.BudgetRule) error {
+	return row.Scan(&b.ID, &b.UserID, &b.CategoryID, &b.Amount, &b.Period, &b.StartDate, &b.EndDate,
+		&b.HardCap, &b.CreatedAt, &b.UpdatedAt)
+}
+
+func (s *Service) GetBudgetRules(ctx context.Context, userID int) ([]models.BudgetRule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+budgetRuleColumns+` FROM budget_rules
+			  WHERE user_id = $1 ORDER BY start_date DESC, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.BudgetRule{}
+	for rows.Next() {
+		var rule models.BudgetRule
+		if err := scanBudgetRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Service) CreateBudgetRule(ctx context.Context, b *models.BudgetRule) error {
+	if err := ensureOwned(ctx, s.db, "categories", b.CategoryID, b.UserID); err != nil {
+		return err
+	}
+
+	b.Period = "monthly"
+	query := `INSERT INTO budget_rules (user_id, category_id, amount, period, start_date, end_date, hard_cap, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return s.db.QueryRowContext(ctx, query, b.UserID, b.CategoryID, b.Amount, b.Period, b.StartDate, b.EndDate, b.HardCap).
+		Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+}
+
+func (s *Service) UpdateBudgetRule(ctx context.Context, b *models.BudgetRule) error {
+	if err := ensureOwned(ctx, s.db, "categories", b.CategoryID, b.UserID); err != nil {
+		return err
+	}
+
+	query := `UPDATE budget_rules SET category_id = $1, amount = $2, start_date = $3, end_date = $4, hard_cap = $5, updated_at = NOW()
+			  WHERE id = $6 AND user_id = $7 RETURNING period, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, b.CategoryID, b.Amount, b.StartDate, b.EndDate, b.HardCap, b.ID, b.UserID).
+		Scan(&b.Period, &b.CreatedAt, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrBudgetNotFound
+	}
+	return err
+}
+
+func (s *Service) DeleteBudgetRule(ctx context.Context, userID, ruleID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM budget_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/cache"
+)
+
+func (s *Service) Cache() cache.Store {
+	return s.cache
+}
+
+// UserCacheVersion returns a counter that changes whenever the user's data is
+// written, so cached responses can be keyed by it instead of being purged.
+func (s *Service) UserCacheVersion(userID int) int64 {
+	value, ok, err := s.cache.Get(context.Background(), userVersionKey(userID))
+	if err != nil || !ok {
+		return 0
+	}
+	version, _ := strconv.ParseInt(string(value), 10, 64)
+	return version
+}
+
+func (s *Service) LastModified(userID int) (time.Time, bool) {
+	value, ok, err := s.cache.Get(context.Background(), fmt.Sprintf("user:%d:modified", userID))
+	if err != nil || !ok {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0).UTC(), true
+}
+
+func (s *Service) InvalidateUserCache(userID int) {
+	ctx := context.Background()
+	if _, err := s.cache.Incr(ctx, userVersionKey(userID), 0); err != nil {
+		log.Printf("Error invalidating cache for user %d: %v", userID, err)
+	}
+	now := []byte(strconv.FormatInt(time.Now().Unix(), 10))
+	if err := s.cache.Set(ctx, fmt.Sprintf("user:%d:modified", userID), now, 0); err != nil {
+		log.Printf("Error recording last modification for user %d: %v", userID, err)
+	}
+}
+
+func userVersionKey(userID int) string {
+	return fmt.Sprintf("user:%d:version", userID)
+}
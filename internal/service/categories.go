@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"personal-finance-tracker/internal/classifier"
+	"personal-finance-tracker/internal/models"
+)
+
+func (s *Service) ClassifierExamples(ctx context.Context, userID int, transactionType string) ([]classifier.Example, map[int]string, error) {
+	query := `SELECT t.category_id, c.name, t.description, t.amount
+			  FROM transactions t
+			  JOIN categories c ON c.id = t.category_id AND c.user_id = t.user_id
+			  WHERE t.user_id = $1 AND ($2 = '' OR t.type = $2)
+			  ORDER BY t.date DESC
+			  LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, transactionType, models.ClassifierSettings.TrainingLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var examples []classifier.Example
+	categoryNames := make(map[int]string)
+	for rows.Next() {
+		var ex classifier.Example
+		var name string
+		if err := rows.Scan(&ex.CategoryID, &name, &ex.Description, &ex.Amount); err != nil {
+			return nil, nil, err
+		}
+		categoryNames[ex.CategoryID] = name
+		examples = append(examples, ex)
+	}
+
+	return examples, categoryNames, rows.Err()
+}
+
+func (s *Service) SuggestCategory(ctx context.Context, userID int, description string, amount float64, transactionType string) (int, error) {
+	examples, _, err := s.ClassifierExamples(ctx, userID, transactionType)
+	if err != nil {
+		return 0, err
+	}
+
+	return predictCategory(ctx, s.db, classifier.Train(examples), userID, description, amount, transactionType)
+}
+
+// predictCategory returns the model's best guess or, when it has none, the
+// user's oldest category of the type.
+func predictCategory(ctx context.Context, q queryRower, model *classifier.Model, userID int, description string, amount float64, transactionType string) (int, error) {
+	if predictions := model.Predict(description, amount); len(predictions) > 0 {
+		return predictions[0].CategoryID, nil
+	}
+
+	var categoryID int
+	err := q.QueryRowContext(ctx, `SELECT id FROM categories WHERE user_id = $1 AND type = $2 ORDER BY id LIMIT 1`,
+		userID, transactionType).Scan(&categoryID)
+	if err == sql.ErrNoRows {
+		return 0, ErrCategoryNotFound
+	}
+	return categoryID, err
+}
+
+func (s *Service) GetCategories(ctx context.Context, userID int, categoryType string) ([]models.Category, error) {
+	query := `SELECT id, user_id, name, type, COALESCE(color, ''), COALESCE(icon, ''), parent_id, created_at, updated_at,
+			  tax_deductible, tax_code, group_id
+			  FROM categories WHERE user_id = $1 AND ($2 = '' OR type = $2) ORDER BY name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, categoryType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.UserID, &category.Name, &category.Type, &category.Color,
+			&category.Icon, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.TaxDeductible,
+			&category.TaxCode, &category.GroupID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (s *Service) CreateCategory(ctx context.Context, c *models.Category) error {
+	if err := normalizeAppearance(c); err != nil {
+		return err
+	}
+	if c.ParentID != nil {
+		if err := ensureOwned(ctx, s.db, "categories", *c.ParentID, c.UserID); err != nil {
+			return err
+		}
+	}
+	if c.GroupID != nil {
+		if err := ensureOwned(ctx, s.db, "category_groups", *c.GroupID, c.UserID); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO categories (user_id, name, type, color, icon, parent_id, tax_deductible, tax_code, group_id, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return s.db.QueryRowContext(ctx, query, c.UserID, c.Name, c.Type, c.Color, c.Icon, c.ParentID, c.TaxDeductible, c.TaxCode, c.GroupID).
+		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (s *Service) UpdateCategory(ctx context.Context, c *models.Category) error {
+	if err := normalizeAppearance(c); err != nil {
+		return err
+	}
+	if c.ParentID != nil {
+		if *c.ParentID == c.ID {
+			return ErrCategoryNotFound
+		}
+		if err := ensureOwned(ctx, s.db, "categories", *c.ParentID, c.UserID); err != nil {
+			return err
+		}
+	}
+	if c.GroupID != nil {
+		if err := ensureOwned(ctx, s.db, "category_groups", *c.GroupID, c.UserID); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE categories SET name = $1, type = $2, color = $3, icon = $4, parent_id = $5, tax_deductible = $6,
+			  tax_code = $7, group_id = $8, updated_at = NOW()
+			  WHERE id = $9 AND user_id = $10 RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, c.Name, c.Type, c.Color, c.Icon, c.ParentID, c.TaxDeductible, c.TaxCode, c.GroupID, c.ID, c.UserID).
+		Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+// DeleteCategory deletes an unused category. A category still holding
+// transactions, budgets, rules or subcategories is only deleted by merging
+// it into reassignTo, so nothing filed under it is lost or orphaned.
+func (s *Service) DeleteCategory(ctx context.Context, userID, categoryID int, reassignTo *int) error {
+	if reassignTo != nil {
+		_, err := s.MergeCategory(ctx, userID, categoryID, *reassignTo)
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := lockCategory(ctx, tx, userID, categoryID); err != nil {
+		return err
+	}
+	inUse, err := categoryInUse(ctx, tx, userID, categoryID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1 AND user_id = $2`, categoryID, userID)
+	if isForeignKeyViolation(err) {
+		return ErrResourceInUse
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
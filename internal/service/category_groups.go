@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// groupedCategories maps every category of user $1 reachable from a root to
+// its reporting group. A subcategory without a group of its own belongs to
+// the group of its nearest grouped ancestor.
+const groupedCategories = `WITH RECURSIVE grouped AS (
+				  SELECT id, group_id FROM categories WHERE user_id = $1 AND parent_id IS NULL
+				  UNION ALL
+				  SELECT c.id, COALESCE(c.group_id, grouped.group_id)
+				  FROM categories c JOIN grouped ON c.parent_id = grouped.id
+			  )`
+
+func (s *Service) GetCategoryGroups(ctx context.Context, userID int) ([]models.CategoryGroup, error) {
+	query := `SELECT id, user_id, name, monthly_budget, created_at, updated_at
+			  FROM category_groups WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.CategoryGroup{}
+	for rows.Next() {
+		var group models.CategoryGroup
+		if err := rows.Scan(&group.ID, &group.UserID, &group.Name, &group.MonthlyBudget, &group.CreatedAt, &group.UpdatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (s *Service) CreateCategoryGroup(ctx context.Context, g *models.CategoryGroup) error {
+	query := `INSERT INTO category_groups (user_id, name, monthly_budget, created_at, updated_at)
+			  VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, g.UserID, g.Name, g.MonthlyBudget).Scan(&g.ID, &g.CreatedAt, &g.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrCategoryGroupExists
+	}
+	return err
+}
+
+func (s *Service) UpdateCategoryGroup(ctx context.Context, g *models.CategoryGroup) error {
+	query := `UPDATE category_groups SET name = $1, monthly_budget = $2, updated_at = NOW()
+			  WHERE id = $3 AND user_id = $4 RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, g.Name, g.MonthlyBudget, g.ID, g.UserID).Scan(&g.CreatedAt, &g.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrCategoryGroupNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrCategoryGroupExists
+	}
+	return err
+}
+
+// DeleteCategoryGroup deletes a group; its categories stay and become
+// ungrouped.
+func (s *Service) DeleteCategoryGroup(ctx context.Context, userID, groupID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM category_groups WHERE id = $1 AND user_id = $2`, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrCategoryGroupNotFound
+	}
+	return nil
+}
+
+// groupBudgets reports expenses in [start, end) against every group with a
+// monthly budget.
+func (s *Service) groupBudgets(ctx context.Context, userID int, start, end time.Time) ([]models.GroupBudgetStatus, error) {
+	query := groupedCategories + `
+			  SELECT g.id, g.name, g.monthly_budget, COALESCE(SUM(t.amount), 0)
+			  FROM category_groups g
+			  LEFT JOIN grouped ON grouped.group_id = g.id
+			  LEFT JOIN transactions t ON t.category_id = grouped.id AND t.user_id = $1
+				  AND t.type = 'expense' AND t.date >= $2 AND t.date < $3
+			  WHERE g.user_id = $1 AND g.monthly_budget IS NOT NULL
+			  GROUP BY g.id, g.name, g.monthly_budget
+			  ORDER BY g.name`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.GroupBudgetStatus{}
+	for rows.Next() {
+		var status models.GroupBudgetStatus
+		if err := rows.Scan(&status.GroupID, &status.GroupName, &status.Budget, &status.Spent); err != nil {
+			return nil, err
+		}
+		status.Remaining = status.Budget - status.Spent
+		status.PercentUsed = math.Round(status.Spent/status.Budget*1000) / 10
+		groups = append(groups, status)
+	}
+	return groups, rows.Err()
+}
+
+// SpendingByGroup breaks expenses in [start, end) down by category group and,
+// within each group, by category. Groups and categories are ordered by
+// spending, largest first.
+func (s *Service) SpendingByGroup(ctx context.Context, userID int, start, end time.Time, scope string) ([]models.SpendingByGroup, error) {
+	totals, err := s.CategoryTotals(ctx, userID, start, end, scope)
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[int]float64)
+	for _, total := range totals {
+		if total.Type == "expense" {
+			spent[total.CategoryID] += total.Amount
+		}
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, groupedCategories+`
+			  SELECT c.id, c.name, g.id, COALESCE(g.name, '')
+			  FROM categories c
+			  LEFT JOIN grouped ON grouped.id = c.id
+			  LEFT JOIN category_groups g ON g.id = grouped.group_id
+			  WHERE c.user_id = $1 AND c.type = 'expense'`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []models.SpendingByGroup
+	index := make(map[int]int)
+	var total float64
+	for rows.Next() {
+		var category models.SpendingByCategory
+		var groupID *int
+		var groupName string
+		if err := rows.Scan(&category.CategoryID, &category.CategoryName, &groupID, &groupName); err != nil {
+			return nil, err
+		}
+		category.Amount = spent[category.CategoryID]
+		total += category.Amount
+
+		key := 0
+		if groupID != nil {
+			key = *groupID
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.SpendingByGroup{GroupID: groupID, GroupName: groupName})
+		}
+		groups[i].Amount += category.Amount
+		groups[i].Categories = append(groups[i].Categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		if total > 0 {
+			groups[i].Percentage = groups[i].Amount / total * 100
+			for j := range groups[i].Categories {
+				groups[i].Categories[j].Percentage = groups[i].Categories[j].Amount / total * 100
+			}
+		}
+		sort.SliceStable(groups[i].Categories, func(a, b int) bool {
+			return groups[i].Categories[a].Amount > groups[i].Categories[b].Amount
+		})
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Amount > groups[b].Amount
+	})
+	if groups == nil {
+		groups = []models.SpendingByGroup{}
+	}
+	return groups, nil
+}
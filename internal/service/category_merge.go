@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// categoryReferences are the records that point at a category, each with the
+// column naming the user who owns them. Merging moves them all; deleting a
+// category one of them still uses is refused.
+var categoryReferences = []struct {
+	table  string
+	owner  string
+	counts func(m *models.CategoryMerge) *int
+}{
+	{"transactions", "user_id", func(m *models.CategoryMerge) *int { return &m.Transactions }},
+	{"budget_rules", "user_id", func(m *models.CategoryMerge) *int { return &m.Budgets }},
+	{"recurring_rules", "user_id", func(m *models.CategoryMerge) *int { return &m.RecurringRules }},
+	{"allowance_rules", "owner_id", func(m *models.CategoryMerge) *int { return &m.AllowanceRules }},
+	{"transaction_approvals", "owner_id", func(m *models.CategoryMerge) *int { return &m.Approvals }},
+}
+
+// lockCategory locks a category of the user and returns its type and parent.
+func lockCategory(ctx context.Context, tx *sql.Tx, userID, categoryID int) (string, *int, error) {
+	var categoryType string
+	var parentID *int
+	err := tx.QueryRowContext(ctx, `SELECT type, parent_id FROM categories WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		categoryID, userID).Scan(&categoryType, &parentID)
+	if err == sql.ErrNoRows {
+		return "", nil, ErrCategoryNotFound
+	}
+	return categoryType, parentID, err
+}
+
+// MergeCategory moves everything filed under a category to target, which
+// must have the same type, and deletes the category. Its subcategories move
+// under target; when target is nested below it, target first takes the
+// merged category's place in the tree so no cycle forms.
+func (s *Service) MergeCategory(ctx context.Context, userID, categoryID, targetID int) (models.CategoryMerge, error) {
+	merge := models.CategoryMerge{TargetID: targetID}
+	if categoryID == targetID {
+		return merge, ErrMergeSameCategory
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return merge, err
+	}
+	defer tx.Rollback()
+
+	sourceType, parentID, err := lockCategory(ctx, tx, userID, categoryID)
+	if err != nil {
+		return merge, err
+	}
+	targetType, _, err := lockCategory(ctx, tx, userID, targetID)
+	if err != nil {
+		return merge, err
+	}
+	if sourceType != targetType {
+		return merge, ErrCategoryTypeMismatch
+	}
+
+	for _, ref := range categoryReferences {
+		result, err := tx.ExecContext(ctx, `UPDATE `+ref.table+` SET category_id = $1 WHERE category_id = $2 AND `+ref.owner+` = $3`,
+			targetID, categoryID, userID)
+		if err != nil {
+			return merge, err
+		}
+		n, _ := result.RowsAffected()
+		*ref.counts(&merge) = int(n)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE staged_import_rows SET suggested_category_id = $1
+			  WHERE suggested_category_id = $2 AND import_id IN (SELECT id FROM staged_imports WHERE user_id = $3)`,
+		targetID, categoryID, userID)
+	if err != nil {
+		return merge, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1, updated_at = NOW()
+			  WHERE id = $2 AND id IN (
+			      WITH RECURSIVE below AS (
+			          SELECT id FROM categories WHERE parent_id = $3 AND user_id = $4
+			          UNION
+			          SELECT c.id FROM categories c JOIN below ON c.parent_id = below.id
+			      )
+			      SELECT id FROM below
+			  )`, parentID, targetID, categoryID, userID)
+	if err != nil {
+		return merge, err
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = $1, updated_at = NOW() WHERE parent_id = $2 AND user_id = $3`,
+		targetID, categoryID, userID)
+	if err != nil {
+		return merge, err
+	}
+	n, _ := result.RowsAffected()
+	merge.Subcategories = int(n)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1 AND user_id = $2`, categoryID, userID); err != nil {
+		return merge, err
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntry{
+		UserID:     userID,
+		Action:     models.AuditActions.CategoryMerge,
+		EntityType: "category",
+		EntityID:   &targetID,
+		Details: map[string]interface{}{
+			"merged_category_id": categoryID,
+			"transactions":       merge.Transactions,
+			"budgets":            merge.Budgets,
+			"recurring_rules":    merge.RecurringRules,
+			"allowance_rules":    merge.AllowanceRules,
+		},
+	})
+	if err != nil {
+		return merge, err
+	}
+	if err := tx.Commit(); err != nil {
+		return merge, err
+	}
+
+	s.TransactionUpdated(userID)
+	return merge, nil
+}
+
+// categoryInUse reports whether any record or subcategory still points at the
+// category.
+func categoryInUse(ctx context.Context, tx *sql.Tx, userID, categoryID int) (bool, error) {
+	query := `EXISTS(SELECT 1 FROM categories WHERE parent_id = $1 AND user_id = $2)`
+	for _, ref := range categoryReferences {
+		query += ` OR EXISTS(SELECT 1 FROM ` + ref.table + ` WHERE category_id = $1 AND ` + ref.owner + ` = $2)`
+	}
+
+	var inUse bool
+	err := tx.QueryRowContext(ctx, `SELECT `+query, categoryID, userID).Scan(&inUse)
+	return inUse, err
+}
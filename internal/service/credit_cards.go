@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+var ErrNoStatementCycle = errors.New("account has no statement cycle configured")
+
+// statementDates returns the last statement period closed on or before at,
+// as a half-open [start, end) range, and the payment due date for it.
+func statementDates(closingDay, dueDay int, at time.Time) (time.Time, time.Time, time.Time) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	closing := time.Date(day.Year(), day.Month(), closingDay, 0, 0, 0, 0, day.Location())
+	if closing.After(day) {
+		closing = closing.AddDate(0, -1, 0)
+	}
+	start := closing.AddDate(0, -1, 1)
+	end := closing.AddDate(0, 0, 1)
+
+	due := time.Date(closing.Year(), closing.Month(), dueDay, 0, 0, 0, 0, closing.Location())
+	if !due.After(closing) {
+		due = due.AddDate(0, 1, 0)
+	}
+	return start, end, due
+}
+
+func (s *Service) CardStatement(ctx context.Context, userID, accountID int, at time.Time) (models.CreditCardStatement, error) {
+	var statement models.CreditCardStatement
+
+	account, err := s.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return statement, err
+	}
+	if account.StatementClosingDay == nil || account.PaymentDueDay == nil {
+		return statement, ErrNoStatementCycle
+	}
+
+	start, end, due := statementDates(*account.StatementClosingDay, *account.PaymentDueDay, at.In(s.Location(ctx, userID)))
+
+	query := `SELECT COALESCE(SUM(amount) FILTER (WHERE type = 'expense' AND date >= $3 AND date < $4), 0),
+			  COALESCE(SUM(amount) FILTER (WHERE type = 'income' AND date >= $3 AND date < $4), 0),
+			  COALESCE(SUM(amount) FILTER (WHERE type = 'income' AND date >= $4), 0),
+			  COALESCE(SUM(CASE WHEN type = 'expense' THEN -amount ELSE amount END) FILTER (WHERE date >= $4), 0)
+			  FROM transactions WHERE account_id = $1 AND user_id = $2`
+
+	var sinceClosing float64
+	err = s.ReadDB().QueryRowContext(ctx, query, accountID, userID, start, end).
+		Scan(&statement.Purchases, &statement.Payments, &statement.PaidSinceClosing, &sinceClosing)
+	if err != nil {
+		return statement, err
+	}
+
+	statement.AccountID = accountID
+	statement.PeriodStart = start
+	statement.ClosingDate = end.AddDate(0, 0, -1)
+	statement.DueDate = due
+	statement.CurrentBalance = account.Balance
+	statement.StatementBalance = -(account.Balance - sinceClosing)
+	statement.RemainingDue = math.Max(0, statement.StatementBalance-statement.PaidSinceClosing)
+	if statement.StatementBalance > 0 {
+		minimum := math.Max(statement.StatementBalance*models.CreditCardSettings.MinimumPaymentRatio, models.CreditCardSettings.MinimumPaymentFloor)
+		statement.MinimumPayment = math.Max(0, math.Min(minimum, statement.StatementBalance)-statement.PaidSinceClosing)
+	}
+
+	if account.CreditLimit != nil && *account.CreditLimit > 0 {
+		utilization := math.Max(0, -account.Balance) / *account.CreditLimit
+		statement.CreditLimit = account.CreditLimit
+		statement.Utilization = &utilization
+	}
+	return statement, nil
+}
+
+func (s *Service) CreditUtilization(ctx context.Context, userID int) (models.CreditUtilization, error) {
+	usage := models.CreditUtilization{Cards: []models.CardUtilization{}}
+
+	query := `SELECT id, name, credit_limit, balance FROM accounts
+			  WHERE user_id = $1 AND type = $2 AND credit_limit > 0 AND archived_at IS NULL
+			  ORDER BY name`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, models.AccountTypes.CreditCard)
+	if err != nil {
+		return usage, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var card models.CardUtilization
+		var balance float64
+		if err := rows.Scan(&card.AccountID, &card.Name, &card.CreditLimit, &balance); err != nil {
+			return usage, err
+		}
+		card.Owed = math.Max(0, -balance)
+		card.Utilization = card.Owed / card.CreditLimit
+
+		usage.CreditLimit += card.CreditLimit
+		usage.Owed += card.Owed
+		usage.Cards = append(usage.Cards, card)
+	}
+	if usage.CreditLimit > 0 {
+		usage.Utilization = usage.Owed / usage.CreditLimit
+	}
+	return usage, rows.Err()
+}
+
+// checkUtilizationAlert notifies the user when an expense pushes a credit
+// card's utilization across the alert threshold.
+func (s *Service) checkUtilizationAlert(ctx context.Context, t models.Transaction, balance float64) error {
+	var name, accountType, currency string
+	var limit sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `SELECT name, type, credit_limit, currency FROM accounts WHERE id = $1 AND user_id = $2`, t.AccountID, t.UserID).
+		Scan(&name, &accountType, &limit, &currency)
+	if err != nil {
+		return err
+	}
+	if accountType != models.AccountTypes.CreditCard || !limit.Valid || limit.Float64 <= 0 {
+		return nil
+	}
+
+	threshold := limit.Float64 * models.CreditCardSettings.UtilizationAlertRatio
+	before, after := -(balance + t.Amount), -balance
+	if before >= threshold || after < threshold {
+		return nil
+	}
+
+	l, _ := s.localizer(t.UserID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID: t.UserID,
+		Type:   notifications.Types.CreditUsage,
+		Title:  l.T("High credit utilization: %s", name),
+		Message: l.T("You have used %s of your %s credit limit (%s%%).",
+			l.Amount(after, currency), l.Amount(limit.Float64, currency), l.Number(after/limit.Float64*100, 0)),
+		Data: map[string]interface{}{
+			"account_id":   t.AccountID,
+			"credit_limit": limit.Float64,
+			"owed":         after,
+		},
+	})
+	return nil
+}
+
+func (s *Service) RunPaymentReminders(ctx context.Context) {
+	ticker := time.NewTicker(models.CreditCardSettings.ReminderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sendPaymentReminders(ctx, time.Now()); err != nil {
+			log.Printf("Error sending payment reminders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendPaymentReminders reminds about the minimum payment of every statement
+// due within the reminder window. The reminded due date is claimed on the
+// account first, so each statement is reminded once across all instances.
+func (s *Service) sendPaymentReminders(ctx context.Context, now time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, name, currency FROM accounts
+			  WHERE statement_closing_day IS NOT NULL AND archived_at IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	type card struct {
+		id, userID     int
+		name, currency string
+	}
+	var cards []card
+	for rows.Next() {
+		var c card
+		if err := rows.Scan(&c.id, &c.userID, &c.name, &c.currency); err != nil {
+			rows.Close()
+			return err
+		}
+		cards = append(cards, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range cards {
+		statement, err := s.CardStatement(ctx, c.userID, c.id, now)
+		if err != nil {
+			log.Printf("Error computing statement for account %d: %v", c.id, err)
+			continue
+		}
+
+		local := now.In(statement.DueDate.Location())
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		window := today.AddDate(0, 0, models.CreditCardSettings.ReminderDaysBefore)
+		if statement.MinimumPayment <= 0 || statement.DueDate.Before(today) || statement.DueDate.After(window) {
+			continue
+		}
+
+		result, err := s.db.ExecContext(ctx, `UPDATE accounts SET payment_reminder_due_date = $1
+				  WHERE id = $2 AND payment_reminder_due_date IS DISTINCT FROM $1::date`, statement.DueDate.Format("2006-01-02"), c.id)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+
+		l, _ := s.localizer(c.userID)
+		s.notifier.Dispatch(notifications.Notification{
+			UserID: c.userID,
+			Type:   notifications.Types.BillReminder,
+			Title:  l.T("Card payment due: %s", c.name),
+			Message: l.T("Pay at least %s of %s by %s.", l.Amount(statement.MinimumPayment, c.currency),
+				l.Amount(statement.RemainingDue, c.currency), statement.DueDate.Format("2006-01-02")),
+			Data: map[string]interface{}{
+				"account_id":      c.id,
+				"due_date":        statement.DueDate.Format("2006-01-02"),
+				"minimum_payment": statement.MinimumPayment,
+				"remaining_due":   statement.RemainingDue,
+			},
+		})
+	}
+	return nil
+}
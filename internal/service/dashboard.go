@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// defaultDashboard is shown until the user saves a layout of their own.
+var defaultDashboard = []models.DashboardWidget{
+	{Type: models.DashboardWidgetTypes.Summary},
+	{Type: models.DashboardWidgetTypes.CategoryDonut},
+	{Type: models.DashboardWidgetTypes.BudgetBars},
+	{Type: models.DashboardWidgetTypes.TrendLine},
+	{Type: models.DashboardWidgetTypes.NetWorth},
+}
+
+func validateWidget(w models.DashboardWidget) error {
+	switch w.Type {
+	case models.DashboardWidgetTypes.Summary, models.DashboardWidgetTypes.CategoryDonut, models.DashboardWidgetTypes.TrendLine,
+		models.DashboardWidgetTypes.BudgetBars, models.DashboardWidgetTypes.NetWorth:
+	default:
+		return ErrWidgetType
+	}
+
+	p := w.Params
+	if !ValidScope(p.Scope) || (p.Period != "" && p.Period != "current" && p.Period != "previous") ||
+		p.Limit < 0 || p.Limit > models.DashboardSettings.MaxSlices ||
+		p.Count < 0 || p.Count > models.SettingsDefaults.MaxPeriodHistory {
+		return ErrWidgetParams
+	}
+	return nil
+}
+
+// Dashboard returns the user's widgets in display order, or the default
+// layout when none are saved.
+func (s *Service) Dashboard(ctx context.Context, userID int) ([]models.DashboardWidget, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, type, position, params FROM dashboard_widgets
+			  WHERE user_id = $1 ORDER BY position`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	widgets := []models.DashboardWidget{}
+	for rows.Next() {
+		var widget models.DashboardWidget
+		var params []byte
+		if err := rows.Scan(&widget.ID, &widget.Type, &widget.Position, &params); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(params, &widget.Params); err != nil {
+			return nil, err
+		}
+		widgets = append(widgets, widget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(widgets) == 0 {
+		for i, widget := range defaultDashboard {
+			widget.Position = i
+			widgets = append(widgets, widget)
+		}
+	}
+	return widgets, nil
+}
+
+// SaveDashboard replaces the user's widgets with widgets, in the given order.
+func (s *Service) SaveDashboard(ctx context.Context, userID int, widgets []models.DashboardWidget) ([]models.DashboardWidget, error) {
+	if len(widgets) > models.DashboardSettings.MaxWidgets {
+		return nil, ErrTooManyWidgets
+	}
+	for _, widget := range widgets {
+		if err := validateWidget(widget); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dashboard_widgets WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	for i := range widgets {
+		params, err := json.Marshal(widgets[i].Params)
+		if err != nil {
+			return nil, err
+		}
+		widgets[i].Position = i
+		err = tx.QueryRowContext(ctx, `INSERT INTO dashboard_widgets (user_id, position, type, params, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`, userID, i, widgets[i].Type, params).Scan(&widgets[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Dashboard(ctx, userID)
+}
+
+// DashboardData resolves the data of every widget on the user's dashboard in
+// one call. A widget that fails is reported with an error of its own so the
+// rest of the dashboard still renders.
+func (s *Service) DashboardData(ctx context.Context, userID int) ([]models.DashboardWidgetData, error) {
+	widgets, err := s.Dashboard(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(SettingsLocation(settings))
+
+	data := make([]models.DashboardWidgetData, len(widgets))
+	for i, widget := range widgets {
+		data[i] = models.DashboardWidgetData{ID: widget.ID, Type: widget.Type}
+		if data[i].Data, err = s.widgetData(ctx, userID, settings, now, widget); err != nil {
+			log.Printf("Error loading %s widget for user %d: %v", widget.Type, userID, err)
+			data[i].Data = nil
+			data[i].Error = "Failed to load widget"
+		}
+	}
+	return data, nil
+}
+
+func (s *Service) widgetData(ctx context.Context, userID int, settings models.UserSettings, now time.Time, w models.DashboardWidget) (interface{}, error) {
+	start, end := PayPeriod(settings, now)
+	if w.Params.Period == "previous" {
+		start, end = PayPeriod(settings, start.AddDate(0, 0, -1))
+	}
+
+	switch w.Type {
+	case models.DashboardWidgetTypes.Summary:
+		totals, err := s.CategoryTotals(ctx, userID, start, end, w.Params.Scope)
+		if err != nil {
+			return nil, err
+		}
+		summary := models.PeriodSummary{Start: start.Format("2006-01-02"), End: end.AddDate(0, 0, -1).Format("2006-01-02")}
+		for _, total := range totals {
+			if total.Type == "income" {
+				summary.TotalIncome += total.Amount
+			} else {
+				summary.TotalExpenses += total.Amount
+			}
+		}
+		summary.NetIncome = summary.TotalIncome - summary.TotalExpenses
+		return summary, nil
+
+	case models.DashboardWidgetTypes.CategoryDonut:
+		limit := w.Params.Limit
+		if limit == 0 {
+			limit = models.DashboardSettings.DefaultSlices
+		}
+		return s.spendingSlices(ctx, userID, start, end, w.Params.Scope, limit)
+
+	case models.DashboardWidgetTypes.TrendLine:
+		count := w.Params.Count
+		if count == 0 {
+			count = models.SettingsDefaults.PeriodHistory
+		}
+		return s.PeriodSummaries(ctx, userID, now, count, w.Params.Scope)
+
+	case models.DashboardWidgetTypes.BudgetBars:
+		return s.BudgetOverview(ctx, userID, now)
+
+	case models.DashboardWidgetTypes.NetWorth:
+		return s.NetWorth(ctx, userID, w.Params.Scope)
+	}
+	return nil, ErrWidgetType
+}
+
+// spendingSlices returns the limit expense categories with the most spending
+// in [start, end), merging the remaining ones into an "Other" slice with
+// category ID 0.
+func (s *Service) spendingSlices(ctx context.Context, userID int, start, end time.Time, scope string, limit int) ([]models.SpendingByCategory, error) {
+	totals, err := s.CategoryTotals(ctx, userID, start, end, scope)
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[int]float64)
+	var total float64
+	for _, t := range totals {
+		if t.Type == "expense" {
+			spent[t.CategoryID] += t.Amount
+			total += t.Amount
+		}
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT id, name FROM categories WHERE user_id = $1 AND type = 'expense'`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slices := []models.SpendingByCategory{}
+	for rows.Next() {
+		var slice models.SpendingByCategory
+		if err := rows.Scan(&slice.CategoryID, &slice.CategoryName); err != nil {
+			return nil, err
+		}
+		if slice.Amount = spent[slice.CategoryID]; slice.Amount > 0 {
+			slices = append(slices, slice)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(slices, func(i, j int) bool {
+		return slices[i].Amount > slices[j].Amount
+	})
+	if len(slices) > limit {
+		other := models.SpendingByCategory{CategoryName: "Other"}
+		for _, slice := range slices[limit:] {
+			other.Amount += slice.Amount
+		}
+		slices = append(slices[:limit], other)
+	}
+	for i := range slices {
+		if total > 0 {
+			slices[i].Percentage = slices[i].Amount / total * 100
+		}
+	}
+	return slices, nil
+}
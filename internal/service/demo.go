@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+)
+
+type demoCategory struct {
+	name, kind, color string
+	payees            []string
+}
+
+var demoCategories = []demoCategory{
+	{"Salary", "income", "#00b894", []string{"Company Payroll"}},
+	{"Freelance", "income", "#00cec9", []string{"Client Payment", "Consulting Fee"}},
+	{"Rent", "expense", "#e17055", []string{"Apartment Rent"}},
+	{"Groceries", "expense", "#ff6b6b", []string{"Costco", "Trader Joe's", "Aldi", "Whole Foods Market", "Kroger"}},
+	{"Restaurants", "expense", "#fd79a8", []string{"Starbucks", "Local Restaurant", "Pizza Hut", "Cafe", "Food Truck"}},
+	{"Transportation", "expense", "#4ecdc4", []string{"Uber", "Metro Transit", "Shell", "Parking Meter"}},
+	{"Utilities", "expense", "#f9ca24", []string{"Electric Company", "Internet Provider", "Phone Company"}},
+	{"Entertainment", "expense", "#45b7d1", []string{"Netflix", "Spotify", "Movie Theater", "Bookstore"}},
+	{"Shopping", "expense", "#a29bfe", []string{"Amazon", "Home Depot", "Clothing Store", "Best Buy"}},
+	{"Healthcare", "expense", "#6c5ce7", []string{"Pharmacy", "Dental Clinic", "Doctor Visit"}},
+}
+
+var demoBudgets = map[string]float64{
+	"Groceries":      600,
+	"Restaurants":    250,
+	"Transportation": 200,
+	"Entertainment":  120,
+	"Shopping":       300,
+}
+
+// CreateDemoUser creates a sandbox user filled with made-up but realistic
+// data. Sandbox users are deleted once they expire.
+func (s *Service) CreateDemoUser(ctx context.Context) (models.User, error) {
+	var user models.User
+
+	suffix, err := auth.GenerateRandomToken(6)
+	if err != nil {
+		return user, err
+	}
+	password, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return user, err
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return user, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	user.Email = fmt.Sprintf("demo-%s@%s", strings.ToLower(suffix), models.DemoSettings.EmailDomain)
+	user.FirstName, user.LastName = "Demo", "User"
+
+	query := `INSERT INTO users (email, password_hash, first_name, last_name, demo_expires_at, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, user.Email, hashedPassword, user.FirstName, user.LastName,
+		time.Now().Add(models.DemoSettings.TTL)).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return user, err
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if err := seedUserData(ctx, tx, user.ID, time.Now().UTC(), models.DemoSettings.Months, 1, rng); err != nil {
+		return user, err
+	}
+	return user, tx.Commit()
+}
+
+// SeedUser creates a regular user with the same generated data as sandbox
+// users, for development databases. Volume scales how many day-to-day
+// purchases are made; fixed income and bills stay the same.
+func (s *Service) SeedUser(ctx context.Context, email, password string, months int, volume float64, rng *rand.Rand) (models.User, error) {
+	user := models.User{Email: email, FirstName: "Dev", LastName: "User"}
+
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return user, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) ON CONFLICT (email) DO NOTHING
+			  RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, user.Email, hashedPassword, user.FirstName, user.LastName).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrEmailTaken
+	}
+	if err != nil {
+		return user, err
+	}
+
+	if err := seedUserData(ctx, tx, user.ID, time.Now().UTC(), months, volume, rng); err != nil {
+		return user, err
+	}
+	return user, tx.Commit()
+}
+
+// DeleteUserByEmail removes a user and their financial records. It reports
+// false when there is no such user.
+func (s *Service) DeleteUserByEmail(ctx context.Context, email string) (bool, error) {
+	var id int
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = $1`, email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, s.deleteUserData(ctx, id)
+}
+
+func seedUserData(ctx context.Context, tx *sql.Tx, userID int, now time.Time, months int, volume float64, rng *rand.Rand) error {
+	accounts := map[string]int{}
+	for _, a := range []struct {
+		name, kind string
+		limit      *float64
+	}{
+		{"Everyday Checking", models.AccountTypes.Checking, nil},
+		{"Rainy Day Savings", models.AccountTypes.Savings, nil},
+		{"Rewards Card", models.AccountTypes.CreditCard, floatPtr(5000)},
+	} {
+		var id int
+		err := tx.QueryRowContext(ctx, `INSERT INTO accounts (user_id, name, type, balance, currency, credit_limit, created_at, updated_at)
+				  VALUES ($1, $2, $3, 0, 'USD', $4, NOW(), NOW()) RETURNING id`, userID, a.name, a.kind, a.limit).Scan(&id)
+		if err != nil {
+			return err
+		}
+		accounts[a.kind] = id
+	}
+
+	categories := map[string]int{}
+	for _, c := range demoCategories {
+		var id int
+		err := tx.QueryRowContext(ctx, `INSERT INTO categories (user_id, name, type, color, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`, userID, c.name, c.kind, c.color).Scan(&id)
+		if err != nil {
+			return err
+		}
+		categories[c.name] = id
+	}
+
+	payee := func(category string) string {
+		for _, c := range demoCategories {
+			if c.name == category {
+				return c.payees[rng.Intn(len(c.payees))]
+			}
+		}
+		return category
+	}
+	amount := func(base, spread float64) float64 {
+		return math.Round(base*(1+spread*(2*rng.Float64()-1))*100) / 100
+	}
+	insert := func(accountID int, category, kind, description string, value float64, date time.Time) error {
+		var categoryID *int
+		if id, ok := categories[category]; ok {
+			categoryID = &id
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO transactions (user_id, account_id, category_id, amount, type, description, date, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())`, userID, accountID, categoryID, value, kind, description, date)
+		return err
+	}
+
+	checking, savings, card := accounts[models.AccountTypes.Checking], accounts[models.AccountTypes.Savings], accounts[models.AccountTypes.CreditCard]
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := today.AddDate(0, -months, 0)
+
+	// times turns a daily probability into a number of purchases, so volumes
+	// above 1 can buy the same thing more than once a day.
+	times := func(p float64) int {
+		expected := p * volume
+		n := int(expected)
+		if rng.Float64() < expected-float64(n) {
+			n++
+		}
+		return n
+	}
+
+	cardSpent := 0.0
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		at := day.Add(time.Duration(8+rng.Intn(12)) * time.Hour).Add(time.Duration(rng.Intn(60)) * time.Minute)
+
+		var err error
+		spend := func(accountID int, category string, base, spread float64) {
+			if err != nil {
+				return
+			}
+			value := amount(base, spread)
+			if accountID == card {
+				cardSpent += value
+			}
+			err = insert(accountID, category, "expense", payee(category), value, at)
+		}
+
+		switch day.Day() {
+		case 1:
+			spend(checking, "Rent", 1450, 0)
+			if err == nil {
+				err = insert(checking, "", "expense", "Transfer to savings", 400, at)
+			}
+			if err == nil {
+				err = insert(savings, "", "income", "Transfer from checking", 400, at)
+			}
+		case 5:
+			spend(checking, "Utilities", 160, 0.25)
+		case 10, 25:
+			err = insert(checking, "Salary", "income", payee("Salary"), 2600, at)
+		case 20:
+			if cardSpent > 0 {
+				paid := math.Round(cardSpent*100) / 100
+				err = insert(checking, "", "expense", "Credit card payment", paid, at)
+				if err == nil {
+					err = insert(card, "", "income", "Payment received", paid, at)
+				}
+				cardSpent = 0
+			}
+		}
+		if day.Day() == 15 && rng.Float64() < 0.4 && err == nil {
+			err = insert(checking, "Freelance", "income", payee("Freelance"), amount(700, 0.5), at)
+		}
+
+		if day.Weekday() == time.Saturday {
+			spend(checking, "Groceries", 85, 0.5)
+		}
+		for i := times(0.15); i > 0; i-- {
+			spend(checking, "Groceries", 85, 0.5)
+		}
+		for i := times(0.3); i > 0; i-- {
+			spend(card, "Restaurants", 22, 0.6)
+		}
+		for i := times(0.25); i > 0; i-- {
+			spend(checking, "Transportation", 18, 0.7)
+		}
+		if day.Day() == 12 {
+			spend(card, "Entertainment", 15.99, 0)
+		}
+		for i := times(0.08); i > 0; i-- {
+			spend(card, "Shopping", 70, 0.8)
+		}
+		for i := times(0.03); i > 0; i-- {
+			spend(checking, "Healthcare", 60, 0.7)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE accounts a SET balance = COALESCE((
+			  SELECT SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END)
+			  FROM transactions t WHERE t.account_id = a.id), 0)
+			  WHERE a.user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	budgetStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for name, value := range demoBudgets {
+		_, err := tx.ExecContext(ctx, `INSERT INTO budget_rules (user_id, category_id, amount, period, start_date, created_at, updated_at)
+				  VALUES ($1, $2, $3, 'monthly', $4, NOW(), NOW())`, userID, categories[name], value, budgetStart)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) RunDemoCleanup(ctx context.Context) {
+	ticker := time.NewTicker(models.DemoSettings.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.deleteExpiredDemoUsers(ctx); err != nil {
+			log.Printf("Error deleting expired demo users: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d expired demo users", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) deleteExpiredDemoUsers(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users WHERE demo_expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		if err := s.deleteUserData(ctx, id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// deleteUserData removes the user with their financial records, which are not
+// all deleted by cascade.
+func (s *Service) deleteUserData(ctx context.Context, userID int) error {
+	if err := s.cancelSubscription(ctx, userID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"transactions", "budget_rules", "accounts", "categories"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
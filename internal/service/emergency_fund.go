@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+
+	"github.com/lib/pq"
+)
+
+// EmergencyFund works out how many months of essential spending the
+// emergency fund covers, averaging the whole financial months before now.
+func (s *Service) EmergencyFund(ctx context.Context, userID int, now time.Time) (models.EmergencyFund, error) {
+	fund := models.EmergencyFund{
+		AccountIDs:           []int{},
+		EssentialCategoryIDs: []int{},
+		ThresholdMonths:      models.EmergencyFundSettings.DefaultThresholdMonths,
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return fund, err
+	}
+	fund.Currency = settings.BaseCurrency
+
+	err = s.db.QueryRowContext(ctx, `SELECT threshold_months FROM emergency_funds WHERE user_id = $1`, userID).Scan(&fund.ThresholdMonths)
+	if err != nil && err != sql.ErrNoRows {
+		return fund, err
+	}
+
+	// Savings accounts stand in until accounts are designated.
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT id, balance FROM accounts
+			  WHERE user_id = $1 AND archived_at IS NULL
+			  AND (emergency_fund OR (type = $2 AND NOT EXISTS (
+				  SELECT 1 FROM accounts WHERE user_id = $1 AND emergency_fund AND archived_at IS NULL)))
+			  ORDER BY id`, userID, models.AccountTypes.Savings)
+	if err != nil {
+		return fund, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var balance float64
+		if err := rows.Scan(&id, &balance); err != nil {
+			return fund, err
+		}
+		fund.AccountIDs = append(fund.AccountIDs, id)
+		fund.Balance += balance
+	}
+	if err := rows.Err(); err != nil {
+		return fund, err
+	}
+
+	if err := s.ReadDB().QueryRowContext(ctx, `SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM categories
+			  WHERE user_id = $1 AND essential`, userID).Scan(pq.Array(&fund.EssentialCategoryIDs)); err != nil {
+		return fund, err
+	}
+	essential := make(map[int]bool, len(fund.EssentialCategoryIDs))
+	for _, id := range fund.EssentialCategoryIDs {
+		essential[id] = true
+	}
+
+	n := models.EmergencyFundSettings.Months
+	end := FiscalMonthStart(now.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	start := end.AddDate(0, -n, 0)
+	fund.PeriodStart = start.Format("2006-01-02")
+	fund.PeriodEnd = end.AddDate(0, 0, -1).Format("2006-01-02")
+
+	totals, err := s.CategoryTotals(ctx, userID, start, end, "")
+	if err != nil {
+		return fund, err
+	}
+	var spent float64
+	for _, total := range totals {
+		if total.Type == "expense" && (len(essential) == 0 || essential[total.CategoryID]) {
+			spent += total.Amount
+		}
+	}
+	fund.MonthlyEssentialSpending = math.Round(spent/float64(n)*100) / 100
+
+	if fund.MonthlyEssentialSpending > 0 {
+		coverage := math.Round(fund.Balance/fund.MonthlyEssentialSpending*10) / 10
+		fund.CoverageMonths = &coverage
+		fund.BelowThreshold = coverage < fund.ThresholdMonths
+	}
+	return fund, nil
+}
+
+// SetEmergencyFund designates the emergency fund accounts and essential
+// categories, replacing the previous choice, and sets the alert threshold.
+func (s *Service) SetEmergencyFund(ctx context.Context, userID int, req models.EmergencyFundRequest) (models.EmergencyFund, error) {
+	threshold := req.ThresholdMonths
+	if threshold == 0 {
+		threshold = models.EmergencyFundSettings.DefaultThresholdMonths
+	}
+	accountIDs, categoryIDs := req.AccountIDs, req.EssentialCategoryIDs
+	if accountIDs == nil {
+		accountIDs = []int{}
+	}
+	if categoryIDs == nil {
+		categoryIDs = []int{}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.EmergencyFund{}, err
+	}
+	defer tx.Rollback()
+
+	for _, id := range accountIDs {
+		if err := ensureOwned(ctx, tx, "accounts", id, userID); err != nil {
+			return models.EmergencyFund{}, err
+		}
+	}
+	for _, id := range categoryIDs {
+		if err := ensureOwned(ctx, tx, "categories", id, userID); err != nil {
+			return models.EmergencyFund{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE accounts SET emergency_fund = (id = ANY($2)) WHERE user_id = $1`,
+		userID, pq.Array(accountIDs)); err != nil {
+		return models.EmergencyFund{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE categories SET essential = (id = ANY($2)) WHERE user_id = $1`,
+		userID, pq.Array(categoryIDs)); err != nil {
+		return models.EmergencyFund{}, err
+	}
+	// A new choice is checked afresh, so a fund that is still short is
+	// reported again.
+	if _, err := tx.ExecContext(ctx, `INSERT INTO emergency_funds (user_id, threshold_months, created_at, updated_at)
+			  VALUES ($1, $2, NOW(), NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET threshold_months = EXCLUDED.threshold_months, alerted_at = NULL, updated_at = NOW()`,
+		userID, threshold); err != nil {
+		return models.EmergencyFund{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.EmergencyFund{}, err
+	}
+
+	return s.EmergencyFund(ctx, userID, time.Now())
+}
+
+// RunEmergencyFundChecks alerts users who set up an emergency fund when its
+// coverage drops below their threshold.
+func (s *Service) RunEmergencyFundChecks(ctx context.Context) {
+	ticker := time.NewTicker(models.EmergencyFundSettings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.checkEmergencyFunds(ctx, time.Now()); err != nil {
+			log.Printf("Error checking emergency funds: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) checkEmergencyFunds(ctx context.Context, now time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM emergency_funds`)
+	if err != nil {
+		return err
+	}
+
+	var users []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		if err := s.checkEmergencyFund(ctx, userID, now); err != nil {
+			log.Printf("Error checking emergency fund of user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// checkEmergencyFund sends one alert per drop below the threshold. Claiming
+// alerted_at first keeps other instances from sending it too.
+func (s *Service) checkEmergencyFund(ctx context.Context, userID int, now time.Time) error {
+	fund, err := s.EmergencyFund(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	if !fund.BelowThreshold {
+		_, err := s.db.ExecContext(ctx, `UPDATE emergency_funds SET alerted_at = NULL WHERE user_id = $1 AND alerted_at IS NOT NULL`, userID)
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE emergency_funds SET alerted_at = NOW() WHERE user_id = $1 AND alerted_at IS NULL`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	l, currency := s.localizer(userID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID: userID,
+		Type:   notifications.Types.EmergencyFundLow,
+		Title:  l.T("Emergency fund running low"),
+		Message: l.T("Your emergency fund of %s covers %s months of essential spending, below your target of %s months.",
+			l.Amount(fund.Balance, currency), l.Number(*fund.CoverageMonths, 1), l.Number(fund.ThresholdMonths, 1)),
+		Data: map[string]interface{}{
+			"balance":          fund.Balance,
+			"coverage_months":  *fund.CoverageMonths,
+			"threshold_months": fund.ThresholdMonths,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/i18n"
+	"personal-finance-tracker/internal/models"
+)
+
+// healthWindow holds what the health metrics are computed from over a run of
+// whole financial months. Balances are only known for the current window.
+type healthWindow struct {
+	months      float64
+	income      float64
+	expenses    float64
+	balances    bool
+	coverage    *float64
+	liabilities float64
+	budgets     int
+	budgetsKept int
+}
+
+// healthCheck scores one metric linearly between the value worth nothing and
+// the value worth full marks.
+type healthCheck struct {
+	key         string
+	weight      float64
+	worst, best float64
+	value       func(w healthWindow) (float64, bool)
+	explain     func(l *i18n.Localizer, value float64) string
+}
+
+var healthChecks = []healthCheck{
+	{
+		key: "savings_rate", weight: 25, worst: 0, best: models.FinancialHealthSettings.TargetSavingsRate,
+		value: func(w healthWindow) (float64, bool) {
+			return (w.income - w.expenses) / w.income * 100, w.income > 0
+		},
+		explain: func(l *i18n.Localizer, value float64) string {
+			return l.T("You saved %.1f%% of your income; %.0f%% or more earns full marks.", value, models.FinancialHealthSettings.TargetSavingsRate)
+		},
+	},
+	{
+		key: "expense_ratio", weight: 15, worst: 100, best: models.FinancialHealthSettings.TargetExpenseRatio,
+		value: func(w healthWindow) (float64, bool) {
+			return w.expenses / w.income * 100, w.income > 0
+		},
+		explain: func(l *i18n.Localizer, value float64) string {
+			return l.T("Expenses took %.1f%% of your income; %.0f%% or less earns full marks.", value, models.FinancialHealthSettings.TargetExpenseRatio)
+		},
+	},
+	{
+		key: "emergency_fund", weight: 25, worst: 0, best: models.FinancialHealthSettings.TargetEmergencyMonths,
+		value: func(w healthWindow) (float64, bool) {
+			if w.coverage == nil {
+				return 0, false
+			}
+			return *w.coverage, true
+		},
+		explain: func(l *i18n.Localizer, value float64) string {
+			return l.T("Your emergency fund covers %.1f months of essential spending; %.0f months earns full marks.", value, models.FinancialHealthSettings.TargetEmergencyMonths)
+		},
+	},
+	{
+		key: "debt_to_income", weight: 20, worst: models.FinancialHealthSettings.MaxDebtToIncome, best: 0,
+		value: func(w healthWindow) (float64, bool) {
+			return w.liabilities / (w.income / w.months * 12) * 100, w.balances && w.income > 0
+		},
+		explain: func(l *i18n.Localizer, value float64) string {
+			return l.T("Your debts equal %.1f%% of a year's income; no debt earns full marks and %.0f%% or more none.", value, models.FinancialHealthSettings.MaxDebtToIncome)
+		},
+	},
+	{
+		key: "budget_adherence", weight: 15, worst: 0, best: 100,
+		value: func(w healthWindow) (float64, bool) {
+			return float64(w.budgetsKept) / float64(w.budgets) * 100, w.budgets > 0
+		},
+		explain: func(l *i18n.Localizer, value float64) string {
+			return l.T("You kept %.1f%% of your monthly category budgets.", value)
+		},
+	},
+}
+
+// FinancialHealth scores the user's finances over the last whole financial
+// months before now and compares each metric with the months before that.
+func (s *Service) FinancialHealth(ctx context.Context, userID int, now time.Time) (models.FinancialHealth, error) {
+	var health models.FinancialHealth
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return health, err
+	}
+	n := models.FinancialHealthSettings.Months
+	end := FiscalMonthStart(now.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	start := end.AddDate(0, -n, 0)
+	health.PeriodStart = start.Format("2006-01-02")
+	health.PeriodEnd = end.AddDate(0, 0, -1).Format("2006-01-02")
+	health.Currency = settings.BaseCurrency
+
+	current, err := s.healthWindow(ctx, userID, start, n)
+	if err != nil {
+		return health, err
+	}
+	previous, err := s.healthWindow(ctx, userID, start.AddDate(0, -n, 0), n)
+	if err != nil {
+		return health, err
+	}
+	current.balances = true
+	if err := s.ReadDB().QueryRowContext(ctx, `SELECT COALESCE(-SUM(balance), 0) FROM accounts
+			  WHERE user_id = $1 AND type IN ($2, $3) AND archived_at IS NULL`,
+		userID, models.AccountTypes.CreditCard, models.AccountTypes.Loan).Scan(&current.liabilities); err != nil {
+		return health, err
+	}
+	fund, err := s.EmergencyFund(ctx, userID, now)
+	if err != nil {
+		return health, err
+	}
+	current.coverage = fund.CoverageMonths
+
+	l, _ := s.localizer(userID)
+	health.Metrics = []models.HealthMetric{}
+	var weights float64
+	for _, check := range healthChecks {
+		value, ok := check.value(current)
+		if !ok {
+			continue
+		}
+		metric := models.HealthMetric{
+			Key:         check.key,
+			Value:       math.Round(value*10) / 10,
+			Score:       check.score(value),
+			Weight:      check.weight,
+			Explanation: check.explain(l, value),
+		}
+		if before, ok := check.value(previous); ok {
+			rounded := math.Round(before*10) / 10
+			metric.Previous = &rounded
+			metric.Trend = healthTrend(metric.Score - check.score(before))
+		}
+		weights += check.weight
+		health.Metrics = append(health.Metrics, metric)
+	}
+
+	for i := range health.Metrics {
+		health.Metrics[i].Weight = math.Round(health.Metrics[i].Weight/weights*1000) / 1000
+		health.Score += health.Metrics[i].Score * health.Metrics[i].Weight
+	}
+	health.Score = math.Round(health.Score*10) / 10
+	if len(health.Metrics) > 0 {
+		health.Rating = healthRating(health.Score)
+	}
+	return health, nil
+}
+
+// healthWindow sums income and expenses and checks the category budgets of
+// the given number of financial months from start.
+func (s *Service) healthWindow(ctx context.Context, userID int, start time.Time, months int) (healthWindow, error) {
+	w := healthWindow{months: float64(months)}
+
+	totals, err := s.CategoryTotals(ctx, userID, start, start.AddDate(0, months, 0), "")
+	if err != nil {
+		return w, err
+	}
+	for _, total := range totals {
+		if total.Type == "income" {
+			w.income += total.Amount
+		} else {
+			w.expenses += total.Amount
+		}
+	}
+
+	for i := 0; i < months; i++ {
+		overview, err := s.BudgetOverview(ctx, userID, start.AddDate(0, i, 0))
+		if err != nil {
+			return w, err
+		}
+		for _, status := range overview.Categories {
+			w.budgets++
+			if status.Spent <= status.Budget {
+				w.budgetsKept++
+			}
+		}
+	}
+	return w, nil
+}
+
+func (c healthCheck) score(value float64) float64 {
+	score := (value - c.worst) / (c.best - c.worst) * 100
+	return math.Round(math.Max(0, math.Min(100, score))*10) / 10
+}
+
+func healthTrend(change float64) string {
+	switch {
+	case change > models.FinancialHealthSettings.TrendThreshold:
+		return "improving"
+	case change < -models.FinancialHealthSettings.TrendThreshold:
+		return "declining"
+	}
+	return "steady"
+}
+
+func healthRating(score float64) string {
+	switch {
+	case score >= 80:
+		return models.HealthRatings.Excellent
+	case score >= 60:
+		return models.HealthRatings.Good
+	case score >= 40:
+		return models.HealthRatings.Fair
+	}
+	return models.HealthRatings.Poor
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"sync"
+	"time"
+
+	"personal-finance-tracker/internal/cache"
+	"personal-finance-tracker/internal/models"
+)
+
+type healthProbe struct {
+	name  string
+	check func(ctx context.Context) (string, error)
+}
+
+// Readiness probes every dependency the API needs to serve traffic, each
+// with its own timeout, and reports the instance as down if any of them is.
+func (s *Service) Readiness(ctx context.Context) models.HealthReport {
+	probes := []healthProbe{
+		{"database", func(ctx context.Context) (string, error) { return databaseVersion(ctx, s.db) }},
+		{"jobs", func(ctx context.Context) (string, error) { return "", s.jobs.Ping(ctx) }},
+	}
+	if s.replica != nil {
+		probes = append(probes, healthProbe{"replica", func(ctx context.Context) (string, error) { return databaseVersion(ctx, s.replica) }})
+	}
+	pinger, redis := s.cache.(cache.Pinger)
+	if redis {
+		probes = append(probes, healthProbe{"redis", pinger.Ping})
+	}
+
+	report := models.HealthReport{
+		Status:     models.HealthStatuses.Up,
+		Version:    models.AppVersion,
+		GoVersion:  runtime.Version(),
+		Components: make([]models.ComponentHealth, len(probes)),
+		CheckedAt:  time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func(i int, probe healthProbe) {
+			defer wg.Done()
+			report.Components[i] = runProbe(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	if !redis {
+		report.Components = append(report.Components, models.ComponentHealth{Name: "redis", Status: models.HealthStatuses.Disabled})
+	}
+	for _, component := range report.Components {
+		if component.Status == models.HealthStatuses.Down {
+			report.Status = models.HealthStatuses.Down
+		}
+	}
+	return report
+}
+
+func runProbe(ctx context.Context, probe healthProbe) models.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, models.HealthSettings.ProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	version, err := probe.check(ctx)
+	component := models.ComponentHealth{
+		Name:      probe.name,
+		Status:    models.HealthStatuses.Up,
+		Version:   version,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		component.Status = models.HealthStatuses.Down
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func databaseVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version string
+	err := db.QueryRowContext(ctx, `SHOW server_version`).Scan(&version)
+	return version, err
+}
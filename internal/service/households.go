@@ -0,0 +1,562 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+
+	"github.com/lib/pq"
+)
+
+// householdOf returns the household the user records transactions in as an
+// editor, or ErrNotInHousehold.
+func (s *Service) householdOf(ctx context.Context, memberID int) (models.Household, error) {
+	household := models.Household{}
+	query := `SELECT m.owner_id, u.email, m.role, h.approval_threshold
+			  FROM household_members m
+			  JOIN households h ON h.owner_id = m.owner_id
+			  JOIN users u ON u.id = m.owner_id
+			  WHERE m.member_id = $1`
+
+	err := s.db.QueryRowContext(ctx, query, memberID).
+		Scan(&household.OwnerID, &household.OwnerEmail, &household.Role, &household.ApprovalThreshold)
+	if err == sql.ErrNoRows {
+		return household, ErrNotInHousehold
+	}
+	return household, err
+}
+
+// memberAccounts returns the owner's accounts the member may use: all of
+// them for editors, the granted ones for everyone else.
+func (s *Service) memberAccounts(ctx context.Context, household models.Household, memberID int) ([]models.Account, error) {
+	accounts, err := s.GetAccounts(ctx, household.OwnerID)
+	if err != nil || household.Role == models.HouseholdRoles.Editor {
+		return accounts, err
+	}
+
+	granted := map[int]bool{}
+	rows, err := s.db.QueryContext(ctx, `SELECT account_id FROM household_member_accounts WHERE member_id = $1`, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		granted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var allowed []models.Account
+	for _, account := range accounts {
+		if granted[account.ID] {
+			allowed = append(allowed, account)
+		}
+	}
+	return allowed, nil
+}
+
+func (s *Service) memberCanUse(ctx context.Context, household models.Household, memberID, accountID int) error {
+	accounts, err := s.memberAccounts(ctx, household, memberID)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if account.ID == accountID {
+			return nil
+		}
+	}
+	return ErrAccountNotFound
+}
+
+// Household returns the household the user belongs to, as a member or as its
+// owner. Every user owns a household, even if it has no members yet.
+func (s *Service) Household(ctx context.Context, userID int) (models.Household, error) {
+	household, err := s.householdOf(ctx, userID)
+	if err == nil {
+		if household.Accounts, err = s.memberAccounts(ctx, household, userID); err != nil {
+			return household, err
+		}
+		household.Categories, err = s.GetCategories(ctx, household.OwnerID, "")
+		return household, err
+	}
+	if err != ErrNotInHousehold {
+		return household, err
+	}
+
+	household = models.Household{OwnerID: userID, Role: models.HouseholdRoles.Owner, Members: []models.HouseholdMember{}}
+	err = s.db.QueryRowContext(ctx, `SELECT u.email, h.approval_threshold FROM users u
+			  LEFT JOIN households h ON h.owner_id = u.id WHERE u.id = $1`, userID).
+		Scan(&household.OwnerEmail, &household.ApprovalThreshold)
+	if err == sql.ErrNoRows {
+		return household, ErrUserNotFound
+	}
+	if err != nil {
+		return household, err
+	}
+
+	query := `SELECT u.id, u.email, u.first_name, u.last_name, m.role, m.managed, m.created_at,
+			  ARRAY(SELECT account_id FROM household_member_accounts a WHERE a.member_id = m.member_id ORDER BY account_id)
+			  FROM household_members m JOIN users u ON u.id = m.member_id
+			  WHERE m.owner_id = $1 ORDER BY m.created_at`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return household, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.HouseholdMember
+		var accountIDs pq.Int64Array
+		if err := rows.Scan(&member.UserID, &member.Email, &member.FirstName, &member.LastName, &member.Role,
+			&member.Managed, &member.CreatedAt, &accountIDs); err != nil {
+			return household, err
+		}
+		member.AccountIDs = make([]int, len(accountIDs))
+		for i, id := range accountIDs {
+			member.AccountIDs[i] = int(id)
+		}
+		household.Members = append(household.Members, member)
+	}
+	return household, rows.Err()
+}
+
+// setMemberAccounts replaces the accounts granted to a member. Editors need
+// no grants as they can use every account.
+func setMemberAccounts(ctx context.Context, tx *sql.Tx, ownerID, memberID int, role string, accountIDs []int) ([]int, error) {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM household_member_accounts WHERE member_id = $1`, memberID); err != nil {
+		return nil, err
+	}
+	if role == models.HouseholdRoles.Editor {
+		return []int{}, nil
+	}
+
+	granted := []int{}
+	for _, id := range accountIDs {
+		if err := ensureOwned(ctx, tx, "accounts", id, ownerID); err != nil {
+			return nil, err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO household_member_accounts (owner_id, member_id, account_id)
+				  VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, ownerID, memberID, id)
+		if err != nil {
+			return nil, err
+		}
+		granted = append(granted, id)
+	}
+	return granted, nil
+}
+
+func (s *Service) UpdateHousehold(ctx context.Context, ownerID int, threshold *float64) (models.Household, error) {
+	if _, err := s.householdOf(ctx, ownerID); err != ErrNotInHousehold {
+		if err == nil {
+			err = ErrNestedHousehold
+		}
+		return models.Household{}, err
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO households (owner_id, approval_threshold, created_at, updated_at)
+			  VALUES ($1, $2, NOW(), NOW())
+			  ON CONFLICT (owner_id) DO UPDATE SET approval_threshold = EXCLUDED.approval_threshold, updated_at = NOW()`,
+		ownerID, threshold)
+	if err != nil {
+		return models.Household{}, err
+	}
+	return s.Household(ctx, ownerID)
+}
+
+func (s *Service) AddHouseholdMember(ctx context.Context, ownerID int, email, role string, accountIDs []int) (models.HouseholdMember, error) {
+	member := models.HouseholdMember{Role: role}
+	if member.Role == "" {
+		member.Role = models.HouseholdRoles.Editor
+	}
+
+	if _, err := s.householdOf(ctx, ownerID); err != ErrNotInHousehold {
+		if err == nil {
+			err = ErrNestedHousehold
+		}
+		return member, err
+	}
+
+	err := s.db.QueryRowContext(ctx, `SELECT id, email, first_name, last_name FROM users WHERE LOWER(email) = LOWER($1)`, email).
+		Scan(&member.UserID, &member.Email, &member.FirstName, &member.LastName)
+	if err == sql.ErrNoRows {
+		return member, ErrUserNotFound
+	}
+	if err != nil {
+		return member, err
+	}
+	if member.UserID == ownerID {
+		return member, ErrInvalidMember
+	}
+
+	var hasMembers bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM household_members WHERE owner_id = $1)`, member.UserID).Scan(&hasMembers)
+	if err != nil {
+		return member, err
+	}
+	if hasMembers {
+		return member, ErrNestedHousehold
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return member, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO households (owner_id, created_at, updated_at) VALUES ($1, NOW(), NOW())
+			  ON CONFLICT (owner_id) DO NOTHING`, ownerID); err != nil {
+		return member, err
+	}
+	err = tx.QueryRowContext(ctx, `INSERT INTO household_members (owner_id, member_id, role, created_at)
+			  VALUES ($1, $2, $3, NOW()) RETURNING created_at`, ownerID, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if isUniqueViolation(err) {
+		return member, ErrMemberExists
+	}
+	if err != nil {
+		return member, err
+	}
+	if member.AccountIDs, err = setMemberAccounts(ctx, tx, ownerID, member.UserID, member.Role, accountIDs); err != nil {
+		return member, err
+	}
+	return member, tx.Commit()
+}
+
+// CreateManagedProfile creates a login for a member without an account of
+// their own and adds it to the owner's household.
+func (s *Service) CreateManagedProfile(ctx context.Context, ownerID int, req models.ManagedProfileRequest) (models.HouseholdMember, error) {
+	member := models.HouseholdMember{Email: req.Email, FirstName: req.FirstName, LastName: req.LastName, Role: req.Role, Managed: true}
+	if member.Role == "" {
+		member.Role = models.HouseholdRoles.Limited
+	}
+
+	if _, err := s.householdOf(ctx, ownerID); err != ErrNotInHousehold {
+		if err == nil {
+			err = ErrNestedHousehold
+		}
+		return member, err
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return member, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return member, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) ON CONFLICT (email) DO NOTHING RETURNING id`,
+		member.Email, hashedPassword, member.FirstName, member.LastName).Scan(&member.UserID)
+	if err == sql.ErrNoRows {
+		return member, ErrEmailTaken
+	}
+	if err != nil {
+		return member, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO households (owner_id, created_at, updated_at) VALUES ($1, NOW(), NOW())
+			  ON CONFLICT (owner_id) DO NOTHING`, ownerID); err != nil {
+		return member, err
+	}
+	err = tx.QueryRowContext(ctx, `INSERT INTO household_members (owner_id, member_id, role, managed, created_at)
+			  VALUES ($1, $2, $3, TRUE, NOW()) RETURNING created_at`, ownerID, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if err != nil {
+		return member, err
+	}
+	if member.AccountIDs, err = setMemberAccounts(ctx, tx, ownerID, member.UserID, member.Role, req.AccountIDs); err != nil {
+		return member, err
+	}
+	return member, tx.Commit()
+}
+
+func (s *Service) UpdateHouseholdMember(ctx context.Context, ownerID, memberID int, role string, accountIDs []int) (models.HouseholdMember, error) {
+	member := models.HouseholdMember{UserID: memberID, Role: role}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return member, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE household_members m SET role = $1 FROM users u
+			  WHERE m.owner_id = $2 AND m.member_id = $3 AND u.id = m.member_id
+			  RETURNING u.email, u.first_name, u.last_name, m.managed, m.created_at`
+
+	err = tx.QueryRowContext(ctx, query, role, ownerID, memberID).
+		Scan(&member.Email, &member.FirstName, &member.LastName, &member.Managed, &member.CreatedAt)
+	if err == sql.ErrNoRows {
+		return member, ErrUserNotFound
+	}
+	if err != nil {
+		return member, err
+	}
+	if member.AccountIDs, err = setMemberAccounts(ctx, tx, ownerID, memberID, role, accountIDs); err != nil {
+		return member, err
+	}
+	return member, tx.Commit()
+}
+
+// RemoveHouseholdMember ends a membership. Managed profiles have no life
+// outside the household, so their user is deleted as well.
+func (s *Service) RemoveHouseholdMember(ctx context.Context, ownerID, memberID int) error {
+	var managed bool
+	err := s.db.QueryRowContext(ctx, `DELETE FROM household_members WHERE owner_id = $1 AND member_id = $2 RETURNING managed`,
+		ownerID, memberID).Scan(&managed)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil || !managed {
+		return err
+	}
+	return s.deleteUserData(ctx, memberID)
+}
+
+// CreateHouseholdTransaction records an editor's transaction in the owner's
+// books. Amounts above the approval threshold are held for the owner and
+// returned as an approval instead; they do not touch any balance until then.
+func (s *Service) CreateHouseholdTransaction(ctx context.Context, memberID int, t *models.Transaction) (*models.TransactionApproval, error) {
+	household, err := s.householdOf(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if household.Role == models.HouseholdRoles.Viewer {
+		return nil, ErrReadOnlyMember
+	}
+	if err := s.memberCanUse(ctx, household, memberID, t.AccountID); err != nil {
+		return nil, err
+	}
+	t.UserID = household.OwnerID
+
+	if household.ApprovalThreshold == nil || t.Amount <= *household.ApprovalThreshold {
+		return nil, s.CreateTransaction(ctx, t, false)
+	}
+
+	if err := ensureOwned(ctx, s.db, "categories", t.CategoryID, t.UserID); err != nil {
+		return nil, err
+	}
+	account, err := s.GetAccount(ctx, t.UserID, t.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.ArchivedAt != nil {
+		return nil, ErrAccountArchived
+	}
+
+	approval := models.TransactionApproval{
+		OwnerID:     household.OwnerID,
+		RequestedBy: &memberID,
+		AccountID:   t.AccountID,
+		CategoryID:  t.CategoryID,
+		Amount:      t.Amount,
+		Type:        t.Type,
+		Description: t.Description,
+		Date:        t.Date,
+		Status:      models.DraftStatuses.Pending,
+	}
+	query := `INSERT INTO transaction_approvals (owner_id, requested_by, account_id, category_id, amount, type, description, date, status, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = s.db.QueryRowContext(ctx, query, approval.OwnerID, approval.RequestedBy, approval.AccountID, approval.CategoryID,
+		approval.Amount, approval.Type, approval.Description, approval.Date, approval.Status).
+		Scan(&approval.ID, &approval.CreatedAt, &approval.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	requester, err := s.GetUser(ctx, memberID)
+	if err != nil {
+		log.Printf("Error loading approval requester %d: %v", memberID, err)
+	}
+	l, currency := s.localizer(approval.OwnerID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  approval.OwnerID,
+		Type:    notifications.Types.ApprovalRequest,
+		Title:   l.T("Transaction awaiting approval"),
+		Message: l.T("%s recorded a %s of %s that needs your approval: %s", requester.FirstName, l.T(approval.Type), l.Amount(approval.Amount, currency), approval.Description),
+		Data: map[string]interface{}{
+			"approval_id": approval.ID,
+			"amount":      approval.Amount,
+			"description": approval.Description,
+		},
+	})
+	return &approval, nil
+}
+
+// HouseholdTransactions lists the owner's recent transactions in the accounts
+// the member may use, optionally narrowed to one of them.
+func (s *Service) HouseholdTransactions(ctx context.Context, memberID, accountID, limit int) ([]models.Transaction, error) {
+	household, err := s.householdOf(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.memberAccounts(ctx, household, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids pq.Int64Array
+	for _, account := range accounts {
+		if accountID == 0 || account.ID == accountID {
+			ids = append(ids, int64(account.ID))
+		}
+	}
+	if accountID != 0 && len(ids) == 0 {
+		return nil, ErrAccountNotFound
+	}
+
+	query := `SELECT id, user_id, account_id, COALESCE(category_id, 0), amount, type, COALESCE(description, ''), date, created_at, updated_at
+			  FROM transactions WHERE user_id = $1 AND account_id = ANY($2)
+			  ORDER BY date DESC LIMIT $3`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, household.OwnerID, ids, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type, &t.Description,
+			&t.Date, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+func scanApproval(row interface{ Scan(...interface{}) error }, a *models.TransactionApproval) error {
+	return row.Scan(&a.ID, &a.OwnerID, &a.RequestedBy, &a.AccountID, &a.CategoryID, &a.Amount, &a.Type,
+		&a.Description, &a.Date, &a.Status, &a.TransactionID, &a.DecidedAt, &a.CreatedAt, &a.UpdatedAt)
+}
+
+const approvalColumns = `id, owner_id, requested_by, account_id, category_id, amount, type, description, date,
+			  status, transaction_id, decided_at, created_at, updated_at`
+
+func (s *Service) TransactionApprovals(ctx context.Context, ownerID int, status string) ([]models.TransactionApproval, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+approvalColumns+` FROM transaction_approvals
+			  WHERE owner_id = $1 AND status = $2 ORDER BY created_at DESC`, ownerID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []models.TransactionApproval{}
+	for rows.Next() {
+		var approval models.TransactionApproval
+		if err := scanApproval(rows, &approval); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, rows.Err()
+}
+
+// ApproveTransaction books a pending transaction in the owner's accounts.
+// Like CreateTransaction, an expense over a hard-capped budget fails with a
+// *BudgetCapError unless force is set.
+func (s *Service) ApproveTransaction(ctx context.Context, ownerID, approvalID int, force bool) (models.Transaction, error) {
+	var transaction models.Transaction
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return transaction, err
+	}
+	defer tx.Rollback()
+
+	var approval models.TransactionApproval
+	err = scanApproval(tx.QueryRowContext(ctx, `SELECT `+approvalColumns+` FROM transaction_approvals
+			  WHERE id = $1 AND owner_id = $2 AND status = $3 FOR UPDATE`, approvalID, ownerID, models.DraftStatuses.Pending), &approval)
+	if err == sql.ErrNoRows {
+		return transaction, ErrApprovalNotFound
+	}
+	if err != nil {
+		return transaction, err
+	}
+
+	transaction = models.Transaction{
+		UserID:      ownerID,
+		AccountID:   approval.AccountID,
+		CategoryID:  approval.CategoryID,
+		Amount:      approval.Amount,
+		Type:        approval.Type,
+		Description: approval.Description,
+		Date:        approval.Date,
+	}
+	balance, err := s.InsertWithinCap(ctx, tx, &transaction, force)
+	if err != nil {
+		return transaction, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE transaction_approvals SET status = $1, transaction_id = $2, decided_at = NOW(), updated_at = NOW()
+			  WHERE id = $3`, models.DraftStatuses.Approved, transaction.ID, approvalID)
+	if err != nil {
+		return transaction, err
+	}
+	if err := RecordTransactionCreated(ctx, tx, transaction, balance); err != nil {
+		return transaction, err
+	}
+	if err := tx.Commit(); err != nil {
+		return transaction, err
+	}
+
+	s.TransactionCreated(transaction)
+	approval.Status = models.DraftStatuses.Approved
+	s.notifyApprovalDecision(approval)
+	return transaction, nil
+}
+
+func (s *Service) RejectTransaction(ctx context.Context, ownerID, approvalID int) error {
+	var approval models.TransactionApproval
+	err := scanApproval(s.db.QueryRowContext(ctx, `UPDATE transaction_approvals SET status = $1, decided_at = NOW(), updated_at = NOW()
+			  WHERE id = $2 AND owner_id = $3 AND status = $4 RETURNING `+approvalColumns,
+		models.DraftStatuses.Rejected, approvalID, ownerID, models.DraftStatuses.Pending), &approval)
+	if err == sql.ErrNoRows {
+		return ErrApprovalNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.notifyApprovalDecision(approval)
+	return nil
+}
+
+func (s *Service) notifyApprovalDecision(approval models.TransactionApproval) {
+	if approval.RequestedBy == nil {
+		return
+	}
+
+	// Amounts are in the owner's currency, the message in the editor's language.
+	l, _ := s.localizer(*approval.RequestedBy)
+	_, currency := s.localizer(approval.OwnerID)
+	title := l.T("Transaction approved")
+	if approval.Status == models.DraftStatuses.Rejected {
+		title = l.T("Transaction rejected")
+	}
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  *approval.RequestedBy,
+		Type:    notifications.Types.ApprovalDecision,
+		Title:   title,
+		Message: l.T("Your %s of %s was %s: %s", l.T(approval.Type), l.Amount(approval.Amount, currency), l.T(approval.Status), approval.Description),
+		Data: map[string]interface{}{
+			"approval_id":    approval.ID,
+			"status":         approval.Status,
+			"transaction_id": approval.TransactionID,
+		},
+	})
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/statements"
+)
+
+// importFields are the columns an import can read; importDefaultColumns maps
+// them to headers of the same name for imports without a profile.
+var (
+	importFields         = []string{"date", "description", "memo", "amount", "debit", "credit", "type", "category"}
+	importDefaultColumns = map[string]string{
+		"date": "date", "description": "description", "amount": "amount", "type": "type", "category": "category",
+	}
+)
+
+const importProfileColumns = `id, user_id, name, columns, delimiter, date_format, decimal_separator, sign_convention,
+			  account_id, created_at, updated_at`
+
+func scanImportProfile(row interface{ Scan(...interface{}) error }, p *models.ImportProfile) error {
+	var columns []byte
+	if err := row.Scan(&p.ID, &p.UserID, &p.Name, &columns, &p.Delimiter, &p.DateFormat, &p.DecimalSeparator,
+		&p.SignConvention, &p.AccountID, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return err
+	}
+	return json.Unmarshal(columns, &p.Columns)
+}
+
+// ImportProfiles lists the user's profiles followed by the built-in ones.
+func (s *Service) ImportProfiles(ctx context.Context, userID int) ([]models.ImportProfile, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+importProfileColumns+` FROM import_profiles WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.ImportProfile{}
+	for rows.Next() {
+		var profile models.ImportProfile
+		if err := scanImportProfile(rows, &profile); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return append(profiles, statements.BuiltinProfiles()...), nil
+}
+
+// ImportProfile resolves the profile an import was started with: a number
+// selects one of the user's profiles, anything else a built-in one by key.
+func (s *Service) ImportProfile(ctx context.Context, userID int, ref string) (models.ImportProfile, error) {
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		profile, ok := statements.BuiltinProfile(ref)
+		if !ok {
+			return profile, ErrImportProfileNotFound
+		}
+		return profile, nil
+	}
+
+	var profile models.ImportProfile
+	err = scanImportProfile(s.db.QueryRowContext(ctx, `SELECT `+importProfileColumns+` FROM import_profiles
+			  WHERE id = $1 AND user_id = $2`, id, userID), &profile)
+	if err == sql.ErrNoRows {
+		return profile, ErrImportProfileNotFound
+	}
+	return profile, err
+}
+
+func (s *Service) CreateImportProfile(ctx context.Context, p *models.ImportProfile) error {
+	if err := s.validateImportProfile(ctx, p); err != nil {
+		return err
+	}
+	columns, err := json.Marshal(p.Columns)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO import_profiles (user_id, name, columns, delimiter, date_format, decimal_separator, sign_convention,
+			  account_id, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING ` + importProfileColumns
+
+	err = scanImportProfile(s.db.QueryRowContext(ctx, query, p.UserID, p.Name, columns, p.Delimiter, p.DateFormat,
+		p.DecimalSeparator, p.SignConvention, p.AccountID), p)
+	if isUniqueViolation(err) {
+		return ErrImportProfileExists
+	}
+	return err
+}
+
+func (s *Service) UpdateImportProfile(ctx context.Context, p *models.ImportProfile) error {
+	if err := s.validateImportProfile(ctx, p); err != nil {
+		return err
+	}
+	columns, err := json.Marshal(p.Columns)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE import_profiles SET name = $1, columns = $2, delimiter = $3, date_format = $4, decimal_separator = $5,
+			  sign_convention = $6, account_id = $7, updated_at = NOW()
+			  WHERE id = $8 AND user_id = $9 RETURNING ` + importProfileColumns
+
+	err = scanImportProfile(s.db.QueryRowContext(ctx, query, p.Name, columns, p.Delimiter, p.DateFormat, p.DecimalSeparator,
+		p.SignConvention, p.AccountID, p.ID, p.UserID), p)
+	if err == sql.ErrNoRows {
+		return ErrImportProfileNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrImportProfileExists
+	}
+	return err
+}
+
+func (s *Service) DeleteImportProfile(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM import_profiles WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrImportProfileNotFound
+	}
+	return nil
+}
+
+// validateImportProfile fills in defaults and checks the profile can read
+// a file: the columns it needs are mapped and the formats are understood.
+func (s *Service) validateImportProfile(ctx context.Context, p *models.ImportProfile) error {
+	if p.Delimiter == "" {
+		p.Delimiter = ","
+	}
+	if p.DecimalSeparator == "" {
+		p.DecimalSeparator = "."
+	}
+	if p.SignConvention == "" {
+		p.SignConvention = models.ImportSignConventions.Signed
+	}
+
+	columns := map[string]string{}
+	for _, field := range importFields {
+		if header := strings.TrimSpace(p.Columns[field]); header != "" {
+			columns[field] = header
+		}
+	}
+	p.Columns = columns
+
+	amountColumns := columns["amount"] != ""
+	if p.SignConvention == models.ImportSignConventions.DebitCredit {
+		amountColumns = columns["debit"] != "" && columns["credit"] != ""
+	}
+	if columns["date"] == "" || columns["description"] == "" || !amountColumns {
+		return ErrImportProfileColumns
+	}
+	if _, err := importDateLayout(p.DateFormat); err != nil {
+		return err
+	}
+	if (p.DecimalSeparator != "." && p.DecimalSeparator != ",") || utf8.RuneCountInString(p.Delimiter) != 1 {
+		return ErrImportSeparators
+	}
+
+	if p.AccountID != nil {
+		return ensureOwned(ctx, s.db, "accounts", *p.AccountID, p.UserID)
+	}
+	return nil
+}
+
+// importDateLayout turns a date format such as DD.MM.YYYY into a Go layout.
+// An empty format keeps the layouts imports try by default.
+func importDateLayout(format string) (string, error) {
+	if format == "" {
+		return "", nil
+	}
+	layout := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02").Replace(format)
+	if strings.IndexFunc(layout, unicode.IsLetter) >= 0 ||
+		!strings.Contains(layout, "06") || !strings.Contains(layout, "01") || !strings.Contains(layout, "02") {
+		return "", ErrImportDateFormat
+	}
+	return layout, nil
+}
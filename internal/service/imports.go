@@ -0,0 +1,624 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"personal-finance-tracker/internal/classifier"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/jobs"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/statements"
+)
+
+var ErrInvalidImportFile = errors.New("import file must be a CSV with Date, Description and Amount columns or a QIF, MT940 or CAMT.053 statement")
+
+type importJob struct {
+	FileID    int                   `json:"file_id"`
+	AccountID int                   `json:"account_id"`
+	Profile   *models.ImportProfile `json:"profile,omitempty"`
+}
+
+type exportJob struct {
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+	Scramble  bool   `json:"scramble,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+var importDateLayouts = []string{"2006-01-02", "02.01.2006", "2006/01/02", time.RFC3339}
+
+func (s *Service) registerJobHandlers() {
+	s.jobs.Register(models.JobTypes.Import, s.runImport, jobs.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   models.JobSettings.BaseRetryDelay,
+		MaxDelay:    models.JobSettings.MaxRetryDelay,
+	})
+	s.jobs.Register(models.JobTypes.Export, s.runExport, jobs.DefaultRetryPolicy())
+	s.jobs.Register(models.JobTypes.Report, s.runReportSchedule, jobs.DefaultRetryPolicy())
+	s.jobs.Register(models.JobTypes.AutomationWebhook, s.runAutomationWebhook, jobs.DefaultRetryPolicy())
+	s.jobs.OnFinish(s.jobFinished)
+}
+
+// StartImport queues an import into accountID, or into the profile's default
+// account when accountID is 0. profileRef selects an import profile by ID or
+// built-in key; the profile is copied into the job so later edits do not
+// change a retried import.
+func (s *Service) StartImport(ctx context.Context, userID, accountID int, name string, data []byte, profileRef string) (models.Job, error) {
+	var profile *models.ImportProfile
+	if profileRef != "" {
+		p, err := s.ImportProfile(ctx, userID, profileRef)
+		if err != nil {
+			return models.Job{}, err
+		}
+		profile = &p
+		if accountID == 0 && p.AccountID != nil {
+			accountID = *p.AccountID
+		}
+	}
+	if accountID == 0 {
+		return models.Job{}, ErrImportAccountRequired
+	}
+	if err := ensureOwned(ctx, s.db, "accounts", accountID, userID); err != nil {
+		return models.Job{}, err
+	}
+
+	format := statements.Detect(name, data)
+	if _, _, err := importRows(format, data, profile); err != nil {
+		return models.Job{}, ErrInvalidImportFile
+	}
+
+	fileID, err := s.jobs.SaveFile(ctx, userID, name, statements.ContentTypes[format], data)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return s.jobs.Enqueue(ctx, userID, models.JobTypes.Import, importJob{FileID: fileID, AccountID: accountID, Profile: profile})
+}
+
+func (s *Service) StartExport(ctx context.Context, userID int, req models.ExportRequest) (models.Job, error) {
+	return s.jobs.Enqueue(ctx, userID, models.JobTypes.Export, exportJob{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Scramble:  req.Scramble,
+		Scope:     req.Scope,
+	})
+}
+
+// runImport parses the file into a staged import the user reviews before
+// committing it. Everything is staged in one database transaction, and a
+// retried job replaces whatever an earlier attempt staged.
+func (s *Service) runImport(ctx context.Context, job models.Job) (interface{}, error) {
+	var payload importJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	file, err := s.jobs.File(ctx, job.UserID, payload.FileID)
+	if err != nil {
+		return nil, err
+	}
+
+	format := statements.Detect(file.Name, file.Data)
+	columns, records, err := importRows(format, file.Data, payload.Profile)
+	if err != nil {
+		return nil, err
+	}
+	var profile models.ImportProfile
+	if payload.Profile != nil && format == statements.CSV {
+		profile = *payload.Profile
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM staged_imports WHERE job_id = $1`, job.ID); err != nil {
+		return nil, err
+	}
+	staged := models.StagedImport{
+		UserID:    job.UserID,
+		AccountID: payload.AccountID,
+		JobID:     &job.ID,
+		FileName:  file.Name,
+		Status:    models.ImportStatuses.Staged,
+		RowsTotal: len(records),
+	}
+	err = tx.QueryRowContext(ctx, `INSERT INTO staged_imports (user_id, account_id, job_id, file_name, status, created_at)
+			  VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`,
+		staged.UserID, staged.AccountID, job.ID, staged.FileName, staged.Status).Scan(&staged.ID, &staged.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewer, err := s.newImportReviewer(ctx, tx, job.UserID, payload.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := models.ImportProgress{RowsTotal: len(records)}
+	loc := s.Location(ctx, job.UserID)
+
+	for _, record := range records {
+		row := models.StagedImportRow{Row: record.line, Include: true}
+		t, categoryName, err := parseImportRow(record.fields, columns, profile, loc)
+		if err == nil {
+			row.Date, row.Description, row.Amount, row.Type, row.CategoryName = &t.Date, t.Description, t.Amount, t.Type, categoryName
+			if err := reviewer.review(ctx, &row); err != nil {
+				return nil, err
+			}
+		}
+
+		progress.RowsProcessed++
+		if err != nil {
+			row.Error, row.Include = err.Error(), false
+			staged.Failed++
+			progress.Failed++
+			if len(progress.Errors) < models.ImportSettings.MaxReportedErrors {
+				progress.Errors = append(progress.Errors, models.ImportRowError{Row: record.line, Error: err.Error()})
+			}
+		} else {
+			progress.Imported++
+		}
+		if row.DuplicateOf != nil {
+			staged.Duplicates++
+		}
+
+		if err := stageImportRow(ctx, tx, staged.ID, row); err != nil {
+			return nil, err
+		}
+
+		if progress.RowsProcessed%models.ImportSettings.ProgressEvery == 0 {
+			if err := s.jobs.ReportProgress(ctx, job.ID, progress); err != nil {
+				log.Printf("Error reporting import progress: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := s.jobs.ReportProgress(ctx, job.ID, progress); err != nil {
+		log.Printf("Error reporting import progress: %v", err)
+	}
+	return staged, nil
+}
+
+// importRecord is a data row of an import file with its 1-based row number.
+type importRecord struct {
+	line   int
+	fields []string
+}
+
+// importRows reads an import file into its data rows and the index of each
+// column they hold. CSV files are read through the import profile, if any;
+// rows above the header (bank preambles) are skipped. Statements are
+// converted to date, description, amount and category columns, so every
+// format goes through the same row parsing and category mapping.
+func importRows(format string, data []byte, profile *models.ImportProfile) (map[string]int, []importRecord, error) {
+	if format != statements.CSV {
+		entries, err := statements.Parse(format, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse %s: %w", format, err)
+		}
+		records := make([]importRecord, len(entries))
+		for i, entry := range entries {
+			records[i] = importRecord{line: i + 1, fields: []string{
+				entry.Date.Format("2006-01-02"),
+				entry.Description,
+				strconv.FormatFloat(entry.Amount, 'f', 2, 64),
+				entry.Category,
+			}}
+		}
+		return map[string]int{"date": 0, "description": 1, "amount": 2, "category": 3}, records, nil
+	}
+
+	names := importDefaultColumns
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	if profile != nil {
+		names = profile.Columns
+		reader.Comma, _ = utf8.DecodeRuneInString(profile.Delimiter)
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse CSV: %w", err)
+	}
+
+	for i, row := range rows {
+		columns := make(map[string]int)
+		for field, name := range names {
+			for j, header := range row {
+				if strings.EqualFold(strings.TrimSpace(header), name) {
+					columns[field] = j
+					break
+				}
+			}
+		}
+		if _, ok := columns["date"]; !ok {
+			continue
+		}
+
+		_, amount := columns["amount"]
+		_, debit := columns["debit"]
+		_, credit := columns["credit"]
+		if _, ok := columns["description"]; !ok || !amount && !(debit && credit) {
+			return nil, nil, ErrInvalidImportFile
+		}
+
+		records := make([]importRecord, 0, len(rows)-i-1)
+		for j, fields := range rows[i+1:] {
+			records = append(records, importRecord{line: i + j + 2, fields: fields})
+		}
+		return columns, records, nil
+	}
+	return nil, nil, ErrInvalidImportFile
+}
+
+// importRow inserts a transaction, creating its category from categoryName
+// unless the transaction already has one. A row without a category name gets
+// the suggested category, or the default one when the user has none of the
+// type yet; trained holds the classifiers trained so far in the import. The
+// caller checks the quota for the whole import.
+func (s *Service) importRow(ctx context.Context, tx *sql.Tx, t *models.Transaction, categoryName string, categories map[string]int, trained map[string]*classifier.Model) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return err
+	}
+
+	err := func() error {
+		if t.CategoryID == 0 && categoryName == "" {
+			model, err := s.importModel(ctx, t.UserID, t.Type, trained)
+			if err != nil {
+				return err
+			}
+			categoryID, err := predictCategory(ctx, tx, model, t.UserID, t.Description, t.Amount, t.Type)
+			if err != nil && err != ErrCategoryNotFound {
+				return err
+			}
+			t.CategoryID, categoryName = categoryID, models.ImportSettings.DefaultCategory
+		}
+		if t.CategoryID == 0 {
+			categoryID, err := importCategory(ctx, tx, t.UserID, categoryName, t.Type, categories)
+			if err != nil {
+				return err
+			}
+			t.CategoryID = categoryID
+		}
+		_, err := insertTransaction(ctx, tx, t)
+		return err
+	}()
+	if err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`)
+	return err
+}
+
+// importModel returns the classifier for the transaction type, training it
+// only the first time an import asks for it.
+func (s *Service) importModel(ctx context.Context, userID int, transactionType string, trained map[string]*classifier.Model) (*classifier.Model, error) {
+	if model, ok := trained[transactionType]; ok {
+		return model, nil
+	}
+	examples, _, err := s.ClassifierExamples(ctx, userID, transactionType)
+	if err != nil {
+		return nil, err
+	}
+	model := classifier.Train(examples)
+	trained[transactionType] = model
+	return model, nil
+}
+
+func importCategory(ctx context.Context, tx *sql.Tx, userID int, name, categoryType string, cached map[string]int) (int, error) {
+	key := categoryType + "|" + strings.ToLower(name)
+	if id, ok := cached[key]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM categories WHERE user_id = $1 AND LOWER(name) = LOWER($2) AND type = $3 ORDER BY id LIMIT 1`,
+		userID, name, categoryType).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRowContext(ctx, `INSERT INTO categories (user_id, name, type, created_at, updated_at)
+			  VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id`, userID, name, categoryType).Scan(&id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	cached[key] = id
+	return id, nil
+}
+
+// parseImportRow reads one row with the profile's date format, decimal
+// separator and sign convention; the zero profile keeps the defaults.
+func parseImportRow(record []string, columns map[string]int, profile models.ImportProfile, loc *time.Location) (models.Transaction, string, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var t models.Transaction
+
+	date, err := parseImportDate(field("date"), profile.DateFormat, loc)
+	if err != nil {
+		return t, "", err
+	}
+	t.Date = date
+
+	var amount float64
+	if profile.SignConvention == models.ImportSignConventions.DebitCredit {
+		debit, debitErr := parseImportAmount(field("debit"), profile.DecimalSeparator)
+		credit, creditErr := parseImportAmount(field("credit"), profile.DecimalSeparator)
+		if debitErr != nil || creditErr != nil {
+			return t, "", fmt.Errorf("invalid amount %q / %q", field("debit"), field("credit"))
+		}
+		amount = math.Abs(credit) - math.Abs(debit)
+	} else if amount, err = parseImportAmount(field("amount"), profile.DecimalSeparator); err != nil {
+		return t, "", fmt.Errorf("invalid amount %q", field("amount"))
+	}
+	if profile.SignConvention == models.ImportSignConventions.Inverted {
+		amount = -amount
+	}
+	if amount == 0 {
+		return t, "", fmt.Errorf("invalid amount %q", field("amount"))
+	}
+
+	t.Type = strings.ToLower(field("type"))
+	if t.Type != "income" && t.Type != "expense" {
+		t.Type = "income"
+		if amount < 0 {
+			t.Type = "expense"
+		}
+	}
+	t.Amount = math.Abs(amount)
+
+	t.Description = strings.TrimSpace(field("description") + " " + field("memo"))
+	if t.Description == "" {
+		return t, "", errors.New("description is required")
+	}
+
+	return t, field("category"), nil
+}
+
+// parseImportAmount reads an amount written with the given decimal separator,
+// ignoring spaces, thousands separators and a trailing currency code. Without
+// a separator both a dot and a comma are read as decimal.
+func parseImportAmount(value, decimalSeparator string) (float64, error) {
+	value = strings.TrimRightFunc(strings.NewReplacer(" ", "", "\u00a0", "").Replace(value), unicode.IsLetter)
+	switch decimalSeparator {
+	case ",":
+		value = strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", ".")
+	case ".":
+		value = strings.ReplaceAll(value, ",", "")
+	default:
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseImportDate reads dates without a zone as midnight in the user's
+// timezone. With a profile date format only that format is accepted, and
+// anything after the date (a time of day) is ignored.
+func parseImportDate(value, format string, loc *time.Location) (time.Time, error) {
+	if layout, _ := importDateLayout(format); layout != "" {
+		if len(value) > len(layout) {
+			value = value[:len(layout)]
+		}
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, nil
+		}
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	for _, layout := range importDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func (s *Service) runExport(ctx context.Context, job models.Job) (interface{}, error) {
+	var payload exportJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.GetSettings(ctx, job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	layout := models.DateFormats[settings.DateFormat]
+	loc := SettingsLocation(settings)
+
+	// Start and end dates are whole days in the user's timezone.
+	query := `SELECT t.date, t.description, t.amount, t.type, COALESCE(c.name, ''), a.name
+			  FROM transactions t
+			  JOIN accounts a ON a.id = t.account_id
+			  LEFT JOIN categories c ON c.id = t.category_id
+			  WHERE t.user_id = $1
+			  AND ($2 = '' OR t.date >= $2::date::timestamp AT TIME ZONE $4)
+			  AND ($3 = '' OR t.date < ($3::date + 1)::timestamp AT TIME ZONE $4)
+			  AND ($5 = '' OR t.scope = $5)
+			  ORDER BY t.date, t.id`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, job.UserID, payload.StartDate, payload.EndDate, loc.String(), payload.Scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scrambler *exportScrambler
+	if payload.Scramble {
+		scrambler = newExportScrambler()
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Date", "Description", "Amount", "Type", "Category", "Account"})
+
+	count := 0
+	for rows.Next() {
+		var date time.Time
+		var description, transactionType, category, account string
+		var amount float64
+		if err := rows.Scan(&date, &description, &amount, &transactionType, &category, &account); err != nil {
+			return nil, err
+		}
+		if scrambler != nil {
+			description, amount, account = scrambler.scramble(description, amount, account)
+		}
+
+		writer.Write([]string{
+			date.In(loc).Format(layout),
+			description,
+			strconv.FormatFloat(SignedAmount(transactionType, amount), 'f', 2, 64),
+			transactionType,
+			category,
+			account,
+		})
+
+		count++
+		if count%models.ImportSettings.ProgressEvery == 0 {
+			if err := s.jobs.ReportProgress(ctx, job.ID, map[string]int{"rows_processed": count}); err != nil {
+				log.Printf("Error reporting export progress: %v", err)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("transactions-%s.csv", time.Now().Format("20060102-150405"))
+	if scrambler != nil {
+		name = fmt.Sprintf("transactions-scrambled-%s.csv", time.Now().Format("20060102-150405"))
+	}
+	fileID, err := s.jobs.SaveFile(ctx, job.UserID, name, "text/csv", buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"file_id": fileID, "file_name": name, "rows": count}, nil
+}
+
+// exportScrambler hides real finances in exports shared for bug reports.
+// Amounts are scaled by one random factor with a little noise per row, so
+// totals and proportions stay plausible, and payees and accounts are replaced
+// by stable placeholders. Dates, types and categories are kept.
+type exportScrambler struct {
+	rng      *rand.Rand
+	factor   float64
+	payees   map[string]string
+	accounts map[string]string
+}
+
+func newExportScrambler() *exportScrambler {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &exportScrambler{
+		rng:      rng,
+		factor:   0.5 + rng.Float64(),
+		payees:   map[string]string{},
+		accounts: map[string]string{},
+	}
+}
+
+func (e *exportScrambler) scramble(description string, amount float64, account string) (string, float64, string) {
+	payee, ok := e.payees[description]
+	if !ok {
+		payee = fmt.Sprintf("Payee %d", len(e.payees)+1)
+		e.payees[description] = payee
+	}
+	name, ok := e.accounts[account]
+	if !ok {
+		name = fmt.Sprintf("Account %d", len(e.accounts)+1)
+		e.accounts[account] = name
+	}
+
+	noise := 0.9 + 0.2*e.rng.Float64()
+	return payee, math.Max(0.01, math.Round(amount*e.factor*noise*100)/100), name
+}
+
+func (s *Service) JobFile(ctx context.Context, userID, jobID int) (models.JobFile, error) {
+	job, err := s.jobs.Get(ctx, userID, jobID)
+	if err != nil {
+		return models.JobFile{}, err
+	}
+
+	var result struct {
+		FileID int `json:"file_id"`
+	}
+	if job.Status != models.JobStatuses.Completed || json.Unmarshal(job.Result, &result) != nil || result.FileID == 0 {
+		return models.JobFile{}, jobs.ErrNotFound
+	}
+	return s.jobs.File(ctx, userID, result.FileID)
+}
+
+// recordBalance adds the account's balance as of tx to the outbox.
+func recordBalance(ctx context.Context, tx *sql.Tx, userID, accountID int) error {
+	var balance float64
+	err := tx.QueryRowContext(ctx, `SELECT balance FROM accounts WHERE id = $1 AND user_id = $2`, accountID, userID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+	return recordBalanceChanged(ctx, tx, userID, accountID, balance)
+}
+
+func (s *Service) jobFinished(job models.Job) {
+	if job.UserID == 0 || job.Type == models.JobTypes.WebhookDelivery || job.Type == models.JobTypes.AutomationWebhook {
+		return
+	}
+
+	// Job progress is not a domain event; it only goes to open streams.
+	if err := s.fanout.Publish(context.Background(), events.Event{Type: events.Types.JobFinished, UserID: job.UserID, Data: job}); err != nil {
+		log.Printf("Error streaming job %d: %v", job.ID, err)
+	}
+
+	l, _ := s.localizer(job.UserID)
+	title := l.T("Your %s is ready", l.T(job.Type))
+	message := l.T("Job #%d completed successfully.", job.ID)
+	if job.Status == models.JobStatuses.Dead {
+		title = l.T("Your %s failed", l.T(job.Type))
+		message = l.T("Job #%d failed after %d attempts.", job.ID, job.Attempts)
+	}
+
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  job.UserID,
+		Type:    notifications.Types.JobFinished,
+		Title:   title,
+		Message: message,
+		Data:    map[string]interface{}{"job_id": job.ID, "job_type": job.Type, "status": job.Status},
+	})
+}
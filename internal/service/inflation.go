@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// priceSeriesLabels name the series a priced expense t belongs to, with the
+// join each label needs.
+var priceSeriesLabels = map[string]reportDimension{
+	"tag":      {label: "LOWER(tg.tag)", join: "JOIN LATERAL unnest(t.tags) AS tg(tag) ON TRUE"},
+	"merchant": {label: merchantExpr, join: reportDimensions["payee"].join},
+	"category": {label: "c.name", join: reportDimensions["category"].join},
+}
+
+// PersonalInflation follows the price per unit of the expenses of
+// [start, end) recorded with a quantity, month by month in the user's
+// timezone. Series are split by unit as well as by the by label, so litres
+// and kWh never mix; expenses without a label are left out. Zero bounds
+// leave the range open.
+func (s *Service) PersonalInflation(ctx context.Context, userID int, start, end time.Time, by string) (models.PersonalInflation, error) {
+	inflation := models.PersonalInflation{By: by, Series: []models.PriceSeries{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return inflation, err
+	}
+	inflation.Currency = settings.BaseCurrency
+	if !start.IsZero() {
+		inflation.PeriodStart = start.Format("2006-01-02")
+	} else {
+		start = rollupMinDate
+	}
+	if !end.IsZero() {
+		inflation.PeriodEnd = end.AddDate(0, 0, -1).Format("2006-01-02")
+	} else {
+		end = rollupMaxDate
+	}
+
+	dimension := priceSeriesLabels[by]
+	query := `SELECT label, LOWER(unit), MIN(unit), month, SUM(quantity), SUM(amount), COUNT(*)
+			  FROM (
+				  SELECT ` + dimension.label + ` AS label, t.unit, to_char(t.date AT TIME ZONE $4, 'YYYY-MM') AS month,
+				  t.quantity, t.amount
+				  FROM transactions t
+				  ` + dimension.join + `
+				  WHERE t.user_id = $1 AND t.type = 'expense' AND t.quantity IS NOT NULL AND t.date >= $2 AND t.date < $3
+			  ) p
+			  WHERE COALESCE(label, '') <> ''
+			  GROUP BY label, LOWER(unit), month
+			  ORDER BY label, LOWER(unit), month`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, start, end, SettingsLocation(settings).String())
+	if err != nil {
+		return inflation, err
+	}
+	defer rows.Close()
+
+	var series *models.PriceSeries
+	var seriesKey string
+	for rows.Next() {
+		var label, unitKey, unit string
+		var point models.UnitPricePoint
+		if err := rows.Scan(&label, &unitKey, &unit, &point.Month, &point.Quantity, &point.Amount, &point.Count); err != nil {
+			return inflation, err
+		}
+		point.UnitPrice = math.Round(point.Amount/point.Quantity*10000) / 10000
+
+		if key := label + "\x00" + unitKey; series == nil || key != seriesKey {
+			inflation.Series = append(inflation.Series, models.PriceSeries{Label: label, Unit: unit})
+			series = &inflation.Series[len(inflation.Series)-1]
+			seriesKey = key
+		}
+		series.Quantity += point.Quantity
+		series.Amount += point.Amount
+		series.Months = append(series.Months, point)
+	}
+	if err := rows.Err(); err != nil {
+		return inflation, err
+	}
+
+	// The overall rate weighs each series' change by what was spent on it,
+	// so a pricier litre of fuel counts for more than a pricier lightbulb.
+	var weighted, weight float64
+	for i := range inflation.Series {
+		series := &inflation.Series[i]
+		first, last := series.Months[0], series.Months[len(series.Months)-1]
+		series.FirstUnitPrice = first.UnitPrice
+		series.LastUnitPrice = last.UnitPrice
+		series.Quantity = math.Round(series.Quantity*10000) / 10000
+		series.Amount = math.Round(series.Amount*100) / 100
+		if len(series.Months) < 2 || first.UnitPrice <= 0 {
+			continue
+		}
+
+		change := percentChange(first.UnitPrice, last.UnitPrice)
+		rounded := math.Round(change*10) / 10
+		series.ChangePercent = &rounded
+		if months := monthsBetween(first.Month, last.Month); months > 0 {
+			annualized := math.Round((math.Pow(last.UnitPrice/first.UnitPrice, 12/float64(months))-1)*1000) / 10
+			series.AnnualizedPercent = &annualized
+		}
+		weighted += change * series.Amount
+		weight += series.Amount
+	}
+	if weight > 0 {
+		rate := math.Round(weighted/weight*10) / 10
+		inflation.InflationPercent = &rate
+	}
+	return inflation, nil
+}
+
+// monthsBetween counts the months from one YYYY-MM month to another.
+func monthsBetween(from, to string) int {
+	a, errA := time.Parse("2006-01", from)
+	b, errB := time.Parse("2006-01", to)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
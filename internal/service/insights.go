@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/insights"
+	"personal-finance-tracker/internal/models"
+)
+
+func (s *Service) registerInsightAnalyzers() {
+	s.insights.Register(models.InsightTypes.SpendingSpike, s.spendingSpikeInsights)
+	s.insights.Register(models.InsightTypes.DuplicateCharge, s.duplicateChargeInsights)
+	s.insights.Register(models.InsightTypes.BankFee, s.bankFeeInsights)
+	s.insights.Register(models.InsightTypes.UnusualBill, s.unusualBillInsights)
+	s.insights.Register(models.InsightTypes.SavingsOpportunity, s.savingsOpportunityInsights)
+}
+
+func (s *Service) Insights() *insights.Engine {
+	return s.insights
+}
+
+// RunInsights runs the insight analyzers for every user on a schedule.
+func (s *Service) RunInsights(ctx context.Context) {
+	ticker := time.NewTicker(models.InsightSettings.RunInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.analyzeInsights(ctx, time.Now()); err != nil {
+			log.Printf("Error running insights: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) analyzeInsights(ctx context.Context, now time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users ORDER BY id`)
+	if err != nil {
+		return err
+	}
+
+	var users []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Analyze logs each failing analyzer itself.
+	for _, userID := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.insights.Analyze(ctx, userID, now)
+	}
+	return nil
+}
+
+// spendingSpikeInsights reports expense categories whose spending so far
+// this financial month is well above their monthly average before it.
+func (s *Service) spendingSpikeInsights(ctx context.Context, userID int, now time.Time) ([]insights.Finding, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	n := models.InsightSettings.SpikeMonths
+	start := FiscalMonthStart(now.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+
+	current, err := s.CategoryTotals(ctx, userID, start, now, "")
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.CategoryTotals(ctx, userID, start.AddDate(0, -n, 0), start, "")
+	if err != nil {
+		return nil, err
+	}
+	average := make(map[int]float64)
+	for _, total := range previous {
+		if total.Type == "expense" {
+			average[total.CategoryID] += total.Amount / float64(n)
+		}
+	}
+	categories, err := s.GetCategories(ctx, userID, "expense")
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	l, currency := s.localizer(userID)
+	var findings []insights.Finding
+	for _, total := range current {
+		before := average[total.CategoryID]
+		if total.Type != "expense" || before <= 0 || total.Amount < before*models.InsightSettings.SpikeRatio ||
+			total.Amount-before < models.InsightSettings.SpikeMinAmount {
+			continue
+		}
+		name := names[total.CategoryID]
+		findings = append(findings, insights.Finding{
+			Key:      fmt.Sprintf("%d:%s", total.CategoryID, start.Format("2006-01")),
+			Severity: models.InsightSeverities.Warning,
+			Title:    l.T("Spending spike: %s", name),
+			Message: l.T("You have spent %s on %s this month, against %s in an average month.",
+				l.Amount(total.Amount, currency), name, l.Amount(before, currency)),
+			Data: map[string]interface{}{
+				"category_id": total.CategoryID,
+				"amount":      total.Amount,
+				"average":     math.Round(before*100) / 100,
+			},
+		})
+	}
+	return findings, nil
+}
+
+// duplicateChargeInsights reports recent expenses repeating the amount and
+// description of another one on the same account a day or two apart.
+func (s *Service) duplicateChargeInsights(ctx context.Context, userID int, now time.Time) ([]insights.Finding, error) {
+	query := `SELECT a.id, b.id, b.amount, b.description
+			  FROM transactions a
+			  JOIN transactions b ON b.user_id = a.user_id AND b.account_id = a.account_id AND b.id > a.id
+				AND b.type = 'expense' AND b.amount = a.amount
+				AND LOWER(TRIM(b.description)) = LOWER(TRIM(a.description))
+				AND b.date BETWEEN a.date - make_interval(days => $3) AND a.date + make_interval(days => $3)
+			  WHERE a.user_id = $1 AND a.type = 'expense' AND a.date >= $2 AND TRIM(COALESCE(a.description, '')) <> ''
+			  ORDER BY b.date DESC, b.id DESC`
+
+	since := now.AddDate(0, 0, -models.InsightSettings.LookbackDays)
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, since, models.InsightSettings.DuplicateDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l, currency := s.localizer(userID)
+	var findings []insights.Finding
+	for rows.Next() {
+		var first, second int
+		var amount float64
+		var description string
+		if err := rows.Scan(&first, &second, &amount, &description); err != nil {
+			return nil, err
+		}
+		findings = append(findings, insights.Finding{
+			Key:      fmt.Sprintf("%d:%d", first, second),
+			Severity: models.InsightSeverities.Warning,
+			Title:    l.T("Possible duplicate charge: %s", description),
+			Message:  l.T("%s was charged %s twice within %d days. Check whether both charges are right.", description, l.Amount(amount, currency), models.InsightSettings.DuplicateDays),
+			Data: map[string]interface{}{
+				"transaction_ids": []int{first, second},
+				"amount":          amount,
+			},
+		})
+	}
+	return findings, rows.Err()
+}
+
+// bankFeeInsights reports what bank fees, overdraft charges and interest
+// cost over the last year. A new charge brings back a dismissed insight.
+func (s *Service) bankFeeInsights(ctx context.Context, userID int, now time.Time) ([]insights.Finding, error) {
+	charges, err := s.bankCharges(ctx, userID, now.AddDate(-1, 0, 0), now)
+	if err != nil || len(charges) == 0 {
+		return nil, err
+	}
+
+	var total float64
+	latest := 0
+	kinds := make(map[string]float64)
+	for _, charge := range charges {
+		total += charge.Amount
+		kinds[charge.Kind] += charge.Amount
+		if charge.TransactionID > latest {
+			latest = charge.TransactionID
+		}
+	}
+
+	severity := models.InsightSeverities.Info
+	if kinds[models.BankChargeKinds.Overdraft] > 0 {
+		severity = models.InsightSeverities.Warning
+	}
+
+	l, currency := s.localizer(userID)
+	return []insights.Finding{{
+		Key:      strconv.Itoa(latest),
+		Severity: severity,
+		Title:    l.T("Bank fees"),
+		Message: l.T("Bank charges cost you %s over the last 12 months: %s in fees, %s in overdraft charges and %s in interest.",
+			l.Amount(total, currency), l.Amount(kinds[models.BankChargeKinds.Fee], currency),
+			l.Amount(kinds[models.BankChargeKinds.Overdraft], currency), l.Amount(kinds[models.BankChargeKinds.Interest], currency)),
+		Data: map[string]interface{}{
+			"annual_cost": math.Round(total*100) / 100,
+			"fees":        math.Round(kinds[models.BankChargeKinds.Fee]*100) / 100,
+			"overdraft":   math.Round(kinds[models.BankChargeKinds.Overdraft]*100) / 100,
+			"interest":    math.Round(kinds[models.BankChargeKinds.Interest]*100) / 100,
+			"count":       len(charges),
+		},
+	}}, nil
+}
+
+// unusualBillInsights reports recent charges of a recurring bill well above
+// its median charge. Unlike price increases, bills whose amount varies from
+// one period to the next count too.
+func (s *Service) unusualBillInsights(ctx context.Context, userID int, now time.Time) ([]insights.Finding, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	from := now.AddDate(0, -models.SubscriptionSettings.LookbackMonths, 0)
+	merchants, err := merchantCharges(ctx, s.ReadDB(), userID, from, now, "", SettingsLocation(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	since := calendarDay(now.AddDate(0, 0, -models.InsightSettings.LookbackDays), SettingsLocation(settings))
+	l, currency := s.localizer(userID)
+	var findings []insights.Finding
+	for _, m := range merchants {
+		_, streak, ok := detectCadence(m.charges)
+		if !ok || len(streak) <= models.SubscriptionSettings.MinCharges {
+			continue
+		}
+		last := streak[len(streak)-1]
+		if last.date.Before(since) {
+			continue
+		}
+
+		amounts := make([]float64, 0, len(streak)-1)
+		for _, charge := range streak[:len(streak)-1] {
+			amounts = append(amounts, charge.amount)
+		}
+		sort.Float64s(amounts)
+		median := amounts[len(amounts)/2]
+		if len(amounts)%2 == 0 {
+			median = (amounts[len(amounts)/2-1] + median) / 2
+		}
+		if median <= 0 || last.amount < median*models.InsightSettings.UnusualBillRatio {
+			continue
+		}
+
+		findings = append(findings, insights.Finding{
+			Key:      strconv.Itoa(last.id),
+			Severity: models.InsightSeverities.Warning,
+			Title:    l.T("Unusually high bill: %s", m.merchant),
+			Message: l.T("Your latest bill from %s is %s, while it usually comes to %s.", m.merchant,
+				l.Amount(last.amount, currency), l.Amount(median, currency)),
+			Data: map[string]interface{}{
+				"transaction_id": last.id,
+				"amount":         last.amount,
+				"median":         median,
+			},
+		})
+	}
+	return findings, nil
+}
+
+// savingsOpportunityInsights suggests cancelling each subscription that looks
+// forgotten.
+func (s *Service) savingsOpportunityInsights(ctx context.Context, userID int, now time.Time) ([]insights.Finding, error) {
+	forgotten, err := s.ForgottenSubscriptions(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	l, currency := s.localizer(userID)
+	var findings []insights.Finding
+	for _, subscription := range forgotten.Subscriptions {
+		findings = append(findings, insights.Finding{
+			Key:      strings.ToLower(subscription.Merchant),
+			Severity: models.InsightSeverities.Info,
+			Title:    l.T("Savings opportunity: %s", subscription.Merchant),
+			Message: l.T("You have paid %s for %s every month for %d months. Cancelling it would save %s a year.",
+				l.Amount(subscription.Amount, currency), subscription.Merchant, subscription.MonthsRunning,
+				l.Amount(subscription.AnnualCost, currency)),
+			Data: map[string]interface{}{
+				"merchant":    subscription.Merchant,
+				"annual_cost": subscription.AnnualCost,
+			},
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+// iouColumns reads an IOU from the point of view of the user bound to $1.
+const iouColumns = `i.id, i.creditor_id, i.debtor_id, i.created_by,
+			  CASE WHEN i.creditor_id = $1 THEN 'lent' ELSE 'borrowed' END, u.id, u.email,
+			  i.amount, i.description, to_char(i.date, 'YYYY-MM-DD'), i.status, i.dispute_reason,
+			  CASE WHEN i.creditor_id = $1 THEN i.creditor_transaction_id ELSE i.debtor_transaction_id END,
+			  i.settled_at, i.created_at, i.updated_at
+			  FROM ious i JOIN users u ON u.id = CASE WHEN i.creditor_id = $1 THEN i.debtor_id ELSE i.creditor_id END`
+
+func scanIOU(row interface{ Scan(...interface{}) error }, iou *models.IOU) error {
+	return row.Scan(&iou.ID, &iou.CreditorID, &iou.DebtorID, &iou.CreatedBy, &iou.Direction, &iou.CounterpartyID,
+		&iou.CounterpartyEmail, &iou.Amount, &iou.Description, &iou.Date, &iou.Status, &iou.DisputeReason,
+		&iou.TransactionID, &iou.SettledAt, &iou.CreatedAt, &iou.UpdatedAt)
+}
+
+func (s *Service) UserLinks(ctx context.Context, userID int) ([]models.UserLink, error) {
+	query := `SELECT l.id, u.id, u.email, u.first_name, u.last_name, l.status, l.addressee_id = $1, l.created_at, l.accepted_at
+			  FROM user_links l
+			  JOIN users u ON u.id = CASE WHEN l.requester_id = $1 THEN l.addressee_id ELSE l.requester_id END
+			  WHERE $1 IN (l.requester_id, l.addressee_id) ORDER BY u.email`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.UserLink{}
+	for rows.Next() {
+		var link models.UserLink
+		if err := rows.Scan(&link.ID, &link.UserID, &link.Email, &link.FirstName, &link.LastName, &link.Status,
+			&link.Incoming, &link.CreatedAt, &link.AcceptedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// RequestUserLink invites the user registered under email. The link stays
+// pending until they accept it.
+func (s *Service) RequestUserLink(ctx context.Context, userID int, email string) (models.UserLink, error) {
+	link := models.UserLink{Status: models.UserLinkStatuses.Pending}
+	err := s.db.QueryRowContext(ctx, `SELECT id, email, first_name, last_name FROM users WHERE LOWER(email) = LOWER($1)`, email).
+		Scan(&link.UserID, &link.Email, &link.FirstName, &link.LastName)
+	if err == sql.ErrNoRows {
+		return link, ErrUserNotFound
+	}
+	if err != nil {
+		return link, err
+	}
+	if link.UserID == userID {
+		return link, ErrInvalidLink
+	}
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO user_links (requester_id, addressee_id, status, created_at)
+			  VALUES ($1, $2, $3, NOW()) RETURNING id, created_at`, userID, link.UserID, link.Status).
+		Scan(&link.ID, &link.CreatedAt)
+	if isUniqueViolation(err) {
+		return link, ErrLinkExists
+	}
+	return link, err
+}
+
+func (s *Service) AcceptUserLink(ctx context.Context, userID, linkID int) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE user_links SET status = $1, accepted_at = NOW()
+			  WHERE id = $2 AND addressee_id = $3 AND status = $4`,
+		models.UserLinkStatuses.Accepted, linkID, userID, models.UserLinkStatuses.Pending)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// DeleteUserLink removes a link from either side. IOUs already recorded
+// stay on both ledgers.
+func (s *Service) DeleteUserLink(ctx context.Context, userID, linkID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM user_links WHERE id = $1 AND $2 IN (requester_id, addressee_id)`, linkID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+func (s *Service) ensureLinked(ctx context.Context, userID, otherID int) error {
+	var linked bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM user_links WHERE status = $3
+			  AND ((requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)))`,
+		userID, otherID, models.UserLinkStatuses.Accepted).Scan(&linked)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// IOUs lists the IOUs the user is a party to, newest first, optionally only
+// those in one status.
+func (s *Service) IOUs(ctx context.Context, userID int, status string) ([]models.IOU, error) {
+	query := `SELECT ` + iouColumns + `
+			  WHERE $1 IN (i.creditor_id, i.debtor_id) AND ($2 = '' OR i.status = $2)
+			  ORDER BY i.date DESC, i.id DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ious := []models.IOU{}
+	for rows.Next() {
+		var iou models.IOU
+		if err := scanIOU(rows, &iou); err != nil {
+			return nil, err
+		}
+		ious = append(ious, iou)
+	}
+	return ious, rows.Err()
+}
+
+func (s *Service) IOU(ctx context.Context, userID, id int) (models.IOU, error) {
+	var iou models.IOU
+	err := scanIOU(s.db.QueryRowContext(ctx, `SELECT `+iouColumns+`
+			  WHERE i.id = $2 AND $1 IN (i.creditor_id, i.debtor_id)`, userID, id), &iou)
+	if err == sql.ErrNoRows {
+		return iou, ErrIOUNotFound
+	}
+	return iou, err
+}
+
+// CreateIOU records money lent to or borrowed from a linked user. It waits
+// for the other user to confirm it; the date defaults to today in the
+// recording user's timezone.
+func (s *Service) CreateIOU(ctx context.Context, userID int, req models.IOURequest) (models.IOU, error) {
+	if err := s.ensureLinked(ctx, userID, req.UserID); err != nil {
+		return models.IOU{}, err
+	}
+
+	creditorID, debtorID := userID, req.UserID
+	if req.Direction == models.IOUDirections.Borrowed {
+		creditorID, debtorID = req.UserID, userID
+	}
+	date := req.Date
+	if date == "" {
+		date = time.Now().In(s.Location(ctx, userID)).Format("2006-01-02")
+	}
+
+	var id int
+	err := s.db.QueryRowContext(ctx, `INSERT INTO ious (creditor_id, debtor_id, created_by, amount, description, date, status, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id`,
+		creditorID, debtorID, userID, req.Amount, req.Description, date, models.IOUStatuses.Pending).Scan(&id)
+	if err != nil {
+		return models.IOU{}, err
+	}
+
+	iou, err := s.IOU(ctx, userID, id)
+	if err == nil {
+		s.notifyIOU(iou)
+	}
+	return iou, err
+}
+
+// ConfirmIOU accepts an IOU recorded by the other party, including one that
+// was disputed before.
+func (s *Service) ConfirmIOU(ctx context.Context, userID, id int) (models.IOU, error) {
+	query := `UPDATE ious SET status = $3, dispute_reason = '', updated_at = NOW()
+			  WHERE id = $1 AND $2 IN (creditor_id, debtor_id) AND created_by <> $2 AND status IN ($4, $5)`
+
+	return s.moveIOU(ctx, userID, id, query, models.IOUStatuses.Confirmed, models.IOUStatuses.Pending, models.IOUStatuses.Disputed)
+}
+
+func (s *Service) DisputeIOU(ctx context.Context, userID, id int, reason string) (models.IOU, error) {
+	query := `UPDATE ious SET status = $3, dispute_reason = $4, updated_at = NOW()
+			  WHERE id = $1 AND $2 IN (creditor_id, debtor_id) AND created_by <> $2 AND status = $5`
+
+	return s.moveIOU(ctx, userID, id, query, models.IOUStatuses.Disputed, reason, models.IOUStatuses.Pending)
+}
+
+// moveIOU runs a status update made by the party who did not record the
+// IOU, tells the reason apart when nothing changed and notifies the other
+// party when something did.
+func (s *Service) moveIOU(ctx context.Context, userID, id int, query string, args ...interface{}) (models.IOU, error) {
+	result, err := s.db.ExecContext(ctx, query, append([]interface{}{id, userID}, args...)...)
+	if err != nil {
+		return models.IOU{}, err
+	}
+
+	iou, err := s.IOU(ctx, userID, id)
+	if err != nil {
+		return iou, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if iou.CreatedBy == userID {
+			return iou, ErrIOUCounterparty
+		}
+		return iou, ErrIOUStatus
+	}
+
+	s.notifyIOU(iou)
+	return iou, nil
+}
+
+// SettleIOU pays off a confirmed IOU. The debtor gets an expense and the
+// creditor an income in the same database transaction, so both ledgers
+// change together or not at all. The settling user may pick their account;
+// the other party's side goes to their default account.
+func (s *Service) SettleIOU(ctx context.Context, userID, id int, accountID *int) (models.IOU, error) {
+	iou, err := s.IOU(ctx, userID, id)
+	if err != nil {
+		return iou, err
+	}
+	if iou.Status != models.IOUStatuses.Confirmed {
+		return iou, ErrIOUStatus
+	}
+
+	debit := models.Transaction{UserID: iou.DebtorID, Amount: iou.Amount, Type: "expense", Description: iou.Description, Date: time.Now()}
+	credit := models.Transaction{UserID: iou.CreditorID, Amount: iou.Amount, Type: "income", Description: iou.Description, Date: time.Now()}
+	for _, t := range []*models.Transaction{&debit, &credit} {
+		if t.UserID == userID && accountID != nil {
+			t.AccountID = *accountID
+		} else if t.AccountID, err = s.DefaultAccountID(ctx, t.UserID); err != nil {
+			if err == ErrAccountNotFound && t.UserID != userID {
+				err = ErrCounterpartyAccount
+			}
+			return iou, err
+		}
+		if t.CategoryID, err = s.SuggestCategory(ctx, t.UserID, t.Description, t.Amount, t.Type); err != nil {
+			return iou, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return iou, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM ious WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		return iou, err
+	}
+	if status != models.IOUStatuses.Confirmed {
+		return iou, ErrIOUStatus
+	}
+
+	debitBalance, err := InsertTransaction(ctx, tx, &debit)
+	if err != nil {
+		return iou, err
+	}
+	creditBalance, err := InsertTransaction(ctx, tx, &credit)
+	if err != nil {
+		return iou, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE ious SET status = $1, creditor_transaction_id = $2, debtor_transaction_id = $3,
+			  settled_at = NOW(), updated_at = NOW() WHERE id = $4`,
+		models.IOUStatuses.Settled, credit.ID, debit.ID, id)
+	if err != nil {
+		return iou, err
+	}
+	if err := RecordTransactionCreated(ctx, tx, debit, debitBalance); err != nil {
+		return iou, err
+	}
+	if err := RecordTransactionCreated(ctx, tx, credit, creditBalance); err != nil {
+		return iou, err
+	}
+	if err := tx.Commit(); err != nil {
+		return iou, err
+	}
+
+	s.TransactionCreated(debit)
+	s.TransactionCreated(credit)
+
+	iou, err = s.IOU(ctx, userID, id)
+	if err == nil {
+		s.notifyIOU(iou)
+	}
+	return iou, err
+}
+
+// iouTitles name the notification sent for each status an IOU moves to.
+var iouTitles = map[string]string{
+	models.IOUStatuses.Pending:   "IOU recorded",
+	models.IOUStatuses.Confirmed: "IOU confirmed",
+	models.IOUStatuses.Disputed:  "IOU disputed",
+	models.IOUStatuses.Settled:   "IOU settled",
+}
+
+// notifyIOU tells the counterparty of the user who just recorded,
+// confirmed, disputed or settled an IOU.
+func (s *Service) notifyIOU(iou models.IOU) {
+	l, currency := s.localizer(iou.CounterpartyID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  iou.CounterpartyID,
+		Type:    notifications.Types.IOUActivity,
+		Title:   l.T(iouTitles[iou.Status]),
+		Message: l.T("An IOU of %s was %s: %s", l.Amount(iou.Amount, currency), l.T(iou.Status), iou.Description),
+		Data: map[string]interface{}{
+			"iou_id": iou.ID,
+			"status": iou.Status,
+		},
+	})
+}
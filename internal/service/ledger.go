@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// ledgerBalance is the balance of account a derived from its ledger: the
+// opening balance plus every transaction on it, whatever its date.
+const ledgerBalance = `a.opening_balance + COALESCE((
+			  SELECT SUM(CASE WHEN t.type = 'expense' THEN -t.amount ELSE t.amount END)
+			  FROM transactions t WHERE t.account_id = a.id), 0)`
+
+// RecomputeBalance rebuilds the stored balance of an account from its ledger
+// within tx and returns it. The account row stays locked until tx ends, so
+// no transaction can be added to it meanwhile.
+func RecomputeBalance(ctx context.Context, tx *sql.Tx, userID, accountID int) (float64, error) {
+	var balance float64
+	err := tx.QueryRowContext(ctx, `UPDATE accounts a SET balance = `+ledgerBalance+`, updated_at = NOW()
+			  WHERE a.id = $1 AND a.user_id = $2 RETURNING a.balance`, accountID, userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, ErrAccountNotFound
+	}
+	return balance, err
+}
+
+// BalanceDrift lists the accounts whose stored balance differs from their
+// ledger, for one user or for all users when userID is 0. With fix set, each
+// balance is rebuilt from the ledger and a BalanceChanged event recorded.
+func (s *Service) BalanceDrift(ctx context.Context, userID int, fix bool) ([]models.BalanceDrift, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The accounts stay locked, so the rebuild below sees every transaction
+	// committed by then and none can slip in before it is done.
+	query := `SELECT a.id, a.user_id, a.name, a.balance, ` + ledgerBalance + `
+			  FROM accounts a
+			  WHERE ($1 = 0 OR a.user_id = $1) AND a.balance <> ` + ledgerBalance + `
+			  ORDER BY a.id
+			  FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	drift := []models.BalanceDrift{}
+	for rows.Next() {
+		var d models.BalanceDrift
+		if err := rows.Scan(&d.AccountID, &d.UserID, &d.Name, &d.Stored, &d.Ledger); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.Difference = d.Stored - d.Ledger
+		drift = append(drift, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !fix || len(drift) == 0 {
+		return drift, nil
+	}
+
+	users := map[int]bool{}
+	for i := range drift {
+		balance, err := RecomputeBalance(ctx, tx, drift[i].UserID, drift[i].AccountID)
+		if err != nil {
+			return nil, err
+		}
+		drift[i].Ledger = balance
+		drift[i].Difference = drift[i].Stored - balance
+		drift[i].Fixed = true
+		if err := recordBalanceChanged(ctx, tx, drift[i].UserID, drift[i].AccountID, balance); err != nil {
+			return nil, err
+		}
+		users[drift[i].UserID] = true
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for id := range users {
+		s.InvalidateUserCache(id)
+	}
+	s.outbox.Wake()
+	return drift, nil
+}
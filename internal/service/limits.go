@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrPlanNotFound = errors.New("plan not found")
+	ErrPlanID       = errors.New("plan ID may only contain lowercase letters, digits, - and _")
+	ErrQuotaReached = errors.New("plan limit reached")
+	ErrPlanPrice    = errors.New("another plan already uses this Stripe price")
+)
+
+var planIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// QuotaError is returned when a request would take a user over a limit of
+// their plan.
+type QuotaError struct {
+	Resource string
+	Limit    int64
+	Used     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s (%d of %d used)", ErrQuotaReached, e.Resource, e.Used, e.Limit)
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaReached
+}
+
+const planLimitColumns = `max_accounts, max_transactions_per_month, max_storage_bytes, max_webhooks`
+
+const planColumns = `id, name, ` + planLimitColumns + `, COALESCE(stripe_price_id, ''), created_at, updated_at`
+
+func scanPlan(row interface{ Scan(...interface{}) error }, p *models.Plan) error {
+	return row.Scan(&p.ID, &p.Name, &p.Limits.Accounts, &p.Limits.TransactionsPerMonth, &p.Limits.StorageBytes,
+		&p.Limits.Webhooks, &p.StripePriceID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (s *Service) Plans(ctx context.Context) ([]models.Plan, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+planColumns+` FROM plans ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []models.Plan{}
+	for rows.Next() {
+		var p models.Plan
+		if err := scanPlan(rows, &p); err != nil {
+			return nil, err
+		}
+		plans = append(plans, p)
+	}
+	return plans, rows.Err()
+}
+
+// SavePlan creates the plan with the given ID or replaces its name, limits
+// and Stripe price.
+func (s *Service) SavePlan(ctx context.Context, id string, req models.PlanRequest) (models.Plan, error) {
+	var p models.Plan
+	if !planIDPattern.MatchString(id) {
+		return p, ErrPlanID
+	}
+
+	query := `INSERT INTO plans (id, name, ` + planLimitColumns + `, stripe_price_id, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NOW(), NOW())
+			  ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, max_accounts = EXCLUDED.max_accounts,
+			  max_transactions_per_month = EXCLUDED.max_transactions_per_month, max_storage_bytes = EXCLUDED.max_storage_bytes,
+			  max_webhooks = EXCLUDED.max_webhooks, stripe_price_id = EXCLUDED.stripe_price_id, updated_at = NOW()
+			  RETURNING ` + planColumns
+
+	err := scanPlan(s.db.QueryRowContext(ctx, query, id, req.Name, req.Limits.Accounts, req.Limits.TransactionsPerMonth,
+		req.Limits.StorageBytes, req.Limits.Webhooks, req.StripePriceID), &p)
+	if isUniqueViolation(err) {
+		return p, ErrPlanPrice
+	}
+	return p, err
+}
+
+// DeletePlan deletes a plan; its users fall back to the default plan.
+func (s *Service) DeletePlan(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM plans WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPlanNotFound
+	}
+	return nil
+}
+
+// SetUserPlan assigns a plan to a user and replaces their overrides of its
+// limits.
+func (s *Service) SetUserPlan(ctx context.Context, userID int, req models.UserPlanRequest) (models.LimitsStatus, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.LimitsStatus{}, err
+	}
+	defer tx.Rollback()
+
+	if req.PlanID != "" {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM plans WHERE id = $1)`, req.PlanID).Scan(&exists); err != nil {
+			return models.LimitsStatus{}, err
+		}
+		if !exists {
+			return models.LimitsStatus{}, ErrPlanNotFound
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET plan_id = NULLIF($2, ''), updated_at = NOW() WHERE id = $1`, userID, req.PlanID)
+	if err != nil {
+		return models.LimitsStatus{}, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.LimitsStatus{}, ErrUserNotFound
+	}
+
+	limits := req.Limits
+	if limits.Accounts == nil && limits.TransactionsPerMonth == nil && limits.StorageBytes == nil && limits.Webhooks == nil {
+		_, err = tx.ExecContext(ctx, `DELETE FROM user_limits WHERE user_id = $1`, userID)
+	} else {
+		_, err = tx.ExecContext(ctx, `INSERT INTO user_limits (user_id, `+planLimitColumns+`, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, NOW())
+				  ON CONFLICT (user_id) DO UPDATE SET max_accounts = EXCLUDED.max_accounts,
+				  max_transactions_per_month = EXCLUDED.max_transactions_per_month, max_storage_bytes = EXCLUDED.max_storage_bytes,
+				  max_webhooks = EXCLUDED.max_webhooks, updated_at = NOW()`,
+			userID, limits.Accounts, limits.TransactionsPerMonth, limits.StorageBytes, limits.Webhooks)
+	}
+	if err != nil {
+		return models.LimitsStatus{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.LimitsStatus{}, err
+	}
+	return s.LimitsStatus(ctx, userID)
+}
+
+type contextQueryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// UserLimits returns the plan in effect for the user, their own or the
+// default one, and its limits after the user's overrides.
+func (s *Service) UserLimits(ctx context.Context, userID int) (string, models.PlanLimits, error) {
+	return userLimits(ctx, s.db, userID)
+}
+
+// userLimits reads the limits with q. The ledger user of a workspace cannot
+// subscribe, so its books are held to the plan of the workspace owner.
+func userLimits(ctx context.Context, q contextQueryRower, userID int) (string, models.PlanLimits, error) {
+	var plan sql.NullString
+	var limits models.PlanLimits
+	err := q.QueryRowContext(ctx, `SELECT COALESCE(u.plan_id, NULLIF($2, '')),
+			  COALESCE(ul.max_accounts, p.max_accounts), COALESCE(ul.max_transactions_per_month, p.max_transactions_per_month),
+			  COALESCE(ul.max_storage_bytes, p.max_storage_bytes), COALESCE(ul.max_webhooks, p.max_webhooks)
+			  FROM users u
+			  LEFT JOIN plans p ON p.id = COALESCE(u.plan_id, NULLIF($2, ''))
+			  LEFT JOIN user_limits ul ON ul.user_id = u.id
+			  WHERE u.id = COALESCE((SELECT m.user_id FROM workspaces w
+			  JOIN workspace_members m ON m.workspace_id = w.id AND m.role = $3
+			  WHERE w.ledger_user_id = $1 LIMIT 1), $1)`,
+		userID, config.Get().Limits.DefaultPlan, models.WorkspaceRoles.Owner).
+		Scan(&plan, &limits.Accounts, &limits.TransactionsPerMonth, &limits.StorageBytes, &limits.Webhooks)
+	if err == sql.ErrNoRows {
+		return "", limits, ErrUserNotFound
+	}
+	return plan.String, limits, err
+}
+
+// QuotaUsage measures how much of a limited resource the user has used.
+func (s *Service) QuotaUsage(ctx context.Context, userID int, resource string) (int64, error) {
+	var used int64
+	var err error
+	switch resource {
+	case models.QuotaResources.Accounts:
+		err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM accounts WHERE user_id = $1`, userID).Scan(&used)
+	case models.QuotaResources.TransactionsPerMonth:
+		err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions
+				  WHERE user_id = $1 AND created_at >= date_trunc('month', NOW())`, userID).Scan(&used)
+	case models.QuotaResources.StorageBytes:
+		used, err = s.storedBytes(ctx, avatarPrefix(userID))
+	case models.QuotaResources.Webhooks:
+		err = s.db.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM notification_channels WHERE user_id = $1)
+				  + (SELECT COUNT(*) FROM report_schedules WHERE user_id = $1 AND delivery = 'webhook')
+				  + (SELECT COUNT(*) FROM automation_rules WHERE user_id = $1 AND webhook_url <> '')`, userID).Scan(&used)
+	default:
+		err = fmt.Errorf("unknown quota resource %q", resource)
+	}
+	return used, err
+}
+
+func (s *Service) storedBytes(ctx context.Context, prefix string) (int64, error) {
+	objects, err := s.files.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, object := range objects {
+		total += object.Size
+	}
+	return total, nil
+}
+
+// CheckQuota returns a QuotaError when adding more of resource would take
+// the user over their plan's limit. adding may be negative, e.g. for files
+// that are replaced by smaller ones.
+func (s *Service) CheckQuota(ctx context.Context, userID int, resource string, adding int64) error {
+	_, limits, err := s.UserLimits(ctx, userID)
+	if err != nil {
+		return err
+	}
+	limit := quotaLimit(limits, resource)
+	if limit == nil {
+		return nil
+	}
+
+	used, err := s.QuotaUsage(ctx, userID, resource)
+	if err != nil {
+		return err
+	}
+	if adding > 0 && used+adding > *limit {
+		return &QuotaError{Resource: resource, Limit: *limit, Used: used}
+	}
+	return nil
+}
+
+// checkTransactionQuota returns a QuotaError when adding transactions would
+// take the user over their monthly limit. It reads with q, so that writes
+// can check within their own database transaction.
+func checkTransactionQuota(ctx context.Context, q contextQueryRower, userID int, adding int64) error {
+	_, limits, err := userLimits(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if limits.TransactionsPerMonth == nil {
+		return nil
+	}
+
+	var used int64
+	err = q.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions
+			  WHERE user_id = $1 AND created_at >= date_trunc('month', NOW())`, userID).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if adding > 0 && used+adding > *limits.TransactionsPerMonth {
+		return &QuotaError{Resource: models.QuotaResources.TransactionsPerMonth, Limit: *limits.TransactionsPerMonth, Used: used}
+	}
+	return nil
+}
+
+func quotaLimit(limits models.PlanLimits, resource string) *int64 {
+	switch resource {
+	case models.QuotaResources.Accounts:
+		return limits.Accounts
+	case models.QuotaResources.TransactionsPerMonth:
+		return limits.TransactionsPerMonth
+	case models.QuotaResources.StorageBytes:
+		return limits.StorageBytes
+	case models.QuotaResources.Webhooks:
+		return limits.Webhooks
+	}
+	return nil
+}
+
+// LimitsStatus reports the user's plan, its limits and their usage.
+func (s *Service) LimitsStatus(ctx context.Context, userID int) (models.LimitsStatus, error) {
+	var status models.LimitsStatus
+	var err error
+	if status.Plan, status.Limits, err = s.UserLimits(ctx, userID); err != nil {
+		return status, err
+	}
+
+	usage := map[string]*int64{
+		models.QuotaResources.Accounts:             &status.Usage.Accounts,
+		models.QuotaResources.TransactionsPerMonth: &status.Usage.TransactionsPerMonth,
+		models.QuotaResources.StorageBytes:         &status.Usage.StorageBytes,
+		models.QuotaResources.Webhooks:             &status.Usage.Webhooks,
+	}
+	for resource, used := range usage {
+		if *used, err = s.QuotaUsage(ctx, userID, resource); err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
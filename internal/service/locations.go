@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// geoCondition filters transactions t by a GeoFilter passed as the seven
+// parameters from $n on, see geoArgs. Distances are great-circle distances
+// in kilometres.
+func geoCondition(n int) string {
+	return fmt.Sprintf(`($%[1]d::float8 IS NULL OR (t.latitude BETWEEN $%[1]d AND $%[3]d AND CASE WHEN $%[2]d::float8 <= $%[4]d::float8
+			  THEN t.longitude BETWEEN $%[2]d AND $%[4]d ELSE t.longitude >= $%[2]d OR t.longitude <= $%[4]d END))
+			  AND ($%[5]d::float8 IS NULL OR 12742 * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(t.latitude - $%[5]d) / 2), 2)
+			  + COS(RADIANS($%[5]d)) * COS(RADIANS(t.latitude)) * POWER(SIN(RADIANS(t.longitude - $%[6]d) / 2), 2)))) <= $%[7]d::float8)`,
+		n, n+1, n+2, n+3, n+4, n+5, n+6)
+}
+
+func geoArgs(f models.GeoFilter) []interface{} {
+	args := make([]interface{}, 7)
+	if f.Bounds != nil {
+		args[0], args[1], args[2], args[3] = f.Bounds.South, f.Bounds.West, f.Bounds.North, f.Bounds.East
+	}
+	if f.Near != nil {
+		args[4], args[5], args[6] = f.Near.Latitude, f.Near.Longitude, f.RadiusKm
+	}
+	return args
+}
+
+// SpendingByLocation clusters the expenses of [start, end) matching geo by
+// their coordinates rounded to precision decimal places, largest first.
+// Zero bounds leave the range open.
+func (s *Service) SpendingByLocation(ctx context.Context, userID int, start, end time.Time, scope string, precision int, geo models.GeoFilter) (models.LocationSpending, error) {
+	spending := models.LocationSpending{Precision: precision, Locations: []models.LocationCluster{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return spending, err
+	}
+	spending.Currency = settings.BaseCurrency
+	if !start.IsZero() {
+		spending.PeriodStart = start.Format("2006-01-02")
+	} else {
+		start = rollupMinDate
+	}
+	if !end.IsZero() {
+		spending.PeriodEnd = end.AddDate(0, 0, -1).Format("2006-01-02")
+	} else {
+		end = rollupMaxDate
+	}
+
+	query := `SELECT AVG(t.latitude), AVG(t.longitude), COALESCE(MODE() WITHIN GROUP (ORDER BY NULLIF(t.place, '')), ''),
+			  SUM(t.amount), COUNT(*)
+			  FROM transactions t
+			  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date < $3 AND ($4 = '' OR t.scope = $4)
+				AND t.latitude IS NOT NULL AND ` + geoCondition(7) + `
+			  GROUP BY ROUND(t.latitude::numeric, $5), ROUND(t.longitude::numeric, $5)
+			  ORDER BY SUM(t.amount) DESC
+			  LIMIT $6`
+
+	args := append([]interface{}{userID, start, end, scope, precision, models.GeoSettings.MaxLocations + 1}, geoArgs(geo)...)
+	rows, err := s.ReadDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return spending, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cluster models.LocationCluster
+		if err := rows.Scan(&cluster.Latitude, &cluster.Longitude, &cluster.Place, &cluster.Amount, &cluster.Count); err != nil {
+			return spending, err
+		}
+		if len(spending.Locations) == models.GeoSettings.MaxLocations {
+			spending.Truncated = true
+			break
+		}
+		cluster.Latitude = math.Round(cluster.Latitude*1e6) / 1e6
+		cluster.Longitude = math.Round(cluster.Longitude*1e6) / 1e6
+		spending.Total += cluster.Amount
+		spending.Count += cluster.Count
+		spending.Locations = append(spending.Locations, cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return spending, err
+	}
+
+	err = s.ReadDB().QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0) FROM transactions
+			  WHERE user_id = $1 AND type = 'expense' AND date >= $2 AND date < $3 AND ($4 = '' OR scope = $4) AND latitude IS NULL`,
+		userID, start, end, scope).Scan(&spending.Unlocated)
+	spending.Total = math.Round(spending.Total*100) / 100
+	return spending, err
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/templates"
+)
+
+func (s *Service) LoginRetryAfter(ctx context.Context, email, ipAddress string) (time.Duration, error) {
+	settings := models.LoginSecuritySettings
+
+	var ipFailures int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM login_attempts
+			  WHERE ip_address = $1 AND NOT succeeded AND created_at > NOW() - make_interval(secs => $2)`,
+		ipAddress, settings.IPWindow.Seconds()).Scan(&ipFailures)
+	if err != nil {
+		return 0, err
+	}
+	if ipFailures >= settings.IPMaxFailures {
+		return settings.IPWindow, nil
+	}
+
+	var remaining float64
+	err = s.db.QueryRowContext(ctx, `SELECT EXTRACT(EPOCH FROM l.locked_until - NOW())
+			  FROM account_lockouts l JOIN users u ON u.id = l.user_id
+			  WHERE LOWER(u.email) = LOWER($1) AND l.locked_until > NOW()`, email).Scan(&remaining)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(math.Ceil(remaining)) * time.Second, nil
+}
+
+func (s *Service) RecordLoginFailure(ctx context.Context, email, ipAddress string) error {
+	if err := s.recordLoginAttempt(ctx, email, ipAddress, false); err != nil {
+		return err
+	}
+
+	var user models.User
+	err := s.db.QueryRowContext(ctx, `SELECT id, email, first_name FROM users WHERE LOWER(email) = LOWER($1)`, email).
+		Scan(&user.ID, &user.Email, &user.FirstName)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	settings := models.LoginSecuritySettings
+
+	// Lockouts stop escalating once the account has gone the decay period
+	// without a failed sign-in.
+	var lockouts int
+	query := `INSERT INTO account_lockouts (user_id, updated_at) VALUES ($1, NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET
+			  lockout_count = CASE WHEN account_lockouts.updated_at < NOW() - make_interval(secs => $2)
+			                       THEN 0 ELSE account_lockouts.lockout_count END,
+			  updated_at = NOW()
+			  RETURNING lockout_count`
+
+	if err := s.db.QueryRowContext(ctx, query, user.ID, settings.LockoutDecay.Seconds()).Scan(&lockouts); err != nil {
+		return err
+	}
+
+	var failed int
+	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM login_attempts
+			  WHERE email = LOWER($1) AND NOT succeeded
+			  AND created_at > GREATEST(NOW() - make_interval(secs => $2),
+			                            (SELECT failures_since FROM account_lockouts WHERE user_id = $3))`,
+		email, settings.FailureWindow.Seconds(), user.ID).Scan(&failed)
+	if err != nil {
+		return err
+	}
+	if failed < settings.MaxFailedAttempts {
+		return nil
+	}
+
+	return s.lockAccount(ctx, user, lockouts, ipAddress)
+}
+
+func (s *Service) RecordLoginSuccess(ctx context.Context, userID int, email, ipAddress string) error {
+	if err := s.recordLoginAttempt(ctx, email, ipAddress, true); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE account_lockouts SET failures_since = NOW(), lockout_count = 0, locked_until = NULL,
+			  unlock_token_hash = NULL, updated_at = NOW() WHERE user_id = $1`, userID)
+	return err
+}
+
+func (s *Service) UnlockAccount(ctx context.Context, token string) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE account_lockouts SET failures_since = NOW(), locked_until = NULL, unlock_token_hash = NULL, updated_at = NOW()
+			  WHERE unlock_token_hash = $1 AND locked_until > NOW()`, auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUnlockTokenInvalid
+	}
+	return nil
+}
+
+func (s *Service) lockAccount(ctx context.Context, user models.User, lockouts int, ipAddress string) error {
+	settings := models.LoginSecuritySettings
+
+	duration := settings.BaseLockout * time.Duration(math.Pow(2, float64(lockouts)))
+	if duration <= 0 || duration > settings.MaxLockout {
+		duration = settings.MaxLockout
+	}
+
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE account_lockouts SET failures_since = NOW(), lockout_count = lockout_count + 1,
+			  locked_until = NOW() + make_interval(secs => $2), unlock_token_hash = $3, updated_at = NOW()
+			  WHERE user_id = $1`, user.ID, duration.Seconds(), auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	l, _ := s.localizer(user.ID)
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  user.ID,
+		Type:    notifications.Types.SecurityAlert,
+		Title:   l.T("Account temporarily locked"),
+		Message: l.T("Sign-in was locked for %s after repeated failed attempts from %s.", duration, ipAddress),
+		Data: map[string]interface{}{
+			"ip_address":       ipAddress,
+			"lockout_duration": duration.Seconds(),
+		},
+	})
+
+	baseURL := strings.TrimRight(config.Get().Server.BaseURL, "/")
+	subject, body, err := templates.Email("account_locked", l.Tag().String(), templates.AccountLocked{
+		FirstName: user.FirstName,
+		Duration:  duration,
+		Attempts:  settings.MaxFailedAttempts,
+		IPAddress: ipAddress,
+		UnlockURL: baseURL + "/api/v1/auth/unlock/" + token,
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.mailer.Send(user.Email, subject, body); err != nil {
+			log.Printf("Error sending lockout email: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// RunLoginAttemptPruning deletes login attempts once they are older than
+// both the IP throttling window and the failure window, the only things
+// they are kept for.
+func (s *Service) RunLoginAttemptPruning(ctx context.Context) {
+	ticker := time.NewTicker(models.LoginSecuritySettings.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.pruneLoginAttempts(ctx); err != nil {
+			log.Printf("Error pruning login attempts: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d login attempts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) pruneLoginAttempts(ctx context.Context) (int64, error) {
+	retention := models.LoginSecuritySettings.IPWindow
+	if window := models.LoginSecuritySettings.FailureWindow; window > retention {
+		retention = window
+	}
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE created_at < NOW() - make_interval(secs => $1)`,
+		retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *Service) recordLoginAttempt(ctx context.Context, email, ipAddress string, succeeded bool) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO login_attempts (email, ip_address, succeeded, created_at) VALUES (LOWER($1), $2, $3, NOW())`,
+		email, ipAddress, succeeded)
+	return err
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/testdb"
+)
+
+func TestLoginFailureWindow(t *testing.T) {
+	db := testdb.New(t)
+	svc := New(db, notifications.NewDispatcher(), events.NewBroker())
+	ctx := context.Background()
+	settings := models.LoginSecuritySettings
+	const email, ip = "ada@example.com", "192.0.2.1"
+
+	var userID int
+	if err := db.QueryRow(`INSERT INTO users (email, password_hash) VALUES ($1, '') RETURNING id`, email).Scan(&userID); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := func(times int) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			if err := svc.RecordLoginFailure(ctx, email, ip); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	retryAfter := func() float64 {
+		t.Helper()
+		d, err := svc.LoginRetryAfter(ctx, email, "198.51.100.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Seconds()
+	}
+
+	// Failures older than the window do not count towards a lockout.
+	_, err := db.Exec(`INSERT INTO login_attempts (email, ip_address, succeeded, created_at)
+			  SELECT $1, $2, FALSE, NOW() - make_interval(secs => $3) - INTERVAL '1 minute' FROM generate_series(1, $4)`,
+		email, ip, settings.FailureWindow.Seconds(), settings.MaxFailedAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail(settings.MaxFailedAttempts - 1)
+	if d := retryAfter(); d != 0 {
+		t.Fatalf("locked for %vs after %d failures in the window", d, settings.MaxFailedAttempts-1)
+	}
+	fail(1)
+	if d := retryAfter(); d <= 0 || d > settings.BaseLockout.Seconds() {
+		t.Fatalf("first lockout lasts %vs, want up to %v", d, settings.BaseLockout)
+	}
+
+	// After a quiet period the next lockout is as short as the first.
+	_, err = db.Exec(`UPDATE account_lockouts SET locked_until = NULL, failures_since = NOW(),
+			  updated_at = NOW() - make_interval(secs => $2) - INTERVAL '1 minute' WHERE user_id = $1`,
+		userID, settings.LockoutDecay.Seconds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail(settings.MaxFailedAttempts)
+	if d := retryAfter(); d <= 0 || d > settings.BaseLockout.Seconds() {
+		t.Errorf("lockout after a quiet period lasts %vs, want up to %v", d, settings.BaseLockout)
+	}
+}
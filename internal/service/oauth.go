@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/oauth"
+)
+
+var (
+	ErrOAuthEmailRequired = errors.New("provider did not return an email address")
+	ErrOAuthEmailTaken    = errors.New("an account with this email already exists; sign in with your password and verify your email with the provider")
+)
+
+// ResolveOAuthIdentity returns the user an identity from an OAuth provider
+// signs in as. An identity seen for the first time is linked to the user
+// with its email address, provided the provider verified it, or to a new
+// user; the lookup and the link happen in one transaction.
+func (s *Service) ResolveOAuthIdentity(ctx context.Context, identity *oauth.Identity) (models.User, error) {
+	var user models.User
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT u.id, u.email, u.first_name, u.last_name, u.created_at, u.updated_at
+			  FROM user_identities i JOIN users u ON u.id = i.user_id
+			  WHERE i.provider = $1 AND i.subject = $2`
+
+	err = tx.QueryRowContext(ctx, query, identity.Provider, identity.Subject).
+		Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if err != sql.ErrNoRows {
+		return user, err
+	}
+
+	if identity.Email == "" {
+		return user, ErrOAuthEmailRequired
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT id, email, first_name, last_name, created_at, updated_at FROM users WHERE LOWER(email) = LOWER($1)`, identity.Email).
+		Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	switch {
+	case err == nil && !identity.EmailVerified:
+		return user, ErrOAuthEmailTaken
+	case err == sql.ErrNoRows:
+		user, err = createOAuthUser(ctx, tx, identity)
+		if err != nil {
+			return user, err
+		}
+	case err != nil:
+		return user, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO user_identities (user_id, provider, subject, email, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW())`, user.ID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return user, err
+	}
+
+	return user, tx.Commit()
+}
+
+func createOAuthUser(ctx context.Context, tx *sql.Tx, identity *oauth.Identity) (models.User, error) {
+	// Social accounts get an unusable random password until the user sets one.
+	secret, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := auth.HashPassword(secret)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Email:     identity.Email,
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+	}
+	if user.FirstName == "" {
+		user.FirstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	query := `INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, user.Email, hashedPassword, user.FirstName, user.LastName).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
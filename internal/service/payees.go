@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+// payeeSpendJoin selects a payee's expenses in [$2, $3). A transaction belongs
+// to the payee when its description contains match_text, ignoring case.
+const payeeSpendJoin = `LEFT JOIN transactions t ON t.user_id = p.user_id AND t.type = 'expense'
+			AND t.date >= $2 AND t.date < $3
+			AND POSITION(LOWER(p.match_text) IN LOWER(t.description)) > 0`
+
+func (s *Service) GetPayees(ctx context.Context, userID int) ([]models.Payee, error) {
+	query := `SELECT id, user_id, name, match_text, monthly_limit, created_at, updated_at
+			  FROM payees WHERE user_id = $1 ORDER BY name`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payees := []models.Payee{}
+	for rows.Next() {
+		var payee models.Payee
+		if err := rows.Scan(&payee.ID, &payee.UserID, &payee.Name, &payee.MatchText, &payee.MonthlyLimit,
+			&payee.CreatedAt, &payee.UpdatedAt); err != nil {
+			return nil, err
+		}
+		payees = append(payees, payee)
+	}
+	return payees, rows.Err()
+}
+
+func (s *Service) CreatePayee(ctx context.Context, p *models.Payee) error {
+	if p.MatchText == "" {
+		p.MatchText = p.Name
+	}
+
+	query := `INSERT INTO payees (user_id, name, match_text, monthly_limit, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, p.UserID, p.Name, p.MatchText, p.MonthlyLimit).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrPayeeExists
+	}
+	return err
+}
+
+func (s *Service) UpdatePayee(ctx context.Context, p *models.Payee) error {
+	if p.MatchText == "" {
+		p.MatchText = p.Name
+	}
+
+	query := `UPDATE payees SET name = $1, match_text = $2, monthly_limit = $3, updated_at = NOW()
+			  WHERE id = $4 AND user_id = $5 RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, p.Name, p.MatchText, p.MonthlyLimit, p.ID, p.UserID).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrPayeeNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrPayeeExists
+	}
+	return err
+}
+
+func (s *Service) DeletePayee(ctx context.Context, userID, payeeID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM payees WHERE id = $1 AND user_id = $2`, payeeID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPayeeNotFound
+	}
+	return nil
+}
+
+// PayeeLimits reports spending against every payee limit in the user's
+// financial month containing at.
+func (s *Service) PayeeLimits(ctx context.Context, userID int, at time.Time) (models.PayeeLimitOverview, error) {
+	overview := models.PayeeLimitOverview{Payees: []models.PayeeLimitStatus{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return overview, err
+	}
+	monthStart := FiscalMonthStart(at.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	overview.PeriodStart = monthStart.Format("2006-01-02")
+	overview.PeriodEnd = monthEnd.AddDate(0, 0, -1).Format("2006-01-02")
+	overview.Currency = settings.BaseCurrency
+
+	query := `SELECT p.id, p.name, p.monthly_limit, COALESCE(SUM(t.amount), 0)
+			  FROM payees p
+			  ` + payeeSpendJoin + `
+			  WHERE p.user_id = $1 AND p.monthly_limit IS NOT NULL
+			  GROUP BY p.id, p.name, p.monthly_limit
+			  ORDER BY p.name`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, monthStart, monthEnd)
+	if err != nil {
+		return overview, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.PayeeLimitStatus
+		if err := rows.Scan(&status.PayeeID, &status.PayeeName, &status.Limit, &status.Spent); err != nil {
+			return overview, err
+		}
+		status.Remaining = status.Limit - status.Spent
+		status.PercentUsed = math.Round(status.Spent/status.Limit*1000) / 10
+		overview.Payees = append(overview.Payees, status)
+	}
+	return overview, rows.Err()
+}
+
+// checkPayeeLimitAlerts notifies the user when an expense moves a payee's
+// monthly spending past the warning level or the limit.
+func (s *Service) checkPayeeLimitAlerts(ctx context.Context, t models.Transaction) error {
+	if strings.TrimSpace(t.Description) == "" {
+		return nil
+	}
+
+	settings, err := s.GetSettings(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	monthStart := FiscalMonthStart(t.Date.In(SettingsLocation(settings)), settings.FiscalMonthStartDay)
+
+	query := `SELECT p.id, p.name, p.monthly_limit, COALESCE(SUM(t.amount), 0)
+			  FROM payees p
+			  ` + payeeSpendJoin + `
+			  WHERE p.user_id = $1 AND p.monthly_limit IS NOT NULL
+				AND POSITION(LOWER(p.match_text) IN LOWER($4)) > 0
+			  GROUP BY p.id, p.name, p.monthly_limit`
+
+	rows, err := s.db.QueryContext(ctx, query, t.UserID, monthStart, monthStart.AddDate(0, 1, 0), t.Description)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var crossed []notifications.Notification
+	for rows.Next() {
+		var status models.PayeeLimitStatus
+		if err := rows.Scan(&status.PayeeID, &status.PayeeName, &status.Limit, &status.Spent); err != nil {
+			return err
+		}
+		if n, ok := s.payeeLimitNotification(t, status); ok {
+			crossed = append(crossed, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range crossed {
+		s.notifier.Dispatch(n)
+	}
+	return nil
+}
+
+func (s *Service) payeeLimitNotification(t models.Transaction, status models.PayeeLimitStatus) (notifications.Notification, bool) {
+	before := status.Spent - t.Amount
+	warningLevel := status.Limit * models.BudgetAlertSettings.WarningRatio
+
+	l, currency := s.localizer(t.UserID)
+	spent, limit := l.Amount(status.Spent, currency), l.Amount(status.Limit, currency)
+
+	var notification notifications.Notification
+	switch {
+	case before <= status.Limit && status.Spent > status.Limit:
+		notification = notifications.Notification{
+			Type:    notifications.Types.PayeeExceeded,
+			Title:   l.T("Payee limit exceeded: %s", status.PayeeName),
+			Message: l.T("You have spent %s at %s this month, over your %s limit.", spent, status.PayeeName, limit),
+		}
+	case before < warningLevel && status.Spent >= warningLevel:
+		notification = notifications.Notification{
+			Type:    notifications.Types.PayeeWarning,
+			Title:   l.T("Payee limit almost reached: %s", status.PayeeName),
+			Message: l.T("You have spent %s of your %s monthly limit at %s.", spent, limit, status.PayeeName),
+		}
+	default:
+		return notification, false
+	}
+
+	notification.UserID = t.UserID
+	notification.Data = map[string]interface{}{
+		"payee_id": status.PayeeID,
+		"limit":    status.Limit,
+		"spent":    status.Spent,
+	}
+	return notification, true
+}
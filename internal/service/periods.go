@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// WeekStart returns the midnight starting the week that contains date, for
+// weeks beginning on firstDay (0 is Sunday).
+func WeekStart(date time.Time, firstDay int) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	offset := (int(day.Weekday()) - firstDay + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// FiscalMonthStart returns the start of the fiscal month containing date,
+// where fiscal months begin on startDay of each calendar month.
+func FiscalMonthStart(date time.Time, startDay int) time.Time {
+	start := time.Date(date.Year(), date.Month(), startDay, 0, 0, 0, 0, date.Location())
+	if start.After(date) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// PayPeriod returns the half-open pay period containing at under the user's
+// pay-period calendar. Monthly periods follow the fiscal month start day.
+func PayPeriod(settings models.UserSettings, at time.Time) (time.Time, time.Time) {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+
+	step := 0
+	switch settings.PayPeriod {
+	case models.PayPeriods.Weekly:
+		step = 7
+	case models.PayPeriods.Biweekly:
+		step = 14
+	}
+	if step == 0 {
+		start := FiscalMonthStart(day, settings.FiscalMonthStartDay)
+		return start, start.AddDate(0, 1, 0)
+	}
+
+	if settings.PayPeriodAnchor == nil {
+		start := WeekStart(day, settings.FirstDayOfWeek)
+		return start, start.AddDate(0, 0, step)
+	}
+
+	anchor, err := time.ParseInLocation("2006-01-02", *settings.PayPeriodAnchor, at.Location())
+	if err != nil {
+		start := WeekStart(day, settings.FirstDayOfWeek)
+		return start, start.AddDate(0, 0, step)
+	}
+	days := math.Round(day.Sub(anchor).Hours() / 24)
+	periods := int(math.Floor(days / float64(step)))
+	start := anchor.AddDate(0, 0, periods*step)
+	return start, start.AddDate(0, 0, step)
+}
+
+// PeriodSummaries returns income and expense totals for the last count pay
+// periods, newest first, starting with the one containing at, optionally only
+// for one scope.
+func (s *Service) PeriodSummaries(ctx context.Context, userID int, at time.Time, count int, scope string) ([]models.PeriodSummary, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.PeriodSummary, 0, count)
+	start, end := PayPeriod(settings, at.In(SettingsLocation(settings)))
+	for i := 0; i < count; i++ {
+		totals, err := s.CategoryTotals(ctx, userID, start, end, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		summary := models.PeriodSummary{Start: start.Format("2006-01-02"), End: end.AddDate(0, 0, -1).Format("2006-01-02")}
+		for _, total := range totals {
+			if total.Type == "income" {
+				summary.TotalIncome += total.Amount
+			} else {
+				summary.TotalExpenses += total.Amount
+			}
+		}
+		summary.NetIncome = summary.TotalIncome - summary.TotalExpenses
+		summaries = append(summaries, summary)
+
+		start, end = PayPeriod(settings, start.AddDate(0, 0, -1))
+	}
+	return summaries, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+func day(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		date     string
+		firstDay int
+		want     string
+	}{
+		{"2026-10-16", 1, "2026-10-12"},
+		{"2026-10-16", 0, "2026-10-11"},
+		{"2026-10-12", 1, "2026-10-12"},
+		{"2026-10-11", 1, "2026-10-05"},
+		{"2026-10-11", 0, "2026-10-11"},
+	}
+
+	for _, tt := range tests {
+		got := WeekStart(day(tt.date), tt.firstDay).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("WeekStart(%s, %d) = %s, want %s", tt.date, tt.firstDay, got, tt.want)
+		}
+	}
+}
+
+func TestFiscalMonthStart(t *testing.T) {
+	tests := []struct {
+		date     string
+		startDay int
+		want     string
+	}{
+		{"2026-10-16", 1, "2026-10-01"},
+		{"2026-10-16", 25, "2026-09-25"},
+		{"2026-10-25", 25, "2026-10-25"},
+		{"2026-01-10", 25, "2025-12-25"},
+	}
+
+	for _, tt := range tests {
+		got := FiscalMonthStart(day(tt.date), tt.startDay).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("FiscalMonthStart(%s, %d) = %s, want %s", tt.date, tt.startDay, got, tt.want)
+		}
+	}
+}
+
+func TestPayPeriod(t *testing.T) {
+	anchor := "2026-10-02"
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		at       string
+		start    string
+		end      string
+	}{
+		{"monthly", models.UserSettings{PayPeriod: models.PayPeriods.Monthly, FiscalMonthStartDay: 1}, "2026-10-16", "2026-10-01", "2026-11-01"},
+		{"monthly from the 25th", models.UserSettings{PayPeriod: models.PayPeriods.Monthly, FiscalMonthStartDay: 25}, "2026-10-16", "2026-09-25", "2026-10-25"},
+		{"weekly without anchor", models.UserSettings{PayPeriod: models.PayPeriods.Weekly, FirstDayOfWeek: 1}, "2026-10-16", "2026-10-12", "2026-10-19"},
+		{"biweekly on the anchor", models.UserSettings{PayPeriod: models.PayPeriods.Biweekly, PayPeriodAnchor: &anchor}, "2026-10-02", "2026-10-02", "2026-10-16"},
+		{"biweekly last day", models.UserSettings{PayPeriod: models.PayPeriods.Biweekly, PayPeriodAnchor: &anchor}, "2026-10-15", "2026-10-02", "2026-10-16"},
+		{"biweekly next period", models.UserSettings{PayPeriod: models.PayPeriods.Biweekly, PayPeriodAnchor: &anchor}, "2026-10-16", "2026-10-16", "2026-10-30"},
+		{"biweekly before the anchor", models.UserSettings{PayPeriod: models.PayPeriods.Biweekly, PayPeriodAnchor: &anchor}, "2026-09-30", "2026-09-18", "2026-10-02"},
+	}
+
+	for _, tt := range tests {
+		start, end := PayPeriod(tt.settings, day(tt.at))
+		if got := start.Format("2006-01-02"); got != tt.start {
+			t.Errorf("%s: PayPeriod(%s) starts %s, want %s", tt.name, tt.at, got, tt.start)
+		}
+		if got := end.Format("2006-01-02"); got != tt.end {
+			t.Errorf("%s: PayPeriod(%s) ends %s, want %s", tt.name, tt.at, got, tt.end)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// recategorizeMatch selects the transactions a recategorization touches.
+// Descriptions match like payees do, by case-insensitive substring; dates are
+// whole days in the user's timezone. Transactions already in the target
+// category, or of another type than it, do not match.
+const recategorizeMatch = `FROM transactions t
+			  JOIN categories target ON target.id = $2 AND target.user_id = t.user_id AND target.type = t.type
+			  WHERE t.user_id = $1 AND t.category_id IS DISTINCT FROM $2
+			  AND ($3 = '' OR POSITION(LOWER($3) IN LOWER(COALESCE(t.description, ''))) > 0)
+			  AND ($4 = '' OR POSITION(LOWER($4) IN LOWER(COALESCE(t.description, ''))) > 0)
+			  AND ($5 = 0 OR t.category_id = $5)
+			  AND ($6 = 0 OR t.account_id = $6)
+			  AND ($7 = '' OR t.date >= $7::date::timestamp AT TIME ZONE $9)
+			  AND ($8 = '' OR t.date < ($8::date + 1)::timestamp AT TIME ZONE $9)`
+
+// Recategorize previews or applies a bulk category change. Applying runs in
+// one database transaction, so either every match moves or none does.
+func (s *Service) Recategorize(ctx context.Context, userID int, req models.RecategorizeRequest) (models.Recategorization, error) {
+	result := models.Recategorization{Sample: []models.Transaction{}}
+	if req.Description == "" && req.PayeeID == nil && req.FromCategoryID == nil {
+		return result, ErrRecategorizeFilter
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureOwned(ctx, tx, "categories", req.CategoryID, userID); err != nil {
+		return result, err
+	}
+	var fromCategoryID, accountID int
+	if req.FromCategoryID != nil {
+		fromCategoryID = *req.FromCategoryID
+		if err := ensureOwned(ctx, tx, "categories", fromCategoryID, userID); err != nil {
+			return result, err
+		}
+	}
+	if req.AccountID != nil {
+		accountID = *req.AccountID
+		if err := ensureOwned(ctx, tx, "accounts", accountID, userID); err != nil {
+			return result, err
+		}
+	}
+	var payeeText string
+	if req.PayeeID != nil {
+		err := tx.QueryRowContext(ctx, `SELECT match_text FROM payees WHERE id = $1 AND user_id = $2`, *req.PayeeID, userID).Scan(&payeeText)
+		if err == sql.ErrNoRows {
+			return result, ErrPayeeNotFound
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	args := []interface{}{userID, req.CategoryID, req.Description, payeeText, fromCategoryID, accountID,
+		req.StartDate, req.EndDate, s.Location(ctx, userID).String()}
+
+	rows, err := tx.QueryContext(ctx, `SELECT t.id, t.user_id, t.account_id, COALESCE(t.category_id, 0), t.amount, t.type,
+			  COALESCE(t.description, ''), t.date, t.scope, t.created_at, t.updated_at, COUNT(*) OVER ()
+			  `+recategorizeMatch+`
+			  ORDER BY t.date DESC, t.id DESC
+			  LIMIT `+strconv.Itoa(models.RecategorizeSettings.SampleSize), args...)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(&t.ID, &t.UserID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type, &t.Description,
+			&t.Date, &t.Scope, &t.CreatedAt, &t.UpdatedAt, &result.Matched); err != nil {
+			rows.Close()
+			return result, err
+		}
+		result.Sample = append(result.Sample, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	if !req.Apply || result.Matched == 0 {
+		return result, nil
+	}
+
+	updated, err := tx.ExecContext(ctx, `UPDATE transactions SET category_id = $2, updated_at = NOW()
+			  WHERE id IN (SELECT t.id `+recategorizeMatch+`)`, args...)
+	if err != nil {
+		return result, err
+	}
+	n, err := updated.RowsAffected()
+	if err != nil {
+		return result, err
+	}
+	result.Updated = int(n)
+	if req.ExpectedCount != nil && *req.ExpectedCount != result.Updated {
+		return result, ErrRecategorizeChanged
+	}
+
+	err = recordAudit(ctx, tx, models.AuditEntry{
+		UserID:     userID,
+		Action:     models.AuditActions.Recategorize,
+		EntityType: "category",
+		EntityID:   &req.CategoryID,
+		Details: map[string]interface{}{
+			"description":      req.Description,
+			"payee_id":         req.PayeeID,
+			"from_category_id": req.FromCategoryID,
+			"account_id":       req.AccountID,
+			"start_date":       req.StartDate,
+			"end_date":         req.EndDate,
+			"updated":          result.Updated,
+		},
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+
+	result.Applied = true
+	s.TransactionUpdated(userID)
+	return result, nil
+}
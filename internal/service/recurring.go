@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+const recurringColumns = `id, user_id, account_id, category_id, amount, type, description, frequency,
+			  start_date, end_date, created_at, updated_at`
+
+func scanRecurringRule(row interface{ Scan(...interface{}) error }, r *models.RecurringRule) error {
+	return row.Scan(&r.ID, &r.UserID, &r.AccountID, &r.CategoryID, &r.Amount, &r.Type, &r.Description,
+		&r.Frequency, &r.StartDate, &r.EndDate, &r.CreatedAt, &r.UpdatedAt)
+}
+
+func (s *Service) GetRecurringRules(ctx context.Context, userID int) ([]models.RecurringRule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+recurringColumns+` FROM recurring_rules
+			  WHERE user_id = $1 ORDER BY start_date, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.RecurringRule{}
+	for rows.Next() {
+		var rule models.RecurringRule
+		if err := scanRecurringRule(rows, &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Service) CreateRecurringRule(ctx context.Context, r *models.RecurringRule) error {
+	if err := ensureOwned(ctx, s.db, "accounts", r.AccountID, r.UserID); err != nil {
+		return err
+	}
+	if err := ensureOwned(ctx, s.db, "categories", r.CategoryID, r.UserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO recurring_rules (user_id, account_id, category_id, amount, type, description, frequency, start_date, end_date, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	return s.db.QueryRowContext(ctx, query, r.UserID, r.AccountID, r.CategoryID, r.Amount, r.Type, r.Description,
+		r.Frequency, r.StartDate, r.EndDate).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+}
+
+func (s *Service) UpdateRecurringRule(ctx context.Context, r *models.RecurringRule) error {
+	if err := ensureOwned(ctx, s.db, "accounts", r.AccountID, r.UserID); err != nil {
+		return err
+	}
+	if err := ensureOwned(ctx, s.db, "categories", r.CategoryID, r.UserID); err != nil {
+		return err
+	}
+
+	query := `UPDATE recurring_rules SET account_id = $1, category_id = $2, amount = $3, type = $4, description = $5,
+			  frequency = $6, start_date = $7, end_date = $8, updated_at = NOW()
+			  WHERE id = $9 AND user_id = $10 RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, r.AccountID, r.CategoryID, r.Amount, r.Type, r.Description,
+		r.Frequency, r.StartDate, r.EndDate, r.ID, r.UserID).Scan(&r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrRecurringNotFound
+	}
+	return err
+}
+
+func (s *Service) DeleteRecurringRule(ctx context.Context, userID, ruleID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM recurring_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRecurringNotFound
+	}
+	return nil
+}
+
+// addMonths moves a date by n months, clamping the day to the end of shorter
+// months where time.AddDate would spill over into the next one.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// occurrences returns the dates in [from, to] of a rule repeating from start.
+// Each date is computed from start, so a rule on the 31st stays on the last
+// day of shorter months without drifting.
+func occurrences(start time.Time, end *time.Time, frequency string, from, to time.Time) []time.Time {
+	var dates []time.Time
+	for k := 0; ; k++ {
+		var date time.Time
+		switch frequency {
+		case models.RecurrenceFrequencies.Weekly:
+			date = start.AddDate(0, 0, 7*k)
+		case models.RecurrenceFrequencies.Biweekly:
+			date = start.AddDate(0, 0, 14*k)
+		case models.RecurrenceFrequencies.Monthly:
+			date = addMonths(start, k)
+		case models.RecurrenceFrequencies.Yearly:
+			date = addMonths(start, 12*k)
+		default:
+			return nil
+		}
+		if date.After(to) || (end != nil && date.After(*end)) {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+// Upcoming projects the user's recurring rules, card payments and allowance
+// transfers over the next days, starting today in the user's timezone.
+func (s *Service) Upcoming(ctx context.Context, userID int, now time.Time, days int) (models.UpcomingFeed, error) {
+	feed := models.UpcomingFeed{Transactions: []models.UpcomingTransaction{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return feed, err
+	}
+	local := now.In(SettingsLocation(settings))
+	// Dates are compared as calendar days, the way DATE columns are scanned.
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, days-1)
+	feed.From, feed.To, feed.Currency = from.Format("2006-01-02"), to.Format("2006-01-02"), settings.BaseCurrency
+
+	rules, err := s.GetRecurringRules(ctx, userID)
+	if err != nil {
+		return feed, err
+	}
+	for _, rule := range rules {
+		categoryID := rule.CategoryID
+		for _, date := range occurrences(rule.StartDate, rule.EndDate, rule.Frequency, from, to) {
+			feed.Transactions = append(feed.Transactions, models.UpcomingTransaction{
+				Date:        date.Format("2006-01-02"),
+				Source:      models.UpcomingSources.Recurring,
+				SourceID:    rule.ID,
+				AccountID:   rule.AccountID,
+				CategoryID:  &categoryID,
+				Type:        rule.Type,
+				Amount:      rule.Amount,
+				Description: rule.Description,
+			})
+		}
+	}
+
+	bills, err := s.upcomingBills(ctx, userID, now, from, to)
+	if err != nil {
+		return feed, err
+	}
+	transfers, err := s.upcomingTransfers(ctx, userID, from, to)
+	if err != nil {
+		return feed, err
+	}
+	feed.Transactions = append(append(feed.Transactions, bills...), transfers...)
+
+	sort.SliceStable(feed.Transactions, func(i, j int) bool {
+		return feed.Transactions[i].Date < feed.Transactions[j].Date
+	})
+	for _, t := range feed.Transactions {
+		switch {
+		case t.Source == models.UpcomingSources.Transfer:
+		case t.Type == "income":
+			feed.Income += t.Amount
+		default:
+			feed.Expenses += t.Amount
+		}
+	}
+	return feed, nil
+}
+
+// upcomingBills lists what is still owed on the last statement of each credit
+// card when its payment is due in the window.
+func (s *Service) upcomingBills(ctx context.Context, userID int, now, from, to time.Time) ([]models.UpcomingTransaction, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM accounts
+			  WHERE user_id = $1 AND statement_closing_day IS NOT NULL AND archived_at IS NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	type card struct {
+		id   int
+		name string
+	}
+	var cards []card
+	for rows.Next() {
+		var c card
+		if err := rows.Scan(&c.id, &c.name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	l, _ := s.localizer(userID)
+	var bills []models.UpcomingTransaction
+	for _, c := range cards {
+		statement, err := s.CardStatement(ctx, userID, c.id, now)
+		if err != nil {
+			return nil, err
+		}
+		due := statement.DueDate.Format("2006-01-02")
+		if statement.RemainingDue <= 0 || due < from.Format("2006-01-02") || due > to.Format("2006-01-02") {
+			continue
+		}
+		bills = append(bills, models.UpcomingTransaction{
+			Date:        due,
+			Source:      models.UpcomingSources.Bill,
+			SourceID:    c.id,
+			AccountID:   c.id,
+			Type:        "expense",
+			Amount:      statement.RemainingDue,
+			Description: l.T("Card payment due: %s", c.name),
+		})
+	}
+	return bills, nil
+}
+
+// upcomingTransfers lists allowance transfers the user pays as a household
+// owner. They step from next_date one period at a time, like the scheduler.
+func (s *Service) upcomingTransfers(ctx context.Context, userID int, from, to time.Time) ([]models.UpcomingTransaction, error) {
+	rules, err := s.AllowanceRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	l, _ := s.localizer(userID)
+	var transfers []models.UpcomingTransaction
+	for _, rule := range rules {
+		date, err := time.Parse("2006-01-02", rule.NextDate)
+		if err != nil {
+			return nil, err
+		}
+		var member string
+		if err := s.db.QueryRowContext(ctx, `SELECT first_name FROM users WHERE id = $1`, rule.MemberID).Scan(&member); err != nil {
+			return nil, err
+		}
+
+		toAccount, categoryID := rule.ToAccountID, rule.CategoryID
+		// Rules overdue while the scheduler was down are paid on its next run.
+		if date.Before(from) {
+			date = from
+		}
+		for ; !date.After(to); date = nextAllowanceDate(date, rule.Frequency) {
+			transfers = append(transfers, models.UpcomingTransaction{
+				Date:        date.Format("2006-01-02"),
+				Source:      models.UpcomingSources.Transfer,
+				SourceID:    rule.ID,
+				AccountID:   rule.FromAccountID,
+				ToAccountID: &toAccount,
+				CategoryID:  &categoryID,
+				Type:        "expense",
+				Amount:      rule.Amount,
+				Description: l.T("Allowance for %s", member),
+			})
+		}
+	}
+	return transfers, nil
+}
+
+func nextAllowanceDate(date time.Time, frequency string) time.Time {
+	if frequency == models.AllowanceFrequencies.Weekly {
+		return date.AddDate(0, 0, 7)
+	}
+	return addMonths(date, 1)
+}
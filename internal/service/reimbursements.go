@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+
+	"personal-finance-tracker/internal/models"
+)
+
+const reimbursementColumns = `id, user_id, transaction_id, distance_km, rate_per_km, to_char(trip_date, 'YYYY-MM-DD'), amount,
+			  description, payer, status, submitted_at, reimbursed_at, reimbursement_transaction_id, created_at, updated_at`
+
+func scanReimbursement(row interface{ Scan(...interface{}) error }, r *models.Reimbursement) error {
+	return row.Scan(&r.ID, &r.UserID, &r.TransactionID, &r.DistanceKm, &r.RatePerKm, &r.TripDate, &r.Amount,
+		&r.Description, &r.Payer, &r.Status, &r.SubmittedAt, &r.ReimbursedAt, &r.ReimbursementTransactionID,
+		&r.CreatedAt, &r.UpdatedAt)
+}
+
+// Reimbursements lists the user's reimbursements, newest first, optionally
+// only those in one status.
+func (s *Service) Reimbursements(ctx context.Context, userID int, status string) ([]models.Reimbursement, error) {
+	query := `SELECT ` + reimbursementColumns + ` FROM reimbursements
+			  WHERE user_id = $1 AND ($2 = '' OR status = $2) ORDER BY created_at DESC, id DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reimbursements := []models.Reimbursement{}
+	for rows.Next() {
+		var r models.Reimbursement
+		if err := scanReimbursement(rows, &r); err != nil {
+			return nil, err
+		}
+		reimbursements = append(reimbursements, r)
+	}
+	return reimbursements, rows.Err()
+}
+
+// CreateReimbursement flags an expense as reimbursable for its full amount,
+// or records a mileage claim worth distance times rate.
+func (s *Service) CreateReimbursement(ctx context.Context, r *models.Reimbursement) error {
+	mileage := r.DistanceKm != nil || r.RatePerKm != nil || r.TripDate != nil
+	switch {
+	case r.TransactionID != nil && !mileage:
+		var transactionType, description string
+		err := s.db.QueryRowContext(ctx, `SELECT amount, type, COALESCE(description, '') FROM transactions WHERE id = $1 AND user_id = $2`,
+			*r.TransactionID, r.UserID).Scan(&r.Amount, &transactionType, &description)
+		if err == sql.ErrNoRows {
+			return ErrTransactionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if transactionType != "expense" {
+			return ErrInvalidReimbursement
+		}
+		if r.Description == "" {
+			r.Description = description
+		}
+	case r.TransactionID == nil && r.DistanceKm != nil && r.RatePerKm != nil && r.TripDate != nil:
+		r.Amount = math.Round(*r.DistanceKm**r.RatePerKm*100) / 100
+		if r.Amount <= 0 {
+			return ErrInvalidReimbursement
+		}
+	default:
+		return ErrInvalidReimbursement
+	}
+
+	query := `INSERT INTO reimbursements (user_id, transaction_id, distance_km, rate_per_km, trip_date, amount, description, payer, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING ` + reimbursementColumns
+
+	err := scanReimbursement(s.db.QueryRowContext(ctx, query, r.UserID, r.TransactionID, r.DistanceKm, r.RatePerKm, r.TripDate,
+		r.Amount, r.Description, r.Payer), r)
+	if isUniqueViolation(err) {
+		return ErrReimbursementExists
+	}
+	return err
+}
+
+// SubmitReimbursement marks a pending reimbursement as claimed from the payer.
+func (s *Service) SubmitReimbursement(ctx context.Context, userID, id int) (models.Reimbursement, error) {
+	query := `UPDATE reimbursements SET status = $3, submitted_at = NOW(), updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2 AND status = $4 RETURNING ` + reimbursementColumns
+
+	return s.moveReimbursement(ctx, userID, id, query, models.ReimbursementStatuses.Submitted, models.ReimbursementStatuses.Pending)
+}
+
+// Reimburse closes a reimbursement against the income transaction that paid
+// it back. Reimbursements settled without being submitted first are
+// considered submitted at the same time.
+func (s *Service) Reimburse(ctx context.Context, userID, id, transactionID int) (models.Reimbursement, error) {
+	var transactionType string
+	err := s.db.QueryRowContext(ctx, `SELECT type FROM transactions WHERE id = $1 AND user_id = $2`, transactionID, userID).
+		Scan(&transactionType)
+	if err == sql.ErrNoRows {
+		return models.Reimbursement{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return models.Reimbursement{}, err
+	}
+	if transactionType != "income" {
+		return models.Reimbursement{}, ErrNotIncome
+	}
+
+	query := `UPDATE reimbursements SET status = $3, reimbursed_at = NOW(), submitted_at = COALESCE(submitted_at, NOW()),
+			  reimbursement_transaction_id = $4, updated_at = NOW()
+			  WHERE id = $1 AND user_id = $2 AND status <> $3 RETURNING ` + reimbursementColumns
+
+	return s.moveReimbursement(ctx, userID, id, query, models.ReimbursementStatuses.Reimbursed, transactionID)
+}
+
+// moveReimbursement runs a status update and tells a missing reimbursement
+// apart from one whose current status does not allow the move.
+func (s *Service) moveReimbursement(ctx context.Context, userID, id int, query string, args ...interface{}) (models.Reimbursement, error) {
+	var r models.Reimbursement
+	err := scanReimbursement(s.db.QueryRowContext(ctx, query, append([]interface{}{id, userID}, args...)...), &r)
+	if err != sql.ErrNoRows {
+		return r, err
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM reimbursements WHERE id = $1 AND user_id = $2)`, id, userID).
+		Scan(&exists); err != nil {
+		return r, err
+	}
+	if exists {
+		return r, ErrReimbursementStatus
+	}
+	return r, ErrReimbursementNotFound
+}
+
+func (s *Service) DeleteReimbursement(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM reimbursements WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrReimbursementNotFound
+	}
+	return nil
+}
+
+// OutstandingReimbursements sums what is still owed to the user, in total
+// and per payer, largest first.
+func (s *Service) OutstandingReimbursements(ctx context.Context, userID int) (models.OutstandingReimbursements, error) {
+	report := models.OutstandingReimbursements{Payers: []models.PayerReimbursements{}, Reimbursements: []models.Reimbursement{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+	report.Currency = settings.BaseCurrency
+
+	query := `SELECT ` + reimbursementColumns + ` FROM reimbursements
+			  WHERE user_id = $1 AND status <> $2 ORDER BY created_at, id`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, models.ReimbursementStatuses.Reimbursed)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	payers := map[string]*models.PayerReimbursements{}
+	for rows.Next() {
+		var r models.Reimbursement
+		if err := scanReimbursement(rows, &r); err != nil {
+			return report, err
+		}
+		report.Reimbursements = append(report.Reimbursements, r)
+
+		report.Total += r.Amount
+		if r.Status == models.ReimbursementStatuses.Submitted {
+			report.Submitted += r.Amount
+		} else {
+			report.Pending += r.Amount
+		}
+		if payers[r.Payer] == nil {
+			payers[r.Payer] = &models.PayerReimbursements{Payer: r.Payer}
+		}
+		payers[r.Payer].Amount += r.Amount
+		payers[r.Payer].Count++
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, payer := range payers {
+		report.Payers = append(report.Payers, *payer)
+	}
+	sort.Slice(report.Payers, func(i, j int) bool {
+		if report.Payers[i].Amount != report.Payers[j].Amount {
+			return report.Payers[i].Amount > report.Payers[j].Amount
+		}
+		return report.Payers[i].Payer < report.Payers[j].Payer
+	})
+	return report, nil
+}
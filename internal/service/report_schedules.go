@@ -0,0 +1,542 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/cron"
+	"personal-finance-tracker/internal/i18n"
+	"personal-finance-tracker/internal/mailer"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/pdf"
+	"personal-finance-tracker/internal/secrets"
+	"personal-finance-tracker/internal/templates"
+	"personal-finance-tracker/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	ErrTooManyReportSchedules = errors.New("too many report schedules")
+	ErrInvalidCron            = errors.New("cron must be a five-field cron expression or a macro such as @weekly")
+	ErrCronTooFrequent        = errors.New("reports can be scheduled at most once an hour")
+	ErrReportWebhookURL       = errors.New("webhook delivery needs a webhook_url")
+)
+
+var reportWebhookClient = &http.Client{Timeout: models.ReportScheduleSettings.WebhookTimeout}
+
+var reportLabels = map[string]string{
+	"category": "Category",
+	"account":  "Account",
+	"payee":    "Payee",
+	"tag":      "Tag",
+	"month":    "Month",
+	"sum":      "Sum",
+	"count":    "Count",
+	"avg":      "Average",
+}
+
+type reportScheduleJob struct {
+	ScheduleID int       `json:"schedule_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+const reportScheduleColumns = `id, user_id, name, group_by, pivot, metrics, filter, format, delivery, webhook_url, cron, enabled,
+			  next_run_at, last_run_at, created_at, updated_at`
+
+func scanReportSchedule(row interface{ Scan(...interface{}) error }, r *models.ReportSchedule) error {
+	var filter []byte
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.GroupBy, &r.Pivot, pq.Array(&r.Metrics), &filter, &r.Format, &r.Delivery,
+		&r.WebhookURL, &r.Cron, &r.Enabled, &r.NextRunAt, &r.LastRunAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(filter, &r.Filter); err != nil {
+		return err
+	}
+	r.WebhookURL, err = secrets.Decrypt(r.WebhookURL)
+	return err
+}
+
+func (s *Service) ReportSchedules(ctx context.Context, userID int) ([]models.ReportSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+reportScheduleColumns+` FROM report_schedules WHERE user_id = $1 ORDER BY name, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.ReportSchedule{}
+	for rows.Next() {
+		var schedule models.ReportSchedule
+		if err := scanReportSchedule(rows, &schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (s *Service) ReportSchedule(ctx context.Context, userID, id int) (models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	err := scanReportSchedule(s.db.QueryRowContext(ctx, `SELECT `+reportScheduleColumns+` FROM report_schedules
+			  WHERE id = $1 AND user_id = $2`, id, userID), &schedule)
+	if err == sql.ErrNoRows {
+		return schedule, ErrReportScheduleNotFound
+	}
+	return schedule, err
+}
+
+// prepareReportSchedule validates a schedule request and works out when it
+// first fires, in the user's timezone.
+func (s *Service) prepareReportSchedule(ctx context.Context, userID int, req models.ReportScheduleRequest) (models.ReportSchedule, error) {
+	schedule := models.ReportSchedule{
+		UserID:     userID,
+		Name:       req.Name,
+		GroupBy:    req.GroupBy,
+		Pivot:      req.Pivot,
+		Metrics:    req.Metrics,
+		Filter:     req.Filter,
+		Format:     req.Format,
+		Delivery:   req.Delivery,
+		WebhookURL: req.WebhookURL,
+		Cron:       strings.TrimSpace(req.Cron),
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if len(schedule.Metrics) == 0 {
+		schedule.Metrics = []string{"sum"}
+	}
+	if err := validateReport(schedule.GroupBy, schedule.Pivot, schedule.Metrics); err != nil {
+		return schedule, err
+	}
+	if schedule.Delivery == models.ReportDeliveries.Webhook && schedule.WebhookURL == "" {
+		return schedule, ErrReportWebhookURL
+	}
+	if schedule.Delivery == models.ReportDeliveries.Email {
+		schedule.WebhookURL = ""
+	}
+	if schedule.Filter.AccountID != nil {
+		if err := ensureOwned(ctx, s.db, "accounts", *schedule.Filter.AccountID, userID); err != nil {
+			return schedule, err
+		}
+	}
+	if schedule.Filter.CategoryID != nil {
+		if err := ensureOwned(ctx, s.db, "categories", *schedule.Filter.CategoryID, userID); err != nil {
+			return schedule, err
+		}
+	}
+
+	expr, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return schedule, ErrInvalidCron
+	}
+	if expr.RunsPerHour() > models.ReportScheduleSettings.MaxRunsPerHour {
+		return schedule, ErrCronTooFrequent
+	}
+	if schedule.NextRunAt = expr.Next(time.Now().In(s.Location(ctx, userID))); schedule.NextRunAt.IsZero() {
+		return schedule, ErrInvalidCron
+	}
+	return schedule, nil
+}
+
+func (s *Service) CreateReportSchedule(ctx context.Context, userID int, req models.ReportScheduleRequest) (models.ReportSchedule, error) {
+	schedule, err := s.prepareReportSchedule(ctx, userID, req)
+	if err != nil {
+		return schedule, err
+	}
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM report_schedules WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return schedule, err
+	}
+	if count >= models.ReportScheduleSettings.MaxSchedules {
+		return schedule, ErrTooManyReportSchedules
+	}
+
+	filter, err := json.Marshal(schedule.Filter)
+	if err != nil {
+		return schedule, err
+	}
+	webhookURL, err := secrets.Encrypt(schedule.WebhookURL)
+	if err != nil {
+		return schedule, err
+	}
+
+	query := `INSERT INTO report_schedules (user_id, name, group_by, pivot, metrics, filter, format, delivery, webhook_url, cron,
+			  enabled, next_run_at, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err = s.db.QueryRowContext(ctx, query, userID, schedule.Name, schedule.GroupBy, schedule.Pivot, pq.Array(schedule.Metrics), filter,
+		schedule.Format, schedule.Delivery, webhookURL, schedule.Cron, schedule.Enabled, schedule.NextRunAt).
+		Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+	return schedule, err
+}
+
+// UpdateReportSchedule replaces a schedule's settings. Its next run is worked
+// out again from the new cron expression.
+func (s *Service) UpdateReportSchedule(ctx context.Context, userID, id int, req models.ReportScheduleRequest) (models.ReportSchedule, error) {
+	schedule, err := s.prepareReportSchedule(ctx, userID, req)
+	if err != nil {
+		return schedule, err
+	}
+
+	filter, err := json.Marshal(schedule.Filter)
+	if err != nil {
+		return schedule, err
+	}
+	webhookURL, err := secrets.Encrypt(schedule.WebhookURL)
+	if err != nil {
+		return schedule, err
+	}
+
+	query := `UPDATE report_schedules SET name = $1, group_by = $2, pivot = $3, metrics = $4, filter = $5, format = $6,
+			  delivery = $7, webhook_url = $8, cron = $9, enabled = $10, next_run_at = $11, updated_at = NOW()
+			  WHERE id = $12 AND user_id = $13 RETURNING ` + reportScheduleColumns
+
+	err = scanReportSchedule(s.db.QueryRowContext(ctx, query, schedule.Name, schedule.GroupBy, schedule.Pivot, pq.Array(schedule.Metrics),
+		filter, schedule.Format, schedule.Delivery, webhookURL, schedule.Cron, schedule.Enabled, schedule.NextRunAt, id, userID), &schedule)
+	if err == sql.ErrNoRows {
+		return schedule, ErrReportScheduleNotFound
+	}
+	return schedule, err
+}
+
+func (s *Service) DeleteReportSchedule(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM report_schedules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrReportScheduleNotFound
+	}
+	return nil
+}
+
+// SendReportSchedule queues a delivery right away, covering the time since
+// the last scheduled one. The schedule's own timing is unchanged.
+func (s *Service) SendReportSchedule(ctx context.Context, userID, id int) (models.Job, error) {
+	schedule, err := s.ReportSchedule(ctx, userID, id)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	start := schedule.CreatedAt
+	if schedule.LastRunAt != nil {
+		start = *schedule.LastRunAt
+	}
+	return s.jobs.Enqueue(ctx, userID, models.JobTypes.Report, reportScheduleJob{ScheduleID: id, Start: start, End: time.Now()})
+}
+
+// RunReportSchedules queues the deliveries of schedules that are due.
+func (s *Service) RunReportSchedules(ctx context.Context) {
+	ticker := time.NewTicker(models.ReportScheduleSettings.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.queueDueReports(ctx, time.Now()); err != nil {
+			log.Printf("Error queueing scheduled reports: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) queueDueReports(ctx context.Context, now time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM report_schedules WHERE enabled AND next_run_at <= $1`, now)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := s.queueReport(ctx, id, now); err != nil {
+			log.Printf("Error queueing report schedule %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// queueReport queues one due delivery and moves the schedule to its next
+// run. A delivery covers the time from the previous scheduled run to this
+// one; runs missed while the server was down are folded into the next
+// delivery rather than sent one by one. The row lock keeps other instances
+// from queueing it twice.
+func (s *Service) queueReport(ctx context.Context, id int, now time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var expr string
+	var due, createdAt time.Time
+	var lastRunAt *time.Time
+	err = tx.QueryRowContext(ctx, `SELECT user_id, cron, next_run_at, last_run_at, created_at FROM report_schedules
+			  WHERE id = $1 AND enabled AND next_run_at <= $2 FOR UPDATE SKIP LOCKED`, id, now).
+		Scan(&userID, &expr, &due, &lastRunAt, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return err
+	}
+	next := schedule.Next(now.In(s.Location(ctx, userID)))
+	if next.IsZero() {
+		if _, err := tx.ExecContext(ctx, `UPDATE report_schedules SET enabled = FALSE, updated_at = NOW() WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	start := createdAt
+	if lastRunAt != nil {
+		start = *lastRunAt
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE report_schedules SET last_run_at = $1, next_run_at = $2 WHERE id = $3`, due, next, id); err != nil {
+		return err
+	}
+	if _, err := s.jobs.Enqueue(ctx, userID, models.JobTypes.Report, reportScheduleJob{ScheduleID: id, Start: start, End: due}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// runReportSchedule builds a scheduled report, keeps the file with the job
+// and delivers it.
+func (s *Service) runReportSchedule(ctx context.Context, job models.Job) (interface{}, error) {
+	var payload reportScheduleJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.ReportSchedule(ctx, job.UserID, payload.ScheduleID)
+	if err == ErrReportScheduleNotFound {
+		return map[string]interface{}{"skipped": "schedule removed"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := payload.Start, payload.End
+	req := models.ReportRequest{
+		GroupBy: schedule.GroupBy,
+		Pivot:   schedule.Pivot,
+		Metrics: schedule.Metrics,
+		Filter: models.TransactionFilter{
+			AccountID:  schedule.Filter.AccountID,
+			CategoryID: schedule.Filter.CategoryID,
+			Type:       schedule.Filter.Type,
+			StartDate:  &start,
+			EndDate:    &end,
+			Scope:      schedule.Filter.Scope,
+		},
+	}
+	report, err := s.Report(ctx, job.UserID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	l, _ := s.localizer(job.UserID)
+	loc := s.Location(ctx, job.UserID)
+	period := start.In(loc).Format("2006-01-02 15:04") + " – " + end.In(loc).Format("2006-01-02 15:04")
+	header, rows := reportTable(report, l)
+
+	stamp := end.In(loc).Format("20060102-1504")
+	attachment := mailer.Attachment{Name: fmt.Sprintf("report-%d-%s.csv", schedule.ID, stamp), ContentType: "text/csv"}
+	if schedule.Format == models.ReportFormats.PDF {
+		attachment.Name = fmt.Sprintf("report-%d-%s.pdf", schedule.ID, stamp)
+		attachment.ContentType = "application/pdf"
+		title, err := templates.Render("reports/scheduled_report", l.Tag().String(), templates.ScheduledReport{Name: schedule.Name, Period: period})
+		if err != nil {
+			return nil, err
+		}
+		attachment.Data = pdf.Text(title, alignReportTable(header, rows))
+	} else {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+		attachment.Data = buf.Bytes()
+	}
+
+	fileID, err := s.jobs.SaveFile(ctx, job.UserID, attachment.Name, attachment.ContentType, attachment.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch schedule.Delivery {
+	case models.ReportDeliveries.Email:
+		var email string
+		if err := s.db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, job.UserID).Scan(&email); err != nil {
+			return nil, err
+		}
+		subject, body, err := templates.Email("scheduled_report", l.Tag().String(), templates.ScheduledReport{Name: schedule.Name, Period: period})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.mailer.SendAttachment(email, subject, body, attachment); err != nil {
+			return nil, err
+		}
+	case models.ReportDeliveries.Webhook:
+		if err := deliverReportWebhook(ctx, schedule, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{"file_id": fileID, "file_name": attachment.Name, "delivery": schedule.Delivery, "rows": len(report.Rows)}, nil
+}
+
+// deliverReportWebhook posts the report file as the request body.
+func deliverReportWebhook(ctx context.Context, schedule models.ReportSchedule, attachment mailer.Attachment) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, schedule.WebhookURL, bytes.NewReader(attachment.Data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", attachment.ContentType)
+	req.Header.Set("Content-Disposition", `attachment; filename="`+attachment.Name+`"`)
+	req.Header.Set("X-Report-Schedule", strconv.Itoa(schedule.ID))
+
+	resp, err := tracing.Do(reportWebhookClient, req, "report webhook")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// reportTable flattens a report into a header and rows ending in a total
+// row. With a pivot every column gets one cell per metric, followed by the
+// row totals.
+func reportTable(report models.Report, l *i18n.Localizer) ([]string, [][]string) {
+	header := []string{l.T(reportLabels[report.GroupBy])}
+	heading := func(label string) {
+		for _, metric := range report.Metrics {
+			if len(report.Metrics) == 1 && label != "" {
+				header = append(header, label)
+			} else {
+				header = append(header, strings.TrimSpace(label+" "+l.T(reportLabels[metric])))
+			}
+		}
+	}
+	for _, column := range report.Columns {
+		heading(reportLabel(column.Label, l))
+	}
+	if report.Pivot != "" {
+		heading(l.T("Total"))
+	} else {
+		heading("")
+	}
+
+	cells := func(values models.ReportValues) []string {
+		out := make([]string, len(report.Metrics))
+		for i, metric := range report.Metrics {
+			if metric == "count" {
+				out[i] = strconv.FormatFloat(values[metric], 'f', 0, 64)
+			} else {
+				out[i] = strconv.FormatFloat(values[metric], 'f', 2, 64)
+			}
+		}
+		return out
+	}
+	row := func(label string, byColumn map[string]models.ReportValues, total models.ReportValues) []string {
+		out := []string{label}
+		for _, column := range report.Columns {
+			out = append(out, cells(byColumn[column.Key])...)
+		}
+		return append(out, cells(total)...)
+	}
+
+	rows := make([][]string, 0, len(report.Rows)+1)
+	for _, r := range report.Rows {
+		rows = append(rows, row(reportLabel(r.Label, l), r.Cells, r.Total))
+	}
+	rows = append(rows, row(l.T("Total"), report.Totals, report.GrandTotal))
+	return header, rows
+}
+
+func reportLabel(label string, l *i18n.Localizer) string {
+	if label == "" {
+		return l.T("(none)")
+	}
+	return label
+}
+
+// alignReportTable lays a table out in fixed-width columns for the PDF,
+// numbers right-aligned, with a rule under the header and above the total.
+func alignReportTable(header []string, rows [][]string) []string {
+	widths := make([]int, len(header))
+	for _, r := range append([][]string{header}, rows...) {
+		for i, cell := range r {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	format := func(r []string) string {
+		parts := make([]string, len(r))
+		for i, cell := range r {
+			pad := strings.Repeat(" ", widths[i]-len([]rune(cell)))
+			if i == 0 {
+				parts[i] = cell + pad
+			} else {
+				parts[i] = pad + cell
+			}
+		}
+		return strings.Join(parts, "  ")
+	}
+
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	rule := strings.Repeat("-", min(total, pdf.MaxLineWidth))
+
+	lines := []string{format(header), rule}
+	for i, r := range rows {
+		if i == len(rows)-1 {
+			lines = append(lines, rule)
+		}
+		lines = append(lines, format(r))
+	}
+	return lines
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// reportDimension is how the report builder groups transactions: the SQL
+// for a group's key and label and the join they need. The month dimension
+// reads the user's timezone from the placeholder %TZ%.
+type reportDimension struct {
+	key, label, join string
+}
+
+var reportDimensions = map[string]reportDimension{
+	"category": {"COALESCE(t.category_id, 0)::text", "COALESCE(c.name, '')",
+		"LEFT JOIN categories c ON c.id = t.category_id"},
+	"account": {"t.account_id::text", "a.name",
+		"JOIN accounts a ON a.id = t.account_id"},
+	// A transaction belongs to the payee with the longest matching text, so
+	// it is counted once even when several payees match.
+	"payee": {"COALESCE(py.id, 0)::text", "COALESCE(py.name, '')",
+		`LEFT JOIN LATERAL (
+				  SELECT p.id, p.name FROM payees p
+				  WHERE p.user_id = t.user_id AND POSITION(LOWER(p.match_text) IN LOWER(COALESCE(t.description, ''))) > 0
+				  ORDER BY LENGTH(p.match_text) DESC, p.id LIMIT 1
+			  ) py ON TRUE`},
+	"tag": {"COALESCE(tg.tag, '')", "COALESCE(tg.tag, '')",
+		"LEFT JOIN LATERAL unnest(t.tags) AS tg(tag) ON TRUE"},
+	"month": {"to_char(t.date AT TIME ZONE %TZ%, 'YYYY-MM')", "to_char(t.date AT TIME ZONE %TZ%, 'YYYY-MM')", ""},
+}
+
+// Report builds a pivot table of the user's income and expenses. Without a
+// type filter amounts are signed, expenses negative, so sums net out; with
+// one they are reported as stored. A transaction with several tags counts
+// under each of them, in the totals too.
+func (s *Service) Report(ctx context.Context, userID int, req models.ReportRequest) (models.Report, error) {
+	report := models.Report{GroupBy: req.GroupBy, Pivot: req.Pivot, Metrics: req.Metrics, Rows: []models.ReportRow{}}
+	if len(report.Metrics) == 0 {
+		report.Metrics = []string{"sum"}
+	}
+	if err := validateReport(req.GroupBy, req.Pivot, report.Metrics); err != nil {
+		return report, err
+	}
+
+	rows := reportDimensions[req.GroupBy]
+	columns := reportDimension{key: "''", label: "''"}
+	if req.Pivot != "" {
+		columns = reportDimensions[req.Pivot]
+	}
+
+	f := req.Filter
+	args := []interface{}{userID, f.AccountID, f.CategoryID, f.Type, f.StartDate, f.EndDate, f.Scope}
+	query := `SELECT ` + rows.key + `, ` + rows.label + `, ` + columns.key + `, ` + columns.label + `,
+			  SUM(CASE WHEN $4::text IS NULL AND t.type = 'expense' THEN -t.amount ELSE t.amount END), COUNT(*)
+			  FROM transactions t
+			  ` + rows.join + `
+			  ` + columns.join + `
+			  WHERE t.user_id = $1 AND t.type IN ('income', 'expense')
+			  AND ($2::int IS NULL OR t.account_id = $2)
+			  AND ($3::int IS NULL OR t.category_id = $3)
+			  AND ($4::text IS NULL OR t.type = $4)
+			  AND ($5::timestamptz IS NULL OR t.date >= $5)
+			  AND ($6::timestamptz IS NULL OR t.date < $6)
+			  AND ($7 = '' OR t.scope = $7)
+			  GROUP BY 1, 2, 3, 4`
+	if strings.Contains(query, "%TZ%") {
+		args = append(args, s.Location(ctx, userID).String())
+		query = strings.ReplaceAll(query, "%TZ%", "$"+strconv.Itoa(len(args)))
+	}
+
+	result, err := s.ReadDB().QueryContext(ctx, query, args...)
+	if err != nil {
+		return report, err
+	}
+	defer result.Close()
+
+	type sums struct {
+		sum   float64
+		count int
+	}
+	cells := make(map[string]map[string]*sums)
+	rowTotals := make(map[string]*sums)
+	columnTotals := make(map[string]*sums)
+	grand := &sums{}
+	var rowOrder, columnOrder []models.ReportColumn
+	add := func(into map[string]*sums, key string, sum float64, count int) {
+		if into[key] == nil {
+			into[key] = &sums{}
+		}
+		into[key].sum += sum
+		into[key].count += count
+	}
+
+	for result.Next() {
+		var row, column models.ReportColumn
+		var sum float64
+		var count int
+		if err := result.Scan(&row.Key, &row.Label, &column.Key, &column.Label, &sum, &count); err != nil {
+			return report, err
+		}
+		if rowTotals[row.Key] == nil {
+			rowOrder = append(rowOrder, row)
+			cells[row.Key] = make(map[string]*sums)
+		}
+		if req.Pivot != "" && columnTotals[column.Key] == nil {
+			columnOrder = append(columnOrder, column)
+		}
+		add(cells[row.Key], column.Key, sum, count)
+		add(rowTotals, row.Key, sum, count)
+		add(columnTotals, column.Key, sum, count)
+		grand.sum += sum
+		grand.count += count
+	}
+	if err := result.Err(); err != nil {
+		return report, err
+	}
+
+	values := func(v *sums) models.ReportValues {
+		out := make(models.ReportValues, len(report.Metrics))
+		for _, metric := range report.Metrics {
+			switch metric {
+			case "sum":
+				out[metric] = v.sum
+			case "count":
+				out[metric] = float64(v.count)
+			case "avg":
+				if v.count > 0 {
+					out[metric] = v.sum / float64(v.count)
+				}
+			}
+		}
+		return out
+	}
+
+	sortReportGroups(rowOrder, req.GroupBy)
+	for _, group := range rowOrder {
+		row := models.ReportRow{Key: group.Key, Label: group.Label, Total: values(rowTotals[group.Key])}
+		if req.Pivot != "" {
+			row.Cells = make(map[string]models.ReportValues, len(cells[group.Key]))
+			for key, cell := range cells[group.Key] {
+				row.Cells[key] = values(cell)
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if req.Pivot != "" {
+		sortReportGroups(columnOrder, req.Pivot)
+		report.Columns = columnOrder
+		report.Totals = make(map[string]models.ReportValues, len(columnTotals))
+		for key, total := range columnTotals {
+			report.Totals[key] = values(total)
+		}
+	}
+	report.GrandTotal = values(grand)
+	return report, nil
+}
+
+func validateReport(groupBy, pivot string, metrics []string) error {
+	for _, metric := range metrics {
+		if metric != "sum" && metric != "count" && metric != "avg" {
+			return ErrReportMetric
+		}
+	}
+	if _, ok := reportDimensions[groupBy]; !ok {
+		return ErrReportDimension
+	}
+	if _, ok := reportDimensions[pivot]; pivot != "" && (!ok || pivot == groupBy) {
+		return ErrReportDimension
+	}
+	return nil
+}
+
+// sortReportGroups orders months chronologically and everything else by
+// label.
+func sortReportGroups(groups []models.ReportColumn, dimension string) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if dimension == "month" {
+			return groups[i].Key < groups[j].Key
+		}
+		return strings.ToLower(groups[i].Label) < strings.ToLower(groups[j].Label)
+	})
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	rollupMinDate = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rollupMaxDate = time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// CategoryTotals sums transactions per category and type for dates in
+// [start, end). Whole months are read from transaction_monthly_rollups and
+// only the partial months at either edge are scanned in transactions. A zero
+// start or end leaves that side unbounded. Months are those of the user's
+// timezone, matching how the rollups are bucketed. A non-empty scope counts
+// only personal or business transactions.
+func (s *Service) CategoryTotals(ctx context.Context, userID int, start, end time.Time, scope string) ([]models.CategoryTotal, error) {
+	loc := s.Location(ctx, userID)
+	if start.IsZero() {
+		start = rollupMinDate
+	} else {
+		start = start.In(loc)
+	}
+	if end.IsZero() {
+		end = rollupMaxDate
+	} else {
+		end = end.In(loc)
+	}
+
+	fullStart := monthStart(start)
+	if fullStart.Before(start) {
+		fullStart = fullStart.AddDate(0, 1, 0)
+	}
+	fullEnd := monthStart(end)
+	if !fullStart.Before(fullEnd) {
+		fullStart, fullEnd = start, start
+	}
+
+	query := `SELECT category_id, type, SUM(total), SUM(transaction_count)
+			  FROM (
+				  SELECT category_id, type, total, transaction_count
+				  FROM transaction_monthly_rollups
+				  WHERE user_id = $1 AND month >= $2::date AND month < $3::date AND ($8 = '' OR scope = $8) AND type IN ('income', 'expense')
+				  UNION ALL
+				  SELECT COALESCE(category_id, 0), type, amount, 1
+				  FROM transactions
+				  WHERE user_id = $1 AND ((date >= $4 AND date < $6) OR (date >= $7 AND date < $5)) AND ($8 = '' OR scope = $8)
+				  AND type IN ('income', 'expense')
+			  ) totals
+			  GROUP BY category_id, type`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, fullStart.Format("2006-01-02"), fullEnd.Format("2006-01-02"),
+		start, end, fullStart, fullEnd, scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.CategoryTotal
+	for rows.Next() {
+		var t models.CategoryTotal
+		if err := rows.Scan(&t.CategoryID, &t.Type, &t.Amount, &t.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// BackfillRollups rebuilds the monthly rollups from transactions, for one user
+// or for everyone when userID is 0.
+func (s *Service) BackfillRollups(ctx context.Context, userID int) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := rebuildRollups(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// rebuildRollups rebuilds the monthly rollups within tx, for one user or for
+// everyone when userID is 0. Writes to the transactions being rebuilt wait
+// until tx commits so the trigger and the rebuild cannot double count. For
+// one user, locking the user's row holds back new transactions, whose foreign
+// key check needs it, and locking their transactions holds back edits; other
+// users are not blocked. A rebuild for everyone locks the table.
+func rebuildRollups(ctx context.Context, tx *sql.Tx, userID int) (int64, error) {
+	if userID == 0 {
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE transactions IN SHARE MODE`); err != nil {
+			return 0, err
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM transactions WHERE user_id = $1 FOR SHARE`, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	owner := sql.NullInt64{Int64: int64(userID), Valid: userID != 0}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_monthly_rollups WHERE $1::int IS NULL OR user_id = $1`, owner); err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO transaction_monthly_rollups (user_id, month, category_id, type, scope, total, transaction_count)
+			  SELECT user_id, user_month(user_id, date), COALESCE(category_id, 0), type, scope, SUM(amount), COUNT(*)
+			  FROM transactions
+			  WHERE $1::int IS NULL OR user_id = $1
+			  GROUP BY 1, 2, 3, 4, 5`
+
+	result, err := tx.ExecContext(ctx, query, owner)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/testdb"
+)
+
+// The benchmarks aggregate two years of history at roughly fourteen
+// transactions a day.
+const (
+	benchmarkDays         = 730
+	benchmarkTransactions = 10000
+)
+
+func BenchmarkCategoryTotals(b *testing.B) {
+	db := testdb.New(b)
+	svc := New(db, nil, events.NewBroker())
+	userID := testdb.Ledger(b, db, benchmarkDays, benchmarkTransactions)
+	ctx := context.Background()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	lastMonth := monthStart(today).AddDate(0, -1, 0)
+	ranges := []struct {
+		name       string
+		start, end time.Time
+	}{
+		// Whole months come from the rollups alone.
+		{"month", lastMonth, lastMonth.AddDate(0, 1, 0)},
+		{"year", lastMonth.AddDate(0, -11, 0), lastMonth.AddDate(0, 1, 0)},
+		// Partial months at either edge are scanned in transactions.
+		{"partial months", today.AddDate(0, -6, -10), today.AddDate(0, 0, -10)},
+		{"all time", time.Time{}, time.Time{}},
+	}
+	for _, r := range ranges {
+		b.Run(r.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.CategoryTotals(ctx, userID, r.start, r.end, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// The query CategoryTotals replaced, as the baseline the rollups are
+	// measured against.
+	b.Run("ledger scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rows, err := db.QueryContext(ctx, `SELECT category_id, type, SUM(amount), COUNT(*)
+					  FROM transactions WHERE user_id = $1 GROUP BY category_id, type`, userID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for rows.Next() {
+			}
+			if err := rows.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetTransactionsExpanded(b *testing.B) {
+	db := testdb.New(b)
+	svc := New(db, nil, events.NewBroker())
+	userID := testdb.Ledger(b, db, benchmarkDays, benchmarkTransactions)
+	ctx := context.Background()
+
+	pages := []struct {
+		name   string
+		offset int
+		expand models.TransactionExpand
+	}{
+		{"first page", 0, models.TransactionExpand{}},
+		{"first page expanded", 0, models.TransactionExpand{Category: true, Account: true}},
+		{"page 100", 99 * 50, models.TransactionExpand{}},
+	}
+	for _, page := range pages {
+		b.Run(page.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := svc.GetTransactionsExpanded(ctx, userID, 50, page.offset, page.expand, "", 0, models.GeoFilter{})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// SafeToSpend sets aside, from the balances of checking, savings and cash
+// accounts, everything scheduled before the next recurring income and what
+// is left of this month's budgets. Without a recurring income the horizon
+// is the default upcoming window.
+func (s *Service) SafeToSpend(ctx context.Context, userID int, now time.Time) (models.SafeToSpend, error) {
+	var result models.SafeToSpend
+
+	feed, err := s.Upcoming(ctx, userID, now, models.UpcomingSettings.MaxDays)
+	if err != nil {
+		return result, err
+	}
+	result.Currency = feed.Currency
+
+	result.Until = feed.From
+	if from, err := time.Parse("2006-01-02", feed.From); err == nil {
+		result.Until = from.AddDate(0, 0, models.UpcomingSettings.DefaultDays).Format("2006-01-02")
+	}
+	for i, t := range feed.Transactions {
+		// Income due today is treated as already received.
+		if t.Type == "income" && t.Date > feed.From {
+			result.NextIncome = &feed.Transactions[i]
+			result.Until = t.Date
+			break
+		}
+	}
+
+	err = s.ReadDB().QueryRowContext(ctx, `SELECT COALESCE(SUM(balance), 0) FROM accounts
+			  WHERE user_id = $1 AND type IN ($2, $3, $4) AND archived_at IS NULL`,
+		userID, models.AccountTypes.Checking, models.AccountTypes.Savings, models.AccountTypes.Cash).Scan(&result.LiquidBalance)
+	if err != nil {
+		return result, err
+	}
+
+	scheduled := map[int]float64{}
+	for _, t := range feed.Transactions {
+		if t.Date >= result.Until || t.Type == "income" {
+			continue
+		}
+		switch t.Source {
+		case models.UpcomingSources.Bill:
+			result.UpcomingBills += t.Amount
+		case models.UpcomingSources.Transfer:
+			result.ScheduledTransfers += t.Amount
+		default:
+			result.ScheduledExpenses += t.Amount
+			if t.CategoryID != nil {
+				scheduled[*t.CategoryID] += t.Amount
+			}
+		}
+	}
+
+	overview, err := s.BudgetOverview(ctx, userID, now)
+	if err != nil {
+		return result, err
+	}
+	for _, status := range overview.Categories {
+		// Scheduled expenses already count against the budget they fall in.
+		result.UnspentBudgets += math.Max(0, status.Remaining-scheduled[status.CategoryID])
+	}
+
+	result.SafeToSpend = math.Round((result.LiquidBalance-result.UpcomingBills-result.ScheduledExpenses-
+		result.ScheduledTransfers-result.UnspentBudgets)*100) / 100
+	return result, nil
+}
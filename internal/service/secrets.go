@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"personal-finance-tracker/internal/secrets"
+)
+
+var encryptedColumns = []struct {
+	table  string
+	column string
+}{
+	{"jwt_signing_keys", "private_key"},
+	{"vapid_keys", "private_key"},
+	{"notification_channels", "webhook_url"},
+	{"report_schedules", "webhook_url"},
+	{"automation_rules", "webhook_url"},
+	{"push_subscriptions", "p256dh"},
+	{"push_subscriptions", "auth"},
+}
+
+// ReencryptSecrets encrypts values still stored in plaintext and re-wraps
+// values whose data key was sealed with a retired master key.
+func (s *Service) ReencryptSecrets(ctx context.Context) (int, error) {
+	updated := 0
+	for _, target := range encryptedColumns {
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, %s FROM %s`, target.column, target.table))
+		if err != nil {
+			return updated, err
+		}
+
+		pending := map[int]string{}
+		for rows.Next() {
+			var id int
+			var value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return updated, err
+			}
+			if secrets.NeedsRewrap(value) {
+				pending[id] = value
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return updated, err
+		}
+
+		for id, value := range pending {
+			rewrapped, err := secrets.Rewrap(value)
+			if err != nil {
+				return updated, fmt.Errorf("%s.%s id %d: %w", target.table, target.column, id, err)
+			}
+
+			query := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE id = $2 AND %s = $3`, target.table, target.column, target.column)
+			if _, err := s.db.ExecContext(ctx, query, rewrapped, id, value); err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"personal-finance-tracker/internal/billing"
+	"personal-finance-tracker/internal/cache"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/insights"
+	"personal-finance-tracker/internal/jobs"
+	"personal-finance-tracker/internal/mailer"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/storage"
+)
+
+var (
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrAccountArchived       = errors.New("account is archived")
+	ErrCategoryNotFound      = errors.New("category not found")
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrPayeeNotFound         = errors.New("payee not found")
+	ErrPayeeExists           = errors.New("a payee with this name already exists")
+	ErrNotInHousehold        = errors.New("you are not a member of a household")
+	ErrMemberExists          = errors.New("user already belongs to a household")
+	ErrInvalidMember         = errors.New("you cannot add yourself to your own household")
+	ErrNestedHousehold       = errors.New("household members cannot have members of their own")
+	ErrApprovalNotFound      = errors.New("pending approval not found")
+	ErrReadOnlyMember        = errors.New("your household role only allows viewing")
+	ErrAllowanceNotFound     = errors.New("allowance rule not found")
+	ErrBudgetNotFound        = errors.New("budget not found")
+	ErrRecurringNotFound     = errors.New("recurring rule not found")
+	ErrBudgetCapExceeded     = errors.New("transaction would exceed the category's budget cap")
+	ErrVATLineMismatch       = errors.New("net and VAT amounts must add up to the gross amount of each line")
+	ErrVATTotalMismatch      = errors.New("VAT lines must add up to the transaction amount")
+	ErrReimbursementNotFound = errors.New("reimbursement not found")
+	ErrReimbursementExists   = errors.New("transaction is already marked as reimbursable")
+	ErrInvalidReimbursement  = errors.New("a reimbursement needs either an expense transaction or a mileage distance, rate and date")
+	ErrReimbursementStatus   = errors.New("reimbursement cannot move to that status")
+	ErrNotIncome             = errors.New("a reimbursement must be linked to an income transaction")
+	ErrContactNotFound       = errors.New("contact not found")
+	ErrContactExists         = errors.New("a contact with this name already exists")
+	ErrSplitsExceedAmount    = errors.New("shares must not add up to more than the transaction amount")
+	ErrSplitNotExpense       = errors.New("only expenses can be split")
+	ErrDuplicateShare        = errors.New("each contact can only have one share")
+	ErrLinkNotFound          = errors.New("linked user not found")
+	ErrLinkExists            = errors.New("you are already linked with this user")
+	ErrInvalidLink           = errors.New("you cannot link with yourself")
+	ErrIOUNotFound           = errors.New("IOU not found")
+	ErrIOUStatus             = errors.New("IOU cannot move to that status")
+	ErrIOUCounterparty       = errors.New("only the other party can confirm or dispute an IOU")
+	ErrCounterpartyAccount   = errors.New("the other party has no account to record the settlement in")
+	ErrImportProfileNotFound = errors.New("import profile not found")
+	ErrImportProfileExists   = errors.New("an import profile with this name already exists")
+	ErrImportProfileColumns  = errors.New("import profile must map date, description and amount columns, or debit and credit columns with the debit_credit sign convention")
+	ErrImportDateFormat      = errors.New("date_format may only combine YYYY, YY, MM and DD with separators")
+	ErrImportSeparators      = errors.New("decimal_separator must be . or , and delimiter a single character")
+	ErrImportAccountRequired = errors.New("account_id is required unless the import profile has a default account")
+	ErrImportNotFound        = errors.New("import not found")
+	ErrImportCommitted       = errors.New("import has already been committed")
+	ErrImportRowNotFound     = errors.New("import row not found or could not be parsed")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrUnlockTokenInvalid    = errors.New("unlock link is invalid or has expired")
+	ErrResourceInUse         = errors.New("resource is still referenced by other records")
+	ErrRecategorizeFilter    = errors.New("recategorizing needs a description, payee_id or from_category_id filter")
+	ErrRecategorizeChanged   = errors.New("the matching transactions changed since the preview")
+	ErrCategoryInUse         = errors.New("category is still used by transactions, budgets or rules; pass reassign_to to move them")
+	ErrMergeSameCategory     = errors.New("a category cannot be merged into itself")
+	ErrCategoryTypeMismatch  = errors.New("categories must have the same type to be merged")
+	ErrCategoryGroupNotFound = errors.New("category group not found")
+	ErrCategoryGroupExists   = errors.New("a category group with this name already exists")
+	ErrUnknownIcon           = errors.New("icon is not in the icon catalog")
+	ErrInvalidColor          = errors.New("color must be a catalog color or a #rrggbb value")
+	ErrColorContrast         = errors.New("color must leave a 4.5:1 contrast with white or dark text")
+	ErrWidgetType            = errors.New("widget type must be summary, category_donut, trend_line, budget_bars or net_worth")
+	ErrWidgetParams          = errors.New("widget params are invalid or out of range")
+	ErrTooManyWidgets        = errors.New("a dashboard holds at most 20 widgets")
+	ErrReportDimension       = errors.New("group_by and pivot must be different values of category, account, payee, tag or month")
+	ErrReportMetric          = errors.New("metrics must be sum, count or avg")
+)
+
+type Service struct {
+	db       *sql.DB
+	replica  *sql.DB
+	notifier *notifications.Dispatcher
+	events   *events.Broker
+	fanout   *events.Fanout
+	outbox   *events.Outbox
+	mailer   mailer.Mailer
+	cache    cache.Store
+	jobs     *jobs.Queue
+	insights *insights.Engine
+	files    storage.Store
+	billing  *billing.Client
+
+	backupsOnce sync.Once
+	backups     *storage.Disk
+}
+
+func New(db *sql.DB, notifier *notifications.Dispatcher, broker *events.Broker) *Service {
+	s := &Service{
+		db:       db,
+		notifier: notifier,
+		events:   broker,
+		fanout:   events.NewFanout(db, broker),
+		outbox:   events.NewOutbox(db),
+		mailer:   mailer.NewFromConfig(config.Get().SMTP),
+		cache:    cache.NewFromConfig(config.Get().Redis, models.CacheSettings.MemoryMaxEntries),
+		jobs:     jobs.NewQueue(db),
+		insights: insights.NewEngine(db),
+		files:    storage.NewFromConfig(config.Get().Storage, db),
+		billing:  billing.NewClientFromConfig(config.Get().Billing),
+	}
+	s.registerJobHandlers()
+	s.registerInsightAnalyzers()
+	s.outbox.Handle("stream", s.publishEvent)
+	s.outbox.Handle("notifications", s.handleEvent)
+	s.outbox.Handle("automations", s.handleAutomationEvent)
+	if notifier != nil {
+		notifier.SetFilter(s.notificationAllowed)
+	}
+	return s
+}
+
+func (s *Service) Events() *events.Broker {
+	return s.events
+}
+
+// Fanout carries stream events between API instances; run it on each.
+func (s *Service) Fanout() *events.Fanout {
+	return s.fanout
+}
+
+func (s *Service) Outbox() *events.Outbox {
+	return s.outbox
+}
+
+func (s *Service) Jobs() *jobs.Queue {
+	return s.jobs
+}
+
+// UseReadReplica routes analytics and reporting reads to a replica. Writes and
+// reads that must see the caller's latest changes keep using the primary.
+func (s *Service) UseReadReplica(replica *sql.DB) {
+	s.replica = replica
+}
+
+func (s *Service) ReadDB() *sql.DB {
+	if s.replica != nil {
+		return s.replica
+	}
+	return s.db
+}
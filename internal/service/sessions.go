@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+)
+
+func (s *Service) CreateSession(ctx context.Context, userID int, userAgent, ipAddress string) (int, string, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return 0, "", err
+	}
+
+	var sessionID int
+	query := `INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, last_seen_at, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, NOW(), $5, NOW()) RETURNING id`
+
+	err = s.db.QueryRowContext(ctx, query, userID, auth.HashToken(refreshToken), userAgent, ipAddress,
+		time.Now().Add(models.SessionSettings.RefreshTokenTTL)).Scan(&sessionID)
+	return sessionID, refreshToken, err
+}
+
+func (s *Service) SessionActive(ctx context.Context, sessionID, userID int) (bool, error) {
+	if _, revoked, err := s.cache.Get(ctx, sessionKey(sessionID, "revoked")); err == nil && revoked {
+		return false, nil
+	}
+	if _, seen, err := s.cache.Get(ctx, sessionKey(sessionID, "active")); err == nil && seen {
+		return true, nil
+	}
+
+	var stale bool
+	query := `SELECT last_seen_at < NOW() - make_interval(secs => $3)
+			  FROM sessions
+			  WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`
+
+	err := s.db.QueryRowContext(ctx, query, sessionID, userID, models.SessionSettings.LastSeenInterval.Seconds()).Scan(&stale)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if stale {
+		if _, err := s.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = NOW() WHERE id = $1`, sessionID); err != nil {
+			return true, err
+		}
+	}
+
+	if err := s.cache.Set(ctx, sessionKey(sessionID, "active"), []byte("1"), models.SessionSettings.LastSeenInterval); err != nil {
+		log.Printf("Error caching session state: %v", err)
+	}
+	return true, nil
+}
+
+func (s *Service) RefreshSession(ctx context.Context, refreshToken, userAgent, ipAddress string) (models.User, int, string, error) {
+	var user models.User
+	var sessionID int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, 0, "", err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT s.id, u.id, u.email, u.first_name, u.last_name, u.created_at, u.updated_at
+			  FROM sessions s JOIN users u ON u.id = s.user_id
+			  WHERE s.refresh_token_hash = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+			  FOR UPDATE OF s`
+
+	err = tx.QueryRowContext(ctx, query, auth.HashToken(refreshToken)).
+		Scan(&sessionID, &user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, 0, "", ErrSessionNotFound
+	}
+	if err != nil {
+		return user, 0, "", err
+	}
+
+	rotated, err := newRefreshToken()
+	if err != nil {
+		return user, 0, "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE sessions SET refresh_token_hash = $1, user_agent = $2, ip_address = $3, last_seen_at = NOW(), expires_at = $4
+			  WHERE id = $5`, auth.HashToken(rotated), userAgent, ipAddress, time.Now().Add(models.SessionSettings.RefreshTokenTTL), sessionID)
+	if err != nil {
+		return user, 0, "", err
+	}
+
+	return user, sessionID, rotated, tx.Commit()
+}
+
+func (s *Service) GetSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	query := `SELECT id, user_id, user_agent, ip_address, last_seen_at, expires_at, revoked_at, created_at
+			  FROM sessions
+			  WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+			  ORDER BY last_seen_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt, &session.CreatedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+
+	s.markSessionRevoked(sessionID)
+	return nil
+}
+
+func (s *Service) RevokeAllSessions(ctx context.Context, userID int) (int64, error) {
+	return s.RevokeOtherSessions(ctx, userID, 0)
+}
+
+// RevokeOtherSessions signs the user out everywhere except the given session.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int) (int64, error) {
+	rows, err := s.db.QueryContext(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL RETURNING id`,
+		userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var revoked int64
+	for rows.Next() {
+		var sessionID int
+		if err := rows.Scan(&sessionID); err != nil {
+			return revoked, err
+		}
+		s.markSessionRevoked(sessionID)
+		revoked++
+	}
+	return revoked, rows.Err()
+}
+
+// markSessionRevoked puts the session on the shared revocation list so every
+// instance rejects its access tokens without waiting for the cached state to
+// expire.
+func (s *Service) markSessionRevoked(sessionID int) {
+	ctx := context.Background()
+	if err := s.cache.Set(ctx, sessionKey(sessionID, "revoked"), []byte("1"), models.SessionSettings.AccessTokenTTL); err != nil {
+		log.Printf("Error adding session %d to revocation list: %v", sessionID, err)
+	}
+	if err := s.cache.Delete(ctx, sessionKey(sessionID, "active")); err != nil {
+		log.Printf("Error clearing cached session %d: %v", sessionID, err)
+	}
+}
+
+func sessionKey(sessionID int, state string) string {
+	return fmt.Sprintf("session:%d:%s", sessionID, state)
+}
+
+func newRefreshToken() (string, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return models.SessionSettings.RefreshTokenPrefix + token, nil
+}
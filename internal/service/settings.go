@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/i18n"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+var ErrInvalidSettings = errors.New("invalid settings")
+
+var (
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	localePattern   = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+)
+
+func defaultSettings() models.UserSettings {
+	return models.UserSettings{
+		BaseCurrency:            models.SettingsDefaults.BaseCurrency,
+		Locale:                  models.SettingsDefaults.Locale,
+		FirstDayOfWeek:          models.SettingsDefaults.FirstDayOfWeek,
+		FiscalMonthStartDay:     models.SettingsDefaults.FiscalMonthStartDay,
+		DateFormat:              models.SettingsDefaults.DateFormat,
+		NotificationPreferences: map[string]bool{},
+		PayPeriod:               models.SettingsDefaults.PayPeriod,
+		Timezone:                models.SettingsDefaults.Timezone,
+	}
+}
+
+// GetSettings returns the user's preferences, falling back to the defaults
+// for users who never saved any.
+func (s *Service) GetSettings(ctx context.Context, userID int) (models.UserSettings, error) {
+	settings := defaultSettings()
+
+	query := `SELECT base_currency, locale, first_day_of_week, fiscal_month_start_day, date_format,
+			  notification_preferences, default_account_id, pay_period, pay_period_anchor, timezone, updated_at
+			  FROM user_settings WHERE user_id = $1`
+
+	var preferences []byte
+	var anchor sql.NullTime
+	var updatedAt time.Time
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&settings.BaseCurrency, &settings.Locale, &settings.FirstDayOfWeek,
+		&settings.FiscalMonthStartDay, &settings.DateFormat, &preferences, &settings.DefaultAccountID,
+		&settings.PayPeriod, &anchor, &settings.Timezone, &updatedAt)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	settings.UpdatedAt = &updatedAt
+	if anchor.Valid {
+		value := anchor.Time.Format("2006-01-02")
+		settings.PayPeriodAnchor = &value
+	}
+	if err := json.Unmarshal(preferences, &settings.NotificationPreferences); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func (s *Service) UpdateSettings(ctx context.Context, userID int, req models.UpdateSettingsRequest) (models.UserSettings, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return settings, err
+	}
+
+	if req.BaseCurrency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*req.BaseCurrency))
+		if !currencyPattern.MatchString(currency) {
+			return settings, fmt.Errorf("%w: base_currency must be a 3-letter ISO 4217 code", ErrInvalidSettings)
+		}
+		settings.BaseCurrency = currency
+	}
+	if req.Locale != nil {
+		if !localePattern.MatchString(*req.Locale) {
+			return settings, fmt.Errorf("%w: locale must be a BCP 47 tag such as en-US or pl-PL", ErrInvalidSettings)
+		}
+		settings.Locale = *req.Locale
+	}
+	if req.FirstDayOfWeek != nil {
+		if *req.FirstDayOfWeek < 0 || *req.FirstDayOfWeek > 6 {
+			return settings, fmt.Errorf("%w: first_day_of_week must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidSettings)
+		}
+		settings.FirstDayOfWeek = *req.FirstDayOfWeek
+	}
+	if req.FiscalMonthStartDay != nil {
+		if *req.FiscalMonthStartDay < 1 || *req.FiscalMonthStartDay > 28 {
+			return settings, fmt.Errorf("%w: fiscal_month_start_day must be between 1 and 28", ErrInvalidSettings)
+		}
+		settings.FiscalMonthStartDay = *req.FiscalMonthStartDay
+	}
+	if req.DateFormat != nil {
+		if _, ok := models.DateFormats[*req.DateFormat]; !ok {
+			return settings, fmt.Errorf("%w: unsupported date_format %q", ErrInvalidSettings, *req.DateFormat)
+		}
+		settings.DateFormat = *req.DateFormat
+	}
+	for kind, enabled := range req.NotificationPreferences {
+		if !configurableNotification(kind) {
+			return settings, fmt.Errorf("%w: unknown notification type %q", ErrInvalidSettings, kind)
+		}
+		settings.NotificationPreferences[kind] = enabled
+	}
+	if req.ClearDefaultAccount {
+		settings.DefaultAccountID = nil
+	} else if req.DefaultAccountID != nil {
+		account, err := s.GetAccount(ctx, userID, *req.DefaultAccountID)
+		if err != nil {
+			return settings, err
+		}
+		if account.ArchivedAt != nil {
+			return settings, ErrAccountArchived
+		}
+		settings.DefaultAccountID = req.DefaultAccountID
+	}
+
+	if req.PayPeriod != nil {
+		switch *req.PayPeriod {
+		case models.PayPeriods.Monthly, models.PayPeriods.Weekly, models.PayPeriods.Biweekly:
+			settings.PayPeriod = *req.PayPeriod
+		default:
+			return settings, fmt.Errorf("%w: pay_period must be monthly, weekly or biweekly", ErrInvalidSettings)
+		}
+	}
+	if req.PayPeriodAnchor != nil {
+		if *req.PayPeriodAnchor == "" {
+			settings.PayPeriodAnchor = nil
+		} else if _, err := time.Parse("2006-01-02", *req.PayPeriodAnchor); err != nil {
+			return settings, fmt.Errorf("%w: pay_period_anchor must use the YYYY-MM-DD format", ErrInvalidSettings)
+		} else {
+			settings.PayPeriodAnchor = req.PayPeriodAnchor
+		}
+	}
+	timezoneChanged := false
+	if req.Timezone != nil && *req.Timezone != settings.Timezone {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" || *req.Timezone == "Local" {
+			return settings, fmt.Errorf("%w: timezone must be an IANA name such as Europe/Warsaw", ErrInvalidSettings)
+		}
+		settings.Timezone = *req.Timezone
+		timezoneChanged = true
+	}
+	if settings.PayPeriod == models.PayPeriods.Biweekly && settings.PayPeriodAnchor == nil {
+		return settings, fmt.Errorf("%w: biweekly pay periods need a pay_period_anchor payday", ErrInvalidSettings)
+	}
+
+	preferences, err := json.Marshal(settings.NotificationPreferences)
+	if err != nil {
+		return settings, err
+	}
+
+	query := `INSERT INTO user_settings (user_id, base_currency, locale, first_day_of_week, fiscal_month_start_day,
+			  date_format, notification_preferences, default_account_id, pay_period, pay_period_anchor, timezone, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET base_currency = EXCLUDED.base_currency, locale = EXCLUDED.locale,
+			  first_day_of_week = EXCLUDED.first_day_of_week, fiscal_month_start_day = EXCLUDED.fiscal_month_start_day,
+			  date_format = EXCLUDED.date_format, notification_preferences = EXCLUDED.notification_preferences,
+			  default_account_id = EXCLUDED.default_account_id, pay_period = EXCLUDED.pay_period,
+			  pay_period_anchor = EXCLUDED.pay_period_anchor, timezone = EXCLUDED.timezone, updated_at = NOW()
+			  RETURNING updated_at`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return settings, err
+	}
+	defer tx.Rollback()
+
+	var updatedAt time.Time
+	err = tx.QueryRowContext(ctx, query, userID, settings.BaseCurrency, settings.Locale, settings.FirstDayOfWeek,
+		settings.FiscalMonthStartDay, settings.DateFormat, preferences, settings.DefaultAccountID,
+		settings.PayPeriod, settings.PayPeriodAnchor, settings.Timezone).Scan(&updatedAt)
+	if err != nil {
+		return settings, err
+	}
+	settings.UpdatedAt = &updatedAt
+
+	// Monthly rollups are bucketed in the user's timezone, so they are
+	// rebuilt before the new one is committed.
+	if timezoneChanged {
+		if _, err := rebuildRollups(ctx, tx, userID); err != nil {
+			return settings, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return settings, err
+	}
+
+	// Cached analytics are bucketed by these settings.
+	s.InvalidateUserCache(userID)
+	return settings, nil
+}
+
+// Location returns the user's timezone, falling back to UTC.
+func (s *Service) Location(ctx context.Context, userID int) *time.Location {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		log.Printf("Error reading settings for user %d: %v", userID, err)
+		return time.UTC
+	}
+	return SettingsLocation(settings)
+}
+
+// localizer returns the user's language and base currency for messages sent
+// outside of a request, such as notifications and emails.
+func (s *Service) localizer(userID int) (*i18n.Localizer, string) {
+	settings, err := s.GetSettings(context.Background(), userID)
+	if err != nil {
+		log.Printf("Error reading settings for user %d: %v", userID, err)
+	}
+	return i18n.New(i18n.Match(settings.Locale)), settings.BaseCurrency
+}
+
+func SettingsLocation(settings models.UserSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func configurableNotification(kind string) bool {
+	if kind == notifications.Types.SecurityAlert {
+		return false
+	}
+	for _, t := range notifications.PushTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationAllowed drops notifications the user switched off. Security
+// alerts cannot be disabled.
+func (s *Service) notificationAllowed(n notifications.Notification) bool {
+	if n.UserID == 0 || !configurableNotification(n.Type) {
+		return true
+	}
+
+	var enabled sql.NullBool
+	err := s.db.QueryRow(`SELECT (notification_preferences->>$2)::boolean FROM user_settings WHERE user_id = $1`,
+		n.UserID, n.Type).Scan(&enabled)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error reading notification preferences: %v", err)
+	}
+	return !enabled.Valid || enabled.Bool
+}
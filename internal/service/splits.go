@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+func (s *Service) Contacts(ctx context.Context, userID int) ([]models.Contact, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, user_id, name, email, created_at, updated_at
+			  FROM contacts WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []models.Contact{}
+	for rows.Next() {
+		var contact models.Contact
+		if err := rows.Scan(&contact.ID, &contact.UserID, &contact.Name, &contact.Email, &contact.CreatedAt, &contact.UpdatedAt); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, rows.Err()
+}
+
+func (s *Service) CreateContact(ctx context.Context, contact *models.Contact) error {
+	query := `INSERT INTO contacts (user_id, name, email, created_at, updated_at)
+			  VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, contact.UserID, contact.Name, contact.Email).
+		Scan(&contact.ID, &contact.CreatedAt, &contact.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrContactExists
+	}
+	return err
+}
+
+func (s *Service) UpdateContact(ctx context.Context, contact *models.Contact) error {
+	query := `UPDATE contacts SET name = $1, email = $2, updated_at = NOW()
+			  WHERE id = $3 AND user_id = $4 RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, contact.Name, contact.Email, contact.ID, contact.UserID).
+		Scan(&contact.CreatedAt, &contact.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrContactNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrContactExists
+	}
+	return err
+}
+
+func (s *Service) DeleteContact(ctx context.Context, userID, contactID int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM contacts WHERE id = $1 AND user_id = $2`, contactID, userID)
+	if isForeignKeyViolation(err) {
+		return ErrResourceInUse
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+func (s *Service) TransactionSplit(ctx context.Context, userID, transactionID int) (models.TransactionSplit, error) {
+	split := models.TransactionSplit{TransactionID: transactionID, Shares: []models.SplitShare{}}
+
+	err := s.db.QueryRowContext(ctx, `SELECT amount FROM transactions WHERE id = $1 AND user_id = $2`, transactionID, userID).
+		Scan(&split.Amount)
+	if err == sql.ErrNoRows {
+		return split, ErrTransactionNotFound
+	}
+	if err != nil {
+		return split, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT s.contact_id, c.name, s.amount
+			  FROM transaction_splits s JOIN contacts c ON c.id = s.contact_id
+			  WHERE s.transaction_id = $1 ORDER BY c.name`, transactionID)
+	if err != nil {
+		return split, err
+	}
+	defer rows.Close()
+
+	split.OwnShare = split.Amount
+	for rows.Next() {
+		var share models.SplitShare
+		if err := rows.Scan(&share.ContactID, &share.ContactName, &share.Amount); err != nil {
+			return split, err
+		}
+		split.OwnShare -= share.Amount
+		split.Shares = append(split.Shares, share)
+	}
+	split.OwnShare = math.Round(split.OwnShare*100) / 100
+	return split, rows.Err()
+}
+
+// SetTransactionSplit replaces the shares contacts owe of an expense the user
+// paid. An equal split rounds each contact's share down to the cent and
+// leaves the remainder with the user.
+func (s *Service) SetTransactionSplit(ctx context.Context, userID, transactionID int, req models.SplitRequest) (models.TransactionSplit, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.TransactionSplit{}, err
+	}
+	defer tx.Rollback()
+
+	var amount float64
+	var transactionType string
+	err = tx.QueryRowContext(ctx, `SELECT amount, type FROM transactions WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		transactionID, userID).Scan(&amount, &transactionType)
+	if err == sql.ErrNoRows {
+		return models.TransactionSplit{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return models.TransactionSplit{}, err
+	}
+	if transactionType != "expense" {
+		return models.TransactionSplit{}, ErrSplitNotExpense
+	}
+
+	shares := req.Shares
+	if req.Equal && len(shares) > 0 {
+		part := math.Floor(amount*100/float64(len(shares)+1)) / 100
+		for i := range shares {
+			shares[i].Amount = part
+		}
+	}
+
+	seen := map[int]bool{}
+	var total float64
+	for _, share := range shares {
+		if seen[share.ContactID] {
+			return models.TransactionSplit{}, ErrDuplicateShare
+		}
+		seen[share.ContactID] = true
+		if err := ensureOwned(ctx, tx, "contacts", share.ContactID, userID); err != nil {
+			return models.TransactionSplit{}, err
+		}
+		total += share.Amount
+	}
+	if math.Round(total*100) > math.Round(amount*100) {
+		return models.TransactionSplit{}, ErrSplitsExceedAmount
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID); err != nil {
+		return models.TransactionSplit{}, err
+	}
+	for _, share := range shares {
+		if share.Amount <= 0 {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO transaction_splits (transaction_id, contact_id, amount) VALUES ($1, $2, $3)`,
+			transactionID, share.ContactID, share.Amount)
+		if err != nil {
+			return models.TransactionSplit{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.TransactionSplit{}, err
+	}
+
+	return s.TransactionSplit(ctx, userID, transactionID)
+}
+
+func (s *Service) SplitSettlements(ctx context.Context, userID, contactID int) ([]models.SplitSettlement, error) {
+	if err := ensureOwned(ctx, s.db, "contacts", contactID, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, contact_id, direction, amount, to_char(date, 'YYYY-MM-DD'), note, created_at
+			  FROM split_settlements WHERE contact_id = $1 AND user_id = $2 ORDER BY date DESC, id DESC`, contactID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settlements := []models.SplitSettlement{}
+	for rows.Next() {
+		var settlement models.SplitSettlement
+		if err := rows.Scan(&settlement.ID, &settlement.ContactID, &settlement.Direction, &settlement.Amount,
+			&settlement.Date, &settlement.Note, &settlement.CreatedAt); err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, settlement)
+	}
+	return settlements, rows.Err()
+}
+
+// RecordSettlement records money received from or paid to a contact. The
+// date defaults to today in the user's timezone.
+func (s *Service) RecordSettlement(ctx context.Context, userID int, settlement *models.SplitSettlement) error {
+	if err := ensureOwned(ctx, s.db, "contacts", settlement.ContactID, userID); err != nil {
+		return err
+	}
+	if settlement.Date == "" {
+		settlement.Date = time.Now().In(s.Location(ctx, userID)).Format("2006-01-02")
+	}
+
+	query := `INSERT INTO split_settlements (user_id, contact_id, direction, amount, date, note, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`
+
+	return s.db.QueryRowContext(ctx, query, userID, settlement.ContactID, settlement.Direction, settlement.Amount,
+		settlement.Date, settlement.Note).Scan(&settlement.ID, &settlement.CreatedAt)
+}
+
+// ContactBalances nets, per contact, the shares they owe against the
+// settlements exchanged so far.
+func (s *Service) ContactBalances(ctx context.Context, userID int) ([]models.ContactBalance, error) {
+	query := `SELECT c.id, c.name,
+			  COALESCE((SELECT SUM(s.amount) FROM transaction_splits s
+				  JOIN transactions t ON t.id = s.transaction_id AND t.user_id = $1
+				  WHERE s.contact_id = c.id), 0),
+			  COALESCE((SELECT SUM(amount) FROM split_settlements WHERE contact_id = c.id AND direction = $2), 0),
+			  COALESCE((SELECT SUM(amount) FROM split_settlements WHERE contact_id = c.id AND direction = $3), 0)
+			  FROM contacts c WHERE c.user_id = $1 ORDER BY c.name`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, models.SettlementDirections.Received, models.SettlementDirections.Paid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []models.ContactBalance{}
+	for rows.Next() {
+		var balance models.ContactBalance
+		if err := rows.Scan(&balance.ContactID, &balance.Name, &balance.Owed, &balance.Received, &balance.Paid); err != nil {
+			return nil, err
+		}
+		balance.Balance = math.Round((balance.Owed-balance.Received+balance.Paid)*100) / 100
+		balances = append(balances, balance)
+	}
+	return balances, rows.Err()
+}
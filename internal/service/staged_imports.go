@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/classifier"
+	"personal-finance-tracker/internal/models"
+)
+
+// importReviewer suggests a category for each staged row and flags rows that
+// repeat a transaction already recorded on the account.
+type importReviewer struct {
+	s          *Service
+	tx         *sql.Tx
+	userID     int
+	accountID  int
+	categories map[string]int
+	models     map[string]*classifier.Model
+	claimed    map[int]bool
+}
+
+func (s *Service) newImportReviewer(ctx context.Context, tx *sql.Tx, userID, accountID int) (*importReviewer, error) {
+	r := &importReviewer{
+		s:          s,
+		tx:         tx,
+		userID:     userID,
+		accountID:  accountID,
+		categories: map[string]int{},
+		models:     map[string]*classifier.Model{},
+		claimed:    map[int]bool{},
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, type FROM categories WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name, categoryType string
+		if err := rows.Scan(&id, &name, &categoryType); err != nil {
+			return nil, err
+		}
+		key := categoryType + "|" + strings.ToLower(name)
+		if _, ok := r.categories[key]; !ok {
+			r.categories[key] = id
+		}
+	}
+	return r, rows.Err()
+}
+
+// review suggests the category the file names when the user already has it,
+// otherwise the classifier's prediction. Rows naming no category get the one
+// SuggestCategory would pick. A row is a duplicate when an existing
+// transaction on the same day has the same amount and type; each transaction
+// is matched once, so a file repeating a payment only flags as many rows as
+// the account already holds.
+func (r *importReviewer) review(ctx context.Context, row *models.StagedImportRow) error {
+	categoryID, named := r.categories[row.Type+"|"+strings.ToLower(row.CategoryName)]
+	if !named {
+		model, err := r.model(ctx, row.Type)
+		if err != nil {
+			return err
+		}
+		if row.CategoryName == "" {
+			categoryID, err = predictCategory(ctx, r.tx, model, r.userID, row.Description, row.Amount, row.Type)
+			if err != nil && err != ErrCategoryNotFound {
+				return err
+			}
+			named = err == nil
+		} else if predictions := model.Predict(row.Description, row.Amount); len(predictions) > 0 {
+			categoryID, named = predictions[0].CategoryID, true
+		}
+	}
+	if named {
+		row.SuggestedCategoryID = &categoryID
+	}
+
+	rows, err := r.tx.QueryContext(ctx, `SELECT id FROM transactions
+			  WHERE user_id = $1 AND account_id = $2 AND type = $3 AND amount = $4 AND date >= $5 AND date < $6
+			  ORDER BY id`, r.userID, r.accountID, row.Type, row.Amount, *row.Date, row.Date.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if !r.claimed[id] {
+			r.claimed[id] = true
+			row.DuplicateOf, row.Include = &id, false
+			break
+		}
+	}
+	return rows.Err()
+}
+
+// model trains the classifier once per transaction type and import.
+func (r *importReviewer) model(ctx context.Context, transactionType string) (*classifier.Model, error) {
+	return r.s.importModel(ctx, r.userID, transactionType, r.models)
+}
+
+func stageImportRow(ctx context.Context, tx *sql.Tx, importID int, row models.StagedImportRow) error {
+	var date *string
+	if row.Date != nil {
+		day := row.Date.Format("2006-01-02")
+		date = &day
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO staged_import_rows (import_id, row_number, date, description, amount, type,
+			  category_name, suggested_category_id, duplicate_of, error, include)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		importID, row.Row, date, row.Description, row.Amount, row.Type, row.CategoryName,
+		row.SuggestedCategoryID, row.DuplicateOf, row.Error, row.Include)
+	return err
+}
+
+// StagedImport returns an import with its rows, in file order.
+func (s *Service) StagedImport(ctx context.Context, userID, id int) (models.StagedImport, error) {
+	var staged models.StagedImport
+	err := s.db.QueryRowContext(ctx, `SELECT id, user_id, account_id, job_id, file_name, status, created_at, committed_at
+			  FROM staged_imports WHERE id = $1 AND user_id = $2`, id, userID).Scan(&staged.ID, &staged.UserID,
+		&staged.AccountID, &staged.JobID, &staged.FileName, &staged.Status, &staged.CreatedAt, &staged.CommittedAt)
+	if err == sql.ErrNoRows {
+		return staged, ErrImportNotFound
+	}
+	if err != nil {
+		return staged, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, row_number, date, description, amount, type, category_name,
+			  suggested_category_id, duplicate_of, error, include, transaction_id
+			  FROM staged_import_rows WHERE import_id = $1 ORDER BY row_number`, id)
+	if err != nil {
+		return staged, err
+	}
+	defer rows.Close()
+
+	staged.Rows = []models.StagedImportRow{}
+	for rows.Next() {
+		var row models.StagedImportRow
+		if err := rows.Scan(&row.ID, &row.Row, &row.Date, &row.Description, &row.Amount, &row.Type, &row.CategoryName,
+			&row.SuggestedCategoryID, &row.DuplicateOf, &row.Error, &row.Include, &row.TransactionID); err != nil {
+			return staged, err
+		}
+		staged.RowsTotal++
+		if row.DuplicateOf != nil {
+			staged.Duplicates++
+		}
+		if row.Error != "" {
+			staged.Failed++
+		}
+		staged.Rows = append(staged.Rows, row)
+	}
+	return staged, rows.Err()
+}
+
+// CommitImport applies the user's edits and writes the included rows to
+// transactions. Like imports before staging, it runs in one database
+// transaction with a savepoint per row, so a failing row is reported without
+// stopping the rest and an import can only be committed once.
+func (s *Service) CommitImport(ctx context.Context, userID, id int, edits []models.ImportRowEdit) (models.ImportProgress, error) {
+	var progress models.ImportProgress
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return progress, err
+	}
+	defer tx.Rollback()
+
+	var accountID int
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT account_id, status FROM staged_imports WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		id, userID).Scan(&accountID, &status)
+	if err == sql.ErrNoRows {
+		return progress, ErrImportNotFound
+	}
+	if err != nil {
+		return progress, err
+	}
+	if status != models.ImportStatuses.Staged {
+		return progress, ErrImportCommitted
+	}
+
+	for _, edit := range edits {
+		if edit.CategoryID != nil {
+			if err := ensureOwned(ctx, tx, "categories", *edit.CategoryID, userID); err != nil {
+				return progress, err
+			}
+		}
+		result, err := tx.ExecContext(ctx, `UPDATE staged_import_rows SET include = COALESCE($1, include),
+				  suggested_category_id = COALESCE($2, suggested_category_id), description = COALESCE($3, description),
+				  date = COALESCE($4::date, date), amount = COALESCE($5, amount), type = COALESCE($6, type)
+				  WHERE id = $7 AND import_id = $8 AND error = ''`,
+			edit.Include, edit.CategoryID, edit.Description, edit.Date, edit.Amount, edit.Type, edit.ID, id)
+		if err != nil {
+			return progress, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return progress, ErrImportRowNotFound
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, row_number, date, description, amount, type, category_name, suggested_category_id
+			  FROM staged_import_rows WHERE import_id = $1 AND include AND error = '' ORDER BY row_number`, id)
+	if err != nil {
+		return progress, err
+	}
+	var included []models.StagedImportRow
+	for rows.Next() {
+		var row models.StagedImportRow
+		if err := rows.Scan(&row.ID, &row.Row, &row.Date, &row.Description, &row.Amount, &row.Type, &row.CategoryName,
+			&row.SuggestedCategoryID); err != nil {
+			rows.Close()
+			return progress, err
+		}
+		included = append(included, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return progress, err
+	}
+
+	if err := checkTransactionQuota(ctx, tx, userID, int64(len(included))); err != nil {
+		return progress, err
+	}
+
+	progress.RowsTotal = len(included)
+	categories := make(map[string]int)
+	trained := make(map[string]*classifier.Model)
+	loc := s.Location(ctx, userID)
+
+	for _, row := range included {
+		// Staged dates are calendar days; transactions start at midnight
+		// in the user's timezone, as they do when parsed from the file.
+		year, month, day := row.Date.Date()
+		t := models.Transaction{
+			UserID:      userID,
+			AccountID:   accountID,
+			Date:        time.Date(year, month, day, 0, 0, 0, 0, loc),
+			Description: row.Description,
+			Amount:      row.Amount,
+			Type:        row.Type,
+		}
+		if row.SuggestedCategoryID != nil {
+			t.CategoryID = *row.SuggestedCategoryID
+		}
+
+		progress.RowsProcessed++
+		if err := s.importRow(ctx, tx, &t, row.CategoryName, categories, trained); err != nil {
+			progress.Failed++
+			if len(progress.Errors) < models.ImportSettings.MaxReportedErrors {
+				progress.Errors = append(progress.Errors, models.ImportRowError{Row: row.Row, Error: err.Error()})
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE staged_import_rows SET transaction_id = $1 WHERE id = $2`, t.ID, row.ID); err != nil {
+			return progress, err
+		}
+		progress.Imported++
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE staged_imports SET status = $1, committed_at = NOW() WHERE id = $2`,
+		models.ImportStatuses.Committed, id); err != nil {
+		return progress, err
+	}
+	if progress.Imported > 0 {
+		if err := recordBalance(ctx, tx, userID, accountID); err != nil {
+			return progress, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return progress, err
+	}
+
+	if progress.Imported > 0 {
+		s.InvalidateUserCache(userID)
+		s.outbox.Wake()
+	}
+	return progress, nil
+}
+
+// DiscardImport drops a staged import. Transactions of a committed import
+// are kept; only the review record goes.
+func (s *Service) DiscardImport(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM staged_imports WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrImportNotFound
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+// merchantExpr names the merchant of a transaction: the payee it belongs to,
+// or else its description.
+const merchantExpr = `COALESCE(py.name, NULLIF(TRIM(t.description), ''))`
+
+// subscriptionPeriods are the intervals a subscription can repeat at, in days.
+var subscriptionPeriods = []struct {
+	frequency string
+	days      float64
+}{
+	{models.RecurrenceFrequencies.Weekly, 7},
+	{models.RecurrenceFrequencies.Biweekly, 14},
+	{models.RecurrenceFrequencies.Monthly, 30.44},
+	{models.RecurrenceFrequencies.Yearly, 365.25},
+}
+
+type subscriptionCharge struct {
+	id     int
+	date   time.Time
+	amount float64
+}
+
+// merchantHistory holds the charges of one merchant, oldest first. Merchants
+// differing only in case are the same.
+type merchantHistory struct {
+	merchant string
+	charges  []subscriptionCharge
+}
+
+// subscriptionMatch is a detected subscription with the charges it was found
+// from and the count of other charges at the same merchant.
+type subscriptionMatch struct {
+	models.Subscription
+	streak []subscriptionCharge
+	others int
+}
+
+// Subscriptions lists the charges repeating at a regular interval over the
+// last months that are still running, by merchant.
+func (s *Service) Subscriptions(ctx context.Context, userID int, now time.Time) ([]models.Subscription, error) {
+	matches, err := s.activeSubscriptions(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]models.Subscription, 0, len(matches))
+	for _, match := range matches {
+		subscriptions = append(subscriptions, match.Subscription)
+	}
+	return subscriptions, nil
+}
+
+// ForgottenSubscriptions flags the subscriptions likely paid without being
+// used: small, long-running monthly charges that never changed, at merchants
+// the user spends nothing else at.
+func (s *Service) ForgottenSubscriptions(ctx context.Context, userID int, now time.Time) (models.ForgottenSubscriptions, error) {
+	forgotten := models.ForgottenSubscriptions{Subscriptions: []models.ForgottenSubscription{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return forgotten, err
+	}
+	forgotten.Currency = settings.BaseCurrency
+
+	matches, err := s.activeSubscriptions(ctx, userID, now)
+	if err != nil {
+		return forgotten, err
+	}
+	for _, match := range matches {
+		first, last := match.streak[0].date, match.streak[len(match.streak)-1].date
+		months := int(last.Sub(first).Hours() / 24 / 30.44)
+		if match.Frequency != models.RecurrenceFrequencies.Monthly || len(match.PriceChanges) > 0 || match.others > 0 ||
+			match.Amount > models.SubscriptionSettings.ForgottenMaxAmount || months < models.SubscriptionSettings.ForgottenMinMonths {
+			continue
+		}
+
+		annual := math.Round(match.Amount*12*100) / 100
+		forgotten.AnnualCost += annual
+		forgotten.Subscriptions = append(forgotten.Subscriptions, models.ForgottenSubscription{
+			Subscription:  match.Subscription,
+			MonthsRunning: months,
+			AnnualCost:    annual,
+		})
+	}
+
+	sort.SliceStable(forgotten.Subscriptions, func(i, j int) bool {
+		return forgotten.Subscriptions[i].AnnualCost > forgotten.Subscriptions[j].AnnualCost
+	})
+	forgotten.AnnualCost = math.Round(forgotten.AnnualCost*100) / 100
+	return forgotten, nil
+}
+
+// activeSubscriptions detects the subscriptions still running, by merchant.
+func (s *Service) activeSubscriptions(ctx context.Context, userID int, now time.Time) ([]subscriptionMatch, error) {
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := SettingsLocation(settings)
+	today := calendarDay(now, loc)
+
+	merchants, err := merchantCharges(ctx, s.ReadDB(), userID, now.AddDate(0, -models.SubscriptionSettings.LookbackMonths, 0), now, "", loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []subscriptionMatch
+	for _, m := range merchants {
+		frequency, streak, ok := detectSubscription(m.charges)
+		if !ok {
+			continue
+		}
+		// A subscription missing a whole period past its due date has ended.
+		next := nextCharge(streak[len(streak)-1].date, frequency)
+		if nextCharge(next, frequency).Before(today) {
+			continue
+		}
+
+		last := streak[len(streak)-1]
+		matches = append(matches, subscriptionMatch{
+			Subscription: models.Subscription{
+				Merchant:     m.merchant,
+				Frequency:    frequency,
+				Amount:       last.amount,
+				Currency:     settings.BaseCurrency,
+				ChargeCount:  len(streak),
+				FirstCharge:  streak[0].date.Format("2006-01-02"),
+				LastCharge:   last.date.Format("2006-01-02"),
+				NextCharge:   next.Format("2006-01-02"),
+				PriceChanges: subscriptionPriceChanges(streak),
+			},
+			streak: streak,
+			others: len(m.charges) - len(streak),
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Merchant < matches[j].Merchant
+	})
+	return matches, nil
+}
+
+// merchantCharges reads with db the expenses between from and to grouped by
+// merchant, only those of merchant unless it is empty. Dates are calendar
+// days in loc.
+func merchantCharges(ctx context.Context, db *sql.DB, userID int, from, to time.Time, merchant string, loc *time.Location) ([]merchantHistory, error) {
+	query := `SELECT merchant, id, date, amount FROM (
+				  SELECT ` + merchantExpr + ` AS merchant, t.id, t.date, t.amount
+				  FROM transactions t
+				  ` + reportDimensions["payee"].join + `
+				  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date <= $3
+			  ) m
+			  WHERE merchant IS NOT NULL AND ($4 = '' OR LOWER(merchant) = LOWER($4))
+			  ORDER BY date, id`
+
+	rows, err := db.QueryContext(ctx, query, userID, from, to, merchant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merchants []merchantHistory
+	index := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var charge subscriptionCharge
+		if err := rows.Scan(&name, &charge.id, &charge.date, &charge.amount); err != nil {
+			return nil, err
+		}
+		charge.date = calendarDay(charge.date, loc)
+
+		key := strings.ToLower(name)
+		i, ok := index[key]
+		if !ok {
+			i = len(merchants)
+			index[key] = i
+			merchants = append(merchants, merchantHistory{merchant: name})
+		}
+		merchants[i].charges = append(merchants[i].charges, charge)
+	}
+	return merchants, rows.Err()
+}
+
+// detectSubscription finds the frequency the latest charges repeat at and
+// returns the run of charges keeping to it. Regular shopping can repeat too,
+// so most charges in the run must also cost what the one before did.
+func detectSubscription(charges []subscriptionCharge) (string, []subscriptionCharge, bool) {
+	frequency, streak, ok := detectCadence(charges)
+	if !ok {
+		return "", nil, false
+	}
+
+	steady := 0
+	for i := 1; i < len(streak); i++ {
+		if math.Abs(percentChange(streak[i-1].amount, streak[i].amount)) <= models.SubscriptionSettings.PriceChangePercent {
+			steady++
+		}
+	}
+	if steady*2 < len(streak)-1 {
+		return "", nil, false
+	}
+	return frequency, streak, true
+}
+
+// detectCadence finds the frequency the latest charges repeat at, whatever
+// they cost, and returns the run of charges keeping to it.
+func detectCadence(charges []subscriptionCharge) (string, []subscriptionCharge, bool) {
+	var frequency string
+	best := 0
+	for _, period := range subscriptionPeriods {
+		n := 1
+		for i := len(charges) - 1; i > 0; i-- {
+			gap := charges[i].date.Sub(charges[i-1].date).Hours() / 24
+			if math.Abs(gap-period.days) > period.days*models.SubscriptionSettings.IntervalTolerance {
+				break
+			}
+			n++
+		}
+		if n > best {
+			frequency, best = period.frequency, n
+		}
+	}
+	if best < models.SubscriptionSettings.MinCharges {
+		return "", nil, false
+	}
+	return frequency, charges[len(charges)-best:], true
+}
+
+// subscriptionPriceChanges lists the charges costing more or less than the
+// one before them by over the tolerance.
+func subscriptionPriceChanges(streak []subscriptionCharge) []models.SubscriptionPriceChange {
+	changes := []models.SubscriptionPriceChange{}
+	for i := 1; i < len(streak); i++ {
+		previous, charge := streak[i-1], streak[i]
+		percent := percentChange(previous.amount, charge.amount)
+		if math.Abs(percent) <= models.SubscriptionSettings.PriceChangePercent {
+			continue
+		}
+		changes = append(changes, models.SubscriptionPriceChange{
+			TransactionID:  charge.id,
+			Date:           charge.date.Format("2006-01-02"),
+			PreviousAmount: previous.amount,
+			Amount:         charge.amount,
+			Change:         math.Round((charge.amount-previous.amount)*100) / 100,
+			ChangePercent:  math.Round(percent*10) / 10,
+		})
+	}
+	return changes
+}
+
+// checkSubscriptionPrice notifies the user when an expense is the next charge
+// of a subscription and costs more than the charge before it.
+func (s *Service) checkSubscriptionPrice(ctx context.Context, t models.Transaction) error {
+	var merchant sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT `+merchantExpr+`
+			  FROM transactions t
+			  `+reportDimensions["payee"].join+`
+			  WHERE t.id = $1 AND t.user_id = $2`, t.ID, t.UserID).Scan(&merchant)
+	if err == sql.ErrNoRows || (err == nil && !merchant.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.GetSettings(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	from := t.Date.AddDate(0, -models.SubscriptionSettings.LookbackMonths, 0)
+	merchants, err := merchantCharges(ctx, s.db, t.UserID, from, t.Date, merchant.String, SettingsLocation(settings))
+	if err != nil || len(merchants) == 0 {
+		return err
+	}
+
+	charges := merchants[0].charges
+	for i, charge := range charges {
+		if charge.id != t.ID {
+			continue
+		}
+		frequency, streak, ok := detectSubscription(charges[:i+1])
+		if !ok {
+			return nil
+		}
+		previous := streak[len(streak)-2]
+		percent := percentChange(previous.amount, charge.amount)
+		if percent <= models.SubscriptionSettings.PriceChangePercent {
+			return nil
+		}
+
+		l, currency := s.localizer(t.UserID)
+		s.notifier.Dispatch(notifications.Notification{
+			UserID: t.UserID,
+			Type:   notifications.Types.PriceIncrease,
+			Title:  l.T("Price increase: %s", merchants[0].merchant),
+			Message: l.T("%s now charges %s instead of %s, %s%% more.", merchants[0].merchant,
+				l.Amount(charge.amount, currency), l.Amount(previous.amount, currency), l.Number(percent, 1)),
+			Data: map[string]interface{}{
+				"transaction_id":  t.ID,
+				"merchant":        merchants[0].merchant,
+				"frequency":       frequency,
+				"previous_amount": previous.amount,
+				"amount":          charge.amount,
+			},
+		})
+		return nil
+	}
+	return nil
+}
+
+func percentChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
+
+// calendarDay is the day of t in loc as midnight UTC, so days between dates
+// can be counted without daylight saving time shifts.
+func calendarDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func nextCharge(date time.Time, frequency string) time.Time {
+	switch frequency {
+	case models.RecurrenceFrequencies.Weekly:
+		return date.AddDate(0, 0, 7)
+	case models.RecurrenceFrequencies.Biweekly:
+		return date.AddDate(0, 0, 14)
+	case models.RecurrenceFrequencies.Yearly:
+		return addMonths(date, 12)
+	}
+	return addMonths(date, 1)
+}
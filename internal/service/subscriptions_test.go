@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"personal-finance-tracker/internal/models"
+)
+
+func charges(amounts []float64, dates ...string) []subscriptionCharge {
+	var list []subscriptionCharge
+	for i, date := range dates {
+		list = append(list, subscriptionCharge{id: i + 1, date: day(date), amount: amounts[i]})
+	}
+	return list
+}
+
+func TestDetectSubscription(t *testing.T) {
+	tests := []struct {
+		name      string
+		charges   []subscriptionCharge
+		frequency string
+		streak    int
+		ok        bool
+	}{
+		{"monthly", charges([]float64{9.99, 9.99, 9.99, 9.99}, "2026-01-05", "2026-02-05", "2026-03-05", "2026-04-05"), models.RecurrenceFrequencies.Monthly, 4, true},
+		{"weekly", charges([]float64{5, 5, 5}, "2026-03-02", "2026-03-09", "2026-03-17"), models.RecurrenceFrequencies.Weekly, 3, true},
+		{"too few charges", charges([]float64{9.99, 9.99}, "2026-01-05", "2026-02-05"), "", 0, false},
+		{"off-cadence history", charges([]float64{9.99, 9.99, 9.99, 9.99}, "2025-06-20", "2026-01-05", "2026-02-05", "2026-03-05"), models.RecurrenceFrequencies.Monthly, 3, true},
+		{"price increase", charges([]float64{9.99, 9.99, 9.99, 12.99}, "2026-01-05", "2026-02-05", "2026-03-05", "2026-04-05"), models.RecurrenceFrequencies.Monthly, 4, true},
+		{"varying amounts", charges([]float64{10, 50, 20, 80}, "2026-01-05", "2026-02-05", "2026-03-05", "2026-04-05"), "", 0, false},
+		{"irregular dates", charges([]float64{9.99, 9.99, 9.99}, "2026-01-05", "2026-01-20", "2026-03-30"), "", 0, false},
+	}
+
+	for _, tt := range tests {
+		frequency, streak, ok := detectSubscription(tt.charges)
+		if ok != tt.ok || frequency != tt.frequency || len(streak) != tt.streak {
+			t.Errorf("%s: detectSubscription() = %q, %d charges, %v, want %q, %d charges, %v", tt.name, frequency, len(streak), ok, tt.frequency, tt.streak, tt.ok)
+		}
+	}
+}
+
+func TestSubscriptionPriceChanges(t *testing.T) {
+	streak := charges([]float64{9.99, 10.29, 12.99, 9.99}, "2026-01-05", "2026-02-05", "2026-03-05", "2026-04-05")
+
+	changes := subscriptionPriceChanges(streak)
+	want := []models.SubscriptionPriceChange{
+		{TransactionID: 3, Date: "2026-03-05", PreviousAmount: 10.29, Amount: 12.99, Change: 2.7, ChangePercent: 26.2},
+		{TransactionID: 4, Date: "2026-04-05", PreviousAmount: 12.99, Amount: 9.99, Change: -3, ChangePercent: -23.1},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("subscriptionPriceChanges() = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestNextCharge(t *testing.T) {
+	tests := []struct {
+		date      string
+		frequency string
+		want      string
+	}{
+		{"2026-03-02", models.RecurrenceFrequencies.Weekly, "2026-03-09"},
+		{"2026-03-02", models.RecurrenceFrequencies.Biweekly, "2026-03-16"},
+		{"2026-01-15", models.RecurrenceFrequencies.Monthly, "2026-02-15"},
+		{"2026-01-31", models.RecurrenceFrequencies.Monthly, "2026-02-28"},
+		{"2024-02-29", models.RecurrenceFrequencies.Yearly, "2025-02-28"},
+	}
+
+	for _, tt := range tests {
+		got := nextCharge(day(tt.date), tt.frequency).Format("2006-01-02")
+		if got != tt.want {
+			t.Errorf("nextCharge(%s, %s) = %s, want %s", tt.date, tt.frequency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// SetTransactionTax overrides the tax treatment of a single transaction.
+func (s *Service) SetTransactionTax(ctx context.Context, userID int, tax *models.TransactionTax) error {
+	err := s.db.QueryRowContext(ctx, `UPDATE transactions SET tax_deductible = $1, tax_code = $2, updated_at = NOW()
+			  WHERE id = $3 AND user_id = $4 RETURNING id`,
+		tax.TaxDeductible, tax.TaxCode, tax.TransactionID, userID).Scan(&tax.TransactionID)
+	if err == sql.ErrNoRows {
+		return ErrTransactionNotFound
+	}
+	if err == nil {
+		s.InvalidateUserCache(userID)
+	}
+	return err
+}
+
+// TaxReport lists the deductible expenses of a calendar year in the user's
+// timezone, grouped by tax code. A transaction's own tax fields win over
+// those of its category. A non-empty scope keeps only personal or business
+// expenses.
+func (s *Service) TaxReport(ctx context.Context, userID, year int, scope string) (models.TaxReport, error) {
+	report := models.TaxReport{Year: year, Scope: scope, Codes: []models.TaxCodeSummary{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+	report.Currency = settings.BaseCurrency
+	loc := SettingsLocation(settings)
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+
+	query := `SELECT COALESCE(t.tax_code, c.tax_code, ''), t.id, t.date, COALESCE(t.description, ''), c.name, a.name, t.amount
+			  FROM transactions t
+			  JOIN categories c ON c.id = t.category_id
+			  JOIN accounts a ON a.id = t.account_id
+			  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date < $3
+			  AND COALESCE(t.tax_deductible, c.tax_deductible) AND ($4 = '' OR t.scope = $4)
+			  ORDER BY 1, t.date, t.id`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, start, start.AddDate(1, 0, 0), scope)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code string
+		var date time.Time
+		var item models.TaxReportItem
+		if err := rows.Scan(&code, &item.TransactionID, &date, &item.Description, &item.Category, &item.Account, &item.Amount); err != nil {
+			return report, err
+		}
+		item.Date = date.In(loc).Format("2006-01-02")
+
+		if n := len(report.Codes); n == 0 || report.Codes[n-1].TaxCode != code {
+			report.Codes = append(report.Codes, models.TaxCodeSummary{TaxCode: code})
+		}
+		summary := &report.Codes[len(report.Codes)-1]
+		summary.Total += item.Amount
+		summary.Count++
+		summary.Transactions = append(summary.Transactions, item)
+		report.Total += item.Amount
+	}
+	return report, rows.Err()
+}
+
+// TaxReportCSV renders a tax report one expense per row with a subtotal
+// after each tax code, the layout accountants usually ask for.
+func TaxReportCSV(report models.TaxReport) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Tax code", "Date", "Description", "Category", "Account", "Amount", "Currency"})
+
+	amount := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	for _, summary := range report.Codes {
+		for _, item := range summary.Transactions {
+			writer.Write([]string{summary.TaxCode, item.Date, item.Description, item.Category, item.Account, amount(item.Amount), report.Currency})
+		}
+		writer.Write([]string{summary.TaxCode, "", "Subtotal", "", "", amount(summary.Total), report.Currency})
+	}
+	writer.Write([]string{"", "", "Total", "", "", amount(report.Total), report.Currency})
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// tenantTables lists every user-owned table together with the error returned
+// when a row is missing or belongs to someone else; the two cases are
+// deliberately indistinguishable to the caller.
+var tenantTables = map[string]error{
+	"accounts":        ErrAccountNotFound,
+	"categories":      ErrCategoryNotFound,
+	"category_groups": ErrCategoryGroupNotFound,
+	"transactions":    ErrTransactionNotFound,
+	"contacts":        ErrContactNotFound,
+}
+
+func ensureOwned(ctx context.Context, q queryRower, table string, id, userID int) error {
+	notFound, ok := tenantTables[table]
+	if !ok {
+		return fmt.Errorf("ownership check on unknown table %q", table)
+	}
+
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND user_id = $2)`, table)
+	if err := q.QueryRowContext(ctx, query, id, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return notFound
+	}
+	return nil
+}
+
+func (s *Service) EnsureOwned(ctx context.Context, table string, id, userID int) error {
+	return ensureOwned(ctx, s.db, table, id, userID)
+}
+
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
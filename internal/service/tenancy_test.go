@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/testdb"
+
+	"github.com/lib/pq"
+)
+
+func TestMain(m *testing.M) {
+	testdb.Main(m)
+}
+
+// tenants holds two users, each with an account, a category and a
+// transaction of their own.
+type tenants struct {
+	db  *sql.DB
+	svc *Service
+
+	ada, bob                       int
+	adaAccount, adaCategory, adaTx int
+	bobAccount, bobCategory, bobTx int
+}
+
+func newTenants(t *testing.T) *tenants {
+	t.Helper()
+	db := testdb.New(t)
+	f := &tenants{db: db, svc: New(db, nil, events.NewBroker())}
+	f.ada, f.adaAccount, f.adaCategory, f.adaTx = f.addUser(t, "ada@example.com")
+	f.bob, f.bobAccount, f.bobCategory, f.bobTx = f.addUser(t, "bob@example.com")
+	return f
+}
+
+func (f *tenants) addUser(t *testing.T, email string) (userID, accountID, categoryID, transactionID int) {
+	t.Helper()
+	err := f.db.QueryRow(`INSERT INTO users (email, password_hash) VALUES ($1, '') RETURNING id`, email).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := models.Account{UserID: userID, Name: "Checking", Type: models.AccountTypes.Checking, Currency: "USD", Balance: 100}
+	if err := f.svc.CreateAccount(context.Background(), &account); err != nil {
+		t.Fatal(err)
+	}
+	category := models.Category{UserID: userID, Name: "Groceries", Type: "expense"}
+	if err := f.svc.CreateCategory(context.Background(), &category); err != nil {
+		t.Fatal(err)
+	}
+	transaction := models.Transaction{UserID: userID, AccountID: account.ID, CategoryID: category.ID,
+		Amount: 10, Type: "expense", Date: day("2026-03-14")}
+	if err := f.svc.CreateTransaction(context.Background(), &transaction, false); err != nil {
+		t.Fatal(err)
+	}
+	return userID, account.ID, category.ID, transaction.ID
+}
+
+// TestCrossTenantAccess has Bob read, update and delete Ada's records
+// through the service. Each attempt must fail as if the record did not
+// exist and leave it as it was.
+func TestCrossTenantAccess(t *testing.T) {
+	f := newTenants(t)
+	ctx := context.Background()
+
+	if _, err := f.svc.GetAccount(ctx, f.bob, f.adaAccount); err != ErrAccountNotFound {
+		t.Errorf("GetAccount() of another user's account = %v, want ErrAccountNotFound", err)
+	}
+	for table, id := range map[string]int{"accounts": f.adaAccount, "categories": f.adaCategory, "transactions": f.adaTx} {
+		if err := f.svc.EnsureOwned(ctx, table, id, f.bob); err != tenantTables[table] {
+			t.Errorf("EnsureOwned(%s) of another user's row = %v, want %v", table, err, tenantTables[table])
+		}
+	}
+	categories, err := f.svc.GetCategories(ctx, f.bob, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range categories {
+		if category.UserID != f.bob {
+			t.Errorf("GetCategories() listed category %d of user %d", category.ID, category.UserID)
+		}
+	}
+	transactions, err := f.svc.GetTransactions(ctx, f.bob, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transaction := range transactions {
+		if transaction.UserID != f.bob {
+			t.Errorf("GetTransactions() listed transaction %d of user %d", transaction.ID, transaction.UserID)
+		}
+	}
+
+	account := models.Account{ID: f.adaAccount, UserID: f.bob, Name: "Mine now", Type: models.AccountTypes.Checking, Currency: "USD"}
+	if err := f.svc.UpdateAccount(ctx, &account); err != ErrAccountNotFound {
+		t.Errorf("UpdateAccount() of another user's account = %v, want ErrAccountNotFound", err)
+	}
+	if _, err := f.svc.ArchiveAccount(ctx, f.bob, f.adaAccount, true); err != ErrAccountNotFound {
+		t.Errorf("ArchiveAccount() of another user's account = %v, want ErrAccountNotFound", err)
+	}
+	category := models.Category{ID: f.adaCategory, UserID: f.bob, Name: "Mine now", Type: "expense"}
+	if err := f.svc.UpdateCategory(ctx, &category); err != ErrCategoryNotFound {
+		t.Errorf("UpdateCategory() of another user's category = %v, want ErrCategoryNotFound", err)
+	}
+	transaction := models.Transaction{ID: f.adaTx, UserID: f.bob, AccountID: f.bobAccount, CategoryID: f.bobCategory,
+		Amount: 1, Type: "expense", Date: day("2026-03-15")}
+	if err := f.svc.UpdateTransaction(ctx, &transaction); err != ErrTransactionNotFound {
+		t.Errorf("UpdateTransaction() of another user's transaction = %v, want ErrTransactionNotFound", err)
+	}
+
+	moves := []struct {
+		name                  string
+		accountID, categoryID int
+		want                  error
+	}{
+		{"onto another user's account", f.adaAccount, f.bobCategory, ErrAccountNotFound},
+		{"into another user's category", f.bobAccount, f.adaCategory, ErrCategoryNotFound},
+	}
+	for _, move := range moves {
+		created := models.Transaction{UserID: f.bob, AccountID: move.accountID, CategoryID: move.categoryID,
+			Amount: 1, Type: "expense", Date: day("2026-03-15")}
+		if err := f.svc.CreateTransaction(ctx, &created, false); err != move.want {
+			t.Errorf("CreateTransaction() %s = %v, want %v", move.name, err, move.want)
+		}
+		updated := models.Transaction{ID: f.bobTx, UserID: f.bob, AccountID: move.accountID, CategoryID: move.categoryID,
+			Amount: 1, Type: "expense", Date: day("2026-03-15")}
+		if err := f.svc.UpdateTransaction(ctx, &updated); err != move.want {
+			t.Errorf("UpdateTransaction() %s = %v, want %v", move.name, err, move.want)
+		}
+	}
+
+	if err := f.svc.DeleteTransaction(ctx, f.bob, f.adaTx); err != ErrTransactionNotFound {
+		t.Errorf("DeleteTransaction() of another user's transaction = %v, want ErrTransactionNotFound", err)
+	}
+	if err := f.svc.DeleteCategory(ctx, f.bob, f.adaCategory, nil); err != ErrCategoryNotFound {
+		t.Errorf("DeleteCategory() of another user's category = %v, want ErrCategoryNotFound", err)
+	}
+	if err := f.svc.DeleteAccount(ctx, f.bob, f.adaAccount); err != ErrAccountNotFound {
+		t.Errorf("DeleteAccount() of another user's account = %v, want ErrAccountNotFound", err)
+	}
+
+	adaAccount, err := f.svc.GetAccount(ctx, f.ada, f.adaAccount)
+	if err != nil {
+		t.Fatalf("Ada's account is gone: %v", err)
+	}
+	if adaAccount.Name != "Checking" || adaAccount.Balance != 90 || adaAccount.ArchivedAt != nil {
+		t.Errorf("Ada's account changed: %+v", adaAccount)
+	}
+	var name string
+	if err := f.db.QueryRow(`SELECT name FROM categories WHERE id = $1 AND user_id = $2`, f.adaCategory, f.ada).Scan(&name); err != nil || name != "Groceries" {
+		t.Errorf("Ada's category: %q, %v", name, err)
+	}
+	var amount float64
+	var accountID int
+	err = f.db.QueryRow(`SELECT amount, account_id FROM transactions WHERE id = $1 AND user_id = $2`, f.adaTx, f.ada).Scan(&amount, &accountID)
+	if err != nil || amount != 10 || accountID != f.adaAccount {
+		t.Errorf("Ada's transaction: amount %v on account %d, %v", amount, accountID, err)
+	}
+}
+
+// TestTenantForeignKeys writes straight to the tables, past the service's
+// ownership checks: the composite foreign keys must still refuse rows that
+// point at another user's account or category.
+func TestTenantForeignKeys(t *testing.T) {
+	f := newTenants(t)
+
+	var unvalidated []string
+	rows, err := f.db.Query(`SELECT conname FROM pg_constraint WHERE conname LIKE '%\_tenant' AND NOT convalidated`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		unvalidated = append(unvalidated, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(unvalidated) > 0 {
+		t.Errorf("constraints not validated: %v", unvalidated)
+	}
+
+	writes := []struct {
+		name       string
+		query      string
+		args       []interface{}
+		constraint string
+	}{
+		{"insert on another user's account",
+			`INSERT INTO transactions (user_id, account_id, category_id, amount, type, date) VALUES ($1, $2, $3, 1, 'expense', '2026-03-15')`,
+			[]interface{}{f.bob, f.adaAccount, f.bobCategory}, "fk_transactions_account_tenant"},
+		{"insert into another user's category",
+			`INSERT INTO transactions (user_id, account_id, category_id, amount, type, date) VALUES ($1, $2, $3, 1, 'expense', '2026-03-15')`,
+			[]interface{}{f.bob, f.bobAccount, f.adaCategory}, "fk_transactions_category_tenant"},
+		{"move onto another user's account",
+			`UPDATE transactions SET account_id = $1 WHERE id = $2`,
+			[]interface{}{f.adaAccount, f.bobTx}, "fk_transactions_account_tenant"},
+		{"move into another user's category",
+			`UPDATE transactions SET category_id = $1 WHERE id = $2`,
+			[]interface{}{f.adaCategory, f.bobTx}, "fk_transactions_category_tenant"},
+		{"hand a transaction to another user",
+			`UPDATE transactions SET user_id = $1 WHERE id = $2`,
+			[]interface{}{f.ada, f.bobTx}, "fk_transactions_account_tenant"},
+		{"nest under another user's category",
+			`UPDATE categories SET parent_id = $1 WHERE id = $2`,
+			[]interface{}{f.adaCategory, f.bobCategory}, "fk_categories_parent_tenant"},
+	}
+	for _, write := range writes {
+		_, err := f.db.Exec(write.query, write.args...)
+		pqErr, ok := err.(*pq.Error)
+		if !ok || pqErr.Code != "23503" || pqErr.Constraint != write.constraint {
+			t.Errorf("%s: %v, want a violation of %s", write.name, err, write.constraint)
+		}
+	}
+}
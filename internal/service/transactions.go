@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"personal-finance-tracker/internal/events"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+)
+
+// CreateTransaction records a transaction. An expense over a hard-capped
+// budget fails with a *BudgetCapError unless force is set, in which case
+// the override is written to the audit log.
+func (s *Service) CreateTransaction(ctx context.Context, t *models.Transaction, force bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	balance, err := s.InsertWithinCap(ctx, tx, t, force)
+	if err != nil {
+		return err
+	}
+
+	if err := RecordTransactionCreated(ctx, tx, *t, balance); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.TransactionCreated(*t)
+	return nil
+}
+
+// InsertWithinCap inserts t within tx after checking it against the hard
+// budget caps, returning a *BudgetCapError unless force is set and auditing
+// the override when it is. It returns the account balance after t.
+func (s *Service) InsertWithinCap(ctx context.Context, tx *sql.Tx, t *models.Transaction, force bool) (float64, error) {
+	overCap, err := s.budgetCapExceeded(ctx, tx, t)
+	if err != nil {
+		return 0, err
+	}
+	if overCap != nil && !force {
+		return 0, overCap
+	}
+
+	balance, err := InsertTransaction(ctx, tx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	if overCap != nil {
+		err := recordAudit(ctx, tx, models.AuditEntry{
+			UserID:     t.UserID,
+			Action:     models.AuditActions.BudgetCapOverride,
+			EntityType: "transaction",
+			EntityID:   &t.ID,
+			Details: map[string]interface{}{
+				"category_id": overCap.Status.CategoryID,
+				"amount":      t.Amount,
+				"budget":      overCap.Status.Budget,
+				"spent":       overCap.Status.Spent,
+				"remaining":   overCap.Status.Remaining,
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return balance, nil
+}
+
+// transactionCreated is the payload of a TransactionCreated event: the
+// transaction, the balance of its account right after it and the automation
+// rule that created it, if any.
+type transactionCreated struct {
+	models.Transaction
+	Balance          float64 `json:"balance"`
+	AutomationRuleID int     `json:"automation_rule_id,omitempty"`
+}
+
+// RecordTransactionCreated adds the events of a new transaction to the
+// outbox within tx. Call TransactionCreated once tx is committed.
+func RecordTransactionCreated(ctx context.Context, tx *sql.Tx, t models.Transaction, balance float64) error {
+	return recordTransactionCreated(ctx, tx, transactionCreated{Transaction: t, Balance: balance})
+}
+
+func recordTransactionCreated(ctx context.Context, tx *sql.Tx, created transactionCreated) error {
+	err := events.Record(ctx, tx, events.Event{
+		Type:   events.Types.TransactionCreated,
+		UserID: created.UserID,
+		Data:   created,
+	})
+	if err != nil {
+		return err
+	}
+	return recordBalanceChanged(ctx, tx, created.UserID, created.AccountID, created.Balance)
+}
+
+func recordBalanceChanged(ctx context.Context, q execer, userID, accountID int, balance float64) error {
+	return events.Record(ctx, q, events.Event{
+		Type:   events.Types.BalanceChanged,
+		UserID: userID,
+		Data:   map[string]interface{}{"account_id": accountID, "balance": balance},
+	})
+}
+
+// TransactionCreated runs once a recorded transaction is committed. Alerts
+// follow when its event is dispatched, see handleEvent.
+func (s *Service) TransactionCreated(t models.Transaction) {
+	s.InvalidateUserCache(t.UserID)
+	s.outbox.Wake()
+}
+
+// publishEvent streams dispatched events to the user's SSE subscribers on
+// every instance.
+func (s *Service) publishEvent(ctx context.Context, e events.Event) error {
+	return s.fanout.Publish(ctx, e)
+}
+
+// handleEvent sends the notifications a domain event calls for; webhooks
+// receive them through their notification channels. An error retries the
+// event, so alerts are sent at least once.
+func (s *Service) handleEvent(ctx context.Context, e events.Event) error {
+	if e.Type != events.Types.TransactionCreated {
+		return nil
+	}
+	payload, _ := e.Data.(json.RawMessage)
+	var created transactionCreated
+	if err := json.Unmarshal(payload, &created); err != nil {
+		return err
+	}
+	t := created.Transaction
+
+	if t.Amount >= models.TransactionAlertSettings.LargeAmount {
+		l, currency := s.localizer(t.UserID)
+		s.notifier.Dispatch(notifications.Notification{
+			UserID:  t.UserID,
+			Type:    notifications.Types.LargeTransaction,
+			Title:   l.T("Large transaction recorded"),
+			Message: l.T("A %s of %s was recorded: %s", l.T(t.Type), l.Amount(t.Amount, currency), t.Description),
+			Data: map[string]interface{}{
+				"transaction_id": t.ID,
+				"amount":         t.Amount,
+				"description":    t.Description,
+			},
+		})
+	}
+
+	if t.Type != "expense" {
+		return nil
+	}
+	if err := s.checkBudgetAlerts(ctx, t); err != nil {
+		return fmt.Errorf("check budget alerts: %w", err)
+	}
+	if err := s.checkPayeeLimitAlerts(ctx, t); err != nil {
+		return fmt.Errorf("check payee limits: %w", err)
+	}
+	if err := s.checkUtilizationAlert(ctx, t, created.Balance); err != nil {
+		return fmt.Errorf("check credit utilization: %w", err)
+	}
+	if err := s.checkSubscriptionPrice(ctx, t); err != nil {
+		return fmt.Errorf("check subscription price: %w", err)
+	}
+	return nil
+}
+
+// InsertTransaction adds a transaction within tx and moves its account's
+// balance. It fails with a *QuotaError once the user has recorded as many
+// transactions this month as their plan allows.
+func InsertTransaction(ctx context.Context, tx *sql.Tx, t *models.Transaction) (float64, error) {
+	if err := checkTransactionQuota(ctx, tx, t.UserID, 1); err != nil {
+		return 0, err
+	}
+	return insertTransaction(ctx, tx, t)
+}
+
+// insertTransaction is InsertTransaction for callers that have checked the
+// quota for the whole batch.
+func insertTransaction(ctx context.Context, tx *sql.Tx, t *models.Transaction) (float64, error) {
+	if err := ensureOwned(ctx, tx, "categories", t.CategoryID, t.UserID); err != nil {
+		return 0, err
+	}
+
+	var balance float64
+	var accountScope string
+	err := tx.QueryRowContext(ctx, `UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2 AND user_id = $3 AND archived_at IS NULL RETURNING balance, scope`,
+		SignedAmount(t.Type, t.Amount), t.AccountID, t.UserID).Scan(&balance, &accountScope)
+	if err == sql.ErrNoRows {
+		if ensureOwned(ctx, tx, "accounts", t.AccountID, t.UserID) == nil {
+			return 0, ErrAccountArchived
+		}
+		return 0, ErrAccountNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if t.Scope == "" {
+		t.Scope = accountScope
+	}
+
+	query := `INSERT INTO transactions (user_id, account_id, category_id, amount, type, description, date, scope,
+			  latitude, longitude, place, quantity, unit, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, NULLIF($13, ''), NOW(), NOW())
+			  RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, t.UserID, t.AccountID, t.CategoryID, t.Amount, t.Type, t.Description, t.Date, t.Scope,
+		t.Latitude, t.Longitude, t.Place, t.Quantity, t.Unit).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	return balance, err
+}
+
+// UpdateTransaction changes a transaction within tx, rebuilding the balance
+// of its old and new account from the ledger and recording BalanceChanged
+// for each. Like an empty scope, a missing location or quantity keeps the
+// stored one.
+// Call TransactionUpdated once tx is committed.
+func UpdateTransaction(ctx context.Context, tx *sql.Tx, t *models.Transaction) error {
+	var old models.Transaction
+	err := tx.QueryRowContext(ctx, `SELECT account_id, amount, type FROM transactions WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		t.ID, t.UserID).Scan(&old.AccountID, &old.Amount, &old.Type)
+	if err == sql.ErrNoRows {
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ensureOwned(ctx, tx, "categories", t.CategoryID, t.UserID); err != nil {
+		return err
+	}
+	// Archived accounts are closed to new entries, so a transaction may stay
+	// on one but not be moved onto it.
+	var archived bool
+	err = tx.QueryRowContext(ctx, `SELECT archived_at IS NOT NULL FROM accounts WHERE id = $1 AND user_id = $2`,
+		t.AccountID, t.UserID).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if archived && t.AccountID != old.AccountID {
+		return ErrAccountArchived
+	}
+
+	// A VAT breakdown no longer adds up once the amount changes.
+	if t.Amount != old.Amount {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_vat_lines WHERE transaction_id = $1`, t.ID); err != nil {
+			return err
+		}
+	}
+
+	// A zero date keeps the stored one, like a missing location or quantity.
+	date := sql.NullTime{Time: t.Date, Valid: !t.Date.IsZero()}
+	query := `UPDATE transactions SET account_id = $1, category_id = $2, amount = $3, type = $4, description = $5, date = COALESCE($6, date),
+			  scope = COALESCE(NULLIF($9, ''), scope), latitude = COALESCE($10, latitude), longitude = COALESCE($11, longitude),
+			  place = COALESCE(NULLIF($12, ''), place), quantity = COALESCE($13, quantity), unit = COALESCE(NULLIF($14, ''), unit),
+			  updated_at = NOW()
+			  WHERE id = $7 AND user_id = $8
+			  RETURNING date, scope, latitude, longitude, COALESCE(place, ''), quantity, COALESCE(unit, ''), created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, t.AccountID, t.CategoryID, t.Amount, t.Type, t.Description, date, t.ID, t.UserID, t.Scope,
+		t.Latitude, t.Longitude, t.Place, t.Quantity, t.Unit).
+		Scan(&t.Date, &t.Scope, &t.Latitude, &t.Longitude, &t.Place, &t.Quantity, &t.Unit, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Rebuilding rather than applying the difference also repairs any drift
+	// the accounts had, so an edit always leaves them matching the ledger.
+	accounts := []int{old.AccountID}
+	if t.AccountID != old.AccountID {
+		accounts = append(accounts, t.AccountID)
+	}
+	for _, accountID := range accounts {
+		balance, err := RecomputeBalance(ctx, tx, t.UserID, accountID)
+		if err != nil {
+			return err
+		}
+		if err := recordBalanceChanged(ctx, tx, t.UserID, accountID, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) UpdateTransaction(ctx context.Context, t *models.Transaction) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := UpdateTransaction(ctx, tx, t); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.TransactionUpdated(t.UserID)
+	return nil
+}
+
+// TransactionUpdated runs once a change to or deletion of a transaction is
+// committed.
+func (s *Service) TransactionUpdated(userID int) {
+	s.InvalidateUserCache(userID)
+	s.outbox.Wake()
+}
+
+func (s *Service) DeleteTransaction(ctx context.Context, userID, transactionID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var t models.Transaction
+	err = tx.QueryRowContext(ctx, `DELETE FROM transactions WHERE id = $1 AND user_id = $2 RETURNING account_id, amount, type`,
+		transactionID, userID).Scan(&t.AccountID, &t.Amount, &t.Type)
+	if err == sql.ErrNoRows {
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	balance, err := RecomputeBalance(ctx, tx, userID, t.AccountID)
+	if err != nil {
+		return err
+	}
+	if err := recordBalanceChanged(ctx, tx, userID, t.AccountID, balance); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.TransactionUpdated(userID)
+	return nil
+}
+
+// SignedAmount is how a transaction moves its account balance. Opening and
+// adjustment entries are stored signed already.
+func SignedAmount(transactionType string, amount float64) float64 {
+	if transactionType == models.TransactionTypes.Expense {
+		return -amount
+	}
+	return amount
+}
+
+func (s *Service) GetTransactions(ctx context.Context, userID, limit, offset int) ([]models.Transaction, error) {
+	return s.GetTransactionsExpanded(ctx, userID, limit, offset, models.TransactionExpand{}, "", 0, models.GeoFilter{})
+}
+
+// GetTransactionsExpanded joins the requested related records into the same
+// query so clients do not have to look up each category and account. A
+// non-empty scope keeps only personal or business transactions.
+//
+// A non-zero accountID lists that account alone, with each row's running
+// balance: the account's balance less every transaction after the row, in
+// date then creation order. It is computed over the whole ledger before the
+// scope filter and paging, so every page agrees with the stored balance.
+//
+// geo keeps the transactions made in an area; those without a location are
+// left out once it is set.
+func (s *Service) GetTransactionsExpanded(ctx context.Context, userID, limit, offset int, expand models.TransactionExpand, scope string, accountID int, geo models.GeoFilter) ([]models.Transaction, error) {
+	query := `SELECT t.id, t.user_id, t.account_id, COALESCE(t.category_id, 0), t.amount, t.type, COALESCE(t.description, ''), t.date, t.scope,
+			  t.latitude, t.longitude, COALESCE(t.place, ''), t.quantity, COALESCE(t.unit, ''), t.created_at, t.updated_at,
+			  c.id, COALESCE(c.name, ''), COALESCE(c.color, ''), COALESCE(c.icon, ''),
+			  a.id, COALESCE(a.name, ''), COALESCE(a.type, ''), COALESCE(a.currency, ''),
+			  r.balance
+			  FROM transactions t
+			  LEFT JOIN categories c ON $4 AND c.id = t.category_id AND c.user_id = t.user_id
+			  LEFT JOIN accounts a ON $5 AND a.id = t.account_id AND a.user_id = t.user_id
+			  LEFT JOIN (
+			      SELECT l.id, b.balance - COALESCE(SUM(CASE WHEN l.type = 'expense' THEN -l.amount ELSE l.amount END)
+			          OVER (ORDER BY l.date DESC, l.created_at DESC, l.id DESC ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS balance
+			      FROM transactions l
+			      JOIN accounts b ON b.id = l.account_id AND b.user_id = l.user_id
+			      WHERE $7 > 0 AND l.account_id = $7 AND l.user_id = $1
+			  ) r ON r.id = t.id
+			  WHERE t.user_id = $1 AND ($6 = '' OR t.scope = $6) AND ($7 = 0 OR t.account_id = $7) AND ` + geoCondition(8) + `
+			  ORDER BY t.date DESC, t.created_at DESC, t.id DESC
+			  LIMIT $2 OFFSET $3`
+
+	args := append([]interface{}{userID, limit, offset, expand.Category, expand.Account, scope, accountID}, geoArgs(geo)...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		var t models.Transaction
+		var category models.TransactionCategoryRef
+		var account models.TransactionAccountRef
+		var categoryID, refID sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.UserID, &t.AccountID, &t.CategoryID, &t.Amount, &t.Type,
+			&t.Description, &t.Date, &t.Scope, &t.Latitude, &t.Longitude, &t.Place, &t.Quantity, &t.Unit,
+			&t.CreatedAt, &t.UpdatedAt, &categoryID, &category.Name, &category.Color, &category.Icon,
+			&refID, &account.Name, &account.Type, &account.Currency, &t.RunningBalance); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			category.ID = int(categoryID.Int64)
+			t.Category = &category
+		}
+		if refID.Valid {
+			account.ID = int(refID.Int64)
+			t.Account = &account
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+func (s *Service) CountTransactions(ctx context.Context, userID int, scope string, accountID int, geo models.GeoFilter) (int, error) {
+	var total int
+	query := `SELECT COUNT(*) FROM transactions t
+			  WHERE t.user_id = $1 AND ($2 = '' OR t.scope = $2) AND ($3 = 0 OR t.account_id = $3) AND ` + geoCondition(4)
+
+	err := s.db.QueryRowContext(ctx, query, append([]interface{}{userID, scope, accountID}, geoArgs(geo)...)...).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/notifications"
+	"personal-finance-tracker/internal/templates"
+)
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+var (
+	ErrInvalidProfile          = errors.New("invalid profile")
+	ErrWrongPassword           = errors.New("current password is incorrect")
+	ErrEmailTaken              = errors.New("email address is already in use")
+	ErrEmailChangeTokenInvalid = errors.New("confirmation link is invalid or has expired")
+)
+
+func (s *Service) GetUser(ctx context.Context, userID int) (models.User, error) {
+	var user models.User
+	query := `SELECT id, email, first_name, last_name, created_at, updated_at FROM users WHERE id = $1`
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetProfile returns the user with the profile details that are not part of
+// the sign-in identity.
+func (s *Service) GetProfile(ctx context.Context, userID int) (models.User, error) {
+	var user models.User
+	var avatarUpdatedAt sql.NullTime
+	query := `SELECT id, email, first_name, last_name, phone_number, avatar_updated_at, created_at, updated_at
+			  FROM users WHERE id = $1`
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName,
+		&user.PhoneNumber, &avatarUpdatedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrUserNotFound
+	}
+	if err != nil {
+		return user, err
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+	user.PreferredCurrency = settings.BaseCurrency
+	user.AvatarURLs = avatarURLs(avatarUpdatedAt)
+	return user, nil
+}
+
+// IsAdmin reports whether the user's email is listed in ADMIN_EMAILS, which
+// grants access to instance-wide operations such as backups.
+func (s *Service) IsAdmin(ctx context.Context, userID int) (bool, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, email := range config.Get().Server.AdminEmails {
+		if email = strings.TrimSpace(email); email != "" && strings.EqualFold(email, user.Email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Service) UpdatePasswordHash(ctx context.Context, userID int, password string) error {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`, hashedPassword, userID)
+	return err
+}
+
+// UpdateProfile applies name changes right away. A new password revokes every
+// session but the current one; a new email only takes effect once the link
+// sent to that address is confirmed.
+func (s *Service) UpdateProfile(ctx context.Context, userID, sessionID int, req models.UpdateProfileRequest) (models.ProfileUpdate, error) {
+	var update models.ProfileUpdate
+
+	var passwordHash string
+	err := s.db.QueryRowContext(ctx, `SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&passwordHash)
+	if err == sql.ErrNoRows {
+		return update, ErrUserNotFound
+	}
+	if err != nil {
+		return update, err
+	}
+
+	email := ""
+	if req.Email != nil {
+		email = strings.TrimSpace(*req.Email)
+	}
+	if (req.NewPassword != "" || email != "") && !auth.CheckPasswordHash(req.CurrentPassword, passwordHash) {
+		return update, ErrWrongPassword
+	}
+
+	if req.FirstName != nil || req.LastName != nil {
+		firstName, lastName := req.FirstName, req.LastName
+		if (firstName != nil && strings.TrimSpace(*firstName) == "") || (lastName != nil && strings.TrimSpace(*lastName) == "") {
+			return update, fmt.Errorf("%w: first_name and last_name cannot be empty", ErrInvalidProfile)
+		}
+		_, err := s.db.ExecContext(ctx, `UPDATE users SET first_name = COALESCE($1, first_name), last_name = COALESCE($2, last_name),
+				  updated_at = NOW() WHERE id = $3`, firstName, lastName, userID)
+		if err != nil {
+			return update, err
+		}
+	}
+
+	if req.PhoneNumber != nil {
+		var phone *string
+		if number := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(*req.PhoneNumber); number != "" {
+			if !phonePattern.MatchString(number) {
+				return update, fmt.Errorf("%w: phone_number must be in international format, e.g. +48123456789", ErrInvalidProfile)
+			}
+			phone = &number
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE users SET phone_number = $1, updated_at = NOW() WHERE id = $2`, phone, userID); err != nil {
+			return update, err
+		}
+	}
+	if req.PreferredCurrency != nil {
+		if _, err := s.UpdateSettings(ctx, userID, models.UpdateSettingsRequest{BaseCurrency: req.PreferredCurrency}); err != nil {
+			return update, err
+		}
+	}
+
+	if req.NewPassword != "" {
+		if err := s.UpdatePasswordHash(ctx, userID, req.NewPassword); err != nil {
+			return update, err
+		}
+		revoked, err := s.RevokeOtherSessions(ctx, userID, sessionID)
+		if err != nil {
+			return update, err
+		}
+		update.SessionsRevoked = revoked
+
+		l, _ := s.localizer(userID)
+		s.notifier.Dispatch(notifications.Notification{
+			UserID:  userID,
+			Type:    notifications.Types.SecurityAlert,
+			Title:   l.T("Password changed"),
+			Message: l.T("Your password was changed and other devices were signed out."),
+		})
+	}
+
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return update, err
+	}
+
+	if email != "" && !strings.EqualFold(email, user.Email) {
+		if err := s.requestEmailChange(ctx, user, email); err != nil {
+			return update, err
+		}
+		update.PendingEmail = email
+	}
+
+	update.User = user
+	return update, nil
+}
+
+func (s *Service) requestEmailChange(ctx context.Context, user models.User, email string) error {
+	var taken bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))`, email).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at, created_at)
+			  VALUES ($1, $2, $3, $4, NOW())
+			  ON CONFLICT (user_id) DO UPDATE SET new_email = EXCLUDED.new_email, token_hash = EXCLUDED.token_hash,
+			  expires_at = EXCLUDED.expires_at, created_at = NOW()`
+
+	_, err = s.db.ExecContext(ctx, query, user.ID, email, auth.HashToken(token), time.Now().Add(models.ProfileSettings.EmailChangeTTL))
+	if err != nil {
+		return err
+	}
+
+	baseURL := strings.TrimRight(config.Get().Server.BaseURL, "/")
+	l, _ := s.localizer(user.ID)
+	subject, body, err := templates.Email("email_change", l.Tag().String(), templates.EmailChange{
+		FirstName:    user.FirstName,
+		ConfirmURL:   baseURL + "/api/v1/auth/confirm-email/" + token,
+		Expires:      models.ProfileSettings.EmailChangeTTL,
+		CurrentEmail: user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.mailer.Send(email, subject, body); err != nil {
+			log.Printf("Error sending email change confirmation: %v", err)
+		}
+	}()
+
+	s.notifier.Dispatch(notifications.Notification{
+		UserID:  user.ID,
+		Type:    notifications.Types.SecurityAlert,
+		Title:   l.T("Email change requested"),
+		Message: l.T("A confirmation link was sent to %s.", email),
+	})
+	return nil
+}
+
+// ConfirmEmailChange switches the account to the address the token was sent
+// to.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) (models.User, error) {
+	var user models.User
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRowContext(ctx, `DELETE FROM email_change_requests WHERE token_hash = $1 AND expires_at > NOW()
+			  RETURNING user_id, new_email`, auth.HashToken(token)).Scan(&user.ID, &email)
+	if err == sql.ErrNoRows {
+		return user, ErrEmailChangeTokenInvalid
+	}
+	if err != nil {
+		return user, err
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND id <> $2)`, email, user.ID).Scan(&taken)
+	if err != nil {
+		return user, err
+	}
+	if taken {
+		return user, ErrEmailTaken
+	}
+
+	err = tx.QueryRowContext(ctx, `UPDATE users SET email = $1, updated_at = NOW() WHERE id = $2
+			  RETURNING email, first_name, last_name, created_at, updated_at`, email, user.ID).
+		Scan(&user.Email, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return user, err
+	}
+
+	return user, tx.Commit()
+}
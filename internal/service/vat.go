@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// vatLines fills in the VAT and gross amounts a request leaves out and checks
+// that each line adds up.
+func vatLines(reqs []models.VATLineRequest) ([]models.VATLine, error) {
+	lines := make([]models.VATLine, 0, len(reqs))
+	for _, req := range reqs {
+		line := models.VATLine{Description: req.Description, NetAmount: req.NetAmount, VATRate: req.VATRate}
+		line.VATAmount = math.Round(req.NetAmount*req.VATRate) / 100
+		if req.VATAmount != nil {
+			line.VATAmount = *req.VATAmount
+		}
+		line.GrossAmount = line.NetAmount + line.VATAmount
+		if req.GrossAmount != nil {
+			if math.Abs(*req.GrossAmount-line.GrossAmount) > models.VATSettings.Tolerance {
+				return nil, ErrVATLineMismatch
+			}
+			line.GrossAmount = *req.GrossAmount
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// SetVATBreakdown replaces the VAT breakdown of a transaction. The gross
+// amounts of the lines must add up to the transaction amount.
+func (s *Service) SetVATBreakdown(ctx context.Context, userID, transactionID int, reqs []models.VATLineRequest) (models.VATBreakdown, error) {
+	breakdown := models.VATBreakdown{TransactionID: transactionID}
+
+	lines, err := vatLines(reqs)
+	if err != nil {
+		return breakdown, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return breakdown, err
+	}
+	defer tx.Rollback()
+
+	var amount float64
+	err = tx.QueryRowContext(ctx, `SELECT amount FROM transactions WHERE id = $1 AND user_id = $2 FOR UPDATE`,
+		transactionID, userID).Scan(&amount)
+	if err == sql.ErrNoRows {
+		return breakdown, ErrTransactionNotFound
+	}
+	if err != nil {
+		return breakdown, err
+	}
+
+	var gross float64
+	for _, line := range lines {
+		gross += line.GrossAmount
+	}
+	if math.Abs(gross-amount) > models.VATSettings.Tolerance {
+		return breakdown, ErrVATTotalMismatch
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transaction_vat_lines WHERE transaction_id = $1`, transactionID); err != nil {
+		return breakdown, err
+	}
+	for i := range lines {
+		err := tx.QueryRowContext(ctx, `INSERT INTO transaction_vat_lines (transaction_id, description, net_amount, vat_rate, vat_amount, gross_amount)
+			  VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			transactionID, lines[i].Description, lines[i].NetAmount, lines[i].VATRate, lines[i].VATAmount, lines[i].GrossAmount).
+			Scan(&lines[i].ID)
+		if err != nil {
+			return breakdown, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return breakdown, err
+	}
+
+	s.InvalidateUserCache(userID)
+	return s.VATBreakdown(ctx, userID, transactionID)
+}
+
+func (s *Service) VATBreakdown(ctx context.Context, userID, transactionID int) (models.VATBreakdown, error) {
+	breakdown := models.VATBreakdown{TransactionID: transactionID, Lines: []models.VATLine{}}
+	if err := ensureOwned(ctx, s.db, "transactions", transactionID, userID); err != nil {
+		return breakdown, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, description, net_amount, vat_rate, vat_amount, gross_amount
+			  FROM transaction_vat_lines WHERE transaction_id = $1 ORDER BY id`, transactionID)
+	if err != nil {
+		return breakdown, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.VATLine
+		if err := rows.Scan(&line.ID, &line.Description, &line.NetAmount, &line.VATRate, &line.VATAmount, &line.GrossAmount); err != nil {
+			return breakdown, err
+		}
+		breakdown.NetAmount += line.NetAmount
+		breakdown.VATAmount += line.VATAmount
+		breakdown.GrossAmount += line.GrossAmount
+		breakdown.Lines = append(breakdown.Lines, line)
+	}
+	return breakdown, rows.Err()
+}
+
+func (s *Service) DeleteVATBreakdown(ctx context.Context, userID, transactionID int) error {
+	if err := ensureOwned(ctx, s.db, "transactions", transactionID, userID); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM transaction_vat_lines WHERE transaction_id = $1`, transactionID)
+	if err == nil {
+		s.InvalidateUserCache(userID)
+	}
+	return err
+}
+
+// VATReport sums the VAT breakdowns of transactions dated in a calendar
+// quarter in the user's timezone, optionally of one scope. Transactions
+// without a breakdown are left out.
+func (s *Service) VATReport(ctx context.Context, userID, year, quarter int, scope string) (models.VATReport, error) {
+	report := models.VATReport{Year: year, Quarter: quarter, Scope: scope, Output: []models.VATRateSummary{}, Input: []models.VATRateSummary{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+	report.Currency = settings.BaseCurrency
+	start := time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, SettingsLocation(settings))
+	end := start.AddDate(0, 3, 0)
+	report.From, report.To = start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02")
+
+	query := `SELECT t.type, l.vat_rate, SUM(l.net_amount), SUM(l.vat_amount), SUM(l.gross_amount)
+			  FROM transaction_vat_lines l
+			  JOIN transactions t ON t.id = l.transaction_id
+			  WHERE t.user_id = $1 AND t.date >= $2 AND t.date < $3 AND ($4 = '' OR t.scope = $4)
+			  GROUP BY t.type, l.vat_rate
+			  ORDER BY t.type, l.vat_rate`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, start, end, scope)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionType string
+		var summary models.VATRateSummary
+		if err := rows.Scan(&transactionType, &summary.VATRate, &summary.NetAmount, &summary.VATAmount, &summary.GrossAmount); err != nil {
+			return report, err
+		}
+		if transactionType == "income" {
+			report.OutputVAT += summary.VATAmount
+			report.Output = append(report.Output, summary)
+		} else {
+			report.InputVAT += summary.VATAmount
+			report.Input = append(report.Input, summary)
+		}
+	}
+	report.VATPayable = math.Round((report.OutputVAT-report.InputVAT)*100) / 100
+	return report, rows.Err()
+}
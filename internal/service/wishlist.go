@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrPlannedPurchaseNotFound  = errors.New("planned purchase not found")
+	ErrTooManyPlannedPurchases  = errors.New("too many planned purchases")
+	ErrPurchaseNotExpense       = errors.New("a purchase must be an expense transaction")
+	ErrPurchaseTransactionTaken = errors.New("transaction is already linked to another planned purchase")
+)
+
+// plannedPurchaseColumns select a planned purchase p together with the
+// transaction t it is linked to.
+const plannedPurchaseColumns = `p.id, p.user_id, p.name, p.notes, p.url, p.target_price, p.priority, p.category_id,
+			  to_char(p.target_date, 'YYYY-MM-DD'), p.transaction_id, t.amount, t.date, p.created_at, p.updated_at`
+
+const plannedPurchaseOrder = `CASE p.priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END, p.target_date NULLS LAST, p.created_at, p.id`
+
+func scanPlannedPurchase(row interface{ Scan(...interface{}) error }, p *models.PlannedPurchase, loc *time.Location) error {
+	var purchasedAt *time.Time
+	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.Notes, &p.URL, &p.TargetPrice, &p.Priority, &p.CategoryID,
+		&p.TargetDate, &p.TransactionID, &p.ActualPrice, &purchasedAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	p.Status = models.WishlistStatuses.Planned
+	if purchasedAt != nil {
+		p.Status = models.WishlistStatuses.Purchased
+		day := purchasedAt.In(loc).Format("2006-01-02")
+		p.PurchasedOn = &day
+	}
+	return nil
+}
+
+// PlannedPurchases lists the user's wishlist in priority order, optionally
+// only the planned or the purchased items.
+func (s *Service) PlannedPurchases(ctx context.Context, userID int, status string) ([]models.PlannedPurchase, error) {
+	query := `SELECT ` + plannedPurchaseColumns + `
+			  FROM planned_purchases p
+			  LEFT JOIN transactions t ON t.id = p.transaction_id
+			  WHERE p.user_id = $1 AND ($2 = '' OR (p.transaction_id IS NULL) = ($2 = $3))
+			  ORDER BY ` + plannedPurchaseOrder
+
+	rows, err := s.db.QueryContext(ctx, query, userID, status, models.WishlistStatuses.Planned)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loc := s.Location(ctx, userID)
+	purchases := []models.PlannedPurchase{}
+	for rows.Next() {
+		var p models.PlannedPurchase
+		if err := scanPlannedPurchase(rows, &p, loc); err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
+
+// Wishlist lists the user's planned purchases and checks which of the
+// planned ones safe-to-spend can pay for, highest priority first.
+func (s *Service) Wishlist(ctx context.Context, userID int, status string, now time.Time) (models.Wishlist, error) {
+	var wishlist models.Wishlist
+
+	items, err := s.PlannedPurchases(ctx, userID, status)
+	if err != nil {
+		return wishlist, err
+	}
+	wishlist.Items = items
+
+	safe, err := s.SafeToSpend(ctx, userID, now)
+	if err != nil {
+		return wishlist, err
+	}
+	wishlist.Currency = safe.Currency
+	wishlist.SafeToSpend = safe.SafeToSpend
+
+	for i := range wishlist.Items {
+		item := &wishlist.Items[i]
+		if item.Status != models.WishlistStatuses.Planned {
+			continue
+		}
+		wishlist.Planned += item.TargetPrice
+		affordable := wishlist.Planned <= safe.SafeToSpend
+		item.Affordable = &affordable
+	}
+	wishlist.Planned = math.Round(wishlist.Planned*100) / 100
+	return wishlist, nil
+}
+
+func (s *Service) PlannedPurchase(ctx context.Context, userID, id int) (models.PlannedPurchase, error) {
+	var p models.PlannedPurchase
+	err := scanPlannedPurchase(s.db.QueryRowContext(ctx, `SELECT `+plannedPurchaseColumns+`
+			  FROM planned_purchases p
+			  LEFT JOIN transactions t ON t.id = p.transaction_id
+			  WHERE p.id = $1 AND p.user_id = $2`, id, userID), &p, s.Location(ctx, userID))
+	if err == sql.ErrNoRows {
+		return p, ErrPlannedPurchaseNotFound
+	}
+	return p, err
+}
+
+func (s *Service) validatePlannedPurchase(ctx context.Context, userID int, req *models.PlannedPurchaseRequest) error {
+	if req.Priority == "" {
+		req.Priority = models.WishlistPriorities.Medium
+	}
+	if req.CategoryID != nil {
+		return ensureOwned(ctx, s.db, "categories", *req.CategoryID, userID)
+	}
+	return nil
+}
+
+func (s *Service) CreatePlannedPurchase(ctx context.Context, userID int, req models.PlannedPurchaseRequest) (models.PlannedPurchase, error) {
+	var p models.PlannedPurchase
+	if err := s.validatePlannedPurchase(ctx, userID, &req); err != nil {
+		return p, err
+	}
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM planned_purchases WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return p, err
+	}
+	if count >= models.WishlistSettings.MaxItems {
+		return p, ErrTooManyPlannedPurchases
+	}
+
+	query := `WITH p AS (
+				  INSERT INTO planned_purchases (user_id, name, notes, url, target_price, priority, category_id, target_date, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW()) RETURNING *
+			  )
+			  SELECT ` + plannedPurchaseColumns + ` FROM p LEFT JOIN transactions t ON t.id = p.transaction_id`
+
+	err := scanPlannedPurchase(s.db.QueryRowContext(ctx, query, userID, req.Name, req.Notes, req.URL, req.TargetPrice, req.Priority,
+		req.CategoryID, req.TargetDate), &p, s.Location(ctx, userID))
+	return p, err
+}
+
+// UpdatePlannedPurchase replaces an item's details. Whether it was purchased
+// is left alone.
+func (s *Service) UpdatePlannedPurchase(ctx context.Context, userID, id int, req models.PlannedPurchaseRequest) (models.PlannedPurchase, error) {
+	var p models.PlannedPurchase
+	if err := s.validatePlannedPurchase(ctx, userID, &req); err != nil {
+		return p, err
+	}
+
+	query := `WITH p AS (
+				  UPDATE planned_purchases SET name = $3, notes = $4, url = $5, target_price = $6, priority = $7, category_id = $8,
+				  target_date = $9, updated_at = NOW()
+				  WHERE id = $1 AND user_id = $2 RETURNING *
+			  )
+			  SELECT ` + plannedPurchaseColumns + ` FROM p LEFT JOIN transactions t ON t.id = p.transaction_id`
+
+	err := scanPlannedPurchase(s.db.QueryRowContext(ctx, query, id, userID, req.Name, req.Notes, req.URL, req.TargetPrice, req.Priority,
+		req.CategoryID, req.TargetDate), &p, s.Location(ctx, userID))
+	if err == sql.ErrNoRows {
+		return p, ErrPlannedPurchaseNotFound
+	}
+	return p, err
+}
+
+func (s *Service) DeletePlannedPurchase(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM planned_purchases WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPlannedPurchaseNotFound
+	}
+	return nil
+}
+
+// MarkPurchased links an item to the expense that paid for it, replacing
+// any transaction linked before.
+func (s *Service) MarkPurchased(ctx context.Context, userID, id, transactionID int) (models.PlannedPurchase, error) {
+	var transactionType string
+	err := s.db.QueryRowContext(ctx, `SELECT type FROM transactions WHERE id = $1 AND user_id = $2`, transactionID, userID).
+		Scan(&transactionType)
+	if err == sql.ErrNoRows {
+		return models.PlannedPurchase{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return models.PlannedPurchase{}, err
+	}
+	if transactionType != models.TransactionTypes.Expense {
+		return models.PlannedPurchase{}, ErrPurchaseNotExpense
+	}
+
+	p, err := s.setPurchaseTransaction(ctx, userID, id, &transactionID)
+	if isUniqueViolation(err) {
+		return p, ErrPurchaseTransactionTaken
+	}
+	return p, err
+}
+
+// UnmarkPurchased puts a purchased item back on the wishlist.
+func (s *Service) UnmarkPurchased(ctx context.Context, userID, id int) (models.PlannedPurchase, error) {
+	return s.setPurchaseTransaction(ctx, userID, id, nil)
+}
+
+func (s *Service) setPurchaseTransaction(ctx context.Context, userID, id int, transactionID *int) (models.PlannedPurchase, error) {
+	var p models.PlannedPurchase
+	query := `WITH p AS (
+				  UPDATE planned_purchases SET transaction_id = $3, updated_at = NOW()
+				  WHERE id = $1 AND user_id = $2 RETURNING *
+			  )
+			  SELECT ` + plannedPurchaseColumns + ` FROM p LEFT JOIN transactions t ON t.id = p.transaction_id`
+
+	err := scanPlannedPurchase(s.db.QueryRowContext(ctx, query, id, userID, transactionID), &p, s.Location(ctx, userID))
+	if err == sql.ErrNoRows {
+		return p, ErrPlannedPurchaseNotFound
+	}
+	return p, err
+}
+
+// WishlistAnalytics compares the target price of each item purchased in
+// [start, end) with what its transaction cost. Zero bounds leave the range
+// open.
+func (s *Service) WishlistAnalytics(ctx context.Context, userID int, start, end time.Time) (models.WishlistAnalytics, error) {
+	analytics := models.WishlistAnalytics{Purchases: []models.PlannedVsActual{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return analytics, err
+	}
+	analytics.Currency = settings.BaseCurrency
+	if !start.IsZero() {
+		analytics.PeriodStart = start.Format("2006-01-02")
+	} else {
+		start = rollupMinDate
+	}
+	if !end.IsZero() {
+		analytics.PeriodEnd = end.AddDate(0, 0, -1).Format("2006-01-02")
+	} else {
+		end = rollupMaxDate
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT p.id, p.name, t.id, t.date, p.target_price, t.amount
+			  FROM planned_purchases p
+			  JOIN transactions t ON t.id = p.transaction_id
+			  WHERE p.user_id = $1 AND t.date >= $2 AND t.date < $3
+			  ORDER BY t.date, p.id`, userID, start, end)
+	if err != nil {
+		return analytics, err
+	}
+	defer rows.Close()
+
+	loc := SettingsLocation(settings)
+	for rows.Next() {
+		var purchase models.PlannedVsActual
+		var date time.Time
+		if err := rows.Scan(&purchase.PlannedPurchaseID, &purchase.Name, &purchase.TransactionID, &date,
+			&purchase.Planned, &purchase.Actual); err != nil {
+			return analytics, err
+		}
+		purchase.PurchasedOn = date.In(loc).Format("2006-01-02")
+		purchase.Difference = math.Round((purchase.Actual-purchase.Planned)*100) / 100
+		purchase.DifferencePercent = math.Round(percentChange(purchase.Planned, purchase.Actual)*10) / 10
+
+		analytics.Count++
+		analytics.Planned += purchase.Planned
+		analytics.Actual += purchase.Actual
+		switch {
+		case purchase.Difference > 0:
+			analytics.OverTarget++
+		case purchase.Difference < 0:
+			analytics.UnderTarget++
+		}
+		analytics.Purchases = append(analytics.Purchases, purchase)
+	}
+	if err := rows.Err(); err != nil {
+		return analytics, err
+	}
+
+	analytics.Planned = math.Round(analytics.Planned*100) / 100
+	analytics.Actual = math.Round(analytics.Actual*100) / 100
+	analytics.Difference = math.Round((analytics.Actual-analytics.Planned)*100) / 100
+	analytics.DifferencePercent = math.Round(percentChange(analytics.Planned, analytics.Actual)*10) / 10
+	return analytics, nil
+}
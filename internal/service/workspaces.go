@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personal-finance-tracker/internal/auth"
+	"personal-finance-tracker/internal/models"
+)
+
+var (
+	ErrWorkspaceNotFound       = errors.New("workspace not found")
+	ErrTooManyWorkspaces       = errors.New("too many workspaces")
+	ErrTooManyWorkspaceMembers = errors.New("too many workspace members")
+	ErrWorkspaceMemberNotFound = errors.New("workspace member not found")
+	ErrWorkspaceMemberExists   = errors.New("user is already a member of this workspace")
+	ErrWorkspaceRole           = errors.New("your workspace role does not allow this")
+	ErrWorkspaceOwner          = errors.New("the workspace owner cannot be removed or given another role")
+)
+
+var workspaceRoleRank = map[string]int{
+	models.WorkspaceRoles.Viewer: 0,
+	models.WorkspaceRoles.Member: 1,
+	models.WorkspaceRoles.Admin:  2,
+	models.WorkspaceRoles.Owner:  3,
+}
+
+// WorkspaceRoleAllows reports whether role is at least as strong as min.
+func WorkspaceRoleAllows(role, min string) bool {
+	return workspaceRoleRank[role] >= workspaceRoleRank[min]
+}
+
+const workspaceColumns = `w.id, w.name, m.role, (SELECT COUNT(*) FROM workspace_members c WHERE c.workspace_id = w.id),
+			  w.created_at, w.updated_at`
+
+func scanWorkspace(row interface{ Scan(...interface{}) error }, w *models.Workspace) error {
+	return row.Scan(&w.ID, &w.Name, &w.Role, &w.MemberCount, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// WorkspaceMembership returns the user's membership of a workspace, or
+// ErrWorkspaceNotFound when they are not a member.
+func (s *Service) WorkspaceMembership(ctx context.Context, userID, workspaceID int) (models.WorkspaceMembership, error) {
+	var m models.WorkspaceMembership
+	err := s.db.QueryRowContext(ctx, `SELECT w.id, w.ledger_user_id, m.role
+			  FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $2
+			  WHERE w.id = $1`, workspaceID, userID).Scan(&m.WorkspaceID, &m.LedgerUserID, &m.Role)
+	if err == sql.ErrNoRows {
+		return m, ErrWorkspaceNotFound
+	}
+	return m, err
+}
+
+// requireWorkspaceRole returns the user's membership if their role is at
+// least min.
+func (s *Service) requireWorkspaceRole(ctx context.Context, userID, workspaceID int, min string) (models.WorkspaceMembership, error) {
+	m, err := s.WorkspaceMembership(ctx, userID, workspaceID)
+	if err == nil && !WorkspaceRoleAllows(m.Role, min) {
+		err = ErrWorkspaceRole
+	}
+	return m, err
+}
+
+// Workspaces lists the workspaces the user belongs to.
+func (s *Service) Workspaces(ctx context.Context, userID int) ([]models.Workspace, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+workspaceColumns+`
+			  FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+			  WHERE m.user_id = $1 ORDER BY w.name, w.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		var w models.Workspace
+		if err := scanWorkspace(rows, &w); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, w)
+	}
+	return workspaces, rows.Err()
+}
+
+// Workspace returns a workspace the user belongs to with its members.
+func (s *Service) Workspace(ctx context.Context, userID, id int) (models.Workspace, error) {
+	var w models.Workspace
+	err := scanWorkspace(s.db.QueryRowContext(ctx, `SELECT `+workspaceColumns+`
+			  FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+			  WHERE w.id = $1 AND m.user_id = $2`, id, userID), &w)
+	if err == sql.ErrNoRows {
+		return w, ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return w, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT u.id, u.email, u.first_name, u.last_name, m.role, m.created_at
+			  FROM workspace_members m JOIN users u ON u.id = m.user_id
+			  WHERE m.workspace_id = $1 ORDER BY m.created_at, u.id`, id)
+	if err != nil {
+		return w, err
+	}
+	defer rows.Close()
+
+	w.Members = []models.WorkspaceMember{}
+	for rows.Next() {
+		var member models.WorkspaceMember
+		if err := rows.Scan(&member.UserID, &member.Email, &member.FirstName, &member.LastName, &member.Role, &member.CreatedAt); err != nil {
+			return w, err
+		}
+		w.Members = append(w.Members, member)
+	}
+	return w, rows.Err()
+}
+
+// CreateWorkspace creates a workspace owned by the user, with a ledger user
+// of its own to hold the books. The ledger user has a random password
+// nobody knows, so it cannot log in.
+func (s *Service) CreateWorkspace(ctx context.Context, userID int, name string) (models.Workspace, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM workspace_members WHERE user_id = $1 AND role = $2`,
+		userID, models.WorkspaceRoles.Owner).Scan(&count)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	if count >= models.WorkspaceSettings.MaxWorkspaces {
+		return models.Workspace{}, ErrTooManyWorkspaces
+	}
+
+	suffix, err := auth.GenerateRandomToken(8)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	password, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	defer tx.Rollback()
+
+	var ledgerID int
+	email := fmt.Sprintf("workspace-%s@%s", strings.ToLower(suffix), models.WorkspaceSettings.EmailDomain)
+	err = tx.QueryRowContext(ctx, `INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
+			  VALUES ($1, $2, $3, '', NOW(), NOW()) RETURNING id`, email, hashedPassword, name).Scan(&ledgerID)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `INSERT INTO workspaces (name, ledger_user_id, created_at, updated_at)
+			  VALUES ($1, $2, NOW(), NOW()) RETURNING id`, name, ledgerID).Scan(&id)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+			  VALUES ($1, $2, $3, NOW())`, id, userID, models.WorkspaceRoles.Owner); err != nil {
+		return models.Workspace{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Workspace{}, err
+	}
+	return s.Workspace(ctx, userID, id)
+}
+
+func (s *Service) RenameWorkspace(ctx context.Context, userID, id int, name string) (models.Workspace, error) {
+	if _, err := s.requireWorkspaceRole(ctx, userID, id, models.WorkspaceRoles.Admin); err != nil {
+		return models.Workspace{}, err
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE workspaces SET name = $2, updated_at = NOW() WHERE id = $1`, id, name); err != nil {
+		return models.Workspace{}, err
+	}
+	return s.Workspace(ctx, userID, id)
+}
+
+// DeleteWorkspace deletes a workspace with its books. Only the owner may.
+func (s *Service) DeleteWorkspace(ctx context.Context, userID, id int) error {
+	m, err := s.requireWorkspaceRole(ctx, userID, id, models.WorkspaceRoles.Owner)
+	if err != nil {
+		return err
+	}
+	return s.deleteUserData(ctx, m.LedgerUserID)
+}
+
+// AddWorkspaceMember adds the user with req.Email to a workspace, as a
+// member unless req.Role says otherwise.
+func (s *Service) AddWorkspaceMember(ctx context.Context, userID, id int, req models.WorkspaceMemberRequest) (models.WorkspaceMember, error) {
+	member := models.WorkspaceMember{Role: req.Role}
+	if member.Role == "" {
+		member.Role = models.WorkspaceRoles.Member
+	}
+
+	if _, err := s.requireWorkspaceRole(ctx, userID, id, models.WorkspaceRoles.Admin); err != nil {
+		return member, err
+	}
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1`, id).Scan(&count); err != nil {
+		return member, err
+	}
+	if count >= models.WorkspaceSettings.MaxMembers {
+		return member, ErrTooManyWorkspaceMembers
+	}
+
+	// Ledger users hold the books of other workspaces and never join one.
+	err := s.db.QueryRowContext(ctx, `SELECT id, email, first_name, last_name FROM users u
+			  WHERE LOWER(email) = LOWER($1) AND NOT EXISTS (SELECT 1 FROM workspaces w WHERE w.ledger_user_id = u.id)`, req.Email).
+		Scan(&member.UserID, &member.Email, &member.FirstName, &member.LastName)
+	if err == sql.ErrNoRows {
+		return member, ErrUserNotFound
+	}
+	if err != nil {
+		return member, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+			  VALUES ($1, $2, $3, NOW()) RETURNING created_at`, id, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if isUniqueViolation(err) {
+		return member, ErrWorkspaceMemberExists
+	}
+	return member, err
+}
+
+func (s *Service) UpdateWorkspaceMember(ctx context.Context, userID, id, memberID int, role string) (models.WorkspaceMember, error) {
+	member := models.WorkspaceMember{UserID: memberID}
+	if _, err := s.requireWorkspaceRole(ctx, userID, id, models.WorkspaceRoles.Admin); err != nil {
+		return member, err
+	}
+	if err := s.ensureNotWorkspaceOwner(ctx, id, memberID); err != nil {
+		return member, err
+	}
+
+	err := s.db.QueryRowContext(ctx, `UPDATE workspace_members m SET role = $3
+			  FROM users u WHERE u.id = m.user_id AND m.workspace_id = $1 AND m.user_id = $2
+			  RETURNING u.email, u.first_name, u.last_name, m.role, m.created_at`, id, memberID, role).
+		Scan(&member.Email, &member.FirstName, &member.LastName, &member.Role, &member.CreatedAt)
+	if err == sql.ErrNoRows {
+		return member, ErrWorkspaceMemberNotFound
+	}
+	return member, err
+}
+
+// RemoveWorkspaceMember removes a member from a workspace. Admins may remove
+// anyone but the owner, and every member may leave.
+func (s *Service) RemoveWorkspaceMember(ctx context.Context, userID, id, memberID int) error {
+	min := models.WorkspaceRoles.Admin
+	if memberID == userID {
+		min = models.WorkspaceRoles.Viewer
+	}
+	if _, err := s.requireWorkspaceRole(ctx, userID, id, min); err != nil {
+		return err
+	}
+
+	if err := s.ensureNotWorkspaceOwner(ctx, id, memberID); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, id, memberID)
+	return err
+}
+
+// ensureNotWorkspaceOwner returns ErrWorkspaceOwner for the workspace's
+// owner and ErrWorkspaceMemberNotFound for someone who is not a member.
+func (s *Service) ensureNotWorkspaceOwner(ctx context.Context, id, memberID int) error {
+	var role string
+	err := s.db.QueryRowContext(ctx, `SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, id, memberID).
+		Scan(&role)
+	if err == sql.ErrNoRows {
+		return ErrWorkspaceMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role == models.WorkspaceRoles.Owner {
+		return ErrWorkspaceOwner
+	}
+	return nil
+}
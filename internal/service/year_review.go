@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// YearReview sums up a calendar year in the user's timezone: totals and
+// savings rate, the months one by one, where the money went and the
+// category that shrank the most against the year before.
+func (s *Service) YearReview(ctx context.Context, userID, year int, scope string) (models.YearReview, error) {
+	review := models.YearReview{Year: year, Scope: scope, TopMerchants: []models.YearReviewMerchant{}}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return review, err
+	}
+	review.Currency = settings.BaseCurrency
+	loc := SettingsLocation(settings)
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	if review.Months, err = s.yearReviewMonths(ctx, userID, start, scope); err != nil {
+		return review, err
+	}
+	for _, month := range review.Months {
+		review.TotalIncome += month.TotalIncome
+		review.TotalExpenses += month.TotalExpenses
+	}
+	review.NetIncome = review.TotalIncome - review.TotalExpenses
+	if review.TotalIncome > 0 {
+		review.SavingsRate = math.Round(review.NetIncome/review.TotalIncome*1000) / 10
+	}
+
+	totals, err := s.CategoryTotals(ctx, userID, start, end, scope)
+	if err != nil {
+		return review, err
+	}
+	previous, err := s.CategoryTotals(ctx, userID, start.AddDate(-1, 0, 0), start, scope)
+	if err != nil {
+		return review, err
+	}
+	for _, total := range totals {
+		review.TransactionCount += total.Count
+	}
+	if err := s.yearReviewCategories(ctx, userID, &review, totals, previous); err != nil {
+		return review, err
+	}
+
+	query := `SELECT merchant, SUM(amount), COUNT(*) FROM (
+				  SELECT COALESCE(py.name, NULLIF(TRIM(t.description), '')) AS merchant, t.amount
+				  FROM transactions t
+				  ` + reportDimensions["payee"].join + `
+				  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date < $3 AND ($4 = '' OR t.scope = $4)
+			  ) m
+			  WHERE merchant IS NOT NULL
+			  GROUP BY merchant
+			  ORDER BY 2 DESC, merchant
+			  LIMIT $5`
+
+	rows, err := s.ReadDB().QueryContext(ctx, query, userID, start, end, scope, models.YearReviewSettings.TopMerchants)
+	if err != nil {
+		return review, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var merchant models.YearReviewMerchant
+		if err := rows.Scan(&merchant.Name, &merchant.Amount, &merchant.Count); err != nil {
+			return review, err
+		}
+		review.TopMerchants = append(review.TopMerchants, merchant)
+	}
+	if err := rows.Err(); err != nil {
+		return review, err
+	}
+
+	var expense models.YearReviewExpense
+	var date time.Time
+	err = s.ReadDB().QueryRowContext(ctx, `SELECT t.id, t.date, COALESCE(t.description, ''), COALESCE(c.name, ''), t.amount
+			  FROM transactions t
+			  LEFT JOIN categories c ON c.id = t.category_id
+			  WHERE t.user_id = $1 AND t.type = 'expense' AND t.date >= $2 AND t.date < $3 AND ($4 = '' OR t.scope = $4)
+			  ORDER BY t.amount DESC, t.date, t.id
+			  LIMIT 1`, userID, start, end, scope).
+		Scan(&expense.TransactionID, &date, &expense.Description, &expense.CategoryName, &expense.Amount)
+	if err != nil && err != sql.ErrNoRows {
+		return review, err
+	}
+	if err == nil {
+		expense.Date = date.In(loc).Format("2006-01-02")
+		review.BiggestExpense = &expense
+	}
+	return review, nil
+}
+
+// yearReviewMonths reads the twelve months of the year from the monthly
+// rollups; a calendar year always consists of whole months.
+func (s *Service) yearReviewMonths(ctx context.Context, userID int, start time.Time, scope string) ([]models.YearReviewMonth, error) {
+	months := make([]models.YearReviewMonth, 12)
+	for i := range months {
+		months[i].Month = start.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT EXTRACT(MONTH FROM month)::int, type, SUM(total)
+			  FROM transaction_monthly_rollups
+			  WHERE user_id = $1 AND month >= $2::date AND month < $3::date AND ($4 = '' OR scope = $4) AND type IN ('income', 'expense')
+			  GROUP BY 1, 2`, userID, start.Format("2006-01-02"), start.AddDate(1, 0, 0).Format("2006-01-02"), scope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var month int
+		var kind string
+		var total float64
+		if err := rows.Scan(&month, &kind, &total); err != nil {
+			return nil, err
+		}
+		if kind == "income" {
+			months[month-1].TotalIncome = total
+		} else {
+			months[month-1].TotalExpenses = total
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range months {
+		months[i].NetIncome = months[i].TotalIncome - months[i].TotalExpenses
+	}
+	return months, nil
+}
+
+// yearReviewCategories fills in the categories with the most spending and
+// the one whose spending fell the most since the previous year.
+func (s *Service) yearReviewCategories(ctx context.Context, userID int, review *models.YearReview, totals, previous []models.CategoryTotal) error {
+	spent := make(map[int]float64)
+	for _, total := range totals {
+		if total.Type == "expense" {
+			spent[total.CategoryID] += total.Amount
+		}
+	}
+	before := make(map[int]float64)
+	for _, total := range previous {
+		if total.Type == "expense" {
+			before[total.CategoryID] += total.Amount
+		}
+	}
+
+	rows, err := s.ReadDB().QueryContext(ctx, `SELECT id, name FROM categories WHERE user_id = $1 AND type = 'expense'`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	review.TopCategories = []models.SpendingByCategory{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		if amount := spent[id]; amount > 0 {
+			category := models.SpendingByCategory{CategoryID: id, CategoryName: name, Amount: amount}
+			if review.TotalExpenses > 0 {
+				category.Percentage = amount / review.TotalExpenses * 100
+			}
+			review.TopCategories = append(review.TopCategories, category)
+		}
+
+		if before[id] <= 0 {
+			continue
+		}
+		change := spent[id] - before[id]
+		if change < 0 && (review.MostImproved == nil || change < review.MostImproved.Change) {
+			review.MostImproved = &models.YearReviewCategoryChange{
+				CategoryID:     id,
+				CategoryName:   name,
+				Amount:         spent[id],
+				PreviousAmount: before[id],
+				Change:         change,
+				ChangePercent:  math.Round(change/before[id]*1000) / 10,
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.SliceStable(review.TopCategories, func(i, j int) bool {
+		return review.TopCategories[i].Amount > review.TopCategories[j].Amount
+	})
+	if len(review.TopCategories) > models.YearReviewSettings.TopCategories {
+		review.TopCategories = review.TopCategories[:models.YearReviewSettings.TopCategories]
+	}
+	return nil
+}
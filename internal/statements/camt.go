@@ -0,0 +1,107 @@
+package statements
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// camtDocument maps the parts of a CAMT.053 statement imports use. Elements
+// are matched by local name, so any camt.053.001.xx namespace is accepted.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount    string `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+	Reversal  bool   `xml:"RvslInd"`
+	// Sts is plain text up to version 06 and holds a Cd element after.
+	Status struct {
+		Text string `xml:",chardata"`
+		Code string `xml:"Cd"`
+	} `xml:"Sts"`
+	Booking struct {
+		Date     string `xml:"Dt"`
+		DateTime string `xml:"DtTm"`
+	} `xml:"BookgDt"`
+	Value struct {
+		Date string `xml:"Dt"`
+	} `xml:"ValDt"`
+	Info    string `xml:"AddtlNtryInf"`
+	Details []struct {
+		Creditor     string   `xml:"RltdPties>Cdtr>Nm"`
+		Debtor       string   `xml:"RltdPties>Dbtr>Nm"`
+		Unstructured []string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// parseCAMT reads the booked entries of a CAMT.053 statement. Pending
+// entries are left out as they may still change.
+func parseCAMT(data []byte) ([]Entry, error) {
+	var document camtDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parse CAMT.053: %w", err)
+	}
+
+	var entries []Entry
+	for _, statement := range document.Statements {
+		for _, ntry := range statement.Entries {
+			if status := strings.TrimSpace(ntry.Status.Text + ntry.Status.Code); status != "" && status != "BOOK" {
+				continue
+			}
+			entry, err := camtEntryOf(ntry)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func camtEntryOf(ntry camtEntry) (Entry, error) {
+	var entry Entry
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(ntry.Amount), 64)
+	if err != nil {
+		return entry, fmt.Errorf("invalid entry amount %q", ntry.Amount)
+	}
+	// A reversal flips the direction of the indicator.
+	if (strings.TrimSpace(ntry.Indicator) == "DBIT") != ntry.Reversal {
+		amount = -amount
+	}
+	entry.Amount = amount
+
+	date := strings.TrimSpace(ntry.Booking.Date)
+	if date == "" && len(ntry.Booking.DateTime) >= 10 {
+		date = ntry.Booking.DateTime[:10]
+	}
+	if date == "" {
+		date = strings.TrimSpace(ntry.Value.Date)
+	}
+	if entry.Date, err = time.Parse("2006-01-02", date); err != nil {
+		return entry, fmt.Errorf("invalid entry date %q", date)
+	}
+
+	var parts []string
+	for _, details := range ntry.Details {
+		// The counterparty is the creditor of outgoing payments and the
+		// debtor of incoming ones.
+		if amount < 0 {
+			parts = append(parts, details.Creditor)
+		} else {
+			parts = append(parts, details.Debtor)
+		}
+		parts = append(parts, details.Unstructured...)
+	}
+	entry.Description = joinText(parts...)
+	if entry.Description == "" {
+		entry.Description = joinText(ntry.Info)
+	}
+	return entry, nil
+}
@@ -0,0 +1,127 @@
+package statements
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// :61: value date YYMMDD, optional entry date MMDD, mark (C, D, RC, RD),
+	// optional funds code and the amount with a decimal comma.
+	mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)[A-Z]?(\d+,\d{0,2})`)
+	// Structured :86: fields split information into ?NN (or ~NN, ^NN)
+	// subfields.
+	mt940Subfield = regexp.MustCompile(`[?~^](\d{2})`)
+)
+
+// parseMT940 reads the :61: statement lines of an MT940 file and describes
+// each with the :86: information that follows it.
+func parseMT940(data []byte) ([]Entry, error) {
+	var entries []Entry
+	var tag, value, previous string
+
+	flush := func() error {
+		switch tag {
+		case "61":
+			entry, err := parseMT940Line(value)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		case "86":
+			if description := mt940Description(value); previous == "61" && description != "" {
+				entries[len(entries)-1].Description = description
+			}
+		}
+		previous, tag, value = tag, "", ""
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		if text == "-" || text == "-}" {
+			continue
+		}
+		if len(text) > 3 && text[0] == ':' && strings.Index(text[1:], ":") > 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			end := strings.Index(text[1:], ":") + 1
+			tag = strings.TrimRight(text[1:end], "ABCDFM")
+			value = text[end+1:]
+			continue
+		}
+		// Continuation line of the current field.
+		value += "\n" + text
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseMT940Line reads a :61: field. Its supplementary details line, if any,
+// describes the entry until a :86: field replaces it.
+func parseMT940Line(value string) (Entry, error) {
+	lines := strings.SplitN(value, "\n", 2)
+	match := mt940Line.FindStringSubmatch(lines[0])
+	if match == nil {
+		return Entry{}, fmt.Errorf("invalid :61: line %q", value)
+	}
+
+	date, err := time.Parse("060102", match[1])
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid date in :61: line %q", value)
+	}
+	amount, err := strconv.ParseFloat(strings.Replace(match[4], ",", ".", 1), 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("invalid amount in :61: line %q", value)
+	}
+
+	// Debits and reversed credits take money out of the account.
+	if match[3] == "D" || match[3] == "RC" {
+		amount = -amount
+	}
+	entry := Entry{Date: date, Amount: amount}
+	if len(lines) > 1 {
+		entry.Description = joinText(lines[1])
+	}
+	return entry, nil
+}
+
+// mt940Description uses the remittance (20-29) and counterparty name (32-33)
+// subfields of structured :86: fields, and the whole text otherwise.
+func mt940Description(value string) string {
+	joined := strings.ReplaceAll(value, "\n", "")
+	indexes := mt940Subfield.FindAllStringSubmatchIndex(joined, -1)
+	if len(indexes) == 0 {
+		return joinText(value)
+	}
+	value = joined
+
+	var remittance, counterparty []string
+	for i, index := range indexes {
+		end := len(value)
+		if i+1 < len(indexes) {
+			end = indexes[i+1][0]
+		}
+		code, _ := strconv.Atoi(value[index[2]:index[3]])
+		text := value[index[1]:end]
+		switch {
+		case code >= 20 && code <= 29:
+			remittance = append(remittance, text)
+		case code == 32 || code == 33:
+			counterparty = append(counterparty, text)
+		}
+	}
+	return joinText(append(counterparty, remittance...)...)
+}
@@ -0,0 +1,55 @@
+package statements
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"personal-finance-tracker/internal/models"
+)
+
+// Built-in import profiles live in profiles/, one JSON file per bank named
+// after the profile's key. Supporting another bank only takes a new file.
+//
+//go:embed profiles/*.json
+var profileFiles embed.FS
+
+var builtinProfiles []models.ImportProfile
+
+func init() {
+	files, err := profileFiles.ReadDir("profiles")
+	if err != nil {
+		log.Fatalf("Failed to read import profiles: %v", err)
+	}
+	for _, file := range files {
+		data, err := profileFiles.ReadFile(path.Join("profiles", file.Name()))
+		if err != nil {
+			log.Fatalf("Failed to read import profile %s: %v", file.Name(), err)
+		}
+		var profile models.ImportProfile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			log.Fatalf("Invalid import profile %s: %v", file.Name(), err)
+		}
+		profile.Key = strings.TrimSuffix(file.Name(), ".json")
+		profile.Builtin = true
+		builtinProfiles = append(builtinProfiles, profile)
+	}
+	sort.Slice(builtinProfiles, func(i, j int) bool { return builtinProfiles[i].Name < builtinProfiles[j].Name })
+}
+
+// BuiltinProfiles returns the profiles shipped for common banks, by name.
+func BuiltinProfiles() []models.ImportProfile {
+	return append([]models.ImportProfile(nil), builtinProfiles...)
+}
+
+func BuiltinProfile(key string) (models.ImportProfile, bool) {
+	for _, profile := range builtinProfiles {
+		if profile.Key == key {
+			return profile, true
+		}
+	}
+	return models.ImportProfile{}, false
+}
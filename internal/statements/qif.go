@@ -0,0 +1,95 @@
+package statements
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "02.01.2006", "02.01.06"}
+
+// parseQIF reads the bank and cash sections of a QIF file. Investment and
+// list sections (!Type:Invst, !Type:Cat, ...) are skipped.
+func parseQIF(data []byte) ([]Entry, error) {
+	var entries []Entry
+	var entry Entry
+	var payee, memo string
+	var hasAmount, skip bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			header := strings.ToLower(text)
+			skip = strings.HasPrefix(header, "!type:") && !qifBankSection(strings.TrimPrefix(header, "!type:"))
+			continue
+		}
+		if skip {
+			continue
+		}
+
+		value := strings.TrimSpace(text[1:])
+		switch text[0] {
+		case 'D':
+			date, err := parseQIFDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			entry.Date = date
+		case 'T', 'U':
+			amount, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid amount %q", line, value)
+			}
+			entry.Amount = amount
+			hasAmount = true
+		case 'P':
+			payee = value
+		case 'M':
+			memo = value
+		case 'L':
+			// Transfers are written as [Account name] and have no category;
+			// subcategories (Food:Groceries) import under the top level one.
+			if !strings.HasPrefix(value, "[") {
+				entry.Category = strings.SplitN(value, ":", 2)[0]
+			}
+		case '^':
+			if entry.Date.IsZero() || !hasAmount {
+				return nil, fmt.Errorf("line %d: transaction without date or amount", line)
+			}
+			entry.Description = joinText(payee, memo)
+			entries = append(entries, entry)
+			entry, payee, memo, hasAmount = Entry{}, "", "", false
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func qifBankSection(kind string) bool {
+	switch kind {
+	case "bank", "cash", "ccard", "oth a", "oth l":
+		return true
+	}
+	return false
+}
+
+// parseQIFDate accepts US dates, including the 1/2'06 form older software
+// writes for years after 1999, and the European forms some banks use.
+func parseQIFDate(value string) (time.Time, error) {
+	value = strings.ReplaceAll(strings.ReplaceAll(value, "'", "/"), " ", "")
+	for _, layout := range qifDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
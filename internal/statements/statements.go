@@ -0,0 +1,93 @@
+// Package statements reads bank statement formats other than CSV into plain
+// entries, so imports can run them through the same row pipeline.
+package statements
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	CSV   = "csv"
+	QIF   = "qif"
+	MT940 = "mt940"
+	CAMT  = "camt053"
+)
+
+var ContentTypes = map[string]string{
+	CSV:   "text/csv",
+	QIF:   "application/qif",
+	MT940: "text/plain",
+	CAMT:  "application/xml",
+}
+
+var ErrNoEntries = errors.New("statement has no entries")
+
+// Entry is one booked statement line. Amount is negative for money leaving
+// the account. Category is only set by formats that carry one (QIF).
+type Entry struct {
+	Date        time.Time
+	Amount      float64
+	Description string
+	Category    string
+}
+
+// Detect picks the format from the file extension, falling back to the
+// content for files with a generic one. Anything unrecognised is CSV.
+func Detect(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".qif":
+		return QIF
+	case ".sta", ".mt940", ".940":
+		return MT940
+	}
+
+	head := bytes.TrimSpace(data)
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("!Type:")), bytes.HasPrefix(head, []byte("!Account")):
+		return QIF
+	case bytes.Contains(head, []byte("BkToCstmrStmt")):
+		return CAMT
+	case bytes.Contains(head, []byte(":20:")) && bytes.Contains(head, []byte(":61:")):
+		return MT940
+	}
+	return CSV
+}
+
+// Parse reads a QIF, MT940 or CAMT.053 statement.
+func Parse(format string, data []byte) ([]Entry, error) {
+	var entries []Entry
+	var err error
+	switch format {
+	case QIF:
+		entries, err = parseQIF(data)
+	case MT940:
+		entries, err = parseMT940(data)
+	case CAMT:
+		entries, err = parseCAMT(data)
+	default:
+		return nil, errors.New("unsupported statement format " + format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNoEntries
+	}
+	return entries, nil
+}
+
+// joinText collapses the parts of a description into one line.
+func joinText(parts ...string) string {
+	var words []string
+	for _, part := range parts {
+		words = append(words, strings.Fields(part)...)
+	}
+	return strings.Join(words, " ")
+}
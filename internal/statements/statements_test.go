@@ -0,0 +1,97 @@
+package statements
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		want   []Entry
+	}{
+		{"sample.qif", QIF, []Entry{
+			{Date: day("2026-03-14"), Amount: -45.20, Description: "Biedronka Weekly shopping", Category: "Food"},
+			{Date: day("2026-03-15"), Amount: 2500, Description: "ACME Corp", Category: "Salary"},
+			{Date: day("2026-03-16"), Amount: -100, Description: "Transfer to savings"},
+		}},
+		{"sample.sta", MT940, []Entry{
+			{Date: day("2026-03-14"), Amount: -45.20, Description: "BIEDRONKA SKLEP 123 Zakupy spozywcze tydzien 11"},
+			{Date: day("2026-03-15"), Amount: 2500, Description: "Wynagrodzenie za marzec"},
+			{Date: day("2026-03-16"), Amount: -12.50, Description: "ZWROT PROWIZJI"},
+		}},
+		{"sample.xml", CAMT, []Entry{
+			{Date: day("2026-03-14"), Amount: -45.20, Description: "Lidl Weekly shopping"},
+			{Date: day("2026-03-15"), Amount: 2500, Description: "ACME Corp Salary March"},
+			{Date: day("2026-03-16"), Amount: -12.50, Description: "Reversed refund"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format := Detect(tt.file, data); format != tt.format {
+				t.Errorf("Detect() = %s, want %s", format, tt.format)
+			}
+
+			entries, err := Parse(tt.format, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("Parse() returned %d entries, want %d: %+v", len(entries), len(tt.want), entries)
+			}
+			for i, entry := range entries {
+				if !entry.Date.Equal(tt.want[i].Date) || entry.Amount != tt.want[i].Amount ||
+					entry.Description != tt.want[i].Description || entry.Category != tt.want[i].Category {
+					t.Errorf("entry %d = %+v, want %+v", i, entry, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{"qif invalid date", QIF, "!Type:Bank\nD13/45/2026\nT-1.00\n^\n"},
+		{"qif invalid amount", QIF, "!Type:Bank\nD3/14/2026\nTabc\n^\n"},
+		{"qif without amount", QIF, "!Type:Bank\nD3/14/2026\nPShop\n^\n"},
+		{"qif without entries", QIF, "!Type:Bank\n"},
+		{"mt940 invalid statement line", MT940, ":20:STARTUMS\n:61:26AB14C1,00NTRF\n"},
+		{"mt940 invalid date", MT940, ":20:STARTUMS\n:61:261340C1,00NTRF\n"},
+		{"mt940 without entries", MT940, ":20:STARTUMS\n:60F:C260313PLN1000,00\n"},
+		{"camt truncated", CAMT, "<Document><BkToCstmrStmt><Stmt><Ntry>"},
+		{"camt invalid amount", CAMT, "<Document><BkToCstmrStmt><Stmt><Ntry><Amt>abc</Amt><BookgDt><Dt>2026-03-14</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>"},
+		{"camt without date", CAMT, "<Document><BkToCstmrStmt><Stmt><Ntry><Amt>1.00</Amt></Ntry></Stmt></BkToCstmrStmt></Document>"},
+		{"camt without entries", CAMT, "<Document><BkToCstmrStmt><Stmt></Stmt></BkToCstmrStmt></Document>"},
+		{"unsupported format", "ofx", "<OFX></OFX>"},
+	}
+
+	for _, tt := range tests {
+		if entries, err := Parse(tt.format, []byte(tt.data)); err == nil {
+			t.Errorf("%s: Parse() = %+v, want an error", tt.name, entries)
+		}
+	}
+	if _, err := Parse(QIF, []byte("!Type:Bank\n")); !errors.Is(err, ErrNoEntries) {
+		t.Errorf("Parse() of an empty statement = %v, want ErrNoEntries", err)
+	}
+}
+
+func day(s string) time.Time {
+	date, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDiskWrite(t *testing.T) {
+	disk, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	n, err := disk.Write(ctx, "backups/a.dump", strings.NewReader("dump"))
+	if err != nil || n != 4 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	r, err := disk.Open(ctx, "backups/a.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "dump" {
+		t.Errorf("Open() read %q, %v", data, err)
+	}
+
+	// A reader that fails part way leaves no object, not even a partial one.
+	failed := io.MultiReader(strings.NewReader("part"), errReader{})
+	if _, err := disk.Write(ctx, "backups/b.dump", failed); err == nil {
+		t.Error("Write() of a failing reader succeeded")
+	}
+	if _, err := disk.Open(ctx, "backups/b.dump"); err != ErrNotFound {
+		t.Errorf("Open() after a failed write = %v, want ErrNotFound", err)
+	}
+	objects, err := disk.List(ctx, "backups/")
+	if err != nil || len(objects) != 1 {
+		t.Errorf("List() = %v, %v, want only a.dump", objects, err)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("pg_dump failed")
+}
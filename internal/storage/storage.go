@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"personal-finance-tracker/internal/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps uploaded files such as avatars under slash-separated keys.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, string, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+type Object struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// NewFromConfig stores files in the configured directory when it is set and
+// in the database otherwise, so every instance sees the same files without a
+// shared volume.
+func NewFromConfig(cfg config.StorageConfig, db *sql.DB) Store {
+	dir := cfg.Dir
+	if dir == "" {
+		return NewDatabase(db)
+	}
+
+	store, err := NewDisk(dir)
+	if err != nil {
+		log.Printf("Storage directory unavailable, storing files in the database: %v", err)
+		return NewDatabase(db)
+	}
+	log.Printf("Storing files in %s", dir)
+	return store
+}
+
+type Database struct {
+	db *sql.DB
+}
+
+func NewDatabase(db *sql.DB) *Database {
+	return &Database{db: db}
+}
+
+func (d *Database) Put(ctx context.Context, key, contentType string, data []byte) error {
+	query := `INSERT INTO attachments (key, content_type, data, created_at) VALUES ($1, $2, $3, NOW())
+			  ON CONFLICT (key) DO UPDATE SET content_type = EXCLUDED.content_type, data = EXCLUDED.data, created_at = NOW()`
+
+	_, err := d.db.ExecContext(ctx, query, key, contentType, data)
+	return err
+}
+
+func (d *Database) Get(ctx context.Context, key string) ([]byte, string, error) {
+	var data []byte
+	var contentType string
+	err := d.db.QueryRowContext(ctx, `SELECT data, content_type FROM attachments WHERE key = $1`, key).Scan(&data, &contentType)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrNotFound
+	}
+	return data, contentType, err
+}
+
+func (d *Database) Delete(ctx context.Context, key string) error {
+	_, err := d.db.ExecContext(ctx, `DELETE FROM attachments WHERE key = $1`, key)
+	return err
+}
+
+func (d *Database) List(ctx context.Context, prefix string) ([]Object, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT key, octet_length(data), created_at FROM attachments
+			  WHERE starts_with(key, $1) ORDER BY key`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []Object
+	for rows.Next() {
+		var object Object
+		if err := rows.Scan(&object.Key, &object.Size, &object.ModifiedAt); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
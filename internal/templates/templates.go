@@ -0,0 +1,90 @@
+// Package templates renders the emails and report headings the server
+// sends. Templates are text/template files embedded in the binary, named
+// <name>.txt for English and <name>.<lang>.txt for other languages;
+// operators can replace any of them with a file of the same name under
+// <ASSETS_DIR>/templates.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+	"time"
+
+	"personal-finance-tracker/internal/assets"
+)
+
+//go:embed email reports
+var embedded embed.FS
+
+var files fs.FS = embedded
+
+// UseOverrides makes templates in dir take precedence over the embedded
+// ones. Files are read on every render, so edits apply without a restart.
+func UseOverrides(dir string) {
+	files = assets.Overlay(embedded, dir)
+}
+
+// AccountLocked is the data of email/account_locked.
+type AccountLocked struct {
+	FirstName string
+	Duration  time.Duration
+	Attempts  int
+	IPAddress string
+	UnlockURL string
+}
+
+// EmailChange is the data of email/email_change.
+type EmailChange struct {
+	FirstName    string
+	ConfirmURL   string
+	Expires      time.Duration
+	CurrentEmail string
+}
+
+// ScheduledReport is the data of email/scheduled_report and
+// reports/scheduled_report, the title of PDF reports.
+type ScheduledReport struct {
+	Name   string
+	Period string
+}
+
+// Render executes the template name, such as reports/scheduled_report, in
+// language lang, falling back to English when it has no translation.
+func Render(name, lang string, data interface{}) (string, error) {
+	source, err := fs.ReadFile(files, name+"."+lang+".txt")
+	if errors.Is(err, fs.ErrNotExist) {
+		source, err = fs.ReadFile(files, name+".txt")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(source))
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", name, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("template %s: %w", name, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// Email renders email/<name>, whose first line is "Subject: ..." and the
+// rest the body.
+func Email(name, lang string, data interface{}) (string, string, error) {
+	text, err := Render("email/"+name, lang, data)
+	if err != nil {
+		return "", "", err
+	}
+	header, body, _ := strings.Cut(text, "\n")
+	subject, ok := strings.CutPrefix(header, "Subject:")
+	if !ok {
+		return "", "", fmt.Errorf("template email/%s must start with a Subject: line", name)
+	}
+	return strings.TrimSpace(subject), strings.TrimSpace(body), nil
+}
@@ -0,0 +1,220 @@
+// Package testdb provides Postgres databases for tests. The server is the one
+// in TEST_DATABASE_URL or, when that is unset, a throwaway postgres container
+// started with docker; tests that need a database are skipped when neither
+// is available. Every test gets a database of its own, so tests may run in
+// parallel and leave whatever rows they like behind.
+package testdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"personal-finance-tracker/internal/database"
+	"personal-finance-tracker/migrations"
+
+	"github.com/lib/pq"
+)
+
+// image is the server the container runs; keep it in step with
+// docker-compose.yml.
+const image = "postgres:15-alpine"
+
+var (
+	serverOnce sync.Once
+	serverDSN  string
+	container  string
+	serverErr  error
+
+	templateOnce sync.Once
+	templateErr  error
+
+	counter atomic.Int64
+)
+
+// Main runs the tests of a package and stops the container, if one was
+// started, before exiting. Packages using testdb call it from TestMain.
+func Main(m *testing.M) {
+	code := m.Run()
+	if serverDSN != "" && templateErr == nil {
+		if db, err := sql.Open("postgres", serverDSN); err == nil {
+			db.Exec(`DROP DATABASE IF EXISTS ` + pq.QuoteIdentifier(templateName()))
+			db.Close()
+		}
+	}
+	if container != "" {
+		exec.Command("docker", "stop", container).Run()
+	}
+	os.Exit(code)
+}
+
+// New returns a database with every migration applied.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+	admin := server(t)
+
+	templateOnce.Do(func() { templateErr = createTemplate(admin) })
+	if templateErr != nil {
+		t.Fatalf("migrating the template database: %v", templateErr)
+	}
+	return create(t, admin, "TEMPLATE "+pq.QuoteIdentifier(templateName()))
+}
+
+// Empty returns a database without any tables.
+func Empty(t testing.TB) *sql.DB {
+	t.Helper()
+	return create(t, server(t), "")
+}
+
+func server(t testing.TB) *sql.DB {
+	t.Helper()
+	serverOnce.Do(func() { serverErr = start() })
+	if serverErr != nil {
+		t.Skipf("no test database: %v", serverErr)
+	}
+	admin, err := open(serverDSN)
+	if err != nil {
+		t.Fatalf("connecting to the test server: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	return admin
+}
+
+func start() error {
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		dsn, err := pq.ParseURL(url)
+		if err != nil {
+			return fmt.Errorf("invalid TEST_DATABASE_URL: %w", err)
+		}
+		serverDSN = dsn
+		return nil
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		return errors.New("set TEST_DATABASE_URL or install docker")
+	}
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=postgres",
+		"-p", "127.0.0.1::5432", image).Output()
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", image, err)
+	}
+	container = strings.TrimSpace(string(out))
+
+	out, err = exec.Command("docker", "port", container, "5432/tcp").Output()
+	if err != nil {
+		return fmt.Errorf("reading the container port: %w", err)
+	}
+	addr := strings.TrimSpace(strings.Split(string(out), "\n")[0])
+	host, port, _ := strings.Cut(addr, ":")
+	serverDSN = fmt.Sprintf("host=%s port=%s user=postgres password=postgres dbname=postgres sslmode=disable", host, port)
+
+	// The server restarts once while the image initializes, so wait for it
+	// to stay up rather than for the first successful connection.
+	deadline := time.Now().Add(time.Minute)
+	for ready := 0; ready < 2; {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s did not start", image)
+		}
+		time.Sleep(500 * time.Millisecond)
+		db, err := open(serverDSN)
+		if err != nil {
+			ready = 0
+			continue
+		}
+		db.Close()
+		ready++
+	}
+	return nil
+}
+
+// templateName is per process: go test runs packages in parallel, all
+// against the same server when TEST_DATABASE_URL is set.
+func templateName() string {
+	return fmt.Sprintf("pft_template_%d", os.Getpid())
+}
+
+func createTemplate(admin *sql.DB) error {
+	name := templateName()
+	if _, err := admin.Exec(`DROP DATABASE IF EXISTS ` + pq.QuoteIdentifier(name)); err != nil {
+		return err
+	}
+	if _, err := admin.Exec(`CREATE DATABASE ` + pq.QuoteIdentifier(name)); err != nil {
+		return err
+	}
+	db, err := open(serverDSN + " dbname=" + name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = database.Migrate(db, migrations.Files)
+	return err
+}
+
+func create(t testing.TB, admin *sql.DB, options string) *sql.DB {
+	t.Helper()
+	name := fmt.Sprintf("pft_test_%d_%d", os.Getpid(), counter.Add(1))
+	if _, err := admin.Exec(`CREATE DATABASE ` + pq.QuoteIdentifier(name) + ` ` + options); err != nil {
+		t.Fatalf("creating database %s: %v", name, err)
+	}
+
+	db, err := open(serverDSN + " dbname=" + name)
+	if err != nil {
+		t.Fatalf("connecting to database %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec(`DROP DATABASE IF EXISTS ` + pq.QuoteIdentifier(name) + ` WITH (FORCE)`)
+	})
+	return db
+}
+
+// open connects in UTC, as database.Initialize does.
+func open(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn+" timezone=UTC")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Ledger adds a user with an account, a handful of categories and count
+// transactions spread over the days before today, for benchmarks that need
+// history to aggregate. It returns the user's ID.
+func Ledger(t testing.TB, db *sql.DB, days, count int) int {
+	t.Helper()
+	var userID, accountID int
+	err := db.QueryRow(`INSERT INTO users (email, password_hash) VALUES ('ledger' || nextval('users_id_seq') || '@example.com', '')
+			  RETURNING id`).Scan(&userID)
+	if err == nil {
+		err = db.QueryRow(`INSERT INTO accounts (user_id, name, type) VALUES ($1, 'Checking', 'checking') RETURNING id`, userID).Scan(&accountID)
+	}
+	if err == nil {
+		_, err = db.Exec(`INSERT INTO categories (user_id, name, type)
+				  SELECT $1, name, type FROM (VALUES ('Salary', 'income'), ('Rent', 'expense'), ('Groceries', 'expense'),
+				  ('Dining', 'expense'), ('Transport', 'expense'), ('Utilities', 'expense'), ('Fun', 'expense')) c(name, type)`, userID)
+	}
+	if err == nil {
+		_, err = db.Exec(`WITH c AS (
+					  SELECT id, type, row_number() OVER (ORDER BY id) - 1 AS n, COUNT(*) OVER () AS k
+					  FROM categories WHERE user_id = $1)
+				  INSERT INTO transactions (user_id, account_id, category_id, amount, type, description, date)
+				  SELECT $1, $2, c.id, 5 + (g * 37) % 200, c.type, 'Seeded', CURRENT_DATE - (g % $3)
+				  FROM generate_series(0, $4 - 1) g JOIN c ON c.n = g % c.k`, userID, accountID, days, count)
+	}
+	if err != nil {
+		t.Fatalf("seeding a ledger: %v", err)
+	}
+	return userID
+}
@@ -0,0 +1,161 @@
+// Package tracing sets up OpenTelemetry, exporting spans to an OTLP/HTTP
+// collector. HTTP requests are traced by otelgin and SQL statements by
+// otelsql; this package covers the rest: trace context stored with jobs,
+// outgoing webhooks and how incoming traces are sampled. Trace context
+// travels between services in the W3C traceparent header. Until Setup
+// installs a provider the global one is a no-op, so spans cost nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"personal-finance-tracker/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName names the spans this package starts.
+const ScopeName = "personal-finance-tracker/internal/tracing"
+
+const traceParentHeader = "traceparent"
+
+var (
+	enabled    atomic.Bool
+	propagator = propagation.TraceContext{}
+)
+
+// Setup installs a tracer provider that exports to cfg.Endpoint in batches.
+// With no endpoint it leaves tracing off.
+func Setup(cfg config.TracingConfig) error {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.Host),
+		otlptracehttp.WithURLPath(strings.TrimRight(endpoint.Path, "/") + "/v1/traces"),
+		otlptracehttp.WithHeaders(parseHeaders(cfg.Headers)),
+	}
+	if endpoint.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+		sdktrace.WithSampler(Sampler(cfg.SampleRatio)),
+	))
+	otel.SetTextMapPropagator(propagator)
+	enabled.Store(true)
+	return nil
+}
+
+// Sampler records ratio of the traces, deciding by trace ID so that every
+// service keeps the same ones. A span follows its parent in this process,
+// but a remote parent cannot force sampling: a client's traceparent joins
+// its trace to ours, and the trace is then sampled by ratio like a new one.
+// Jobs continue the trace of whoever enqueued them, whose trace ID got the
+// same decision.
+func Sampler(ratio float64) sdktrace.Sampler {
+	byTraceID := sdktrace.TraceIDRatioBased(ratio)
+	return sdktrace.ParentBased(byTraceID,
+		sdktrace.WithRemoteParentSampled(byTraceID),
+		sdktrace.WithRemoteParentNotSampled(byTraceID))
+}
+
+// parseHeaders reads OTEL_EXPORTER_OTLP_HEADERS: key=value pairs separated
+// by commas, with URL-encoded values.
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = unescaped
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+func Enabled() bool {
+	return enabled.Load()
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// StartRemote begins a span continuing the trace in a traceparent value,
+// such as one stored with a job. An empty or invalid value starts a new
+// trace.
+func StartRemote(ctx context.Context, name string, kind trace.SpanKind, traceParent string) (context.Context, trace.Span) {
+	if traceParent != "" {
+		ctx = propagator.Extract(ctx, propagation.MapCarrier{traceParentHeader: traceParent})
+	}
+	return tracer().Start(ctx, name, trace.WithSpanKind(kind))
+}
+
+// TraceParent returns the traceparent value of the span in ctx, or "" when
+// there is none.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier[traceParentHeader]
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or "" when there is
+// none, for correlating logs and error reports with traces.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Do sends an outgoing request in a client span named name, passing the
+// trace on to the receiver.
+func Do(client *http.Client, req *http.Request, name string) (*http.Response, error) {
+	ctx, span := tracer().Start(req.Context(), name, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname())))
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
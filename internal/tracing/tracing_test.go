@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a provider sampling ratio of traces for the test and
+// returns what it records.
+func record(t *testing.T, ratio float64) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(Sampler(ratio)), sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestSamplerAppliesRatioToRemoteParents(t *testing.T) {
+	// The ratio keeps trace IDs whose low half, shifted right by one bit,
+	// is below ratio * 2^63.
+	const kept = "4bf92f3577b34da60000000000000001"
+	const dropped = "4bf92f3577b34da6fffffffffffffff0"
+
+	tests := []struct {
+		name    string
+		ratio   float64
+		traceID string
+		flags   string
+		want    bool
+	}{
+		{"sampled caller within the ratio", 0.5, kept, "01", true},
+		{"sampled caller outside the ratio", 0.5, dropped, "01", false},
+		{"unsampled caller within the ratio", 0.5, kept, "00", true},
+		{"unsampled caller outside the ratio", 0.5, dropped, "00", false},
+		{"sampling off", 0, kept, "01", false},
+		{"sampling everything", 1, dropped, "00", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record(t, tt.ratio)
+			traceParent := "00-" + tt.traceID + "-00f067aa0ba902b7-" + tt.flags
+
+			ctx, span := StartRemote(context.Background(), "request", trace.SpanKindServer, traceParent)
+			_, child := StartRemote(ctx, "query", trace.SpanKindClient, "")
+			child.End()
+			span.End()
+
+			if got := span.SpanContext().IsSampled(); got != tt.want {
+				t.Errorf("sampled = %v, want %v", got, tt.want)
+			}
+			if got := span.SpanContext().TraceID().String(); got != tt.traceID {
+				t.Errorf("trace ID = %s, want the caller's %s", got, tt.traceID)
+			}
+			if child.SpanContext().IsSampled() != tt.want {
+				t.Error("a child span did not follow its parent's decision")
+			}
+			if n := len(recorder.Ended()); (n == 2) != tt.want {
+				t.Errorf("recorded %d spans", n)
+			}
+		})
+	}
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	recorder := record(t, 1)
+
+	ctx, request := StartRemote(context.Background(), "request", trace.SpanKindServer, "")
+	stored := TraceParent(ctx)
+	request.End()
+
+	want := "00-" + request.SpanContext().TraceID().String() + "-" + request.SpanContext().SpanID().String() + "-01"
+	if stored != want {
+		t.Fatalf("TraceParent = %q, want %q", stored, want)
+	}
+	if got := TraceID(ctx); got != request.SpanContext().TraceID().String() {
+		t.Errorf("TraceID = %q", got)
+	}
+
+	_, job := StartRemote(context.Background(), "job", trace.SpanKindConsumer, stored)
+	job.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	if spans[1].SpanContext().TraceID() != request.SpanContext().TraceID() || spans[1].Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("job span %s is not a child of the request span %s", spans[1].Parent().SpanID(), request.SpanContext().SpanID())
+	}
+	if !spans[1].Parent().IsRemote() {
+		t.Error("job span's parent is not remote")
+	}
+
+	for _, value := range []string{"", "garbage", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"} {
+		_, span := StartRemote(context.Background(), "job", trace.SpanKindConsumer, value)
+		if span.SpanContext().TraceID() == request.SpanContext().TraceID() || span.SpanContext().Equal(trace.SpanContext{}) {
+			t.Errorf("StartRemote(%q) did not start a new trace", value)
+		}
+		span.End()
+	}
+}
+
+func TestTraceParentWithoutSpan(t *testing.T) {
+	if got := TraceParent(context.Background()); got != "" {
+		t.Errorf("TraceParent = %q, want none", got)
+	}
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID = %q, want none", got)
+	}
+}
+
+func TestDoPassesTraceOn(t *testing.T) {
+	recorder := record(t, 1)
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, parent := StartRemote(context.Background(), "job", trace.SpanKindConsumer, "")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	resp, err := Do(server.Client(), req, "webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	client := spans[0]
+	if client.Name() != "webhook" || client.SpanKind() != trace.SpanKindClient || client.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("client span %q (%s) is not a child of the job span", client.Name(), client.SpanKind())
+	}
+	if want := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"; received != want {
+		t.Errorf("receiver got traceparent %q, want %q", received, want)
+	}
+	if client.Status().Description != "502 Bad Gateway" {
+		t.Errorf("status = %+v, want the failed response", client.Status())
+	}
+	found := false
+	for _, attr := range client.Attributes() {
+		if attr == attribute.Int("http.response.status_code", http.StatusBadGateway) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("attributes %v lack the response status", client.Attributes())
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got := parseHeaders("x-api-key=abc%3D%3D, x-team = finance,broken,=empty")
+	if len(got) != 2 || got["x-api-key"] != "abc==" || got["x-team"] != "finance" {
+		t.Errorf("parseHeaders = %v", got)
+	}
+}
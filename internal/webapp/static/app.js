@@ -0,0 +1,243 @@
+"use strict";
+
+// Tokens live in sessionStorage so closing the tab signs the user out.
+const storage = window.sessionStorage;
+const svgNS = "http://www.w3.org/2000/svg";
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+async function api(path, options = {}) {
+  const request = () => fetch("/api/v1" + path, {
+    ...options,
+    headers: {
+      "Accept": "application/json",
+      "Content-Type": "application/json",
+      ...(storage.getItem("token") ? { "Authorization": "Bearer " + storage.getItem("token") } : {}),
+    },
+  });
+
+  const authenticated = Boolean(storage.getItem("token"));
+  let response = await request();
+  if (authenticated && response.status === 401 && storage.getItem("refresh_token") && await refresh()) {
+    response = await request();
+  }
+  if (authenticated && response.status === 401) {
+    signOut();
+    throw new Error("Your session has expired, please sign in again.");
+  }
+
+  const body = response.status === 204 ? null : await response.json();
+  if (!response.ok) {
+    throw new Error((body && (body.error || body.detail || body.title)) || response.statusText);
+  }
+  return body;
+}
+
+async function refresh() {
+  const response = await fetch("/api/v1/auth/refresh", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ refresh_token: storage.getItem("refresh_token") }),
+  });
+  if (!response.ok) {
+    return false;
+  }
+  const session = await response.json();
+  storage.setItem("token", session.token);
+  if (session.refresh_token) {
+    storage.setItem("refresh_token", session.refresh_token);
+  }
+  return true;
+}
+
+function signOut() {
+  storage.clear();
+  $("dashboard").hidden = true;
+  $("user").hidden = true;
+  $("login").hidden = false;
+}
+
+function formatAmount(value, currency) {
+  const formatted = new Intl.NumberFormat(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 }).format(value);
+  return currency ? formatted + " " + currency : formatted;
+}
+
+function isoDate(date) {
+  return date.getFullYear() + "-" + String(date.getMonth() + 1).padStart(2, "0") + "-" + String(date.getDate()).padStart(2, "0");
+}
+
+function cell(row, text, className) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+function svg(tag, attributes, text) {
+  const element = document.createElementNS(svgNS, tag);
+  for (const [name, value] of Object.entries(attributes)) {
+    element.setAttribute(name, value);
+  }
+  if (text !== undefined) {
+    element.textContent = text;
+  }
+  return element;
+}
+
+// horizontalBars draws one labelled bar per item, scaled to the largest one.
+function horizontalBars(container, items, currency) {
+  container.replaceChildren();
+  if (items.length === 0) {
+    container.textContent = "No expenses in this period.";
+    return;
+  }
+
+  const rowHeight = 26;
+  const labelWidth = 160;
+  const width = 640;
+  const max = Math.max(...items.map((item) => item.amount));
+  const chart = svg("svg", { viewBox: `0 0 ${width} ${items.length * rowHeight}`, role: "img" });
+
+  items.forEach((item, i) => {
+    const y = i * rowHeight;
+    const barWidth = max > 0 ? (item.amount / max) * (width - labelWidth - 130) : 0;
+    chart.append(
+      svg("text", { x: 0, y: y + 17 }, item.label),
+      svg("rect", { x: labelWidth, y: y + 5, width: Math.max(barWidth, 1), height: rowHeight - 10, rx: 3, class: "bar-expense" }),
+      svg("text", { x: labelWidth + barWidth + 8, y: y + 17 }, formatAmount(item.amount, currency) + ` (${item.percentage.toFixed(1)}%)`),
+    );
+  });
+  container.append(chart);
+}
+
+// groupedColumns draws income and expense columns side by side per period.
+function groupedColumns(container, periods) {
+  container.replaceChildren();
+  if (periods.length === 0) {
+    container.textContent = "No history yet.";
+    return;
+  }
+
+  const width = 640;
+  const height = 220;
+  const bottom = 30;
+  const groupWidth = width / periods.length;
+  const columnWidth = Math.min(groupWidth / 3, 40);
+  const max = Math.max(1, ...periods.flatMap((p) => [p.total_income, p.total_expenses]));
+  const chart = svg("svg", { viewBox: `0 0 ${width} ${height}`, role: "img" });
+
+  periods.forEach((period, i) => {
+    const x = i * groupWidth + groupWidth / 2;
+    [["total_income", "bar-income", -columnWidth], ["total_expenses", "bar-expense", 0]].forEach(([key, className, offset]) => {
+      const columnHeight = (period[key] / max) * (height - bottom - 10);
+      const column = svg("rect", {
+        x: x + offset, y: height - bottom - columnHeight, width: columnWidth - 2, height: Math.max(columnHeight, 1), rx: 2, class: className,
+      });
+      column.append(svg("title", {}, formatAmount(period[key])));
+      chart.append(column);
+    });
+    chart.append(svg("text", { x: x, y: height - 10, "text-anchor": "middle" }, period.start_date.slice(5)));
+  });
+  container.append(chart);
+}
+
+async function loadDashboard() {
+  const now = new Date();
+  const monthStart = isoDate(new Date(now.getFullYear(), now.getMonth(), 1));
+  const today = isoDate(now);
+
+  const [profile, settings, summary, accounts, spending, periods, trends] = await Promise.all([
+    api("/profile"),
+    api("/settings"),
+    api(`/analytics/summary?start_date=${monthStart}&end_date=${today}`),
+    api("/accounts"),
+    api(`/analytics/spending?start_date=${monthStart}&end_date=${today}`),
+    api("/analytics/periods?count=6"),
+    api("/analytics/trends?period=month"),
+  ]);
+  const currency = settings.base_currency;
+
+  $("user-email").textContent = profile.email;
+  $("net-worth").textContent = formatAmount(summary.net_worth, currency);
+  $("income").textContent = formatAmount(summary.total_income, currency);
+  $("expenses").textContent = formatAmount(summary.total_expenses, currency);
+
+  const accountRows = $("accounts").tBodies[0];
+  accountRows.replaceChildren();
+  for (const account of accounts || []) {
+    const row = accountRows.insertRow();
+    cell(row, account.name);
+    cell(row, account.type.replace("_", " "));
+    cell(row, formatAmount(account.balance, account.currency), "num " + (account.balance < 0 ? "negative" : ""));
+  }
+
+  $("spending-period").textContent = `${monthStart} – ${today}`;
+  horizontalBars($("spending"), (spending || [])
+    .filter((item) => item.amount > 0)
+    .sort((a, b) => b.amount - a.amount)
+    .map((item) => ({ label: item.category_name, amount: item.amount, percentage: item.percentage })), currency);
+
+  groupedColumns($("periods"), [...(periods || [])].reverse());
+
+  const trendRows = $("trends").tBodies[0];
+  trendRows.replaceChildren();
+  for (const trend of (trends && trends.trends) || []) {
+    const row = trendRows.insertRow();
+    cell(row, trend.category_name);
+    cell(row, formatAmount(trend.current_spend, currency), "num");
+    cell(row, formatAmount(trend.predicted_spend, currency), "num");
+    cell(row, `${trend.change_percent > 0 ? "+" : ""}${trend.change_percent.toFixed(1)}%`, "num " + (trend.change_percent > 0 ? "negative" : "positive"));
+  }
+
+  $("login").hidden = true;
+  $("user").hidden = false;
+  $("dashboard").hidden = false;
+}
+
+async function show() {
+  $("error").textContent = "";
+  if (!storage.getItem("token")) {
+    signOut();
+    return;
+  }
+  try {
+    await loadDashboard();
+  } catch (err) {
+    $("error").textContent = err.message;
+  }
+}
+
+$("login").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = new FormData(event.target);
+  $("login-error").textContent = "";
+  try {
+    const session = await api("/auth/login", {
+      method: "POST",
+      body: JSON.stringify({ email: form.get("email"), password: form.get("password") }),
+    });
+    storage.setItem("token", session.token);
+    if (session.refresh_token) {
+      storage.setItem("refresh_token", session.refresh_token);
+    }
+    event.target.reset();
+    await show();
+  } catch (err) {
+    $("login-error").textContent = err.message;
+  }
+});
+
+$("logout").addEventListener("click", async () => {
+  try {
+    await api("/auth/logout", { method: "POST" });
+  } catch (err) {
+    // The tokens are dropped either way.
+  }
+  signOut();
+});
+
+show();
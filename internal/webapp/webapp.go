@@ -0,0 +1,20 @@
+// Package webapp embeds the single-page dashboard served at /app. It talks to
+// the same REST API as every other client and needs no build step.
+package webapp
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var static embed.FS
+
+// Files returns the dashboard assets with index.html at the root.
+func Files() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return files
+}
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS sync_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_tombstones_user_deleted ON sync_tombstones(user_id, deleted_at);
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_user_client ON accounts(user_id, client_id) WHERE client_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_client ON categories(user_id, client_id) WHERE client_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_user_client ON transactions(user_id, client_id) WHERE client_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user_updated ON accounts(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_categories_user_updated ON categories(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_updated ON transactions(user_id, updated_at);
+
+CREATE OR REPLACE FUNCTION record_sync_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO sync_tombstones (user_id, entity_type, entity_id, deleted_at)
+    VALUES (OLD.user_id, TG_ARGV[0], OLD.id, NOW());
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS accounts_sync_tombstone ON accounts;
+CREATE TRIGGER accounts_sync_tombstone AFTER DELETE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('account');
+
+DROP TRIGGER IF EXISTS categories_sync_tombstone ON categories;
+CREATE TRIGGER categories_sync_tombstone AFTER DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('category');
+
+DROP TRIGGER IF EXISTS transactions_sync_tombstone ON transactions;
+CREATE TRIGGER transactions_sync_tombstone AFTER DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_sync_tombstone('transaction');
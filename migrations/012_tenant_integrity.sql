@@ -0,0 +1,17 @@
+-- A transaction may only reference an account and category of the same user, and a
+-- category may only be nested under one of the same user's categories. NOT VALID
+-- enforces this for new writes without failing on legacy rows.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_id_user ON accounts(id, user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_id_user ON categories(id, user_id);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS fk_transactions_account_tenant;
+ALTER TABLE transactions ADD CONSTRAINT fk_transactions_account_tenant
+    FOREIGN KEY (account_id, user_id) REFERENCES accounts(id, user_id) NOT VALID;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS fk_transactions_category_tenant;
+ALTER TABLE transactions ADD CONSTRAINT fk_transactions_category_tenant
+    FOREIGN KEY (category_id, user_id) REFERENCES categories(id, user_id) NOT VALID;
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS fk_categories_parent_tenant;
+ALTER TABLE categories ADD CONSTRAINT fk_categories_parent_tenant
+    FOREIGN KEY (parent_id, user_id) REFERENCES categories(id, user_id) NOT VALID;
@@ -0,0 +1,12 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS progress JSONB;
+
+CREATE TABLE IF NOT EXISTS job_files (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_files_created ON job_files(created_at);
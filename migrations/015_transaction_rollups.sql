@@ -0,0 +1,40 @@
+-- Per-user, per-month, per-category totals kept in step with transactions by a
+-- trigger, so analytics can sum a handful of rows instead of scanning history.
+-- Populate existing data with: go run ./cmd/rollups
+CREATE TABLE IF NOT EXISTS transaction_monthly_rollups (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    category_id INTEGER NOT NULL DEFAULT 0,
+    type VARCHAR(10) NOT NULL,
+    total NUMERIC(15,2) NOT NULL DEFAULT 0,
+    transaction_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, month, category_id, type)
+);
+
+CREATE OR REPLACE FUNCTION apply_transaction_rollup() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE transaction_monthly_rollups
+        SET total = total - OLD.amount, transaction_count = transaction_count - 1
+        WHERE user_id = OLD.user_id
+          AND month = date_trunc('month', OLD.date)::date
+          AND category_id = COALESCE(OLD.category_id, 0)
+          AND type = OLD.type;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO transaction_monthly_rollups (user_id, month, category_id, type, total, transaction_count)
+        VALUES (NEW.user_id, date_trunc('month', NEW.date)::date, COALESCE(NEW.category_id, 0), NEW.type, NEW.amount, 1)
+        ON CONFLICT (user_id, month, category_id, type)
+        DO UPDATE SET total = transaction_monthly_rollups.total + EXCLUDED.total,
+                      transaction_count = transaction_monthly_rollups.transaction_count + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS transactions_rollup ON transactions;
+CREATE TRIGGER transactions_rollup
+    AFTER INSERT OR UPDATE OF user_id, category_id, amount, type, date OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION apply_transaction_rollup();
@@ -0,0 +1,5 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS closing_balance NUMERIC(15,2);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_user_active ON accounts(user_id) WHERE archived_at IS NULL;
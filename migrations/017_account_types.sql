@@ -0,0 +1,19 @@
+-- Account types become a closed set. Legacy free-form values are mapped onto the
+-- closest type so the constraint can be validated for every existing row.
+UPDATE accounts SET type = CASE
+        WHEN LOWER(type) IN ('credit', 'credit card', 'card') THEN 'credit_card'
+        WHEN LOWER(type) IN ('checking', 'savings', 'credit_card', 'cash', 'investment', 'loan') THEN LOWER(type)
+        ELSE 'checking'
+    END
+WHERE type NOT IN ('checking', 'savings', 'credit_card', 'cash', 'investment', 'loan');
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS credit_limit NUMERIC(15,2);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS interest_rate NUMERIC(7,4);
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_accounts_type;
+ALTER TABLE accounts ADD CONSTRAINT chk_accounts_type
+    CHECK (type IN ('checking', 'savings', 'credit_card', 'cash', 'investment', 'loan'));
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_accounts_credit_limit;
+ALTER TABLE accounts ADD CONSTRAINT chk_accounts_credit_limit
+    CHECK (credit_limit IS NULL OR (type = 'credit_card' AND credit_limit >= 0));
@@ -0,0 +1,11 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS statement_closing_day SMALLINT;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS payment_due_day SMALLINT;
+-- Due date of the last statement a minimum-payment reminder was sent for.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS payment_reminder_due_date DATE;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_accounts_statement_cycle;
+ALTER TABLE accounts ADD CONSTRAINT chk_accounts_statement_cycle
+    CHECK ((statement_closing_day IS NULL AND payment_due_day IS NULL) OR
+           (type = 'credit_card' AND statement_closing_day BETWEEN 1 AND 28 AND payment_due_day BETWEEN 1 AND 28));
+
+CREATE INDEX IF NOT EXISTS idx_accounts_statement_cycle ON accounts(payment_due_day) WHERE statement_closing_day IS NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    base_currency CHAR(3) NOT NULL DEFAULT 'USD',
+    locale VARCHAR(35) NOT NULL DEFAULT 'en-US',
+    first_day_of_week SMALLINT NOT NULL DEFAULT 1 CHECK (first_day_of_week BETWEEN 0 AND 6),
+    fiscal_month_start_day SMALLINT NOT NULL DEFAULT 1 CHECK (fiscal_month_start_day BETWEEN 1 AND 28),
+    date_format VARCHAR(20) NOT NULL DEFAULT 'YYYY-MM-DD',
+    notification_preferences JSONB NOT NULL DEFAULT '{}',
+    default_account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
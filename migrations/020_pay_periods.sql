@@ -0,0 +1,7 @@
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pay_period VARCHAR(20) NOT NULL DEFAULT 'monthly';
+-- Any payday; weekly and biweekly periods repeat from it.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS pay_period_anchor DATE;
+
+ALTER TABLE user_settings DROP CONSTRAINT IF EXISTS chk_user_settings_pay_period;
+ALTER TABLE user_settings ADD CONSTRAINT chk_user_settings_pay_period
+    CHECK (pay_period IN ('monthly', 'weekly', 'biweekly') AND (pay_period <> 'biweekly' OR pay_period_anchor IS NOT NULL));
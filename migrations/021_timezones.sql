@@ -0,0 +1,41 @@
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Transaction dates become absolute instants. Existing values were written by
+-- servers running in UTC and are read as such.
+DROP TRIGGER IF EXISTS transactions_rollup ON transactions;
+
+ALTER TABLE transactions ALTER COLUMN date TYPE TIMESTAMPTZ USING date::timestamp AT TIME ZONE 'UTC';
+
+-- Rollup months are calendar months in the owner's timezone. Changing the
+-- timezone rebuilds that user's rollups.
+CREATE OR REPLACE FUNCTION user_month(p_user_id INTEGER, p_date TIMESTAMPTZ) RETURNS DATE AS $$
+    SELECT date_trunc('month', p_date AT TIME ZONE COALESCE(
+        (SELECT timezone FROM user_settings WHERE user_id = p_user_id), 'UTC'))::date;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION apply_transaction_rollup() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE transaction_monthly_rollups
+        SET total = total - OLD.amount, transaction_count = transaction_count - 1
+        WHERE user_id = OLD.user_id
+          AND month = user_month(OLD.user_id, OLD.date)
+          AND category_id = COALESCE(OLD.category_id, 0)
+          AND type = OLD.type;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO transaction_monthly_rollups (user_id, month, category_id, type, total, transaction_count)
+        VALUES (NEW.user_id, user_month(NEW.user_id, NEW.date), COALESCE(NEW.category_id, 0), NEW.type, NEW.amount, 1)
+        ON CONFLICT (user_id, month, category_id, type)
+        DO UPDATE SET total = transaction_monthly_rollups.total + EXCLUDED.total,
+                      transaction_count = transaction_monthly_rollups.transaction_count + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_rollup
+    AFTER INSERT OR UPDATE OF user_id, category_id, amount, type, date OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION apply_transaction_rollup();
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    key VARCHAR(255) PRIMARY KEY,
+    content_type VARCHAR(100) NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number VARCHAR(16);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_updated_at TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Sandbox users created by POST /api/v1/auth/demo are deleted after this time.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS demo_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_demo_expires ON users(demo_expires_at) WHERE demo_expires_at IS NOT NULL;
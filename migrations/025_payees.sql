@@ -0,0 +1,13 @@
+-- Transactions belong to a payee when their description contains match_text,
+-- so imported and manually entered transactions are matched alike.
+CREATE TABLE IF NOT EXISTS payees (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    match_text VARCHAR(100) NOT NULL,
+    monthly_limit NUMERIC(15, 2) CHECK (monthly_limit > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payees_user_name ON payees(user_id, LOWER(name));
@@ -0,0 +1,36 @@
+-- A household is keyed by its owner. Editors record transactions in the
+-- owner's books; those above approval_threshold wait for the owner.
+CREATE TABLE IF NOT EXISTS households (
+    owner_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    approval_threshold NUMERIC(15, 2) CHECK (approval_threshold > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES households(owner_id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'editor' CHECK (role IN ('editor')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (owner_id <> member_id)
+);
+
+CREATE TABLE IF NOT EXISTS transaction_approvals (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    date TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_approvals_owner_status ON transaction_approvals(owner_id, status);
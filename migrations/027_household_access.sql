@@ -0,0 +1,34 @@
+-- Viewers see the accounts granted to them; limited members may also record
+-- transactions in them. Editors keep access to every account of the owner.
+ALTER TABLE household_members DROP CONSTRAINT IF EXISTS household_members_role_check;
+ALTER TABLE household_members DROP CONSTRAINT IF EXISTS chk_household_members_role;
+ALTER TABLE household_members ADD CONSTRAINT chk_household_members_role
+    CHECK (role IN ('editor', 'viewer', 'limited'));
+
+-- Managed members are profiles the owner created, e.g. for a child, and are
+-- deleted together with their membership.
+ALTER TABLE household_members ADD COLUMN IF NOT EXISTS managed BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS household_member_accounts (
+    owner_id INTEGER NOT NULL REFERENCES households(owner_id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL REFERENCES household_members(member_id) ON DELETE CASCADE,
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    PRIMARY KEY (member_id, account_id)
+);
+
+CREATE TABLE IF NOT EXISTS allowance_rules (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES households(owner_id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL REFERENCES household_members(member_id) ON DELETE CASCADE,
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('weekly', 'monthly')),
+    next_date DATE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_allowance_rules_next_date ON allowance_rules(next_date);
@@ -0,0 +1,7 @@
+-- Categories carry the default tax treatment of their expenses; a NULL on a
+-- transaction inherits it from the category.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_deductible BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_code VARCHAR(50);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_deductible BOOLEAN;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_code VARCHAR(50);
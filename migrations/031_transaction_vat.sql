@@ -0,0 +1,14 @@
+-- VAT breakdown of a transaction; the gross amounts of its lines add up to
+-- the transaction amount.
+CREATE TABLE IF NOT EXISTS transaction_vat_lines (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    net_amount DECIMAL(15,2) NOT NULL CHECK (net_amount >= 0),
+    vat_rate DECIMAL(5,2) NOT NULL CHECK (vat_rate BETWEEN 0 AND 100),
+    vat_amount DECIMAL(15,2) NOT NULL CHECK (vat_amount >= 0),
+    gross_amount DECIMAL(15,2) NOT NULL,
+    CHECK (gross_amount = net_amount + vat_amount)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_vat_lines_transaction ON transaction_vat_lines(transaction_id);
@@ -0,0 +1,43 @@
+-- Accounts and transactions are either personal or business, so one user can
+-- keep freelance books apart. Transactions default to their account's scope.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS scope VARCHAR(20) NOT NULL DEFAULT 'personal'
+    CHECK (scope IN ('personal', 'business'));
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS scope VARCHAR(20) NOT NULL DEFAULT 'personal'
+    CHECK (scope IN ('personal', 'business'));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_scope ON transactions(user_id, scope, date);
+
+-- Rollups are kept per scope so scoped analytics can still read them.
+DROP TRIGGER IF EXISTS transactions_rollup ON transactions;
+
+ALTER TABLE transaction_monthly_rollups ADD COLUMN IF NOT EXISTS scope VARCHAR(20) NOT NULL DEFAULT 'personal';
+ALTER TABLE transaction_monthly_rollups DROP CONSTRAINT IF EXISTS transaction_monthly_rollups_pkey;
+ALTER TABLE transaction_monthly_rollups ADD PRIMARY KEY (user_id, month, category_id, type, scope);
+
+CREATE OR REPLACE FUNCTION apply_transaction_rollup() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE transaction_monthly_rollups
+        SET total = total - OLD.amount, transaction_count = transaction_count - 1
+        WHERE user_id = OLD.user_id
+          AND month = user_month(OLD.user_id, OLD.date)
+          AND category_id = COALESCE(OLD.category_id, 0)
+          AND type = OLD.type
+          AND scope = OLD.scope;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO transaction_monthly_rollups (user_id, month, category_id, type, scope, total, transaction_count)
+        VALUES (NEW.user_id, user_month(NEW.user_id, NEW.date), COALESCE(NEW.category_id, 0), NEW.type, NEW.scope, NEW.amount, 1)
+        ON CONFLICT (user_id, month, category_id, type, scope)
+        DO UPDATE SET total = transaction_monthly_rollups.total + EXCLUDED.total,
+                      transaction_count = transaction_monthly_rollups.transaction_count + 1;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_rollup
+    AFTER INSERT OR UPDATE OF user_id, category_id, amount, type, date, scope OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION apply_transaction_rollup();
@@ -0,0 +1,22 @@
+-- Money the user expects back: either an expense they paid on someone else's
+-- behalf or a mileage claim, which has no transaction of its own.
+CREATE TABLE IF NOT EXISTS reimbursements (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_id INTEGER UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    distance_km DECIMAL(10,2) CHECK (distance_km > 0),
+    rate_per_km DECIMAL(10,4) CHECK (rate_per_km > 0),
+    trip_date DATE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    payer VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'submitted', 'reimbursed')),
+    submitted_at TIMESTAMP,
+    reimbursed_at TIMESTAMP,
+    reimbursement_transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((transaction_id IS NULL) = (distance_km IS NOT NULL AND rate_per_km IS NOT NULL AND trip_date IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_reimbursements_user_status ON reimbursements(user_id, status);
@@ -0,0 +1,37 @@
+-- People the user shares expenses with. They do not need an account; a split
+-- records the share of a transaction a contact owes the user.
+CREATE TABLE IF NOT EXISTS contacts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contacts_user_name ON contacts(user_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS transaction_splits (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    contact_id INTEGER NOT NULL REFERENCES contacts(id),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    UNIQUE (transaction_id, contact_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_contact ON transaction_splits(contact_id);
+
+-- Money that changed hands to square up: received from the contact or paid
+-- to them.
+CREATE TABLE IF NOT EXISTS split_settlements (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    contact_id INTEGER NOT NULL REFERENCES contacts(id),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('received', 'paid')),
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    date DATE NOT NULL,
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_settlements_contact ON split_settlements(contact_id, date);
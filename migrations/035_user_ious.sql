@@ -0,0 +1,38 @@
+-- Registered users who keep an IOU ledger with each other. The requester
+-- invites by email and the link can be used once the other user accepts.
+CREATE TABLE IF NOT EXISTS user_links (
+    id SERIAL PRIMARY KEY,
+    requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    addressee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP,
+    CHECK (requester_id <> addressee_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_links_pair ON user_links (LEAST(requester_id, addressee_id), GREATEST(requester_id, addressee_id));
+CREATE INDEX IF NOT EXISTS idx_user_links_addressee ON user_links(addressee_id);
+
+-- Money one linked user owes another. Both sides see the same row; the
+-- user who did not record it confirms or disputes it, and settling books
+-- an expense for the debtor and an income for the creditor.
+CREATE TABLE IF NOT EXISTS ious (
+    id SERIAL PRIMARY KEY,
+    creditor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    debtor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    date DATE NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'disputed', 'settled')),
+    dispute_reason VARCHAR(255) NOT NULL DEFAULT '',
+    creditor_transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    debtor_transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    settled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (creditor_id <> debtor_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ious_creditor ON ious(creditor_id, status);
+CREATE INDEX IF NOT EXISTS idx_ious_debtor ON ious(debtor_id, status);
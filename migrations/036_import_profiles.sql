@@ -0,0 +1,19 @@
+-- Saved mappings of a bank's CSV export. columns maps the fields an import
+-- reads (date, description, memo, amount, debit, credit, type, category) to
+-- the headers the bank uses.
+CREATE TABLE IF NOT EXISTS import_profiles (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    columns JSONB NOT NULL,
+    delimiter VARCHAR(1) NOT NULL DEFAULT ',',
+    date_format VARCHAR(20) NOT NULL DEFAULT '',
+    decimal_separator VARCHAR(1) NOT NULL DEFAULT '.',
+    sign_convention VARCHAR(20) NOT NULL DEFAULT 'signed'
+        CHECK (sign_convention IN ('signed', 'inverted', 'debit_credit')),
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_import_profiles_user_name ON import_profiles(user_id, LOWER(name));
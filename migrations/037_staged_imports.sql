@@ -0,0 +1,33 @@
+-- Imports are parsed into a staging area first. Rows only become
+-- transactions when the user commits the import, after reviewing
+-- duplicates and categories.
+CREATE TABLE IF NOT EXISTS staged_imports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    job_id INTEGER UNIQUE REFERENCES jobs(id) ON DELETE SET NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL DEFAULT 'staged' CHECK (status IN ('staged', 'committed')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    committed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_staged_imports_user ON staged_imports(user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS staged_import_rows (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES staged_imports(id) ON DELETE CASCADE,
+    row_number INTEGER NOT NULL,
+    date DATE,
+    description TEXT NOT NULL DEFAULT '',
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    type VARCHAR(20) NOT NULL DEFAULT '',
+    category_name TEXT NOT NULL DEFAULT '',
+    suggested_category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    duplicate_of INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    error TEXT NOT NULL DEFAULT '',
+    include BOOLEAN NOT NULL DEFAULT TRUE,
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_staged_import_rows_import ON staged_import_rows(import_id, row_number);
@@ -0,0 +1,3 @@
+-- Handlers an event was already delivered to, so a retry caused by one
+-- failing handler (an unreachable message broker) does not repeat the others.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS delivered TEXT[] NOT NULL DEFAULT '{}';
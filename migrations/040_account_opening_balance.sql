@@ -0,0 +1,13 @@
+-- An account's balance is its opening balance plus its transactions, so the
+-- stored balance can be checked against the ledger and rebuilt from it.
+-- Existing accounts take whatever their transactions do not explain as their
+-- opening balance.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS opening_balance NUMERIC(15,2);
+
+UPDATE accounts a SET opening_balance = a.balance - COALESCE((
+    SELECT SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END)
+    FROM transactions t WHERE t.account_id = a.id), 0)
+WHERE opening_balance IS NULL;
+
+ALTER TABLE accounts ALTER COLUMN opening_balance SET DEFAULT 0;
+ALTER TABLE accounts ALTER COLUMN opening_balance SET NOT NULL;
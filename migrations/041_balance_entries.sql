@@ -0,0 +1,14 @@
+-- Opening balance and adjustment entries live in transactions with a signed
+-- amount and no category. An account has at most one opening entry, which
+-- replaces the starting balance it was created with.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('income', 'expense', 'opening', 'adjustment')) NOT VALID;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_amount_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_amount_check
+    CHECK (amount > 0 OR type IN ('opening', 'adjustment')) NOT VALID;
+
+ALTER TABLE transactions ALTER COLUMN category_id DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_opening ON transactions(account_id) WHERE type = 'opening';
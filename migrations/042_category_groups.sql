@@ -0,0 +1,16 @@
+-- Category groups ("Essentials", "Lifestyle") only roll categories up for
+-- reports and group budgets; they are independent of the parent/child tree.
+CREATE TABLE IF NOT EXISTS category_groups (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    monthly_budget NUMERIC(15, 2) CHECK (monthly_budget > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_groups_user_name ON category_groups(user_id, LOWER(name));
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS group_id INTEGER REFERENCES category_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_categories_group ON categories(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,13 @@
+-- The widgets of a user's dashboard in display order. params holds the
+-- widget's own settings (scope, period, limit, count).
+CREATE TABLE IF NOT EXISTS dashboard_widgets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dashboard_widgets_user_position ON dashboard_widgets(user_id, position);
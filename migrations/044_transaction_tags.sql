@@ -0,0 +1,5 @@
+-- Free-form labels on transactions, used by the report builder to group by
+-- tag. A transaction with several tags is counted under each of them.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_tags ON transactions USING GIN (tags);
@@ -0,0 +1,24 @@
+-- Reports delivered on a cron schedule. filter holds the report's account,
+-- category, type and scope; webhook_url is encrypted like the notification
+-- channels' URLs.
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    group_by VARCHAR(20) NOT NULL,
+    pivot VARCHAR(20) NOT NULL DEFAULT '',
+    metrics TEXT[] NOT NULL DEFAULT '{}',
+    filter JSONB NOT NULL DEFAULT '{}',
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'pdf')),
+    delivery VARCHAR(10) NOT NULL CHECK (delivery IN ('email', 'webhook')),
+    webhook_url TEXT NOT NULL,
+    cron VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_user ON report_schedules(user_id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_due ON report_schedules(next_run_at) WHERE enabled;
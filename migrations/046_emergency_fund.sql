@@ -0,0 +1,14 @@
+-- Accounts set aside as the emergency fund and the categories counted as
+-- essential spending when working out how many months the fund covers.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS emergency_fund BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS essential BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- alerted_at is set while the user has been told the fund is below the
+-- threshold, so the alert is sent once per drop.
+CREATE TABLE IF NOT EXISTS emergency_funds (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    threshold_months NUMERIC(5, 2) NOT NULL CHECK (threshold_months > 0),
+    alerted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,18 @@
+-- Findings of the insight analyzers. Each run updates the insights an
+-- analyzer still reports, keyed by (user_id, type, key), and removes the
+-- rest. Dismissed insights stay hidden and snoozed ones until snoozed_until.
+CREATE TABLE IF NOT EXISTS insights (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    key TEXT NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    dismissed_at TIMESTAMP,
+    snoozed_until TIMESTAMPTZ,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, type, key)
+);
@@ -0,0 +1,18 @@
+-- If-this-then-that rules run on transaction events. conditions and actions
+-- hold the rule's JSON definition; webhook_url is encrypted like the other
+-- webhook URLs and used by webhook actions.
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(30) NOT NULL CHECK (trigger IN ('transaction_created', 'budget_exceeded', 'balance_below')),
+    conditions JSONB NOT NULL DEFAULT '{}',
+    actions JSONB NOT NULL DEFAULT '[]',
+    webhook_url TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_automation_rules_user ON automation_rules(user_id, trigger) WHERE enabled;
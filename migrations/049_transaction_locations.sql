@@ -0,0 +1,14 @@
+-- Where a transaction was made, as sent by mobile clients. The coordinates
+-- are set together or not at all.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS place VARCHAR(255);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_location_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_location_check CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_location ON transactions(user_id, latitude, longitude)
+    WHERE latitude IS NOT NULL;
@@ -0,0 +1,19 @@
+-- The wishlist: things the user plans to buy. An item is purchased once
+-- linked to the expense that paid for it; deleting that transaction puts
+-- the item back on the list.
+CREATE TABLE IF NOT EXISTS planned_purchases (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    notes VARCHAR(500) NOT NULL DEFAULT '',
+    url VARCHAR(500) NOT NULL DEFAULT '',
+    target_price DECIMAL(15,2) NOT NULL CHECK (target_price > 0),
+    priority VARCHAR(10) NOT NULL DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high')),
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    target_date DATE,
+    transaction_id INTEGER UNIQUE REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_planned_purchases_user ON planned_purchases(user_id);
@@ -0,0 +1,10 @@
+-- How much a purchase bought, e.g. 40 l of fuel or 320 kWh of electricity,
+-- so the price per unit can be followed over time. Quantity and unit are
+-- set together or not at all.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS quantity DECIMAL(15,4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS unit VARCHAR(20);
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_quantity_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_quantity_check CHECK (
+    (quantity IS NULL AND unit IS NULL) OR (quantity > 0 AND unit <> '')
+);
@@ -0,0 +1,25 @@
+-- An advisor grant lets another user, e.g. a financial advisor, read the
+-- owner's data through short-lived delegated tokens: the granted accounts
+-- and, with analytics set, the owner's analytics. Revoking a grant stops
+-- its tokens at once.
+CREATE TABLE IF NOT EXISTS advisor_grants (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    advisor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    analytics BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (owner_id <> advisor_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_advisor_grants_active ON advisor_grants(owner_id, advisor_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_advisor_grants_advisor ON advisor_grants(advisor_id);
+
+CREATE TABLE IF NOT EXISTS advisor_grant_accounts (
+    grant_id INTEGER NOT NULL REFERENCES advisor_grants(id) ON DELETE CASCADE,
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    PRIMARY KEY (grant_id, account_id)
+);
@@ -0,0 +1,26 @@
+-- Plans cap what a user of a hosted instance may create. A NULL limit is
+-- unlimited. Users without a plan get DEFAULT_PLAN, or no limits when it is
+-- empty; user_limits overrides single limits of a user's plan.
+CREATE TABLE IF NOT EXISTS plans (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    max_accounts INTEGER CHECK (max_accounts >= 0),
+    max_transactions_per_month INTEGER CHECK (max_transactions_per_month >= 0),
+    max_storage_bytes BIGINT CHECK (max_storage_bytes >= 0),
+    max_webhooks INTEGER CHECK (max_webhooks >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS plan_id VARCHAR(50) REFERENCES plans(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS user_limits (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_accounts INTEGER CHECK (max_accounts >= 0),
+    max_transactions_per_month INTEGER CHECK (max_transactions_per_month >= 0),
+    max_storage_bytes BIGINT CHECK (max_storage_bytes >= 0),
+    max_webhooks INTEGER CHECK (max_webhooks >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_created ON transactions(user_id, created_at);
@@ -0,0 +1,30 @@
+-- Stripe billing: a plan is bought through its Stripe price, and each paying
+-- user has a Stripe customer whose subscription decides their plan.
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS stripe_price_id VARCHAR(100) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS billing_customers (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    stripe_customer_id VARCHAR(100) NOT NULL UNIQUE,
+    subscription_id VARCHAR(100),
+    status VARCHAR(30) NOT NULL DEFAULT '',
+    plan_id VARCHAR(50) REFERENCES plans(id) ON DELETE SET NULL,
+    current_period_end TIMESTAMP,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Set when a payment fails; the plan ends once it passes unless a retry
+    -- succeeds first.
+    grace_until TIMESTAMP,
+    -- Creation time of the last applied event, so that events Stripe
+    -- delivers out of order never roll the subscription back.
+    event_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_customers_grace ON billing_customers(grace_until) WHERE grace_until IS NOT NULL;
+
+-- Webhook events already handled; Stripe delivers events at least once.
+CREATE TABLE IF NOT EXISTS billing_events (
+    id VARCHAR(100) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- W3C traceparent of the request or job that enqueued a job, so the work it
+-- does shows up in the same trace.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS trace_parent VARCHAR(55);
@@ -0,0 +1,2 @@
+-- Login attempts older than the IP throttling window are pruned by created_at.
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created ON login_attempts(created_at);
@@ -0,0 +1,36 @@
+-- Validates the tenant foreign keys added NOT VALID in 012, so they cover
+-- legacy rows as well. Rows crossing users are repaired first, and since
+-- the constraints already check every updated row, each transaction is
+-- fixed in one statement.
+--
+-- A transaction belongs to the owner of its account: it is already part of
+-- that account's balance. Its category must be one of the owner's, so a
+-- category of another user is swapped for the owner's of the same name and
+-- type, created where the owner has none. A moved transaction's client_id
+-- belonged to the previous user's device and is dropped.
+INSERT INTO categories (user_id, name, type, color, icon, created_at, updated_at)
+SELECT DISTINCT ON (a.user_id, c.name, c.type) a.user_id, c.name, c.type, c.color, c.icon, NOW(), NOW()
+FROM transactions t
+JOIN accounts a ON a.id = t.account_id
+JOIN categories c ON c.id = t.category_id
+WHERE c.user_id <> a.user_id
+  AND NOT EXISTS (SELECT 1 FROM categories o WHERE o.user_id = a.user_id AND o.name = c.name AND o.type = c.type);
+
+UPDATE transactions t SET
+    user_id = a.user_id,
+    client_id = CASE WHEN t.user_id = a.user_id THEN t.client_id END,
+    category_id = CASE WHEN c.user_id = a.user_id THEN c.id ELSE (
+        SELECT MIN(o.id) FROM categories o WHERE o.user_id = a.user_id AND o.name = c.name AND o.type = c.type) END,
+    updated_at = NOW()
+FROM accounts a, categories c
+WHERE a.id = t.account_id AND c.id = t.category_id
+  AND (t.user_id <> a.user_id OR c.user_id <> a.user_id);
+
+-- A category nested under another user's becomes top level.
+UPDATE categories c SET parent_id = NULL, updated_at = NOW()
+FROM categories p
+WHERE p.id = c.parent_id AND p.user_id <> c.user_id;
+
+ALTER TABLE transactions VALIDATE CONSTRAINT fk_transactions_account_tenant;
+ALTER TABLE transactions VALIDATE CONSTRAINT fk_transactions_category_tenant;
+ALTER TABLE categories VALIDATE CONSTRAINT fk_categories_parent_tenant;
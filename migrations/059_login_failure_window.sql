@@ -0,0 +1,5 @@
+-- Failed sign-ins are counted from login_attempts within a sliding window
+-- instead of in a running total. failures_since is set when a success,
+-- lockout or unlock starts the count over; NULL counts the whole window.
+ALTER TABLE account_lockouts ADD COLUMN IF NOT EXISTS failures_since TIMESTAMP;
+ALTER TABLE account_lockouts DROP COLUMN IF EXISTS failed_attempts;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so the API binary can apply
+// them itself. New migrations are files named NNN_description.sql; they run
+// in name order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
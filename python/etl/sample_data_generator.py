@@ -25,7 +25,7 @@ class SampleDataGenerator:
             {"email": "mike.johnson@example.com", "first_name": "Mike", "last_name": "Johnson", "password": "password123"},
         ]
         
-        self.account_types = ["checking", "savings", "credit", "investment"]
+        self.account_types = ["checking", "savings", "credit_card", "investment"]
         
         self.expense_categories = [
             {"name": "Groceries", "color": "#ff6b6b"},
@@ -143,7 +143,7 @@ class SampleDataGenerator:
         for user_id in user_ids:
             for i, account_type in enumerate(self.account_types):
                 try:
-                    balance = random.uniform(100, 50000) if account_type != "credit" else random.uniform(-5000, 0)
+                    balance = random.uniform(100, 50000) if account_type != "credit_card" else random.uniform(-5000, 0)
                     
                     cursor.execute("""
                         INSERT INTO accounts (user_id, name, type, balance, currency, description, created_at, updated_at)